@@ -18,7 +18,9 @@ import (
 	"context"
 	"fmt"
 	"iter"
+	"slices"
 
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/artifact"
@@ -63,7 +65,10 @@ func New(cfg Config) (Agent, error) {
 		run:                  cfg.Run,
 		afterAgentCallbacks:  cfg.AfterAgentCallbacks,
 		State: agentinternal.State{
-			AgentType: agentinternal.TypeCustomAgent,
+			AgentType:         agentinternal.TypeCustomAgent,
+			Persist:           persistFunc(cfg.PersistedEvents),
+			TracerServiceName: cfg.TracerServiceName,
+			SpanAttributes:    slices.Clone(cfg.SpanAttributes),
 		},
 	}, nil
 }
@@ -99,6 +104,26 @@ type Config struct {
 	// created from the content or error of that callback and the remaining
 	// callbacks will be skipped.
 	AfterAgentCallbacks []AfterAgentCallback
+
+	// PersistedEvents are the kinds of events of the agent stored in the
+	// session service, see session.KindOf, e.g. session.EventKindModelText
+	// to store only the text responses and not the tool calls. Events that
+	// are not stored are still yielded by the runner, traced and visible to
+	// the agents of the invocation. Skipped events changing the state or
+	// artifacts, transferring or escalating are stored without their
+	// content, so that later runs see the changes. Skipped tool call events
+	// are stored without their content too, keeping only the function calls
+	// in their metadata, so that interrupted runs can be resumed. User
+	// messages are always stored. Defaults to all events.
+	PersistedEvents []session.EventKind
+	// TracerServiceName names the tracers of the spans of the agent, so that
+	// the spans of agents of different logical services sharing a tracer
+	// provider can be told apart. Defaults to RunConfig.TracerServiceName.
+	TracerServiceName string
+	// SpanAttributes are custom attributes, e.g. a tenant ID, recorded on
+	// the call_llm and execute_tool spans of the agent. Attributes beyond
+	// the first 32 custom attributes of a span are dropped.
+	SpanAttributes []attribute.KeyValue
 }
 
 // Artifacts interface provides methods to work with artifacts of the current
@@ -169,6 +194,8 @@ func (a *agent) Run(ctx InvocationContext) iter.Seq2[*session.Event, error] {
 			userContent:   ctx.UserContent(),
 			runConfig:     ctx.RunConfig(),
 			endInvocation: ctx.Ended(),
+			historyPolicy: HistoryPolicyOf(ctx),
+			transferDepth: TransferDepthOf(ctx),
 		}
 
 		event, err := runBeforeAgentCallbacks(ctx)
@@ -234,7 +261,7 @@ func runBeforeAgentCallbacks(ctx InvocationContext) (*session.Event, error) {
 			continue
 		}
 
-		event := session.NewEvent(ctx.InvocationID())
+		event := session.NewEventContext(ctx, ctx.InvocationID())
 		event.LLMResponse = model.LLMResponse{
 			Content: content,
 		}
@@ -247,7 +274,7 @@ func runBeforeAgentCallbacks(ctx InvocationContext) (*session.Event, error) {
 
 	// check if has delta create event with it
 	if len(callbackCtx.actions.StateDelta) > 0 {
-		event := session.NewEvent(ctx.InvocationID())
+		event := session.NewEventContext(ctx, ctx.InvocationID())
 		event.Author = agent.Name()
 		event.Branch = ctx.Branch()
 		event.Actions = *callbackCtx.actions
@@ -277,7 +304,7 @@ func runAfterAgentCallbacks(ctx InvocationContext) (*session.Event, error) {
 			continue
 		}
 
-		event := session.NewEvent(ctx.InvocationID())
+		event := session.NewEventContext(ctx, ctx.InvocationID())
 		event.LLMResponse = model.LLMResponse{
 			Content: newContent,
 		}
@@ -291,7 +318,7 @@ func runAfterAgentCallbacks(ctx InvocationContext) (*session.Event, error) {
 
 	// check if has delta create event with it
 	if len(callbackCtx.actions.StateDelta) > 0 {
-		event := session.NewEvent(ctx.InvocationID())
+		event := session.NewEventContext(ctx, ctx.InvocationID())
 		event.Author = agent.Name()
 		event.Branch = ctx.Branch()
 		event.Actions = *callbackCtx.actions
@@ -391,6 +418,8 @@ type invocationContext struct {
 	userContent   *genai.Content
 	runConfig     *RunConfig
 	endInvocation bool
+	historyPolicy HistoryPolicy
+	transferDepth int
 }
 
 func (c *invocationContext) Agent() Agent {
@@ -432,3 +461,24 @@ func (c *invocationContext) EndInvocation() {
 func (c *invocationContext) Ended() bool {
 	return c.endInvocation
 }
+
+var (
+	_ HistoryContext       = (*invocationContext)(nil)
+	_ TransferDepthContext = (*invocationContext)(nil)
+)
+
+func (c *invocationContext) History() []*session.Event {
+	return c.historyPolicy.History(c.session)
+}
+
+func (c *invocationContext) HistoryPolicy() HistoryPolicy {
+	return c.historyPolicy
+}
+
+func (c *invocationContext) SetHistoryPolicy(p HistoryPolicy) {
+	c.historyPolicy = p
+}
+
+func (c *invocationContext) TransferDepth() int {
+	return c.transferDepth
+}
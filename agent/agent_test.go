@@ -113,7 +113,8 @@ func TestAgentCallbacks(t *testing.T) {
 			}
 
 			ctx := &invocationContext{
-				agent: testAgent,
+				Context: t.Context(),
+				agent:   testAgent,
 			}
 			var gotEvents []*session.Event
 			for event, err := range testAgent.Run(ctx) {
@@ -159,6 +160,7 @@ func TestEndInvocation_EndsBeforeMainCall(t *testing.T) {
 	}
 
 	ctx := &invocationContext{
+		Context:       t.Context(),
 		agent:         testAgent,
 		endInvocation: true,
 	}
@@ -192,7 +194,8 @@ func TestEndInvocation_EndsAfterMainCall(t *testing.T) {
 	}
 
 	ctx := &invocationContext{
-		agent: testAgent,
+		Context: t.Context(),
+		agent:   testAgent,
 	}
 	var gotEvents []*session.Event
 	for event, err := range testAgent.Run(ctx) {
@@ -242,3 +245,30 @@ func (a *customAgent) Run(ctx InvocationContext) iter.Seq2[*session.Event, error
 		}, nil)
 	}
 }
+
+func TestHistoryPolicyApply(t *testing.T) {
+	text := func(s string) *session.Event {
+		return &session.Event{LLMResponse: model.LLMResponse{Content: genai.NewContentFromText(s, genai.RoleUser)}}
+	}
+	call := &session.Event{LLMResponse: model.LLMResponse{Content: genai.NewContentFromFunctionCall("f", nil, genai.RoleModel)}}
+	resp := &session.Event{LLMResponse: model.LLMResponse{Content: genai.NewContentFromFunctionResponse("f", nil, genai.RoleUser)}}
+	events := []*session.Event{text("q1"), call, resp, text("a1"), text("q2")}
+
+	for _, tc := range []struct {
+		name   string
+		policy HistoryPolicy
+		want   []*session.Event
+	}{
+		{name: "no limit", want: events},
+		{name: "limit above length", policy: HistoryPolicy{MaxEvents: 10}, want: events},
+		{name: "last events", policy: HistoryPolicy{MaxEvents: 2}, want: events[3:]},
+		{name: "keeps function call and response", policy: HistoryPolicy{MaxEvents: 4}, want: events[1:]},
+		{name: "drops orphaned function response", policy: HistoryPolicy{MaxEvents: 3}, want: events[3:]},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, tc.policy.Apply(events)); diff != "" {
+				t.Errorf("Apply() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -97,6 +97,119 @@ type InvocationContext interface {
 	Ended() bool
 }
 
+// HistoryContext is an optional interface of an InvocationContext limiting
+// the session history its agent sees with a HistoryPolicy. The invocation
+// contexts created by ADK implement it; see HistoryOf and SetHistoryPolicy.
+type HistoryContext interface {
+	// History returns the events of the session used to build the requests
+	// to the model, i.e. the events of the session trimmed by the history
+	// policy. Superseded interim events are left out, see
+	// session.ReconcileInterim. The branch of the context and the
+	// IncludeContents setting of an LLM agent still apply to the returned
+	// events.
+	History() []*session.Event
+	// HistoryPolicy returns the history policy of the context.
+	HistoryPolicy() HistoryPolicy
+	// SetHistoryPolicy changes the history policy of the context and of the
+	// contexts later derived from it, e.g. for the sub-agents it runs. The
+	// context it was derived from and the contexts already derived from it
+	// keep their policy.
+	SetHistoryPolicy(HistoryPolicy)
+}
+
+// HistoryOf returns the history of ctx if it implements HistoryContext, or
+// all the events of its session otherwise.
+func HistoryOf(ctx InvocationContext) []*session.Event {
+	if hc, ok := ctx.(HistoryContext); ok {
+		return hc.History()
+	}
+	return HistoryPolicy{}.History(ctx.Session())
+}
+
+// HistoryPolicyOf returns the history policy of ctx if it implements
+// HistoryContext, or the zero policy otherwise.
+func HistoryPolicyOf(ctx InvocationContext) HistoryPolicy {
+	if hc, ok := ctx.(HistoryContext); ok {
+		return hc.HistoryPolicy()
+	}
+	return HistoryPolicy{}
+}
+
+// SetHistoryPolicy changes the history policy of ctx if it implements
+// HistoryContext, and reports whether it does.
+func SetHistoryPolicy(ctx InvocationContext, p HistoryPolicy) bool {
+	hc, ok := ctx.(HistoryContext)
+	if ok {
+		hc.SetHistoryPolicy(p)
+	}
+	return ok
+}
+
+// TransferDepthContext is an optional interface of an InvocationContext
+// counting the agent transfers of the invocation. The invocation contexts
+// created by ADK implement it; see TransferDepthOf.
+type TransferDepthContext interface {
+	// TransferDepth is the number of agent transfers of the invocation that
+	// led to the agent of the context. See RunConfig.MaxTransferDepth.
+	TransferDepth() int
+}
+
+// TransferDepthOf returns the transfer depth of ctx if it implements
+// TransferDepthContext, or zero otherwise.
+func TransferDepthOf(ctx InvocationContext) int {
+	if tc, ok := ctx.(TransferDepthContext); ok {
+		return tc.TransferDepth()
+	}
+	return 0
+}
+
+// HistoryPolicy limits the session history an agent sees. The zero value
+// keeps the whole history.
+type HistoryPolicy struct {
+	// MaxEvents keeps only the last MaxEvents events of the session. Zero
+	// means no limit.
+	MaxEvents int
+}
+
+// Apply returns the events kept by the policy. Function responses at the
+// start of the kept events are also dropped, since the function calls they
+// answer are not kept.
+func (p HistoryPolicy) Apply(events []*session.Event) []*session.Event {
+	if p.MaxEvents <= 0 || len(events) <= p.MaxEvents {
+		return events
+	}
+	events = events[len(events)-p.MaxEvents:]
+	for len(events) > 0 && hasFunctionResponses(events[0]) {
+		events = events[1:]
+	}
+	return events
+}
+
+// History returns the events of the session kept by the policy, leaving out
+// the superseded interim events, see session.ReconcileInterim.
+func (p HistoryPolicy) History(s session.Session) []*session.Event {
+	if s == nil {
+		return nil
+	}
+	var events []*session.Event
+	for ev := range s.Events().All() {
+		events = append(events, ev)
+	}
+	return p.Apply(session.ReconcileInterim(events))
+}
+
+func hasFunctionResponses(ev *session.Event) bool {
+	if ev.Content == nil {
+		return false
+	}
+	for _, p := range ev.Content.Parts {
+		if p != nil && p.FunctionResponse != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // ReadonlyContext provides read-only access to invocation context data.
 type ReadonlyContext interface {
 	context.Context
@@ -15,21 +15,46 @@
 package llmagent
 
 import (
+	"context"
 	"fmt"
 	"iter"
 	"strings"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
 	agentinternal "google.golang.org/adk/internal/agent"
+	"google.golang.org/adk/internal/agent/runconfig"
 	icontext "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/internal/llminternal"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/safety"
 	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
 )
 
+// ErrToolBudgetExhausted is returned when a tool call can't be executed or
+// completed because the deadline of the invocation is exceeded.
+var ErrToolBudgetExhausted = llminternal.ErrToolBudgetExhausted
+
+// ErrContextOverflow is matched by errors.Is for a [*ContextOverflowError].
+var ErrContextOverflow = llminternal.ErrContextOverflow
+
+// ContextOverflowError is returned, before the model is called, for a
+// request exceeding [Config.ContextWindow]. It carries the estimated and the
+// allowed number of tokens.
+type ContextOverflowError = llminternal.ContextOverflowError
+
+// EstimateTokens estimates the number of tokens of a request as four
+// characters per token of its contents, system instruction and tool
+// declarations, and a fixed number of tokens per file. It is the default
+// ContextWindow.CountTokens.
+func EstimateTokens(ctx context.Context, req *model.LLMRequest) (int, error) {
+	return llminternal.EstimateTokens(ctx, req)
+}
+
 // New is a constructor for LLMAgent.
 func New(cfg Config) (agent.Agent, error) {
 	beforeModelCallbacks := make([]llminternal.BeforeModelCallback, 0, len(cfg.BeforeModelCallbacks))
@@ -52,6 +77,11 @@ func New(cfg Config) (agent.Agent, error) {
 		afterToolCallbacks = append(afterToolCallbacks, llminternal.AfterToolCallback(c))
 	}
 
+	instructions := make([]llminternal.InstructionProvider, 0, len(cfg.Instructions))
+	for _, p := range cfg.Instructions {
+		instructions = append(instructions, llminternal.InstructionProvider(p))
+	}
+
 	a := &llmAgent{
 		beforeModelCallbacks: beforeModelCallbacks,
 		model:                cfg.Model,
@@ -61,12 +91,33 @@ func New(cfg Config) (agent.Agent, error) {
 		instruction:          cfg.Instruction,
 		inputSchema:          cfg.InputSchema,
 		outputSchema:         cfg.OutputSchema,
+		stopCondition:        llminternal.StopCondition(cfg.StopCondition),
+		outputParser:         llminternal.OutputParser(cfg.OutputParser),
+		maxReprompts:         cfg.MaxReprompts,
+		toolTimeout:          cfg.ToolTimeout,
+		maxToolCalls:         cfg.MaxToolCallsPerTurn,
+		safetyClassifier:     cfg.SafetyClassifier,
+		safetyFallback:       cfg.SafetyFallbackMessage,
+		outputLimit: llminternal.OutputLimit{
+			MaxChars:  cfg.OutputLimit.MaxChars,
+			MaxTokens: cfg.OutputLimit.MaxTokens,
+			Reprompt:  cfg.OutputLimit.Action == OutputLimitReprompt,
+			Notice:    cfg.OutputLimit.Notice,
+		},
+		contextWindow: llminternal.ContextWindow{
+			MaxTokens:   cfg.ContextWindow.MaxTokens,
+			CountTokens: cfg.ContextWindow.CountTokens,
+		},
 
 		State: llminternal.State{
 			Model:                    cfg.Model,
 			GenerateContentConfig:    cfg.GenerateContentConfig,
+			ThinkingConfig:           cfg.ThinkingConfig,
+			FunctionCallingConfig:    cfg.FunctionCallingConfig,
 			Tools:                    cfg.Tools,
 			Toolsets:                 cfg.Toolsets,
+			AllowedTools:             cfg.AllowedTools,
+			DeniedTools:              cfg.DeniedTools,
 			DisallowTransferToParent: cfg.DisallowTransferToParent,
 			DisallowTransferToPeers:  cfg.DisallowTransferToPeers,
 			InputSchema:              cfg.InputSchema,
@@ -77,7 +128,10 @@ func New(cfg Config) (agent.Agent, error) {
 			InstructionProvider:       llminternal.InstructionProvider(cfg.InstructionProvider),
 			GlobalInstruction:         cfg.GlobalInstruction,
 			GlobalInstructionProvider: llminternal.InstructionProvider(cfg.GlobalInstructionProvider),
+			Instructions:              instructions,
 			OutputKey:                 cfg.OutputKey,
+			ReportRemainingBudget:     cfg.ReportRemainingBudget,
+			GroundToolResults:         cfg.GroundToolResults,
 		},
 	}
 
@@ -88,6 +142,9 @@ func New(cfg Config) (agent.Agent, error) {
 		BeforeAgentCallbacks: cfg.BeforeAgentCallbacks,
 		Run:                  a.run,
 		AfterAgentCallbacks:  cfg.AfterAgentCallbacks,
+		PersistedEvents:      cfg.PersistedEvents,
+		TracerServiceName:    cfg.TracerServiceName,
+		SpanAttributes:       cfg.SpanAttributes,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create agent: %w", err)
@@ -96,6 +153,9 @@ func New(cfg Config) (agent.Agent, error) {
 	a.Agent = baseAgent
 	a.AgentType = agentinternal.TypeLLMAgent
 	a.Config = cfg
+	a.Persist = agentinternal.Reveal(baseAgent.(agentinternal.Agent)).Persist
+	a.TracerServiceName = cfg.TracerServiceName
+	a.SpanAttributes = agentinternal.Reveal(baseAgent.(agentinternal.Agent)).SpanAttributes
 
 	return a, nil
 }
@@ -130,6 +190,17 @@ type Config struct {
 	// callbacks will be skipped.
 	AfterAgentCallbacks []agent.AfterAgentCallback
 
+	// PersistedEvents are the kinds of events of the agent stored in the
+	// session service. See agent.Config.PersistedEvents. Defaults to all
+	// events.
+	PersistedEvents []session.EventKind
+	// TracerServiceName names the tracers of the spans of the agent. See
+	// agent.Config.TracerServiceName.
+	TracerServiceName string
+	// SpanAttributes are custom attributes recorded on the spans of the
+	// agent. See agent.Config.SpanAttributes.
+	SpanAttributes []attribute.KeyValue
+
 	// GenerateContentConfig is for the additional content generation
 	// configuration.
 	//
@@ -140,6 +211,23 @@ type Config struct {
 	// safety settings, etc.
 	GenerateContentConfig *genai.GenerateContentConfig
 
+	// ThinkingConfig configures the thinking (reasoning) features of models
+	// that support them, e.g. the thinking token budget.
+	//
+	// If set, it takes precedence over GenerateContentConfig.ThinkingConfig.
+	// If unset, the provider default is used.
+	ThinkingConfig *genai.ThinkingConfig
+
+	// FunctionCallingConfig controls whether the model may call tools:
+	// AUTO lets the model choose, ANY forces a function call (optionally
+	// restricted to AllowedFunctionNames) and NONE disables function calls.
+	//
+	// If set, it takes precedence over
+	// GenerateContentConfig.ToolConfig.FunctionCallingConfig. To change the
+	// mode between phases of a run, modify LLMRequest.Config.ToolConfig in a
+	// BeforeModelCallback.
+	FunctionCallingConfig *genai.FunctionCallingConfig
+
 	// BeforeModelCallbacks will be called in the order they are provided until
 	// there's a callback that returns a non-nil LLMResponse or error. Then
 	// actual LLM call is skipped, and the returned response/error is used.
@@ -205,6 +293,16 @@ type Config struct {
 	// It takes over the GlobalInstruction field if both are set.
 	GlobalInstructionProvider InstructionProvider
 
+	// Instructions composes the system instruction of the agent. The
+	// providers are called in order on each model call and each non-empty
+	// result becomes a block of the system instruction, so a provider can add
+	// a block conditionally by returning an empty string.
+	//
+	// If empty, the system instruction is GlobalInstructionBlock followed by
+	// InstructionBlock. Use those to place the global and the agent
+	// instruction among custom blocks.
+	Instructions []InstructionProvider
+
 	// DisallowTransferToParent prevents transferring to parent agent if LLM
 	// decides to.
 	DisallowTransferToParent bool
@@ -236,6 +334,39 @@ type Config struct {
 	// Toolsets will be used by llmagent to extract tools and pass to the
 	// underlying LLM.
 	Toolsets []tool.Toolset
+	// AllowedTools, if non-empty, restricts the agent to the tools with the
+	// given names. DeniedTools forbids the tools with the given names.
+	//
+	// Tools that are not permitted are not offered to the model, and any
+	// call to them, e.g. a hallucinated one, is rejected with a function
+	// response describing the denial instead of being executed. The lists
+	// apply to all function calls, including transfer_to_agent.
+	AllowedTools []string
+	DeniedTools  []string
+	// ToolTimeout limits the duration of a single tool call. The deadline of
+	// a tool call is min(ToolTimeout, remaining budget), where the remaining
+	// budget is the time left until the deadline of the invocation context.
+	// If the budget is exhausted, the agent fails with
+	// ErrToolBudgetExhausted. Zero means no per-tool timeout.
+	ToolTimeout time.Duration
+	// MaxToolCallsPerTurn caps the number of function calls run from a
+	// single model response, in the order of the response. Calls beyond the
+	// cap are not run; they are answered with a RESOURCE_EXHAUSTED error
+	// response asking the model to narrow its request. Calls rejected by
+	// AllowedTools and DeniedTools don't count. Zero means unlimited.
+	MaxToolCallsPerTurn int
+	// ReportRemainingBudget, if true, tells the model how much time is left
+	// in the run: when the run has a deadline, e.g. from RunConfig.Timeout,
+	// a note with the remaining time is added to the system instruction of
+	// each model request, so that the model can wrap up instead of calling
+	// more tools. Runs without a deadline are not affected.
+	ReportRemainingBudget bool
+	// GroundToolResults, if positive, repeats the responses of the last
+	// GroundToolResults tool calls of the conversation in a separate block
+	// of the system instruction of each model request, so that the model
+	// uses them in long tool-heavy turns instead of overlooking them in the
+	// history. The history itself is unchanged. Zero disables it.
+	GroundToolResults int
 
 	// OutputKey is an optional parameter to specify the key in session state for the agent output.
 	//
@@ -243,8 +374,99 @@ type Config struct {
 	// - Extracts agent reply for later use, such as in tools, callbacks, etc.
 	// - Connects agents to coordinate with each other.
 	OutputKey string
+
+	// StopCondition, if set, is called with each model response and decides
+	// whether the agent stops. Returning false for a final response
+	// re-prompts the model, returning true for a response with function
+	// calls stops the agent after the function responses.
+	StopCondition StopCondition
+	// OutputParser, if set, is called with the final model response before
+	// the agent stops. If it returns an error, the model is re-prompted with
+	// the error message.
+	OutputParser OutputParser
+	// MaxReprompts bounds the number of re-prompts caused by StopCondition
+	// and OutputParser. The agent fails once the limit is exceeded.
+	// Defaults to 3 if zero.
+	MaxReprompts int
+
+	// SafetyClassifier, if set, scores each final response of the agent
+	// before it is returned. The scores are attached to the event as
+	// SafetyScores. Blocked responses are replaced with
+	// SafetyFallbackMessage and have the SAFETY finish reason.
+	//
+	// In streaming mode, the partial responses of the model are withheld
+	// while a classifier is set, since they cannot be classified before they
+	// reach the client: the client only receives the classified aggregated
+	// response.
+	SafetyClassifier safety.Classifier
+	// SafetyFallbackMessage replaces the content of blocked responses.
+	// Defaults to safety.DefaultFallbackMessage if empty.
+	SafetyFallbackMessage string
+
+	// OutputLimit caps the length of final responses, independently of
+	// GenerateContentConfig.MaxOutputTokens, which models do not always
+	// respect. When the cap triggers, the call_llm span has the
+	// gcp.vertex.agent.output_capped attribute.
+	//
+	// In streaming mode, the text of the partial responses is cut once the
+	// text streamed for a model call reaches the limit, with MaxTokens
+	// estimated as four characters per token.
+	OutputLimit OutputLimit
+
+	// ContextWindow checks the size of each request before it is sent to
+	// the model. A request exceeding it fails the run with a
+	// *ContextOverflowError instead of an error of the model provider, so
+	// that the caller can compact the history and retry. The call_llm span
+	// has the gcp.vertex.agent.context_overflow attribute.
+	ContextWindow ContextWindow
 }
 
+// ContextWindow is the size of the context window of the model of an agent.
+type ContextWindow struct {
+	// MaxTokens is the maximum number of tokens of a request. Zero disables
+	// the check.
+	MaxTokens int
+	// CountTokens counts the tokens of a request, e.g. with the token
+	// counting API of the model provider. Defaults to [EstimateTokens].
+	CountTokens func(ctx context.Context, req *model.LLMRequest) (int, error)
+}
+
+// OutputLimit caps the length of the final responses of an agent. Zero
+// limits are not applied.
+type OutputLimit struct {
+	// MaxChars is the maximum number of characters of a response.
+	MaxChars int
+	// MaxTokens is the maximum number of tokens of a response, as reported
+	// by the model or estimated as four characters per token.
+	MaxTokens int
+	// Action is taken for responses exceeding the limit. Defaults to
+	// OutputLimitTruncate.
+	Action OutputLimitAction
+	// Notice is appended to truncated responses. Defaults to
+	// "[Response truncated]".
+	Notice string
+}
+
+// OutputLimitAction is the action taken for responses exceeding an
+// [OutputLimit].
+type OutputLimitAction string
+
+const (
+	// OutputLimitTruncate truncates the response and appends the notice.
+	OutputLimitTruncate OutputLimitAction = "truncate"
+	// OutputLimitReprompt asks the model for a shorter answer. Re-prompts
+	// count towards MaxReprompts.
+	OutputLimitReprompt OutputLimitAction = "reprompt"
+)
+
+// StopCondition inspects a model response and reports whether the agent
+// should stop.
+type StopCondition func(ctx agent.ReadonlyContext, llmResponse *model.LLMResponse) (bool, error)
+
+// OutputParser validates the final model response. A non-nil error rejects
+// the response and its message is sent back to the model.
+type OutputParser func(ctx agent.ReadonlyContext, llmResponse *model.LLMResponse) error
+
 // BeforeModelCallback that is called before sending a request to the model.
 //
 // If it returns non-nil LLMResponse or error, the actual model call is skipped
@@ -301,6 +523,18 @@ type llmAgent struct {
 
 	inputSchema  *genai.Schema
 	outputSchema *genai.Schema
+
+	stopCondition llminternal.StopCondition
+	outputParser  llminternal.OutputParser
+	maxReprompts  int
+	toolTimeout   time.Duration
+	maxToolCalls  int
+
+	safetyClassifier safety.Classifier
+	safetyFallback   string
+
+	outputLimit   llminternal.OutputLimit
+	contextWindow llminternal.ContextWindow
 }
 
 type agentState = agentinternal.State
@@ -315,16 +549,31 @@ func (a *llmAgent) run(ctx agent.InvocationContext) iter.Seq2[*session.Event, er
 		Agent:       a,
 		UserContent: ctx.UserContent(),
 		RunConfig:   ctx.RunConfig(),
+
+		HistoryPolicy: agent.HistoryPolicyOf(ctx),
+		TransferDepth: agent.TransferDepthOf(ctx),
 	})
 
 	f := &llminternal.Flow{
-		Model:                a.model,
+		Model:                runconfig.FromContext(ctx).ModelFor(a.Name(), a.model),
+		ConfiguredModel:      a.model,
 		RequestProcessors:    llminternal.DefaultRequestProcessors,
 		ResponseProcessors:   llminternal.DefaultResponseProcessors,
 		BeforeModelCallbacks: a.beforeModelCallbacks,
 		AfterModelCallbacks:  a.afterModelCallbacks,
 		BeforeToolCallbacks:  a.beforeToolCallbacks,
 		AfterToolCallbacks:   a.afterToolCallbacks,
+		StopCondition:        a.stopCondition,
+		OutputParser:         a.outputParser,
+		MaxReprompts:         a.maxReprompts,
+		ToolTimeout:          a.toolTimeout,
+		MaxToolCalls:         a.maxToolCalls,
+
+		SafetyClassifier:      a.safetyClassifier,
+		SafetyFallbackMessage: a.safetyFallback,
+
+		OutputLimit:   a.outputLimit,
+		ContextWindow: a.contextWindow,
 	}
 
 	return func(yield func(*session.Event, error) bool) {
@@ -381,3 +630,18 @@ func (a *llmAgent) maybeSaveOutputToState(event *session.Event) {
 // placeholders into the instruction. You can use
 // util/instructionutil.InjectSessionState() helper if this functionality is needed.
 type InstructionProvider func(ctx agent.ReadonlyContext) (string, error)
+
+// InstructionBlock is the instruction provider of the agent's own
+// instruction: the result of InstructionProvider, or Instruction with the
+// session state injected. See Config.Instructions.
+func InstructionBlock(ctx agent.ReadonlyContext) (string, error) {
+	return llminternal.InstructionBlock(ctx)
+}
+
+// GlobalInstructionBlock is the instruction provider of the global
+// instruction of the root agent: the result of its GlobalInstructionProvider,
+// or its GlobalInstruction with the session state injected. See
+// Config.Instructions.
+func GlobalInstructionBlock(ctx agent.ReadonlyContext) (string, error) {
+	return llminternal.GlobalInstructionBlock(ctx)
+}
@@ -15,15 +15,23 @@
 package llmagent_test
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"iter"
 	"net/http"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
@@ -32,6 +40,8 @@ import (
 	"google.golang.org/adk/internal/testutil"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/model/gemini"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/safety"
 	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/functiontool"
@@ -689,6 +699,458 @@ func TestInstructionProvider(t *testing.T) {
 	}
 }
 
+func TestThinkingConfig(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name       string
+		cfg        llmagent.Config
+		wantConfig *genai.GenerateContentConfig
+	}{
+		{
+			name:       "provider default",
+			cfg:        llmagent.Config{},
+			wantConfig: &genai.GenerateContentConfig{},
+		},
+		{
+			name: "thinking budget is set",
+			cfg: llmagent.Config{
+				ThinkingConfig: &genai.ThinkingConfig{ThinkingBudget: genai.Ptr[int32](1024)},
+			},
+			wantConfig: &genai.GenerateContentConfig{
+				ThinkingConfig: &genai.ThinkingConfig{ThinkingBudget: genai.Ptr[int32](1024)},
+			},
+		},
+		{
+			name: "thinking config overrides generate content config",
+			cfg: llmagent.Config{
+				GenerateContentConfig: &genai.GenerateContentConfig{
+					Temperature:    genai.Ptr[float32](0.5),
+					ThinkingConfig: &genai.ThinkingConfig{IncludeThoughts: true},
+				},
+				ThinkingConfig: &genai.ThinkingConfig{ThinkingBudget: genai.Ptr[int32](0)},
+			},
+			wantConfig: &genai.GenerateContentConfig{
+				Temperature:    genai.Ptr[float32](0.5),
+				ThinkingConfig: &genai.ThinkingConfig{ThinkingBudget: genai.Ptr[int32](0)},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			model := &testutil.MockModel{
+				Responses: []*genai.Content{
+					genai.NewContentFromText("llm resp stub", genai.RoleModel),
+				},
+			}
+			cfg := tc.cfg
+			cfg.Name = "test_agent"
+			cfg.Model = model
+			a, err := llmagent.New(cfg)
+			if err != nil {
+				t.Fatalf("failed to create LLM Agent: %v", err)
+			}
+
+			if _, err := testutil.CollectTextParts(testutil.NewTestAgentRunner(t, a).Run(t, "session", "user input")); err != nil {
+				t.Fatalf("agent run failed: %v", err)
+			}
+			if len(model.Requests) != 1 {
+				t.Fatalf("got %d LLM requests, want 1", len(model.Requests))
+			}
+			if diff := cmp.Diff(tc.wantConfig, model.Requests[0].Config); diff != "" {
+				t.Errorf("unexpected LLM request config (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFunctionCallingConfig(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name       string
+		cfg        llmagent.Config
+		wantConfig *genai.GenerateContentConfig
+	}{
+		{
+			name:       "provider default",
+			cfg:        llmagent.Config{},
+			wantConfig: &genai.GenerateContentConfig{},
+		},
+		{
+			name: "mode with allowed functions",
+			cfg: llmagent.Config{
+				FunctionCallingConfig: &genai.FunctionCallingConfig{
+					Mode:                 genai.FunctionCallingConfigModeAny,
+					AllowedFunctionNames: []string{"get_weather"},
+				},
+			},
+			wantConfig: &genai.GenerateContentConfig{
+				ToolConfig: &genai.ToolConfig{FunctionCallingConfig: &genai.FunctionCallingConfig{
+					Mode:                 genai.FunctionCallingConfigModeAny,
+					AllowedFunctionNames: []string{"get_weather"},
+				}},
+			},
+		},
+		{
+			name: "function calling config overrides generate content config",
+			cfg: llmagent.Config{
+				GenerateContentConfig: &genai.GenerateContentConfig{
+					ToolConfig: &genai.ToolConfig{
+						FunctionCallingConfig: &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeAuto},
+						RetrievalConfig:       &genai.RetrievalConfig{LanguageCode: "en"},
+					},
+				},
+				FunctionCallingConfig: &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeNone},
+			},
+			wantConfig: &genai.GenerateContentConfig{
+				ToolConfig: &genai.ToolConfig{
+					FunctionCallingConfig: &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeNone},
+					RetrievalConfig:       &genai.RetrievalConfig{LanguageCode: "en"},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			model := &testutil.MockModel{
+				Responses: []*genai.Content{
+					genai.NewContentFromText("llm resp stub", genai.RoleModel),
+				},
+			}
+			cfg := tc.cfg
+			cfg.Name = "test_agent"
+			cfg.Model = model
+			a, err := llmagent.New(cfg)
+			if err != nil {
+				t.Fatalf("failed to create LLM Agent: %v", err)
+			}
+
+			if _, err := testutil.CollectTextParts(testutil.NewTestAgentRunner(t, a).Run(t, "session", "user input")); err != nil {
+				t.Fatalf("agent run failed: %v", err)
+			}
+			if len(model.Requests) != 1 {
+				t.Fatalf("got %d LLM requests, want 1", len(model.Requests))
+			}
+			if diff := cmp.Diff(tc.wantConfig, model.Requests[0].Config); diff != "" {
+				t.Errorf("unexpected LLM request config (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStopConditionAndOutputParser(t *testing.T) {
+	t.Parallel()
+
+	jsonParser := func(ctx agent.ReadonlyContext, resp *model.LLMResponse) error {
+		if !json.Valid([]byte(resp.Content.Parts[0].Text)) {
+			return fmt.Errorf("response is not valid JSON")
+		}
+		return nil
+	}
+	sentinel := func(ctx agent.ReadonlyContext, resp *model.LLMResponse) (bool, error) {
+		return resp.Content != nil && strings.Contains(resp.Content.Parts[0].Text, "DONE"), nil
+	}
+
+	for _, tc := range []struct {
+		name          string
+		cfg           llmagent.Config
+		responses     []string
+		wantResponses []string
+		wantRequests  int
+		wantErr       bool
+	}{
+		{
+			name:          "output parser re-prompts on parse failure",
+			cfg:           llmagent.Config{OutputParser: jsonParser},
+			responses:     []string{"not json", `{"a": 1}`},
+			wantResponses: []string{"not json", `{"a": 1}`},
+			wantRequests:  2,
+		},
+		{
+			name:          "stop condition continues until sentinel",
+			cfg:           llmagent.Config{StopCondition: sentinel},
+			responses:     []string{"thinking", "still thinking", "DONE"},
+			wantResponses: []string{"thinking", "still thinking", "DONE"},
+			wantRequests:  3,
+		},
+		{
+			name:          "re-prompts are bounded",
+			cfg:           llmagent.Config{OutputParser: jsonParser, MaxReprompts: 1},
+			responses:     []string{"a", "b", "c"},
+			wantResponses: []string{"a", "b"},
+			wantRequests:  2,
+			wantErr:       true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockModel := &testutil.MockModel{}
+			for _, r := range tc.responses {
+				mockModel.Responses = append(mockModel.Responses, genai.NewContentFromText(r, genai.RoleModel))
+			}
+			cfg := tc.cfg
+			cfg.Name = "test_agent"
+			cfg.Model = mockModel
+			a, err := llmagent.New(cfg)
+			if err != nil {
+				t.Fatalf("failed to create LLM Agent: %v", err)
+			}
+
+			var gotResponses []string
+			var gotErr error
+			for ev, err := range testutil.NewTestAgentRunner(t, a).Run(t, "session", "user input") {
+				if err != nil {
+					gotErr = err
+					break
+				}
+				if ev.Author == "test_agent" {
+					gotResponses = append(gotResponses, ev.Content.Parts[0].Text)
+				}
+			}
+			if (gotErr != nil) != tc.wantErr {
+				t.Fatalf("agent run error = %v, wantErr %v", gotErr, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.wantResponses, gotResponses); diff != "" {
+				t.Errorf("unexpected agent responses (-want +got):\n%s", diff)
+			}
+			if len(mockModel.Requests) != tc.wantRequests {
+				t.Errorf("got %d LLM requests, want %d", len(mockModel.Requests), tc.wantRequests)
+			}
+			if tc.cfg.OutputParser != nil && len(mockModel.Requests) > 1 {
+				contents := mockModel.Requests[1].Contents
+				last := contents[len(contents)-1]
+				if last.Role != genai.RoleUser || !strings.Contains(last.Parts[0].Text, "not valid JSON") {
+					t.Errorf("re-prompt = %v, want user message with the parse error", last)
+				}
+			}
+		})
+	}
+}
+
+func TestToolResponseParts(t *testing.T) {
+	t.Parallel()
+
+	png := []byte("\x89PNG\r\n\x1a\n")
+	type Args struct {
+		Prompt string `json:"prompt"`
+	}
+	generateImage, err := functiontool.New(functiontool.Config{
+		Name:        "generate_image",
+		Description: "generates an image",
+	}, func(ctx tool.Context, args Args) (map[string]string, error) {
+		if !tool.AddResponseParts(ctx, &genai.FunctionResponsePart{
+			InlineData: &genai.FunctionResponseBlob{MIMEType: "image/png", Data: png},
+		}) {
+			return nil, fmt.Errorf("tool context cannot attach response parts")
+		}
+		return map[string]string{"status": "ok"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockModel := &testutil.MockModel{Responses: []*genai.Content{
+		genai.NewContentFromFunctionCall("generate_image", map[string]any{"prompt": "a cat"}, genai.RoleModel),
+		genai.NewContentFromText("a cat image", genai.RoleModel),
+	}}
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "test_agent",
+		Model: mockModel,
+		Tools: []tool.Tool{generateImage},
+	})
+	if err != nil {
+		t.Fatalf("failed to create LLM Agent: %v", err)
+	}
+	if _, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", "draw a cat")); err != nil {
+		t.Fatalf("agent run failed: %v", err)
+	}
+
+	if len(mockModel.Requests) != 2 {
+		t.Fatalf("got %d LLM requests, want 2", len(mockModel.Requests))
+	}
+	contents := mockModel.Requests[1].Contents
+	fr := contents[len(contents)-1].Parts[0].FunctionResponse
+	if fr == nil {
+		t.Fatalf("last content of the second request is not a function response: %v", contents[len(contents)-1])
+	}
+	want := []*genai.FunctionResponsePart{{
+		InlineData: &genai.FunctionResponseBlob{MIMEType: "image/png", Data: png},
+	}}
+	if diff := cmp.Diff(want, fr.Parts); diff != "" {
+		t.Errorf("unexpected function response parts (-want +got):\n%s", diff)
+	}
+}
+
+func TestToolAllowDenyLists(t *testing.T) {
+	t.Parallel()
+
+	type Args struct{}
+	newTool := func(name string, called *bool) tool.Tool {
+		tl, err := functiontool.New(functiontool.Config{
+			Name:        name,
+			Description: name,
+		}, func(ctx tool.Context, args Args) (map[string]string, error) {
+			*called = true
+			return map[string]string{"status": "ok"}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tl
+	}
+
+	for _, tc := range []struct {
+		name string
+		cfg  llmagent.Config
+	}{
+		{
+			name: "allow list",
+			cfg:  llmagent.Config{AllowedTools: []string{"lookup"}},
+		},
+		{
+			name: "deny list",
+			cfg:  llmagent.Config{DeniedTools: []string{"delete_all"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var lookupCalled, deleteCalled bool
+			mockModel := &testutil.MockModel{Responses: []*genai.Content{
+				{
+					Role: genai.RoleModel,
+					Parts: []*genai.Part{
+						{FunctionCall: &genai.FunctionCall{ID: "1", Name: "lookup", Args: map[string]any{}}},
+						{FunctionCall: &genai.FunctionCall{ID: "2", Name: "delete_all", Args: map[string]any{}}},
+					},
+				},
+				genai.NewContentFromText("done", genai.RoleModel),
+			}}
+			cfg := tc.cfg
+			cfg.Name = "test_agent"
+			cfg.Model = mockModel
+			cfg.Tools = []tool.Tool{newTool("lookup", &lookupCalled), newTool("delete_all", &deleteCalled)}
+			a, err := llmagent.New(cfg)
+			if err != nil {
+				t.Fatalf("failed to create LLM Agent: %v", err)
+			}
+			parts, err := testutil.CollectParts(testutil.NewTestAgentRunner(t, a).Run(t, "session", "clean up"))
+			if err != nil {
+				t.Fatalf("agent run failed: %v", err)
+			}
+
+			if !lookupCalled {
+				t.Error("allowed tool was not called")
+			}
+			if deleteCalled {
+				t.Error("denied tool was called")
+			}
+			responses := make(map[string]map[string]any)
+			for _, p := range parts {
+				if p.FunctionResponse != nil {
+					responses[p.FunctionResponse.Name] = p.FunctionResponse.Response
+				}
+			}
+			if diff := cmp.Diff(map[string]any{"status": "ok"}, responses["lookup"]); diff != "" {
+				t.Errorf("unexpected allowed tool response (-want +got):\n%s", diff)
+			}
+			if denied, _ := responses["delete_all"]["denied"].(bool); !denied {
+				t.Errorf("denied tool response = %v, want a denial", responses["delete_all"])
+			}
+			if _, ok := mockModel.Requests[0].Tools["delete_all"]; ok {
+				t.Error("denied tool was offered to the model")
+			}
+		})
+	}
+}
+
+func TestToolDeadline(t *testing.T) {
+	t.Parallel()
+
+	type Args struct{}
+	for _, tc := range []struct {
+		name           string
+		toolTimeout    time.Duration
+		budget         time.Duration
+		blockTool      bool
+		wantMaxTimeout time.Duration
+		wantErr        error
+	}{
+		{
+			name:           "per-tool timeout is shorter than the budget",
+			toolTimeout:    time.Second,
+			budget:         time.Minute,
+			wantMaxTimeout: time.Second,
+		},
+		{
+			name:           "remaining budget is shorter than the per-tool timeout",
+			toolTimeout:    time.Minute,
+			budget:         time.Second,
+			wantMaxTimeout: time.Second,
+		},
+		{
+			name:      "budget is exhausted during the tool call",
+			budget:    50 * time.Millisecond,
+			blockTool: true,
+			wantErr:   llmagent.ErrToolBudgetExhausted,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotTimeout time.Duration
+			slowTool, err := functiontool.New(functiontool.Config{
+				Name:        "slow",
+				Description: "slow tool",
+			}, func(ctx tool.Context, args Args) (map[string]string, error) {
+				deadline, ok := ctx.Deadline()
+				if !ok {
+					return nil, fmt.Errorf("tool context has no deadline")
+				}
+				gotTimeout = time.Until(deadline)
+				if tc.blockTool {
+					<-ctx.Done()
+					return nil, ctx.Err()
+				}
+				return map[string]string{}, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := llmagent.New(llmagent.Config{
+				Name: "test_agent",
+				Model: &testutil.MockModel{Responses: []*genai.Content{
+					genai.NewContentFromFunctionCall("slow", map[string]any{}, genai.RoleModel),
+					genai.NewContentFromText("done", genai.RoleModel),
+				}},
+				Tools:       []tool.Tool{slowTool},
+				ToolTimeout: tc.toolTimeout,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sessionService := session.InMemoryService()
+			resp, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "test_app", UserID: "user"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := runner.New(runner.Config{AppName: "test_app", Agent: a, SessionService: sessionService})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(t.Context(), tc.budget)
+			defer cancel()
+			var gotErr error
+			for _, err := range r.Run(ctx, "user", resp.Session.ID(), genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+				if err != nil {
+					gotErr = err
+					break
+				}
+			}
+			if !errors.Is(gotErr, tc.wantErr) {
+				t.Fatalf("agent run error = %v, want %v", gotErr, tc.wantErr)
+			}
+			if tc.wantMaxTimeout != 0 && (gotTimeout <= 0 || gotTimeout > tc.wantMaxTimeout) {
+				t.Errorf("tool deadline in %v, want in (0, %v]", gotTimeout, tc.wantMaxTimeout)
+			}
+		})
+	}
+}
+
 func TestFunctionTool(t *testing.T) {
 	model := newGeminiModel(t, modelName, nil)
 
@@ -972,3 +1434,1317 @@ type roundTripperFunc func(*http.Request) (*http.Response, error)
 func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return fn(req)
 }
+
+// keywordClassifier blocks content containing the keyword.
+type keywordClassifier struct {
+	keyword string
+	err     error
+}
+
+func (c keywordClassifier) Classify(ctx context.Context, content *genai.Content) (*safety.Result, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if strings.Contains(content.Parts[0].Text, c.keyword) {
+		return &safety.Result{Scores: map[string]float64{"toxicity": 0.9}, Blocked: true}, nil
+	}
+	return &safety.Result{Scores: map[string]float64{"toxicity": 0.1}}, nil
+}
+
+func TestSafetyClassifier(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name             string
+		classifier       safety.Classifier
+		fallback         string
+		response         string
+		wantText         string
+		wantScores       map[string]float64
+		wantFinishReason genai.FinishReason
+		wantErr          bool
+	}{
+		{
+			name:       "safe response is annotated",
+			classifier: keywordClassifier{keyword: "bad"},
+			response:   "good answer",
+			wantText:   "good answer",
+			wantScores: map[string]float64{"toxicity": 0.1},
+		},
+		{
+			name:             "blocked response is replaced with the fallback",
+			classifier:       keywordClassifier{keyword: "bad"},
+			fallback:         "filtered",
+			response:         "bad answer",
+			wantText:         "filtered",
+			wantScores:       map[string]float64{"toxicity": 0.9},
+			wantFinishReason: genai.FinishReasonSafety,
+		},
+		{
+			name:             "blocked response uses the default fallback",
+			classifier:       keywordClassifier{keyword: "bad"},
+			response:         "bad answer",
+			wantText:         safety.DefaultFallbackMessage,
+			wantScores:       map[string]float64{"toxicity": 0.9},
+			wantFinishReason: genai.FinishReasonSafety,
+		},
+		{
+			name:       "no-op classifier",
+			classifier: safety.Noop{},
+			response:   "bad answer",
+			wantText:   "bad answer",
+		},
+		{
+			name:       "classifier failure fails the run",
+			classifier: keywordClassifier{err: fmt.Errorf("unavailable")},
+			response:   "good answer",
+			wantErr:    true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := llmagent.New(llmagent.Config{
+				Name:                  "test_agent",
+				Model:                 &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText(tc.response, genai.RoleModel)}},
+				SafetyClassifier:      tc.classifier,
+				SafetyFallbackMessage: tc.fallback,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", "user input"))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("agent run error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if len(events) != 1 {
+				t.Fatalf("got %d events, want 1", len(events))
+			}
+			ev := events[0]
+			if got := ev.Content.Parts[0].Text; got != tc.wantText {
+				t.Errorf("response text = %q, want %q", got, tc.wantText)
+			}
+			if diff := cmp.Diff(tc.wantScores, ev.SafetyScores); diff != "" {
+				t.Errorf("safety scores mismatch (-want +got):\n%s", diff)
+			}
+			if ev.FinishReason != tc.wantFinishReason {
+				t.Errorf("finish reason = %q, want %q", ev.FinishReason, tc.wantFinishReason)
+			}
+		})
+	}
+}
+
+func TestSafetyClassifierStreaming(t *testing.T) {
+	t.Parallel()
+
+	a, err := llmagent.New(llmagent.Config{
+		Name: "test_agent",
+		Model: &testutil.MockModel{
+			Responses: []*genai.Content{
+				genai.NewContentFromText("bad ", genai.RoleModel),
+				genai.NewContentFromText("answer", genai.RoleModel),
+			},
+			StreamResponsesCount: 2,
+		},
+		SafetyClassifier:      keywordClassifier{keyword: "bad"},
+		SafetyFallbackMessage: "filtered",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := testutil.NewTestAgentRunner(t, a).RunContentWithConfig(t, "session", genai.NewContentFromText("user input", genai.RoleUser), agent.RunConfig{StreamingMode: agent.StreamingModeSSE})
+	events, err := testutil.CollectEvents(stream)
+	if err != nil {
+		t.Fatalf("agent run error = %v", err)
+	}
+	for _, ev := range events {
+		if ev.Partial {
+			t.Errorf("got partial event %q, want partial responses withheld", ev.Content.Parts[0].Text)
+		}
+	}
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if got := events[0].Content.Parts[0].Text; got != "filtered" {
+		t.Errorf("response text = %q, want %q", got, "filtered")
+	}
+}
+
+func TestOutputLimit(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name         string
+		limit        llmagent.OutputLimit
+		responses    []string
+		wantTexts    []string
+		wantRequests int
+		wantErr      bool
+	}{
+		{
+			name:         "short response is kept",
+			limit:        llmagent.OutputLimit{MaxChars: 20},
+			responses:    []string{"short answer"},
+			wantTexts:    []string{"short answer"},
+			wantRequests: 1,
+		},
+		{
+			name:         "long response is truncated with the default notice",
+			limit:        llmagent.OutputLimit{MaxChars: 10},
+			responses:    []string{"a very long answer"},
+			wantTexts:    []string{"a very lon\n\n[Response truncated]"},
+			wantRequests: 1,
+		},
+		{
+			name:         "token limit truncates with a custom notice",
+			limit:        llmagent.OutputLimit{MaxTokens: 2, Notice: "(cut)"},
+			responses:    []string{"a very long answer"},
+			wantTexts:    []string{"a very l\n\n(cut)"},
+			wantRequests: 1,
+		},
+		{
+			name:         "long response is re-prompted",
+			limit:        llmagent.OutputLimit{MaxChars: 10, Action: llmagent.OutputLimitReprompt},
+			responses:    []string{"a very long answer", "short"},
+			wantTexts:    []string{"a very long answer", "Your previous response was rejected: response of 18 characters exceeds the limit of 10 characters; give a shorter answer. Respond again, fixing the problem.", "short"},
+			wantRequests: 2,
+		},
+		{
+			name:         "re-prompts are bounded",
+			limit:        llmagent.OutputLimit{MaxChars: 1, Action: llmagent.OutputLimitReprompt},
+			responses:    []string{"too long", "too long", "too long", "too long"},
+			wantRequests: 4,
+			wantErr:      true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var responses []*genai.Content
+			for _, r := range tc.responses {
+				responses = append(responses, genai.NewContentFromText(r, genai.RoleModel))
+			}
+			mockModel := &testutil.MockModel{Responses: responses}
+			a, err := llmagent.New(llmagent.Config{
+				Name:        "test_agent",
+				Model:       mockModel,
+				OutputLimit: tc.limit,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", "user input"))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("agent run error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got := len(mockModel.Requests); got != tc.wantRequests {
+				t.Errorf("got %d LLM requests, want %d", got, tc.wantRequests)
+			}
+			if tc.wantErr {
+				return
+			}
+			var gotTexts []string
+			for _, ev := range events {
+				gotTexts = append(gotTexts, ev.Content.Parts[0].Text)
+			}
+			if diff := cmp.Diff(tc.wantTexts, gotTexts); diff != "" {
+				t.Errorf("response texts mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOutputLimitStreaming(t *testing.T) {
+	t.Parallel()
+
+	a, err := llmagent.New(llmagent.Config{
+		Name: "test_agent",
+		Model: &testutil.MockModel{
+			Responses: []*genai.Content{
+				genai.NewContentFromText("hello ", genai.RoleModel),
+				genai.NewContentFromText("world ", genai.RoleModel),
+				genai.NewContentFromText("again", genai.RoleModel),
+			},
+			StreamResponsesCount: 3,
+		},
+		OutputLimit: llmagent.OutputLimit{MaxChars: 8},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := testutil.NewTestAgentRunner(t, a).RunContentWithConfig(t, "session", genai.NewContentFromText("user input", genai.RoleUser), agent.RunConfig{StreamingMode: agent.StreamingModeSSE})
+	events, err := testutil.CollectEvents(stream)
+	if err != nil {
+		t.Fatalf("agent run error = %v", err)
+	}
+	var gotTexts []string
+	for _, ev := range events {
+		gotTexts = append(gotTexts, ev.Content.Parts[0].Text)
+	}
+	wantTexts := []string{
+		"hello ",
+		"wo\n\n[Response truncated]",
+		"hello wo\n\n[Response truncated]",
+	}
+	if diff := cmp.Diff(wantTexts, gotTexts); diff != "" {
+		t.Errorf("response texts mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name         string
+		window       llmagent.ContextWindow
+		input        string
+		wantOverflow *llmagent.ContextOverflowError
+		wantRequests int
+	}{
+		{
+			name:         "request within the window",
+			window:       llmagent.ContextWindow{MaxTokens: 100},
+			input:        "short question",
+			wantRequests: 1,
+		},
+		{
+			name:         "estimated overflow",
+			window:       llmagent.ContextWindow{MaxTokens: 10},
+			input:        strings.Repeat("a", 100),
+			wantOverflow: &llmagent.ContextOverflowError{EstimatedTokens: 25, MaxTokens: 10},
+		},
+		{
+			name: "custom token counter",
+			window: llmagent.ContextWindow{MaxTokens: 10, CountTokens: func(ctx context.Context, req *model.LLMRequest) (int, error) {
+				return 11, nil
+			}},
+			input:        "short question",
+			wantOverflow: &llmagent.ContextOverflowError{EstimatedTokens: 11, MaxTokens: 10},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockModel := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("answer", genai.RoleModel)}}
+			a, err := llmagent.New(llmagent.Config{
+				Name:          "test_agent",
+				Model:         mockModel,
+				ContextWindow: tc.window,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", tc.input))
+			if tc.wantOverflow == nil {
+				if err != nil {
+					t.Fatalf("agent run error = %v", err)
+				}
+			} else {
+				if !errors.Is(err, llmagent.ErrContextOverflow) {
+					t.Fatalf("agent run error = %v, want %v", err, llmagent.ErrContextOverflow)
+				}
+				var overflow *llmagent.ContextOverflowError
+				if !errors.As(err, &overflow) {
+					t.Fatalf("agent run error = %v, want a *ContextOverflowError", err)
+				}
+				if diff := cmp.Diff(tc.wantOverflow, overflow); diff != "" {
+					t.Errorf("overflow mismatch (-want +got):\n%s", diff)
+				}
+			}
+			if got := len(mockModel.Requests); got != tc.wantRequests {
+				t.Errorf("got %d LLM requests, want %d", got, tc.wantRequests)
+			}
+		})
+	}
+}
+
+func TestHistoryPolicy(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name         string
+		policy       agent.HistoryPolicy
+		wantContents []string
+	}{
+		{
+			name:         "whole history",
+			wantContents: []string{"q1", "a1", "q2", "a2", "q3"},
+		},
+		{
+			name:         "last events",
+			policy:       agent.HistoryPolicy{MaxEvents: 3},
+			wantContents: []string{"q2", "a2", "q3"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockModel := &testutil.MockModel{Responses: []*genai.Content{
+				genai.NewContentFromText("a1", genai.RoleModel),
+				genai.NewContentFromText("a2", genai.RoleModel),
+				genai.NewContentFromText("a3", genai.RoleModel),
+			}}
+			assistant, err := llmagent.New(llmagent.Config{
+				Name:                     "assistant",
+				Model:                    mockModel,
+				DisallowTransferToParent: true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The root agent trims the history before running its
+			// sub-agent, whose context inherits the policy.
+			var gotHistory int
+			root, err := agent.New(agent.Config{
+				Name:      "root",
+				SubAgents: []agent.Agent{assistant},
+				Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+					agent.SetHistoryPolicy(ctx, tc.policy)
+					gotHistory = len(agent.HistoryOf(ctx))
+					return assistant.Run(ctx)
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := testutil.NewTestAgentRunner(t, root)
+			for _, q := range []string{"q1", "q2", "q3"} {
+				if _, err := testutil.CollectEvents(r.Run(t, "session", q)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var got []string
+			for _, c := range mockModel.Requests[len(mockModel.Requests)-1].Contents {
+				got = append(got, c.Parts[0].Text)
+			}
+			if diff := cmp.Diff(tc.wantContents, got); diff != "" {
+				t.Errorf("contents of the last request mismatch (-want +got):\n%s", diff)
+			}
+			if gotHistory != len(tc.wantContents) {
+				t.Errorf("History() returned %d events, want %d", gotHistory, len(tc.wantContents))
+			}
+		})
+	}
+}
+
+func TestPhaseEvents(t *testing.T) {
+	t.Parallel()
+
+	type Args struct {
+		City string `json:"city"`
+	}
+	weather, err := functiontool.New(functiontool.Config{
+		Name:        "get_weather",
+		Description: "returns the weather",
+	}, func(tool.Context, Args) (map[string]string, error) {
+		return map[string]string{"temp": "21C"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		cfg  agent.RunConfig
+		want []string
+	}{
+		{
+			name: "disabled",
+			want: []string{"tool_call", "tool_response", "model_text"},
+		},
+		{
+			name: "enabled",
+			cfg:  agent.RunConfig{PhaseEvents: true},
+			want: []string{
+				"thinking mock", "tool_call", "calling_tool get_weather", "tool_response",
+				"thinking mock", "generating", "model_text",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := llmagent.New(llmagent.Config{
+				Name: "weather_agent",
+				Model: &testutil.MockModel{Responses: []*genai.Content{
+					genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "Paris"}, genai.RoleModel),
+					genai.NewContentFromText("21C in Paris", genai.RoleModel),
+				}},
+				Tools: []tool.Tool{weather},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sessionService := session.InMemoryService()
+			if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"}); err != nil {
+				t.Fatal(err)
+			}
+			r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for ev, err := range r.Run(t.Context(), "user", "s1", genai.NewContentFromText("weather?", genai.RoleUser), tc.cfg) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if p := ev.Progress; p != nil {
+					if ev.Content != nil || ev.IsFinalResponse() || session.KindOf(ev) != session.EventKindPhase {
+						t.Errorf("phase event %+v has content, is final or has kind %q", p, session.KindOf(ev))
+					}
+					got = append(got, strings.TrimSpace(string(p.Phase)+" "+p.Detail))
+					continue
+				}
+				got = append(got, string(session.KindOf(ev)))
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("events mismatch (-want +got):\n%s", diff)
+			}
+
+			// Phase events are not stored.
+			resp, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := resp.Session.Events().Len(), 4; got != want {
+				t.Errorf("session has %d events, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestToolErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	type Args struct {
+		City string `json:"city"`
+	}
+	weather, err := functiontool.New(functiontool.Config{
+		Name:        "get_weather",
+		Description: "returns the weather",
+	}, func(_ tool.Context, args Args) (map[string]string, error) {
+		if args.City == "Atlantis" {
+			return nil, tool.NewError(tool.ErrorCodeNotFound, fmt.Errorf("unknown city %q", args.City))
+		}
+		return nil, errors.New("weather service unreachable")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name: "weather_agent",
+		Model: &testutil.MockModel{Responses: []*genai.Content{
+			{Role: genai.RoleModel, Parts: []*genai.Part{
+				{FunctionCall: &genai.FunctionCall{ID: "c1", Name: "get_weather", Args: map[string]any{"city": "Paris"}}},
+				{FunctionCall: &genai.FunctionCall{ID: "c2", Name: "get_weather", Args: map[string]any{"city": "Atlantis"}}},
+				{FunctionCall: &genai.FunctionCall{ID: "c3", Name: "get_weather", Args: map[string]any{"city": 42}}},
+			}},
+			genai.NewContentFromText("sorry", genai.RoleModel),
+		}},
+		Tools: []tool.Tool{weather},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts, err := testutil.CollectParts(testutil.NewTestAgentRunner(t, a).Run(t, "s1", "weather?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]map[string]any)
+	for _, p := range parts {
+		if fr := p.FunctionResponse; fr != nil {
+			got[fr.ID] = fr.Response
+		}
+	}
+	wantCodes := map[string]string{
+		"c1": tool.ErrorCodeInternal,
+		"c2": tool.ErrorCodeNotFound,
+		"c3": tool.ErrorCodeInvalidArgument,
+	}
+	for id, wantCode := range wantCodes {
+		resp := got[id]
+		if resp["status"] != "error" || resp["error_code"] != wantCode {
+			t.Errorf("response of %s = %v, want status error and error_code %s", id, resp, wantCode)
+		}
+		if msg, _ := resp["error"].(string); msg == "" {
+			t.Errorf("response of %s has no error message: %v", id, resp)
+		}
+	}
+	if diff := cmp.Diff("weather service unreachable", got["c1"]["error"]); diff != "" {
+		t.Errorf("error message mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMaxToolCallsPerTurn(t *testing.T) {
+	// Not parallel: the test swaps the global tracer provider.
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	type Args struct {
+		City string `json:"city"`
+	}
+	var (
+		mu  sync.Mutex
+		ran []string
+	)
+	weather, err := functiontool.New(functiontool.Config{
+		Name:        "get_weather",
+		Description: "returns the weather",
+	}, func(_ tool.Context, args Args) (map[string]string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		ran = append(ran, args.City)
+		return map[string]string{"temp": "21C"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls []*genai.Part
+	for i, city := range []string{"Paris", "Rome", "Oslo"} {
+		calls = append(calls, &genai.Part{FunctionCall: &genai.FunctionCall{ID: fmt.Sprintf("c%d", i+1), Name: "get_weather", Args: map[string]any{"city": city}}})
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name: "weather_agent",
+		Model: &testutil.MockModel{Responses: []*genai.Content{
+			{Role: genai.RoleModel, Parts: calls},
+			genai.NewContentFromText("done", genai.RoleModel),
+		}},
+		Tools:               []tool.Tool{weather},
+		MaxToolCallsPerTurn: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := testutil.NewTestAgentRunner(t, a)
+	parts, err := testutil.CollectParts(r.RunContentWithConfig(t, "s1", genai.NewContentFromText("weather?", genai.RoleUser), agent.RunConfig{MaxConcurrentTools: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The test opts in to concurrent execution, so the calls of the turn
+	// execute in no particular order.
+	slices.Sort(ran)
+	if diff := cmp.Diff([]string{"Paris", "Rome"}, ran); diff != "" {
+		t.Errorf("tool runs mismatch (-want +got):\n%s", diff)
+	}
+	got := make(map[string]map[string]any)
+	for _, p := range parts {
+		if fr := p.FunctionResponse; fr != nil {
+			got[fr.ID] = fr.Response
+		}
+	}
+	if diff := cmp.Diff(map[string]any{"temp": "21C"}, got["c2"]); diff != "" {
+		t.Errorf("response of c2 mismatch (-want +got):\n%s", diff)
+	}
+	if code, msg, ok := tool.ResponseError(got["c3"]); !ok || code != tool.ErrorCodeResourceExhausted || !strings.Contains(msg, "at most 2") {
+		t.Errorf("response of c3 = %v, want a %s error response", got["c3"], tool.ErrorCodeResourceExhausted)
+	}
+
+	var capped []string
+	for _, span := range recorder.Ended() {
+		for _, kv := range span.Attributes() {
+			if kv.Key == "gcp.vertex.agent.tool_calls_capped" && kv.Value.AsBool() {
+				capped = append(capped, span.Name())
+			}
+		}
+	}
+	if diff := cmp.Diff([]string{"execute_tool get_weather"}, capped); diff != "" {
+		t.Errorf("capped spans mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMaxConcurrentTools(t *testing.T) {
+	// Not parallel: the test swaps the global tracer provider.
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	const limit = 2
+	type Args struct {
+		City string `json:"city"`
+	}
+	var (
+		mu             sync.Mutex
+		active, maxRan int
+		full           = make(chan struct{})
+		fullOnce       sync.Once
+	)
+	weather, err := functiontool.New(functiontool.Config{
+		Name:        "get_weather",
+		Description: "returns the weather",
+	}, func(_ tool.Context, args Args) (map[string]string, error) {
+		mu.Lock()
+		active++
+		maxRan = max(maxRan, active)
+		if active == limit {
+			fullOnce.Do(func() { close(full) })
+		}
+		mu.Unlock()
+		// The test opts in to concurrent execution up to the limit. Hold the
+		// calls until the limit is reached, so that the calls over the limit
+		// would run too if they were let.
+		select {
+		case <-full:
+		case <-time.After(5 * time.Second):
+			t.Errorf("the tool calls of %s did not reach the limit of %d concurrent calls", args.City, limit)
+		}
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return map[string]string{"temp": "21C"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cities := []string{"Paris", "Rome", "Oslo", "Lima", "Cairo", "Quito"}
+	var calls []*genai.Part
+	for i, city := range cities {
+		calls = append(calls, &genai.Part{FunctionCall: &genai.FunctionCall{ID: fmt.Sprintf("c%d", i+1), Name: "get_weather", Args: map[string]any{"city": city}}})
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name: "weather_agent",
+		Model: &testutil.MockModel{Responses: []*genai.Content{
+			{Role: genai.RoleModel, Parts: calls},
+			genai.NewContentFromText("done", genai.RoleModel),
+		}},
+		Tools: []tool.Tool{weather},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := testutil.NewTestAgentRunner(t, a)
+	parts, err := testutil.CollectParts(r.RunContentWithConfig(t, "s1", genai.NewContentFromText("weather?", genai.RoleUser), agent.RunConfig{MaxConcurrentTools: limit}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if maxRan != limit {
+		t.Errorf("at most %d tool calls ran concurrently, want %d", maxRan, limit)
+	}
+	var gotIDs []string
+	for _, p := range parts {
+		if fr := p.FunctionResponse; fr != nil {
+			gotIDs = append(gotIDs, fr.ID)
+		}
+	}
+	// The responses keep the order of the calls.
+	if diff := cmp.Diff([]string{"c1", "c2", "c3", "c4", "c5", "c6"}, gotIDs); diff != "" {
+		t.Errorf("function responses mismatch (-want +got):\n%s", diff)
+	}
+
+	got := make(map[string]any)
+	for _, span := range recorder.Ended() {
+		if span.Name() != "execute_tool (merged)" {
+			continue
+		}
+		for _, kv := range span.Attributes() {
+			if strings.HasPrefix(string(kv.Key), "gcp.vertex.agent.tool_concurrency_") {
+				got[string(kv.Key)] = kv.Value.AsInterface()
+			}
+		}
+	}
+	want := map[string]any{
+		"gcp.vertex.agent.tool_concurrency_limit":        int64(limit),
+		"gcp.vertex.agent.tool_concurrency_max_observed": int64(limit),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("merged span attributes mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestToolCallsSequentialByDefault(t *testing.T) {
+	type Args struct {
+		City string `json:"city"`
+	}
+	var (
+		mu             sync.Mutex
+		active, maxRan int
+		order          []string
+	)
+	weather, err := functiontool.New(functiontool.Config{
+		Name:        "get_weather",
+		Description: "returns the weather",
+	}, func(_ tool.Context, args Args) (map[string]string, error) {
+		mu.Lock()
+		active++
+		maxRan = max(maxRan, active)
+		order = append(order, args.City)
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return map[string]string{"temp": "21C"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cities := []string{"Paris", "Rome", "Oslo", "Lima"}
+	var calls []*genai.Part
+	for i, city := range cities {
+		calls = append(calls, &genai.Part{FunctionCall: &genai.FunctionCall{ID: fmt.Sprintf("c%d", i+1), Name: "get_weather", Args: map[string]any{"city": city}}})
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name: "weather_agent",
+		Model: &testutil.MockModel{Responses: []*genai.Content{
+			{Role: genai.RoleModel, Parts: calls},
+			genai.NewContentFromText("done", genai.RoleModel),
+		}},
+		Tools: []tool.Tool{weather},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := testutil.NewTestAgentRunner(t, a)
+	if _, err := testutil.CollectParts(r.Run(t, "s1", "weather?")); err != nil {
+		t.Fatal(err)
+	}
+
+	if maxRan != 1 {
+		t.Errorf("at most %d tool calls ran concurrently, want 1", maxRan)
+	}
+	if diff := cmp.Diff(cities, order); diff != "" {
+		t.Errorf("tool call order mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReportRemainingBudget(t *testing.T) {
+	// Not parallel: the test swaps the global tracer provider.
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	for _, tc := range []struct {
+		name     string
+		report   bool
+		timeout  time.Duration
+		wantNote bool
+	}{
+		{name: "reported with a deadline", report: true, timeout: time.Hour, wantNote: true},
+		{name: "not reported without a deadline", report: true},
+		{name: "not reported if disabled", timeout: time.Hour},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder.Reset()
+			m := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("done", genai.RoleModel)}}
+			a, err := llmagent.New(llmagent.Config{
+				Name:                  "budget_agent",
+				Model:                 m,
+				Instruction:           "Be brief.",
+				ReportRemainingBudget: tc.report,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := testutil.NewTestAgentRunner(t, a)
+			if _, err := testutil.CollectEvents(r.RunContentWithConfig(t, "s1", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{Timeout: tc.timeout})); err != nil {
+				t.Fatal(err)
+			}
+
+			var instruction []string
+			for _, p := range m.Requests[0].Config.SystemInstruction.Parts {
+				instruction = append(instruction, p.Text)
+			}
+			gotNote := strings.Contains(strings.Join(instruction, "\n"), "Remaining time budget of this run: 1h0m0s.")
+			if gotNote != tc.wantNote {
+				t.Errorf("system instruction %q has budget note: %v, want %v", instruction, gotNote, tc.wantNote)
+			}
+
+			var budget int64 = -1
+			for _, span := range recorder.Ended() {
+				if span.Name() != "call_llm" {
+					continue
+				}
+				for _, kv := range span.Attributes() {
+					if kv.Key == "gcp.vertex.agent.remaining_budget_ms" {
+						budget = kv.Value.AsInt64()
+					}
+				}
+			}
+			if gotBudget := budget > 0 && budget <= time.Hour.Milliseconds(); gotBudget != tc.wantNote {
+				t.Errorf("remaining_budget_ms = %d, want recorded: %v", budget, tc.wantNote)
+			}
+		})
+	}
+}
+
+func TestInstructionComposition(t *testing.T) {
+	// Not parallel: the test swaps the global tracer provider.
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	contextBlock := func(ctx agent.ReadonlyContext) (string, error) {
+		v, err := ctx.ReadonlyState().Get("var")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Context: %v", v), nil
+	}
+	skippedBlock := func(ctx agent.ReadonlyContext) (string, error) {
+		return "", nil
+	}
+
+	for _, tc := range []struct {
+		name         string
+		instructions []llmagent.InstructionProvider
+		want         []string
+	}{
+		{
+			name: "default order",
+			want: []string{"Global custom_value", "Agent custom_value"},
+		},
+		{
+			name:         "custom order",
+			instructions: []llmagent.InstructionProvider{contextBlock, llmagent.InstructionBlock, skippedBlock, llmagent.GlobalInstructionBlock},
+			want:         []string{"Context: custom_value", "Agent custom_value", "Global custom_value"},
+		},
+		{
+			name:         "agent instruction left out",
+			instructions: []llmagent.InstructionProvider{llmagent.GlobalInstructionBlock, contextBlock},
+			want:         []string{"Global custom_value", "Context: custom_value"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder.Reset()
+			m := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("done", genai.RoleModel)}}
+			a, err := llmagent.New(llmagent.Config{
+				Name:              "test_agent",
+				Model:             m,
+				GlobalInstruction: "Global {var}",
+				Instruction:       "Agent {var}",
+				Instructions:      tc.instructions,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := testutil.NewTestAgentRunner(t, a)
+			r.SetInitSessionState(map[string]any{"var": "custom_value"})
+			if _, err := testutil.CollectEvents(r.Run(t, "s1", "hi")); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, p := range m.Requests[0].Config.SystemInstruction.Parts {
+				got = append(got, p.Text)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("system instruction mismatch (-want +got):\n%s", diff)
+			}
+
+			var traced string
+			for _, span := range recorder.Ended() {
+				if span.Name() != "call_llm" {
+					continue
+				}
+				for _, kv := range span.Attributes() {
+					if kv.Key == "gcp.vertex.agent.system_instruction" {
+						traced = kv.Value.AsString()
+					}
+				}
+			}
+			if want := strings.Join(tc.want, "\n\n"); traced != want {
+				t.Errorf("traced system instruction = %q, want %q", traced, want)
+			}
+		})
+	}
+}
+
+func TestTransferDepthLimit(t *testing.T) {
+	// Not parallel: the test swaps the global tracer provider.
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	transferCall := func(agentName string) *genai.Content {
+		return genai.NewContentFromFunctionCall("transfer_to_agent", map[string]any{"agent_name": agentName}, genai.RoleModel)
+	}
+	// root_agent and sub_agent_1 transfer to each other until the limit cuts
+	// the cycle.
+	m := &testutil.MockModel{Responses: []*genai.Content{
+		transferCall("sub_agent_1"),
+		transferCall("root_agent"),
+		transferCall("sub_agent_1"),
+		transferCall("root_agent"),
+		genai.NewContentFromText("unreachable", genai.RoleModel),
+	}}
+	subAgent1, err := llmagent.New(llmagent.Config{Name: "sub_agent_1", Model: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootAgent, err := llmagent.New(llmagent.Config{Name: "root_agent", Model: m, SubAgents: []agent.Agent{subAgent1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := testutil.NewTestAgentRunner(t, rootAgent)
+	var (
+		last   *session.Event
+		runErr error
+	)
+	for ev, err := range r.RunContentWithConfig(t, "s1", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{MaxTransferDepth: 3}) {
+		if err != nil {
+			runErr = err
+			break
+		}
+		last = ev
+	}
+
+	if !errors.Is(runErr, agent.ErrTransferDepthExceeded) {
+		t.Fatalf("run error = %v, want %v", runErr, agent.ErrTransferDepthExceeded)
+	}
+	var depthErr *agent.TransferDepthError
+	if !errors.As(runErr, &depthErr) {
+		t.Fatalf("run error = %T, want *agent.TransferDepthError", runErr)
+	}
+	if diff := cmp.Diff(&agent.TransferDepthError{From: "sub_agent_1", To: "root_agent", MaxDepth: 3}, depthErr); diff != "" {
+		t.Errorf("TransferDepthError mismatch (-want +got):\n%s", diff)
+	}
+	if last == nil || last.ErrorCode != agent.TransferDepthExceededErrorCode || last.Author != "sub_agent_1" {
+		t.Errorf("last event = %+v, want a %s event by sub_agent_1", last, agent.TransferDepthExceededErrorCode)
+	}
+	if got := len(m.Requests); got != 4 {
+		t.Errorf("model calls = %d, want 4", got)
+	}
+
+	var depths []int64
+	for _, span := range recorder.Ended() {
+		if span.Name() != "call_llm" {
+			continue
+		}
+		for _, kv := range span.Attributes() {
+			if kv.Key == "gcp.vertex.agent.transfer_depth" {
+				depths = append(depths, kv.Value.AsInt64())
+			}
+		}
+	}
+	if diff := cmp.Diff([]int64{1, 2, 3}, depths); diff != "" {
+		t.Errorf("traced transfer depths mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGroundToolResults(t *testing.T) {
+	// Not parallel: the test swaps the global tracer provider.
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	type Args struct {
+		City string `json:"city"`
+	}
+	weather, err := functiontool.New(functiontool.Config{
+		Name:        "get_weather",
+		Description: "returns the weather",
+	}, func(ctx tool.Context, args Args) (map[string]string, error) {
+		return map[string]string{"city": args.City, "temp": "21C"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name        string
+		count       int
+		wantBlock   string
+		wantTracked []int64
+	}{
+		{
+			name: "disabled",
+		},
+		{
+			name:  "last two results",
+			count: 2,
+			wantBlock: "Results of the most recent tool calls of this conversation. Base your answer on them rather than on assumptions:\n" +
+				`- get_weather: {"city":"Rome","temp":"21C"}` + "\n" +
+				`- get_weather: {"city":"Oslo","temp":"21C"}`,
+			// The first call has no results yet, the second one has one.
+			wantTracked: []int64{1, 2, 2},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder.Reset()
+			m := &testutil.MockModel{Responses: []*genai.Content{
+				genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "Paris"}, genai.RoleModel),
+				genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "Rome"}, genai.RoleModel),
+				genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "Oslo"}, genai.RoleModel),
+				genai.NewContentFromText("done", genai.RoleModel),
+			}}
+			a, err := llmagent.New(llmagent.Config{
+				Name:              "test_agent",
+				Model:             m,
+				Instruction:       "Answer about the weather.",
+				Tools:             []tool.Tool{weather},
+				GroundToolResults: tc.count,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "s1", "hi")); err != nil {
+				t.Fatal(err)
+			}
+
+			last := m.Requests[len(m.Requests)-1]
+			var block string
+			for _, p := range last.Config.SystemInstruction.Parts {
+				if strings.HasPrefix(p.Text, "Results of the most recent tool calls") {
+					block = p.Text
+				}
+			}
+			if diff := cmp.Diff(tc.wantBlock, block); diff != "" {
+				t.Errorf("grounding block mismatch (-want +got):\n%s", diff)
+			}
+			var responses int
+			for _, c := range last.Contents {
+				for _, p := range c.Parts {
+					if p.FunctionResponse != nil {
+						responses++
+					}
+				}
+			}
+			if responses != 3 {
+				t.Errorf("history has %d function responses, want 3", responses)
+			}
+
+			var tracked []int64
+			for _, span := range recorder.Ended() {
+				if span.Name() != "call_llm" {
+					continue
+				}
+				for _, kv := range span.Attributes() {
+					if kv.Key == "gcp.vertex.agent.grounded_tool_results" {
+						tracked = append(tracked, kv.Value.AsInt64())
+					}
+				}
+			}
+			if diff := cmp.Diff(tc.wantTracked, tracked); diff != "" {
+				t.Errorf("traced grounded tool results mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCoerceToolArgs(t *testing.T) {
+	// Not parallel: the test swaps the global tracer provider.
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	type Args struct {
+		Count   int    `json:"count"`
+		Verbose bool   `json:"verbose"`
+		Name    string `json:"name"`
+	}
+	for _, tc := range []struct {
+		name          string
+		coerce        bool
+		args          map[string]any
+		want          map[string]any
+		wantCoercions []string
+	}{
+		{
+			name:          "string to int and bool",
+			coerce:        true,
+			args:          map[string]any{"count": "3", "verbose": "true", "name": "42"},
+			want:          map[string]any{"result": "42 3 true"},
+			wantCoercions: []string{"count integer", "verbose boolean"},
+		},
+		{
+			name:   "typed args are left unchanged",
+			coerce: true,
+			args:   map[string]any{"count": 3.0, "verbose": false, "name": "x"},
+			want:   map[string]any{"result": "x 3 false"},
+		},
+		{
+			name:   "non-coercible string is rejected by the tool",
+			coerce: true,
+			args:   map[string]any{"count": "many", "verbose": "yes", "name": "x"},
+		},
+		{
+			name: "no coercion by default",
+			args: map[string]any{"count": "3", "verbose": "true", "name": "x"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder.Reset()
+			describe, err := functiontool.New(functiontool.Config{
+				Name:        "describe",
+				Description: "describes its arguments",
+			}, func(_ tool.Context, args Args) (string, error) {
+				return fmt.Sprintf("%s %d %t", args.Name, args.Count, args.Verbose), nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := llmagent.New(llmagent.Config{
+				Name: "coerce_agent",
+				Model: &testutil.MockModel{Responses: []*genai.Content{
+					genai.NewContentFromFunctionCall("describe", tc.args, genai.RoleModel),
+					genai.NewContentFromText("done", genai.RoleModel),
+				}},
+				Tools: []tool.Tool{describe},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := testutil.NewTestAgentRunner(t, a)
+			parts, err := testutil.CollectParts(r.RunContentWithConfig(t, "s1", genai.NewContentFromText("go", genai.RoleUser), agent.RunConfig{CoerceToolArgs: tc.coerce}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]any
+			for _, p := range parts {
+				if p.FunctionResponse != nil {
+					got = p.FunctionResponse.Response
+				}
+			}
+			if tc.want == nil {
+				if code, _, ok := tool.ResponseError(got); !ok || code != tool.ErrorCodeInvalidArgument {
+					t.Errorf("function response = %v, want a %s error response", got, tool.ErrorCodeInvalidArgument)
+				}
+			} else if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("function response mismatch (-want +got):\n%s", diff)
+			}
+
+			var coercions []string
+			for _, span := range recorder.Ended() {
+				for _, event := range span.Events() {
+					if event.Name != "tool_arg_coerced" {
+						continue
+					}
+					attrs := make(map[string]string)
+					for _, kv := range event.Attributes {
+						attrs[string(kv.Key)] = kv.Value.AsString()
+					}
+					coercions = append(coercions, attrs["gcp.vertex.agent.tool_arg_path"]+" "+attrs["gcp.vertex.agent.tool_arg_type"])
+				}
+			}
+			slices.Sort(coercions)
+			if diff := cmp.Diff(tc.wantCoercions, coercions); diff != "" {
+				t.Errorf("coercion span events mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// noSeedModel is a model whose backend does not support seeds.
+type noSeedModel struct {
+	*testutil.MockModel
+}
+
+func (noSeedModel) SupportsGenerationParam(param string) bool {
+	return param != model.GenerationParamSeed
+}
+
+func TestGenerationOverrides(t *testing.T) {
+	// Not parallel: the test swaps the global tracer provider.
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	for _, tc := range []struct {
+		name      string
+		noSeed    bool
+		overrides *agent.GenerationOverrides
+		want      *genai.GenerateContentConfig
+		wantAttrs map[string]any
+		wantErr   error
+	}{
+		{
+			name:      "overrides reach the request",
+			overrides: &agent.GenerationOverrides{Seed: genai.Ptr[int32](42), Temperature: genai.Ptr[float32](0)},
+			want:      &genai.GenerateContentConfig{Seed: genai.Ptr[int32](42), Temperature: genai.Ptr[float32](0), TopP: genai.Ptr[float32](0.5), MaxOutputTokens: 100},
+			wantAttrs: map[string]any{
+				"gcp.vertex.agent.generation_seed":        int64(42),
+				"gcp.vertex.agent.generation_temperature": 0.0,
+				"gcp.vertex.agent.generation_top_p":       0.5,
+			},
+		},
+		{
+			name:      "no overrides keep the agent config",
+			want:      &genai.GenerateContentConfig{Temperature: genai.Ptr[float32](0.75), TopP: genai.Ptr[float32](0.5), MaxOutputTokens: 100},
+			wantAttrs: map[string]any{},
+		},
+		{
+			name:      "unsupported overrides are dropped",
+			noSeed:    true,
+			overrides: &agent.GenerationOverrides{Seed: genai.Ptr[int32](42), TopP: genai.Ptr[float32](1)},
+			want:      &genai.GenerateContentConfig{Temperature: genai.Ptr[float32](0.75), TopP: genai.Ptr[float32](1), MaxOutputTokens: 100},
+			wantAttrs: map[string]any{
+				"gcp.vertex.agent.generation_temperature":       0.75,
+				"gcp.vertex.agent.generation_top_p":             1.0,
+				"gcp.vertex.agent.generation_overrides_dropped": []string{"seed"},
+			},
+		},
+		{
+			name:      "out of range temperature is rejected",
+			overrides: &agent.GenerationOverrides{Temperature: genai.Ptr[float32](2.5)},
+			wantErr:   runner.ErrInvalidGenerationOverrides,
+		},
+		{
+			name:      "out of range top_p is rejected",
+			overrides: &agent.GenerationOverrides{TopP: genai.Ptr[float32](-0.1)},
+			wantErr:   runner.ErrInvalidGenerationOverrides,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder.Reset()
+			mock := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("done", genai.RoleModel)}}
+			var m model.LLM = mock
+			if tc.noSeed {
+				m = noSeedModel{mock}
+			}
+			agentConfig := &genai.GenerateContentConfig{Temperature: genai.Ptr[float32](0.75), TopP: genai.Ptr[float32](0.5), MaxOutputTokens: 100}
+			a, err := llmagent.New(llmagent.Config{
+				Name:                  "seeded_agent",
+				Model:                 m,
+				GenerateContentConfig: agentConfig,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := testutil.NewTestAgentRunner(t, a)
+			_, err = testutil.CollectParts(r.RunContentWithConfig(t, "s1", genai.NewContentFromText("go", genai.RoleUser), agent.RunConfig{GenerationOverrides: tc.overrides}))
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("run error = %v, want %v", err, tc.wantErr)
+				}
+				if len(mock.Requests) != 0 {
+					t.Errorf("model called %d times after a rejected run, want 0", len(mock.Requests))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(mock.Requests) != 1 {
+				t.Fatalf("model called %d times, want 1", len(mock.Requests))
+			}
+			if diff := cmp.Diff(tc.want, mock.Requests[0].Config); diff != "" {
+				t.Errorf("request config mismatch (-want +got):\n%s", diff)
+			}
+			wantAgentConfig := &genai.GenerateContentConfig{Temperature: genai.Ptr[float32](0.75), TopP: genai.Ptr[float32](0.5), MaxOutputTokens: 100}
+			if diff := cmp.Diff(wantAgentConfig, agentConfig); diff != "" {
+				t.Errorf("agent config modified (-want +got):\n%s", diff)
+			}
+
+			got := make(map[string]any)
+			for _, span := range recorder.Ended() {
+				if span.Name() != "call_llm" {
+					continue
+				}
+				for _, kv := range span.Attributes() {
+					if strings.HasPrefix(string(kv.Key), "gcp.vertex.agent.generation_") {
+						got[string(kv.Key)] = kv.Value.AsInterface()
+					}
+				}
+			}
+			if diff := cmp.Diff(tc.wantAttrs, got); diff != "" {
+				t.Errorf("call_llm span attributes mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"slices"
+
+	"google.golang.org/adk/session"
+)
+
+// persistFunc returns whether events of the given kinds, see
+// session.KindOf, are stored, or nil if all events are.
+func persistFunc(kinds []session.EventKind) func(*session.Event) bool {
+	if len(kinds) == 0 {
+		return nil
+	}
+	kinds = slices.Clone(kinds)
+	return func(ev *session.Event) bool {
+		return slices.Contains(kinds, session.KindOf(ev))
+	}
+}
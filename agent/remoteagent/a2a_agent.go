@@ -253,7 +253,7 @@ func resolveAgentCard(ctx agent.InvocationContext, cfg A2AConfig) (*a2a.AgentCar
 }
 
 func newMessage(ctx agent.InvocationContext) (*a2a.Message, error) {
-	events := ctx.Session().Events()
+	events := session.ReconcileInterimEvents(ctx.Session().Events())
 	if userFnCall := getUserFunctionCallAt(events, events.Len()-1); userFnCall != nil {
 		event := userFnCall.event
 		parts, err := adka2a.ToA2AParts(event.Content.Parts, event.LongRunningToolIDs)
@@ -14,6 +14,12 @@
 
 package agent
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
 // StreamingMode defines the streaming mode for agent execution.
 type StreamingMode string
 
@@ -32,4 +38,137 @@ type RunConfig struct {
 	// If true, ADK runner will save each part of the user input that is a blob
 	// (e.g., images, files) as an artifact.
 	SaveInputBlobsAsArtifacts bool
+	// ToolStub, if set, is consulted before a function tool is run. If it
+	// returns ok, result is used as the response of the tool call and the
+	// tool itself is not run. Tool callbacks are still invoked.
+	ToolStub func(name string, args map[string]any) (result map[string]any, ok bool)
+	// ValidateToolResponses checks the response of each tool declaring an
+	// output schema against it, e.g. in debug or test runs. A response that
+	// does not match is replaced with an error response.
+	ValidateToolResponses bool
+	// CoerceToolArgs coerces the string arguments of tool calls that encode
+	// a number or a boolean, e.g. "5" or "true", to the type declared by the
+	// input schema of the tool before running it. Each coercion is recorded
+	// as a tool_arg_coerced event of the span of the tool call. Arguments
+	// that cannot be coerced are passed unchanged.
+	CoerceToolArgs bool
+	// TracerServiceName names the tracers of the spans of the run, unless
+	// overridden by the agent. Defaults to gcp.vertex.agent.
+	TracerServiceName string
+	// Labels tag the run, e.g. with an experiment ID or a customer tier.
+	// They are recorded on every span of the run as
+	// gcp.vertex.agent.label.<key> attributes and on every event of the run.
+	// Each distinct key and value becomes a distinct attribute value in the
+	// tracing backend, so callers should keep their cardinality low.
+	Labels map[string]string
+	// DisableTelemetry creates no spans for the run, e.g. for high-QPS or
+	// privacy-sensitive runs, instead of only exporting them nowhere.
+	// Events are still tagged with the labels of the run.
+	DisableTelemetry bool
+	// Timeout overrides the run timeout of the runner if positive. A negative
+	// Timeout removes the deadline of the run.
+	Timeout time.Duration
+	// PhaseEvents makes LLM agents yield phase events, e.g. before calling
+	// the model or a tool, so that clients can show the progress of the run.
+	// See session.Phase for their lifecycle.
+	PhaseEvents bool
+	// MaxTransferDepth limits the number of successive agent transfers of an
+	// invocation, e.g. to cut a transfer cycle between two agents. Zero uses
+	// DefaultMaxTransferDepth; a negative value removes the limit.
+	MaxTransferDepth int
+	// ModelOverride names the model that replaces the configured model of the
+	// agent of the run, e.g. for A/B tests. It must be one of the
+	// ModelOverrides of the runner; other names are rejected.
+	ModelOverride string
+	// ModelOverrideSubAgents extends the ModelOverride to all the LLM agents
+	// of the run, e.g. the agents that the agent of the run transfers to.
+	ModelOverrideSubAgents bool
+	// RetryBudget limits the total number of retries of the model and tool
+	// calls of an invocation, shared by all its agents, so that per-call
+	// retries cannot compound. It is consumed by the retry package. Zero
+	// means no limit; a negative value allows no retries.
+	RetryBudget int
+	// InterimCommitEvery makes the runner commit the text streamed so far of
+	// a response to the session every InterimCommitEvery partial events, so
+	// that a failure during a long generation does not lose all of it. The
+	// commits are interim events, superseded by the final event of the
+	// response; see session.Event.Interim. Zero or a negative value commits
+	// nothing until the response completes.
+	InterimCommitEvery int
+	// GenerationOverrides overrides parameters of the generation config of
+	// the model requests of the run, e.g. a fixed seed and a zero
+	// temperature to reproduce a bug. The configs of the agents are not
+	// modified.
+	GenerationOverrides *GenerationOverrides
+	// MaxConcurrentTools limits the number of tool calls of the run executing
+	// at the same time, shared by all its agents, e.g. so that a turn with
+	// many function calls cannot overwhelm the backend of the tools. It is
+	// distinct from the limits of the agent pool of the runner on concurrent
+	// runs. With a limit of one, the function calls of a turn execute one at
+	// a time in the order of the response; with a larger limit they execute
+	// concurrently, so tools and tool callbacks must be safe for concurrent
+	// use. Zero uses DefaultMaxConcurrentTools; a negative value removes the
+	// limit.
+	MaxConcurrentTools int
+}
+
+// GenerationOverrides are generation parameters overriding those of the
+// agents of a run. Nil fields keep the values of the agents. Overrides of
+// parameters that the model of an agent does not support are dropped, see
+// model.GenerationParamSupporter.
+type GenerationOverrides struct {
+	Seed *int32
+	// Temperature must be in [0, 2].
+	Temperature *float32
+	// TopP must be in [0, 1].
+	TopP *float32
+}
+
+// Validate checks that the overrides are in range.
+func (o *GenerationOverrides) Validate() error {
+	if o == nil {
+		return nil
+	}
+	if t := o.Temperature; t != nil && !(*t >= 0 && *t <= 2) {
+		return fmt.Errorf("temperature must be in [0, 2], got %v", *t)
+	}
+	if p := o.TopP; p != nil && !(*p >= 0 && *p <= 1) {
+		return fmt.Errorf("top_p must be in [0, 1], got %v", *p)
+	}
+	return nil
+}
+
+// DefaultMaxTransferDepth is the maximum number of successive agent transfers
+// of an invocation if RunConfig.MaxTransferDepth is zero.
+const DefaultMaxTransferDepth = 10
+
+// DefaultMaxConcurrentTools is the maximum number of tool calls of a run
+// executing at the same time if RunConfig.MaxConcurrentTools is zero. Tool
+// calls execute sequentially by default; concurrency is opt-in.
+const DefaultMaxConcurrentTools = 1
+
+// TransferDepthExceededErrorCode is the error code of the event ending an
+// invocation whose transfers exceeded the maximum transfer depth.
+const TransferDepthExceededErrorCode = "TRANSFER_DEPTH_EXCEEDED"
+
+// ErrTransferDepthExceeded is matched by errors.Is for a
+// [*TransferDepthError].
+var ErrTransferDepthExceeded = errors.New("maximum agent transfer depth exceeded")
+
+// TransferDepthError is returned instead of transferring to an agent when
+// the transfer would exceed the maximum transfer depth of the invocation.
+type TransferDepthError struct {
+	// From and To are the names of the agents of the cut transfer.
+	From, To string
+	// MaxDepth is the maximum transfer depth of the invocation.
+	MaxDepth int
+}
+
+func (e *TransferDepthError) Error() string {
+	return fmt.Sprintf("%v: transfer from %q to %q cut after %d transfers", ErrTransferDepthExceeded, e.From, e.To, e.MaxDepth)
+}
+
+// Is reports whether target is ErrTransferDepthExceeded.
+func (e *TransferDepthError) Is(target error) bool {
+	return target == ErrTransferDepthExceeded
 }
@@ -22,6 +22,9 @@ import (
 
 	"google.golang.org/adk/agent"
 	agentinternal "google.golang.org/adk/internal/agent"
+	"google.golang.org/adk/internal/clock"
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/telemetry"
 	"google.golang.org/adk/session"
 )
 
@@ -33,6 +36,13 @@ type Config struct {
 	// If MaxIterations == 0, then LoopAgent runs indefinitely or until any
 	// sub-agent escalates.
 	MaxIterations uint
+
+	// Until is an optional termination condition, called after each
+	// iteration with the number of iterations done so far. The loop stops
+	// as soon as it returns true. The state written by the sub-agents, e.g.
+	// with OutputKey, is carried from one iteration to the next and can be
+	// read from ctx.
+	Until func(ctx agent.ReadonlyContext, iteration uint) bool
 }
 
 // New creates a LoopAgent.
@@ -42,6 +52,9 @@ type Config struct {
 //
 // Use the LoopAgent when your workflow involves repetition or iterative
 // refinement, such as like revising code.
+//
+// The run of the LoopAgent is traced as an invoke_agent span, recording the
+// number of iterations, under which the spans of the sub-agents nest.
 func New(cfg Config) (agent.Agent, error) {
 	if cfg.AgentConfig.Run != nil {
 		return nil, fmt.Errorf("LoopAgent doesn't allow custom Run implementations")
@@ -49,6 +62,7 @@ func New(cfg Config) (agent.Agent, error) {
 
 	loopAgentImpl := &loopAgent{
 		maxIterations: cfg.MaxIterations,
+		until:         cfg.Until,
 	}
 	cfg.AgentConfig.Run = loopAgentImpl.Run
 
@@ -70,16 +84,26 @@ func New(cfg Config) (agent.Agent, error) {
 
 type loopAgent struct {
 	maxIterations uint
+	until         func(agent.ReadonlyContext, uint) bool
 }
 
 func (a *loopAgent) Run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
 	count := a.maxIterations
 
 	return func(yield func(*session.Event, error) bool) {
+		start := clock.Now(ctx)
+		spans := telemetry.StartTrace(ctx, "invoke_agent "+ctx.Agent().Name())
+		var iterations uint
+		defer func() {
+			telemetry.TraceLoopInvocation(spans, ctx.Agent().Name(), iterations, clock.Since(ctx, start))
+		}()
+		loopCtx := icontext.WithContext(ctx, telemetry.ContextWithSpan(ctx, spans))
+
 		for {
+			iterations++
 			shouldExit := false
 			for _, subAgent := range ctx.Agent().SubAgents() {
-				for event, err := range subAgent.Run(ctx) {
+				for event, err := range subAgent.Run(loopCtx) {
 					// TODO: ensure consistency -- if there's an error, return and close iterator, verify everywhere in ADK.
 					if !yield(event, err) {
 						return
@@ -94,6 +118,9 @@ func (a *loopAgent) Run(ctx agent.InvocationContext) iter.Seq2[*session.Event, e
 				}
 			}
 
+			if a.until != nil && a.until(icontext.NewReadonlyContext(ctx), iterations) {
+				return
+			}
 			if count > 0 {
 				count--
 				if count == 0 {
@@ -22,11 +22,15 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
 	"google.golang.org/adk/agent/workflowagents/loopagent"
+	"google.golang.org/adk/internal/testutil"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/runner"
 	"google.golang.org/adk/session"
@@ -55,6 +59,7 @@ func TestNewLoopAgent(t *testing.T) {
 			wantEvents: []*session.Event{
 				{
 					Author: "custom_agent_0",
+					Kind:   session.EventKindModelText,
 					LLMResponse: model.LLMResponse{
 						Content: &genai.Content{
 							Parts: []*genai.Part{
@@ -75,6 +80,7 @@ func TestNewLoopAgent(t *testing.T) {
 			wantEvents: []*session.Event{
 				{
 					Author: "custom_agent_0",
+					Kind:   session.EventKindModelText,
 					LLMResponse: model.LLMResponse{
 						Content: &genai.Content{
 							Parts: []*genai.Part{
@@ -95,6 +101,7 @@ func TestNewLoopAgent(t *testing.T) {
 			wantEvents: []*session.Event{
 				{
 					Author: "custom_agent_0",
+					Kind:   session.EventKindModelText,
 					LLMResponse: model.LLMResponse{
 						Content: &genai.Content{
 							Parts: []*genai.Part{
@@ -106,6 +113,7 @@ func TestNewLoopAgent(t *testing.T) {
 				},
 				{
 					Author: "custom_agent_1",
+					Kind:   session.EventKindModelText,
 					LLMResponse: model.LLMResponse{
 						Content: &genai.Content{
 							Parts: []*genai.Part{
@@ -126,12 +134,14 @@ func TestNewLoopAgent(t *testing.T) {
 			wantEvents: []*session.Event{
 				{
 					Author: "custom_agent_0",
+					Kind:   session.EventKindToolCall,
 					LLMResponse: model.LLMResponse{
 						Content: genai.NewContentFromFunctionCall("exampleFunction", make(map[string]any), genai.RoleModel),
 					},
 				},
 				{
 					Author: "custom_agent_0",
+					Kind:   session.EventKindToolResponse,
 					LLMResponse: model.LLMResponse{
 						Content: genai.NewContentFromFunctionResponse("exampleFunction", make(map[string]any), genai.RoleUser),
 					},
@@ -141,6 +151,7 @@ func TestNewLoopAgent(t *testing.T) {
 				},
 				{
 					Author: "custom_agent_0",
+					Kind:   session.EventKindModelText,
 					LLMResponse: model.LLMResponse{
 						Content: &genai.Content{
 							Parts: []*genai.Part{
@@ -161,12 +172,14 @@ func TestNewLoopAgent(t *testing.T) {
 			wantEvents: []*session.Event{
 				{
 					Author: "custom_agent_0",
+					Kind:   session.EventKindToolCall,
 					LLMResponse: model.LLMResponse{
 						Content: genai.NewContentFromFunctionCall("exampleFunction", make(map[string]any), genai.RoleModel),
 					},
 				},
 				{
 					Author: "custom_agent_0",
+					Kind:   session.EventKindToolResponse,
 					LLMResponse: model.LLMResponse{
 						Content: genai.NewContentFromFunctionResponse("exampleFunction", make(map[string]any), genai.RoleUser),
 					},
@@ -249,6 +262,78 @@ func TestNewLoopAgent(t *testing.T) {
 	}
 }
 
+func TestLoopUntil(t *testing.T) {
+	// Not parallel: the test swaps the global tracer provider.
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	// The refiner raises the score written by the previous iteration.
+	refiner, err := agent.New(agent.Config{
+		Name: "refiner",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				score := 0
+				if v, err := ctx.Session().State().Get("score"); err == nil {
+					score = v.(int)
+				}
+				event := session.NewEvent(ctx.InvocationID())
+				event.Content = genai.NewContentFromText(fmt.Sprintf("score %d", score+1), genai.RoleModel)
+				event.Actions.StateDelta["score"] = score + 1
+				yield(event, nil)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotIterations []uint
+	loop, err := loopagent.New(loopagent.Config{
+		AgentConfig:   agent.Config{Name: "refine_loop", SubAgents: []agent.Agent{refiner}},
+		MaxIterations: 10,
+		Until: func(ctx agent.ReadonlyContext, iteration uint) bool {
+			gotIterations = append(gotIterations, iteration)
+			score, err := ctx.ReadonlyState().Get("score")
+			return err == nil && score.(int) >= 3
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	texts, err := testutil.CollectTextParts(testutil.NewTestAgentRunner(t, loop).Run(t, "s1", "refine"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"score 1", "score 2", "score 3"}, texts); diff != "" {
+		t.Errorf("events mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]uint{1, 2, 3}, gotIterations); diff != "" {
+		t.Errorf("Until() iterations mismatch (-want +got):\n%s", diff)
+	}
+
+	var loopSpan sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "invoke_agent refine_loop" {
+			loopSpan = span
+		}
+	}
+	if loopSpan == nil {
+		t.Fatal("missing span of the loop agent")
+	}
+	for _, kv := range loopSpan.Attributes() {
+		if kv.Key == "gcp.vertex.agent.loop_iterations" {
+			if got := kv.Value.AsInt64(); got != 3 {
+				t.Errorf("loop_iterations = %d, want 3", got)
+			}
+			return
+		}
+	}
+	t.Errorf("span of the loop agent has no loop_iterations attribute")
+}
+
 func newCustomAgent(t *testing.T, id int) agent.Agent {
 	t.Helper()
 
@@ -16,14 +16,18 @@
 package parallelagent
 
 import (
+	"context"
 	"fmt"
 	"iter"
 
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
 	agentinternal "google.golang.org/adk/internal/agent"
+	"google.golang.org/adk/internal/clock"
 	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/telemetry"
 	"google.golang.org/adk/session"
 )
 
@@ -31,6 +35,34 @@ import (
 type Config struct {
 	// Basic agent setup.
 	AgentConfig agent.Config
+
+	// MaxConcurrency is the maximum number of sub-agents running at the same
+	// time. If MaxConcurrency <= 0, all sub-agents run at once.
+	MaxConcurrency int
+
+	// BestEffort controls what happens when a sub-agent fails. By default
+	// the ParallelAgent fails fast: the first error cancels the other
+	// sub-agents, which then report the cancellation. With BestEffort, the
+	// error is reported and the other sub-agents run to completion.
+	BestEffort bool
+
+	// Merge is an optional step run once all sub-agents are done. It gets
+	// one Result per sub-agent, in the order of the sub-agents, and its
+	// content is emitted as the final response of the ParallelAgent. Merge
+	// is not called if the ParallelAgent failed fast.
+	Merge func(ctx agent.InvocationContext, results []Result) (*genai.Content, error)
+}
+
+// Result is the outcome of the run of a sub-agent, as passed to
+// [Config.Merge].
+type Result struct {
+	// Agent is the name of the sub-agent.
+	Agent string
+	// Content is the content of the last final response of the sub-agent,
+	// or nil if there was none.
+	Content *genai.Content
+	// Err is the first error reported by the sub-agent, if any.
+	Err error
 }
 
 // New creates a ParallelAgent.
@@ -41,14 +73,24 @@ type Config struct {
 // attempts on a single task, such as:
 // - Running different algorithms simultaneously.
 // - Generating multiple responses for review by a subsequent evaluation agent.
+//
+// The run of the ParallelAgent is traced as an invoke_agent span with one
+// nested invoke_agent span per sub-agent, under which the spans of the
+// sub-agent nest.
 func New(cfg Config) (agent.Agent, error) {
 	if cfg.AgentConfig.Run != nil {
 		return nil, fmt.Errorf("ParallelAgent doesn't allow custom Run implementations")
 	}
 
-	cfg.AgentConfig.Run = run
+	p := &parallelAgent{
+		maxConcurrency: cfg.MaxConcurrency,
+		bestEffort:     cfg.BestEffort,
+		merge:          cfg.Merge,
+	}
+	agentConfig := cfg.AgentConfig
+	agentConfig.Run = p.run
 
-	parallelAgent, err := agent.New(cfg.AgentConfig)
+	parallelAgent, err := agent.New(agentConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -64,70 +106,134 @@ func New(cfg Config) (agent.Agent, error) {
 	return parallelAgent, nil
 }
 
-func run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
-	curAgent := ctx.Agent()
-
-	var (
-		errGroup, errGroupCtx = errgroup.WithContext(ctx)
-		doneChan              = make(chan bool)
-		resultsChan           = make(chan result)
-	)
-
-	for _, sa := range ctx.Agent().SubAgents() {
-		branch := fmt.Sprintf("%s.%s", curAgent.Name(), sa.Name())
-		if ctx.Branch() != "" {
-			branch = fmt.Sprintf("%s.%s", ctx.Branch(), branch)
-		}
-		subAgent := sa
-		errGroup.Go(func() error {
-			subCtx := icontext.NewInvocationContext(errGroupCtx, icontext.InvocationContextParams{
-				Artifacts:   ctx.Artifacts(),
-				Memory:      ctx.Memory(),
-				Session:     ctx.Session(),
-				Branch:      branch,
-				Agent:       subAgent,
-				UserContent: ctx.UserContent(),
-				RunConfig:   ctx.RunConfig(),
-			})
-
-			if err := runSubAgent(subCtx, subAgent, resultsChan, doneChan); err != nil {
-				return fmt.Errorf("failed to run sub-agent %q: %w", subAgent.Name(), err)
-			}
-
-			return nil
-		})
-	}
-
-	go func() {
-		_ = errGroup.Wait() // this error is already sent to the user via iterator
-		close(resultsChan)
-	}()
+type parallelAgent struct {
+	maxConcurrency int
+	bestEffort     bool
+	merge          func(agent.InvocationContext, []Result) (*genai.Content, error)
+}
 
+func (p *parallelAgent) run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
 	return func(yield func(*session.Event, error) bool) {
+		curAgent := ctx.Agent()
+		subAgents := curAgent.SubAgents()
+
+		start := clock.Now(ctx)
+		spans := telemetry.StartTrace(ctx, "invoke_agent "+curAgent.Name())
+		defer func() {
+			telemetry.TraceAgentInvocation(spans, curAgent.Name(), -1, 0, clock.Since(ctx, start))
+		}()
+
+		var (
+			errGroup    = &errgroup.Group{}
+			groupCtx    = telemetry.ContextWithSpan(ctx, spans)
+			doneChan    = make(chan bool)
+			resultsChan = make(chan result)
+		)
+		if !p.bestEffort {
+			errGroup, groupCtx = errgroup.WithContext(groupCtx)
+		}
+		if p.maxConcurrency > 0 {
+			errGroup.SetLimit(p.maxConcurrency)
+		}
 		defer close(doneChan)
 
+		go func() {
+			for i, subAgent := range subAgents {
+				errGroup.Go(func() error {
+					select {
+					case <-doneChan:
+						return nil
+					default:
+					}
+					if err := p.runSubAgent(ctx, groupCtx, i, subAgent, resultsChan, doneChan); err != nil {
+						return fmt.Errorf("failed to run sub-agent %q: %w", subAgent.Name(), err)
+					}
+					return nil
+				})
+			}
+			_ = errGroup.Wait() // this error is already sent to the user via iterator
+			close(resultsChan)
+		}()
+
+		results := make([]Result, len(subAgents))
+		for i, subAgent := range subAgents {
+			results[i].Agent = subAgent.Name()
+		}
+		failed := false
 		for res := range resultsChan {
+			r := &results[res.index]
+			if res.err != nil {
+				failed = true
+				if r.Err == nil {
+					r.Err = res.err
+				}
+			} else if res.event != nil && res.event.IsFinalResponse() {
+				r.Content = res.event.Content
+			}
 			if !yield(res.event, res.err) {
-				break
+				return
 			}
 		}
+
+		if p.merge == nil || (failed && !p.bestEffort) {
+			return
+		}
+		content, err := p.merge(ctx, results)
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to merge the results of the sub-agents: %w", err))
+			return
+		}
+		event := session.NewEventContext(ctx, ctx.InvocationID())
+		event.Author = curAgent.Name()
+		event.Branch = ctx.Branch()
+		event.Content = content
+		yield(event, nil)
 	}
 }
 
-func runSubAgent(ctx agent.InvocationContext, agent agent.Agent, results chan<- result, done <-chan bool) error {
-	for event, err := range agent.Run(ctx) {
+// runSubAgent runs the sub-agent at the given index in an isolated branch
+// and sends its events to results. base carries the spans of the
+// ParallelAgent and, when failing fast, is cancelled by the first error.
+func (p *parallelAgent) runSubAgent(ctx agent.InvocationContext, base context.Context, index int, subAgent agent.Agent, results chan<- result, done <-chan bool) error {
+	branch := fmt.Sprintf("%s.%s", ctx.Agent().Name(), subAgent.Name())
+	if ctx.Branch() != "" {
+		branch = fmt.Sprintf("%s.%s", ctx.Branch(), branch)
+	}
+
+	start := clock.Now(ctx)
+	spans := telemetry.StartTrace(base, "invoke_agent "+subAgent.Name())
+	defer func() {
+		telemetry.TraceAgentInvocation(spans, subAgent.Name(), -1, 0, clock.Since(ctx, start))
+	}()
+
+	subCtx := icontext.NewInvocationContext(telemetry.ContextWithSpan(base, spans), icontext.InvocationContextParams{
+		Artifacts:   ctx.Artifacts(),
+		Memory:      ctx.Memory(),
+		Session:     ctx.Session(),
+		Branch:      branch,
+		Agent:       subAgent,
+		UserContent: ctx.UserContent(),
+		RunConfig:   ctx.RunConfig(),
+
+		HistoryPolicy: agent.HistoryPolicyOf(ctx),
+		TransferDepth: agent.TransferDepthOf(ctx),
+	})
+
+	for event, err := range subAgent.Run(subCtx) {
 		select {
 		case <-done:
 			return nil
-		case <-ctx.Done():
+		case <-subCtx.Done():
 			select {
 			case <-done:
 			case results <- result{
-				err: ctx.Err(),
+				index: index,
+				err:   subCtx.Err(),
 			}:
 			}
-			return ctx.Err()
+			return subCtx.Err()
 		case results <- result{
+			index: index,
 			event: event,
 			err:   err,
 		}:
@@ -140,6 +246,7 @@ func runSubAgent(ctx agent.InvocationContext, agent agent.Agent, results chan<-
 }
 
 type result struct {
+	index int
 	event *session.Event
 	err   error
 }
@@ -16,19 +16,27 @@ package parallelagent_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"iter"
+	"maps"
 	rand "math/rand/v2"
 	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/workflowagents/loopagent"
 	"google.golang.org/adk/agent/workflowagents/parallelagent"
+	"google.golang.org/adk/internal/testutil"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/runner"
 	"google.golang.org/adk/session"
@@ -54,6 +62,7 @@ func TestNewParallelAgent(t *testing.T) {
 					for responseCount := 1; responseCount <= 2; responseCount++ {
 						res = append(res, &session.Event{
 							Author: fmt.Sprintf("sub%d", agentID),
+							Kind:   session.EventKindModelText,
 							LLMResponse: model.LLMResponse{
 								Content: &genai.Content{
 									Parts: []*genai.Part{
@@ -161,6 +170,131 @@ func TestNewParallelAgent(t *testing.T) {
 	}
 }
 
+func TestMaxConcurrency(t *testing.T) {
+	var active, maxActive atomic.Int32
+	var subAgents []agent.Agent
+	for i := range 5 {
+		subAgents = append(subAgents, must(agent.New(agent.Config{
+			Name: fmt.Sprintf("sub%d", i),
+			Run: func(agent.InvocationContext) iter.Seq2[*session.Event, error] {
+				return func(yield func(*session.Event, error) bool) {
+					n := active.Add(1)
+					for {
+						m := maxActive.Load()
+						if n <= m || maxActive.CompareAndSwap(m, n) {
+							break
+						}
+					}
+					time.Sleep(5 * time.Millisecond)
+					active.Add(-1)
+					yield(&session.Event{LLMResponse: model.LLMResponse{
+						Content: genai.NewContentFromText("done", genai.RoleModel),
+					}}, nil)
+				}
+			},
+		})))
+	}
+	a, err := parallelagent.New(parallelagent.Config{
+		AgentConfig:    agent.Config{Name: "fanout", SubAgents: subAgents},
+		MaxConcurrency: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "s1", "go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 5 {
+		t.Errorf("got %d events, want 5", len(events))
+	}
+	if got := maxActive.Load(); got > 2 {
+		t.Errorf("%d sub-agents ran at the same time, want at most 2", got)
+	}
+}
+
+func TestBestEffortMerge(t *testing.T) {
+	// Not parallel: the test swaps the global tracer provider.
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	agentErr := errors.New("analysis failed")
+	a, err := parallelagent.New(parallelagent.Config{
+		AgentConfig: agent.Config{
+			Name: "fanout",
+			SubAgents: []agent.Agent{
+				must(agent.New(agent.Config{Name: "sub1", Run: customRun(1, nil)})),
+				must(agent.New(agent.Config{Name: "error_agent", Run: customRun(-1, agentErr)})),
+				must(agent.New(agent.Config{Name: "sub2", Run: customRun(2, nil)})),
+			},
+		},
+		BestEffort: true,
+		Merge: func(ctx agent.InvocationContext, results []parallelagent.Result) (*genai.Content, error) {
+			var parts []string
+			for _, r := range results {
+				if r.Err != nil {
+					parts = append(parts, r.Agent+" failed")
+					continue
+				}
+				parts = append(parts, r.Agent+": "+r.Content.Parts[0].Text)
+			}
+			return genai.NewContentFromText(strings.Join(parts, "; "), genai.RoleModel), nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var texts []string
+	var gotErrs []error
+	var last *session.Event
+	for event, err := range testutil.NewTestAgentRunner(t, a).Run(t, "s1", "go") {
+		if err != nil {
+			gotErrs = append(gotErrs, err)
+			continue
+		}
+		texts = append(texts, event.Author+": "+event.Content.Parts[0].Text)
+		last = event
+	}
+	if len(gotErrs) != 1 || !errors.Is(gotErrs[0], agentErr) {
+		t.Errorf("got errors %v, want only %v", gotErrs, agentErr)
+	}
+	if len(texts) != 3 {
+		t.Fatalf("got events %q, want the two results and the merge", texts)
+	}
+	if diff := cmp.Diff([]string{"sub1: hello 1", "sub2: hello 2"}, slices.Sorted(slices.Values(texts[:2]))); diff != "" {
+		t.Errorf("sub-agent events mismatch (-want +got):\n%s", diff)
+	}
+	if want := "fanout: sub1: hello 1; error_agent failed; sub2: hello 2"; texts[2] != want {
+		t.Errorf("merged event = %q, want %q", texts[2], want)
+	}
+	if !last.IsFinalResponse() {
+		t.Errorf("merged event is not a final response")
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	parent := spans["invoke_agent fanout"]
+	if parent == nil {
+		t.Fatalf("missing span of the parallel agent in %v", slices.Collect(maps.Keys(spans)))
+	}
+	for _, name := range []string{"sub1", "error_agent", "sub2"} {
+		span := spans["invoke_agent "+name]
+		if span == nil {
+			t.Errorf("missing span of sub-agent %q", name)
+			continue
+		}
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span of sub-agent %q is not nested under the parallel agent", name)
+		}
+	}
+}
+
 // newParallelAgent creates parallel agent with 2 subagents emitting maxIterations events or infinitely if maxIterations==0.
 func newParallelAgent(t *testing.T, maxIterations uint, numSubAgents int, agentErr error) agent.Agent {
 	var subAgents []agent.Agent
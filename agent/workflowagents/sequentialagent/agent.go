@@ -17,25 +17,42 @@ package sequentialagent
 
 import (
 	"fmt"
+	"iter"
 
 	"google.golang.org/adk/agent"
-	"google.golang.org/adk/agent/workflowagents/loopagent"
 	agentinternal "google.golang.org/adk/internal/agent"
+	"google.golang.org/adk/internal/clock"
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/session"
 )
 
 // New creates a SequentialAgent.
 //
-// SequentialAgent executes its sub-agents once, in the order they are listed.
+// SequentialAgent executes its sub-agents once, in the order they are listed,
+// as a fixed pipeline: no model decides which agent runs next. Each stage
+// sees the events of the previous stages in the session, and LLM sub-agents
+// can hand over results explicitly with OutputKey and a {key} placeholder in
+// the instruction of a later stage. The result of the pipeline is the final
+// response of its last stage. The pipeline stops early if a stage escalates
+// or ends the invocation.
+//
+// The run of the pipeline is traced as an invoke_agent span with one nested
+// invoke_agent span per stage, under which the spans of the stage nest.
 //
 // Use the SequentialAgent when you want the execution to occur in a fixed,
 // strict order.
 func New(cfg Config) (agent.Agent, error) {
-	sequentialAgent, err := loopagent.New(loopagent.Config{
-		AgentConfig:   cfg.AgentConfig,
-		MaxIterations: 1,
-	})
+	if cfg.AgentConfig.Run != nil {
+		return nil, fmt.Errorf("SequentialAgent doesn't allow custom Run implementations")
+	}
+
+	agentConfig := cfg.AgentConfig
+	agentConfig.Run = run
+
+	sequentialAgent, err := agent.New(agentConfig)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to create base agent: %w", err)
 	}
 
 	internalAgent, ok := sequentialAgent.(agentinternal.Agent)
@@ -54,3 +71,39 @@ type Config struct {
 	// Basic agent setup.
 	AgentConfig agent.Config
 }
+
+func run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+	return func(yield func(*session.Event, error) bool) {
+		start := clock.Now(ctx)
+		spans := telemetry.StartTrace(ctx, "invoke_agent "+ctx.Agent().Name())
+		steps := 0
+		defer func() {
+			telemetry.TraceAgentInvocation(spans, ctx.Agent().Name(), -1, steps, clock.Since(ctx, start))
+		}()
+		pipelineCtx := telemetry.ContextWithSpan(ctx, spans)
+
+		for i, subAgent := range ctx.Agent().SubAgents() {
+			if ctx.Ended() {
+				return
+			}
+			steps++
+			stepStart := clock.Now(ctx)
+			stepSpans := telemetry.StartTrace(pipelineCtx, "invoke_agent "+subAgent.Name())
+			stepCtx := icontext.WithContext(ctx, telemetry.ContextWithSpan(pipelineCtx, stepSpans))
+			escalated, stopped := false, false
+			for event, err := range subAgent.Run(stepCtx) {
+				if !yield(event, err) {
+					stopped = true
+					break
+				}
+				if event != nil && event.Actions.Escalate {
+					escalated = true
+				}
+			}
+			telemetry.TraceAgentInvocation(stepSpans, subAgent.Name(), i, 0, clock.Since(ctx, stepStart))
+			if stopped || escalated {
+				return
+			}
+		}
+	}
+}
@@ -18,15 +18,22 @@ import (
 	"context"
 	"fmt"
 	"iter"
+	"maps"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
 	"google.golang.org/adk/agent/workflowagents/sequentialagent"
+	"google.golang.org/adk/internal/testutil"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/runner"
 	"google.golang.org/adk/session"
@@ -56,6 +63,7 @@ func TestNewSequentialAgent(t *testing.T) {
 			wantEvents: []*session.Event{
 				{
 					Author: "custom_agent_0",
+					Kind:   session.EventKindModelText,
 					LLMResponse: model.LLMResponse{
 						Content: &genai.Content{
 							Parts: []*genai.Part{
@@ -67,6 +75,7 @@ func TestNewSequentialAgent(t *testing.T) {
 				},
 				{
 					Author: "custom_agent_1",
+					Kind:   session.EventKindModelText,
 					LLMResponse: model.LLMResponse{
 						Content: &genai.Content{
 							Parts: []*genai.Part{
@@ -87,6 +96,7 @@ func TestNewSequentialAgent(t *testing.T) {
 			wantEvents: []*session.Event{
 				{
 					Author: "custom_agent_0",
+					Kind:   session.EventKindModelText,
 					LLMResponse: model.LLMResponse{
 						Content: &genai.Content{
 							Parts: []*genai.Part{
@@ -98,6 +108,7 @@ func TestNewSequentialAgent(t *testing.T) {
 				},
 				{
 					Author: "custom_agent_1",
+					Kind:   session.EventKindModelText,
 					LLMResponse: model.LLMResponse{
 						Content: &genai.Content{
 							Parts: []*genai.Part{
@@ -109,6 +120,7 @@ func TestNewSequentialAgent(t *testing.T) {
 				},
 				{
 					Author: "custom_agent_2",
+					Kind:   session.EventKindModelText,
 					LLMResponse: model.LLMResponse{
 						Content: &genai.Content{
 							Parts: []*genai.Part{
@@ -120,6 +132,7 @@ func TestNewSequentialAgent(t *testing.T) {
 				},
 				{
 					Author: "custom_agent_3",
+					Kind:   session.EventKindModelText,
 					LLMResponse: model.LLMResponse{
 						Content: &genai.Content{
 							Parts: []*genai.Part{
@@ -263,6 +276,98 @@ func TestNewSequentialAgent(t *testing.T) {
 	}
 }
 
+func TestTwoStagePipeline(t *testing.T) {
+	// Not parallel: the test swaps the global tracer provider.
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	writerModel := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("a draft", genai.RoleModel)}}
+	writer, err := llmagent.New(llmagent.Config{
+		Name:        "writer",
+		Model:       writerModel,
+		Instruction: "Write a draft.",
+		OutputKey:   "draft",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reviewerModel := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("a review", genai.RoleModel)}}
+	reviewer, err := llmagent.New(llmagent.Config{
+		Name:        "reviewer",
+		Model:       reviewerModel,
+		Instruction: "Review the draft: {draft}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline, err := sequentialagent.New(sequentialagent.Config{
+		AgentConfig: agent.Config{Name: "pipeline", SubAgents: []agent.Agent{writer, reviewer}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, pipeline).Run(t, "s1", "write about go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, ev := range events {
+		got = append(got, ev.Author+": "+ev.Content.Parts[0].Text)
+	}
+	if diff := cmp.Diff([]string{"writer: a draft", "reviewer: a review"}, got); diff != "" {
+		t.Errorf("events mismatch (-want +got):\n%s", diff)
+	}
+	if !events[len(events)-1].IsFinalResponse() {
+		t.Errorf("last event of the pipeline is not a final response")
+	}
+
+	// The second stage sees the output of the first one, both in its
+	// instruction and in its history.
+	req := reviewerModel.Requests[0]
+	if got := req.Config.SystemInstruction.Parts[0].Text; !strings.Contains(got, "Review the draft: a draft") {
+		t.Errorf("system instruction of the reviewer = %q, want the draft", got)
+	}
+	var history []string
+	for _, c := range req.Contents {
+		for _, p := range c.Parts {
+			history = append(history, p.Text)
+		}
+	}
+	if !slices.Contains(history, "write about go") || !slices.ContainsFunc(history, func(s string) bool { return strings.Contains(s, "a draft") }) {
+		t.Errorf("history of the reviewer = %q, want the user message and the draft", history)
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		name := span.Name()
+		if name == "call_llm" {
+			for _, kv := range span.Attributes() {
+				if kv.Key == "gcp.vertex.agent.agent_name" {
+					name += " " + kv.Value.AsString()
+				}
+			}
+		}
+		spans[name] = span
+	}
+	for child, parent := range map[string]string{
+		"invoke_agent writer":   "invoke_agent pipeline",
+		"invoke_agent reviewer": "invoke_agent pipeline",
+		"call_llm writer":       "invoke_agent writer",
+		"call_llm reviewer":     "invoke_agent reviewer",
+	} {
+		c, p := spans[child], spans[parent]
+		if c == nil || p == nil {
+			t.Fatalf("missing span %q or %q in %v", child, parent, slices.Collect(maps.Keys(spans)))
+		}
+		if c.Parent().SpanID() != p.SpanContext().SpanID() {
+			t.Errorf("span %q is not nested under %q", child, parent)
+		}
+	}
+}
+
 func newCustomAgent(t *testing.T, id int) agent.Agent {
 	t.Helper()
 
@@ -33,6 +33,8 @@ import (
 type apiConfig struct {
 	frontendAddress string
 	sseWriteTimeout time.Duration
+	idleTimeout     time.Duration
+	maxBodyBytes    int64
 }
 
 // apiLauncher can launch ADK REST API
@@ -52,7 +54,8 @@ func corsWithArgs(frontendAddress string) func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", frontendAddress)
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-None-Match")
+			w.Header().Set("Access-Control-Expose-Headers", "ETag")
 			if r.Method == "OPTIONS" {
 				w.WriteHeader(http.StatusOK)
 				return
@@ -71,7 +74,7 @@ func (a *apiLauncher) UserMessage(webURL string, printer func(v ...any)) {
 // SetupSubrouters adds the API router to the parent router.
 func (a *apiLauncher) SetupSubrouters(router *mux.Router, config *launcher.Config) error {
 	// Create the ADK REST API handler
-	apiHandler := adkrest.NewHandler(config, a.config.sseWriteTimeout)
+	apiHandler := adkrest.NewHandler(config, a.config.sseWriteTimeout, adkrest.WithIdleTimeout(a.config.idleTimeout), adkrest.WithMaxRequestBodyBytes(a.config.maxBodyBytes))
 
 	// Wrap it with CORS middleware
 	corsHandler := corsWithArgs(a.config.frontendAddress)(apiHandler)
@@ -112,6 +115,9 @@ func NewLauncher() weblauncher.Sublauncher {
 	fs.StringVar(&config.frontendAddress, "webui_address", "localhost:8080", "ADK WebUI address as seen from the user browser. It's used to allow CORS requests. Please specify only hostname and (optionally) port.")
 	fs.DurationVar(&config.sseWriteTimeout, "sse-write-timeout", 120*time.Second, "SSE server write timeout (i.e. '10s', '2m' - see time.ParseDuration for details) - for writing the SSE response after reading the headers & body")
 
+	fs.DurationVar(&config.idleTimeout, "idle-timeout", 0, "cancel a streaming run if no data is sent to the client for this duration (i.e. '30s', '2m'); 0 disables the timeout")
+	fs.Int64Var(&config.maxBodyBytes, "max-request-body-bytes", adkrest.DefaultMaxRequestBodyBytes, "maximum size of REST API request bodies in bytes; larger requests are rejected with 413")
+
 	return &apiLauncher{
 		config: config,
 		flags:  fs,
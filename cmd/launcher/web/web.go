@@ -29,6 +29,7 @@ import (
 	"google.golang.org/adk/cmd/launcher"
 	"google.golang.org/adk/cmd/launcher/universal"
 	"google.golang.org/adk/internal/cli/util"
+	"google.golang.org/adk/internal/clock"
 	"google.golang.org/adk/session"
 )
 
@@ -215,25 +216,27 @@ func NewLauncher(sublaunchers ...Sublauncher) launcher.SubLauncher {
 	}
 }
 
-// logger is a middleware that logs the HTTP method, request URI, and the time taken to process the request.
-func logger(inner http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-
-		inner.ServeHTTP(w, r)
-
-		log.Printf(
-			"%s %s %s",
-			r.Method,
-			r.RequestURI,
-			time.Since(start),
-		)
-	})
+// logger returns a middleware that logs the HTTP method, request URI, and the time taken to process the request, as told by clk.
+func logger(clk clock.Clock) mux.MiddlewareFunc {
+	return func(inner http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := clk.Now()
+
+			inner.ServeHTTP(w, r)
+
+			log.Printf(
+				"%s %s %s",
+				r.Method,
+				r.RequestURI,
+				clk.Since(start),
+			)
+		})
+	}
 }
 
 // BuildBaseRouter returns the main router, which can be extended by sub-routers.
 func BuildBaseRouter() *mux.Router {
 	router := mux.NewRouter().StrictSlash(true)
-	router.Use(logger)
+	router.Use(logger(clock.Real()))
 	return router
 }
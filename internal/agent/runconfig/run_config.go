@@ -14,7 +14,11 @@
 
 package runconfig
 
-import "context"
+import (
+	"context"
+
+	"google.golang.org/adk/model"
+)
 
 type StreamingMode string
 
@@ -26,6 +30,21 @@ const (
 
 type RunConfig struct {
 	StreamingMode StreamingMode
+
+	// Model, if set, replaces the model of the agent named ModelAgent, or of
+	// all LLM agents of the run if ModelSubAgents is set.
+	Model          model.LLM
+	ModelAgent     string
+	ModelSubAgents bool
+}
+
+// ModelFor returns the model of the run for the agent, or configured if the
+// run does not override it.
+func (c *RunConfig) ModelFor(agentName string, configured model.LLM) model.LLM {
+	if c == nil || c.Model == nil || (!c.ModelSubAgents && c.ModelAgent != agentName) {
+		return configured
+	}
+	return c.Model
 }
 
 func ToContext(ctx context.Context, cfg *RunConfig) context.Context {
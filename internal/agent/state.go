@@ -14,6 +14,12 @@
 
 package agent
 
+import (
+	"go.opentelemetry.io/otel/attribute"
+
+	"google.golang.org/adk/session"
+)
+
 // holds Agent internal state
 type Agent interface {
 	internal() *State
@@ -22,6 +28,15 @@ type Agent interface {
 type State struct {
 	AgentType Type
 	Config    any
+	// Persist reports whether an event of the agent is stored in the
+	// session service. All events are stored if nil.
+	Persist func(*session.Event) bool
+	// TracerServiceName is the name of the tracers of the spans of the
+	// agent. The name set for the run is used if empty.
+	TracerServiceName string
+	// SpanAttributes are the custom attributes recorded on the call_llm and
+	// execute_tool spans of the agent.
+	SpanAttributes []attribute.KeyValue
 }
 
 type Type string
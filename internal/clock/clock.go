@@ -0,0 +1,157 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package clock abstracts the system clock so that time-dependent behavior,
+// e.g. timestamps, latencies and periodic work, can be tested
+// deterministically.
+package clock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and creates tickers.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// Since returns the time elapsed since t.
+	Since(t time.Time) time.Duration
+	// NewTicker returns a ticker delivering ticks every d.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks at intervals, like time.Ticker.
+type Ticker interface {
+	// C returns the channel on which the ticks are delivered.
+	C() <-chan time.Time
+	// Stop turns off the ticker.
+	Stop()
+}
+
+type nowKey struct{}
+
+// NewContext returns ctx carrying now, the time source of the code run with
+// the context, e.g. the clock of a runner. It tells timestamps and
+// latencies; deadlines of contexts always follow the system clock.
+func NewContext(ctx context.Context, now func() time.Time) context.Context {
+	return context.WithValue(ctx, nowKey{}, now)
+}
+
+// Now returns the time of the time source carried by ctx, or the system
+// time if it has none.
+func Now(ctx context.Context) time.Time {
+	if now, ok := ctx.Value(nowKey{}).(func() time.Time); ok {
+		return now()
+	}
+	return time.Now()
+}
+
+// Since returns the time elapsed since t according to the time source
+// carried by ctx. See Now.
+func Since(ctx context.Context, t time.Time) time.Duration {
+	return Now(ctx).Sub(t)
+}
+
+// Real returns the system clock.
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                  { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration { return time.Since(t) }
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time { return t.t.C }
+func (t realTicker) Stop()               { t.t.Stop() }
+
+// Fake is a Clock that only moves when advanced. It is safe for concurrent
+// use.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+// NewFake returns a fake clock set to now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTicker{clock: f, c: make(chan time.Time, 1), interval: d, next: f.now.Add(d)}
+	f.tickers = append(f.tickers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, delivering the ticks that became
+// due. As with time.Ticker, ticks are dropped for slow receivers.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	for _, t := range f.tickers {
+		for !t.next.After(f.now) {
+			select {
+			case t.c <- t.next:
+			default:
+			}
+			t.next = t.next.Add(t.interval)
+		}
+	}
+}
+
+type fakeTicker struct {
+	clock    *Fake
+	c        chan time.Time
+	interval time.Duration
+	next     time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.c }
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	for i, other := range t.clock.tickers {
+		if other == t {
+			t.clock.tickers = append(t.clock.tickers[:i], t.clock.tickers[i+1:]...)
+			return
+		}
+	}
+}
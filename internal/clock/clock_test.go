@@ -0,0 +1,63 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+	ticker := c.NewTicker(time.Second)
+
+	c.Advance(500 * time.Millisecond)
+	if got, want := c.Since(start), 500*time.Millisecond; got != want {
+		t.Errorf("Since() = %v, want %v", got, want)
+	}
+	select {
+	case tick := <-ticker.C():
+		t.Fatalf("ticker fired early at %v", tick)
+	default:
+	}
+
+	// Ticks beyond the buffered one are dropped, like time.Ticker does.
+	c.Advance(2 * time.Second)
+	select {
+	case tick := <-ticker.C():
+		if want := start.Add(time.Second); !tick.Equal(want) {
+			t.Errorf("tick = %v, want %v", tick, want)
+		}
+	default:
+		t.Fatal("ticker did not fire")
+	}
+	select {
+	case tick := <-ticker.C():
+		t.Fatalf("dropped tick delivered at %v", tick)
+	default:
+	}
+
+	ticker.Stop()
+	c.Advance(time.Hour)
+	select {
+	case tick := <-ticker.C():
+		t.Fatalf("stopped ticker fired at %v", tick)
+	default:
+	}
+	if got, want := c.Now(), start.Add(time.Hour+2500*time.Millisecond); !got.Equal(want) {
+		t.Errorf("Now() = %v, want %v", got, want)
+	}
+}
@@ -15,6 +15,7 @@
 package context
 
 import (
+	"context"
 	"testing"
 
 	"google.golang.org/adk/agent"
@@ -40,3 +41,31 @@ func TestCallbackContext(t *testing.T) {
 		t.Errorf("CallbackContext(%+T) is unexpectedly an InvocationContext", got)
 	}
 }
+
+func TestDerivedContext(t *testing.T) {
+	type key struct{}
+	inv := NewInvocationContext(t.Context(), InvocationContextParams{TransferDepth: 2})
+	agent.SetHistoryPolicy(inv, agent.HistoryPolicy{MaxEvents: 3})
+
+	ctx, cancel := context.WithCancel(context.WithValue(t.Context(), key{}, "value"))
+	derived := WithTransferDepth(WithContext(inv, ctx), 3)
+	cancel()
+
+	if derived.Err() == nil {
+		t.Error("Err() = nil after the cancellation of the context, want an error")
+	}
+	if got := derived.Value(key{}); got != "value" {
+		t.Errorf("Value() = %v, want %q", got, "value")
+	}
+	if got := agent.TransferDepthOf(derived); got != 3 {
+		t.Errorf("TransferDepthOf() = %d, want 3", got)
+	}
+	if got := agent.TransferDepthOf(WithContext(inv, ctx)); got != 2 {
+		t.Errorf("TransferDepthOf() of WithContext = %d, want 2", got)
+	}
+	// The history policy is the one of the parent.
+	agent.SetHistoryPolicy(derived, agent.HistoryPolicy{MaxEvents: 5})
+	if got := agent.HistoryPolicyOf(inv); got.MaxEvents != 5 {
+		t.Errorf("HistoryPolicyOf() of the parent = %+v, want MaxEvents 5", got)
+	}
+}
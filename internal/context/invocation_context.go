@@ -16,6 +16,7 @@ package context
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"google.golang.org/genai"
@@ -35,6 +36,8 @@ type InvocationContextParams struct {
 	UserContent   *genai.Content
 	RunConfig     *agent.RunConfig
 	EndInvocation bool
+	HistoryPolicy agent.HistoryPolicy
+	TransferDepth int
 }
 
 func NewInvocationContext(ctx context.Context, params InvocationContextParams) agent.InvocationContext {
@@ -91,3 +94,70 @@ func (c *InvocationContext) EndInvocation() {
 func (c *InvocationContext) Ended() bool {
 	return c.params.EndInvocation
 }
+
+var (
+	_ agent.HistoryContext       = (*InvocationContext)(nil)
+	_ agent.TransferDepthContext = (*InvocationContext)(nil)
+)
+
+func (c *InvocationContext) History() []*session.Event {
+	return c.params.HistoryPolicy.History(c.params.Session)
+}
+
+func (c *InvocationContext) HistoryPolicy() agent.HistoryPolicy {
+	return c.params.HistoryPolicy
+}
+
+func (c *InvocationContext) SetHistoryPolicy(p agent.HistoryPolicy) {
+	c.params.HistoryPolicy = p
+}
+
+func (c *InvocationContext) TransferDepth() int {
+	return c.params.TransferDepth
+}
+
+// WithContext returns the invocation context with the deadline, the
+// cancellation and the values of ctx, e.g. the spans of a workflow agent or
+// the deadline of a tool call.
+func WithContext(parent agent.InvocationContext, ctx context.Context) agent.InvocationContext {
+	return &derivedContext{InvocationContext: parent, ctx: ctx, transferDepth: agent.TransferDepthOf(parent)}
+}
+
+// WithTransferDepth returns the invocation context with the transfer depth,
+// see agent.TransferDepthContext.
+func WithTransferDepth(parent agent.InvocationContext, depth int) agent.InvocationContext {
+	return &derivedContext{InvocationContext: parent, ctx: parent, transferDepth: depth}
+}
+
+// derivedContext is an invocation context overriding the context.Context or
+// the transfer depth of its parent. The history of the parent is kept.
+type derivedContext struct {
+	agent.InvocationContext
+	ctx           context.Context
+	transferDepth int
+}
+
+var (
+	_ agent.HistoryContext       = (*derivedContext)(nil)
+	_ agent.TransferDepthContext = (*derivedContext)(nil)
+)
+
+func (c *derivedContext) Deadline() (time.Time, bool) { return c.ctx.Deadline() }
+
+func (c *derivedContext) Done() <-chan struct{} { return c.ctx.Done() }
+
+func (c *derivedContext) Err() error { return c.ctx.Err() }
+
+func (c *derivedContext) Value(key any) any { return c.ctx.Value(key) }
+
+func (c *derivedContext) History() []*session.Event { return agent.HistoryOf(c.InvocationContext) }
+
+func (c *derivedContext) HistoryPolicy() agent.HistoryPolicy {
+	return agent.HistoryPolicyOf(c.InvocationContext)
+}
+
+func (c *derivedContext) SetHistoryPolicy(p agent.HistoryPolicy) {
+	agent.SetHistoryPolicy(c.InvocationContext, p)
+}
+
+func (c *derivedContext) TransferDepth() int { return c.transferDepth }
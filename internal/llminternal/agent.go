@@ -15,6 +15,8 @@
 package llminternal
 
 import (
+	"slices"
+
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
@@ -33,14 +35,20 @@ type State struct {
 	Tools    []tool.Tool
 	Toolsets []tool.Toolset
 
+	AllowedTools []string
+	DeniedTools  []string
+
 	IncludeContents string
 
 	GenerateContentConfig *genai.GenerateContentConfig
+	ThinkingConfig        *genai.ThinkingConfig
+	FunctionCallingConfig *genai.FunctionCallingConfig
 
 	Instruction               string
 	InstructionProvider       InstructionProvider
 	GlobalInstruction         string
 	GlobalInstructionProvider InstructionProvider
+	Instructions              []InstructionProvider
 
 	DisallowTransferToParent bool
 	DisallowTransferToPeers  bool
@@ -49,10 +57,26 @@ type State struct {
 	OutputSchema *genai.Schema
 
 	OutputKey string
+
+	// ReportRemainingBudget adds the time left before the deadline of the
+	// invocation, if any, to the system instruction of each model request.
+	ReportRemainingBudget bool
+	// GroundToolResults repeats the responses of the last GroundToolResults
+	// function calls of the request in a block of its system instruction.
+	GroundToolResults int
 }
 
 type InstructionProvider func(ctx agent.ReadonlyContext) (string, error)
 
 func (s *State) internal() *State { return s }
 
+// ToolPermitted reports whether the allow and deny lists permit calling the
+// tool with the given name.
+func (s *State) ToolPermitted(name string) bool {
+	if slices.Contains(s.DeniedTools, name) {
+		return false
+	}
+	return len(s.AllowedTools) == 0 || slices.Contains(s.AllowedTools, name)
+}
+
 func Reveal(a Agent) *State { return a.internal() }
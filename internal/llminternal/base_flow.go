@@ -15,28 +15,40 @@
 package llminternal
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"iter"
+	"log/slog"
 	"maps"
 	"slices"
+	"sync"
+	"time"
 
+	"github.com/google/jsonschema-go/jsonschema"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/internal/agent/parentmap"
 	"google.golang.org/adk/internal/agent/runconfig"
 	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/logging"
 	"google.golang.org/adk/internal/telemetry"
 	"google.golang.org/adk/internal/toolinternal"
 	"google.golang.org/adk/internal/utils"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/retry"
+	"google.golang.org/adk/safety"
 	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
 )
 
 var ErrModelNotConfigured = errors.New("model not configured; ensure Model is set in llmagent.Config")
 
+var ErrToolBudgetExhausted = errors.New("the deadline of the invocation is exceeded, no budget left for tool execution")
+
 type BeforeModelCallback func(ctx agent.CallbackContext, llmRequest *model.LLMRequest) (*model.LLMResponse, error)
 
 type AfterModelCallback func(ctx agent.CallbackContext, llmResponse *model.LLMResponse, llmResponseError error) (*model.LLMResponse, error)
@@ -45,8 +57,18 @@ type BeforeToolCallback func(ctx tool.Context, tool tool.Tool, args map[string]a
 
 type AfterToolCallback func(ctx tool.Context, tool tool.Tool, args, result map[string]any, err error) (map[string]any, error)
 
+type StopCondition func(ctx agent.ReadonlyContext, llmResponse *model.LLMResponse) (bool, error)
+
+type OutputParser func(ctx agent.ReadonlyContext, llmResponse *model.LLMResponse) error
+
+// defaultMaxReprompts is used if Flow.MaxReprompts is zero.
+const defaultMaxReprompts = 3
+
 type Flow struct {
 	Model model.LLM
+	// ConfiguredModel is the model of the agent, which Model replaces if the
+	// run overrides it.
+	ConfiguredModel model.LLM
 
 	RequestProcessors    []func(ctx agent.InvocationContext, req *model.LLMRequest) error
 	ResponseProcessors   []func(ctx agent.InvocationContext, req *model.LLMRequest, resp *model.LLMResponse) error
@@ -54,6 +76,31 @@ type Flow struct {
 	AfterModelCallbacks  []AfterModelCallback
 	BeforeToolCallbacks  []BeforeToolCallback
 	AfterToolCallbacks   []AfterToolCallback
+
+	StopCondition StopCondition
+	OutputParser  OutputParser
+	MaxReprompts  int
+
+	// ToolTimeout limits the duration of a single tool call. The deadline
+	// of a tool call is further capped by the deadline of the invocation.
+	ToolTimeout time.Duration
+	// MaxToolCalls caps the number of function calls run from a single model
+	// response. Further calls are answered with an error response. Zero
+	// means unlimited.
+	MaxToolCalls int
+	// SafetyClassifier, if set, checks each final response before it is
+	// yielded. Blocked responses are replaced with SafetyFallbackMessage.
+	// Partial responses are withheld while it is set.
+	SafetyClassifier      safety.Classifier
+	SafetyFallbackMessage string
+
+	// OutputLimit caps the length of final responses, either truncating
+	// them or re-prompting the model within MaxReprompts.
+	OutputLimit OutputLimit
+
+	// ContextWindow fails requests exceeding the context window of the
+	// model before they are sent.
+	ContextWindow ContextWindow
 }
 
 var (
@@ -71,6 +118,10 @@ var (
 		codeExecutionRequestProcessor,
 		AgentTransferRequestProcessor,
 		removeDisplayNameIfExists,
+		// The notes below are added last, after the contents and the
+		// instructions of the agent.
+		toolResultsGroundingRequestProcessor,
+		budgetRequestProcessor,
 	}
 	DefaultResponseProcessors = []func(ctx agent.InvocationContext, req *model.LLMRequest, resp *model.LLMResponse) error{
 		nlPlanningResponseProcessor,
@@ -80,8 +131,25 @@ var (
 
 func (f *Flow) Run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
 	return func(yield func(*session.Event, error) bool) {
-		for {
+		if ev := InterruptedFunctionCalls(ctx.Session()); ev != nil && ev.Author == ctx.Agent().Name() {
 			var lastEvent *session.Event
+			for ev, err := range f.resumeFunctionCalls(ctx, ev) {
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				if !yield(ev, nil) {
+					return
+				}
+				lastEvent = ev
+			}
+			if lastEvent == nil || lastEvent.IsFinalResponse() {
+				return
+			}
+		}
+		reprompts := 0
+		for {
+			var lastEvent, lastModelEvent *session.Event
 			for ev, err := range f.runOneStep(ctx) {
 				if err != nil {
 					yield(nil, err)
@@ -91,11 +159,44 @@ func (f *Flow) Run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error]
 				if !yield(ev, nil) {
 					return
 				}
+				if ev.Kind == session.EventKindPhase {
+					continue
+				}
 				lastEvent = ev
+				if ev.Content != nil && ev.Content.Role == genai.RoleModel {
+					lastModelEvent = ev
+				}
 			}
-			if lastEvent == nil || lastEvent.IsFinalResponse() {
+			if lastEvent == nil {
+				return
+			}
+			final := lastEvent.IsFinalResponse()
+			stop, feedback, err := f.checkStop(ctx, lastEvent, lastModelEvent)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if f.StopCondition != nil || f.OutputParser != nil || f.OutputLimit.Reprompt {
+				telemetry.TraceReprompts(ctx, reprompts, stop)
+			}
+			if stop {
 				return
 			}
+			if final {
+				maxReprompts := f.MaxReprompts
+				if maxReprompts == 0 {
+					maxReprompts = defaultMaxReprompts
+				}
+				if reprompts >= maxReprompts {
+					yield(nil, fmt.Errorf("agent %q: response rejected after %d re-prompts", ctx.Agent().Name(), reprompts))
+					return
+				}
+				reprompts++
+				if feedback != nil && !yield(feedback, nil) {
+					return
+				}
+				continue
+			}
 			if lastEvent.LLMResponse.Partial {
 				// We may have reached max token limit during streaming mode.
 				// TODO: handle Partial response in model level. CL 781377328
@@ -106,6 +207,100 @@ func (f *Flow) Run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error]
 	}
 }
 
+// checkStop reports whether the flow should stop after a step ending with
+// lastEvent whose last model response is ev. If the output parser rejects the
+// response, the returned event carries the re-prompt for the model.
+func (f *Flow) checkStop(ctx agent.InvocationContext, lastEvent, ev *session.Event) (bool, *session.Event, error) {
+	final := lastEvent.IsFinalResponse()
+	if ev == nil {
+		return final, nil, nil
+	}
+	rctx := icontext.NewReadonlyContext(ctx)
+	stop := final
+	if f.StopCondition != nil {
+		var err error
+		stop, err = f.StopCondition(rctx, &ev.LLMResponse)
+		if err != nil {
+			return false, nil, fmt.Errorf("stop condition failed: %w", err)
+		}
+	}
+	// Only parse text responses, not e.g. function calls of tools skipping
+	// summarization.
+	if !stop || !final || ev != lastEvent {
+		return stop, nil, nil
+	}
+	if f.OutputLimit.enabled() && f.OutputLimit.Reprompt {
+		if length, _, exceeded := f.OutputLimit.check(&ev.LLMResponse); exceeded {
+			return false, repromptEvent(ctx, f.OutputLimit.feedback(length)), nil
+		}
+	}
+	if f.OutputParser == nil {
+		return true, nil, nil
+	}
+	if err := f.OutputParser(rctx, &ev.LLMResponse); err != nil {
+		return false, repromptEvent(ctx, err.Error()), nil
+	}
+	return true, nil, nil
+}
+
+// repromptEvent returns the user event asking the model to fix the problem
+// of its previous response.
+func repromptEvent(ctx agent.InvocationContext, problem string) *session.Event {
+	feedback := session.NewEventContext(ctx, ctx.InvocationID())
+	feedback.Author = "user"
+	feedback.Kind = session.EventKindUser
+	feedback.Branch = ctx.Branch()
+	feedback.LLMResponse = model.LLMResponse{
+		Content: genai.NewContentFromText(fmt.Sprintf("Your previous response was rejected: %s. Respond again, fixing the problem.", problem), genai.RoleUser),
+	}
+	return feedback
+}
+
+// checkOutputLimit records final responses exceeding the output limit on the
+// spans and truncates them, unless the limit re-prompts the model. Re-prompts
+// are issued by checkStop.
+func (f *Flow) checkOutputLimit(spans []trace.Span, ev *session.Event) {
+	if !f.OutputLimit.enabled() || ev.Content == nil || !ev.IsFinalResponse() {
+		return
+	}
+	length, keep, exceeded := f.OutputLimit.check(&ev.LLMResponse)
+	if !exceeded {
+		return
+	}
+	telemetry.SetOutputCapped(spans, length)
+	if f.OutputLimit.Reprompt {
+		return
+	}
+	notice := f.OutputLimit.Notice
+	if notice == "" {
+		notice = DefaultOutputLimitNotice
+	}
+	ev.Content = truncateContent(ev.Content, keep, notice)
+}
+
+// checkSafety runs the safety classifier on a final response, attaching the
+// scores to the event and replacing the content of blocked responses.
+func (f *Flow) checkSafety(ctx agent.InvocationContext, spans []trace.Span, ev *session.Event) error {
+	if f.SafetyClassifier == nil || ev.Content == nil || !ev.IsFinalResponse() {
+		return nil
+	}
+	res, err := f.SafetyClassifier.Classify(ctx, ev.Content)
+	if err != nil {
+		return fmt.Errorf("safety classifier failed: %w", err)
+	}
+	ev.SafetyScores = res.Scores
+	telemetry.SetSafety(spans, res.Scores, res.Blocked)
+	if res.Blocked {
+		msg := f.SafetyFallbackMessage
+		if msg == "" {
+			msg = safety.DefaultFallbackMessage
+		}
+		ev.Content = genai.NewContentFromText(msg, genai.RoleModel)
+		ev.FinishReason = genai.FinishReasonSafety
+	}
+	return nil
+}
+
 func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
 	return func(yield func(*session.Event, error) bool) {
 		if f.Model == nil {
@@ -126,11 +321,45 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 			return
 		}
 		spans := telemetry.StartTrace(ctx, "call_llm")
+		if llmAgent := asLLMAgent(ctx.Agent()); llmAgent != nil {
+			if remaining, ok := remainingBudget(ctx); ok && llmAgent.internal().ReportRemainingBudget {
+				telemetry.SetRemainingBudget(spans, remaining)
+			}
+			if grounded := len(lastFunctionResponses(req, llmAgent.internal().GroundToolResults)); grounded > 0 {
+				telemetry.SetGroundedToolResults(spans, grounded)
+			}
+		}
+		if inst := systemInstruction(req); inst != "" {
+			telemetry.SetInstruction(spans, inst)
+		}
+		configured := f.Model.Name()
+		if f.ConfiguredModel != nil {
+			configured = f.ConfiguredModel.Name()
+		}
+		telemetry.SetModels(spans, configured, f.Model.Name())
+		if cfg := ctx.RunConfig(); cfg != nil && cfg.GenerationOverrides != nil {
+			dropped := applyGenerationOverrides(cfg.GenerationOverrides, f.Model, req)
+			telemetry.SetGenerationOverrides(spans, req.Config, dropped)
+		}
+		if depth := agent.TransferDepthOf(ctx); depth > 0 {
+			telemetry.SetTransferDepth(spans, depth)
+		}
+		if !yieldPhase(ctx, spans, session.PhaseThinking, req.Model, yield) {
+			endSpans(spans)
+			return
+		}
+		generating := false
+		logger := runLogger(ctx)
+		logCtx := withSpan(ctx, spans)
+		logger.DebugContext(logCtx, "calling model", slog.String("model", req.Model))
 		// Create event to pass to callback state delta
 		stateDelta := make(map[string]any)
+		stream := &streamCap{limit: f.OutputLimit}
 		// Calls the LLM.
-		for resp, err := range f.callLLM(ctx, req, stateDelta) {
+		for resp, err := range f.callLLM(ctx, req, stateDelta, spans) {
+			traceRetryBudget(ctx, spans)
 			if err != nil {
+				logger.WarnContext(logCtx, "model call failed", slog.String("model", req.Model), slog.Any("error", err))
 				yield(nil, err)
 				return
 			}
@@ -159,6 +388,26 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 
 			// Build the event and yield.
 			modelResponseEvent := f.finalizeModelResponseEvent(ctx, resp, tools, stateDelta)
+			if modelResponseEvent.Partial && f.SafetyClassifier != nil {
+				// Partial responses would reach the client before the
+				// aggregated final response is classified.
+				continue
+			}
+			if modelResponseEvent.Partial && !stream.apply(&modelResponseEvent.LLMResponse) {
+				continue
+			}
+			if err := f.checkSafety(ctx, spans, modelResponseEvent); err != nil {
+				yield(nil, err)
+				return
+			}
+			f.checkOutputLimit(spans, modelResponseEvent)
+			if !generating && hasText(modelResponseEvent.Content) {
+				generating = true
+				if !yieldPhase(ctx, spans, session.PhaseGenerating, "", yield) {
+					endSpans(spans)
+					return
+				}
+			}
 			telemetry.TraceLLMCall(spans, ctx, req, modelResponseEvent)
 			if !yield(modelResponseEvent, nil) {
 				return
@@ -167,7 +416,11 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 
 			// Handle function calls.
 
-			ev, err := f.handleFunctionCalls(ctx, tools, resp)
+			onTool, stopped := toolPhaseYielder(ctx, yield)
+			ev, err := f.handleFunctionCalls(ctx, tools, resp, onTool)
+			if stopped() {
+				return
+			}
 			if err != nil {
 				yield(nil, err)
 				return
@@ -179,29 +432,63 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 			if !yield(ev, nil) {
 				return
 			}
-
-			// Actually handle "transfer_to_agent" tool. The function call sets the ev.Actions.TransferToAgent field.
-			// We are following python's execution flow which is
-			//   BaseLlmFlow._postprocess_async
-			//    -> _postprocess_handle_function_calls_async
-			// TODO(hakim): figure out why this isn't handled by the runner.
-			if ev.Actions.TransferToAgent == "" {
-				return
-			}
-			nextAgent := f.agentToRun(ctx, ev.Actions.TransferToAgent)
-			if nextAgent == nil {
-				yield(nil, fmt.Errorf("failed to find agent: %s", ev.Actions.TransferToAgent))
+			if ev.Actions.TransferToAgent == "" || !f.transfer(ctx, ev, yield) {
 				return
 			}
-			for ev, err := range nextAgent.Run(ctx) {
-				if !yield(ev, err) || err != nil { // forward
-					return
-				}
-			}
 		}
 	}
 }
 
+// transfer runs the agent that the function response event ev transfers to,
+// if any, forwarding its events. It reports whether the caller may continue
+// yielding.
+func (f *Flow) transfer(ctx agent.InvocationContext, ev *session.Event, yield func(*session.Event, error) bool) bool {
+	// Actually handle "transfer_to_agent" tool. The function call sets the ev.Actions.TransferToAgent field.
+	// We are following python's execution flow which is
+	//   BaseLlmFlow._postprocess_async
+	//    -> _postprocess_handle_function_calls_async
+	// TODO(hakim): figure out why this isn't handled by the runner.
+	if ev.Actions.TransferToAgent == "" {
+		return true
+	}
+	nextAgent := f.agentToRun(ctx, ev.Actions.TransferToAgent)
+	if nextAgent == nil {
+		yield(nil, fmt.Errorf("failed to find agent: %s", ev.Actions.TransferToAgent))
+		return false
+	}
+	depth := agent.TransferDepthOf(ctx) + 1
+	if maxDepth := maxTransferDepth(ctx); maxDepth >= 0 && depth > maxDepth {
+		err := &agent.TransferDepthError{From: ctx.Agent().Name(), To: nextAgent.Name(), MaxDepth: maxDepth}
+		ev := session.NewEventContext(ctx, ctx.InvocationID())
+		ev.Author = ctx.Agent().Name()
+		ev.Branch = ctx.Branch()
+		ev.LLMResponse = model.LLMResponse{
+			ErrorCode:    agent.TransferDepthExceededErrorCode,
+			ErrorMessage: err.Error(),
+			TurnComplete: true,
+		}
+		if yield(ev, nil) {
+			yield(nil, err)
+		}
+		return false
+	}
+	for ev, err := range nextAgent.Run(icontext.WithTransferDepth(ctx, depth)) {
+		if !yield(ev, err) || err != nil { // forward
+			return false
+		}
+	}
+	return true
+}
+
+// maxTransferDepth returns the maximum transfer depth of the invocation, or a
+// negative value if transfers are not limited.
+func maxTransferDepth(ctx agent.InvocationContext) int {
+	if cfg := ctx.RunConfig(); cfg != nil && cfg.MaxTransferDepth != 0 {
+		return cfg.MaxTransferDepth
+	}
+	return agent.DefaultMaxTransferDepth
+}
+
 func (f *Flow) preprocess(ctx agent.InvocationContext, req *model.LLMRequest) error {
 	llmAgent, ok := ctx.Agent().(Agent)
 	if !ok {
@@ -225,6 +512,9 @@ func (f *Flow) preprocess(ctx agent.InvocationContext, req *model.LLMRequest) er
 
 		tools = append(tools, tsTools...)
 	}
+	tools = slices.DeleteFunc(slices.Clone(tools), func(t tool.Tool) bool {
+		return !Reveal(llmAgent).ToolPermitted(t.Name())
+	})
 
 	return toolPreprocess(ctx, req, tools)
 }
@@ -247,7 +537,15 @@ func toolPreprocess(ctx agent.InvocationContext, req *model.LLMRequest, tools []
 	return nil
 }
 
-func (f *Flow) callLLM(ctx agent.InvocationContext, req *model.LLMRequest, stateDelta map[string]any) iter.Seq2[*model.LLMResponse, error] {
+// traceRetryBudget records the retries left to the calls of the invocation,
+// if they are limited.
+func traceRetryBudget(ctx agent.InvocationContext, spans []trace.Span) {
+	if b := retry.BudgetFromContext(ctx); b != nil {
+		telemetry.SetRetryBudget(spans, b.Remaining())
+	}
+}
+
+func (f *Flow) callLLM(ctx agent.InvocationContext, req *model.LLMRequest, stateDelta map[string]any, spans []trace.Span) iter.Seq2[*model.LLMResponse, error] {
 	return func(yield func(*model.LLMResponse, error) bool) {
 		for _, callback := range f.BeforeModelCallbacks {
 			cctx := icontext.NewCallbackContextWithDelta(ctx, stateDelta)
@@ -259,13 +557,20 @@ func (f *Flow) callLLM(ctx agent.InvocationContext, req *model.LLMRequest, state
 			}
 		}
 
+		if err := f.checkContextWindow(ctx, req, spans); err != nil {
+			yield(nil, err)
+			return
+		}
+
 		// TODO: Set _ADK_AGENT_NAME_LABEL_KEY in req.GenerateConfig.Labels
 		// to help with slicing the billing reports on a per-agent basis.
 
 		// TODO: RunLive mode when invocation_context.run_config.support_cfc is true.
 		useStream := runconfig.FromContext(ctx).StreamingMode == runconfig.StreamingModeSSE
 
-		for resp, err := range f.Model.GenerateContent(ctx, req, useStream) {
+		// Requests of the model continue the trace under the call_llm span.
+		for resp, err := range f.Model.GenerateContent(telemetry.ContextWithSpan(ctx, spans), req, useStream) {
+			err = model.NormalizeError(err)
 			callbackResp, callbackErr := f.runAfterModelCallbacks(ctx, resp, stateDelta, err)
 			// TODO: check if we should stop iterator on the first error from stream or continue yielding next results.
 			if callbackErr != nil {
@@ -336,10 +641,11 @@ func (f *Flow) finalizeModelResponseEvent(ctx agent.InvocationContext, resp *mod
 	// Generate function call ids. (see functions.populate_client_function_call_id in python SDK)
 	utils.PopulateClientFunctionCallID(resp.Content)
 
-	ev := session.NewEvent(ctx.InvocationID())
+	ev := session.NewEventContext(ctx, ctx.InvocationID())
 	ev.Author = ctx.Agent().Name()
 	ev.Branch = ctx.Branch()
 	ev.LLMResponse = *resp
+	ev.Kind = session.DeriveKind(ev)
 	ev.Actions.StateDelta = stateDelta
 
 	// Populate ev.LongRunningToolIDs
@@ -366,71 +672,411 @@ func findLongRunningFunctionCallIDs(c *genai.Content, tools map[string]tool.Tool
 // handleFunctionCalls calls the functions and returns the function response event.
 //
 // TODO: accept filters to include/exclude function calls.
-// TODO: check feasibility of running tool.Run concurrently.
-func (f *Flow) handleFunctionCalls(ctx agent.InvocationContext, toolsDict map[string]tool.Tool, resp *model.LLMResponse) (*session.Event, error) {
-	var fnResponseEvents []*session.Event
+// handleFunctionCalls runs the function calls of the response and returns
+// the event merging their responses. If onTool is set, it is called before
+// each tool is run with the spans of the call; the calls are abandoned if it
+// returns false. The calls execute concurrently, bounded by the tool limiter
+// of the run, or in order if it lets one call execute at a time.
+func (f *Flow) handleFunctionCalls(ctx agent.InvocationContext, toolsDict map[string]tool.Tool, resp *model.LLMResponse, onTool func(spans []trace.Span, toolName string) bool) (*session.Event, error) {
+	if resp.Partial {
+		// The arguments of function calls in partial responses may still be
+		// streamed; the calls are handled once the aggregator finalized them.
+		return nil, nil
+	}
 
 	fnCalls := utils.FunctionCalls(resp.Content)
-	for _, fnCall := range fnCalls {
+	fnResponseEvents := make([]*session.Event, len(fnCalls))
+	var calls []*toolCall
+	abandon := func() {
+		for _, c := range calls {
+			endSpans(c.spans)
+		}
+	}
+	for i, fnCall := range fnCalls {
+		if llmAgent, ok := ctx.Agent().(Agent); ok && !Reveal(llmAgent).ToolPermitted(fnCall.Name) {
+			fnResponseEvents[i] = f.denyFunctionCall(ctx, fnCall)
+			continue
+		}
+		if f.MaxToolCalls > 0 && len(calls) >= f.MaxToolCalls {
+			fnResponseEvents[i] = f.capFunctionCall(ctx, fnCall, len(fnCalls))
+			continue
+		}
 		curTool, ok := toolsDict[fnCall.Name]
 		if !ok {
+			abandon()
 			return nil, fmt.Errorf("unknown tool: %q", fnCall.Name)
 		}
 		funcTool, ok := curTool.(toolinternal.FunctionTool)
 		if !ok {
+			abandon()
 			return nil, fmt.Errorf("tool %q is not a function tool", curTool.Name())
 		}
-		toolCtx := toolinternal.NewToolContext(ctx, fnCall.ID, &session.EventActions{StateDelta: make(map[string]any)})
-		// toolCtx := tool.
 		spans := telemetry.StartTrace(ctx, "execute_tool "+fnCall.Name)
+		if deadline, ok := ctx.Deadline(); ok {
+			remaining := time.Until(deadline)
+			telemetry.SetToolBudget(spans, remaining)
+			if remaining <= 0 {
+				abandon()
+				telemetry.TraceToolBudgetExhausted(spans)
+				return nil, fmt.Errorf("tool %q: %w", fnCall.Name, ErrToolBudgetExhausted)
+			}
+		}
+		if onTool != nil && !onTool(spans, fnCall.Name) {
+			abandon()
+			endSpans(spans)
+			return nil, nil
+		}
+		calls = append(calls, &toolCall{index: i, fnCall: fnCall, tool: curTool, funcTool: funcTool, spans: spans})
+	}
 
-		result := f.callTool(funcTool, fnCall.Args, toolCtx)
-
-		// TODO: agent.canonical_after_tool_callbacks
-		// TODO: handle long-running tool.
-		ev := session.NewEvent(ctx.InvocationID())
-		ev.LLMResponse = model.LLMResponse{
-			Content: &genai.Content{
-				Role: "user",
-				Parts: []*genai.Part{
-					{
-						FunctionResponse: &genai.FunctionResponse{
-							ID:       fnCall.ID,
-							Name:     fnCall.Name,
-							Response: result,
-						},
-					},
-				},
-			},
+	limiter := toolLimiter(ctx)
+	if limiter.Limit() == 1 {
+		// The calls after a failed one are not executed.
+		for i, c := range calls {
+			if c.event, c.err = f.executeToolCall(ctx, limiter, c); c.err != nil {
+				for _, rest := range calls[i+1:] {
+					endSpans(rest.spans)
+				}
+				break
+			}
 		}
-		ev.Author = ctx.Agent().Name()
-		ev.Branch = ctx.Branch()
-		ev.Actions = *toolCtx.Actions()
-		telemetry.TraceToolCall(spans, curTool, fnCall.Args, ev)
-		fnResponseEvents = append(fnResponseEvents, ev)
+	} else {
+		var wg sync.WaitGroup
+		for _, c := range calls {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c.event, c.err = f.executeToolCall(ctx, limiter, c)
+			}()
+		}
+		wg.Wait()
+	}
+	for _, c := range calls {
+		if c.err != nil {
+			return nil, c.err
+		}
+		fnResponseEvents[c.index] = c.event
 	}
+
 	mergedEvent, err := mergeParallelFunctionResponseEvents(fnResponseEvents)
 	if err != nil {
 		return mergedEvent, err
 	}
 	// this is needed for debug traces of parallel calls
 	spans := telemetry.StartTrace(ctx, "execute_tool (merged)")
-	telemetry.TraceMergedToolCalls(spans, mergedEvent)
+	telemetry.SetToolConcurrency(spans, limiter.Limit(), limiter.MaxObserved())
+	telemetry.TraceMergedToolCalls(spans, ctx, f.Model.Name(), mergedEvent)
 	return mergedEvent, nil
 }
 
-func (f *Flow) callTool(tool toolinternal.FunctionTool, fArgs map[string]any, toolCtx tool.Context) map[string]any {
-	result, err := f.invokeBeforeToolCallbacks(tool, fArgs, toolCtx)
+// toolCall is a function call of a model response to execute.
+type toolCall struct {
+	index    int // of the call in the response
+	fnCall   *genai.FunctionCall
+	tool     tool.Tool
+	funcTool toolinternal.FunctionTool
+	spans    []trace.Span
+
+	event *session.Event
+	err   error
+}
+
+// toolLimiter returns the limiter of the tool calls of the run, or a limiter
+// of the calls of the turn if the invocation was not started by a runner.
+func toolLimiter(ctx agent.InvocationContext) *toolinternal.Limiter {
+	if l := toolinternal.LimiterFromContext(ctx); l != nil {
+		return l
+	}
+	n := agent.DefaultMaxConcurrentTools
+	if cfg := ctx.RunConfig(); cfg != nil && cfg.MaxConcurrentTools != 0 {
+		n = cfg.MaxConcurrentTools
+	}
+	return toolinternal.NewLimiter(n)
+}
+
+// executeToolCall executes the call once the limiter lets it and returns the
+// event of its response.
+func (f *Flow) executeToolCall(ctx agent.InvocationContext, limiter *toolinternal.Limiter, c *toolCall) (*session.Event, error) {
+	fnCall, curTool, funcTool, spans := c.fnCall, c.tool, c.funcTool, c.spans
+	release, err := limiter.Acquire(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			telemetry.TraceToolBudgetExhausted(spans)
+			return nil, fmt.Errorf("tool %q: %w", fnCall.Name, ErrToolBudgetExhausted)
+		}
+		endSpans(spans)
+		return nil, fmt.Errorf("tool %q: %w", fnCall.Name, err)
+	}
+	defer release()
+	runLogger(ctx).DebugContext(withSpan(ctx, spans), "executing tool", slog.String("tool_name", fnCall.Name), slog.String("function_call_id", fnCall.ID))
+
+	toolInvCtx, cancel := f.withToolDeadline(ctx, spans)
+	toolCtx := toolinternal.NewToolContext(toolInvCtx, fnCall.ID, &session.EventActions{StateDelta: make(map[string]any)})
+	if cfg := ctx.RunConfig(); cfg != nil && cfg.ToolStub != nil {
+		funcTool = stubbedTool{FunctionTool: funcTool, stub: cfg.ToolStub}
+	}
+	if cfg := ctx.RunConfig(); cfg != nil && cfg.ValidateToolResponses {
+		funcTool = validatedTool{FunctionTool: funcTool}
+	}
+	args := fnCall.Args
+	if cfg := ctx.RunConfig(); cfg != nil && cfg.CoerceToolArgs {
+		var coercions []argCoercion
+		args, coercions = coerceToolArgs(funcTool.Declaration(), args)
+		for _, c := range coercions {
+			telemetry.AddToolArgCoercedEvent(spans, fnCall.Name, c.Path, c.Type)
+		}
+	}
+	result := f.callTool(funcTool, args, toolCtx)
+	traceRetryBudget(ctx, spans)
+	var raw map[string]any
+	if pp, ok := curTool.(toolinternal.PostProcessedTool); ok && pp.PostProcessor() != nil {
+		if processed, ok := postProcessResult(toolCtx, pp.PostProcessor(), result); ok {
+			raw, result = result, processed
+		}
+	}
+	cancel()
+	// The tool context may observe the shared deadline before ctx does,
+	// so compare against the time rather than only ctx.Err().
+	if deadline, ok := ctx.Deadline(); ok && (errors.Is(ctx.Err(), context.DeadlineExceeded) || !time.Now().Before(deadline)) {
+		telemetry.TraceToolBudgetExhausted(spans)
+		return nil, fmt.Errorf("tool %q: %w", fnCall.Name, ErrToolBudgetExhausted)
+	}
+
+	// TODO: agent.canonical_after_tool_callbacks
+	// TODO: handle long-running tool.
+	ev := session.NewEventContext(ctx, ctx.InvocationID())
+	ev.Kind = session.EventKindToolResponse
+	ev.LLMResponse = model.LLMResponse{
+		Content: &genai.Content{
+			Role: "user",
+			Parts: []*genai.Part{
+				{
+					FunctionResponse: &genai.FunctionResponse{
+						ID:       fnCall.ID,
+						Name:     fnCall.Name,
+						Response: result,
+						Parts:    toolinternal.ResponseParts(toolCtx),
+					},
+				},
+			},
+		},
+	}
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	ev.Actions = *toolCtx.Actions()
+	if raw != nil {
+		ev.CustomMetadata = map[string]any{
+			toolinternal.RawResponsesMetadataKey: map[string]any{fnCall.ID: raw},
+		}
+		telemetry.SetRawToolResponse(spans, raw)
+	}
+	telemetry.TraceToolCall(spans, ctx, f.Model.Name(), curTool, args, ev)
+	return ev, nil
+}
+
+// withToolDeadline returns ctx with the deadline of a single tool call:
+// min(per-tool timeout, remaining budget of the invocation). The returned
+// context carries the span of the tool call, so that requests made by the
+// tool continue the trace.
+func (f *Flow) withToolDeadline(ctx agent.InvocationContext, spans []trace.Span) (agent.InvocationContext, context.CancelFunc) {
+	toolCtx := telemetry.ContextWithSpan(ctx, spans)
+	deadline, ok := ctx.Deadline()
+	if f.ToolTimeout > 0 {
+		if toolDeadline := time.Now().Add(f.ToolTimeout); !ok || toolDeadline.Before(deadline) {
+			deadline, ok = toolDeadline, true
+		}
+	}
+	if !ok {
+		return icontext.WithContext(ctx, toolCtx), func() {}
+	}
+	toolCtx, cancel := context.WithDeadline(toolCtx, deadline)
+	return icontext.WithContext(ctx, toolCtx), cancel
+}
+
+// stubbedTool answers calls with the tool stub of the run config, falling
+// back to the wrapped tool if the stub has no response for a call.
+type stubbedTool struct {
+	toolinternal.FunctionTool
+	stub func(name string, args map[string]any) (map[string]any, bool)
+}
+
+func (t stubbedTool) Run(ctx tool.Context, args any) (map[string]any, error) {
+	m, _ := args.(map[string]any)
+	if result, ok := t.stub(t.Name(), m); ok {
+		return result, nil
+	}
+	return t.FunctionTool.Run(ctx, args)
+}
+
+// validatedTool checks the responses of the wrapped tool against the output
+// schema of its declaration.
+type validatedTool struct {
+	toolinternal.FunctionTool
+}
+
+func (t validatedTool) Run(ctx tool.Context, args any) (map[string]any, error) {
+	result, err := t.FunctionTool.Run(ctx, args)
+	if err != nil {
+		return result, err
+	}
+	if err := validateToolResponse(t.Declaration(), result); err != nil {
+		return nil, fmt.Errorf("response of tool %q does not match its output schema: %w", t.Name(), err)
+	}
+	return result, nil
+}
+
+// validateToolResponse validates result against the response schema of decl,
+// if any. Responses wrapping a non-object value under "result", like those
+// of function tools, are validated by the wrapped value.
+func validateToolResponse(decl *genai.FunctionDeclaration, result map[string]any) error {
+	if decl == nil || decl.ResponseJsonSchema == nil {
+		return nil
+	}
+	schema, ok := decl.ResponseJsonSchema.(*jsonschema.Schema)
+	if !ok {
+		b, err := json.Marshal(decl.ResponseJsonSchema)
+		if err != nil {
+			return fmt.Errorf("invalid output schema: %w", err)
+		}
+		schema = &jsonschema.Schema{}
+		if err := json.Unmarshal(b, schema); err != nil {
+			return fmt.Errorf("invalid output schema: %w", err)
+		}
+	}
+	resolved, err := schema.Resolve(nil)
+	if err != nil {
+		return fmt.Errorf("invalid output schema: %w", err)
+	}
+	// Validate the JSON representation, as the model receives it.
+	b, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	var value map[string]any
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+	err = resolved.Validate(value)
+	if wrapped, ok := value["result"]; err != nil && ok && len(value) == 1 {
+		if resolved.Validate(wrapped) == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// denyFunctionCall returns the function response event rejecting a call to a
+// tool that the agent is not permitted to use.
+func (f *Flow) denyFunctionCall(ctx agent.InvocationContext, fnCall *genai.FunctionCall) *session.Event {
+	spans := telemetry.StartTrace(ctx, "execute_tool "+fnCall.Name)
+	runLogger(ctx).WarnContext(withSpan(ctx, spans), "denied tool call", slog.String("tool_name", fnCall.Name), slog.String("function_call_id", fnCall.ID))
+
+	ev := session.NewEventContext(ctx, ctx.InvocationID())
+	ev.Kind = session.EventKindToolResponse
+	ev.LLMResponse = model.LLMResponse{
+		Content: &genai.Content{
+			Role: "user",
+			Parts: []*genai.Part{
+				{
+					FunctionResponse: &genai.FunctionResponse{
+						ID:       fnCall.ID,
+						Name:     fnCall.Name,
+						Response: deniedResponse(fnCall.Name, ctx.Agent().Name()),
+					},
+				},
+			},
+		},
+	}
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	telemetry.TraceToolDenied(spans, ctx, f.Model.Name(), fnCall.Name, fnCall.Args, ev)
+	return ev
+}
+
+// capFunctionCall answers a function call beyond MaxToolCalls of a model
+// response with total function calls, without running the tool.
+func (f *Flow) capFunctionCall(ctx agent.InvocationContext, fnCall *genai.FunctionCall, total int) *session.Event {
+	spans := telemetry.StartTrace(ctx, "execute_tool "+fnCall.Name)
+	runLogger(ctx).WarnContext(withSpan(ctx, spans), "tool call over the limit of the turn", slog.String("tool_name", fnCall.Name), slog.String("function_call_id", fnCall.ID), slog.Int("max_tool_calls", f.MaxToolCalls))
+
+	ev := session.NewEventContext(ctx, ctx.InvocationID())
+	ev.Kind = session.EventKindToolResponse
+	ev.LLMResponse = model.LLMResponse{
+		Content: &genai.Content{
+			Role: "user",
+			Parts: []*genai.Part{
+				{
+					FunctionResponse: &genai.FunctionResponse{
+						ID:   fnCall.ID,
+						Name: fnCall.Name,
+						Response: tool.ErrorResponse(tool.NewError(tool.ErrorCodeResourceExhausted, fmt.Errorf(
+							"not run: the response made %d function calls but at most %d are run per turn; narrow the request and make fewer calls",
+							total, f.MaxToolCalls))),
+					},
+				},
+			},
+		},
+	}
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	telemetry.TraceToolCallCapped(spans, ctx, f.Model.Name(), fnCall.Name, fnCall.Args, ev, f.MaxToolCalls)
+	return ev
+}
+
+// deniedResponse is the response of a function call rejected by the tool
+// filters of the agent.
+func deniedResponse(toolName, agentName string) map[string]any {
+	resp := tool.ErrorResponse(tool.NewError(tool.ErrorCodePermissionDenied, fmt.Errorf("tool %q is not permitted for agent %q", toolName, agentName)))
+	resp["denied"] = true
+	return resp
+}
+
+// runLogger returns the request-scoped logger annotated with the invocation
+// and agent of ctx.
+func runLogger(ctx agent.InvocationContext) *slog.Logger {
+	return logging.FromContext(ctx).With(
+		slog.String(logging.KeyInvocationID, ctx.InvocationID()),
+		slog.String(logging.KeyAgentName, ctx.Agent().Name()),
+	)
+}
+
+// withSpan returns ctx carrying the first of the given spans, so that log
+// records are correlated with it.
+func withSpan(ctx context.Context, spans []trace.Span) context.Context {
+	if len(spans) == 0 {
+		return ctx
+	}
+	return trace.ContextWithSpan(ctx, spans[0])
+}
+
+func (f *Flow) callTool(funcTool toolinternal.FunctionTool, fArgs map[string]any, toolCtx tool.Context) map[string]any {
+	result, err := f.invokeBeforeToolCallbacks(funcTool, fArgs, toolCtx)
 	if result == nil && err == nil {
-		result, err = tool.Run(toolCtx, fArgs)
+		result, err = funcTool.Run(toolCtx, fArgs)
 	}
-	result, err = f.invokeAfterToolCallbacks(tool, fArgs, toolCtx, result, err)
+	result, err = f.invokeAfterToolCallbacks(funcTool, fArgs, toolCtx, result, err)
 	if err != nil {
-		return map[string]any{"error": err.Error()}
+		return tool.ErrorResponse(err)
 	}
 	return result
 }
 
+// postProcessResult returns the response of a tool transformed by its
+// post-processor, and whether it was transformed. Error responses are not.
+func postProcessResult(ctx tool.Context, postProcess func(tool.Context, any) (any, error), raw map[string]any) (map[string]any, bool) {
+	if _, _, isErr := tool.ResponseError(raw); isErr {
+		return nil, false
+	}
+	v, err := postProcess(ctx, raw)
+	if err != nil {
+		return tool.ErrorResponse(fmt.Errorf("failed to post-process the response: %w", err)), true
+	}
+	if m, ok := v.(map[string]any); ok {
+		return m, true
+	}
+	return map[string]any{"result": v}, true
+}
+
 func (f *Flow) invokeBeforeToolCallbacks(tool toolinternal.FunctionTool, fArgs map[string]any, toolCtx tool.Context) (map[string]any, error) {
 	for _, callback := range f.BeforeToolCallbacks {
 		result, err := callback(toolCtx, tool, fArgs)
@@ -471,12 +1117,19 @@ func mergeParallelFunctionResponseEvents(events []*session.Event) (*session.Even
 	}
 	var parts []*genai.Part
 	var actions *session.EventActions
+	var raw map[string]any
 	for _, ev := range events {
 		if ev == nil || ev.LLMResponse.Content == nil {
 			continue
 		}
 		parts = append(parts, ev.LLMResponse.Content.Parts...)
 		actions = mergeEventActions(actions, &ev.Actions)
+		if r, ok := ev.CustomMetadata[toolinternal.RawResponsesMetadataKey].(map[string]any); ok {
+			if raw == nil {
+				raw = make(map[string]any)
+			}
+			maps.Copy(raw, r)
+		}
 	}
 	// reuse events[0]
 	ev := events[0]
@@ -486,6 +1139,9 @@ func mergeParallelFunctionResponseEvents(events []*session.Event) (*session.Even
 			Parts: parts,
 		},
 	}
+	if raw != nil {
+		ev.CustomMetadata = map[string]any{toolinternal.RawResponsesMetadataKey: raw}
+	}
 	ev.Actions = *actions
 	return ev, nil
 }
@@ -516,3 +1172,23 @@ func mergeEventActions(base, other *session.EventActions) *session.EventActions
 	}
 	return base
 }
+
+// BuildRequest assembles the request the LLM agent of ctx would send to its
+// model for its next step: the request processors and the request
+// processors of the tools are applied, but no callback is run and the model
+// is not called.
+func BuildRequest(ctx agent.InvocationContext) (*model.LLMRequest, error) {
+	llmAgent, ok := ctx.Agent().(Agent)
+	if !ok {
+		return nil, fmt.Errorf("agent %v is not an LLMAgent", ctx.Agent().Name())
+	}
+	f := &Flow{Model: Reveal(llmAgent).Model, RequestProcessors: DefaultRequestProcessors}
+	if f.Model == nil {
+		return nil, fmt.Errorf("agent %q: %w", ctx.Agent().Name(), ErrModelNotConfigured)
+	}
+	req := &model.LLMRequest{Model: f.Model.Name()}
+	if err := f.preprocess(ctx, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
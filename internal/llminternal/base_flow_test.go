@@ -28,6 +28,7 @@ import (
 
 type mockFunctionTool struct {
 	name    string
+	decl    *genai.FunctionDeclaration
 	runFunc func(tool.Context, map[string]any) (map[string]any, error)
 }
 
@@ -63,7 +64,7 @@ func (m *mockFunctionTool) Run(ctx tool.Context, args any) (map[string]any, erro
 }
 
 func (m *mockFunctionTool) Declaration() *genai.FunctionDeclaration {
-	return nil
+	return m.decl
 }
 
 func TestCallTool(t *testing.T) {
@@ -95,7 +96,7 @@ func TestCallTool(t *testing.T) {
 				},
 			},
 			args: map[string]any{"key": "value"},
-			want: map[string]any{"error": "tool error"},
+			want: map[string]any{"status": "error", "error_code": "INTERNAL", "error": "tool error"},
 		},
 		{
 			name: "before callback returns result",
@@ -133,7 +134,7 @@ func TestCallTool(t *testing.T) {
 					return nil, errors.New("unexpected error")
 				},
 			},
-			want: map[string]any{"error": "before callback error"},
+			want: map[string]any{"status": "error", "error_code": "INTERNAL", "error": "before callback error"},
 		},
 		{
 			name: "after callback modifies result",
@@ -187,7 +188,7 @@ func TestCallTool(t *testing.T) {
 					return nil, errors.New("unexpected error")
 				},
 			},
-			want: map[string]any{"error": "after callback error"},
+			want: map[string]any{"status": "error", "error_code": "INTERNAL", "error": "after callback error"},
 		},
 		{
 			name: "no-op callbacks return func results",
@@ -273,3 +274,71 @@ func TestCallTool(t *testing.T) {
 		})
 	}
 }
+
+func TestValidatedTool(t *testing.T) {
+	decl := &genai.FunctionDeclaration{
+		Name: "answer",
+		ResponseJsonSchema: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"answer": map[string]any{"type": "string"}},
+			"required":   []any{"answer"},
+		},
+	}
+	for _, tc := range []struct {
+		name    string
+		decl    *genai.FunctionDeclaration
+		result  map[string]any
+		wantErr bool
+	}{
+		{
+			name:   "matching response",
+			decl:   decl,
+			result: map[string]any{"answer": "42"},
+		},
+		{
+			name:    "wrong property type",
+			decl:    decl,
+			result:  map[string]any{"answer": 42},
+			wantErr: true,
+		},
+		{
+			name:    "missing required property",
+			decl:    decl,
+			result:  map[string]any{"other": "42"},
+			wantErr: true,
+		},
+		{
+			name: "wrapped non-object response",
+			decl: &genai.FunctionDeclaration{
+				Name:               "count",
+				ResponseJsonSchema: map[string]any{"type": "integer"},
+			},
+			result: map[string]any{"result": 3},
+		},
+		{
+			name:   "no output schema",
+			decl:   &genai.FunctionDeclaration{Name: "answer"},
+			result: map[string]any{"answer": 42},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			v := validatedTool{FunctionTool: &mockFunctionTool{
+				name: tc.decl.Name,
+				decl: tc.decl,
+				runFunc: func(tool.Context, map[string]any) (map[string]any, error) {
+					return tc.result, nil
+				},
+			}}
+			got, err := v.Run(nil, map[string]any{})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tc.result, got); diff != "" {
+				t.Errorf("Run() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
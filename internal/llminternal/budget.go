@@ -0,0 +1,56 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+)
+
+// budgetNote returns the system instruction note reporting the remaining
+// time of the invocation to the model.
+func budgetNote(remaining time.Duration) string {
+	return fmt.Sprintf("Remaining time budget of this run: %s. "+
+		"If it is too short to call more tools, stop and answer with what you have.",
+		max(remaining, 0).Round(time.Second))
+}
+
+// budgetRequestProcessor appends a note with the time left before the
+// deadline of the invocation to the system instruction of req, if the agent
+// reports its remaining budget.
+func budgetRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest) error {
+	llmAgent := asLLMAgent(ctx.Agent())
+	if llmAgent == nil || !llmAgent.internal().ReportRemainingBudget {
+		return nil
+	}
+	if remaining, ok := remainingBudget(ctx); ok {
+		utils.AppendInstructions(req, budgetNote(remaining))
+	}
+	return nil
+}
+
+// remainingBudget returns the time left before the deadline of the
+// invocation. It reports false if the invocation has no deadline.
+func remainingBudget(ctx agent.InvocationContext) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
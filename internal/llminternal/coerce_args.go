@@ -0,0 +1,181 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"encoding/json"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// argCoercion is a string argument of a tool call coerced to the type
+// declared by the input schema of the tool.
+type argCoercion struct {
+	// Path locates the argument, e.g. "items[0].count".
+	Path string
+	// Type is the declared type, e.g. "integer".
+	Type string
+}
+
+// argSchema is the part of an input schema used to coerce arguments. It
+// decodes both JSON schemas and genai schemas, whose types are upper case.
+type argSchema struct {
+	Type       any                   `json:"type"`
+	Properties map[string]*argSchema `json:"properties"`
+	Items      *argSchema            `json:"items"`
+}
+
+// types returns the lower case declared types of the schema.
+func (s *argSchema) types() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{strings.ToLower(t)}
+	case []any:
+		var types []string
+		for _, v := range t {
+			if v, ok := v.(string); ok {
+				types = append(types, strings.ToLower(v))
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// coerceToolArgs returns the arguments with the string-encoded numbers and
+// booleans coerced to the types declared by the input schema of decl, and
+// the coercions made. Values that cannot be coerced are left unchanged, for
+// the tool to reject. args is not modified.
+func coerceToolArgs(decl *genai.FunctionDeclaration, args map[string]any) (map[string]any, []argCoercion) {
+	schema := inputArgSchema(decl)
+	if schema == nil || len(args) == 0 {
+		return args, nil
+	}
+	var coercions []argCoercion
+	coerced, _ := coerceValue(schema, args, "", &coercions)
+	return coerced.(map[string]any), coercions
+}
+
+// inputArgSchema returns the input schema of decl, or nil if it has none.
+func inputArgSchema(decl *genai.FunctionDeclaration) *argSchema {
+	if decl == nil {
+		return nil
+	}
+	var schema any
+	switch {
+	case decl.ParametersJsonSchema != nil:
+		schema = decl.ParametersJsonSchema
+	case decl.Parameters != nil:
+		schema = decl.Parameters
+	default:
+		return nil
+	}
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+	var s argSchema
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil
+	}
+	return &s
+}
+
+// coerceValue returns v coerced to schema and whether it changed, copying
+// the maps and slices it changes.
+func coerceValue(schema *argSchema, v any, path string, coercions *[]argCoercion) (any, bool) {
+	if schema == nil {
+		return v, false
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		var out map[string]any
+		for k, prop := range schema.Properties {
+			old, ok := v[k]
+			if !ok {
+				continue
+			}
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			if c, changed := coerceValue(prop, old, p, coercions); changed {
+				if out == nil {
+					out = maps.Clone(v)
+				}
+				out[k] = c
+			}
+		}
+		if out == nil {
+			return v, false
+		}
+		return out, true
+	case []any:
+		var out []any
+		for i, old := range v {
+			if c, changed := coerceValue(schema.Items, old, path+"["+strconv.Itoa(i)+"]", coercions); changed {
+				if out == nil {
+					out = slices.Clone(v)
+				}
+				out[i] = c
+			}
+		}
+		if out == nil {
+			return v, false
+		}
+		return out, true
+	case string:
+		types := schema.types()
+		if slices.Contains(types, "string") {
+			return v, false
+		}
+		for _, t := range types {
+			if c, ok := coerceString(v, t); ok {
+				*coercions = append(*coercions, argCoercion{Path: path, Type: t})
+				return c, true
+			}
+		}
+	}
+	return v, false
+}
+
+// coerceString returns s parsed as a value of the given JSON schema type.
+func coerceString(s, typ string) (any, bool) {
+	s = strings.TrimSpace(s)
+	switch typ {
+	case "boolean":
+		switch strings.ToLower(s) {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+	case "integer", "number":
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, false
+		}
+		if typ == "integer" && f != math.Trunc(f) {
+			return nil, false
+		}
+		return f, true
+	}
+	return nil, false
+}
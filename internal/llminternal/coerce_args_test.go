@@ -0,0 +1,113 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/genai"
+)
+
+func TestCoerceToolArgs(t *testing.T) {
+	jsonDecl := &genai.FunctionDeclaration{ParametersJsonSchema: &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"n":     {Type: "integer"},
+			"x":     {Type: "number"},
+			"b":     {Type: "boolean"},
+			"s":     {Type: "string"},
+			"multi": {Types: []string{"string", "integer"}},
+			"items": {Type: "array", Items: &jsonschema.Schema{
+				Type:       "object",
+				Properties: map[string]*jsonschema.Schema{"qty": {Type: "integer"}},
+			}},
+		},
+	}}
+	genaiDecl := &genai.FunctionDeclaration{Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"n": {Type: genai.TypeInteger},
+			"b": {Type: genai.TypeBoolean},
+		},
+	}}
+	for _, tc := range []struct {
+		name          string
+		decl          *genai.FunctionDeclaration
+		args          map[string]any
+		want          map[string]any
+		wantCoercions []argCoercion
+	}{
+		{
+			name:          "string to int",
+			decl:          jsonDecl,
+			args:          map[string]any{"n": " 42 ", "x": "2.5"},
+			want:          map[string]any{"n": 42.0, "x": 2.5},
+			wantCoercions: []argCoercion{{Path: "n", Type: "integer"}, {Path: "x", Type: "number"}},
+		},
+		{
+			name:          "string to bool",
+			decl:          jsonDecl,
+			args:          map[string]any{"b": "FALSE"},
+			want:          map[string]any{"b": false},
+			wantCoercions: []argCoercion{{Path: "b", Type: "boolean"}},
+		},
+		{
+			name: "non-coercible values are left unchanged",
+			decl: jsonDecl,
+			args: map[string]any{"n": "1.5", "x": "Inf", "b": "yes", "s": "7", "multi": "7", "other": "8"},
+			want: map[string]any{"n": "1.5", "x": "Inf", "b": "yes", "s": "7", "multi": "7", "other": "8"},
+		},
+		{
+			name:          "nested values",
+			decl:          jsonDecl,
+			args:          map[string]any{"items": []any{map[string]any{"qty": 1.0}, map[string]any{"qty": "2"}}},
+			want:          map[string]any{"items": []any{map[string]any{"qty": 1.0}, map[string]any{"qty": 2.0}}},
+			wantCoercions: []argCoercion{{Path: "items[1].qty", Type: "integer"}},
+		},
+		{
+			name:          "genai schema",
+			decl:          genaiDecl,
+			args:          map[string]any{"n": "3", "b": "true"},
+			want:          map[string]any{"n": 3.0, "b": true},
+			wantCoercions: []argCoercion{{Path: "b", Type: "boolean"}, {Path: "n", Type: "integer"}},
+		},
+		{
+			name: "no schema",
+			decl: &genai.FunctionDeclaration{},
+			args: map[string]any{"n": "3"},
+			want: map[string]any{"n": "3"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			orig := fmt.Sprint(tc.args)
+			got, coercions := coerceToolArgs(tc.decl, tc.args)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("coerceToolArgs() args mismatch (-want +got):\n%s", diff)
+			}
+			slices.SortFunc(coercions, func(a, b argCoercion) int { return strings.Compare(a.Path, b.Path) })
+			if diff := cmp.Diff(tc.wantCoercions, coercions); diff != "" {
+				t.Errorf("coerceToolArgs() coercions mismatch (-want +got):\n%s", diff)
+			}
+			if fmt.Sprint(tc.args) != orig {
+				t.Errorf("coerceToolArgs() modified its args: %v", tc.args)
+			}
+		})
+	}
+}
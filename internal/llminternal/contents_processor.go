@@ -25,6 +25,7 @@ import (
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/deepcopy"
 	"google.golang.org/adk/internal/utils"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
@@ -44,13 +45,7 @@ func ContentsRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest
 		// Include current turn context only (no conversation history)
 		fn = buildContentsCurrentTurnContextOnly
 	}
-	var events []*session.Event
-	if ctx.Session() != nil {
-		for e := range ctx.Session().Events().All() {
-			events = append(events, e)
-		}
-	}
-	contents, err := fn(ctx.Agent().Name(), ctx.Branch(), events)
+	contents, err := fn(ctx.Agent().Name(), ctx.Branch(), agent.HistoryOf(ctx))
 	if err != nil {
 		return err
 	}
@@ -103,7 +98,7 @@ func buildContentsDefault(agentName, invocationBranch string, events []*session.
 
 	var contents []*genai.Content
 	for _, ev := range filtered {
-		content := clone(utils.Content(ev))
+		content := deepcopy.Clone(utils.Content(ev))
 		if content == nil {
 			continue
 		}
@@ -0,0 +1,123 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/model"
+)
+
+// ErrContextOverflow is matched by errors.Is for a [*ContextOverflowError].
+// It is the typed error of the model package, also returned by providers
+// rejecting a request that is too large.
+var ErrContextOverflow = model.ErrContextOverflow
+
+// ContextOverflowError is returned, before the model is called, for a
+// request whose estimated size exceeds the context window.
+type ContextOverflowError struct {
+	// EstimatedTokens is the estimated number of tokens of the request.
+	EstimatedTokens int
+	// MaxTokens is the size of the context window.
+	MaxTokens int
+}
+
+func (e *ContextOverflowError) Error() string {
+	return fmt.Sprintf("%v: estimated %d tokens, allowed %d", ErrContextOverflow, e.EstimatedTokens, e.MaxTokens)
+}
+
+// Is reports whether target is ErrContextOverflow.
+func (e *ContextOverflowError) Is(target error) bool {
+	return target == ErrContextOverflow
+}
+
+// mediaTokens estimates the number of tokens of an inline or referenced file,
+// e.g. an image.
+const mediaTokens = 258
+
+// ContextWindow enables the check of the size of requests before they are
+// sent to the model. A zero MaxTokens disables the check.
+type ContextWindow struct {
+	MaxTokens int
+	// CountTokens counts the tokens of a request. Defaults to
+	// EstimateTokens.
+	CountTokens func(context.Context, *model.LLMRequest) (int, error)
+}
+
+// EstimateTokens estimates the number of tokens of the contents, system
+// instruction and tool declarations of req, as four characters per token
+// and a fixed number of tokens per file.
+func EstimateTokens(_ context.Context, req *model.LLMRequest) (int, error) {
+	var chars, tokens int
+	count := func(c *genai.Content) {
+		if c == nil {
+			return
+		}
+		for _, p := range c.Parts {
+			switch {
+			case p == nil:
+			case p.Text != "":
+				chars += utf8.RuneCountInString(p.Text)
+			case p.InlineData != nil || p.FileData != nil:
+				tokens += mediaTokens
+			default:
+				b, _ := json.Marshal(p)
+				chars += len(b)
+			}
+		}
+	}
+	for _, c := range req.Contents {
+		count(c)
+	}
+	if req.Config != nil {
+		count(req.Config.SystemInstruction)
+		if len(req.Config.Tools) > 0 {
+			b, err := json.Marshal(req.Config.Tools)
+			if err != nil {
+				return 0, fmt.Errorf("failed to encode tools: %w", err)
+			}
+			chars += len(b)
+		}
+	}
+	return tokens + (chars+charsPerToken-1)/charsPerToken, nil
+}
+
+// checkContextWindow returns a *ContextOverflowError if req exceeds the
+// context window. The overflow is recorded on the call_llm spans.
+func (f *Flow) checkContextWindow(ctx context.Context, req *model.LLMRequest, spans []trace.Span) error {
+	if f.ContextWindow.MaxTokens <= 0 {
+		return nil
+	}
+	countTokens := f.ContextWindow.CountTokens
+	if countTokens == nil {
+		countTokens = EstimateTokens
+	}
+	tokens, err := countTokens(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to count the tokens of the request: %w", err)
+	}
+	if tokens <= f.ContextWindow.MaxTokens {
+		return nil
+	}
+	telemetry.TraceContextOverflow(spans, tokens, f.ContextWindow.MaxTokens)
+	return &ContextOverflowError{EstimatedTokens: tokens, MaxTokens: f.ContextWindow.MaxTokens}
+}
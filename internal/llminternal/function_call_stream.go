@@ -0,0 +1,245 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// streamedFunctionCall is a function call whose arguments are streamed in
+// fragments, each fragment setting or extending the values at JSON paths of
+// the arguments.
+type streamedFunctionCall struct {
+	id               string
+	name             string
+	args             map[string]any
+	thoughtSignature []byte
+	fragments        int
+}
+
+// accumulateFunctionCall merges the function call fragment starting the
+// content of llmResponse, if any, into the call being streamed. Responses
+// with a fragment are marked as partial, except the one with the last
+// fragment, whose fragment is replaced with the complete call. The complete
+// call is returned along with the number of its fragments.
+func (s *streamingResponseAggregator) accumulateFunctionCall(llmResponse *model.LLMResponse) (*genai.FunctionCall, int, error) {
+	if llmResponse.Content == nil || len(llmResponse.Content.Parts) == 0 {
+		return nil, 0, nil
+	}
+	part0 := llmResponse.Content.Parts[0]
+	fc := part0.FunctionCall
+	if fc == nil {
+		return nil, 0, nil
+	}
+	willContinue := fc.WillContinue != nil && *fc.WillContinue
+	if s.call == nil {
+		if len(fc.PartialArgs) == 0 && !willContinue {
+			// A complete function call.
+			return nil, 0, nil
+		}
+		s.call = &streamedFunctionCall{args: make(map[string]any)}
+	}
+	call := s.call
+	call.fragments++
+	if fc.ID != "" {
+		call.id = fc.ID
+	}
+	if fc.Name != "" {
+		call.name = fc.Name
+	}
+	if len(part0.ThoughtSignature) > 0 {
+		call.thoughtSignature = part0.ThoughtSignature
+	}
+	for k, v := range fc.Args {
+		call.args[k] = v
+	}
+	for _, arg := range fc.PartialArgs {
+		if err := call.apply(arg); err != nil {
+			s.call = nil
+			return nil, 0, fmt.Errorf("invalid partial argument of function call %q: %w", call.name, err)
+		}
+	}
+	if willContinue {
+		llmResponse.Partial = true
+		return nil, 0, nil
+	}
+	s.call = nil
+	// The content may be shared with the model response, so replace the
+	// fragment in a copy.
+	content := *llmResponse.Content
+	content.Parts = slices.Clone(content.Parts)
+	content.Parts[0] = call.part()
+	llmResponse.Content = &content
+	return content.Parts[0].FunctionCall, call.fragments, nil
+}
+
+// part returns the part of the function call with the arguments streamed so
+// far.
+func (c *streamedFunctionCall) part() *genai.Part {
+	return &genai.Part{
+		FunctionCall:     &genai.FunctionCall{ID: c.id, Name: c.name, Args: c.args},
+		ThoughtSignature: c.thoughtSignature,
+	}
+}
+
+// apply sets the value of the partial argument at its JSON path. String
+// values extend the string already at the path.
+func (c *streamedFunctionCall) apply(arg *genai.PartialArg) error {
+	path, err := parseJSONPath(arg.JsonPath)
+	if err != nil {
+		return err
+	}
+	var value any
+	switch {
+	case arg.NumberValue != nil:
+		value = *arg.NumberValue
+	case arg.BoolValue != nil:
+		value = *arg.BoolValue
+	case arg.NULLValue != "":
+		value = nil
+	default:
+		prefix, _ := getJSONPath(c.args, path).(string)
+		value = prefix + arg.StringValue
+	}
+	args, err := setJSONPath(c.args, path, value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", arg.JsonPath, err)
+	}
+	c.args = args.(map[string]any)
+	return nil
+}
+
+// parseJSONPath parses a JSON path of the form $.a.b[0]['c'] into object
+// member names and array indices. The path must select a member of an object.
+func parseJSONPath(path string) ([]any, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, fmt.Errorf("JSON path %q does not start with $", path)
+	}
+	var segments []any
+	for rest != "" {
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			name := rest[1 : 1+end]
+			if name == "" {
+				return nil, fmt.Errorf("JSON path %q has an empty member name", path)
+			}
+			segments = append(segments, name)
+			rest = rest[1+end:]
+		case strings.HasPrefix(rest, "['") || strings.HasPrefix(rest, `["`):
+			quote := rest[1]
+			end := strings.IndexByte(rest[2:], quote)
+			if end < 0 || !strings.HasPrefix(rest[2+end+1:], "]") {
+				return nil, fmt.Errorf("JSON path %q has an unterminated member name", path)
+			}
+			segments = append(segments, rest[2:2+end])
+			rest = rest[2+end+2:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("JSON path %q has an unterminated index", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("JSON path %q has an invalid index %q", path, rest[1:end])
+			}
+			segments = append(segments, index)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("JSON path %q is invalid at %q", path, rest)
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("JSON path %q selects the arguments themselves", path)
+	}
+	if _, ok := segments[0].(string); !ok {
+		return nil, fmt.Errorf("JSON path %q does not select an argument", path)
+	}
+	return segments, nil
+}
+
+// getJSONPath returns the value at the path in node, or nil if there is none.
+func getJSONPath(node any, path []any) any {
+	for _, segment := range path {
+		switch segment := segment.(type) {
+		case string:
+			m, _ := node.(map[string]any)
+			node = m[segment]
+		case int:
+			a, _ := node.([]any)
+			if segment >= len(a) {
+				return nil
+			}
+			node = a[segment]
+		}
+	}
+	return node
+}
+
+// setJSONPath sets the value at the path in node, creating the objects and
+// arrays on the path, and returns the updated node. Array indices past the
+// end of their array fail, except the one appending an element.
+func setJSONPath(node any, path []any, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	switch segment := path[0].(type) {
+	case string:
+		m, ok := node.(map[string]any)
+		if !ok {
+			if node != nil {
+				return nil, fmt.Errorf("member %q of a %T", segment, node)
+			}
+			m = make(map[string]any)
+		}
+		v, err := setJSONPath(m[segment], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		m[segment] = v
+		return m, nil
+	case int:
+		a, ok := node.([]any)
+		if !ok && node != nil {
+			return nil, fmt.Errorf("index %d of a %T", segment, node)
+		}
+		// Streamed arrays grow one element at a time, so an index may only
+		// be that of an element or of the next one.
+		if segment < 0 || segment > len(a) {
+			return nil, fmt.Errorf("index %d of an array of %d elements", segment, len(a))
+		}
+		if segment == len(a) {
+			a = append(a, nil)
+		}
+		v, err := setJSONPath(a[segment], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		a[segment] = v
+		return a, nil
+	}
+	return nil, fmt.Errorf("invalid JSON path segment %v", path[0])
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/telemetry"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	for _, tc := range []struct {
+		path    string
+		want    []any
+		wantErr bool
+	}{
+		{path: "$.city", want: []any{"city"}},
+		{path: "$.a.b[2].c", want: []any{"a", "b", 2, "c"}},
+		{path: `$['a.b']["c]"]`, want: []any{"a.b", "c]"}},
+		{path: "city", wantErr: true},
+		{path: "$", wantErr: true},
+		{path: "$[0]", wantErr: true},
+		{path: "$.a..b", wantErr: true},
+		{path: "$.a[-1]", wantErr: true},
+		{path: "$.a[1", wantErr: true},
+		{path: "$['a", wantErr: true},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			got, err := parseJSONPath(tc.path)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseJSONPath() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("parseJSONPath() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFunctionCallFinalizedEvent(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	_, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test").Start(t.Context(), "call_llm")
+	ctx := telemetry.ContextWithSpan(t.Context(), []trace.Span{span})
+
+	aggregator := NewStreamingResponseAggregator()
+	for _, fc := range []*genai.FunctionCall{
+		{ID: "c1", Name: "lookup", WillContinue: genai.Ptr(true), PartialArgs: []*genai.PartialArg{{JsonPath: "$.q", StringValue: "a"}}},
+		{WillContinue: genai.Ptr(true), PartialArgs: []*genai.PartialArg{{JsonPath: "$.q", StringValue: "b"}}},
+		{},
+	} {
+		resp := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{Content: genai.NewContentFromParts([]*genai.Part{{FunctionCall: fc}}, genai.RoleModel)}}}
+		for _, err := range aggregator.ProcessResponse(ctx, resp) {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	span.End()
+
+	var got []map[string]any
+	for _, event := range recorder.Ended()[0].Events() {
+		attrs := map[string]any{"name": event.Name}
+		for _, kv := range event.Attributes {
+			attrs[string(kv.Key)] = kv.Value.AsInterface()
+		}
+		got = append(got, attrs)
+	}
+	want := []map[string]any{{
+		"name":                "function_call_finalized",
+		"gen_ai.tool.name":    "lookup",
+		"gen_ai.tool.call.id": "c1",
+		"gcp.vertex.agent.function_call_fragments": int64(3),
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("span events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestInvalidPartialArgument(t *testing.T) {
+	aggregator := NewStreamingResponseAggregator()
+	resp := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{Content: genai.NewContentFromParts([]*genai.Part{{FunctionCall: &genai.FunctionCall{
+		Name:        "lookup",
+		PartialArgs: []*genai.PartialArg{{JsonPath: "$.q", StringValue: "a"}, {JsonPath: "$.q[0]", StringValue: "b"}},
+	}}}, genai.RoleModel)}}}
+	var gotErr error
+	for _, err := range aggregator.ProcessResponse(t.Context(), resp) {
+		gotErr = err
+	}
+	if gotErr == nil {
+		t.Errorf("ProcessResponse() with a conflicting partial argument succeeded, want error")
+	}
+}
+
+func TestSetJSONPath(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		node    any
+		path    []any
+		want    any
+		wantErr bool
+	}{
+		{name: "new member", path: []any{"a", "b"}, want: map[string]any{"a": map[string]any{"b": "v"}}},
+		{name: "new element", path: []any{"a", 0}, want: map[string]any{"a": []any{"v"}}},
+		{name: "next element", node: []any{"x"}, path: []any{1}, want: []any{"x", "v"}},
+		{name: "existing element", node: []any{"x"}, path: []any{0}, want: []any{"v"}},
+		{name: "index past the end", node: []any{"x"}, path: []any{2}, wantErr: true},
+		{name: "huge index", path: []any{"a", 1 << 40}, wantErr: true},
+		{name: "member of a string", node: "x", path: []any{"a"}, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := setJSONPath(tc.node, tc.path, "v")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("setJSONPath() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("setJSONPath() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
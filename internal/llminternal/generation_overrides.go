@@ -0,0 +1,52 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+)
+
+// applyGenerationOverrides sets the parameters overridden by the run in the
+// config of the request, which holds a copy of the config of the agent. It
+// returns the overridden parameters that llm does not support, which are
+// left unchanged.
+func applyGenerationOverrides(o *agent.GenerationOverrides, llm model.LLM, req *model.LLMRequest) (dropped []string) {
+	if o == nil {
+		return nil
+	}
+	if req.Config == nil {
+		req.Config = &genai.GenerateContentConfig{}
+	}
+	supported := func(param string) bool {
+		if s, ok := llm.(model.GenerationParamSupporter); ok && !s.SupportsGenerationParam(param) {
+			dropped = append(dropped, param)
+			return false
+		}
+		return true
+	}
+	if o.Seed != nil && supported(model.GenerationParamSeed) {
+		req.Config.Seed = genai.Ptr(*o.Seed)
+	}
+	if o.Temperature != nil && supported(model.GenerationParamTemperature) {
+		req.Config.Temperature = genai.Ptr(*o.Temperature)
+	}
+	if o.TopP != nil && supported(model.GenerationParamTopP) {
+		req.Config.TopP = genai.Ptr(*o.TopP)
+	}
+	return dropped
+}
@@ -35,82 +35,125 @@ const (
 	tempPrefix = "temp:"
 )
 
+// defaultInstructions is the instruction composition of agents that don't
+// set their own: the global instruction of the root agent followed by the
+// instruction of the agent.
+var defaultInstructions = []InstructionProvider{GlobalInstructionBlock, InstructionBlock}
+
 // instructionsRequestProcessor configures req's instructions and global instructions for LLM flow.
+// The instruction providers of the agent are called in order and each
+// non-empty block is appended to the system instruction.
 func instructionsRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest) error {
 	llmAgent := asLLMAgent(ctx.Agent())
 	if llmAgent == nil {
 		return nil // do nothing.
 	}
 
-	parents := parentmap.FromContext(ctx)
-
-	rootAgent := asLLMAgent(parents.RootAgent(ctx.Agent()))
-	if rootAgent == nil {
-		rootAgent = llmAgent
+	providers := llmAgent.internal().Instructions
+	if len(providers) == 0 {
+		providers = defaultInstructions
 	}
-
-	// Append global instructions.
-	if err := appendGlobalInstructions(ctx, req, rootAgent.internal()); err != nil {
-		return fmt.Errorf("failed to append global instructions: %w", err)
+	rctx := icontext.NewReadonlyContext(ctx)
+	for i, provider := range providers {
+		inst, err := provider(rctx)
+		if err != nil {
+			return fmt.Errorf("failed to compose instruction %d: %w", i, err)
+		}
+		if inst == "" {
+			continue
+		}
+		utils.AppendInstructions(req, inst)
 	}
+	return nil
+}
 
-	// Append agent's instruction
-	if err := appendInstructions(ctx, req, llmAgent.internal()); err != nil {
-		return fmt.Errorf("failed to append instructions: %w", err)
+// systemInstruction returns the text of the system instruction of req, its
+// blocks separated by blank lines.
+func systemInstruction(req *model.LLMRequest) string {
+	if req.Config == nil || req.Config.SystemInstruction == nil {
+		return ""
 	}
-
-	return nil
+	var blocks []string
+	for _, part := range req.Config.SystemInstruction.Parts {
+		if part != nil && part.Text != "" {
+			blocks = append(blocks, part.Text)
+		}
+	}
+	return strings.Join(blocks, "\n\n")
 }
 
 // The regex to find placeholders like {variable} or {artifact.file_name}.
 var placeholderRegex = regexp.MustCompile(`{+[^{}]*}+`)
 
-func appendInstructions(ctx agent.InvocationContext, req *model.LLMRequest, agentState *State) error {
+// InstructionBlock returns the instruction of the agent of ctx, i.e. the
+// result of its InstructionProvider or its Instruction with the session state
+// injected.
+func InstructionBlock(ctx agent.ReadonlyContext) (string, error) {
+	ictx, llmAgent, err := blockAgent(ctx)
+	if err != nil || llmAgent == nil {
+		return "", err
+	}
+	agentState := llmAgent.internal()
 	if agentState.InstructionProvider != nil {
-		instruction, err := agentState.InstructionProvider(icontext.NewReadonlyContext(ctx))
+		instruction, err := agentState.InstructionProvider(ctx)
 		if err != nil {
-			return fmt.Errorf("failed to evaluate global instruction provider: %w", err)
+			return "", fmt.Errorf("failed to evaluate instruction provider: %w", err)
 		}
-
-		utils.AppendInstructions(req, instruction)
-		return nil
+		return instruction, nil
 	}
 
 	if agentState.Instruction == "" {
-		return nil
+		return "", nil
 	}
 
-	inst, err := InjectSessionState(ctx, agentState.Instruction)
+	inst, err := InjectSessionState(ictx, agentState.Instruction)
 	if err != nil {
-		return fmt.Errorf("failed to inject session state into instruction: %w", err)
+		return "", fmt.Errorf("failed to inject session state into instruction: %w", err)
 	}
-
-	utils.AppendInstructions(req, inst)
-	return nil
+	return inst, nil
 }
 
-func appendGlobalInstructions(ctx agent.InvocationContext, req *model.LLMRequest, agentState *State) error {
+// GlobalInstructionBlock returns the global instruction of the root agent of
+// the agent of ctx, i.e. the result of its GlobalInstructionProvider or its
+// GlobalInstruction with the session state injected.
+func GlobalInstructionBlock(ctx agent.ReadonlyContext) (string, error) {
+	ictx, llmAgent, err := blockAgent(ctx)
+	if err != nil || llmAgent == nil {
+		return "", err
+	}
+	rootAgent := asLLMAgent(parentmap.FromContext(ictx).RootAgent(ictx.Agent()))
+	if rootAgent == nil {
+		rootAgent = llmAgent
+	}
+	agentState := rootAgent.internal()
 	if agentState.GlobalInstructionProvider != nil {
-		instruction, err := agentState.GlobalInstructionProvider(icontext.NewReadonlyContext(ctx))
+		instruction, err := agentState.GlobalInstructionProvider(ctx)
 		if err != nil {
-			return fmt.Errorf("failed to evaluate global instruction provider: %w", err)
+			return "", fmt.Errorf("failed to evaluate global instruction provider: %w", err)
 		}
-
-		utils.AppendInstructions(req, instruction)
-		return nil
+		return instruction, nil
 	}
 
 	if agentState.GlobalInstruction == "" {
-		return nil
+		return "", nil
 	}
 
-	inst, err := InjectSessionState(ctx, agentState.GlobalInstruction)
+	inst, err := InjectSessionState(ictx, agentState.GlobalInstruction)
 	if err != nil {
-		return fmt.Errorf("failed to inject session state into global instruction: %w", err)
+		return "", fmt.Errorf("failed to inject session state into global instruction: %w", err)
 	}
+	return inst, nil
+}
 
-	utils.AppendInstructions(req, inst)
-	return nil
+// blockAgent returns the invocation context and the LLM agent behind the
+// context given to an instruction provider. The agent is nil if it is not an
+// LLM agent.
+func blockAgent(ctx agent.ReadonlyContext) (agent.InvocationContext, Agent, error) {
+	rctx, ok := ctx.(*icontext.ReadonlyContext)
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected context type: %T", ctx)
+	}
+	return rctx.InvocationContext, asLLMAgent(rctx.InvocationContext.Agent()), nil
 }
 
 // replaceMatch is the Go equivalent of the _replace_match async function in the Python code.
@@ -0,0 +1,181 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// charsPerToken estimates the number of characters of a token if the model
+// does not report token counts.
+const charsPerToken = 4
+
+// DefaultOutputLimitNotice is appended to truncated responses if
+// OutputLimit.Notice is empty.
+const DefaultOutputLimitNotice = "[Response truncated]"
+
+// OutputLimit caps the length of final responses. Zero limits are not
+// applied.
+type OutputLimit struct {
+	MaxChars  int
+	MaxTokens int
+	// Reprompt asks the model for a shorter answer instead of truncating.
+	Reprompt bool
+	Notice   string
+}
+
+func (l OutputLimit) enabled() bool {
+	return l.MaxChars > 0 || l.MaxTokens > 0
+}
+
+// check returns the length of the text of resp in characters and, if it
+// exceeds the limit, the number of characters to keep.
+func (l OutputLimit) check(resp *model.LLMResponse) (length, keep int, exceeded bool) {
+	length = utf8.RuneCountInString(responseText(resp.Content))
+	keep = length
+	if l.MaxChars > 0 && length > l.MaxChars {
+		keep, exceeded = l.MaxChars, true
+	}
+	if l.MaxTokens > 0 {
+		tokens := (length + charsPerToken - 1) / charsPerToken
+		if resp.UsageMetadata != nil && resp.UsageMetadata.CandidatesTokenCount > 0 {
+			tokens = int(resp.UsageMetadata.CandidatesTokenCount)
+		}
+		if tokens > l.MaxTokens {
+			keep, exceeded = min(keep, l.MaxTokens*charsPerToken), true
+		}
+	}
+	return length, keep, exceeded
+}
+
+// feedback returns the re-prompt for a response of the given length.
+func (l OutputLimit) feedback(length int) string {
+	var limits []string
+	if l.MaxChars > 0 {
+		limits = append(limits, fmt.Sprintf("%d characters", l.MaxChars))
+	}
+	if l.MaxTokens > 0 {
+		limits = append(limits, fmt.Sprintf("%d tokens", l.MaxTokens))
+	}
+	return fmt.Sprintf("response of %d characters exceeds the limit of %s; give a shorter answer", length, strings.Join(limits, " and "))
+}
+
+// responseText returns the text of the content, excluding thoughts.
+func responseText(c *genai.Content) string {
+	if c == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, p := range c.Parts {
+		if p.Text != "" && !p.Thought {
+			sb.WriteString(p.Text)
+		}
+	}
+	return sb.String()
+}
+
+// truncateContent returns a copy of c whose text is cut to keep characters
+// followed by the notice. Text parts past the limit are dropped.
+func truncateContent(c *genai.Content, keep int, notice string) *genai.Content {
+	out, last := cutContent(c, keep)
+	if last != nil {
+		last.Text += "\n\n" + notice
+	} else {
+		out.Parts = append(out.Parts, genai.NewPartFromText(notice))
+	}
+	return out
+}
+
+// cutContent returns a copy of c whose text is cut to keep characters, and
+// its last text part, if any. Text parts past the limit are dropped.
+func cutContent(c *genai.Content, keep int) (out *genai.Content, last *genai.Part) {
+	out = &genai.Content{Role: c.Role}
+	for _, p := range c.Parts {
+		if p.Text == "" || p.Thought {
+			out.Parts = append(out.Parts, p)
+			continue
+		}
+		if keep == 0 {
+			continue
+		}
+		cp := *p
+		if n := utf8.RuneCountInString(p.Text); n > keep {
+			cp.Text = string([]rune(p.Text)[:keep])
+			keep = 0
+		} else {
+			keep -= n
+		}
+		out.Parts = append(out.Parts, &cp)
+		last = &cp
+	}
+	return out, last
+}
+
+// maxStreamedChars returns the number of characters of a response that may
+// be streamed, estimating MaxTokens in characters since partial responses do
+// not report token counts, or 0 if it is not limited.
+func (l OutputLimit) maxStreamedChars() int {
+	limit := l.MaxChars
+	if l.MaxTokens > 0 {
+		if tokenChars := l.MaxTokens * charsPerToken; limit == 0 || tokenChars < limit {
+			limit = tokenChars
+		}
+	}
+	return limit
+}
+
+// streamCap enforces an OutputLimit on the partial responses of a streamed
+// model response, before the aggregated final response is checked.
+type streamCap struct {
+	limit    OutputLimit
+	streamed int
+}
+
+// apply cuts the text of the partial response resp so that the text
+// streamed so far stays within the limit, appending the notice of the limit
+// to the chunk reaching it unless the limit re-prompts the model. It
+// reports whether resp has anything left to forward.
+func (c *streamCap) apply(resp *model.LLMResponse) bool {
+	maxChars := c.limit.maxStreamedChars()
+	if maxChars == 0 || resp.Content == nil {
+		return true
+	}
+	n := utf8.RuneCountInString(responseText(resp.Content))
+	if n == 0 {
+		return true
+	}
+	keep := max(maxChars-c.streamed, 0)
+	reached := c.streamed < maxChars && c.streamed+n > maxChars
+	c.streamed += n
+	if n <= keep {
+		return true
+	}
+	if reached && !c.limit.Reprompt {
+		notice := c.limit.Notice
+		if notice == "" {
+			notice = DefaultOutputLimitNotice
+		}
+		resp.Content = truncateContent(resp.Content, keep, notice)
+		return true
+	}
+	resp.Content, _ = cutContent(resp.Content, keep)
+	return len(resp.Content.Parts) > 0
+}
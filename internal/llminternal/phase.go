@@ -0,0 +1,72 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/session"
+)
+
+// yieldPhase yields a phase event of the agent of ctx and records the phase
+// on the spans, if the run config asks for phase events. It reports whether
+// the caller may continue yielding.
+func yieldPhase(ctx agent.InvocationContext, spans []trace.Span, phase session.Phase, detail string, yield func(*session.Event, error) bool) bool {
+	if cfg := ctx.RunConfig(); cfg == nil || !cfg.PhaseEvents {
+		return true
+	}
+	telemetry.AddPhaseEvent(spans, string(phase), detail)
+	ev := session.NewPhaseEvent(ctx.InvocationID(), phase, detail)
+	ev.ID = session.NewEventID(ctx)
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	return yield(ev, nil)
+}
+
+// toolPhaseYielder returns the function reporting the PhaseCallingTool phase
+// of the tool calls to handleFunctionCalls, and a function reporting whether
+// the consumer of yield stopped while a phase was reported.
+func toolPhaseYielder(ctx agent.InvocationContext, yield func(*session.Event, error) bool) (onTool func([]trace.Span, string) bool, stopped func() bool) {
+	done := false
+	onTool = func(spans []trace.Span, name string) bool {
+		done = !yieldPhase(ctx, spans, session.PhaseCallingTool, name, yield)
+		return !done
+	}
+	return onTool, func() bool { return done }
+}
+
+// hasText reports whether the content has a text part that is not a
+// thought.
+func hasText(c *genai.Content) bool {
+	if c == nil {
+		return false
+	}
+	for _, p := range c.Parts {
+		if p != nil && p.Text != "" && !p.Thought {
+			return true
+		}
+	}
+	return false
+}
+
+// endSpans ends the spans of a stage abandoned when the consumer stopped.
+func endSpans(spans []trace.Span) {
+	for _, span := range spans {
+		span.End()
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"slices"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+)
+
+// FunctionCallsMetadataKey is the custom metadata key of the function calls
+// of an event stored without its content by the persist policy of its
+// agent, so that the run can be resumed if it is interrupted while the
+// tools are executing.
+const FunctionCallsMetadataKey = "function_calls"
+
+// FunctionCallsMarker returns a copy of ev without its content, keeping its
+// function calls, if any, under FunctionCallsMetadataKey.
+func FunctionCallsMarker(ev *session.Event) *session.Event {
+	marker := *ev
+	marker.LLMResponse = model.LLMResponse{}
+	if calls := utils.FunctionCalls(ev.Content); len(calls) > 0 {
+		marker.CustomMetadata = map[string]any{FunctionCallsMetadataKey: calls}
+	}
+	return &marker
+}
+
+// markedFunctionCalls returns the function calls kept by FunctionCallsMarker
+// in the metadata of ev. Services storing the metadata as JSON return them
+// decoded as generic values, so they are re-decoded through JSON.
+func markedFunctionCalls(ev *session.Event) []*genai.FunctionCall {
+	v, ok := ev.CustomMetadata[FunctionCallsMetadataKey]
+	if !ok || ev.Content != nil {
+		return nil
+	}
+	if calls, ok := v.([]*genai.FunctionCall); ok {
+		return calls
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var calls []*genai.FunctionCall
+	if err := json.Unmarshal(b, &calls); err != nil {
+		return nil
+	}
+	return calls
+}
+
+// InterruptedFunctionCalls returns the last event of the session if it has
+// function calls without a response, i.e. the run was interrupted while the
+// tools were executing. Calls of long-running tools are expected to have no
+// response yet and don't count. If the last event is a marker stored by
+// FunctionCallsMarker, a copy of it with its function calls as content is
+// returned.
+func InterruptedFunctionCalls(s session.Session) *session.Event {
+	events := s.Events()
+	if events.Len() == 0 {
+		return nil
+	}
+	responded := make(map[string]bool)
+	for ev := range events.All() {
+		for _, fr := range utils.FunctionResponses(ev.Content) {
+			responded[fr.ID] = true
+		}
+	}
+	last := events.At(events.Len() - 1)
+	if calls := markedFunctionCalls(last); len(calls) > 0 {
+		restored := *last
+		restored.Content = &genai.Content{Role: genai.RoleModel}
+		for _, fc := range calls {
+			restored.Content.Parts = append(restored.Content.Parts, &genai.Part{FunctionCall: fc})
+		}
+		last = &restored
+	}
+	for _, fc := range utils.FunctionCalls(last.Content) {
+		if !responded[fc.ID] && !slices.Contains(last.LongRunningToolIDs, fc.ID) {
+			return last
+		}
+	}
+	return nil
+}
+
+// resumeFunctionCalls executes the function calls of ev, an event of an
+// interrupted run, and yields the function response event followed by the
+// events of the agent the calls transfer to, if any.
+func (f *Flow) resumeFunctionCalls(ctx agent.InvocationContext, ev *session.Event) iter.Seq2[*session.Event, error] {
+	return func(yield func(*session.Event, error) bool) {
+		req := &model.LLMRequest{Model: f.Model.Name()}
+		if err := f.preprocess(ctx, req); err != nil {
+			yield(nil, err)
+			return
+		}
+		tools := make(map[string]tool.Tool)
+		for k, v := range req.Tools {
+			t, ok := v.(tool.Tool)
+			if !ok {
+				yield(nil, fmt.Errorf("unexpected tool type %T for tool %v", v, k))
+				return
+			}
+			tools[k] = t
+		}
+		onTool, stopped := toolPhaseYielder(ctx, yield)
+		respEv, err := f.handleFunctionCalls(ctx, tools, &ev.LLMResponse, onTool)
+		if stopped() {
+			return
+		}
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if respEv == nil || !yield(respEv, nil) {
+			return
+		}
+		f.transfer(ctx, respEv, yield)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/internal/llminternal/converters"
+	"google.golang.org/adk/internal/telemetry"
 	"google.golang.org/adk/model"
 )
 
@@ -34,6 +35,9 @@ type streamingResponseAggregator struct {
 	thoughtText string
 	response    *model.LLMResponse
 	role        string
+	// call accumulates the function call whose arguments are being streamed,
+	// if any.
+	call *streamedFunctionCall
 }
 
 // NewStreamingResponseAggregator creates a new, initialized streamingResponseAggregator.
@@ -53,6 +57,14 @@ func (s *streamingResponseAggregator) ProcessResponse(ctx context.Context, genRe
 		candidate := genResp.Candidates[0]
 		resp := converters.Genai2LLMResponse(genResp)
 		resp.TurnComplete = candidate.FinishReason != ""
+		call, fragments, err := s.accumulateFunctionCall(resp)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if call != nil {
+			telemetry.AddFunctionCallFinalizedEvent(telemetry.SpansFromContext(ctx), call.Name, call.ID, fragments)
+		}
 		// Aggregate the response and check if an intermediate event to yield was created
 		if aggrResp := s.aggregateResponse(resp); aggrResp != nil {
 			if !yield(aggrResp, nil) {
@@ -106,10 +118,28 @@ func (s *streamingResponseAggregator) aggregateResponse(llmResponse *model.LLMRe
 	return nil
 }
 
+// IncompleteFunctionCallErrorCode is the error code of the response closing
+// a stream that ended before the last fragment of a function call.
+const IncompleteFunctionCallErrorCode = "INCOMPLETE_FUNCTION_CALL"
+
 // Close generates an aggregated response at the end, if needed,
 // this should be called after all the model responses are processed.
+// A function call whose last fragment was not received is dropped, since
+// its arguments may be truncated: the aggregated response then reports
+// IncompleteFunctionCallErrorCode instead, so that the call is not executed.
 func (s *streamingResponseAggregator) Close() *model.LLMResponse {
-	return s.createAggregateResponse()
+	call := s.call
+	s.call = nil
+	resp := s.createAggregateResponse()
+	if call == nil {
+		return resp
+	}
+	if resp == nil {
+		resp = &model.LLMResponse{}
+	}
+	resp.ErrorCode = IncompleteFunctionCallErrorCode
+	resp.ErrorMessage = fmt.Sprintf("stream ended before the last fragment of function call %q", call.name)
+	return resp
 }
 
 func (s *streamingResponseAggregator) createAggregateResponse() *model.LLMResponse {
@@ -20,6 +20,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"google.golang.org/genai"
 
+	"google.golang.org/adk/internal/llminternal"
 	"google.golang.org/adk/internal/testutil"
 	"google.golang.org/adk/model"
 )
@@ -167,6 +168,53 @@ func TestStreamAggregator(t *testing.T) {
 				false, false, false,
 			},
 		},
+		{
+			name: "function call arguments streamed with interleaved text",
+			initialResponses: []*genai.Content{
+				genai.NewContentFromText("Let me check", "model"),
+				genai.NewContentFromParts([]*genai.Part{{FunctionCall: &genai.FunctionCall{
+					ID: "c1", Name: "get_weather", WillContinue: genai.Ptr(true),
+					PartialArgs: []*genai.PartialArg{{JsonPath: "$.city", StringValue: "Par", WillContinue: genai.Ptr(true)}},
+				}}}, "model"),
+				genai.NewContentFromText(" the weather.", "model"),
+				genai.NewContentFromParts([]*genai.Part{{FunctionCall: &genai.FunctionCall{
+					WillContinue: genai.Ptr(true),
+					PartialArgs: []*genai.PartialArg{
+						{JsonPath: "$.city", StringValue: "is"},
+						{JsonPath: "$.days[0]", NumberValue: genai.Ptr(1.0)},
+						{JsonPath: "$.options['metric units']", BoolValue: genai.Ptr(true)},
+					},
+				}}}, "model"),
+				genai.NewContentFromParts([]*genai.Part{{FunctionCall: &genai.FunctionCall{}}}, "model"),
+			},
+			numberOfStreamCalls:  1,
+			streamResponsesCount: 5,
+			want: []*genai.Content{
+				genai.NewContentFromText("Let me check", "model"),
+				genai.NewContentFromText("Let me check", "model"),
+				genai.NewContentFromParts([]*genai.Part{{FunctionCall: &genai.FunctionCall{
+					ID: "c1", Name: "get_weather", WillContinue: genai.Ptr(true),
+					PartialArgs: []*genai.PartialArg{{JsonPath: "$.city", StringValue: "Par", WillContinue: genai.Ptr(true)}},
+				}}}, "model"),
+				genai.NewContentFromText(" the weather.", "model"),
+				genai.NewContentFromText(" the weather.", "model"),
+				genai.NewContentFromParts([]*genai.Part{{FunctionCall: &genai.FunctionCall{
+					WillContinue: genai.Ptr(true),
+					PartialArgs: []*genai.PartialArg{
+						{JsonPath: "$.city", StringValue: "is"},
+						{JsonPath: "$.days[0]", NumberValue: genai.Ptr(1.0)},
+						{JsonPath: "$.options['metric units']", BoolValue: genai.Ptr(true)},
+					},
+				}}}, "model"),
+				genai.NewContentFromParts([]*genai.Part{{FunctionCall: &genai.FunctionCall{
+					ID: "c1", Name: "get_weather",
+					Args: map[string]any{"city": "Paris", "days": []any{1.0}, "options": map[string]any{"metric units": true}},
+				}}}, "model"),
+			},
+			wantPartial: []bool{
+				true, false, true, true, false, true, false,
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -205,3 +253,29 @@ func TestStreamAggregator(t *testing.T) {
 		})
 	}
 }
+
+func TestStreamAggregatorIncompleteFunctionCall(t *testing.T) {
+	aggregator := llminternal.NewStreamingResponseAggregator()
+	fragment := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{Content: genai.NewContentFromParts([]*genai.Part{{FunctionCall: &genai.FunctionCall{
+		Name: "get_weather", WillContinue: genai.Ptr(true),
+		PartialArgs: []*genai.PartialArg{{JsonPath: "$.city", StringValue: "Rome"}},
+	}}}, "model")}}}
+	for resp, err := range aggregator.ProcessResponse(t.Context(), fragment) {
+		if err != nil {
+			t.Fatalf("ProcessResponse() error = %v", err)
+		}
+		if !resp.Partial {
+			t.Errorf("ProcessResponse() returned a non-partial response for a function call fragment: %v", resp.Content)
+		}
+	}
+	got := aggregator.Close()
+	if got == nil {
+		t.Fatal("Close() = nil, want an error response")
+	}
+	if got.ErrorCode != llminternal.IncompleteFunctionCallErrorCode {
+		t.Errorf("Close() error code = %q, want %q", got.ErrorCode, llminternal.IncompleteFunctionCallErrorCode)
+	}
+	if got.Content != nil {
+		t.Errorf("Close() content = %v, want no function call", got.Content)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+)
+
+// toolResultsGroundingHeader introduces the grounding block of tool results.
+const toolResultsGroundingHeader = "Results of the most recent tool calls of this conversation. " +
+	"Base your answer on them rather than on assumptions:"
+
+// toolResultsGroundingRequestProcessor appends to the system instruction of
+// req a block repeating the responses of the last GroundToolResults function
+// calls of its contents, so that the model does not overlook them in long
+// tool-heavy turns.
+func toolResultsGroundingRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest) error {
+	llmAgent := asLLMAgent(ctx.Agent())
+	if llmAgent == nil {
+		return nil
+	}
+	responses := lastFunctionResponses(req, llmAgent.internal().GroundToolResults)
+	if len(responses) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(toolResultsGroundingHeader)
+	for _, r := range responses {
+		result, err := json.Marshal(r.Response)
+		if err != nil {
+			result = []byte(fmt.Sprint(r.Response))
+		}
+		fmt.Fprintf(&sb, "\n- %s: %s", r.Name, result)
+	}
+	utils.AppendInstructions(req, sb.String())
+	return nil
+}
+
+// lastFunctionResponses returns the responses of the last n function calls of
+// the contents of req, in order.
+func lastFunctionResponses(req *model.LLMRequest, n int) []*genai.FunctionResponse {
+	if n <= 0 {
+		return nil
+	}
+	var responses []*genai.FunctionResponse
+	for _, c := range slices.Backward(req.Contents) {
+		if c == nil {
+			continue
+		}
+		for _, p := range slices.Backward(c.Parts) {
+			if p.FunctionResponse != nil && len(responses) < n {
+				responses = append(responses, p.FunctionResponse)
+			}
+		}
+	}
+	slices.Reverse(responses)
+	return responses
+}
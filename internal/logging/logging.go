@@ -0,0 +1,95 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logging provides request-scoped structured logging for the run path.
+//
+// Every record logged through a logger from this package carries the trace_id
+// and span_id of the span found in the logging context, so log lines can be
+// joined with the corresponding traces.
+//
+// Only identifiers (app, user, session, invocation, agent, tool and model
+// names) are logged. Message content, tool arguments and tool results are
+// never logged.
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys used on log records.
+const (
+	KeyTraceID      = "trace_id"
+	KeySpanID       = "span_id"
+	KeyAppName      = "app_name"
+	KeyUserID       = "user_id"
+	KeySessionID    = "session_id"
+	KeyInvocationID = "invocation_id"
+	KeyAgentName    = "agent_name"
+)
+
+// New wraps the handler of the given logger so that trace and span IDs are
+// added to each record. If l is nil, slog.Default() is used.
+func New(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	if _, ok := l.Handler().(traceHandler); ok {
+		return l
+	}
+	return slog.New(traceHandler{Handler: l.Handler()})
+}
+
+// ToContext returns a copy of ctx carrying the logger.
+func ToContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerCtxKey, l)
+}
+
+// FromContext returns the logger stored in ctx, or a trace-correlated
+// slog.Default() if there is none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerCtxKey).(*slog.Logger); ok && l != nil {
+		return l
+	}
+	return New(nil)
+}
+
+type ctxKey int
+
+const loggerCtxKey ctxKey = 0
+
+// traceHandler decorates records with the IDs of the span in the context.
+type traceHandler struct {
+	slog.Handler
+}
+
+func (h traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String(KeyTraceID, sc.TraceID().String()),
+			slog.String(KeySpanID, sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestTraceCorrelation(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(slog.New(slog.NewJSONHandler(&buf, nil))).With(slog.String(KeySessionID, "s1"))
+
+	tp := sdktrace.NewTracerProvider()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+	defer span.End()
+
+	ctx = ToContext(ctx, logger)
+	FromContext(ctx).InfoContext(ctx, "hello")
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("failed to decode log record %q: %v", buf.String(), err)
+	}
+	want := map[string]string{
+		KeyTraceID:   span.SpanContext().TraceID().String(),
+		KeySpanID:    span.SpanContext().SpanID().String(),
+		KeySessionID: "s1",
+	}
+	for k, v := range want {
+		if got := record[k]; got != v {
+			t.Errorf("record[%q] = %v, want %q", k, got, v)
+		}
+	}
+}
+
+func TestNoSpan(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(slog.New(slog.NewJSONHandler(&buf, nil)))
+	logger.InfoContext(context.Background(), "hello")
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("failed to decode log record %q: %v", buf.String(), err)
+	}
+	if _, ok := record[KeyTraceID]; ok {
+		t.Errorf("record has %q without a span in context", KeyTraceID)
+	}
+}
+
+func TestNewIsIdempotent(t *testing.T) {
+	l := New(nil)
+	if got := New(l); got != l {
+		t.Errorf("New() wrapped an already trace-correlated logger")
+	}
+}
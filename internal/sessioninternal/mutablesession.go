@@ -17,6 +17,8 @@ package sessioninternal
 import (
 	"fmt"
 	"iter"
+	"slices"
+	"sync"
 	"time"
 
 	"google.golang.org/adk/session"
@@ -26,6 +28,19 @@ import (
 type MutableSession struct {
 	service       session.Service
 	storedSession session.Session
+
+	// Events of the invocation that are not stored, or stored without
+	// their content, see AddLocalEvent and ReplaceLocalEvent.
+	mu       sync.RWMutex
+	local    []localEvent
+	replaced map[string]*session.Event
+}
+
+// localEvent is an event that is not stored, following the first after
+// stored events.
+type localEvent struct {
+	after int
+	event *session.Event
 }
 
 // NewMutableSession creates and returns session.Session implementation.
@@ -52,8 +67,64 @@ func (s *MutableSession) ID() string {
 	return s.storedSession.ID()
 }
 
+// Events returns the stored events of the session and the local events of
+// the invocation, in the order they were added.
 func (s *MutableSession) Events() session.Events {
-	return s.storedSession.Events()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stored := s.storedSession.Events()
+	if len(s.local) == 0 && len(s.replaced) == 0 {
+		return stored
+	}
+	events := make(localEvents, 0, stored.Len()+len(s.local))
+	next := 0
+	for i, ev := range slices.Collect(stored.All()) {
+		for ; next < len(s.local) && s.local[next].after == i; next++ {
+			events = append(events, s.local[next].event)
+		}
+		if r, ok := s.replaced[ev.ID]; ok {
+			ev = r
+		}
+		events = append(events, ev)
+	}
+	for ; next < len(s.local); next++ {
+		events = append(events, s.local[next].event)
+	}
+	return events
+}
+
+// AddLocalEvent adds an event that is not stored in the session service, so
+// that agents of the invocation still see it.
+func (s *MutableSession) AddLocalEvent(ev *session.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.local = append(s.local, localEvent{after: s.storedSession.Events().Len(), event: ev})
+}
+
+// ReplaceLocalEvent shows ev instead of the stored event with the same ID,
+// e.g. an event stored without its content, to the agents of the
+// invocation.
+func (s *MutableSession) ReplaceLocalEvent(ev *session.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.replaced == nil {
+		s.replaced = make(map[string]*session.Event)
+	}
+	s.replaced[ev.ID] = ev
+}
+
+type localEvents []*session.Event
+
+func (e localEvents) All() iter.Seq[*session.Event] {
+	return slices.Values(e)
+}
+
+func (e localEvents) Len() int {
+	return len(e)
+}
+
+func (e localEvents) At(i int) *session.Event {
+	return e[i]
 }
 
 func (s *MutableSession) LastUpdateTime() time.Time {
@@ -0,0 +1,122 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"google.golang.org/adk/internal/logging"
+)
+
+// MetricExportFailures is the name of the counter of failed span exports and
+// span processor calls.
+const MetricExportFailures = "adk.telemetry.export_failures"
+
+// exportFailures counts export failures. Instruments of the global meter
+// provider forward to the provider set later with otel.SetMeterProvider.
+var exportFailures, _ = otel.Meter("google.golang.org/adk/telemetry").Int64Counter(MetricExportFailures,
+	metric.WithDescription("Number of failed span exports."),
+	metric.WithUnit("{failure}"))
+
+// recordExportFailure logs err at debug level and counts it.
+func recordExportFailure(ctx context.Context, op string, err error) {
+	logging.FromContext(ctx).DebugContext(ctx, "telemetry export failed", "operation", op, "error", err)
+	if exportFailures != nil {
+		exportFailures.Add(ctx, 1, metric.WithAttributes(attribute.String("operation", op)))
+	}
+}
+
+// recoverExportPanic turns a panic of an exporter or processor into a
+// recorded failure.
+func recoverExportPanic(ctx context.Context, op string) {
+	if r := recover(); r != nil {
+		recordExportFailure(ctx, op, fmt.Errorf("panic: %v", r))
+	}
+}
+
+// SafeExporter returns a span exporter that never fails: errors and panics of
+// e are logged at debug level, counted in [MetricExportFailures] and
+// swallowed, so an unavailable collector does not affect agent runs or stall
+// the span processor.
+func SafeExporter(e sdktrace.SpanExporter) sdktrace.SpanExporter {
+	if _, ok := e.(safeExporter); ok {
+		return e
+	}
+	return safeExporter{exporter: e}
+}
+
+type safeExporter struct {
+	exporter sdktrace.SpanExporter
+}
+
+func (e safeExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	defer recoverExportPanic(ctx, "export")
+	if err := e.exporter.ExportSpans(ctx, spans); err != nil {
+		recordExportFailure(ctx, "export", err)
+	}
+	return nil
+}
+
+func (e safeExporter) Shutdown(ctx context.Context) error {
+	defer recoverExportPanic(ctx, "shutdown")
+	if err := e.exporter.Shutdown(ctx); err != nil {
+		recordExportFailure(ctx, "shutdown", err)
+	}
+	return nil
+}
+
+// safeProcessor keeps panics and errors of a registered span processor out
+// of the run path. Spans end synchronously in the run path, so a panic in
+// OnEnd would otherwise abort the run.
+type safeProcessor struct {
+	processor sdktrace.SpanProcessor
+}
+
+func (p safeProcessor) OnStart(ctx context.Context, s sdktrace.ReadWriteSpan) {
+	defer recoverExportPanic(ctx, "start")
+	p.processor.OnStart(ctx, s)
+}
+
+func (p safeProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	defer recoverExportPanic(context.Background(), "end")
+	p.processor.OnEnd(s)
+}
+
+func (p safeProcessor) ForceFlush(ctx context.Context) error {
+	defer recoverExportPanic(ctx, "flush")
+	if err := p.processor.ForceFlush(ctx); err != nil {
+		recordExportFailure(ctx, "flush", err)
+	}
+	return nil
+}
+
+func (p safeProcessor) Shutdown(ctx context.Context) error {
+	defer recoverExportPanic(ctx, "shutdown")
+	if err := p.processor.Shutdown(ctx); err != nil {
+		recordExportFailure(ctx, "shutdown", err)
+	}
+	return nil
+}
+
+var (
+	_ sdktrace.SpanExporter  = safeExporter{}
+	_ sdktrace.SpanProcessor = safeProcessor{}
+)
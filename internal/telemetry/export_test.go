@@ -0,0 +1,102 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// failingExporter fails every export, as an exporter does when its
+// collector is down.
+type failingExporter struct {
+	calls int
+}
+
+func (e *failingExporter) ExportSpans(context.Context, []sdktrace.ReadOnlySpan) error {
+	e.calls++
+	return errors.New("collector unavailable")
+}
+
+func (e *failingExporter) Shutdown(context.Context) error {
+	return errors.New("collector unavailable")
+}
+
+// panickingProcessor panics when a span ends.
+type panickingProcessor struct {
+	sdktrace.SpanProcessor
+}
+
+func (panickingProcessor) OnEnd(sdktrace.ReadOnlySpan) {
+	panic("processor bug")
+}
+
+func TestExportFailuresAreSwallowed(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	prevMeterProvider := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(prevMeterProvider) })
+
+	exporter := &failingExporter{}
+	var errs []error
+	prevHandler := otel.GetErrorHandler()
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) { errs = append(errs, err) }))
+	t.Cleanup(func() { otel.SetErrorHandler(prevHandler) })
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(safeProcessor{processor: sdktrace.NewSimpleSpanProcessor(SafeExporter(exporter))}),
+		sdktrace.WithSpanProcessor(safeProcessor{processor: panickingProcessor{sdktrace.NewSimpleSpanProcessor(SafeExporter(exporter))}}),
+	)
+
+	for range 2 {
+		_, span := tp.Tracer("test").Start(t.Context(), "call_llm")
+		span.End()
+	}
+	if err := tp.Shutdown(t.Context()); err != nil {
+		t.Errorf("Shutdown() error = %v, want nil", err)
+	}
+
+	if exporter.calls != 2 {
+		t.Errorf("exporter got %d exports, want 2", exporter.calls)
+	}
+	if len(errs) != 0 {
+		t.Errorf("errors reported to the otel error handler: %v", errs)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(t.Context(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	var failures int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != MetricExportFailures {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				failures += dp.Value
+			}
+		}
+	}
+	// Two failed exports, two processor panics and two failed shutdowns.
+	if failures != 6 {
+		t.Errorf("%s = %d, want 6", MetricExportFailures, failures)
+	}
+}
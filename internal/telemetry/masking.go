@@ -0,0 +1,65 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync/atomic"
+)
+
+// idMasking is the configuration set with SetIDMasking.
+type idMasking struct {
+	salt          []byte
+	maskSessionID bool
+}
+
+var masking atomic.Pointer[idMasking]
+
+// SetIDMasking makes the user IDs, and the session IDs if maskSessionID is
+// set, recorded on spans salted hashes of the IDs. An empty salt restores
+// the recording of the IDs as they are.
+func SetIDMasking(salt []byte, maskSessionID bool) {
+	if len(salt) == 0 {
+		masking.Store(nil)
+		return
+	}
+	masking.Store(&idMasking{salt: append([]byte(nil), salt...), maskSessionID: maskSessionID})
+}
+
+// userIDForTrace returns the user ID as recorded on spans.
+func userIDForTrace(id string) string {
+	if m := masking.Load(); m != nil {
+		return maskID(m.salt, id)
+	}
+	return id
+}
+
+// sessionIDForTrace returns the session ID as recorded on spans.
+func sessionIDForTrace(id string) string {
+	if m := masking.Load(); m != nil && m.maskSessionID {
+		return maskID(m.salt, id)
+	}
+	return id
+}
+
+// maskID returns the hex-encoded HMAC-SHA256 of the ID keyed with the salt,
+// so that equal IDs have equal masks under the same salt.
+func maskID(salt []byte, id string) string {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
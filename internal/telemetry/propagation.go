@@ -0,0 +1,99 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// outboundPropagationDisabled stops the injection of trace context into
+// outgoing requests.
+var outboundPropagationDisabled atomic.Bool
+
+// Propagator returns the globally configured propagator or, if none is
+// configured, the W3C trace context propagator.
+func Propagator() propagation.TextMapPropagator {
+	p := otel.GetTextMapPropagator()
+	// The default global propagator propagates nothing.
+	if len(p.Fields()) == 0 {
+		return propagation.TraceContext{}
+	}
+	return p
+}
+
+// SetOutboundPropagation enables or disables the injection of trace context
+// into outgoing requests. It is enabled by default.
+func SetOutboundPropagation(enabled bool) {
+	outboundPropagationDisabled.Store(!enabled)
+}
+
+// InjectHeaders adds the trace context of ctx to the headers of an outgoing
+// request, unless outbound propagation is disabled.
+func InjectHeaders(ctx context.Context, h http.Header) {
+	if outboundPropagationDisabled.Load() {
+		return
+	}
+	Propagator().Inject(ctx, propagation.HeaderCarrier(h))
+}
+
+// ContextWithSpan returns a copy of ctx carrying the span of the global
+// tracer among spans, so that outgoing requests made with the returned
+// context continue the exported trace. All the spans can be retrieved with
+// SpansFromContext.
+func ContextWithSpan(ctx context.Context, spans []trace.Span) context.Context {
+	if len(spans) == 0 {
+		return ctx
+	}
+	ctx = context.WithValue(ctx, spansCtxKey{}, spans)
+	return trace.ContextWithSpan(ctx, spans[len(spans)-1])
+}
+
+// SpansFromContext returns the spans stored in ctx by ContextWithSpan, e.g.
+// the spans of the tool call running with ctx, or nil if there are none.
+func SpansFromContext(ctx context.Context) []trace.Span {
+	spans, _ := ctx.Value(spansCtxKey{}).([]trace.Span)
+	return spans
+}
+
+type spansCtxKey struct{}
+
+// Transport injects the trace context of each request's context into its
+// headers before passing it to Base.
+type Transport struct {
+	// Base is the underlying round tripper.
+	// optional: http.DefaultTransport is used if nil.
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if outboundPropagationDisabled.Load() {
+		return base.RoundTrip(req)
+	}
+	// A RoundTripper must not modify the request.
+	req = req.Clone(req.Context())
+	InjectHeaders(req.Context(), req.Header)
+	return base.RoundTrip(req)
+}
@@ -21,7 +21,13 @@ package telemetry
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -30,6 +36,7 @@ import (
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
+	agentinternal "google.golang.org/adk/internal/agent"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
@@ -41,9 +48,17 @@ type tracerProviderHolder struct {
 
 type tracerProviderConfig struct {
 	spanProcessors []sdktrace.SpanProcessor
-	mu             *sync.RWMutex
+	// provider is the local tracer provider once created from the config.
+	// Processors added afterwards are registered on it directly.
+	provider *sdktrace.TracerProvider
+	mu       *sync.RWMutex
 }
 
+// ErrTelemetryStarted is returned when a span processor is inserted at a
+// position after the local tracer provider was created, i.e. after the first
+// span: the provider can only append processors.
+var ErrTelemetryStarted = errors.New("telemetry already started: span processors can only be appended")
+
 var (
 	once              sync.Once
 	localTracer       tracerProviderHolder
@@ -61,104 +76,390 @@ const (
 	genAiToolCallID      = "gen_ai.tool.call.id"
 	genAiSystemName      = "gen_ai.system"
 
-	genAiRequestModelName = "gen_ai.request.model"
-	genAiRequestTopP      = "gen_ai.request.top_p"
-	genAiRequestMaxTokens = "gen_ai.request.max_tokens"
+	genAiRequestModelName      = "gen_ai.request.model"
+	genAiRequestTopP           = "gen_ai.request.top_p"
+	genAiRequestMaxTokens      = "gen_ai.request.max_tokens"
+	genAiRequestThinkingBudget = "gen_ai.request.thinking_budget"
 
 	genAiResponseFinishReason            = "gen_ai.response.finish_reason"
 	genAiResponsePromptTokenCount        = "gen_ai.response.prompt_token_count"
 	genAiResponseCandidatesTokenCount    = "gen_ai.response.candidates_token_count"
 	genAiResponseCachedContentTokenCount = "gen_ai.response.cached_content_token_count"
 	genAiResponseTotalTokenCount         = "gen_ai.response.total_token_count"
+	genAiResponseThoughtsTokenCount      = "gen_ai.response.thoughts_token_count"
 
-	gcpVertexAgentLLMRequestName   = "gcp.vertex.agent.llm_request"
-	gcpVertexAgentToolCallArgsName = "gcp.vertex.agent.tool_call_args"
-	gcpVertexAgentEventID          = "gcp.vertex.agent.event_id"
-	gcpVertexAgentToolResponseName = "gcp.vertex.agent.tool_response"
-	gcpVertexAgentLLMResponseName  = "gcp.vertex.agent.llm_response"
-	gcpVertexAgentInvocationID     = "gcp.vertex.agent.invocation_id"
-	gcpVertexAgentSessionID        = "gcp.vertex.agent.session_id"
+	// Keys relative to the attribute prefix, see agentKey.
+	gcpVertexAgentLLMRequestName   = "llm_request"
+	gcpVertexAgentToolCallArgsName = "tool_call_args"
+	gcpVertexAgentEventID          = "event_id"
+	gcpVertexAgentToolResponseName = "tool_response"
+	gcpVertexAgentLLMResponseName  = "llm_response"
+	gcpVertexAgentInvocationID     = "invocation_id"
+	gcpVertexAgentSessionID        = "session_id"
+	gcpVertexAgentUserID           = "user_id"
+	gcpVertexAgentAgentName        = "agent_name"
+	gcpVertexAgentReprompts        = "reprompt_count"
+	gcpVertexAgentAccepted         = "response_accepted"
+	gcpVertexAgentToolDenied       = "tool_denied"
+	gcpVertexAgentToolBudgetMs     = "tool_budget_ms"
+	gcpVertexAgentRemainingBudget  = "remaining_budget_ms"
+	gcpVertexAgentBudgetExhausted  = "budget_exhausted"
+	gcpVertexAgentMergedToolNames  = "merged_tool_names"
+	gcpVertexAgentMergedToolIDs    = "merged_tool_call_ids"
+	gcpVertexAgentSafetyScore      = "safety_score."
+	gcpVertexAgentSafetyBlocked    = "safety_blocked"
+	gcpVertexAgentFunctionCalling  = "function_calling_mode"
+	gcpVertexAgentAllowedFunctions = "allowed_function_names"
+	gcpVertexAgentQueueWaitMs      = "queue_wait_ms"
+	gcpVertexAgentRunRejected      = "run_rejected"
+	gcpVertexAgentOutputCapped     = "output_capped"
+	gcpVertexAgentOutputChars      = "output_chars"
+	gcpVertexAgentToolOutputSchema = "tool_output_schema"
+	gcpVertexAgentContextOverflow  = "context_overflow"
+	gcpVertexAgentEstimatedTokens  = "estimated_tokens"
+	gcpVertexAgentContextWindow    = "context_window_tokens"
+	gcpVertexAgentSQLQuery         = "sql_query"
+	gcpVertexAgentLabel            = "label."
+	gcpVertexAgentTaskID           = "task_id"
+	gcpVertexAgentTaskFireAt       = "task_fire_at"
+	gcpVertexAgentTaskLatenessMs   = "task_lateness_ms"
+	gcpVertexAgentTaskFailed       = "task_failed"
+	gcpVertexAgentCallFragments    = "function_call_fragments"
+	gcpVertexAgentRunDurationMs    = "run_duration_ms"
+	gcpVertexAgentRunTimedOut      = "run_timed_out"
+	gcpVertexAgentEventKind        = "event_kind"
+	gcpVertexAgentLLMCoalesced     = "llm_coalesced"
+	gcpVertexAgentNotifyTo         = "notification_recipients"
+	gcpVertexAgentNotifySubject    = "notification_subject"
+	gcpVertexAgentNotifyDryRun     = "notification_dry_run"
+	gcpVertexAgentPhase            = "phase"
+	gcpVertexAgentToolStatus       = "tool_status"
+	gcpVertexAgentToolErrorCode    = "tool_error_code"
+	gcpVertexAgentToolCallsCapped  = "tool_calls_capped"
+	gcpVertexAgentMaxToolCalls     = "max_tool_calls"
+	gcpVertexAgentPipelineStep     = "pipeline_step"
+	gcpVertexAgentPipelineSteps    = "pipeline_steps"
+	gcpVertexAgentLoopIterations   = "loop_iterations"
+	gcpVertexAgentPhaseDetail      = "phase_detail"
+	gcpVertexAgentInstruction      = "system_instruction"
+	gcpVertexAgentTransferDepth    = "transfer_depth"
+	gcpVertexAgentConfiguredModel  = "configured_model"
+	gcpVertexAgentEffectiveModel   = "effective_model"
+	gcpVertexAgentRawToolResponse  = "tool_raw_response"
+	gcpVertexAgentGroundedResults  = "grounded_tool_results"
+	gcpVertexAgentRetryBudget      = "retry_budget_remaining"
+	gcpVertexAgentQueueDepth       = "agent_queue_depth"
+	gcpVertexAgentToolArgPath      = "tool_arg_path"
+	gcpVertexAgentToolArgType      = "tool_arg_type"
+	gcpVertexAgentGenSeed          = "generation_seed"
+	gcpVertexAgentGenTemperature   = "generation_temperature"
+	gcpVertexAgentGenTopP          = "generation_top_p"
+	gcpVertexAgentGenDropped       = "generation_overrides_dropped"
+	gcpVertexAgentTargetLanguage   = "translation_target_language"
+	gcpVertexAgentTranslationCache = "translation_cached"
+	gcpVertexAgentToolConcurrency  = "tool_concurrency_limit"
+	gcpVertexAgentToolConcurrent   = "tool_concurrency_max_observed"
 
 	executeToolName = "execute_tool"
+	invokeAgentName = "invoke_agent"
+	checkOutputName = "check_output"
 	mergeToolName   = "(merged tools)"
+
+	taskScheduledEventName = "task_scheduled"
+	taskFiredEventName     = "task_fired"
+
+	functionCallFinalizedEventName = "function_call_finalized"
+	phaseEventName                 = "phase"
+	toolArgCoercedEventName        = "tool_arg_coerced"
 )
 
-// AddSpanProcessor adds a span processor to the local tracer config.
-func AddSpanProcessor(processor sdktrace.SpanProcessor) {
+// DefaultAttributePrefix is the default prefix of the keys of the ADK span
+// attributes.
+const DefaultAttributePrefix = systemName
+
+var attributePrefix atomic.Pointer[string]
+
+// SetAttributePrefix sets the prefix of the keys of the ADK span attributes,
+// e.g. with the prefix "example.agent" the event ID is recorded as
+// "example.agent.event_id". An empty prefix restores [DefaultAttributePrefix].
+func SetAttributePrefix(prefix string) {
+	prefix = strings.TrimSuffix(prefix, ".")
+	if prefix == "" {
+		prefix = DefaultAttributePrefix
+	}
+	attributePrefix.Store(&prefix)
+}
+
+// agentKey returns the key of the named attribute under the configured
+// prefix.
+func agentKey(name string) string {
+	prefix := DefaultAttributePrefix
+	if p := attributePrefix.Load(); p != nil {
+		prefix = *p
+	}
+	return prefix + "." + name
+}
+
+// EventIDKey returns the key of the event ID attribute under the configured
+// prefix.
+func EventIDKey() string {
+	return agentKey(gcpVertexAgentEventID)
+}
+
+// PayloadKeys returns the keys, under the configured prefix, of the
+// attributes holding serialized payloads: LLM requests and responses, tool
+// arguments, responses and output schemas.
+func PayloadKeys() []string {
+	return []string{
+		agentKey(gcpVertexAgentLLMRequestName),
+		agentKey(gcpVertexAgentLLMResponseName),
+		agentKey(gcpVertexAgentToolCallArgsName),
+		agentKey(gcpVertexAgentToolResponseName),
+		agentKey(gcpVertexAgentToolOutputSchema),
+	}
+}
+
+// AddSpanProcessor appends a span processor to the local tracer config.
+// Processors are called in the order of the config, both when a span starts
+// and when it ends.
+// Panics and errors of the processor are recorded as export failures and do
+// not reach the run path.
+// If the local tracer provider already exists, the processor is registered
+// on it and receives the spans started from then on.
+func AddSpanProcessor(processor sdktrace.SpanProcessor) error {
+	return AddSpanProcessors(processor)
+}
+
+// AddSpanProcessors appends the span processors to the local tracer config,
+// in order, as AddSpanProcessor. Either all processors are added or, on
+// error, none.
+func AddSpanProcessors(processors ...sdktrace.SpanProcessor) error {
+	wrapped := make([]sdktrace.SpanProcessor, len(processors))
+	for i, processor := range processors {
+		if processor == nil {
+			return fmt.Errorf("span processor %d is nil", i)
+		}
+		wrapped[i] = safeProcessor{processor: processor}
+	}
+	localTracerConfig.mu.Lock()
+	defer localTracerConfig.mu.Unlock()
+	if tp := localTracerConfig.provider; tp != nil {
+		for _, processor := range wrapped {
+			tp.RegisterSpanProcessor(processor)
+		}
+	}
+	localTracerConfig.spanProcessors = append(localTracerConfig.spanProcessors, wrapped...)
+	return nil
+}
+
+// InsertSpanProcessorAt inserts a span processor at the given index of the
+// local tracer config, e.g. at 0 to call it before the processors already
+// added. The index is clamped to the bounds of the config.
+// It returns ErrTelemetryStarted if the local tracer provider already exists,
+// also reporting it to the OpenTelemetry error handler.
+func InsertSpanProcessorAt(index int, processor sdktrace.SpanProcessor) error {
+	if processor == nil {
+		return fmt.Errorf("span processor is nil")
+	}
 	localTracerConfig.mu.Lock()
 	defer localTracerConfig.mu.Unlock()
-	localTracerConfig.spanProcessors = append(localTracerConfig.spanProcessors, processor)
+	if localTracerConfig.provider != nil {
+		return lateRegistration()
+	}
+	index = min(max(index, 0), len(localTracerConfig.spanProcessors))
+	localTracerConfig.spanProcessors = slices.Insert(localTracerConfig.spanProcessors, index, sdktrace.SpanProcessor(safeProcessor{processor: processor}))
+	return nil
+}
+
+// lateRegistration reports a span processor inserted after the start of
+// telemetry to the OpenTelemetry error handler, so that it is noticed even if
+// the caller drops the returned error.
+func lateRegistration() error {
+	otel.Handle(ErrTelemetryStarted)
+	return ErrTelemetryStarted
+}
+
+// SpanProcessorCount returns the number of span processors of the local
+// tracer config.
+func SpanProcessorCount() int {
+	localTracerConfig.mu.RLock()
+	defer localTracerConfig.mu.RUnlock()
+	return len(localTracerConfig.spanProcessors)
 }
 
 // RegisterTelemetry sets up the local tracer that will be used to emit traces.
 // We use local tracer to respect the global tracer configurations.
+// Span processors added afterwards are registered on the local tracer.
 func RegisterTelemetry() {
 	once.Do(func() {
-		traceProvider := sdktrace.NewTracerProvider()
-		localTracerConfig.mu.RLock()
-		spanProcessors := localTracerConfig.spanProcessors
-		localTracerConfig.mu.RUnlock()
-		for _, processor := range spanProcessors {
-			traceProvider.RegisterSpanProcessor(processor)
-		}
-		localTracer = tracerProviderHolder{tp: traceProvider}
+		localTracer = tracerProviderHolder{tp: startLocalTracerProvider()}
 	})
 }
 
+// startLocalTracerProvider creates the tracer provider of the local tracer
+// config, to which later processors are added.
+func startLocalTracerProvider() *sdktrace.TracerProvider {
+	localTracerConfig.mu.Lock()
+	defer localTracerConfig.mu.Unlock()
+	localTracerConfig.provider = tracerProviderWith(localTracerConfig.spanProcessors)
+	return localTracerConfig.provider
+}
+
+// newLocalTracerProvider returns a tracer provider calling the processors of
+// the local tracer config in order.
+func newLocalTracerProvider() *sdktrace.TracerProvider {
+	localTracerConfig.mu.RLock()
+	defer localTracerConfig.mu.RUnlock()
+	return tracerProviderWith(localTracerConfig.spanProcessors)
+}
+
+func tracerProviderWith(spanProcessors []sdktrace.SpanProcessor) *sdktrace.TracerProvider {
+	traceProvider := sdktrace.NewTracerProvider()
+	for _, processor := range spanProcessors {
+		traceProvider.RegisterSpanProcessor(processor)
+	}
+	return traceProvider
+}
+
 // If the global tracer is not set, the default NoopTracerProvider will be used.
 // That means that the spans are NOT recording/exporting
 // If the local tracer is not set, we'll set up tracer with all registered span processors.
-func getTracers() []trace.Tracer {
-	if localTracer.tp == nil {
-		RegisterTelemetry()
-	}
+func getTracers(serviceName string) []trace.Tracer {
+	// RegisterTelemetry is a no-op once the local tracer is set; calling it
+	// also makes the local tracer safe to read from concurrent runs.
+	RegisterTelemetry()
 	return []trace.Tracer{
-		localTracer.tp.Tracer(systemName),
-		otel.GetTracerProvider().Tracer(systemName),
+		localTracer.tp.Tracer(serviceName),
+		otel.GetTracerProvider().Tracer(serviceName),
 	}
 }
 
+type serviceNameCtxKey struct{}
+
+// WithServiceName returns a copy of ctx selecting the name of the tracers
+// starting the spans with StartTrace. An empty name selects the default
+// name, gcp.vertex.agent.
+func WithServiceName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, serviceNameCtxKey{}, name)
+}
+
+// ServiceName returns the name of the tracers starting spans with ctx: the
+// name configured on the agent of the invocation context if any, else the
+// name set with WithServiceName, else gcp.vertex.agent.
+func ServiceName(ctx context.Context) string {
+	if agentCtx, ok := ctx.(agent.InvocationContext); ok {
+		if a, ok := agentCtx.Agent().(agentinternal.Agent); ok {
+			if name := agentinternal.Reveal(a).TracerServiceName; name != "" {
+				return name
+			}
+		}
+	}
+	if name, ok := ctx.Value(serviceNameCtxKey{}).(string); ok && name != "" {
+		return name
+	}
+	return systemName
+}
+
+type labelsCtxKey struct{}
+
+// WithLabels returns a copy of ctx carrying the labels of a run, recorded on
+// the spans started with StartTrace.
+func WithLabels(ctx context.Context, labels map[string]string) context.Context {
+	if len(labels) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, labelsCtxKey{}, labels)
+}
+
+func labelAttributes(ctx context.Context) []attribute.KeyValue {
+	labels, _ := ctx.Value(labelsCtxKey{}).(map[string]string)
+	attributes := make([]attribute.KeyValue, 0, len(labels))
+	for k, v := range labels {
+		attributes = append(attributes, attribute.String(agentKey(gcpVertexAgentLabel)+k, v))
+	}
+	return attributes
+}
+
+type disabledCtxKey struct{}
+
+// WithDisabled returns a copy of ctx for which StartTrace starts no spans, so
+// that the helpers of this package record nothing for the run.
+func WithDisabled(ctx context.Context) context.Context {
+	return context.WithValue(ctx, disabledCtxKey{}, true)
+}
+
+// Disabled reports whether telemetry is disabled for ctx with WithDisabled.
+func Disabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(disabledCtxKey{}).(bool)
+	return disabled
+}
+
 // StartTrace returns two spans to start emitting events, one from global tracer and second from the local.
+// The tracers are named after ServiceName(ctx). The spans carry the labels
+// set with WithLabels.
+// It returns no spans if telemetry is disabled for ctx, in which case the
+// helpers of this package given the spans neither serialize nor record
+// attributes.
 func StartTrace(ctx context.Context, traceName string) []trace.Span {
-	tracers := getTracers()
+	if Disabled(ctx) {
+		return nil
+	}
+	tracers := getTracers(ServiceName(ctx))
+	labels := labelAttributes(ctx)
 	spans := make([]trace.Span, len(tracers))
 	for i, tracer := range tracers {
-		_, span := tracer.Start(ctx, traceName)
+		_, span := tracer.Start(ctx, traceName, trace.WithAttributes(labels...))
 		spans[i] = span
 	}
 	return spans
 }
 
 // TraceMergedToolCalls traces the tool execution events.
-func TraceMergedToolCalls(spans []trace.Span, fnResponseEvent *session.Event) {
+//
+// The tool name stays "(merged tools)" for the UI; the names and call IDs of
+// the merged calls are recorded in separate attributes, in the same order.
+func TraceMergedToolCalls(spans []trace.Span, agentCtx agent.InvocationContext, modelName string, fnResponseEvent *session.Event) {
 	if fnResponseEvent == nil {
 		return
 	}
+	var toolNames, callIDs []string
+	if fnResponseEvent.Content != nil {
+		for _, part := range fnResponseEvent.Content.Parts {
+			if fr := part.FunctionResponse; fr != nil {
+				toolNames = append(toolNames, fr.Name)
+				callIDs = append(callIDs, fr.ID)
+			}
+		}
+	}
 	for _, span := range spans {
-		attributes := []attribute.KeyValue{
+		attributes := append(commonAttributes(agentCtx, modelName),
 			attribute.String(genAiOperationName, executeToolName),
 			attribute.String(genAiToolName, mergeToolName),
 			attribute.String(genAiToolDescription, mergeToolName),
 			// Setting empty llm request and response (as UI expect these) while not
 			// applicable for tool_response.
-			attribute.String(gcpVertexAgentLLMRequestName, "{}"),
-			attribute.String(gcpVertexAgentLLMRequestName, "{}"),
-			attribute.String(gcpVertexAgentToolCallArgsName, "N/A"),
-			attribute.String(gcpVertexAgentEventID, fnResponseEvent.ID),
-			attribute.String(gcpVertexAgentToolResponseName, safeSerialize(fnResponseEvent)),
-		}
+			attribute.String(agentKey(gcpVertexAgentLLMRequestName), "{}"),
+			attribute.String(agentKey(gcpVertexAgentLLMRequestName), "{}"),
+			attribute.String(agentKey(gcpVertexAgentToolCallArgsName), "N/A"),
+			attribute.String(agentKey(gcpVertexAgentEventID), fnResponseEvent.ID),
+			attribute.String(agentKey(gcpVertexAgentEventKind), string(session.KindOf(fnResponseEvent))),
+			attribute.String(agentKey(gcpVertexAgentToolResponseName), safeSerialize(eventToTrace(fnResponseEvent))),
+			attribute.StringSlice(agentKey(gcpVertexAgentMergedToolNames), toolNames),
+			attribute.StringSlice(agentKey(gcpVertexAgentMergedToolIDs), callIDs),
+		)
 		span.SetAttributes(attributes...)
 		span.End()
 	}
 }
 
 // TraceToolCall traces the tool execution events.
-func TraceToolCall(spans []trace.Span, tool tool.Tool, fnArgs map[string]any, fnResponseEvent *session.Event) {
+func TraceToolCall(spans []trace.Span, agentCtx agent.InvocationContext, modelName string, tool tool.Tool, fnArgs map[string]any, fnResponseEvent *session.Event) {
 	if fnResponseEvent == nil {
 		return
 	}
 	for _, span := range spans {
-		attributes := []attribute.KeyValue{
+		attributes := append(customAttributes(agentCtx, tool), commonAttributes(agentCtx, modelName)...)
+		attributes = append(attributes,
 			attribute.String(genAiOperationName, executeToolName),
 			attribute.String(genAiToolName, tool.Name()),
 			attribute.String(genAiToolDescription, tool.Description()),
@@ -166,14 +467,16 @@ func TraceToolCall(spans []trace.Span, tool tool.Tool, fnArgs map[string]any, fn
 
 			// Setting empty llm request and response (as UI expect these) while not
 			// applicable for tool_response.
-			attribute.String(gcpVertexAgentLLMRequestName, "{}"),
-			attribute.String(gcpVertexAgentLLMRequestName, "{}"),
-			attribute.String(gcpVertexAgentToolCallArgsName, safeSerialize(fnArgs)),
-			attribute.String(gcpVertexAgentEventID, fnResponseEvent.ID),
-		}
+			attribute.String(agentKey(gcpVertexAgentLLMRequestName), "{}"),
+			attribute.String(agentKey(gcpVertexAgentLLMRequestName), "{}"),
+			attribute.String(agentKey(gcpVertexAgentToolCallArgsName), safeSerialize(fnArgs)),
+			attribute.String(agentKey(gcpVertexAgentEventID), fnResponseEvent.ID),
+			attribute.String(agentKey(gcpVertexAgentEventKind), string(session.KindOf(fnResponseEvent))),
+		)
 
 		toolCallID := "<not specified>"
 		toolResponse := "<not specified>"
+		var response map[string]any
 
 		if fnResponseEvent.LLMResponse.Content != nil {
 			responseParts := fnResponseEvent.LLMResponse.Content.Parts
@@ -187,13 +490,408 @@ func TraceToolCall(spans []trace.Span, tool tool.Tool, fnArgs map[string]any, fn
 					if functionResponse.Response != nil {
 						toolResponse = safeSerialize(functionResponse.Response)
 					}
+					response = functionResponse.Response
 				}
 			}
 		}
 
 		attributes = append(attributes, attribute.String(genAiToolCallID, toolCallID))
-		attributes = append(attributes, attribute.String(gcpVertexAgentToolResponseName, toolResponse))
+		attributes = append(attributes, attribute.String(agentKey(gcpVertexAgentToolResponseName), toolResponse))
+		attributes = append(attributes, toolStatusAttributes(response)...)
+		if d, ok := tool.(interface {
+			Declaration() *genai.FunctionDeclaration
+		}); ok {
+			if decl := d.Declaration(); decl != nil && decl.ResponseJsonSchema != nil {
+				attributes = append(attributes, attribute.String(agentKey(gcpVertexAgentToolOutputSchema), safeSerialize(decl.ResponseJsonSchema)))
+			}
+		}
+
+		span.SetAttributes(attributes...)
+		span.End()
+	}
+}
+
+// toolStatusAttributes returns the status of a tool call, error or ok, and
+// the code of the error reported by its response, see tool.ErrorResponse.
+func toolStatusAttributes(response map[string]any) []attribute.KeyValue {
+	code, _, failed := tool.ResponseError(response)
+	if !failed {
+		return []attribute.KeyValue{attribute.String(agentKey(gcpVertexAgentToolStatus), "ok")}
+	}
+	return []attribute.KeyValue{
+		attribute.String(agentKey(gcpVertexAgentToolStatus), "error"),
+		attribute.String(agentKey(gcpVertexAgentToolErrorCode), code),
+	}
+}
+
+// TraceToolDenied traces a function call rejected by the tool allow/deny
+// lists of the agent.
+func TraceToolDenied(spans []trace.Span, agentCtx agent.InvocationContext, modelName, toolName string, fnArgs map[string]any, fnResponseEvent *session.Event) {
+	traceRejectedToolCall(spans, agentCtx, modelName, toolName, fnArgs, fnResponseEvent, attribute.Bool(agentKey(gcpVertexAgentToolDenied), true))
+}
+
+// TraceToolCallCapped traces a function call not run because the model
+// response exceeded the maximum number of tool calls per turn.
+func TraceToolCallCapped(spans []trace.Span, agentCtx agent.InvocationContext, modelName, toolName string, fnArgs map[string]any, fnResponseEvent *session.Event, maxCalls int) {
+	traceRejectedToolCall(spans, agentCtx, modelName, toolName, fnArgs, fnResponseEvent,
+		attribute.Bool(agentKey(gcpVertexAgentToolCallsCapped), true),
+		attribute.Int(agentKey(gcpVertexAgentMaxToolCalls), maxCalls),
+	)
+}
+
+// traceRejectedToolCall traces a function call answered without running the
+// tool, with the given attributes describing the rejection.
+func traceRejectedToolCall(spans []trace.Span, agentCtx agent.InvocationContext, modelName, toolName string, fnArgs map[string]any, fnResponseEvent *session.Event, rejection ...attribute.KeyValue) {
+	for _, span := range spans {
+		attributes := append(commonAttributes(agentCtx, modelName),
+			attribute.String(genAiOperationName, executeToolName),
+			attribute.String(genAiToolName, toolName),
+			// Setting empty llm request and response (as UI expect these) while not
+			// applicable for tool_response.
+			attribute.String(agentKey(gcpVertexAgentLLMRequestName), "{}"),
+			attribute.String(agentKey(gcpVertexAgentToolCallArgsName), safeSerialize(fnArgs)),
+			attribute.String(agentKey(gcpVertexAgentEventID), fnResponseEvent.ID),
+			attribute.String(agentKey(gcpVertexAgentEventKind), string(session.KindOf(fnResponseEvent))),
+			attribute.String(agentKey(gcpVertexAgentToolResponseName), safeSerialize(fnResponseEvent.Content.Parts[0].FunctionResponse.Response)),
+		)
+		attributes = append(attributes, rejection...)
+		attributes = append(attributes, toolStatusAttributes(fnResponseEvent.Content.Parts[0].FunctionResponse.Response)...)
+		span.SetAttributes(attributes...)
+		span.End()
+	}
+}
+
+// SetToolBudget records the remaining budget of the invocation at the start
+// of a tool call.
+func SetToolBudget(spans []trace.Span, remaining time.Duration) {
+	for _, span := range spans {
+		span.SetAttributes(attribute.Int64(agentKey(gcpVertexAgentToolBudgetMs), remaining.Milliseconds()))
+	}
+}
+
+// SetRemainingBudget records the remaining budget of the invocation reported
+// to the model at the start of a model call.
+func SetRemainingBudget(spans []trace.Span, remaining time.Duration) {
+	for _, span := range spans {
+		span.SetAttributes(attribute.Int64(agentKey(gcpVertexAgentRemainingBudget), remaining.Milliseconds()))
+	}
+}
+
+// SetInstruction records the system instruction sent to the model, as
+// composed by the instruction providers of the agent.
+func SetInstruction(spans []trace.Span, instruction string) {
+	for _, span := range spans {
+		span.SetAttributes(attribute.String(agentKey(gcpVertexAgentInstruction), instruction))
+	}
+}
+
+// SetRawToolResponse records the response of a tool as returned by the tool,
+// before its post-processor transformed the response sent to the model.
+func SetRawToolResponse(spans []trace.Span, raw map[string]any) {
+	for _, span := range spans {
+		span.SetAttributes(attribute.String(agentKey(gcpVertexAgentRawToolResponse), safeSerialize(raw)))
+	}
+}
+
+// SetGroundedToolResults records the number of tool results repeated in the
+// grounding block of the system instruction of a model call.
+func SetGroundedToolResults(spans []trace.Span, count int) {
+	for _, span := range spans {
+		span.SetAttributes(attribute.Int(agentKey(gcpVertexAgentGroundedResults), count))
+	}
+}
+
+// SetModels records the model configured for the agent and the model called
+// instead, which differ if the run overrides the model.
+func SetModels(spans []trace.Span, configured, effective string) {
+	for _, span := range spans {
+		span.SetAttributes(
+			attribute.String(agentKey(gcpVertexAgentConfiguredModel), configured),
+			attribute.String(agentKey(gcpVertexAgentEffectiveModel), effective),
+		)
+	}
+}
+
+// SetGenerationOverrides records the effective generation parameters of a
+// model request of a run overriding them, and the overrides dropped because
+// the model does not support them. Parameters left unset are not recorded.
+func SetGenerationOverrides(spans []trace.Span, config *genai.GenerateContentConfig, dropped []string) {
+	var attrs []attribute.KeyValue
+	if config != nil && config.Seed != nil {
+		attrs = append(attrs, attribute.Int(agentKey(gcpVertexAgentGenSeed), int(*config.Seed)))
+	}
+	if config != nil && config.Temperature != nil {
+		attrs = append(attrs, attribute.Float64(agentKey(gcpVertexAgentGenTemperature), float64(*config.Temperature)))
+	}
+	if config != nil && config.TopP != nil {
+		attrs = append(attrs, attribute.Float64(agentKey(gcpVertexAgentGenTopP), float64(*config.TopP)))
+	}
+	if len(dropped) > 0 {
+		attrs = append(attrs, attribute.StringSlice(agentKey(gcpVertexAgentGenDropped), dropped))
+	}
+	for _, span := range spans {
+		span.SetAttributes(attrs...)
+	}
+}
+
+// SetTransferDepth records the number of agent transfers that led to the
+// agent making the model call.
+func SetTransferDepth(spans []trace.Span, depth int) {
+	for _, span := range spans {
+		span.SetAttributes(attribute.Int(agentKey(gcpVertexAgentTransferDepth), depth))
+	}
+}
+
+// SetToolConcurrency records the limit on the tool calls of a run executing
+// at the same time, zero if they are not limited, and the maximum number
+// observed so far.
+func SetToolConcurrency(spans []trace.Span, limit, maxObserved int) {
+	for _, span := range spans {
+		span.SetAttributes(
+			attribute.Int(agentKey(gcpVertexAgentToolConcurrency), limit),
+			attribute.Int(agentKey(gcpVertexAgentToolConcurrent), maxObserved),
+		)
+	}
+}
+
+// SetRetryBudget records the number of retries left to the calls of the
+// invocation.
+func SetRetryBudget(spans []trace.Span, remaining int) {
+	for _, span := range spans {
+		span.SetAttributes(attribute.Int(agentKey(gcpVertexAgentRetryBudget), remaining))
+	}
+}
+
+// SetSafety records the verdict of the safety classifier on the response of
+// a model call. Each score is recorded under its own attribute.
+func SetSafety(spans []trace.Span, scores map[string]float64, blocked bool) {
+	attributes := []attribute.KeyValue{attribute.Bool(agentKey(gcpVertexAgentSafetyBlocked), blocked)}
+	for category, score := range scores {
+		attributes = append(attributes, attribute.Float64(agentKey(gcpVertexAgentSafetyScore)+category, score))
+	}
+	for _, span := range spans {
+		span.SetAttributes(attributes...)
+	}
+}
+
+// SetLLMCoalesced records that the model call shared the backend round trip
+// of an identical concurrent call instead of issuing its own.
+func SetLLMCoalesced(spans []trace.Span) {
+	for _, span := range spans {
+		span.SetAttributes(attribute.Bool(agentKey(gcpVertexAgentLLMCoalesced), true))
+	}
+}
+
+// SetOutputCapped records that a response of the given length in characters
+// exceeded the output limit of the agent.
+func SetOutputCapped(spans []trace.Span, length int) {
+	for _, span := range spans {
+		span.SetAttributes(
+			attribute.Bool(agentKey(gcpVertexAgentOutputCapped), true),
+			attribute.Int(agentKey(gcpVertexAgentOutputChars), length),
+		)
+	}
+}
+
+// SetSQLQuery records the SQL query run by a tool call. The query is
+// recorded with its placeholders, not with the values of its parameters.
+func SetSQLQuery(spans []trace.Span, query string) {
+	for _, span := range spans {
+		span.SetAttributes(attribute.String(agentKey(gcpVertexAgentSQLQuery), query))
+	}
+}
+
+// SetTranslation records the target language of a translation made by a
+// tool call, and whether it was served from the cache of the tool. The text
+// is not recorded.
+func SetTranslation(spans []trace.Span, targetLanguage string, cached bool) {
+	for _, span := range spans {
+		span.SetAttributes(
+			attribute.String(agentKey(gcpVertexAgentTargetLanguage), targetLanguage),
+			attribute.Bool(agentKey(gcpVertexAgentTranslationCache), cached),
+		)
+	}
+}
+
+// SetNotification records the recipients and subject of a notification sent
+// by a tool call. The body is not recorded.
+func SetNotification(spans []trace.Span, recipients []string, subject string, dryRun bool) {
+	for _, span := range spans {
+		span.SetAttributes(
+			attribute.StringSlice(agentKey(gcpVertexAgentNotifyTo), recipients),
+			attribute.String(agentKey(gcpVertexAgentNotifySubject), subject),
+			attribute.Bool(agentKey(gcpVertexAgentNotifyDryRun), dryRun),
+		)
+	}
+}
+
+// AddTaskScheduledEvent records on the spans that a follow-up task was
+// scheduled to fire at the given time.
+func AddTaskScheduledEvent(spans []trace.Span, taskID string, fireAt time.Time) {
+	for _, span := range spans {
+		span.AddEvent(taskScheduledEventName, trace.WithAttributes(
+			attribute.String(agentKey(gcpVertexAgentTaskID), taskID),
+			attribute.String(agentKey(gcpVertexAgentTaskFireAt), fireAt.UTC().Format(time.RFC3339Nano)),
+		))
+	}
+}
+
+// AddTaskFiredEvent records on the spans that a follow-up task fired the
+// given time after its fire time.
+func AddTaskFiredEvent(spans []trace.Span, taskID string, lateness time.Duration) {
+	for _, span := range spans {
+		span.AddEvent(taskFiredEventName, trace.WithAttributes(
+			attribute.String(agentKey(gcpVertexAgentTaskID), taskID),
+			attribute.Int64(agentKey(gcpVertexAgentTaskLatenessMs), lateness.Milliseconds()),
+		))
+	}
+}
+
+// AddPhaseEvent records on the spans that the run entered the given phase,
+// e.g. before calling a tool.
+func AddPhaseEvent(spans []trace.Span, phase, detail string) {
+	for _, span := range spans {
+		attributes := []attribute.KeyValue{attribute.String(agentKey(gcpVertexAgentPhase), phase)}
+		if detail != "" {
+			attributes = append(attributes, attribute.String(agentKey(gcpVertexAgentPhaseDetail), detail))
+		}
+		span.AddEvent(phaseEventName, trace.WithAttributes(attributes...))
+	}
+}
+
+// AddFunctionCallFinalizedEvent records on the spans that the arguments of a
+// function call streamed in the given number of fragments were assembled.
+func AddFunctionCallFinalizedEvent(spans []trace.Span, name, id string, fragments int) {
+	for _, span := range spans {
+		span.AddEvent(functionCallFinalizedEventName, trace.WithAttributes(
+			attribute.String(genAiToolName, name),
+			attribute.String(genAiToolCallID, id),
+			attribute.Int(agentKey(gcpVertexAgentCallFragments), fragments),
+		))
+	}
+}
+
+// AddToolArgCoercedEvent records on the spans that a string argument of a
+// tool call was coerced to the type declared by the input schema of the
+// tool. The value of the argument is not recorded.
+func AddToolArgCoercedEvent(spans []trace.Span, toolName, path, typ string) {
+	for _, span := range spans {
+		span.AddEvent(toolArgCoercedEventName, trace.WithAttributes(
+			attribute.String(genAiToolName, toolName),
+			attribute.String(agentKey(gcpVertexAgentToolArgPath), path),
+			attribute.String(agentKey(gcpVertexAgentToolArgType), typ),
+		))
+	}
+}
+
+// TraceTaskRun ends the spans of the run of a fired follow-up task,
+// recording whether it failed.
+func TraceTaskRun(spans []trace.Span, err error) {
+	for _, span := range spans {
+		span.SetAttributes(attribute.Bool(agentKey(gcpVertexAgentTaskFailed), err != nil))
+		span.End()
+	}
+}
+
+// TraceToolBudgetExhausted ends the spans of a tool call aborted because the
+// deadline of the invocation is exceeded.
+func TraceToolBudgetExhausted(spans []trace.Span) {
+	for _, span := range spans {
+		span.SetAttributes(attribute.Bool(agentKey(gcpVertexAgentBudgetExhausted), true))
+		span.End()
+	}
+}
 
+// TraceContextOverflow ends the spans of a model call not made because the
+// request exceeds the context window of the model.
+func TraceContextOverflow(spans []trace.Span, estimatedTokens, maxTokens int) {
+	for _, span := range spans {
+		span.SetAttributes(
+			attribute.Bool(agentKey(gcpVertexAgentContextOverflow), true),
+			attribute.Int(agentKey(gcpVertexAgentEstimatedTokens), estimatedTokens),
+			attribute.Int(agentKey(gcpVertexAgentContextWindow), maxTokens),
+		)
+		span.End()
+	}
+}
+
+// TraceAgentInvocation ends the invoke_agent spans of a run of the given
+// agent. For a stage of a pipeline, step is its index in the pipeline, else
+// -1; for a pipeline, steps is the number of stages that ran.
+func TraceAgentInvocation(spans []trace.Span, agentName string, step, steps int, duration time.Duration) {
+	for _, span := range spans {
+		attributes := []attribute.KeyValue{
+			attribute.String(genAiOperationName, invokeAgentName),
+			attribute.String(agentKey(gcpVertexAgentAgentName), agentName),
+			attribute.Int64(agentKey(gcpVertexAgentRunDurationMs), duration.Milliseconds()),
+		}
+		if step >= 0 {
+			attributes = append(attributes, attribute.Int(agentKey(gcpVertexAgentPipelineStep), step))
+		}
+		if steps > 0 {
+			attributes = append(attributes, attribute.Int(agentKey(gcpVertexAgentPipelineSteps), steps))
+		}
+		span.SetAttributes(attributes...)
+		span.End()
+	}
+}
+
+// TraceLoopInvocation ends the invoke_agent spans of a run of the given loop
+// agent, recording the number of iterations it ran.
+func TraceLoopInvocation(spans []trace.Span, agentName string, iterations uint, duration time.Duration) {
+	for _, span := range spans {
+		span.SetAttributes(attribute.Int64(agentKey(gcpVertexAgentLoopIterations), int64(iterations)))
+	}
+	TraceAgentInvocation(spans, agentName, -1, 0, duration)
+}
+
+// TraceRunQueued ends the spans of a run waiting for the concurrency limiter,
+// recording the wait time and whether the run was rejected.
+func TraceRunQueued(spans []trace.Span, wait time.Duration, err error) {
+	for _, span := range spans {
+		span.SetAttributes(
+			attribute.Int64(agentKey(gcpVertexAgentQueueWaitMs), wait.Milliseconds()),
+			attribute.Bool(agentKey(gcpVertexAgentRunRejected), err != nil),
+		)
+		span.End()
+	}
+}
+
+// TraceAgentRunQueued ends the spans of a run waiting for the pool of its
+// agent, recording the number of runs waiting ahead of it, the wait time and
+// whether the run was rejected.
+func TraceAgentRunQueued(spans []trace.Span, queued int, wait time.Duration, err error) {
+	for _, span := range spans {
+		span.SetAttributes(
+			attribute.Int(agentKey(gcpVertexAgentQueueDepth), queued),
+			attribute.Int64(agentKey(gcpVertexAgentQueueWaitMs), wait.Milliseconds()),
+			attribute.Bool(agentKey(gcpVertexAgentRunRejected), err != nil),
+		)
+		span.End()
+	}
+}
+
+// TraceRun ends the root spans of a run, recording its total duration and
+// whether it exceeded its timeout.
+func TraceRun(spans []trace.Span, duration time.Duration, timedOut bool) {
+	for _, span := range spans {
+		span.SetAttributes(
+			attribute.Int64(agentKey(gcpVertexAgentRunDurationMs), duration.Milliseconds()),
+			attribute.Bool(agentKey(gcpVertexAgentRunTimedOut), timedOut),
+		)
+		span.End()
+	}
+}
+
+// TraceReprompts emits a check_output span recording how many times the
+// model was re-prompted so far and whether the last response was accepted.
+func TraceReprompts(agentCtx agent.InvocationContext, reprompts int, accepted bool) {
+	for _, span := range StartTrace(agentCtx, checkOutputName) {
+		attributes := append(commonAttributes(agentCtx, ""),
+			attribute.String(agentKey(gcpVertexAgentInvocationID), agentCtx.InvocationID()),
+			attribute.Int(agentKey(gcpVertexAgentReprompts), reprompts),
+			attribute.Bool(agentKey(gcpVertexAgentAccepted), accepted),
+		)
 		span.SetAttributes(attributes...)
 		span.End()
 	}
@@ -202,15 +900,15 @@ func TraceToolCall(spans []trace.Span, tool tool.Tool, fnArgs map[string]any, fn
 // TraceLLMCall fills the call_llm event details.
 func TraceLLMCall(spans []trace.Span, agentCtx agent.InvocationContext, llmRequest *model.LLMRequest, event *session.Event) {
 	for _, span := range spans {
-		attributes := []attribute.KeyValue{
+		attributes := append(customAttributes(agentCtx, nil), commonAttributes(agentCtx, llmRequest.Model)...)
+		attributes = append(attributes,
 			attribute.String(genAiSystemName, systemName),
-			attribute.String(genAiRequestModelName, llmRequest.Model),
-			attribute.String(gcpVertexAgentInvocationID, event.InvocationID),
-			attribute.String(gcpVertexAgentSessionID, agentCtx.Session().ID()),
-			attribute.String(gcpVertexAgentEventID, event.ID),
-			attribute.String(gcpVertexAgentLLMRequestName, safeSerialize(llmRequestToTrace(llmRequest))),
-			attribute.String(gcpVertexAgentLLMResponseName, safeSerialize(event.LLMResponse)),
-		}
+			attribute.String(agentKey(gcpVertexAgentInvocationID), event.InvocationID),
+			attribute.String(agentKey(gcpVertexAgentEventID), event.ID),
+			attribute.String(agentKey(gcpVertexAgentEventKind), string(session.KindOf(event))),
+			attribute.String(agentKey(gcpVertexAgentLLMRequestName), safeSerialize(llmRequestToTrace(llmRequest))),
+			attribute.String(agentKey(gcpVertexAgentLLMResponseName), safeSerialize(event.LLMResponse)),
+		)
 
 		if llmRequest.Config.TopP != nil {
 			attributes = append(attributes, attribute.Float64(genAiRequestTopP, float64(*llmRequest.Config.TopP)))
@@ -219,6 +917,16 @@ func TraceLLMCall(spans []trace.Span, agentCtx agent.InvocationContext, llmReque
 		if llmRequest.Config.MaxOutputTokens != 0 {
 			attributes = append(attributes, attribute.Int(genAiRequestMaxTokens, int(llmRequest.Config.MaxOutputTokens)))
 		}
+		if tc := llmRequest.Config.ThinkingConfig; tc != nil && tc.ThinkingBudget != nil {
+			attributes = append(attributes, attribute.Int(genAiRequestThinkingBudget, int(*tc.ThinkingBudget)))
+		}
+		if tc := llmRequest.Config.ToolConfig; tc != nil && tc.FunctionCallingConfig != nil {
+			fc := tc.FunctionCallingConfig
+			attributes = append(attributes, attribute.String(agentKey(gcpVertexAgentFunctionCalling), string(fc.Mode)))
+			if len(fc.AllowedFunctionNames) > 0 {
+				attributes = append(attributes, attribute.StringSlice(agentKey(gcpVertexAgentAllowedFunctions), fc.AllowedFunctionNames))
+			}
+		}
 		if event.FinishReason != "" {
 			attributes = append(attributes, attribute.String(genAiResponseFinishReason, string(event.FinishReason)))
 		}
@@ -232,6 +940,9 @@ func TraceLLMCall(spans []trace.Span, agentCtx agent.InvocationContext, llmReque
 			if event.UsageMetadata.CachedContentTokenCount > 0 {
 				attributes = append(attributes, attribute.Int(genAiResponseCachedContentTokenCount, int(event.UsageMetadata.CachedContentTokenCount)))
 			}
+			if event.UsageMetadata.ThoughtsTokenCount > 0 {
+				attributes = append(attributes, attribute.Int(genAiResponseThoughtsTokenCount, int(event.UsageMetadata.ThoughtsTokenCount)))
+			}
 			if event.UsageMetadata.TotalTokenCount > 0 {
 				attributes = append(attributes, attribute.Int(genAiResponseTotalTokenCount, int(event.UsageMetadata.TotalTokenCount)))
 			}
@@ -242,6 +953,50 @@ func TraceLLMCall(spans []trace.Span, agentCtx agent.InvocationContext, llmReque
 	}
 }
 
+// maxCustomAttributes caps the number of custom attributes of a span, so that
+// misbehaving tools and agents don't blow up the cardinality of the spans.
+const maxCustomAttributes = 32
+
+// customAttributes returns the custom attributes of the agent and, if it
+// implements tool.SpanAttributer, of the tool, capped to maxCustomAttributes.
+// They are set before the built-in attributes, which take precedence on
+// conflicting keys.
+func customAttributes(agentCtx agent.InvocationContext, t any) []attribute.KeyValue {
+	var attributes []attribute.KeyValue
+	if agentCtx != nil {
+		if a, ok := agentCtx.Agent().(agentinternal.Agent); ok {
+			attributes = append(attributes, agentinternal.Reveal(a).SpanAttributes...)
+		}
+	}
+	if sa, ok := t.(tool.SpanAttributer); ok {
+		attributes = append(attributes, sa.SpanAttributes()...)
+	}
+	if len(attributes) > maxCustomAttributes {
+		attributes = attributes[:maxCustomAttributes]
+	}
+	return attributes
+}
+
+// commonAttributes returns the attributes set on every span: the name of the
+// agent, the session and user IDs, masked if configured with SetIDMasking,
+// and, if known, the name of the model.
+func commonAttributes(agentCtx agent.InvocationContext, modelName string) []attribute.KeyValue {
+	var attributes []attribute.KeyValue
+	if agentCtx != nil && agentCtx.Agent() != nil {
+		attributes = append(attributes, attribute.String(agentKey(gcpVertexAgentAgentName), agentCtx.Agent().Name()))
+	}
+	if agentCtx != nil && agentCtx.Session() != nil {
+		attributes = append(attributes,
+			attribute.String(agentKey(gcpVertexAgentSessionID), sessionIDForTrace(agentCtx.Session().ID())),
+			attribute.String(agentKey(gcpVertexAgentUserID), userIDForTrace(agentCtx.Session().UserID())),
+		)
+	}
+	if modelName != "" {
+		attributes = append(attributes, attribute.String(genAiRequestModelName, modelName))
+	}
+	return attributes
+}
+
 func safeSerialize(obj any) string {
 	dump, err := json.Marshal(obj)
 	if err != nil {
@@ -250,6 +1005,12 @@ func safeSerialize(obj any) string {
 	return string(dump)
 }
 
+// LLMRequestForTrace returns the request as recorded on call_llm spans,
+// without inline data.
+func LLMRequestForTrace(llmRequest *model.LLMRequest) map[string]any {
+	return llmRequestToTrace(llmRequest)
+}
+
 func llmRequestToTrace(llmRequest *model.LLMRequest) map[string]any {
 	result := map[string]any{
 		"config":  llmRequest.Config,
@@ -257,19 +1018,46 @@ func llmRequestToTrace(llmRequest *model.LLMRequest) map[string]any {
 		"content": []*genai.Content{},
 	}
 	for _, content := range llmRequest.Contents {
-		parts := []*genai.Part{}
-		// filter out InlineData part
-		for _, part := range content.Parts {
-			if part.InlineData != nil {
-				continue
-			}
-			parts = append(parts, part)
+		result["content"] = append(result["content"].([]*genai.Content), filterInlineData(content))
+	}
+	return result
+}
+
+// eventToTrace returns a shallow copy of event without inline data.
+func eventToTrace(event *session.Event) *session.Event {
+	filtered := *event
+	filtered.LLMResponse.Content = filterInlineData(event.LLMResponse.Content)
+	return &filtered
+}
+
+// filterInlineData returns a copy of content without inline data, including
+// the inline data of function responses, so that traces don't balloon.
+func filterInlineData(content *genai.Content) *genai.Content {
+	if content == nil {
+		return nil
+	}
+	parts := []*genai.Part{}
+	for _, part := range content.Parts {
+		if part.InlineData != nil {
+			continue
 		}
-		filteredContent := &genai.Content{
-			Role:  content.Role,
-			Parts: parts,
+		if fr := part.FunctionResponse; fr != nil && len(fr.Parts) > 0 {
+			frCopy := *fr
+			frCopy.Parts = nil
+			for _, frPart := range fr.Parts {
+				if frPart.InlineData != nil {
+					continue
+				}
+				frCopy.Parts = append(frCopy.Parts, frPart)
+			}
+			partCopy := *part
+			partCopy.FunctionResponse = &frCopy
+			part = &partCopy
 		}
-		result["content"] = append(result["content"].([]*genai.Content), filteredContent)
+		parts = append(parts, part)
+	}
+	return &genai.Content{
+		Role:  content.Role,
+		Parts: parts,
 	}
-	return result
 }
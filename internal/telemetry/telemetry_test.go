@@ -0,0 +1,712 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool/exitlooptool"
+)
+
+func TestAgentAndModelOnEverySpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	AddSpanProcessor(recorder)
+
+	a, err := agent.New(agent.Config{Name: "test_agent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := session.InMemoryService().Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{
+		Agent:   a,
+		Session: resp.Session,
+	})
+	testTool, err := exitlooptool.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := session.NewEvent(ctx.InvocationID())
+
+	TraceLLMCall(StartTrace(ctx, "call_llm"), ctx, &model.LLMRequest{Model: "test_model", Config: &genai.GenerateContentConfig{}}, ev)
+	TraceToolCall(StartTrace(ctx, "execute_tool exit_loop"), ctx, "test_model", testTool, nil, ev)
+	TraceMergedToolCalls(StartTrace(ctx, "execute_tool (merged)"), ctx, "test_model", ev)
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d ended spans, want 3", len(spans))
+	}
+	for _, span := range spans {
+		attrs := make(map[attribute.Key]string)
+		for _, kv := range span.Attributes() {
+			attrs[kv.Key] = kv.Value.AsString()
+		}
+		if got := attrs[attribute.Key(agentKey(gcpVertexAgentAgentName))]; got != "test_agent" {
+			t.Errorf("span %q: %s = %q, want %q", span.Name(), agentKey(gcpVertexAgentAgentName), got, "test_agent")
+		}
+		if got := attrs[genAiRequestModelName]; got != "test_model" {
+			t.Errorf("span %q: %s = %q, want %q", span.Name(), genAiRequestModelName, got, "test_model")
+		}
+	}
+}
+
+func TestLLMRequestToTraceFiltersInlineData(t *testing.T) {
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{
+			{
+				Role: genai.RoleUser,
+				Parts: []*genai.Part{
+					genai.NewPartFromBytes([]byte("image"), "image/png"),
+					{FunctionResponse: &genai.FunctionResponse{
+						Name: "generate_image",
+						Parts: []*genai.FunctionResponsePart{
+							{InlineData: &genai.FunctionResponseBlob{MIMEType: "image/png", Data: []byte("image")}},
+							genai.NewFunctionResponsePartFromURI("gs://bucket/image.png", "image/png"),
+						},
+					}},
+				},
+			},
+		},
+	}
+	got := llmRequestToTrace(req)["content"].([]*genai.Content)
+	if len(got) != 1 || len(got[0].Parts) != 1 {
+		t.Fatalf("llmRequestToTrace() contents = %v, want a single function response part", got)
+	}
+	frParts := got[0].Parts[0].FunctionResponse.Parts
+	if len(frParts) != 1 || frParts[0].FileData == nil {
+		t.Errorf("function response parts = %v, want only the file data part", frParts)
+	}
+	// The request itself must not be modified.
+	if n := len(req.Contents[0].Parts[1].FunctionResponse.Parts); n != 2 {
+		t.Errorf("llmRequestToTrace() modified the request: got %d function response parts, want 2", n)
+	}
+}
+
+func TestMergedToolCallsAttributes(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	a, err := agent.New(agent.Config{Name: "test_agent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := session.InMemoryService().Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{
+		Agent:   a,
+		Session: resp.Session,
+	})
+	ev := session.NewEvent(ctx.InvocationID())
+	ev.Content = &genai.Content{
+		Role: genai.RoleUser,
+		Parts: []*genai.Part{
+			{FunctionResponse: &genai.FunctionResponse{ID: "call-1", Name: "get_weather"}},
+			{FunctionResponse: &genai.FunctionResponse{ID: "call-2", Name: "get_time"}},
+		},
+	}
+
+	_, span := tp.Tracer("test").Start(ctx, "execute_tool (merged)")
+	TraceMergedToolCalls([]trace.Span{span}, ctx, "test_model", ev)
+
+	ended := recorder.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("got %d ended spans, want 1", len(ended))
+	}
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range ended[0].Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if got := attrs[genAiToolName].AsString(); got != mergeToolName {
+		t.Errorf("%s = %q, want %q", genAiToolName, got, mergeToolName)
+	}
+	if diff := cmp.Diff([]string{"get_weather", "get_time"}, attrs[attribute.Key(agentKey(gcpVertexAgentMergedToolNames))].AsStringSlice()); diff != "" {
+		t.Errorf("%s mismatch (-want +got):\n%s", agentKey(gcpVertexAgentMergedToolNames), diff)
+	}
+	if diff := cmp.Diff([]string{"call-1", "call-2"}, attrs[attribute.Key(agentKey(gcpVertexAgentMergedToolIDs))].AsStringSlice()); diff != "" {
+		t.Errorf("%s mismatch (-want +got):\n%s", agentKey(gcpVertexAgentMergedToolIDs), diff)
+	}
+}
+
+func TestFunctionCallingModeAttribute(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	a, err := agent.New(agent.Config{Name: "test_agent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := session.InMemoryService().Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{
+		Agent:   a,
+		Session: resp.Session,
+	})
+	req := &model.LLMRequest{
+		Model: "test_model",
+		Config: &genai.GenerateContentConfig{
+			ToolConfig: &genai.ToolConfig{FunctionCallingConfig: &genai.FunctionCallingConfig{
+				Mode:                 genai.FunctionCallingConfigModeAny,
+				AllowedFunctionNames: []string{"get_weather"},
+			}},
+		},
+	}
+
+	_, span := tp.Tracer("test").Start(ctx, "call_llm")
+	TraceLLMCall([]trace.Span{span}, ctx, req, session.NewEvent(ctx.InvocationID()))
+
+	ended := recorder.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("got %d ended spans, want 1", len(ended))
+	}
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range ended[0].Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if got, want := attrs[attribute.Key(agentKey(gcpVertexAgentFunctionCalling))].AsString(), string(genai.FunctionCallingConfigModeAny); got != want {
+		t.Errorf("%s = %q, want %q", agentKey(gcpVertexAgentFunctionCalling), got, want)
+	}
+	if diff := cmp.Diff([]string{"get_weather"}, attrs[attribute.Key(agentKey(gcpVertexAgentAllowedFunctions))].AsStringSlice()); diff != "" {
+		t.Errorf("%s mismatch (-want +got):\n%s", agentKey(gcpVertexAgentAllowedFunctions), diff)
+	}
+}
+
+func TestAttributePrefix(t *testing.T) {
+	SetAttributePrefix("example.agent.")
+	defer SetAttributePrefix("")
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	a, err := agent.New(agent.Config{Name: "test_agent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := session.InMemoryService().Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{
+		Agent:   a,
+		Session: resp.Session,
+	})
+	testTool, err := exitlooptool.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := session.NewEvent(ctx.InvocationID())
+	ev.Content = &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{
+		{FunctionResponse: &genai.FunctionResponse{ID: "call-1", Name: "exit_loop"}},
+	}}
+
+	start := func(name string) []trace.Span {
+		_, span := tracer.Start(ctx, name)
+		return []trace.Span{span}
+	}
+	TraceLLMCall(start("call_llm"), ctx, &model.LLMRequest{Model: "test_model", Config: &genai.GenerateContentConfig{}}, ev)
+	TraceToolCall(start("execute_tool exit_loop"), ctx, "test_model", testTool, nil, ev)
+	TraceMergedToolCalls(start("execute_tool (merged)"), ctx, "test_model", ev)
+
+	if got, want := EventIDKey(), "example.agent.event_id"; got != want {
+		t.Errorf("EventIDKey() = %q, want %q", got, want)
+	}
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d ended spans, want 3", len(spans))
+	}
+	for _, span := range spans {
+		attrs := make(map[attribute.Key]string)
+		for _, kv := range span.Attributes() {
+			if strings.HasPrefix(string(kv.Key), DefaultAttributePrefix+".") {
+				t.Errorf("span %q has attribute %q under the default prefix", span.Name(), kv.Key)
+			}
+			attrs[kv.Key] = kv.Value.AsString()
+		}
+		if got := attrs["example.agent.event_id"]; got != ev.ID {
+			t.Errorf("span %q: example.agent.event_id = %q, want %q", span.Name(), got, ev.ID)
+		}
+	}
+}
+
+func TestServiceName(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(prev)
+
+	plain, err := agent.New(agent.Config{Name: "plain_agent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	named, err := agent.New(agent.Config{Name: "named_agent", TracerServiceName: "search"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := session.InMemoryService().Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runCtx := WithServiceName(t.Context(), "billing")
+
+	for _, tc := range []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "default", ctx: t.Context(), want: "gcp.vertex.agent"},
+		{name: "empty", ctx: WithServiceName(t.Context(), ""), want: "gcp.vertex.agent"},
+		{name: "run", ctx: runCtx, want: "billing"},
+		{
+			name: "agent without name",
+			ctx:  icontext.NewInvocationContext(runCtx, icontext.InvocationContextParams{Agent: plain, Session: resp.Session}),
+			want: "billing",
+		},
+		{
+			name: "agent",
+			ctx:  icontext.NewInvocationContext(runCtx, icontext.InvocationContextParams{Agent: named, Session: resp.Session}),
+			want: "search",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ServiceName(tc.ctx); got != tc.want {
+				t.Errorf("ServiceName() = %q, want %q", got, tc.want)
+			}
+			for _, span := range StartTrace(tc.ctx, "op "+tc.name) {
+				span.End()
+			}
+			found := false
+			for _, span := range recorder.Ended() {
+				if span.Name() != "op "+tc.name {
+					continue
+				}
+				found = true
+				if got := span.InstrumentationScope().Name; got != tc.want {
+					t.Errorf("span tracer name = %q, want %q", got, tc.want)
+				}
+			}
+			if !found {
+				t.Errorf("no span %q recorded", "op "+tc.name)
+			}
+		})
+	}
+}
+
+func TestLabels(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(prev)
+
+	ctx := WithLabels(t.Context(), map[string]string{"experiment": "b"})
+	for _, span := range StartTrace(ctx, "labelled") {
+		span.End()
+	}
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d ended spans, want 1", len(spans))
+	}
+	attrs := make(map[attribute.Key]string)
+	for _, kv := range spans[0].Attributes() {
+		attrs[kv.Key] = kv.Value.AsString()
+	}
+	if diff := cmp.Diff(map[attribute.Key]string{"gcp.vertex.agent.label.experiment": "b"}, attrs); diff != "" {
+		t.Errorf("span attributes mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// startProcessor calls onStart for every started span.
+type startProcessor struct {
+	sdktrace.SpanProcessor
+	onStart func(sdktrace.ReadWriteSpan)
+}
+
+func (p startProcessor) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	p.onStart(s)
+}
+
+// resetSpanProcessors empties the local tracer config, as if telemetry had
+// not started, for the duration of the test.
+func resetSpanProcessors(t *testing.T) {
+	localTracerConfig.mu.Lock()
+	prev, prevProvider := localTracerConfig.spanProcessors, localTracerConfig.provider
+	localTracerConfig.spanProcessors, localTracerConfig.provider = nil, nil
+	localTracerConfig.mu.Unlock()
+	t.Cleanup(func() {
+		localTracerConfig.mu.Lock()
+		localTracerConfig.spanProcessors, localTracerConfig.provider = prev, prevProvider
+		localTracerConfig.mu.Unlock()
+	})
+}
+
+func TestAddSpanProcessors(t *testing.T) {
+	resetSpanProcessors(t)
+
+	if err := AddSpanProcessors(tracetest.NewSpanRecorder(), tracetest.NewSpanRecorder()); err != nil {
+		t.Fatalf("AddSpanProcessors() error = %v", err)
+	}
+	if err := AddSpanProcessors(tracetest.NewSpanRecorder(), nil); err == nil {
+		t.Errorf("AddSpanProcessors() with a nil processor succeeded, want error")
+	}
+	if got := SpanProcessorCount(); got != 2 {
+		t.Errorf("SpanProcessorCount() = %d, want 2", got)
+	}
+
+	var handled []error
+	prevHandler := otel.GetErrorHandler()
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) { handled = append(handled, err) }))
+	t.Cleanup(func() { otel.SetErrorHandler(prevHandler) })
+
+	tp := startLocalTracerProvider()
+	if err := InsertSpanProcessorAt(0, tracetest.NewSpanRecorder()); !errors.Is(err, ErrTelemetryStarted) {
+		t.Errorf("InsertSpanProcessorAt() after start error = %v, want %v", err, ErrTelemetryStarted)
+	}
+	if len(handled) != 1 || !errors.Is(handled[0], ErrTelemetryStarted) {
+		t.Errorf("errors reported to the otel error handler = %v, want %v", handled, ErrTelemetryStarted)
+	}
+
+	// Processors added after the start receive the spans started from then on.
+	_, before := tp.Tracer("test").Start(t.Context(), "before")
+	before.End()
+	late := tracetest.NewSpanRecorder()
+	if err := AddSpanProcessor(late); err != nil {
+		t.Fatalf("AddSpanProcessor() after start error = %v", err)
+	}
+	_, after := tp.Tracer("test").Start(t.Context(), "after")
+	after.End()
+	var got []string
+	for _, span := range late.Ended() {
+		got = append(got, span.Name())
+	}
+	if diff := cmp.Diff([]string{"after"}, got); diff != "" {
+		t.Errorf("spans of the late processor mismatch (-want +got):\n%s", diff)
+	}
+	if got := SpanProcessorCount(); got != 3 {
+		t.Errorf("SpanProcessorCount() = %d, want 3", got)
+	}
+}
+
+func TestInsertSpanProcessorAt(t *testing.T) {
+	resetSpanProcessors(t)
+
+	secretKey := attribute.Key("gcp.vertex.agent.label.secret")
+	var order []string
+	var exported []string
+	newProcessor := func(name string, onStart func(sdktrace.ReadWriteSpan)) sdktrace.SpanProcessor {
+		return startProcessor{
+			SpanProcessor: tracetest.NewSpanRecorder(),
+			onStart: func(s sdktrace.ReadWriteSpan) {
+				order = append(order, name)
+				onStart(s)
+			},
+		}
+	}
+	AddSpanProcessor(newProcessor("exporter", func(s sdktrace.ReadWriteSpan) {
+		for _, kv := range s.Attributes() {
+			if kv.Key == secretKey {
+				exported = append(exported, kv.Value.AsString())
+			}
+		}
+	}))
+	AddSpanProcessor(newProcessor("last", func(sdktrace.ReadWriteSpan) {}))
+	InsertSpanProcessorAt(0, newProcessor("redactor", func(s sdktrace.ReadWriteSpan) {
+		s.SetAttributes(secretKey.String("[REDACTED]"))
+	}))
+	InsertSpanProcessorAt(100, newProcessor("clamped", func(sdktrace.ReadWriteSpan) {}))
+
+	tp := newLocalTracerProvider()
+	_, span := tp.Tracer("test").Start(t.Context(), "op", trace.WithAttributes(secretKey.String("hunter2")))
+	span.End()
+
+	if diff := cmp.Diff([]string{"redactor", "exporter", "last", "clamped"}, order); diff != "" {
+		t.Errorf("processor call order mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"[REDACTED]"}, exported); diff != "" {
+		t.Errorf("exported attribute mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(prev)
+
+	a, err := agent.New(agent.Config{Name: "test_agent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := session.InMemoryService().Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithDisabled(t.Context())
+	if !Disabled(ctx) {
+		t.Fatalf("Disabled() = false, want true")
+	}
+	if Disabled(t.Context()) {
+		t.Errorf("Disabled() of a plain context = true, want false")
+	}
+	agentCtx := icontext.NewInvocationContext(ctx, icontext.InvocationContextParams{Agent: a, Session: resp.Session})
+
+	spans := StartTrace(agentCtx, "call_llm")
+	if len(spans) != 0 {
+		t.Errorf("StartTrace() returned %d spans, want none", len(spans))
+	}
+	TraceLLMCall(spans, agentCtx, &model.LLMRequest{Model: "m", Config: &genai.GenerateContentConfig{}}, session.NewEvent("inv"))
+	TraceReprompts(agentCtx, 1, true)
+	if got := len(recorder.Started()); got != 0 {
+		t.Errorf("got %d started spans, want none", got)
+	}
+}
+
+// attributedTool is a tool adding custom attributes to its spans.
+type attributedTool struct {
+	attributes []attribute.KeyValue
+}
+
+func (attributedTool) Name() string        { return "attributed" }
+func (attributedTool) Description() string { return "has custom span attributes" }
+func (attributedTool) IsLongRunning() bool { return false }
+
+func (t attributedTool) SpanAttributes() []attribute.KeyValue { return t.attributes }
+
+func TestCustomSpanAttributes(t *testing.T) {
+	many := make([]attribute.KeyValue, maxCustomAttributes)
+	for i := range many {
+		many[i] = attribute.Int("custom."+strings.Repeat("k", i+1), i)
+	}
+	for _, tc := range []struct {
+		name           string
+		agentAttrs     []attribute.KeyValue
+		toolAttrs      []attribute.KeyValue
+		wantLLM        map[string]string
+		wantTool       map[string]string
+		wantToolAbsent []string
+	}{
+		{
+			name:       "agent and tool attributes",
+			agentAttrs: []attribute.KeyValue{attribute.String("tenant.id", "t1")},
+			toolAttrs:  []attribute.KeyValue{attribute.String("tool.endpoint", "https://api.example.com")},
+			wantLLM:    map[string]string{"tenant.id": "t1"},
+			wantTool:   map[string]string{"tenant.id": "t1", "tool.endpoint": "https://api.example.com"},
+		},
+		{
+			name:       "tool attributes override agent attributes",
+			agentAttrs: []attribute.KeyValue{attribute.String("tenant.id", "t1")},
+			toolAttrs:  []attribute.KeyValue{attribute.String("tenant.id", "t2")},
+			wantTool:   map[string]string{"tenant.id": "t2"},
+		},
+		{
+			name:      "built-in attributes take precedence",
+			toolAttrs: []attribute.KeyValue{attribute.String(genAiToolName, "spoofed")},
+			wantTool:  map[string]string{genAiToolName: "attributed"},
+		},
+		{
+			name:           "attributes are capped",
+			agentAttrs:     many,
+			toolAttrs:      []attribute.KeyValue{attribute.String("tool.endpoint", "https://api.example.com")},
+			wantToolAbsent: []string{"tool.endpoint"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+			a, err := agent.New(agent.Config{Name: "test_agent", SpanAttributes: tc.agentAttrs})
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := session.InMemoryService().Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{
+				Agent:   a,
+				Session: resp.Session,
+			})
+			ev := session.NewEvent(ctx.InvocationID())
+
+			_, llmSpan := tp.Tracer("test").Start(ctx, "call_llm")
+			TraceLLMCall([]trace.Span{llmSpan}, ctx, &model.LLMRequest{Model: "test_model", Config: &genai.GenerateContentConfig{}}, ev)
+			_, toolSpan := tp.Tracer("test").Start(ctx, "execute_tool")
+			TraceToolCall([]trace.Span{toolSpan}, ctx, "test_model", attributedTool{attributes: tc.toolAttrs}, nil, ev)
+
+			ended := recorder.Ended()
+			if len(ended) != 2 {
+				t.Fatalf("got %d ended spans, want 2", len(ended))
+			}
+			for i, want := range []map[string]string{tc.wantLLM, tc.wantTool} {
+				attrs := make(map[string]string)
+				for _, kv := range ended[i].Attributes() {
+					attrs[string(kv.Key)] = kv.Value.Emit()
+				}
+				for k, v := range want {
+					if got := attrs[k]; got != v {
+						t.Errorf("span %q: %s = %q, want %q", ended[i].Name(), k, got, v)
+					}
+				}
+				if i == 1 {
+					for _, k := range tc.wantToolAbsent {
+						if _, ok := attrs[k]; ok {
+							t.Errorf("span %q has %s beyond the cap", ended[i].Name(), k)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestToolStatusAttributes(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		response map[string]any
+		want     map[string]string
+	}{
+		{
+			name:     "success",
+			response: map[string]any{"temp": "21C"},
+			want:     map[string]string{"tool_status": "ok"},
+		},
+		{
+			name:     "error",
+			response: map[string]any{"status": "error", "error_code": "NOT_FOUND", "error": "unknown city"},
+			want:     map[string]string{"tool_status": "error", "tool_error_code": "NOT_FOUND"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			testTool, err := exitlooptool.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ev := session.NewEvent("inv")
+			ev.Content = &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{
+				{FunctionResponse: &genai.FunctionResponse{ID: "c1", Name: "exit_loop", Response: tc.response}},
+			}}
+			_, span := tp.Tracer("test").Start(t.Context(), "execute_tool")
+			TraceToolCall([]trace.Span{span}, nil, "", testTool, nil, ev)
+
+			got := make(map[string]string)
+			for _, kv := range recorder.Ended()[0].Attributes() {
+				for _, k := range []string{"tool_status", "tool_error_code"} {
+					if string(kv.Key) == agentKey(k) {
+						got[k] = kv.Value.AsString()
+					}
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("status attributes mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIDMasking(t *testing.T) {
+	defer SetIDMasking(nil, false)
+
+	a, err := agent.New(agent.Config{Name: "test_agent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := session.InMemoryService().Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "jane@example.com", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{
+		Agent:   a,
+		Session: resp.Session,
+	})
+	testTool, err := exitlooptool.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	salt := []byte("secret")
+	for _, tc := range []struct {
+		name          string
+		salt          []byte
+		maskSessionID bool
+		want          map[string]string
+	}{
+		{
+			name: "default",
+			want: map[string]string{"user_id": "jane@example.com", "session_id": "s1"},
+		},
+		{
+			name: "user ID",
+			salt: salt,
+			want: map[string]string{"user_id": maskID(salt, "jane@example.com"), "session_id": "s1"},
+		},
+		{
+			name:          "user and session IDs",
+			salt:          salt,
+			maskSessionID: true,
+			want:          map[string]string{"user_id": maskID(salt, "jane@example.com"), "session_id": maskID(salt, "s1")},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			SetIDMasking(tc.salt, tc.maskSessionID)
+			recorder := tracetest.NewSpanRecorder()
+			tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+			start := func(name string) []trace.Span {
+				_, span := tracer.Start(ctx, name)
+				return []trace.Span{span}
+			}
+			ev := session.NewEvent(ctx.InvocationID())
+			TraceLLMCall(start("call_llm"), ctx, &model.LLMRequest{Model: "test_model", Config: &genai.GenerateContentConfig{}}, ev)
+			TraceToolCall(start("execute_tool exit_loop"), ctx, "test_model", testTool, nil, ev)
+
+			for _, span := range recorder.Ended() {
+				got := make(map[string]string)
+				for _, kv := range span.Attributes() {
+					switch string(kv.Key) {
+					case agentKey(gcpVertexAgentUserID):
+						got["user_id"] = kv.Value.AsString()
+					case agentKey(gcpVertexAgentSessionID):
+						got["session_id"] = kv.Value.AsString()
+					}
+				}
+				if diff := cmp.Diff(tc.want, got); diff != "" {
+					t.Errorf("span %q IDs mismatch (-want +got):\n%s", span.Name(), diff)
+				}
+			}
+		})
+	}
+	if maskID(salt, "a") == maskID([]byte("other"), "a") {
+		t.Errorf("maskID() is the same for different salts")
+	}
+}
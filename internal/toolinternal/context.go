@@ -78,9 +78,14 @@ type toolContext struct {
 	functionCallID    string
 	eventActions      *session.EventActions
 	artifacts         *internalArtifacts
+	responseParts     []*genai.FunctionResponsePart
 }
 
+// Artifacts returns nil if the invocation has no artifact service.
 func (c *toolContext) Artifacts() agent.Artifacts {
+	if c.artifacts.Artifacts == nil {
+		return nil
+	}
 	return c.artifacts
 }
 
@@ -96,6 +101,21 @@ func (c *toolContext) AgentName() string {
 	return c.invocationContext.Agent().Name()
 }
 
+var _ tool.ResponsePartsAdder = (*toolContext)(nil)
+
+func (c *toolContext) AddResponseParts(parts ...*genai.FunctionResponsePart) {
+	c.responseParts = append(c.responseParts, parts...)
+}
+
+// ResponseParts returns the parts added to the function response by the tool
+// with tool.AddResponseParts.
+func ResponseParts(ctx tool.Context) []*genai.FunctionResponsePart {
+	if c, ok := ctx.(*toolContext); ok {
+		return c.responseParts
+	}
+	return nil
+}
+
 func (c *toolContext) SearchMemory(ctx context.Context, query string) (*memory.SearchResponse, error) {
 	return c.invocationContext.Memory().Search(ctx, query)
 }
@@ -0,0 +1,97 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolinternal
+
+import (
+	"context"
+	"sync"
+)
+
+// Limiter limits the number of tool calls executing at the same time, e.g.
+// the calls of a run, and tracks the maximum number observed.
+type Limiter struct {
+	slots chan struct{} // nil if the calls are not limited
+
+	mu          sync.Mutex
+	active      int
+	maxObserved int
+}
+
+// NewLimiter returns a limiter letting n calls execute at the same time. Zero
+// or a negative n does not limit them.
+func NewLimiter(n int) *Limiter {
+	l := &Limiter{}
+	if n > 0 {
+		l.slots = make(chan struct{}, n)
+	}
+	return l
+}
+
+// Acquire blocks until a call may execute or ctx is done. The call must
+// invoke release once it completed.
+func (l *Limiter) Acquire(ctx context.Context) (release func(), err error) {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	l.mu.Lock()
+	l.active++
+	l.maxObserved = max(l.maxObserved, l.active)
+	l.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			l.active--
+			l.mu.Unlock()
+			if l.slots != nil {
+				<-l.slots
+			}
+		})
+	}, nil
+}
+
+// Limit returns the maximum number of calls executing at the same time, or
+// zero if they are not limited.
+func (l *Limiter) Limit() int {
+	return cap(l.slots)
+}
+
+// MaxObserved returns the maximum number of calls that executed at the same
+// time so far.
+func (l *Limiter) MaxObserved() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.maxObserved
+}
+
+// ContextWithLimiter returns a copy of ctx carrying the limiter of the tool
+// calls made under it.
+func ContextWithLimiter(ctx context.Context, l *Limiter) context.Context {
+	return context.WithValue(ctx, limiterCtxKey, l)
+}
+
+// LimiterFromContext returns the limiter of ctx, or nil if there is none.
+func LimiterFromContext(ctx context.Context) *Limiter {
+	l, _ := ctx.Value(limiterCtxKey).(*Limiter)
+	return l
+}
+
+type limiterCtxKeyType int
+
+const limiterCtxKey limiterCtxKeyType = 0
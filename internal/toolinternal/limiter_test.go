@@ -0,0 +1,69 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolinternal
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(2)
+	r1, err := l.Acquire(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := l.Acquire(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := l.Acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Acquire() over the limit = %v, want %v", err, context.Canceled)
+	}
+
+	r1()
+	r1() // releasing twice frees a single slot
+	r3, err := l.Acquire(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2()
+	r3()
+	if got, want := l.MaxObserved(), 2; got != want {
+		t.Errorf("MaxObserved() = %d, want %d", got, want)
+	}
+	if got, want := l.Limit(), 2; got != want {
+		t.Errorf("Limit() = %d, want %d", got, want)
+	}
+}
+
+func TestLimiterUnlimited(t *testing.T) {
+	l := NewLimiter(0)
+	for range 5 {
+		if _, err := l.Acquire(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := l.MaxObserved(), 5; got != want {
+		t.Errorf("MaxObserved() = %d, want %d", got, want)
+	}
+	if got := l.Limit(); got != 0 {
+		t.Errorf("Limit() = %d, want 0", got)
+	}
+}
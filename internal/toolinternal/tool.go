@@ -28,6 +28,19 @@ type FunctionTool interface {
 	Run(ctx tool.Context, args any) (result map[string]any, err error)
 }
 
+// PostProcessedTool is implemented by function tools whose responses are
+// transformed before they are sent to the model.
+type PostProcessedTool interface {
+	// PostProcessor returns the post-processor of the responses of the
+	// tool, or nil if they are sent as they are.
+	PostProcessor() func(ctx tool.Context, raw any) (any, error)
+}
+
+// RawResponsesMetadataKey is the key of the custom metadata of function
+// response events keeping the raw responses of post-processed tools, by
+// function call ID.
+const RawResponsesMetadataKey = "raw_tool_responses"
+
 type RequestProcessor interface {
 	ProcessRequest(ctx tool.Context, req *model.LLMRequest) error
 }
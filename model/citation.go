@@ -0,0 +1,97 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "google.golang.org/genai"
+
+// Citation links a segment of the text of a response to the sources that
+// support it, e.g. to render footnotes for a grounded answer.
+type Citation struct {
+	// Text is the supported text.
+	Text string
+	// PartIndex is the index of the part of the content holding Text.
+	PartIndex int
+	// StartIndex and EndIndex are the byte offsets of Text in the text of
+	// the part, EndIndex being exclusive.
+	StartIndex, EndIndex int
+	// Sources are the sources supporting Text, most relevant first.
+	Sources []CitationSource
+}
+
+// CitationSource is a source supporting a cited segment.
+type CitationSource struct {
+	// URI locates the source, e.g. the URL of a web page.
+	URI string
+	// Title is the title of the source, if known.
+	Title string
+	// Confidence is the confidence of the model that the source supports
+	// the segment, in [0, 1], or 0 if not reported.
+	Confidence float32
+}
+
+// Citations returns the citations of the response, derived from the
+// grounding supports of its GroundingMetadata, e.g. from Google Search
+// grounding with Gemini. It returns nil if the response is not grounded.
+// Supports without any known source are left out.
+func (r *LLMResponse) Citations() []Citation {
+	if r == nil || r.GroundingMetadata == nil {
+		return nil
+	}
+	chunks := r.GroundingMetadata.GroundingChunks
+	var citations []Citation
+	for _, support := range r.GroundingMetadata.GroundingSupports {
+		if support == nil || support.Segment == nil {
+			continue
+		}
+		var sources []CitationSource
+		for i, index := range support.GroundingChunkIndices {
+			if index < 0 || int(index) >= len(chunks) || chunks[index] == nil {
+				continue
+			}
+			source, ok := chunkSource(*chunks[index])
+			if !ok {
+				continue
+			}
+			if i < len(support.ConfidenceScores) {
+				source.Confidence = support.ConfidenceScores[i]
+			}
+			sources = append(sources, source)
+		}
+		if len(sources) == 0 {
+			continue
+		}
+		citations = append(citations, Citation{
+			Text:       support.Segment.Text,
+			PartIndex:  int(support.Segment.PartIndex),
+			StartIndex: int(support.Segment.StartIndex),
+			EndIndex:   int(support.Segment.EndIndex),
+			Sources:    sources,
+		})
+	}
+	return citations
+}
+
+// chunkSource returns the source of a grounding chunk.
+func chunkSource(chunk genai.GroundingChunk) (CitationSource, bool) {
+	switch {
+	case chunk.Web != nil && chunk.Web.URI != "":
+		return CitationSource{URI: chunk.Web.URI, Title: chunk.Web.Title}, true
+	case chunk.RetrievedContext != nil && chunk.RetrievedContext.URI != "":
+		return CitationSource{URI: chunk.RetrievedContext.URI, Title: chunk.RetrievedContext.Title}, true
+	case chunk.Maps != nil && chunk.Maps.URI != "":
+		return CitationSource{URI: chunk.Maps.URI, Title: chunk.Maps.Title}, true
+	}
+	return CitationSource{}, false
+}
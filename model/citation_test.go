@@ -0,0 +1,86 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+func TestCitations(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		resp *model.LLMResponse
+		want []model.Citation
+	}{
+		{
+			name: "not grounded",
+			resp: &model.LLMResponse{Content: genai.NewContentFromText("hi", genai.RoleModel)},
+		},
+		{
+			name: "grounded",
+			resp: &model.LLMResponse{GroundingMetadata: &genai.GroundingMetadata{
+				GroundingChunks: []*genai.GroundingChunk{
+					{Web: &genai.GroundingChunkWeb{URI: "https://example.com/paris", Title: "Paris"}},
+					{RetrievedContext: &genai.GroundingChunkRetrievedContext{URI: "gs://docs/france.txt"}},
+					{Web: &genai.GroundingChunkWeb{}},
+				},
+				GroundingSupports: []*genai.GroundingSupport{
+					{
+						Segment:               &genai.Segment{Text: "Paris is the capital of France.", StartIndex: 0, EndIndex: 31},
+						GroundingChunkIndices: []int32{0, 1},
+						ConfidenceScores:      []float32{0.9, 0.5},
+					},
+					{
+						Segment:               &genai.Segment{Text: "It has 2M inhabitants.", PartIndex: 1, StartIndex: 32, EndIndex: 54},
+						GroundingChunkIndices: []int32{5, 1},
+					},
+					{
+						Segment:               &genai.Segment{Text: "Unsourced."},
+						GroundingChunkIndices: []int32{2},
+					},
+					{GroundingChunkIndices: []int32{0}},
+				},
+			}},
+			want: []model.Citation{
+				{
+					Text:     "Paris is the capital of France.",
+					EndIndex: 31,
+					Sources: []model.CitationSource{
+						{URI: "https://example.com/paris", Title: "Paris", Confidence: 0.9},
+						{URI: "gs://docs/france.txt", Confidence: 0.5},
+					},
+				},
+				{
+					Text:       "It has 2M inhabitants.",
+					PartIndex:  1,
+					StartIndex: 32,
+					EndIndex:   54,
+					Sources:    []model.CitationSource{{URI: "gs://docs/france.txt"}},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, tc.resp.Citations()); diff != "" {
+				t.Errorf("Citations() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
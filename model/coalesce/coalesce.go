@@ -0,0 +1,171 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package coalesce provides a model sharing one backend round trip between
+// concurrent identical requests.
+//
+// Under load, runs may issue byte-identical model requests at the same time,
+// e.g. for a shared classification prompt. The model returned by [New]
+// issues only the first of them; the others wait for its responses, which
+// each caller receives as its own copy.
+//
+// Only non-streaming requests are coalesced. Streaming requests are passed
+// through to the wrapped model.
+package coalesce
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"iter"
+	"sync"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/deepcopy"
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/model"
+)
+
+// New returns a model coalescing concurrent identical non-streaming requests
+// to m. Requests are identical if their model name, contents and config
+// encode to the same JSON.
+//
+// A caller whose context is cancelled stops waiting for the shared call
+// without cancelling it for the other callers. The shared call is cancelled
+// once all of its callers stopped waiting. The spans of the callers sharing
+// the call of another caller are marked with the
+// gcp.vertex.agent.llm_coalesced attribute.
+func New(m model.LLM) model.LLM {
+	return &coalescingModel{llm: m, calls: make(map[string]*call)}
+}
+
+type coalescingModel struct {
+	llm model.LLM
+
+	mu    sync.Mutex
+	calls map[string]*call // by request key
+}
+
+// call is a backend round trip shared by its waiters.
+type call struct {
+	done    chan struct{}
+	resps   []*model.LLMResponse
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+func (m *coalescingModel) Name() string {
+	return m.llm.Name()
+}
+
+func (m *coalescingModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	if stream {
+		return m.llm.GenerateContent(ctx, req, stream)
+	}
+	key, err := requestKey(req)
+	if err != nil {
+		// Requests that cannot be encoded are not coalesced.
+		return m.llm.GenerateContent(ctx, req, stream)
+	}
+	return func(yield func(*model.LLMResponse, error) bool) {
+		c, shared := m.join(ctx, key, req)
+		if shared {
+			telemetry.SetLLMCoalesced(telemetry.SpansFromContext(ctx))
+		}
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			m.leave(key, c)
+			yield(nil, ctx.Err())
+			return
+		}
+		for _, resp := range c.resps {
+			if !yield(deepcopy.Clone(resp), nil) {
+				return
+			}
+		}
+		if c.err != nil {
+			yield(nil, c.err)
+		}
+	}
+}
+
+// join returns the call of the request with the given key, starting it if
+// there is none, and reports whether the call was already started by another
+// caller.
+func (m *coalescingModel) join(ctx context.Context, key string, req *model.LLMRequest) (*call, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.calls[key]; ok {
+		c.waiters++
+		return c, true
+	}
+	// The shared call keeps the values of the context of the first caller,
+	// e.g. its spans, but not its cancellation.
+	callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	c := &call{done: make(chan struct{}), waiters: 1, cancel: cancel}
+	m.calls[key] = c
+	go m.run(callCtx, key, req, c)
+	return c, false
+}
+
+// leave removes a waiter that stopped waiting from the call, cancelling the
+// call if it has no waiters left.
+func (m *coalescingModel) leave(key string, c *call) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c.waiters--
+	if c.waiters > 0 {
+		return
+	}
+	if m.calls[key] == c {
+		delete(m.calls, key)
+	}
+	c.cancel()
+}
+
+func (m *coalescingModel) run(ctx context.Context, key string, req *model.LLMRequest, c *call) {
+	defer c.cancel()
+	for resp, err := range m.llm.GenerateContent(ctx, req, false) {
+		if err != nil {
+			c.err = err
+			break
+		}
+		c.resps = append(c.resps, resp)
+	}
+	m.mu.Lock()
+	if m.calls[key] == c {
+		delete(m.calls, key)
+	}
+	m.mu.Unlock()
+	close(c.done)
+}
+
+// requestKey returns the hash of the parts of the request sent to the
+// backend.
+func requestKey(req *model.LLMRequest) (string, error) {
+	b, err := json.Marshal(struct {
+		Model    string                       `json:"model"`
+		Contents []*genai.Content             `json:"contents"`
+		Config   *genai.GenerateContentConfig `json:"config"`
+	}{req.Model, req.Contents, req.Config})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
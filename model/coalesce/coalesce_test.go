@@ -0,0 +1,238 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coalesce
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/model"
+)
+
+// blockingModel answers each request with its text once released, or fails
+// with the error of the context of the call if it is cancelled first.
+type blockingModel struct {
+	release   chan struct{}
+	calls     atomic.Int32
+	cancelled atomic.Int32
+}
+
+func newBlockingModel() *blockingModel {
+	return &blockingModel{release: make(chan struct{})}
+}
+
+func (m *blockingModel) Name() string { return "blocking" }
+
+func (m *blockingModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		m.calls.Add(1)
+		select {
+		case <-m.release:
+			yield(&model.LLMResponse{Content: genai.NewContentFromText("answer to "+req.Contents[0].Parts[0].Text, genai.RoleModel)}, nil)
+		case <-ctx.Done():
+			m.cancelled.Add(1)
+			yield(nil, ctx.Err())
+		}
+	}
+}
+
+func request(text string) *model.LLMRequest {
+	return &model.LLMRequest{Model: "m", Contents: []*genai.Content{genai.NewContentFromText(text, genai.RoleUser)}}
+}
+
+// waitForWaiters waits until the call of the request has n waiters.
+func waitForWaiters(t *testing.T, m *coalescingModel, req *model.LLMRequest, n int) {
+	t.Helper()
+	key, err := requestKey(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		m.mu.Lock()
+		c := m.calls[key]
+		m.mu.Unlock()
+		if c != nil {
+			m.mu.Lock()
+			waiters := c.waiters
+			m.mu.Unlock()
+			if waiters == n {
+				return
+			}
+		}
+	}
+	t.Fatalf("call never had %d waiters", n)
+}
+
+func generate(ctx context.Context, m model.LLM, req *model.LLMRequest) (string, error) {
+	var text string
+	for resp, err := range m.GenerateContent(ctx, req, false) {
+		if err != nil {
+			return "", err
+		}
+		text += resp.Content.Parts[0].Text
+		// Callers own their copy of the responses.
+		resp.Content.Parts[0].Text = "mutated"
+	}
+	return text, nil
+}
+
+func TestConcurrentIdenticalCalls(t *testing.T) {
+	backend := newBlockingModel()
+	m := New(backend).(*coalescingModel)
+
+	const n = 10
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	var wg sync.WaitGroup
+	got := make([]string, n)
+	errs := make([]error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, span := tp.Tracer("test").Start(t.Context(), "call_llm")
+			defer span.End()
+			got[i], errs[i] = generate(telemetry.ContextWithSpan(t.Context(), []trace.Span{span}), m, request("q"))
+		}()
+	}
+	// A different request is not coalesced.
+	var other string
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		other, _ = generate(t.Context(), m, request("other"))
+	}()
+	waitForWaiters(t, m, request("q"), n)
+	waitForWaiters(t, m, request("other"), 1)
+	close(backend.release)
+	wg.Wait()
+
+	if calls := backend.calls.Load(); calls != 2 {
+		t.Errorf("backend got %d calls, want 2", calls)
+	}
+	for i := range n {
+		if errs[i] != nil || got[i] != "answer to q" {
+			t.Errorf("caller %d got (%q, %v), want (%q, nil)", i, got[i], errs[i], "answer to q")
+		}
+	}
+	if other != "answer to other" {
+		t.Errorf("other request got %q, want %q", other, "answer to other")
+	}
+	coalesced := 0
+	for _, span := range recorder.Ended() {
+		for _, kv := range span.Attributes() {
+			if kv == attribute.Bool("gcp.vertex.agent.llm_coalesced", true) {
+				coalesced++
+			}
+		}
+	}
+	if coalesced != n-1 {
+		t.Errorf("got %d coalesced spans, want %d", coalesced, n-1)
+	}
+}
+
+func TestCancelledWaiter(t *testing.T) {
+	backend := newBlockingModel()
+	m := New(backend).(*coalescingModel)
+
+	firstCtx, cancelFirst := context.WithCancel(t.Context())
+	var firstErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, firstErr = generate(firstCtx, m, request("q"))
+	}()
+	waitForWaiters(t, m, request("q"), 1)
+	var second string
+	var secondErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		second, secondErr = generate(t.Context(), m, request("q"))
+	}()
+	waitForWaiters(t, m, request("q"), 2)
+
+	cancelFirst()
+	waitForWaiters(t, m, request("q"), 1)
+	close(backend.release)
+	wg.Wait()
+
+	if !errors.Is(firstErr, context.Canceled) {
+		t.Errorf("cancelled caller got error %v, want %v", firstErr, context.Canceled)
+	}
+	if secondErr != nil || second != "answer to q" {
+		t.Errorf("other caller got (%q, %v), want (%q, nil)", second, secondErr, "answer to q")
+	}
+	if calls, cancelled := backend.calls.Load(), backend.cancelled.Load(); calls != 1 || cancelled != 0 {
+		t.Errorf("backend got %d calls, %d cancelled, want 1 call, none cancelled", calls, cancelled)
+	}
+}
+
+func TestAllWaitersCancelled(t *testing.T) {
+	backend := newBlockingModel()
+	m := New(backend).(*coalescingModel)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error)
+	go func() {
+		_, err := generate(ctx, m, request("q"))
+		done <- err
+	}()
+	waitForWaiters(t, m, request("q"), 1)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("generate() error = %v, want %v", err, context.Canceled)
+	}
+	for deadline := time.Now().Add(5 * time.Second); backend.cancelled.Load() == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("shared call was not cancelled")
+		}
+	}
+
+	// A new identical request starts a new call.
+	close(backend.release)
+	if got, err := generate(t.Context(), m, request("q")); err != nil || got != "answer to q" {
+		t.Errorf("generate() = (%q, %v), want (%q, nil)", got, err, "answer to q")
+	}
+}
+
+func TestStreamingNotCoalesced(t *testing.T) {
+	backend := newBlockingModel()
+	close(backend.release)
+	m := New(backend)
+	for range 2 {
+		for _, err := range m.GenerateContent(t.Context(), request("q"), true) {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if calls := backend.calls.Load(); calls != 2 {
+		t.Errorf("backend got %d calls, want 2", calls)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Typed errors of model calls. Providers return errors in different shapes;
+// errors recognized by a registered [ErrorMapper] are normalized to a
+// [*Error] matched by errors.Is for one of these, so that retry and
+// circuit-breaker logic does not depend on the provider.
+var (
+	// ErrRateLimited is returned when the provider rejects a call because
+	// of a rate limit or an exhausted quota.
+	ErrRateLimited = errors.New("model call rate limited")
+	// ErrContextOverflow is returned when a request exceeds the context
+	// window of the model.
+	ErrContextOverflow = errors.New("request exceeds the context window of the model")
+	// ErrContentFiltered is returned when the provider blocks the request
+	// or the response, e.g. for safety reasons.
+	ErrContentFiltered = errors.New("content filtered by the model provider")
+	// ErrInvalidArgument is returned for requests the provider rejects as
+	// invalid. Retrying them unchanged fails again.
+	ErrInvalidArgument = errors.New("invalid model request")
+	// ErrTransient is returned for failures that may succeed if retried,
+	// e.g. an unavailable backend or a network timeout.
+	ErrTransient = errors.New("transient model failure")
+)
+
+// Error is a provider error normalized to one of the typed errors. It is
+// matched by errors.Is for both Kind and the original error.
+type Error struct {
+	// Kind is one of the typed errors, e.g. ErrRateLimited.
+	Kind error
+	// Provider names the provider which returned the error, e.g. "gemini".
+	Provider string
+	// Err is the error returned by the provider.
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %s: %v", e.Kind, e.Provider, e.Err)
+}
+
+// Unwrap returns the kind and the original error.
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// ErrorMapper maps an error of a provider to one of the typed errors, e.g.
+// ErrRateLimited. It returns nil for errors it does not recognize.
+type ErrorMapper func(err error) error
+
+var errorMappers struct {
+	mu      sync.RWMutex
+	mappers []namedMapper
+}
+
+type namedMapper struct {
+	provider string
+	mapper   ErrorMapper
+}
+
+// RegisterErrorMapper adds the mapper of the errors of a provider. Mappers
+// are tried in the order they were registered; the first one recognizing
+// an error wins. Model packages register the mapper of their provider when
+// they are imported.
+func RegisterErrorMapper(provider string, mapper ErrorMapper) {
+	errorMappers.mu.Lock()
+	defer errorMappers.mu.Unlock()
+	errorMappers.mappers = append(errorMappers.mappers, namedMapper{provider: provider, mapper: mapper})
+}
+
+// NormalizeError returns err as a [*Error] if a registered mapper
+// recognizes it. Errors already normalized, nil and unrecognized errors are
+// returned unchanged.
+func NormalizeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var normalized *Error
+	if errors.As(err, &normalized) {
+		return err
+	}
+	errorMappers.mu.RLock()
+	defer errorMappers.mu.RUnlock()
+	for _, m := range errorMappers.mappers {
+		if kind := m.mapper(err); kind != nil {
+			return &Error{Kind: kind, Provider: m.provider, Err: err}
+		}
+	}
+	return err
+}
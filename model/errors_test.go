@@ -0,0 +1,71 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/adk/model"
+)
+
+// quotaError is the error of a fictional provider.
+type quotaError struct {
+	retryable bool
+}
+
+func (e quotaError) Error() string { return "quota" }
+
+func TestNormalizeError(t *testing.T) {
+	model.RegisterErrorMapper("fictional", func(err error) error {
+		var q quotaError
+		if !errors.As(err, &q) {
+			return nil
+		}
+		if q.retryable {
+			return model.ErrTransient
+		}
+		return model.ErrRateLimited
+	})
+	unknown := errors.New("unknown")
+	normalized := &model.Error{Kind: model.ErrInvalidArgument, Provider: "other", Err: unknown}
+
+	for _, tc := range []struct {
+		name     string
+		err      error
+		want     error
+		wantSame bool
+	}{
+		{name: "nil"},
+		{name: "recognized", err: quotaError{}, want: model.ErrRateLimited},
+		{name: "wrapped", err: fmt.Errorf("call failed: %w", quotaError{retryable: true}), want: model.ErrTransient},
+		{name: "unrecognized", err: unknown, wantSame: true},
+		{name: "already normalized", err: normalized, want: model.ErrInvalidArgument, wantSame: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := model.NormalizeError(tc.err)
+			if tc.wantSame && got != tc.err {
+				t.Errorf("NormalizeError() = %v, want the error unchanged", got)
+			}
+			if tc.want != nil && !errors.Is(got, tc.want) {
+				t.Errorf("NormalizeError() = %v, want an error matching %v", got, tc.want)
+			}
+			if tc.err != nil && !errors.Is(got, tc.err) {
+				t.Errorf("NormalizeError() = %v, does not wrap the original error", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+func init() {
+	model.RegisterErrorMapper("gemini", MapError)
+}
+
+// errPromptBlocked is returned for responses without candidates because the
+// prompt was blocked.
+type errPromptBlocked struct {
+	reason genai.BlockedReason
+}
+
+func (e errPromptBlocked) Error() string {
+	return "prompt blocked: " + string(e.reason)
+}
+
+// MapError maps the errors of the Gemini API and Vertex AI to the typed
+// errors of the model package. It returns nil for other errors.
+//
+// It is registered with model.RegisterErrorMapper, and the errors of the
+// models of this package are already normalized.
+func MapError(err error) error {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.Code == http.StatusTooManyRequests || apiErr.Status == "RESOURCE_EXHAUSTED":
+			return model.ErrRateLimited
+		case apiErr.Code == http.StatusBadRequest || apiErr.Status == "INVALID_ARGUMENT":
+			if isContextOverflow(apiErr.Message) {
+				return model.ErrContextOverflow
+			}
+			return model.ErrInvalidArgument
+		case apiErr.Code == http.StatusInternalServerError, apiErr.Code == http.StatusBadGateway,
+			apiErr.Code == http.StatusServiceUnavailable, apiErr.Code == http.StatusGatewayTimeout,
+			apiErr.Status == "UNAVAILABLE", apiErr.Status == "INTERNAL", apiErr.Status == "DEADLINE_EXCEEDED":
+			return model.ErrTransient
+		}
+		return nil
+	}
+	var blocked errPromptBlocked
+	if errors.As(err, &blocked) {
+		return model.ErrContentFiltered
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return model.ErrTransient
+	}
+	return nil
+}
+
+// normalizeError returns err as a *model.Error if MapError recognizes it.
+func normalizeError(err error) error {
+	if kind := MapError(err); kind != nil {
+		return &model.Error{Kind: kind, Provider: "gemini", Err: err}
+	}
+	return err
+}
+
+// isContextOverflow reports whether the message of an invalid argument
+// error is about the size of the request.
+func isContextOverflow(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "exceeds the maximum number of tokens") ||
+		strings.Contains(message, "input token count") && strings.Contains(message, "exceeds")
+}
@@ -0,0 +1,67 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestMapError(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "rate limited", err: genai.APIError{Code: 429, Status: "RESOURCE_EXHAUSTED"}, want: model.ErrRateLimited},
+		{name: "quota", err: genai.APIError{Status: "RESOURCE_EXHAUSTED"}, want: model.ErrRateLimited},
+		{
+			name: "context overflow",
+			err:  genai.APIError{Code: 400, Status: "INVALID_ARGUMENT", Message: "The input token count (1200000) exceeds the maximum number of tokens allowed (1048576)."},
+			want: model.ErrContextOverflow,
+		},
+		{name: "invalid argument", err: genai.APIError{Code: 400, Status: "INVALID_ARGUMENT", Message: "invalid schema"}, want: model.ErrInvalidArgument},
+		{name: "unavailable", err: genai.APIError{Code: 503, Status: "UNAVAILABLE"}, want: model.ErrTransient},
+		{name: "internal", err: genai.APIError{Code: 500, Status: "INTERNAL"}, want: model.ErrTransient},
+		{name: "wrapped", err: fmt.Errorf("call: %w", genai.APIError{Code: 504}), want: model.ErrTransient},
+		{name: "prompt blocked", err: errPromptBlocked{reason: genai.BlockedReasonSafety}, want: model.ErrContentFiltered},
+		{name: "network timeout", err: &url.Error{Op: "Post", URL: "https://example.com", Err: timeoutError{}}, want: model.ErrTransient},
+		{name: "permission denied", err: genai.APIError{Code: 403, Status: "PERMISSION_DENIED"}},
+		{name: "cancelled", err: context.Canceled},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := MapError(tc.err); got != tc.want {
+				t.Errorf("MapError() = %v, want %v", got, tc.want)
+			}
+			normalized := model.NormalizeError(tc.err)
+			if tc.want != nil && !errors.Is(normalized, tc.want) {
+				t.Errorf("NormalizeError() = %v, want an error matching %v", normalized, tc.want)
+			}
+		})
+	}
+}
@@ -23,10 +23,13 @@ import (
 	"runtime"
 	"strings"
 
+	"cloud.google.com/go/auth"
+	"cloud.google.com/go/auth/httptransport"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/internal/llminternal"
 	"google.golang.org/adk/internal/llminternal/converters"
+	"google.golang.org/adk/internal/telemetry"
 	"google.golang.org/adk/internal/version"
 	"google.golang.org/adk/model"
 )
@@ -44,8 +47,23 @@ type geminiModel struct {
 // [genai.Client]. The modelName specifies which Gemini model to target
 // (e.g., "gemini-2.5-flash").
 //
+// All requests of the model, including those of Ping, are sent with
+// cfg.HTTPClient if it is set, e.g. to route them through a proxy or to set a
+// CA bundle and timeouts. For the Vertex AI backend, cfg.Credentials are added
+// to the requests of cfg.HTTPClient, so that the client does not have to
+// handle authentication itself.
+//
 // An error is returned if the [genai.Client] fails to initialize.
 func NewModel(ctx context.Context, modelName string, cfg *genai.ClientConfig) (model.LLM, error) {
+	if cfg != nil && cfg.HTTPClient != nil && cfg.Backend == genai.BackendVertexAI && cfg.Credentials != nil && cfg.APIKey == "" {
+		httpClient, err := authenticatedClient(ctx, cfg.HTTPClient, cfg.Credentials)
+		if err != nil {
+			return nil, err
+		}
+		c := *cfg
+		c.HTTPClient = httpClient
+		cfg = &c
+	}
 	client, err := genai.NewClient(ctx, cfg)
 	if err != nil {
 		return nil, err
@@ -62,6 +80,30 @@ func NewModel(ctx context.Context, modelName string, cfg *genai.ClientConfig) (m
 	}, nil
 }
 
+// authenticatedClient returns a copy of client whose transport adds creds to
+// each request, like the client genai creates when no client is given.
+func authenticatedClient(ctx context.Context, client *http.Client, creds *auth.Credentials) (*http.Client, error) {
+	quotaProjectID, err := creds.QuotaProjectID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quota project ID: %w", err)
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	authClient, err := httptransport.NewClient(&httptransport.Options{
+		Credentials:      creds,
+		BaseRoundTripper: base,
+		Headers:          http.Header{"X-Goog-User-Project": []string{quotaProjectID}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+	c := *client
+	c.Transport = authClient.Transport
+	return &c, nil
+}
+
 func (m *geminiModel) Name() string {
 	return m.name
 }
@@ -79,6 +121,7 @@ func (m *geminiModel) GenerateContent(ctx context.Context, req *model.LLMRequest
 		req.Config.HTTPOptions.Headers = make(http.Header)
 	}
 	m.addHeaders(req.Config.HTTPOptions.Headers)
+	telemetry.InjectHeaders(ctx, req.Config.HTTPOptions.Headers)
 
 	if stream {
 		return m.generateStream(ctx, req)
@@ -90,6 +133,16 @@ func (m *geminiModel) GenerateContent(ctx context.Context, req *model.LLMRequest
 	}
 }
 
+// Ping implements model.Pinger by counting the tokens of a minimal request,
+// which checks the credentials and the endpoint without generating content.
+func (m *geminiModel) Ping(ctx context.Context) error {
+	_, err := m.client.Models.CountTokens(ctx, m.name, []*genai.Content{genai.NewContentFromText("ping", genai.RoleUser)}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to ping model: %w", normalizeError(err))
+	}
+	return nil
+}
+
 // addHeaders sets the x-goog-api-client and user-agent headers
 func (m *geminiModel) addHeaders(headers http.Header) {
 	headers.Set("x-goog-api-client", m.versionHeaderValue)
@@ -100,9 +153,12 @@ func (m *geminiModel) addHeaders(headers http.Header) {
 func (m *geminiModel) generate(ctx context.Context, req *model.LLMRequest) (*model.LLMResponse, error) {
 	resp, err := m.client.Models.GenerateContent(ctx, m.name, req.Contents, req.Config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call model: %w", err)
+		return nil, fmt.Errorf("failed to call model: %w", normalizeError(err))
 	}
 	if len(resp.Candidates) == 0 {
+		if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" {
+			return nil, normalizeError(errPromptBlocked{reason: resp.PromptFeedback.BlockReason})
+		}
 		// shouldn't happen?
 		return nil, fmt.Errorf("empty response")
 	}
@@ -116,7 +172,7 @@ func (m *geminiModel) generateStream(ctx context.Context, req *model.LLMRequest)
 	return func(yield func(*model.LLMResponse, error) bool) {
 		for resp, err := range m.client.Models.GenerateContentStream(ctx, m.name, req.Contents, req.Config) {
 			if err != nil {
-				yield(nil, err)
+				yield(nil, normalizeError(err))
 				return
 			}
 			for llmResponse, err := range aggregator.ProcessResponse(ctx, resp) {
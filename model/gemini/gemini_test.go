@@ -15,13 +15,16 @@
 package gemini
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"iter"
 	"net/http"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"cloud.google.com/go/auth"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"google.golang.org/genai"
@@ -260,3 +263,113 @@ func (h *headerInterceptor) RoundTrip(req *http.Request) (*http.Response, error)
 	}
 	return h.base.RoundTrip(req)
 }
+
+func TestModel_Ping(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		status  int
+		body    string
+		wantErr bool
+	}{
+		{
+			name:   "reachable",
+			status: http.StatusOK,
+			body:   `{"totalTokens": 1}`,
+		},
+		{
+			name:    "invalid credentials",
+			status:  http.StatusUnauthorized,
+			body:    `{"error": {"code": 401, "message": "API key not valid", "status": "UNAUTHENTICATED"}}`,
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var paths []string
+			transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				paths = append(paths, req.URL.Path)
+				return &http.Response{
+					StatusCode: tc.status,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(tc.body)),
+					Request:    req,
+				}, nil
+			})
+			m, err := NewModel(t.Context(), "gemini-2.0-flash", &genai.ClientConfig{
+				HTTPClient: &http.Client{Transport: transport},
+				APIKey:     "fakekey",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := model.Ping(t.Context(), m); (err != nil) != tc.wantErr {
+				t.Errorf("Ping() error = %v, want error: %v", err, tc.wantErr)
+			}
+			if len(paths) != 1 || !strings.HasSuffix(paths[0], "/models/gemini-2.0-flash:countTokens") {
+				t.Errorf("Ping() requested %v, want one countTokens request", paths)
+			}
+		})
+	}
+}
+
+func TestNewModel_HTTPClient(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		cfg      genai.ClientConfig
+		wantAuth string
+	}{
+		{
+			name: "gemini api",
+			cfg:  genai.ClientConfig{APIKey: "fakekey", Backend: genai.BackendGeminiAPI},
+		},
+		{
+			name: "vertex ai with credentials",
+			cfg: genai.ClientConfig{
+				Backend:  genai.BackendVertexAI,
+				Project:  "project",
+				Location: "us-central1",
+				Credentials: auth.NewCredentials(&auth.CredentialsOptions{
+					TokenProvider: tokenProviderFunc(func(context.Context) (*auth.Token, error) {
+						return &auth.Token{Value: "token", Type: "Bearer"}, nil
+					}),
+				}),
+			},
+			wantAuth: "Bearer token",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var auths []string
+			cfg := tc.cfg
+			cfg.HTTPClient = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				auths = append(auths, req.Header.Get("Authorization"))
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(`{"totalTokens": 1}`)),
+					Request:    req,
+				}, nil
+			})}
+			m, err := NewModel(t.Context(), "gemini-2.0-flash", &cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := model.Ping(t.Context(), m); err != nil {
+				t.Fatalf("Ping() error = %v", err)
+			}
+			if diff := cmp.Diff([]string{tc.wantAuth}, auths); diff != "" {
+				t.Errorf("Authorization headers sent with the injected client mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+type tokenProviderFunc func(context.Context) (*auth.Token, error)
+
+func (fn tokenProviderFunc) Token(ctx context.Context) (*auth.Token, error) {
+	return fn(ctx)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
@@ -28,6 +28,48 @@ type LLM interface {
 	GenerateContent(ctx context.Context, req *LLMRequest, stream bool) iter.Seq2[*LLMResponse, error]
 }
 
+// Pinger is implemented by LLMs that can cheaply check that their backend is
+// reachable and accepts their credentials, e.g. with a token count call.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping checks that the backend of the LLM is reachable. It uses the Ping
+// method of LLMs implementing [Pinger], or else generates at most one token
+// for a minimal request.
+func Ping(ctx context.Context, llm LLM) error {
+	if p, ok := llm.(Pinger); ok {
+		return p.Ping(ctx)
+	}
+	req := &LLMRequest{
+		Model:    llm.Name(),
+		Contents: []*genai.Content{genai.NewContentFromText("ping", genai.RoleUser)},
+		Config:   &genai.GenerateContentConfig{MaxOutputTokens: 1},
+	}
+	for _, err := range llm.GenerateContent(ctx, req, false) {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Generation parameters that runs may override, see
+// agent.RunConfig.GenerationOverrides.
+const (
+	GenerationParamSeed        = "seed"
+	GenerationParamTemperature = "temperature"
+	GenerationParamTopP        = "top_p"
+)
+
+// GenerationParamSupporter is implemented by LLMs whose backend supports
+// only some of the generation parameters that runs may override. The
+// overrides of unsupported parameters are dropped for such LLMs instead of
+// failing their requests. Other LLMs are assumed to support all of them.
+type GenerationParamSupporter interface {
+	SupportsGenerationParam(param string) bool
+}
+
 // LLMRequest is the raw LLM request.
 type LLMRequest struct {
 	Model    string
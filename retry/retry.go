@@ -0,0 +1,209 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package retry retries failed model and tool calls within a retry budget
+// shared by all calls of an invocation.
+//
+// Per-call retries compound: if every call of a run retries a few times, a
+// run can make dozens of backend calls during an incident. The runner puts
+// the budget of agent.RunConfig.RetryBudget in the context of the
+// invocation; [Do] and the models wrapped by [Model] take one retry from it
+// for each retry they make, and return failures immediately once it is
+// exhausted.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/adk/model"
+)
+
+// Defaults used for unset Config fields.
+const (
+	DefaultMaxAttempts = 3
+	DefaultBackoff     = 200 * time.Millisecond
+)
+
+// ErrBudgetExhausted is matched by errors.Is for failures returned without
+// retry because the retry budget of the invocation is exhausted.
+var ErrBudgetExhausted = errors.New("retry budget exhausted")
+
+// Budget is the number of retries left to the calls of an invocation. It is
+// safe for concurrent use. A nil *Budget is unlimited.
+type Budget struct {
+	remaining atomic.Int64
+}
+
+// NewBudget returns a budget of n retries. A negative n allows no retries.
+func NewBudget(n int) *Budget {
+	b := &Budget{}
+	b.remaining.Store(int64(max(n, 0)))
+	return b
+}
+
+// Take takes one retry from the budget. It reports false, leaving the budget
+// unchanged, if the budget is exhausted.
+func (b *Budget) Take() bool {
+	if b == nil {
+		return true
+	}
+	for {
+		n := b.remaining.Load()
+		if n <= 0 {
+			return false
+		}
+		if b.remaining.CompareAndSwap(n, n-1) {
+			return true
+		}
+	}
+}
+
+// Remaining returns the number of retries left, or -1 for a nil budget.
+func (b *Budget) Remaining() int {
+	if b == nil {
+		return -1
+	}
+	return int(b.remaining.Load())
+}
+
+// ContextWithBudget returns a copy of ctx carrying the budget.
+func ContextWithBudget(ctx context.Context, b *Budget) context.Context {
+	return context.WithValue(ctx, budgetCtxKey, b)
+}
+
+// BudgetFromContext returns the budget of ctx, or nil if there is none.
+func BudgetFromContext(ctx context.Context) *Budget {
+	b, _ := ctx.Value(budgetCtxKey).(*Budget)
+	return b
+}
+
+type ctxKey int
+
+const budgetCtxKey ctxKey = 0
+
+// Config is the retry policy of a call.
+type Config struct {
+	// MaxAttempts is the maximum number of attempts of a call, including the
+	// first one. Defaults to DefaultMaxAttempts.
+	MaxAttempts int
+	// Backoff is the delay before the first retry. It doubles with each
+	// further retry. Defaults to DefaultBackoff.
+	Backoff time.Duration
+	// Retryable reports whether a failure may succeed if retried. Defaults
+	// to failures matching model.ErrTransient or model.ErrRateLimited.
+	Retryable func(error) bool
+}
+
+func (c Config) withDefaults() Config {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = DefaultMaxAttempts
+	}
+	if c.Backoff <= 0 {
+		c.Backoff = DefaultBackoff
+	}
+	if c.Retryable == nil {
+		c.Retryable = func(err error) bool {
+			return errors.Is(err, model.ErrTransient) || errors.Is(err, model.ErrRateLimited)
+		}
+	}
+	return c
+}
+
+// Do calls fn until it succeeds, fails with an error that is not retryable,
+// reaches cfg.MaxAttempts or exhausts the budget of ctx. It returns the
+// error of the last attempt.
+func Do(ctx context.Context, cfg Config, fn func(ctx context.Context) error) error {
+	cfg = cfg.withDefaults()
+	backoff := cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= cfg.MaxAttempts || !cfg.Retryable(err) {
+			return err
+		}
+		if err := wait(ctx, BudgetFromContext(ctx), backoff, err); err != nil {
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// wait takes a retry from the budget and waits for the backoff. It returns
+// an error if the retry must not happen.
+func wait(ctx context.Context, b *Budget, backoff time.Duration, err error) error {
+	if !b.Take() {
+		return fmt.Errorf("%w: %w", ErrBudgetExhausted, err)
+	}
+	t := time.NewTimer(backoff)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return err
+	case <-t.C:
+		return nil
+	}
+}
+
+// Model returns an LLM retrying the failed calls of llm. A call is only
+// retried if it fails before yielding a response, so that no response is
+// yielded twice.
+func Model(llm model.LLM, cfg Config) model.LLM {
+	return &retryModel{LLM: llm, cfg: cfg.withDefaults()}
+}
+
+type retryModel struct {
+	model.LLM
+	cfg Config
+}
+
+func (m *retryModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		backoff := m.cfg.Backoff
+		for attempt := 1; ; attempt++ {
+			yielded := false
+			var failure error
+			for resp, err := range m.LLM.GenerateContent(ctx, req, stream) {
+				if err != nil && !yielded {
+					failure = err
+					break
+				}
+				yielded = true
+				if !yield(resp, err) {
+					return
+				}
+			}
+			if failure == nil {
+				return
+			}
+			if attempt >= m.cfg.MaxAttempts || !m.cfg.Retryable(failure) {
+				yield(nil, failure)
+				return
+			}
+			if err := wait(ctx, BudgetFromContext(ctx), backoff, failure); err != nil {
+				yield(nil, err)
+				return
+			}
+			backoff *= 2
+		}
+	}
+}
+
+// Ping checks the backend of the wrapped LLM, see model.Ping.
+func (m *retryModel) Ping(ctx context.Context) error {
+	return model.Ping(ctx, m.LLM)
+}
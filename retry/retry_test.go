@@ -0,0 +1,224 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/retry"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+var errTransient = fmt.Errorf("backend unavailable: %w", model.ErrTransient)
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name          string
+		budget        *retry.Budget
+		failures      int
+		err           error
+		wantCalls     int
+		wantErr       error
+		wantRemaining int
+	}{
+		{
+			name:          "success after retries",
+			budget:        retry.NewBudget(5),
+			failures:      2,
+			err:           errTransient,
+			wantCalls:     3,
+			wantRemaining: 3,
+		},
+		{
+			name:          "max attempts",
+			budget:        retry.NewBudget(5),
+			failures:      5,
+			err:           errTransient,
+			wantCalls:     3,
+			wantErr:       model.ErrTransient,
+			wantRemaining: 3,
+		},
+		{
+			name:          "not retryable",
+			budget:        retry.NewBudget(5),
+			failures:      1,
+			err:           model.ErrInvalidArgument,
+			wantCalls:     1,
+			wantErr:       model.ErrInvalidArgument,
+			wantRemaining: 5,
+		},
+		{
+			name:          "budget exhausted",
+			budget:        retry.NewBudget(1),
+			failures:      5,
+			err:           errTransient,
+			wantCalls:     2,
+			wantErr:       retry.ErrBudgetExhausted,
+			wantRemaining: 0,
+		},
+		{
+			name:          "no budget",
+			failures:      2,
+			err:           errTransient,
+			wantCalls:     3,
+			wantRemaining: -1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := t.Context()
+			if tc.budget != nil {
+				ctx = retry.ContextWithBudget(ctx, tc.budget)
+			}
+			calls := 0
+			err := retry.Do(ctx, retry.Config{Backoff: time.Millisecond}, func(context.Context) error {
+				calls++
+				if calls <= tc.failures {
+					return tc.err
+				}
+				return nil
+			})
+			if !errors.Is(err, tc.wantErr) || (err != nil) != (tc.wantErr != nil) {
+				t.Errorf("Do() error = %v, want %v", err, tc.wantErr)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("Do() made %d calls, want %d", calls, tc.wantCalls)
+			}
+			if got := tc.budget.Remaining(); got != tc.wantRemaining {
+				t.Errorf("Remaining() = %d, want %d", got, tc.wantRemaining)
+			}
+		})
+	}
+}
+
+// flakyModel fails the first calls with a transient error.
+type flakyModel struct {
+	failures int
+	calls    int
+}
+
+func (m *flakyModel) Name() string { return "flaky" }
+
+func (m *flakyModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		m.calls++
+		if m.calls <= m.failures {
+			yield(nil, errTransient)
+			return
+		}
+		yield(&model.LLMResponse{Content: genai.NewContentFromText("done", genai.RoleModel)}, nil)
+	}
+}
+
+func TestBudgetSharedByRun(t *testing.T) {
+	tests := []struct {
+		name           string
+		budget         int
+		wantErr        bool
+		wantModelCalls int
+		wantToolCalls  int
+	}{
+		{
+			name:           "enough budget",
+			budget:         3,
+			wantModelCalls: 3,
+			wantToolCalls:  2,
+		},
+		{
+			// The tool takes the only retry, so the model call fails at once.
+			name:           "budget exhausted by the tool",
+			budget:         1,
+			wantErr:        true,
+			wantModelCalls: 2,
+			wantToolCalls:  2,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			toolCalls := 0
+			flakyTool, err := functiontool.New(functiontool.Config{
+				Name:        "lookup",
+				Description: "looks something up",
+			}, func(ctx tool.Context, args struct{}) (map[string]string, error) {
+				err := retry.Do(ctx, retry.Config{Backoff: time.Millisecond}, func(context.Context) error {
+					toolCalls++
+					if toolCalls == 1 {
+						return errTransient
+					}
+					return nil
+				})
+				return map[string]string{"result": "found"}, err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The first response calls the tool, the next call fails once.
+			mock := &testutil.MockModel{Responses: []*genai.Content{
+				genai.NewContentFromFunctionCall("lookup", map[string]any{}, genai.RoleModel),
+			}}
+			flaky := &flakyModel{failures: 1}
+			llm := &sequenceModel{first: mock, then: flaky}
+			a, err := llmagent.New(llmagent.Config{
+				Name:  "agent",
+				Model: retry.Model(llm, retry.Config{Backoff: time.Millisecond}),
+				Tools: []tool.Tool{flakyTool},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := testutil.NewTestAgentRunner(t, a)
+			_, err = testutil.CollectEvents(r.RunContentWithConfig(t, "s1", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{RetryBudget: tc.budget}))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("run error = %v, want error: %v", err, tc.wantErr)
+			}
+			if tc.wantErr && !errors.Is(err, retry.ErrBudgetExhausted) {
+				t.Errorf("run error = %v, want %v", err, retry.ErrBudgetExhausted)
+			}
+			got := []int{llm.calls, toolCalls}
+			if diff := cmp.Diff([]int{tc.wantModelCalls, tc.wantToolCalls}, got); diff != "" {
+				t.Errorf("model and tool calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// sequenceModel answers the first call with first and the others with then.
+type sequenceModel struct {
+	first, then model.LLM
+	calls       int
+}
+
+func (m *sequenceModel) Name() string { return "sequence" }
+
+func (m *sequenceModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	m.calls++
+	if m.calls == 1 {
+		return m.first.GenerateContent(ctx, req, stream)
+	}
+	return m.then.GenerateContent(ctx, req, stream)
+}
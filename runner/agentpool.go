@@ -0,0 +1,157 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrAgentBusy is returned by [AgentPools] rejecting a run because the pool
+// of its agent is full and, if runs are queued, so is the queue.
+var ErrAgentBusy = errors.New("agent has too many concurrent runs")
+
+// AgentPoolConfig configures the pools returned by [NewAgentPools].
+type AgentPoolConfig struct {
+	// MaxConcurrentRuns is the capacity of the pools of the listed agents,
+	// by agent name.
+	MaxConcurrentRuns map[string]int
+	// DefaultMaxConcurrentRuns is the capacity of the pools of the other
+	// agents. Zero leaves them unlimited.
+	DefaultMaxConcurrentRuns int
+	// Queue makes runs beyond the capacity of their agent wait for a run of
+	// the agent to finish. Otherwise they are rejected with [ErrAgentBusy].
+	Queue bool
+	// MaxQueueLength limits the number of runs waiting for each agent if
+	// Queue is set; further runs are rejected with [ErrAgentBusy]. Zero
+	// means no limit.
+	MaxQueueLength int
+}
+
+// AgentPools gives each agent its own capacity of concurrent runs, so that
+// a burst of runs of one agent does not starve the other agents sharing the
+// process, e.g. of model quota. Pools are keyed by app and agent name. Share
+// one AgentPools between runners to enforce the capacities across them.
+//
+// A run holds a slot of the pool of its entry agent, the agent it starts
+// with, for its whole duration. Agents reached by transfer during the run
+// do not acquire slots of their own pools, so the pool of an agent bounds
+// the runs starting with it, not all the runs it takes part in.
+type AgentPools struct {
+	cfg AgentPoolConfig
+
+	mu    sync.Mutex
+	pools map[poolKey]*agentPool
+}
+
+type poolKey struct {
+	appName, agentName string
+}
+
+type agentPool struct {
+	sem chan struct{}
+	// waiting is the number of runs waiting for the pool, guarded by the
+	// mutex of the AgentPools.
+	waiting int
+}
+
+// NewAgentPools returns in-process [AgentPools].
+func NewAgentPools(cfg AgentPoolConfig) (*AgentPools, error) {
+	if cfg.DefaultMaxConcurrentRuns < 0 || cfg.MaxQueueLength < 0 {
+		return nil, errors.New("DefaultMaxConcurrentRuns and MaxQueueLength must not be negative")
+	}
+	for name, n := range cfg.MaxConcurrentRuns {
+		if n <= 0 {
+			return nil, fmt.Errorf("max concurrent runs of agent %q must be positive, got %d", name, n)
+		}
+	}
+	return &AgentPools{cfg: cfg, pools: make(map[poolKey]*agentPool)}, nil
+}
+
+// Acquire blocks until a run of the agent may start or returns an error,
+// e.g. [ErrAgentBusy] or the error of ctx. It also returns the number of
+// runs of the agent that were waiting when the run arrived. If Acquire
+// succeeds, release must be called once the run finishes.
+func (p *AgentPools) Acquire(ctx context.Context, appName, agentName string) (release func(), queued int, err error) {
+	pool := p.pool(appName, agentName)
+	if pool == nil {
+		return func() {}, 0, nil
+	}
+	select {
+	case pool.sem <- struct{}{}:
+		return pool.releaser(), 0, nil
+	default:
+	}
+
+	p.mu.Lock()
+	queued = pool.waiting
+	if !p.cfg.Queue || (p.cfg.MaxQueueLength > 0 && queued >= p.cfg.MaxQueueLength) {
+		p.mu.Unlock()
+		return nil, queued, fmt.Errorf("%w: %q", ErrAgentBusy, agentName)
+	}
+	pool.waiting++
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		pool.waiting--
+		p.mu.Unlock()
+	}()
+
+	select {
+	case pool.sem <- struct{}{}:
+		return pool.releaser(), queued, nil
+	case <-ctx.Done():
+		return nil, queued, ctx.Err()
+	}
+}
+
+// QueueLength returns the number of runs waiting for the pool of the agent.
+func (p *AgentPools) QueueLength(appName, agentName string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pool, ok := p.pools[poolKey{appName, agentName}]; ok {
+		return pool.waiting
+	}
+	return 0
+}
+
+// pool returns the pool of the agent, or nil if its runs are not limited.
+func (p *AgentPools) pool(appName, agentName string) *agentPool {
+	size, ok := p.cfg.MaxConcurrentRuns[agentName]
+	if !ok {
+		size = p.cfg.DefaultMaxConcurrentRuns
+	}
+	if size <= 0 {
+		return nil
+	}
+	key := poolKey{appName, agentName}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pool, ok := p.pools[key]
+	if !ok {
+		pool = &agentPool{sem: make(chan struct{}, size)}
+		p.pools[key] = pool
+	}
+	return pool
+}
+
+func (pool *agentPool) releaser() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-pool.sem })
+	}
+}
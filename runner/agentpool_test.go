@@ -0,0 +1,245 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/session"
+)
+
+func TestAgentPools(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     AgentPoolConfig
+		wantErr error
+	}{
+		{
+			name:    "reject",
+			cfg:     AgentPoolConfig{MaxConcurrentRuns: map[string]int{"agent": 2}},
+			wantErr: ErrAgentBusy,
+		},
+		{
+			name:    "queue until the context is done",
+			cfg:     AgentPoolConfig{MaxConcurrentRuns: map[string]int{"agent": 2}, Queue: true},
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := NewAgentPools(tc.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var releases []func()
+			for range 2 {
+				release, _, err := p.Acquire(t.Context(), "app", "agent")
+				if err != nil {
+					t.Fatalf("Acquire() error = %v", err)
+				}
+				releases = append(releases, release)
+			}
+
+			// Other agents and other apps have their own pools.
+			for _, key := range []poolKey{{"app", "other"}, {"other_app", "agent"}} {
+				release, _, err := p.Acquire(t.Context(), key.appName, key.agentName)
+				if err != nil {
+					t.Fatalf("Acquire(%q, %q) error = %v", key.appName, key.agentName, err)
+				}
+				release()
+			}
+
+			ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+			defer cancel()
+			if _, _, err := p.Acquire(ctx, "app", "agent"); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Acquire() beyond the capacity error = %v, want %v", err, tc.wantErr)
+			}
+
+			releases[0]()
+			// Releasing twice must not free another slot.
+			releases[0]()
+			release, _, err := p.Acquire(t.Context(), "app", "agent")
+			if err != nil {
+				t.Fatalf("Acquire() after release error = %v", err)
+			}
+			if _, _, err := p.Acquire(ctx, "app", "agent"); err == nil {
+				t.Fatal("Acquire() succeeded beyond the capacity after a double release")
+			}
+			release()
+			releases[1]()
+		})
+	}
+}
+
+func TestAgentPoolsQueueLength(t *testing.T) {
+	p, err := NewAgentPools(AgentPoolConfig{DefaultMaxConcurrentRuns: 1, Queue: true, MaxQueueLength: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release, _, err := p.Acquire(t.Context(), "app", "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	queued := make(chan int, 2)
+	for range 2 {
+		go func() {
+			r, n, err := p.Acquire(t.Context(), "app", "agent")
+			if err != nil {
+				t.Errorf("queued Acquire() error = %v", err)
+				queued <- -1
+				return
+			}
+			queued <- n
+			r()
+		}()
+	}
+	for p.QueueLength("app", "agent") < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, n, err := p.Acquire(t.Context(), "app", "agent"); !errors.Is(err, ErrAgentBusy) || n != 2 {
+		t.Errorf("Acquire() with a full queue = (%d, %v), want (2, %v)", n, err, ErrAgentBusy)
+	}
+	release()
+	got := map[int]bool{<-queued: true, <-queued: true}
+	if !got[0] || !got[1] {
+		t.Errorf("queue depths of the waiting runs = %v, want 0 and 1", got)
+	}
+}
+
+func TestNewAgentPoolsInvalid(t *testing.T) {
+	for _, cfg := range []AgentPoolConfig{
+		{MaxConcurrentRuns: map[string]int{"agent": 0}},
+		{DefaultMaxConcurrentRuns: -1},
+		{MaxQueueLength: -1},
+	} {
+		if _, err := NewAgentPools(cfg); err == nil {
+			t.Errorf("NewAgentPools(%+v) succeeded, want error", cfg)
+		}
+	}
+}
+
+// TestRunner_AgentPoolsIsolation floods one agent with runs and checks that
+// a run of another agent sharing the pools is not starved.
+func TestRunner_AgentPoolsIsolation(t *testing.T) {
+	unblock := make(chan struct{})
+	var running, maxRunning atomic.Int32
+	newAgent := func(name string, block bool) agent.Agent {
+		a, err := agent.New(agent.Config{
+			Name: name,
+			Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+				return func(yield func(*session.Event, error) bool) {
+					if block {
+						n := running.Add(1)
+						defer running.Add(-1)
+						for m := maxRunning.Load(); n > m && !maxRunning.CompareAndSwap(m, n); m = maxRunning.Load() {
+						}
+						select {
+						case <-unblock:
+						case <-ctx.Done():
+							yield(nil, ctx.Err())
+							return
+						}
+					}
+					ev := session.NewEvent(ctx.InvocationID())
+					ev.Author = name
+					ev.Content = genai.NewContentFromText("done", genai.RoleModel)
+					yield(ev, nil)
+				}
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	pools, err := NewAgentPools(AgentPoolConfig{DefaultMaxConcurrentRuns: 2, Queue: true, MaxQueueLength: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	newRunner := func(a agent.Agent) *Runner {
+		r, err := New(Config{AppName: "test", Agent: a, SessionService: sessionService, AgentPools: pools})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	run := func(r *Runner) error {
+		resp, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "test", UserID: "user"})
+		if err != nil {
+			return err
+		}
+		for _, err := range r.Run(t.Context(), "user", resp.Session.ID(), genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	popular := newRunner(newAgent("popular", true))
+	const burst = 20
+	var wg sync.WaitGroup
+	var rejected atomic.Int32
+	for range burst {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := run(popular); errors.Is(err, ErrAgentBusy) {
+				rejected.Add(1)
+			} else if err != nil {
+				t.Errorf("run of the popular agent error = %v", err)
+			}
+		}()
+	}
+	// Wait until the popular agent is saturated: two runs are running, five
+	// are queued and the others are rejected.
+	deadline := time.Now().Add(5 * time.Second)
+	for running.Load() < 2 || pools.QueueLength("test", "popular") < 5 || rejected.Load() < burst-2-5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("popular agent not saturated: %d running, %d queued, %d rejected", running.Load(), pools.QueueLength("test", "popular"), rejected.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- run(newRunner(newAgent("quiet", false))) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("run of the quiet agent error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("run of the quiet agent starved by the popular agent")
+	}
+
+	close(unblock)
+	wg.Wait()
+	if got := maxRunning.Load(); got != 2 {
+		t.Errorf("max concurrent runs of the popular agent = %d, want 2", got)
+	}
+	if got := rejected.Load(); got != burst-2-5 {
+		t.Errorf("rejected runs of the popular agent = %d, want %d", got, burst-2-5)
+	}
+}
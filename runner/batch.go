@@ -0,0 +1,144 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sync"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/session"
+)
+
+// DefaultBatchConcurrency is the number of inputs run in parallel if
+// BatchConfig.Concurrency is not set.
+const DefaultBatchConcurrency = 4
+
+// BatchInput is a single input of a batch run.
+type BatchInput struct {
+	UserID string
+	// SessionID is the session to run the input in.
+	// optional: a new session is created if empty.
+	SessionID string
+	Message   *genai.Content
+}
+
+// BatchConfig configures [Runner.RunBatch].
+type BatchConfig struct {
+	// Concurrency is the maximum number of inputs run in parallel.
+	// optional: DefaultBatchConcurrency is used if zero.
+	Concurrency int
+	// RunConfig is used for every run of the batch.
+	RunConfig agent.RunConfig
+}
+
+// BatchResult is the outcome of running a single input of a batch.
+type BatchResult struct {
+	// Index is the position of the input in the batch.
+	Index int
+	// SessionID is the session used for the run.
+	SessionID string
+	// FinalEvent is the last final response event, if any.
+	FinalEvent *session.Event
+	// Err is the error of the run. A failed input does not stop the batch.
+	Err error
+}
+
+// RunBatch runs the agent over the inputs with bounded concurrency, yielding
+// one result per input in completion order. The index of a result can be
+// used to correlate it with its input and the number of yielded results
+// reports the progress of the batch.
+//
+// Stopping the iteration cancels the runs that are still in progress.
+func (r *Runner) RunBatch(ctx context.Context, inputs []BatchInput, cfg BatchConfig) iter.Seq[*BatchResult] {
+	return func(yield func(*BatchResult) bool) {
+		concurrency := cfg.Concurrency
+		if concurrency <= 0 {
+			concurrency = DefaultBatchConcurrency
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		results := make(chan *BatchResult)
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		go func() {
+			defer close(results)
+			for i, in := range inputs {
+				select {
+				case sem <- struct{}{}:
+				case <-ctx.Done():
+					wg.Wait()
+					return
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer func() { <-sem }()
+					res := r.runBatchInput(ctx, in, cfg.RunConfig)
+					res.Index = i
+					select {
+					case results <- res:
+					case <-ctx.Done():
+					}
+				}()
+			}
+			wg.Wait()
+		}()
+
+		for res := range results {
+			if !yield(res) {
+				cancel()
+				for range results {
+				}
+				return
+			}
+		}
+	}
+}
+
+func (r *Runner) runBatchInput(ctx context.Context, in BatchInput, cfg agent.RunConfig) *BatchResult {
+	res := &BatchResult{SessionID: in.SessionID}
+	if in.Message == nil {
+		res.Err = fmt.Errorf("message is required")
+		return res
+	}
+	if res.SessionID == "" {
+		resp, err := r.sessionService.Create(ctx, &session.CreateRequest{
+			AppName: r.appName,
+			UserID:  in.UserID,
+		})
+		if err != nil {
+			res.Err = fmt.Errorf("failed to create session: %w", err)
+			return res
+		}
+		res.SessionID = resp.Session.ID()
+	}
+	for event, err := range r.Run(ctx, in.UserID, res.SessionID, in.Message, cfg) {
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		if event.IsFinalResponse() {
+			res.FinalEvent = event
+		}
+	}
+	return res
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"fmt"
+	"iter"
+	"sort"
+	"testing"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+)
+
+func TestRunner_RunBatch(t *testing.T) {
+	t.Parallel()
+
+	echo, err := agent.New(agent.Config{
+		Name: "echo",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				text := ctx.UserContent().Parts[0].Text
+				if text == "bad" {
+					yield(nil, fmt.Errorf("bad input"))
+					return
+				}
+				ev := session.NewEvent(ctx.InvocationID())
+				ev.Author = "echo"
+				ev.LLMResponse = model.LLMResponse{
+					Content: genai.NewContentFromText("echo: "+text, genai.RoleModel),
+				}
+				yield(ev, nil)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{
+		AppName:        "test",
+		Agent:          echo,
+		SessionService: session.InMemoryService(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := []BatchInput{
+		{UserID: "user", Message: genai.NewContentFromText("a", genai.RoleUser)},
+		{UserID: "user", Message: genai.NewContentFromText("bad", genai.RoleUser)},
+		{UserID: "user", Message: genai.NewContentFromText("c", genai.RoleUser)},
+		{UserID: "user"},
+	}
+	var results []*BatchResult
+	for res := range r.RunBatch(t.Context(), inputs, BatchConfig{Concurrency: 2}) {
+		results = append(results, res)
+	}
+	if len(results) != len(inputs) {
+		t.Fatalf("RunBatch() returned %d results, want %d", len(results), len(inputs))
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Index < results[j].Index })
+
+	for _, i := range []int{0, 2} {
+		res := results[i]
+		if res.Err != nil {
+			t.Errorf("result %d: unexpected error %v", i, res.Err)
+			continue
+		}
+		if res.SessionID == "" {
+			t.Errorf("result %d: no session created", i)
+		}
+		want := "echo: " + inputs[i].Message.Parts[0].Text
+		if res.FinalEvent == nil || res.FinalEvent.Content.Parts[0].Text != want {
+			t.Errorf("result %d: final event = %v, want text %q", i, res.FinalEvent, want)
+		}
+	}
+	for _, i := range []int{1, 3} {
+		if results[i].Err == nil {
+			t.Errorf("result %d: got no error, want error", i)
+		}
+	}
+}
+
+func TestRunner_RunBatchStop(t *testing.T) {
+	t.Parallel()
+
+	r, err := New(Config{
+		AppName: "test",
+		Agent: must(agent.New(agent.Config{
+			Name: "noop",
+			Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+				return func(yield func(*session.Event, error) bool) {}
+			},
+		})),
+		SessionService: session.InMemoryService(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := make([]BatchInput, 10)
+	for i := range inputs {
+		inputs[i] = BatchInput{UserID: "user", Message: genai.NewContentFromText("hi", genai.RoleUser)}
+	}
+
+	count := 0
+	for range r.RunBatch(t.Context(), inputs, BatchConfig{Concurrency: 1}) {
+		count++
+		if count == 2 {
+			break
+		}
+	}
+	if count != 2 {
+		t.Errorf("got %d results, want 2", count)
+	}
+}
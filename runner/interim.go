@@ -0,0 +1,81 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/adk/session"
+)
+
+// interimCommits accumulates the text of the partial events of a streaming
+// response and returns an interim event to commit every so many of them.
+// See agent.RunConfig.InterimCommitEvery.
+type interimCommits struct {
+	every int
+
+	author, branch string
+	chunks         int
+	text           strings.Builder
+}
+
+// add records the event and returns the interim event to commit after it,
+// or nil. A non-partial event ends the response being accumulated.
+func (c *interimCommits) add(ctx context.Context, event *session.Event) *session.Event {
+	if c.every <= 0 {
+		return nil
+	}
+	if !event.Partial {
+		c.reset("", "")
+		return nil
+	}
+	text := partialText(event)
+	if text == "" {
+		// e.g. a phase event
+		return nil
+	}
+	if event.Author != c.author || event.Branch != c.branch {
+		c.reset(event.Author, event.Branch)
+	}
+	c.text.WriteString(text)
+	c.chunks++
+	if c.chunks%c.every != 0 {
+		return nil
+	}
+	interim := session.NewInterimEvent(event.InvocationID, c.author, c.branch, c.text.String())
+	interim.ID = session.NewEventID(ctx)
+	return interim
+}
+
+func (c *interimCommits) reset(author, branch string) {
+	c.author, c.branch = author, branch
+	c.chunks = 0
+	c.text.Reset()
+}
+
+// partialText returns the text of the event, without thoughts.
+func partialText(event *session.Event) string {
+	if event.Content == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, p := range event.Content.Parts {
+		if p != nil && !p.Thought {
+			sb.WriteString(p.Text)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"errors"
+	"iter"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+)
+
+func TestRunner_InterimCommits(t *testing.T) {
+	t.Parallel()
+
+	errStream := errors.New("stream broken")
+	for _, tc := range []struct {
+		name        string
+		every       int
+		fail        bool
+		wantStored  []string
+		wantHistory []string
+	}{
+		{
+			name:        "no interim commits by default",
+			wantStored:  []string{"user: go", "agent: abcde"},
+			wantHistory: []string{"user: go", "agent: abcde"},
+		},
+		{
+			name:        "interim commits reconciled into the final event",
+			every:       2,
+			wantStored:  []string{"user: go", "agent (interim): ab", "agent (interim): abcd", "agent: abcde"},
+			wantHistory: []string{"user: go", "agent: abcde"},
+		},
+		{
+			name:        "mid-stream failure keeps the last interim commit",
+			every:       2,
+			fail:        true,
+			wantStored:  []string{"user: go", "agent (interim): ab", "agent (interim): abcd"},
+			wantHistory: []string{"user: go", "agent (interim): abcd"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := agent.New(agent.Config{
+				Name: "agent",
+				Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+					return func(yield func(*session.Event, error) bool) {
+						for _, chunk := range []string{"a", "b", "c", "d"} {
+							ev := session.NewEvent(ctx.InvocationID())
+							ev.Author = "agent"
+							ev.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText(chunk, genai.RoleModel), Partial: true}
+							if !yield(ev, nil) {
+								return
+							}
+						}
+						if tc.fail {
+							yield(nil, errStream)
+							return
+						}
+						ev := session.NewEvent(ctx.InvocationID())
+						ev.Author = "agent"
+						ev.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText("abcde", genai.RoleModel)}
+						yield(ev, nil)
+					}
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sessionService := session.InMemoryService()
+			resp, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "test", UserID: "user"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := New(Config{AppName: "test", Agent: a, SessionService: sessionService})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var partials int
+			var runErr error
+			for ev, err := range r.Run(t.Context(), "user", resp.Session.ID(), genai.NewContentFromText("go", genai.RoleUser), agent.RunConfig{InterimCommitEvery: tc.every}) {
+				if err != nil {
+					runErr = err
+					continue
+				}
+				if ev.Interim {
+					t.Errorf("Run() yielded interim event %q", describeInterim(ev))
+				}
+				if ev.Partial {
+					partials++
+				}
+			}
+			if tc.fail != errors.Is(runErr, errStream) {
+				t.Errorf("Run() error = %v, want stream error: %v", runErr, tc.fail)
+			}
+			if partials != 4 {
+				t.Errorf("Run() yielded %d partial events, want 4", partials)
+			}
+
+			got, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "test", UserID: "user", SessionID: resp.Session.ID()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var events []*session.Event
+			var stored []string
+			for ev := range got.Session.Events().All() {
+				events = append(events, ev)
+				stored = append(stored, describeInterim(ev))
+				if ev.Interim && ev.IsFinalResponse() {
+					t.Errorf("stored interim event %q is a final response", describeInterim(ev))
+				}
+			}
+			if diff := cmp.Diff(tc.wantStored, stored); diff != "" {
+				t.Errorf("stored events mismatch (-want +got):\n%s", diff)
+			}
+			var history []string
+			for _, ev := range session.ReconcileInterim(events) {
+				history = append(history, describeInterim(ev))
+			}
+			if diff := cmp.Diff(tc.wantHistory, history); diff != "" {
+				t.Errorf("ReconcileInterim() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func describeInterim(ev *session.Event) string {
+	author := ev.Author
+	if ev.Interim {
+		author += " (interim)"
+	}
+	var text string
+	if ev.Content != nil {
+		for _, p := range ev.Content.Parts {
+			text += p.Text
+		}
+	}
+	return author + ": " + text
+}
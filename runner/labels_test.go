@@ -0,0 +1,94 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+func TestRunner_Labels(t *testing.T) {
+	t.Parallel()
+
+	type Args struct {
+		Q string `json:"q"`
+	}
+	lookup, err := functiontool.New(functiontool.Config{
+		Name:        "lookup",
+		Description: "looks up a value",
+	}, func(ctx tool.Context, args Args) (map[string]string, error) {
+		return map[string]string{"v": "value of " + args.Q}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "agent",
+		Model: lookupModel{},
+		Tools: []tool.Tool{lookup},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	resp, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "test", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{AppName: "test", Agent: a, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	labels := map[string]string{"experiment": "b", "tier": "free"}
+	cfg := agent.RunConfig{Labels: labels}
+	for ev, err := range r.Run(t.Context(), "user", resp.Session.ID(), genai.NewContentFromText("x", genai.RoleUser), cfg) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(labels, ev.Labels); diff != "" {
+			t.Errorf("yielded %s event labels mismatch (-want +got):\n%s", describeEvent(ev), diff)
+		}
+	}
+
+	got, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "test", UserID: "user", SessionID: resp.Session.ID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Session.Events().Len() != 4 {
+		t.Errorf("got %d stored events, want 4", got.Session.Events().Len())
+	}
+	for ev := range got.Session.Events().All() {
+		if diff := cmp.Diff(labels, ev.Labels); diff != "" {
+			t.Errorf("stored %s event labels mismatch (-want +got):\n%s", describeEvent(ev), diff)
+		}
+	}
+}
+
+func TestAddLabels(t *testing.T) {
+	ev := &session.Event{Labels: map[string]string{"tier": "paid"}}
+	addLabels(ev, map[string]string{"experiment": "b", "tier": "free"})
+	if diff := cmp.Diff(map[string]string{"experiment": "b", "tier": "paid"}, ev.Labels); diff != "" {
+		t.Errorf("addLabels() mismatch (-want +got):\n%s", diff)
+	}
+}
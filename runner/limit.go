@@ -0,0 +1,115 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrTooManyRuns is returned by a [ConcurrencyLimiter] rejecting a run
+// because the user already has the maximum number of concurrent runs.
+var ErrTooManyRuns = errors.New("too many concurrent runs")
+
+// ConcurrencyLimiter limits the number of concurrent runs per app and user.
+// Implement it on top of shared storage to enforce the limit across servers.
+type ConcurrencyLimiter interface {
+	// Acquire blocks until a run of the user may start or returns an error,
+	// e.g. [ErrTooManyRuns] or the error of ctx. If Acquire succeeds, release
+	// must be called once the run finishes.
+	Acquire(ctx context.Context, appName, userID string) (release func(), err error)
+}
+
+// LimitConfig configures the limiter returned by [NewConcurrencyLimiter].
+type LimitConfig struct {
+	// MaxConcurrentRuns is the maximum number of concurrent runs per app and
+	// user. Must be positive.
+	MaxConcurrentRuns int
+	// Queue makes runs beyond the limit wait for a running one to finish.
+	// Otherwise they are rejected with [ErrTooManyRuns].
+	Queue bool
+}
+
+// NewConcurrencyLimiter returns an in-process [ConcurrencyLimiter].
+func NewConcurrencyLimiter(cfg LimitConfig) (ConcurrencyLimiter, error) {
+	if cfg.MaxConcurrentRuns <= 0 {
+		return nil, errors.New("max concurrent runs must be positive")
+	}
+	return &limiter{cfg: cfg, slots: make(map[limitKey]*slot)}, nil
+}
+
+type limiter struct {
+	cfg LimitConfig
+
+	mu    sync.Mutex
+	slots map[limitKey]*slot
+}
+
+type limitKey struct {
+	appName, userID string
+}
+
+// slot holds the runs of a user. It is removed once no run holds or waits
+// for it.
+type slot struct {
+	sem  chan struct{}
+	refs int
+}
+
+func (l *limiter) Acquire(ctx context.Context, appName, userID string) (func(), error) {
+	key := limitKey{appName, userID}
+	l.mu.Lock()
+	s, ok := l.slots[key]
+	if !ok {
+		s = &slot{sem: make(chan struct{}, l.cfg.MaxConcurrentRuns)}
+		l.slots[key] = s
+	}
+	s.refs++
+	l.mu.Unlock()
+
+	if l.cfg.Queue {
+		select {
+		case s.sem <- struct{}{}:
+		case <-ctx.Done():
+			l.unref(key, s)
+			return nil, ctx.Err()
+		}
+	} else {
+		select {
+		case s.sem <- struct{}{}:
+		default:
+			l.unref(key, s)
+			return nil, ErrTooManyRuns
+		}
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-s.sem
+			l.unref(key, s)
+		})
+	}, nil
+}
+
+func (l *limiter) unref(key limitKey, s *slot) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s.refs--
+	if s.refs == 0 {
+		delete(l.slots, key)
+	}
+}
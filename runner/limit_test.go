@@ -0,0 +1,122 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     LimitConfig
+		wantErr error
+	}{
+		{
+			name:    "reject",
+			cfg:     LimitConfig{MaxConcurrentRuns: 2},
+			wantErr: ErrTooManyRuns,
+		},
+		{
+			name:    "queue until the context is done",
+			cfg:     LimitConfig{MaxConcurrentRuns: 2, Queue: true},
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			l, err := NewConcurrencyLimiter(tc.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var releases []func()
+			for range tc.cfg.MaxConcurrentRuns {
+				release, err := l.Acquire(t.Context(), "app", "user")
+				if err != nil {
+					t.Fatalf("Acquire() error = %v", err)
+				}
+				releases = append(releases, release)
+			}
+
+			// Other users are not limited.
+			release, err := l.Acquire(t.Context(), "app", "other")
+			if err != nil {
+				t.Fatalf("Acquire() for another user error = %v", err)
+			}
+			release()
+
+			ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+			defer cancel()
+			if _, err := l.Acquire(ctx, "app", "user"); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Acquire() beyond the limit error = %v, want %v", err, tc.wantErr)
+			}
+
+			releases[0]()
+			// Releasing twice must not free another slot.
+			releases[0]()
+			release, err = l.Acquire(t.Context(), "app", "user")
+			if err != nil {
+				t.Fatalf("Acquire() after release error = %v", err)
+			}
+			if _, err := l.Acquire(ctx, "app", "user"); err == nil {
+				t.Fatal("Acquire() succeeded beyond the limit after a double release")
+			}
+			release()
+			releases[1]()
+
+			if n := len(l.(*limiter).slots); n != 0 {
+				t.Errorf("limiter keeps %d slots after all runs finished, want 0", n)
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimiterQueue(t *testing.T) {
+	l, err := NewConcurrencyLimiter(LimitConfig{MaxConcurrentRuns: 1, Queue: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release, err := l.Acquire(t.Context(), "app", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan error)
+	go func() {
+		release, err := l.Acquire(t.Context(), "app", "user")
+		if err == nil {
+			release()
+		}
+		acquired <- err
+	}()
+	select {
+	case err := <-acquired:
+		t.Fatalf("queued Acquire() returned %v before the running run finished", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	release()
+	if err := <-acquired; err != nil {
+		t.Errorf("queued Acquire() error = %v", err)
+	}
+}
+
+func TestNewConcurrencyLimiterInvalid(t *testing.T) {
+	if _, err := NewConcurrencyLimiter(LimitConfig{}); err == nil {
+		t.Error("NewConcurrencyLimiter() succeeded without a limit")
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+)
+
+// scriptedModel answers with its responses in order, then with its name.
+type scriptedModel struct {
+	name      string
+	responses []*genai.Content
+	requests  []string
+}
+
+func (m *scriptedModel) Name() string { return m.name }
+
+func (m *scriptedModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		m.requests = append(m.requests, req.Model)
+		content := genai.NewContentFromText(m.name, genai.RoleModel)
+		if len(m.responses) > 0 {
+			content, m.responses = m.responses[0], m.responses[1:]
+		}
+		yield(&model.LLMResponse{Content: content}, nil)
+	}
+}
+
+func TestRunner_ModelOverride(t *testing.T) {
+	t.Parallel()
+
+	transfer := genai.NewContentFromFunctionCall("transfer_to_agent", map[string]any{"agent_name": "sub_agent"}, genai.RoleModel)
+	for _, tc := range []struct {
+		name string
+		cfg  agent.RunConfig
+		// transfer makes the first model call transfer to sub_agent.
+		transfer bool
+		// want maps model names to the model names of their requests.
+		want    map[string][]string
+		wantErr error
+	}{
+		{
+			name: "configured model",
+			want: map[string][]string{"configured": {"configured"}},
+		},
+		{
+			name: "override",
+			cfg:  agent.RunConfig{ModelOverride: "candidate"},
+			want: map[string][]string{"candidate": {"candidate"}},
+		},
+		{
+			name:     "override of the agent of the run only",
+			cfg:      agent.RunConfig{ModelOverride: "candidate"},
+			transfer: true,
+			want:     map[string][]string{"candidate": {"candidate"}, "sub": {"sub"}},
+		},
+		{
+			name:     "override of sub-agents",
+			cfg:      agent.RunConfig{ModelOverride: "candidate", ModelOverrideSubAgents: true},
+			transfer: true,
+			want:     map[string][]string{"candidate": {"candidate", "candidate"}},
+		},
+		{
+			name:    "override not allowed",
+			cfg:     agent.RunConfig{ModelOverride: "unknown"},
+			want:    map[string][]string{},
+			wantErr: ErrModelNotAllowed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var first []*genai.Content
+			if tc.transfer {
+				first = []*genai.Content{transfer}
+			}
+			models := []*scriptedModel{
+				{name: "configured", responses: first},
+				{name: "candidate", responses: first},
+				{name: "sub"},
+			}
+			sub, err := llmagent.New(llmagent.Config{Name: "sub_agent", Model: models[2]})
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := llmagent.New(llmagent.Config{Name: "agent", Model: models[0], SubAgents: []agent.Agent{sub}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sessionService := session.InMemoryService()
+			resp, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "test", UserID: "user"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := New(Config{AppName: "test", Agent: a, SessionService: sessionService, ModelOverrides: []model.LLM{models[1]}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var runErr error
+			for _, err := range r.Run(t.Context(), "user", resp.Session.ID(), genai.NewContentFromText("hi", genai.RoleUser), tc.cfg) {
+				if err != nil {
+					runErr = err
+					break
+				}
+			}
+			if !errors.Is(runErr, tc.wantErr) {
+				t.Fatalf("Run() error = %v, want %v", runErr, tc.wantErr)
+			}
+			got := make(map[string][]string)
+			for _, m := range models {
+				if len(m.requests) > 0 {
+					got[m.name] = m.requests
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("model requests mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
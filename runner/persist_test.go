@@ -0,0 +1,151 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/llminternal"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+func TestRunner_PersistedEvents(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name       string
+		persisted  []session.EventKind
+		setState   bool
+		wantStored []string
+	}{
+		{
+			name:       "all events by default",
+			wantStored: []string{"user", "call", "response", "final"},
+		},
+		{
+			name:       "text responses only",
+			persisted:  []session.EventKind{session.EventKindModelText},
+			wantStored: []string{"user", "call marker", "response marker", "final"},
+		},
+		{
+			name:       "tool calls only",
+			persisted:  []session.EventKind{session.EventKindToolCall, session.EventKindToolResponse},
+			wantStored: []string{"user", "call", "response"},
+		},
+		{
+			name:       "skipped event changing the state is stored without content",
+			persisted:  []session.EventKind{session.EventKindModelText},
+			setState:   true,
+			wantStored: []string{"user", "call marker", "response marker", "final"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			type Args struct {
+				Q string `json:"q"`
+			}
+			lookup, err := functiontool.New(functiontool.Config{
+				Name:        "lookup",
+				Description: "looks up a value",
+			}, func(ctx tool.Context, args Args) (map[string]string, error) {
+				if tc.setState {
+					if err := ctx.State().Set("looked_up", args.Q); err != nil {
+						return nil, err
+					}
+				}
+				return map[string]string{"v": "value of " + args.Q}, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := llmagent.New(llmagent.Config{
+				Name:            "agent",
+				Model:           lookupModel{},
+				Tools:           []tool.Tool{lookup},
+				PersistedEvents: tc.persisted,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sessionService := session.InMemoryService()
+			resp, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "test", UserID: "user"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := New(Config{AppName: "test", Agent: a, SessionService: sessionService})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var yielded []string
+			for ev, err := range r.Run(t.Context(), "user", resp.Session.ID(), genai.NewContentFromText("x", genai.RoleUser), agent.RunConfig{}) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				yielded = append(yielded, describeEvent(ev))
+			}
+			// All events are yielded, and the model saw the tool response
+			// in the final call.
+			if diff := cmp.Diff([]string{"call", "response", "final"}, yielded); diff != "" {
+				t.Errorf("yielded events mismatch (-want +got):\n%s", diff)
+			}
+
+			got, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "test", UserID: "user", SessionID: resp.Session.ID()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var stored []string
+			for ev := range got.Session.Events().All() {
+				stored = append(stored, describeEvent(ev))
+			}
+			if diff := cmp.Diff(tc.wantStored, stored); diff != "" {
+				t.Errorf("stored events mismatch (-want +got):\n%s", diff)
+			}
+			if tc.setState {
+				if v, err := got.Session.State().Get("looked_up"); err != nil || v != "x" {
+					t.Errorf("state looked_up = %v, %v, want %q", v, err, "x")
+				}
+			}
+		})
+	}
+}
+
+func describeEvent(ev *session.Event) string {
+	switch {
+	case ev.Author == "user":
+		return "user"
+	case ev.Content == nil && ev.CustomMetadata[llminternal.FunctionCallsMetadataKey] != nil:
+		return "call marker"
+	case ev.Content == nil && ev.Kind == session.EventKindToolResponse:
+		return "response marker"
+	case ev.Content == nil:
+		return "actions"
+	case ev.Content.Parts[0].FunctionCall != nil:
+		return "call"
+	case ev.Content.Parts[0].FunctionResponse != nil:
+		return "response"
+	default:
+		if ev.Content.Parts[0].Text != "value of x" {
+			return "unexpected final response " + ev.Content.Parts[0].Text
+		}
+		return "final"
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/agent/parentmap"
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/llminternal"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+)
+
+// PreviewRequest assembles the request that the agent continuing the
+// session would send to its model on the next turn, without running it.
+// The instructions, the history and the tool declarations are applied as in
+// a run, but no callback is run, the model is not called and the session is
+// not modified. The agent continuing the session is chosen as in
+// [Runner.Run] and must be an LLM agent.
+func (r *Runner) PreviewRequest(ctx context.Context, userID, sessionID string) (agent.Agent, *model.LLMRequest, error) {
+	resp, err := r.sessionService.Get(ctx, &session.GetRequest{
+		AppName:   r.appName,
+		UserID:    userID,
+		SessionID: sessionID,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	agentToRun, err := r.findAgentToRun(ctx, resp.Session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx = parentmap.ToContext(ctx, r.parents)
+	artifacts, memoryImpl := r.sessionServices(resp.Session)
+	// The session is not wrapped in a mutable session, so that state
+	// changes of request processors are not persisted.
+	invCtx := icontext.NewInvocationContext(ctx, icontext.InvocationContextParams{
+		Artifacts: artifacts,
+		Memory:    memoryImpl,
+		Session:   resp.Session,
+		Agent:     agentToRun,
+		RunConfig: &agent.RunConfig{},
+	})
+	req, err := llminternal.BuildRequest(invCtx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return agentToRun, req, nil
+}
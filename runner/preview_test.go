@@ -0,0 +1,182 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"iter"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// countingModel counts its calls and answers with an empty response.
+type countingModel struct {
+	calls int
+}
+
+func (*countingModel) Name() string { return "counting-model" }
+
+func (m *countingModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	m.calls++
+	return func(yield func(*model.LLMResponse, error) bool) {
+		yield(&model.LLMResponse{}, nil)
+	}
+}
+
+func TestPreviewRequest(t *testing.T) {
+	type Args struct {
+		City string `json:"city"`
+	}
+	weather, err := functiontool.New(functiontool.Config{
+		Name:        "get_weather",
+		Description: "returns the weather",
+	}, func(tool.Context, Args) (map[string]string, error) {
+		t.Error("tool called by PreviewRequest")
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockModel := &countingModel{}
+	a, err := llmagent.New(llmagent.Config{
+		Name:        "weather_agent",
+		Model:       mockModel,
+		Instruction: "Answer about the weather.",
+		Tools:       []tool.Tool{weather},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := session.NewEvent("inv")
+	event.Author = "user"
+	event.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText("weather in Paris?", genai.RoleUser)}
+	if err := sessionService.AppendEvent(t.Context(), created.Session, event); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{AppName: "app", Agent: a, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gotAgent, req, err := r.PreviewRequest(t.Context(), "user", "s1")
+	if err != nil {
+		t.Fatalf("PreviewRequest() error = %v", err)
+	}
+	if gotAgent.Name() != "weather_agent" {
+		t.Errorf("PreviewRequest() agent = %q, want %q", gotAgent.Name(), "weather_agent")
+	}
+	if diff := cmp.Diff([]*genai.Content{genai.NewContentFromText("weather in Paris?", genai.RoleUser)}, req.Contents); diff != "" {
+		t.Errorf("request contents mismatch (-want +got):\n%s", diff)
+	}
+	if req.Config == nil || req.Config.SystemInstruction == nil || len(req.Config.SystemInstruction.Parts) == 0 ||
+		req.Config.SystemInstruction.Parts[0].Text != "Answer about the weather." {
+		t.Errorf("request system instruction = %+v, want the agent instruction", req.Config)
+	}
+	var decls []string
+	for _, tl := range req.Config.Tools {
+		for _, d := range tl.FunctionDeclarations {
+			decls = append(decls, d.Name)
+		}
+	}
+	if diff := cmp.Diff([]string{"get_weather"}, decls); diff != "" {
+		t.Errorf("request function declarations mismatch (-want +got):\n%s", diff)
+	}
+	if mockModel.calls != 0 {
+		t.Errorf("model called %d times, want 0", mockModel.calls)
+	}
+
+	got, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := got.Session.Events().Len(); n != 1 {
+		t.Errorf("session has %d events after PreviewRequest, want 1", n)
+	}
+
+	if _, _, err := r.PreviewRequest(t.Context(), "user", "missing"); err == nil {
+		t.Error("PreviewRequest() of a missing session succeeded, want error")
+	}
+	nonLLM, err := agent.New(agent.Config{Name: "plain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err = New(Config{AppName: "app", Agent: nonLLM, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := r.PreviewRequest(t.Context(), "user", "s1"); err == nil {
+		t.Error("PreviewRequest() of a non-LLM agent succeeded, want error")
+	}
+}
+
+func TestPreviewRequestNotes(t *testing.T) {
+	a, err := llmagent.New(llmagent.Config{
+		Name:                  "weather_agent",
+		Model:                 &countingModel{},
+		Instruction:           "Answer about the weather.",
+		ReportRemainingBudget: true,
+		GroundToolResults:     1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := session.NewEvent("inv")
+	event.Author = "weather_agent"
+	event.LLMResponse = model.LLMResponse{Content: genai.NewContentFromFunctionResponse("get_weather", map[string]any{"sky": "clear"}, genai.RoleUser)}
+	if err := sessionService.AppendEvent(t.Context(), created.Session, event); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{AppName: "app", Agent: a, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Hour)
+	defer cancel()
+	_, req, err := r.PreviewRequest(ctx, "user", "s1")
+	if err != nil {
+		t.Fatalf("PreviewRequest() error = %v", err)
+	}
+	var instruction []string
+	for _, p := range req.Config.SystemInstruction.Parts {
+		instruction = append(instruction, p.Text)
+	}
+	for _, want := range []string{`get_weather: {"sky":"clear"}`, "Remaining time budget of this run"} {
+		if !strings.Contains(strings.Join(instruction, "\n"), want) {
+			t.Errorf("request system instruction %q does not contain %q", instruction, want)
+		}
+	}
+}
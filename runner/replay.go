@@ -0,0 +1,127 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool/toolstub"
+)
+
+// ReplayConfig configures [Runner.Replay].
+type ReplayConfig struct {
+	// StubTools answers tool calls with the responses recorded in the source
+	// session, so that the replay does not depend on external services.
+	// Identical calls get the recorded responses in order, repeating the
+	// last one. Calls without a recorded response for the same tool name and
+	// arguments run the tool.
+	StubTools bool
+	// RunConfig is used for every replayed input. Its ToolStub is replaced
+	// if StubTools is set.
+	RunConfig agent.RunConfig
+}
+
+// Replay feeds the user inputs of the src session, in order, to the agent of
+// the runner and returns the new session holding the result. The new session
+// belongs to the same user as src and can be diffed against it to validate
+// a new version of the agent.
+//
+// Only the message that started each invocation of src is replayed. Other
+// user-authored events of src are produced by the agents themselves.
+func (r *Runner) Replay(ctx context.Context, src session.Session, cfg ReplayConfig) (session.Session, error) {
+	created, err := r.sessionService.Create(ctx, &session.CreateRequest{
+		AppName: r.appName,
+		UserID:  src.UserID(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create replay session: %w", err)
+	}
+	sessionID := created.Session.ID()
+
+	runCfg := cfg.RunConfig
+	if cfg.StubTools {
+		stubs, err := toolstub.New(toolstub.Config{Stubs: recordedToolStubs(src)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to stub recorded tool responses: %w", err)
+		}
+		runCfg.ToolStub = stubs.Stub
+	}
+	for i, msg := range userInputs(src) {
+		for _, err := range r.Run(ctx, src.UserID(), sessionID, msg, runCfg) {
+			if err != nil {
+				return nil, fmt.Errorf("failed to replay input %d: %w", i, err)
+			}
+		}
+	}
+
+	resp, err := r.sessionService.Get(ctx, &session.GetRequest{
+		AppName:   r.appName,
+		UserID:    src.UserID(),
+		SessionID: sessionID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get replay session: %w", err)
+	}
+	return resp.Session, nil
+}
+
+// userInputs returns the messages that started the invocations of s.
+func userInputs(s session.Session) []*genai.Content {
+	var inputs []*genai.Content
+	seen := make(map[string]bool)
+	for ev := range s.Events().All() {
+		if seen[ev.InvocationID] {
+			continue
+		}
+		seen[ev.InvocationID] = true
+		if ev.Author == "user" && ev.Content != nil {
+			inputs = append(inputs, ev.Content)
+		}
+	}
+	return inputs
+}
+
+// recordedToolStubs returns stubs answering tool calls with the responses
+// recorded in s.
+func recordedToolStubs(s session.Session) []toolstub.Stub {
+	var stubs []toolstub.Stub
+	calls := make(map[string]*genai.FunctionCall)
+	for ev := range s.Events().All() {
+		for _, fc := range utils.FunctionCalls(ev.Content) {
+			if fc.ID != "" {
+				calls[fc.ID] = fc
+			}
+		}
+		for _, fr := range utils.FunctionResponses(ev.Content) {
+			fc, ok := calls[fr.ID]
+			if !ok {
+				continue
+			}
+			args := fc.Args
+			if args == nil {
+				// A nil Args would match any call of the tool.
+				args = map[string]any{}
+			}
+			stubs = append(stubs, toolstub.Stub{Tool: fc.Name, Args: args, Response: fr.Response})
+		}
+	}
+	return stubs
+}
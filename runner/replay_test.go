@@ -0,0 +1,126 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"iter"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// lookupModel calls the lookup tool with the user text and answers with the
+// value returned by the tool.
+type lookupModel struct{}
+
+func (lookupModel) Name() string { return "lookup-model" }
+
+func (lookupModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		last := req.Contents[len(req.Contents)-1]
+		if frs := utils.FunctionResponses(last); len(frs) > 0 {
+			v, _ := frs[0].Response["v"].(string)
+			yield(&model.LLMResponse{Content: genai.NewContentFromText(v, genai.RoleModel)}, nil)
+			return
+		}
+		q := last.Parts[0].Text
+		yield(&model.LLMResponse{Content: genai.NewContentFromFunctionCall("lookup", map[string]any{"q": q}, genai.RoleModel)}, nil)
+	}
+}
+
+func TestRunner_Replay(t *testing.T) {
+	t.Parallel()
+
+	src := createSession(t, t.Context(), "src", "test", "user", []*session.Event{
+		{InvocationID: "inv1", Author: "user", LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("x", genai.RoleUser)}},
+		{InvocationID: "inv1", Author: "agent", LLMResponse: model.LLMResponse{Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+			{FunctionCall: &genai.FunctionCall{ID: "c1", Name: "lookup", Args: map[string]any{"q": "x"}}},
+		}}}},
+		{InvocationID: "inv1", Author: "agent", LLMResponse: model.LLMResponse{Content: &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{
+			{FunctionResponse: &genai.FunctionResponse{ID: "c1", Name: "lookup", Response: map[string]any{"v": "recorded"}}},
+		}}}},
+		{InvocationID: "inv1", Author: "agent", LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("recorded", genai.RoleModel)}},
+		{InvocationID: "inv2", Author: "user", LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("y", genai.RoleUser)}},
+		// Feedback authored by the flow within the invocation is not replayed.
+		{InvocationID: "inv2", Author: "user", LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("try again", genai.RoleUser)}},
+	})
+
+	for _, tc := range []struct {
+		name      string
+		stubTools bool
+		want      []string
+	}{
+		{
+			name: "live tools",
+			want: []string{"x", "live", "y", "live"},
+		},
+		{
+			name:      "recorded tool responses",
+			stubTools: true,
+			want:      []string{"x", "recorded", "y", "live"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			type Args struct {
+				Q string `json:"q"`
+			}
+			lookup, err := functiontool.New(functiontool.Config{
+				Name:        "lookup",
+				Description: "looks up a value",
+			}, func(ctx tool.Context, args Args) (map[string]string, error) {
+				return map[string]string{"v": "live"}, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := llmagent.New(llmagent.Config{
+				Name:  "agent",
+				Model: lookupModel{},
+				Tools: []tool.Tool{lookup},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := New(Config{AppName: "test", Agent: a, SessionService: session.InMemoryService()})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := r.Replay(t.Context(), src, ReplayConfig{StubTools: tc.stubTools})
+			if err != nil {
+				t.Fatalf("Replay() error = %v", err)
+			}
+			if got.ID() == src.ID() {
+				t.Errorf("Replay() reused the source session")
+			}
+			var texts []string
+			for ev := range got.Events().All() {
+				texts = append(texts, utils.TextParts(ev.Content)...)
+			}
+			if diff := cmp.Diff(tc.want, texts); diff != "" {
+				t.Errorf("Replay() text mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/llminternal"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+func TestRunner_Resume(t *testing.T) {
+	t.Parallel()
+
+	userEvent := &session.Event{InvocationID: "inv1", Author: "user", LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("x", genai.RoleUser)}}
+	callEvent := func(longRunning bool) *session.Event {
+		ev := &session.Event{InvocationID: "inv1", Author: "agent", LLMResponse: model.LLMResponse{Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+			{FunctionCall: &genai.FunctionCall{ID: "c1", Name: "lookup", Args: map[string]any{"q": "x"}}},
+		}}}}
+		if longRunning {
+			ev.LongRunningToolIDs = []string{"c1"}
+		}
+		return ev
+	}
+	responseEvent := &session.Event{InvocationID: "inv1", Author: "agent", LLMResponse: model.LLMResponse{Content: &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{
+		{FunctionResponse: &genai.FunctionResponse{ID: "c1", Name: "lookup", Response: map[string]any{"v": "recorded"}}},
+	}}}}
+
+	// jsonMarker is a call marker as read back from a service storing the
+	// metadata as JSON.
+	jsonMarker := llminternal.FunctionCallsMarker(callEvent(false))
+	jsonMarker.CustomMetadata = map[string]any{llminternal.FunctionCallsMetadataKey: []any{
+		map[string]any{"id": "c1", "name": "lookup", "args": map[string]any{"q": "x"}},
+	}}
+
+	for _, tc := range []struct {
+		name      string
+		events    []*session.Event
+		persisted []session.EventKind
+		wantTexts []string
+		wantErr   error
+	}{
+		{
+			name:      "interrupted function call is executed",
+			events:    []*session.Event{userEvent, callEvent(false)},
+			wantTexts: []string{"live"},
+		},
+		{
+			name:      "interrupted function call stored as a marker",
+			events:    []*session.Event{userEvent, llminternal.FunctionCallsMarker(callEvent(false))},
+			persisted: []session.EventKind{session.EventKindModelText},
+			wantTexts: []string{"live"},
+		},
+		{
+			name:      "interrupted function call stored as a JSON marker",
+			events:    []*session.Event{userEvent, jsonMarker},
+			persisted: []session.EventKind{session.EventKindModelText},
+			wantTexts: []string{"live"},
+		},
+		{
+			name:    "complete run",
+			events:  []*session.Event{userEvent, callEvent(false), responseEvent},
+			wantErr: ErrNothingToResume,
+		},
+		{
+			name:    "pending long-running call",
+			events:  []*session.Event{userEvent, callEvent(true)},
+			wantErr: ErrNothingToResume,
+		},
+		{
+			name:    "empty session",
+			wantErr: ErrNothingToResume,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			type Args struct {
+				Q string `json:"q"`
+			}
+			var calls int
+			lookup, err := functiontool.New(functiontool.Config{
+				Name:        "lookup",
+				Description: "looks up a value",
+			}, func(ctx tool.Context, args Args) (map[string]string, error) {
+				calls++
+				return map[string]string{"v": "live"}, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := llmagent.New(llmagent.Config{
+				Name:            "agent",
+				Model:           lookupModel{},
+				Tools:           []tool.Tool{lookup},
+				PersistedEvents: tc.persisted,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sessionService := session.InMemoryService()
+			resp, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "test", UserID: "user"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, ev := range tc.events {
+				if err := sessionService.AppendEvent(t.Context(), resp.Session, ev); err != nil {
+					t.Fatal(err)
+				}
+			}
+			r, err := New(Config{AppName: "test", Agent: a, SessionService: sessionService})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var gotErr error
+			var gotResponse map[string]any
+			var gotTexts []string
+			for ev, err := range r.Resume(t.Context(), "user", resp.Session.ID(), agent.RunConfig{}) {
+				if err != nil {
+					gotErr = err
+					break
+				}
+				if frs := utils.FunctionResponses(ev.Content); len(frs) > 0 {
+					gotResponse = frs[0].Response
+				}
+				gotTexts = append(gotTexts, utils.TextParts(ev.Content)...)
+			}
+			if !errors.Is(gotErr, tc.wantErr) {
+				t.Fatalf("Resume() error = %v, want %v", gotErr, tc.wantErr)
+			}
+			if tc.wantErr != nil {
+				if calls != 0 {
+					t.Errorf("tool was called %d times, want 0", calls)
+				}
+				return
+			}
+			if calls != 1 {
+				t.Errorf("tool was called %d times, want 1", calls)
+			}
+			if diff := cmp.Diff(map[string]any{"v": "live"}, gotResponse); diff != "" {
+				t.Errorf("function response mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantTexts, gotTexts); diff != "" {
+				t.Errorf("Resume() text mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -16,24 +16,35 @@
 package runner
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"iter"
-	"log"
+	"log/slog"
+	"maps"
+	"time"
 
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/artifact"
+	agentinternal "google.golang.org/adk/internal/agent"
 	"google.golang.org/adk/internal/agent/parentmap"
 	"google.golang.org/adk/internal/agent/runconfig"
 	artifactinternal "google.golang.org/adk/internal/artifact"
+	"google.golang.org/adk/internal/clock"
 	icontext "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/internal/llminternal"
+	"google.golang.org/adk/internal/logging"
 	imemory "google.golang.org/adk/internal/memory"
 	"google.golang.org/adk/internal/sessioninternal"
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/internal/utils"
 	"google.golang.org/adk/memory"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/retry"
 	"google.golang.org/adk/session"
 )
 
@@ -48,6 +59,54 @@ type Config struct {
 	ArtifactService artifact.Service
 	// optional
 	MemoryService memory.Service
+	// Logger is used for structured logging on the run path. Every record
+	// carries the trace_id and span_id of the current span, plus app, user,
+	// session and invocation IDs. Message content is never logged.
+	// optional: slog.Default() is used if nil.
+	Logger *slog.Logger
+	// ConcurrencyLimiter limits the number of concurrent runs per app and
+	// user. Share one limiter between runners to enforce the limit
+	// across them. Runs rejected by the limiter yield its error, e.g.
+	// [ErrTooManyRuns].
+	// optional: runs are not limited if nil.
+	ConcurrencyLimiter ConcurrencyLimiter
+	// SessionLocker serializes the runs of a session. Share one locker
+	// between runners to serialize the runs of all of them. The session is
+	// locked before the run is admitted by the ConcurrencyLimiter, so runs
+	// waiting for a busy session do not count against the limit. Runs
+	// rejected by the locker yield its error, e.g. [ErrSessionBusy].
+	// optional: concurrent runs of a session are not serialized if nil.
+	SessionLocker SessionLocker
+	// RunTimeout is the default deadline of a run, counted from its admission
+	// by the ConcurrencyLimiter. Runs exceeding it are cancelled and end with
+	// an event with the RUN_TIMEOUT error code. See agent.RunConfig.Timeout to
+	// override it per run.
+	// optional: runs have no deadline if zero.
+	RunTimeout time.Duration
+	// ModelOverrides are the models that runs may use instead of the models
+	// of their agents, by name. See agent.RunConfig.ModelOverride.
+	// optional: runs cannot override models if empty.
+	ModelOverrides []model.LLM
+	// AgentPools limits the number of concurrent runs of each agent, so
+	// that agents sharing the process have isolated capacity. Runs wait for
+	// the pool of their entry agent once their session is loaded, and agents
+	// reached by transfer are not limited; see [AgentPools]. The wait
+	// counts against the RunTimeout. Runs rejected by the pools yield their
+	// error, e.g. [ErrAgentBusy].
+	// optional: runs of an agent are not limited if nil.
+	AgentPools *AgentPools
+	// Clock tells the time of runs: the timestamps of their events and
+	// their latencies. Tests set a fake clock to make them deterministic.
+	// The RunTimeout and the timeouts of tool calls are context deadlines,
+	// which follow the system clock.
+	// optional: the system clock is used if nil.
+	Clock Clock
+}
+
+// Clock tells the time of a [Runner].
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
 }
 
 // New creates a new [Runner].
@@ -65,12 +124,32 @@ func New(cfg Config) (*Runner, error) {
 		return nil, fmt.Errorf("failed to create agent tree: %w", err)
 	}
 
+	overrides := make(map[string]model.LLM, len(cfg.ModelOverrides))
+	for _, m := range cfg.ModelOverrides {
+		if m == nil {
+			return nil, fmt.Errorf("model override is nil")
+		}
+		overrides[m.Name()] = m
+	}
+
+	var clk Clock = clock.Real()
+	if cfg.Clock != nil {
+		clk = cfg.Clock
+	}
+
 	return &Runner{
 		appName:         cfg.AppName,
 		rootAgent:       cfg.Agent,
 		sessionService:  cfg.SessionService,
 		artifactService: cfg.ArtifactService,
 		memoryService:   cfg.MemoryService,
+		logger:          logging.New(cfg.Logger),
+		limiter:         cfg.ConcurrencyLimiter,
+		sessionLocker:   cfg.SessionLocker,
+		runTimeout:      cfg.RunTimeout,
+		modelOverrides:  overrides,
+		agentPools:      cfg.AgentPools,
+		clock:           clk,
 		parents:         parents,
 	}, nil
 }
@@ -84,6 +163,13 @@ type Runner struct {
 	sessionService  session.Service
 	artifactService artifact.Service
 	memoryService   memory.Service
+	logger          *slog.Logger
+	limiter         ConcurrencyLimiter
+	sessionLocker   SessionLocker
+	runTimeout      time.Duration
+	modelOverrides  map[string]model.LLM
+	agentPools      *AgentPools
+	clock           Clock
 
 	parents parentmap.Map
 }
@@ -92,10 +178,118 @@ type Runner struct {
 // For each user message it finds the proper agent within an agent tree to
 // continue the conversation within the session.
 func (r *Runner) Run(ctx context.Context, userID, sessionID string, msg *genai.Content, cfg agent.RunConfig) iter.Seq2[*session.Event, error] {
+	return r.run(ctx, userID, sessionID, msg, cfg, r.findAgentToRun)
+}
+
+// Resume continues the interrupted run of a session whose last event has
+// function calls without a response, e.g. because the server crashed while
+// the tools were executing. The agent that made the calls executes them and
+// continues from there, in a new invocation, instead of restarting the
+// conversation.
+//
+// Resume yields ErrNothingToResume if the session has no interrupted run.
+// Runs of agents that do not persist their tool call events (see
+// agent.Config.PersistedEvents) can be resumed too: their function calls
+// are stored without the rest of their event.
+func (r *Runner) Resume(ctx context.Context, userID, sessionID string, cfg agent.RunConfig) iter.Seq2[*session.Event, error] {
+	return r.run(ctx, userID, sessionID, nil, cfg, r.findAgentToResume)
+}
+
+// ErrNothingToResume is yielded by [Runner.Resume] if the last run of the
+// session is complete.
+var ErrNothingToResume = errors.New("session has no interrupted run to resume")
+
+// ErrRunTimeout is the cause of the cancellation of the context of a run
+// exceeding its timeout.
+var ErrRunTimeout = errors.New("run timed out")
+
+// ErrModelNotAllowed is yielded for a run whose agent.RunConfig.ModelOverride
+// is not one of the ModelOverrides of the runner.
+var ErrModelNotAllowed = errors.New("model override not allowed")
+
+// ErrInvalidGenerationOverrides is yielded for a run whose
+// agent.RunConfig.GenerationOverrides are out of range.
+var ErrInvalidGenerationOverrides = errors.New("invalid generation overrides")
+
+// RunTimeoutErrorCode is the error code of the event ending a run that
+// exceeded its timeout.
+const RunTimeoutErrorCode = "RUN_TIMEOUT"
+
+func (r *Runner) run(ctx context.Context, userID, sessionID string, msg *genai.Content, cfg agent.RunConfig, findAgentToRun func(context.Context, session.Session) (agent.Agent, error)) iter.Seq2[*session.Event, error] {
 	// TODO(hakim): we need to validate whether cfg is compatible with the Agent.
 	//   see adk-python/src/google/adk/runners.py Runner._new_invocation_context.
 	// TODO: setup tracer.
 	return func(yield func(*session.Event, error) bool) {
+		logger := r.logger.With(
+			slog.String(logging.KeyAppName, r.appName),
+			slog.String(logging.KeyUserID, userID),
+			slog.String(logging.KeySessionID, sessionID),
+		)
+		ctx = logging.ToContext(ctx, logger)
+		ctx = clock.NewContext(ctx, r.clock.Now)
+		ctx = telemetry.WithServiceName(ctx, cfg.TracerServiceName)
+		ctx = telemetry.WithLabels(ctx, cfg.Labels)
+		if cfg.DisableTelemetry {
+			ctx = telemetry.WithDisabled(ctx)
+		}
+
+		if err := cfg.GenerationOverrides.Validate(); err != nil {
+			err = fmt.Errorf("%w: %w", ErrInvalidGenerationOverrides, err)
+			logger.WarnContext(ctx, "run rejected", slog.Any("error", err))
+			yield(nil, err)
+			return
+		}
+		var overrideModel model.LLM
+		if cfg.ModelOverride != "" {
+			m, ok := r.modelOverrides[cfg.ModelOverride]
+			if !ok {
+				err := fmt.Errorf("%w: %q", ErrModelNotAllowed, cfg.ModelOverride)
+				logger.WarnContext(ctx, "run rejected", slog.Any("error", err))
+				yield(nil, err)
+				return
+			}
+			overrideModel = m
+		}
+
+		if r.sessionLocker != nil {
+			unlock, err := r.sessionLocker.Lock(ctx, r.appName, userID, sessionID)
+			if err != nil {
+				logger.WarnContext(ctx, "run not admitted by the session locker", slog.Any("error", err))
+				yield(nil, err)
+				return
+			}
+			defer unlock()
+		}
+
+		if r.limiter != nil {
+			spans := telemetry.StartTrace(ctx, "queue_run")
+			start := r.clock.Now()
+			release, err := r.limiter.Acquire(ctx, r.appName, userID)
+			telemetry.TraceRunQueued(spans, r.clock.Now().Sub(start), err)
+			if err != nil {
+				logger.WarnContext(ctx, "run not admitted by the concurrency limiter", slog.Any("error", err))
+				yield(nil, err)
+				return
+			}
+			defer release()
+		}
+
+		start := r.clock.Now()
+		timeout := r.timeout(cfg)
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeoutCause(ctx, timeout, ErrRunTimeout)
+			defer cancel()
+		}
+		timedOut := func() bool {
+			return errors.Is(context.Cause(ctx), ErrRunTimeout)
+		}
+		spans := telemetry.StartTrace(ctx, "invocation")
+		defer func() {
+			telemetry.TraceRun(spans, r.clock.Now().Sub(start), timedOut())
+		}()
+		ctx = telemetry.ContextWithSpan(ctx, spans)
+
 		resp, err := r.sessionService.Get(ctx, &session.GetRequest{
 			AppName:   r.appName,
 			UserID:    userID,
@@ -106,83 +300,224 @@ func (r *Runner) Run(ctx context.Context, userID, sessionID string, msg *genai.C
 			return
 		}
 
+		// Buffering services flush the events of the run once it finishes.
+		flusher, _ := r.sessionService.(session.Flusher)
+		ctx = session.ContextWithIDGenerator(ctx, session.IDGeneratorOf(r.sessionService), r.appName, userID, sessionID)
 		session := resp.Session
 
-		agentToRun, err := r.findAgentToRun(session)
+		agentToRun, err := findAgentToRun(ctx, session)
 		if err != nil {
 			yield(nil, err)
 			return
 		}
 
+		if r.agentPools != nil {
+			poolSpans := telemetry.StartTrace(ctx, "queue_agent_run")
+			start := r.clock.Now()
+			release, queued, err := r.agentPools.Acquire(ctx, r.appName, agentToRun.Name())
+			telemetry.TraceAgentRunQueued(poolSpans, queued, r.clock.Now().Sub(start), err)
+			if err != nil {
+				logger.WarnContext(ctx, "run not admitted by the agent pool", slog.String(logging.KeyAgentName, agentToRun.Name()), slog.Any("error", err))
+				yield(nil, err)
+				return
+			}
+			defer release()
+		}
+
 		ctx = parentmap.ToContext(ctx, r.parents)
+		if cfg.RetryBudget != 0 {
+			ctx = retry.ContextWithBudget(ctx, retry.NewBudget(cfg.RetryBudget))
+		}
+		ctx = toolinternal.ContextWithLimiter(ctx, toolinternal.NewLimiter(cmp.Or(cfg.MaxConcurrentTools, agent.DefaultMaxConcurrentTools)))
 		ctx = runconfig.ToContext(ctx, &runconfig.RunConfig{
 			StreamingMode: runconfig.StreamingMode(cfg.StreamingMode),
-		})
 
-		var artifacts agent.Artifacts
-		if r.artifactService != nil {
-			artifacts = &artifactinternal.Artifacts{
-				Service:   r.artifactService,
-				SessionID: session.ID(),
-				AppName:   session.AppName(),
-				UserID:    session.UserID(),
-			}
-		}
+			Model:          overrideModel,
+			ModelAgent:     agentToRun.Name(),
+			ModelSubAgents: cfg.ModelOverrideSubAgents,
+		})
 
-		var memoryImpl agent.Memory = nil
-		if r.memoryService != nil {
-			memoryImpl = &imemory.Memory{
-				Service:   r.memoryService,
-				SessionID: session.ID(),
-				UserID:    session.UserID(),
-				AppName:   session.AppName(),
-			}
+		artifacts, memoryImpl := r.sessionServices(session)
+		mutableSession := sessioninternal.NewMutableSession(r.sessionService, session)
+		if ev := llminternal.InterruptedFunctionCalls(session); ev != nil {
+			// The agents of the run see the function calls of a marker
+			// stored by the persist policy as the original event.
+			mutableSession.ReplaceLocalEvent(ev)
 		}
-
 		ctx := icontext.NewInvocationContext(ctx, icontext.InvocationContextParams{
 			Artifacts:   artifacts,
 			Memory:      memoryImpl,
-			Session:     sessioninternal.NewMutableSession(r.sessionService, session),
+			Session:     mutableSession,
 			Agent:       agentToRun,
 			UserContent: msg,
 			RunConfig:   &cfg,
 		})
 
-		if err := r.appendMessageToSession(ctx, session, msg, cfg.SaveInputBlobsAsArtifacts); err != nil {
+		if err := r.appendMessageToSession(ctx, session, msg, cfg); err != nil {
 			yield(nil, err)
 			return
 		}
 
+		logger = logger.With(slog.String(logging.KeyInvocationID, ctx.InvocationID()))
+		logger.DebugContext(ctx, "run started", slog.String(logging.KeyAgentName, agentToRun.Name()))
+		defer logger.DebugContext(ctx, "run finished")
+
+		if flusher != nil {
+			defer func() {
+				// The events are flushed even if the run timed out.
+				if err := flusher.FlushSession(context.WithoutCancel(ctx), session.AppName(), session.UserID(), session.ID()); err != nil {
+					logger.WarnContext(ctx, "failed to flush session events", slog.Any("error", err))
+				}
+			}()
+		}
+
+		interims := &interimCommits{every: cfg.InterimCommitEvery}
 		for event, err := range agentToRun.Run(ctx) {
+			if timedOut() {
+				// The timeout event replaces the events and errors of
+				// the cancelled calls.
+				break
+			}
 			if err != nil {
+				logger.WarnContext(ctx, "agent run returned an error", slog.Any("error", err))
 				if !yield(event, err) {
 					return
 				}
 				continue
 			}
+			addLabels(event, cfg.Labels)
+			addKind(event)
 
-			// only commit non-partial event to a session service
+			// only commit non-partial event to a session service, and
+			// interim commits of the partial ones
+			interim := interims.add(ctx, event)
 			if !event.LLMResponse.Partial {
-				if err := r.sessionService.AppendEvent(ctx, session, event); err != nil {
+				if err := r.appendEvent(ctx, mutableSession, session, event); err != nil {
 					yield(nil, fmt.Errorf("failed to add event to session: %w", err))
 					return
 				}
+			} else if interim != nil {
+				addLabels(interim, cfg.Labels)
+				if err := r.appendEvent(ctx, mutableSession, session, interim); err != nil {
+					logger.WarnContext(ctx, "failed to commit interim event", slog.Any("error", err))
+				}
 			}
 
 			if !yield(event, nil) {
 				return
 			}
 		}
+		if timedOut() {
+			logger.WarnContext(ctx, "run timed out", slog.Duration("timeout", timeout))
+			event := newTimeoutEvent(ctx, ctx.InvocationID(), agentToRun.Name(), timeout)
+			addLabels(event, cfg.Labels)
+			if err := r.appendEvent(context.WithoutCancel(ctx), mutableSession, session, event); err != nil {
+				yield(nil, fmt.Errorf("failed to add event to session: %w", err))
+				return
+			}
+			yield(event, nil)
+		}
 	}
 }
 
-func (r *Runner) appendMessageToSession(ctx agent.InvocationContext, storedSession session.Session, msg *genai.Content, saveInputBlobsAsArtifacts bool) error {
+// newTimeoutEvent returns the event ending a run that exceeded its timeout.
+func newTimeoutEvent(ctx context.Context, invocationID, author string, timeout time.Duration) *session.Event {
+	event := session.NewEventContext(ctx, invocationID)
+	event.Author = author
+	event.Kind = session.EventKindError
+	event.ErrorCode = RunTimeoutErrorCode
+	event.ErrorMessage = fmt.Sprintf("run exceeded its timeout of %v", timeout)
+	event.TurnComplete = true
+	return event
+}
+
+// timeout returns the timeout of a run with the given config, or 0 if it
+// has none.
+func (r *Runner) timeout(cfg agent.RunConfig) time.Duration {
+	switch {
+	case cfg.Timeout > 0:
+		return cfg.Timeout
+	case cfg.Timeout < 0:
+		return 0
+	}
+	return r.runTimeout
+}
+
+// appendEvent stores the event in the session service, unless the persist
+// policy of its author skips it. Skipped events remain visible to the
+// invocation. Skipped events with actions, function calls or function
+// responses are stored without their content, so that their changes are
+// kept and interrupted runs stay detectable by Resume: the function calls
+// are kept in the metadata of the stored event, see
+// llminternal.FunctionCallsMarker.
+func (r *Runner) appendEvent(ctx context.Context, ms *sessioninternal.MutableSession, storedSession session.Session, event *session.Event) error {
+	if r.persisted(event) {
+		return r.sessionService.AppendEvent(ctx, storedSession, event)
+	}
+	if !hasActions(event) && !hasFunctionParts(event) {
+		ms.AddLocalEvent(event)
+		return nil
+	}
+	if err := r.sessionService.AppendEvent(ctx, storedSession, llminternal.FunctionCallsMarker(event)); err != nil {
+		return err
+	}
+	ms.ReplaceLocalEvent(event)
+	return nil
+}
+
+// Stored reports whether the run stores the event in the session service,
+// in full or, for events its author does not persist, as a marker of its
+// actions and function calls. Partial events are never stored.
+func (r *Runner) Stored(event *session.Event) bool {
+	return !event.Partial && (r.persisted(event) || hasActions(event) || hasFunctionParts(event))
+}
+
+// persisted reports whether the persist policy of the author of the event
+// stores it.
+func (r *Runner) persisted(event *session.Event) bool {
+	author, ok := findAgent(r.rootAgent, event.Author).(agentinternal.Agent)
+	if !ok {
+		return true
+	}
+	persist := agentinternal.Reveal(author).Persist
+	return persist == nil || persist(event)
+}
+
+func hasFunctionParts(event *session.Event) bool {
+	return len(utils.FunctionCalls(event.Content)) > 0 || len(utils.FunctionResponses(event.Content)) > 0
+}
+
+func hasActions(event *session.Event) bool {
+	a := event.Actions
+	return len(a.StateDelta) > 0 || len(a.ArtifactDelta) > 0 || a.TransferToAgent != "" || a.Escalate
+}
+
+// addLabels adds the labels of the run to the event, keeping the labels
+// already set on the event.
+func addLabels(event *session.Event, labels map[string]string) {
+	if event == nil || len(labels) == 0 {
+		return
+	}
+	merged := maps.Clone(labels)
+	maps.Copy(merged, event.Labels)
+	event.Labels = merged
+}
+
+// addKind sets the kind of an event created without one, e.g. by a custom
+// agent, to the kind derived from its content.
+func addKind(event *session.Event) {
+	if event != nil && event.Kind == "" {
+		event.Kind = session.DeriveKind(event)
+	}
+}
+
+func (r *Runner) appendMessageToSession(ctx agent.InvocationContext, storedSession session.Session, msg *genai.Content, cfg agent.RunConfig) error {
 	if msg == nil {
 		return nil
 	}
 
 	artifactsService := ctx.Artifacts()
-	if artifactsService != nil && saveInputBlobsAsArtifacts {
+	if artifactsService != nil && cfg.SaveInputBlobsAsArtifacts {
 		for i, part := range msg.Parts {
 			if part.InlineData == nil {
 				continue
@@ -198,12 +533,14 @@ func (r *Runner) appendMessageToSession(ctx agent.InvocationContext, storedSessi
 		}
 	}
 
-	event := session.NewEvent(ctx.InvocationID())
+	event := session.NewEventContext(ctx, ctx.InvocationID())
 
 	event.Author = "user"
+	event.Kind = session.EventKindUser
 	event.LLMResponse = model.LLMResponse{
 		Content: msg,
 	}
+	addLabels(event, cfg.Labels)
 
 	if err := r.sessionService.AppendEvent(ctx, storedSession, event); err != nil {
 		return fmt.Errorf("failed to append event to sessionService: %w", err)
@@ -213,7 +550,7 @@ func (r *Runner) appendMessageToSession(ctx agent.InvocationContext, storedSessi
 
 // findAgentToRun returns the agent that should handle the next request based on
 // session history.
-func (r *Runner) findAgentToRun(session session.Session) (agent.Agent, error) {
+func (r *Runner) findAgentToRun(ctx context.Context, session session.Session) (agent.Agent, error) {
 	events := session.Events()
 	for i := events.Len() - 1; i >= 0; i-- {
 		event := events.At(i)
@@ -227,7 +564,7 @@ func (r *Runner) findAgentToRun(session session.Session) (agent.Agent, error) {
 		subAgent := findAgent(r.rootAgent, event.Author)
 		// Agent not found, continue looking for the other event.
 		if subAgent == nil {
-			log.Printf("Event from an unknown agent: %s, event id: %s", event.Author, event.ID)
+			logging.FromContext(ctx).WarnContext(ctx, "event from an unknown agent", slog.String("author", event.Author), slog.String("event_id", event.ID))
 			continue
 		}
 
@@ -240,6 +577,44 @@ func (r *Runner) findAgentToRun(session session.Session) (agent.Agent, error) {
 	return r.rootAgent, nil
 }
 
+// findAgentToResume returns the agent whose function calls were interrupted.
+func (r *Runner) findAgentToResume(ctx context.Context, session session.Session) (agent.Agent, error) {
+	ev := llminternal.InterruptedFunctionCalls(session)
+	if ev == nil {
+		return nil, ErrNothingToResume
+	}
+	agentToRun := findAgent(r.rootAgent, ev.Author)
+	if agentToRun == nil {
+		return nil, fmt.Errorf("failed to find agent %q of the interrupted run", ev.Author)
+	}
+	return agentToRun, nil
+}
+
+// sessionServices returns the artifacts and the memory of the session, or
+// nil for the services the runner does not have.
+func (r *Runner) sessionServices(s session.Session) (agent.Artifacts, agent.Memory) {
+	var artifacts agent.Artifacts
+	if r.artifactService != nil {
+		artifacts = &artifactinternal.Artifacts{
+			Service:   r.artifactService,
+			SessionID: s.ID(),
+			AppName:   s.AppName(),
+			UserID:    s.UserID(),
+		}
+	}
+
+	var memoryImpl agent.Memory = nil
+	if r.memoryService != nil {
+		memoryImpl = &imemory.Memory{
+			Service:   r.memoryService,
+			SessionID: s.ID(),
+			UserID:    s.UserID(),
+			AppName:   s.AppName(),
+		}
+	}
+	return artifacts, memoryImpl
+}
+
 // checks if the agent and its parent chain allow transfer up the tree.
 func (r *Runner) isTransferableAcrossAgentTree(agentToRun agent.Agent) bool {
 	for curAgent := agentToRun; curAgent != nil; curAgent = r.parents[curAgent.Name()] {
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"iter"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"google.golang.org/genai"
@@ -87,7 +88,7 @@ func TestRunner_findAgentToRun(t *testing.T) {
 			r := &Runner{
 				rootAgent: tt.rootAgent,
 			}
-			gotAgent, err := r.findAgentToRun(tt.session)
+			gotAgent, err := r.findAgentToRun(t.Context(), tt.session)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Runner.findAgentToRun() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -370,3 +371,55 @@ func createSession(t *testing.T, ctx context.Context, sessionID, appName, userID
 
 	return resp.Session
 }
+
+type shardIDGenerator struct{ n atomic.Int64 }
+
+func (g *shardIDGenerator) NewSessionID(context.Context, string, string) string {
+	return fmt.Sprintf("shard1-s%d", g.n.Add(1))
+}
+
+func (g *shardIDGenerator) NewEventID(_ context.Context, _, _, sessionID string) string {
+	return fmt.Sprintf("shard1-%s-e%d", sessionID, g.n.Add(1))
+}
+
+func TestRunner_IDGenerator(t *testing.T) {
+	ctx := t.Context()
+	sessionService := session.NewInMemoryService(session.InMemoryConfig{IDGenerator: &shardIDGenerator{}})
+	testAgent := must(agent.New(agent.Config{
+		Name: "test_agent",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				ev := session.NewEventContext(ctx, ctx.InvocationID())
+				ev.Content = genai.NewContentFromText("hello", genai.RoleModel)
+				yield(ev, nil)
+			}
+		},
+	}))
+	r, err := New(Config{AppName: "testApp", Agent: testAgent, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "testApp", UserID: "testUser"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionID := created.Session.ID()
+
+	for _, err := range r.Run(ctx, "testUser", sessionID, genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	resp, err := sessionService.Get(ctx, &session.GetRequest{AppName: "testApp", UserID: "testUser", SessionID: sessionID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Session.Events().Len(); got != 2 {
+		t.Fatalf("got %d events, want the user message and the response", got)
+	}
+	for ev := range resp.Session.Events().All() {
+		if want := "shard1-" + sessionID + "-e"; !strings.HasPrefix(ev.ID, want) {
+			t.Errorf("event ID = %q, want prefix %q", ev.ID, want)
+		}
+	}
+}
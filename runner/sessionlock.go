@@ -0,0 +1,104 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrSessionBusy is returned by a [SessionLocker] rejecting a run because
+// another run of the session is in progress.
+var ErrSessionBusy = errors.New("session is busy with another run")
+
+// SessionLocker serializes the runs of a session, so that the events of
+// concurrent runs do not interleave in its history. Runs of different
+// sessions are not serialized. Implement it on top of a distributed lock,
+// e.g. in Redis or SQL, to serialize runs across servers.
+type SessionLocker interface {
+	// Lock blocks until a run of the session may start or returns an error,
+	// e.g. [ErrSessionBusy] or the error of ctx. If Lock succeeds, unlock
+	// must be called once the run finishes.
+	Lock(ctx context.Context, appName, userID, sessionID string) (unlock func(), err error)
+}
+
+// SessionLockConfig configures the locker returned by [NewSessionLocker].
+type SessionLockConfig struct {
+	// Queue makes runs of a busy session wait for the running one to
+	// finish. Otherwise they are rejected with [ErrSessionBusy].
+	Queue bool
+}
+
+// NewSessionLocker returns an in-process [SessionLocker].
+func NewSessionLocker(cfg SessionLockConfig) SessionLocker {
+	return &sessionLocker{cfg: cfg, locks: make(map[sessionKey]*slot)}
+}
+
+type sessionLocker struct {
+	cfg SessionLockConfig
+
+	mu    sync.Mutex
+	locks map[sessionKey]*slot
+}
+
+type sessionKey struct {
+	appName, userID, sessionID string
+}
+
+func (l *sessionLocker) Lock(ctx context.Context, appName, userID, sessionID string) (func(), error) {
+	key := sessionKey{appName, userID, sessionID}
+	l.mu.Lock()
+	s, ok := l.locks[key]
+	if !ok {
+		s = &slot{sem: make(chan struct{}, 1)}
+		l.locks[key] = s
+	}
+	s.refs++
+	l.mu.Unlock()
+
+	if l.cfg.Queue {
+		select {
+		case s.sem <- struct{}{}:
+		case <-ctx.Done():
+			l.unref(key, s)
+			return nil, ctx.Err()
+		}
+	} else {
+		select {
+		case s.sem <- struct{}{}:
+		default:
+			l.unref(key, s)
+			return nil, ErrSessionBusy
+		}
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-s.sem
+			l.unref(key, s)
+		})
+	}, nil
+}
+
+func (l *sessionLocker) unref(key sessionKey, s *slot) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s.refs--
+	if s.refs == 0 {
+		delete(l.locks, key)
+	}
+}
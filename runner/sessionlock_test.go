@@ -0,0 +1,226 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/session"
+)
+
+func TestSessionLocker(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		cfg     SessionLockConfig
+		wantErr error
+	}{
+		{name: "reject", wantErr: ErrSessionBusy},
+		{name: "queue until the context is done", cfg: SessionLockConfig{Queue: true}, wantErr: context.DeadlineExceeded},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			l := NewSessionLocker(tc.cfg)
+			unlock, err := l.Lock(t.Context(), "app", "user", "s1")
+			if err != nil {
+				t.Fatalf("Lock() error = %v", err)
+			}
+
+			// Other sessions are not locked.
+			unlockOther, err := l.Lock(t.Context(), "app", "user", "s2")
+			if err != nil {
+				t.Fatalf("Lock() of another session error = %v", err)
+			}
+			unlockOther()
+
+			ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+			defer cancel()
+			if _, err := l.Lock(ctx, "app", "user", "s1"); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Lock() of a busy session error = %v, want %v", err, tc.wantErr)
+			}
+
+			unlock()
+			// Unlocking twice must not unlock a later run.
+			unlock()
+			unlock, err = l.Lock(t.Context(), "app", "user", "s1")
+			if err != nil {
+				t.Fatalf("Lock() after unlock error = %v", err)
+			}
+			if _, err := l.Lock(ctx, "app", "user", "s1"); err == nil {
+				t.Fatal("Lock() of a busy session succeeded after a double unlock")
+			}
+			unlock()
+
+			if n := len(l.(*sessionLocker).locks); n != 0 {
+				t.Errorf("locker keeps %d locks after all runs finished, want 0", n)
+			}
+		})
+	}
+}
+
+// concurrentAgent yields two events per run, calling wait in between, and
+// records the maximum number of its concurrent runs.
+func concurrentAgent(t *testing.T, wait func()) (agent.Agent, *atomic.Int32) {
+	var active, maxActive atomic.Int32
+	a, err := agent.New(agent.Config{
+		Name: "concurrent_agent",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				n := active.Add(1)
+				defer active.Add(-1)
+				for {
+					m := maxActive.Load()
+					if n <= m || maxActive.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				for i, text := range []string{"first", "second"} {
+					if i > 0 {
+						wait()
+					}
+					ev := session.NewEvent(ctx.InvocationID())
+					ev.Author = "concurrent_agent"
+					ev.Content = genai.NewContentFromText(text, genai.RoleModel)
+					if !yield(ev, nil) {
+						return
+					}
+				}
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a, &maxActive
+}
+
+func TestRunner_SessionLocker(t *testing.T) {
+	t.Run("runs of a session are serialized", func(t *testing.T) {
+		a, maxActive := concurrentAgent(t, func() { time.Sleep(20 * time.Millisecond) })
+		sessionService := session.InMemoryService()
+		created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "test", UserID: "user"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := New(Config{AppName: "test", Agent: a, SessionService: sessionService, SessionLocker: NewSessionLocker(SessionLockConfig{Queue: true})})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		const runs = 4
+		var wg sync.WaitGroup
+		for range runs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for _, err := range r.Run(t.Context(), "user", created.Session.ID(), genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+					if err != nil {
+						t.Errorf("Run() error = %v", err)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got := maxActive.Load(); got != 1 {
+			t.Errorf("got %d concurrent runs of the session, want 1", got)
+		}
+		got, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "test", UserID: "user", SessionID: created.Session.ID()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The events of each run are contiguous.
+		events := got.Session.Events()
+		if n := events.Len(); n != 3*runs {
+			t.Fatalf("got %d stored events, want %d", n, 3*runs)
+		}
+		for i := 0; i < events.Len(); i += 3 {
+			id := events.At(i).InvocationID
+			for j := i; j < i+3; j++ {
+				if events.At(j).InvocationID != id {
+					t.Fatalf("event %d belongs to invocation %s, want %s: runs interleaved", j, events.At(j).InvocationID, id)
+				}
+			}
+		}
+	})
+
+	t.Run("runs of different sessions are parallel", func(t *testing.T) {
+		// Each run waits for the other to start.
+		var started sync.WaitGroup
+		started.Add(2)
+		a, maxActive := concurrentAgent(t, func() {
+			started.Done()
+			started.Wait()
+		})
+		sessionService := session.InMemoryService()
+		r, err := New(Config{AppName: "test", Agent: a, SessionService: sessionService, SessionLocker: NewSessionLocker(SessionLockConfig{})})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var wg sync.WaitGroup
+		for range 2 {
+			created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "test", UserID: "user"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for _, err := range r.Run(t.Context(), "user", created.Session.ID(), genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+					if err != nil {
+						t.Errorf("Run() error = %v", err)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		if got := maxActive.Load(); got != 2 {
+			t.Errorf("got %d concurrent runs, want 2", got)
+		}
+	})
+
+	t.Run("busy session is rejected", func(t *testing.T) {
+		release := make(chan struct{})
+		a, _ := concurrentAgent(t, func() { <-release })
+		sessionService := session.InMemoryService()
+		created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "test", UserID: "user"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := New(Config{AppName: "test", Agent: a, SessionService: sessionService, SessionLocker: NewSessionLocker(SessionLockConfig{})})
+		if err != nil {
+			t.Fatal(err)
+		}
+		next, stop := iter.Pull2(r.Run(t.Context(), "user", created.Session.ID(), genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}))
+		defer stop()
+		if _, err, _ := next(); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+
+		for _, err := range r.Run(t.Context(), "user", created.Session.ID(), genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+			if !errors.Is(err, ErrSessionBusy) {
+				t.Errorf("Run() of a busy session error = %v, want %v", err, ErrSessionBusy)
+			}
+		}
+		close(release)
+	})
+}
@@ -0,0 +1,170 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"iter"
+	"testing"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/clock"
+	"google.golang.org/adk/session"
+)
+
+func TestRunner_RunTimeout(t *testing.T) {
+	t.Parallel()
+
+	// slow_agent yields a reply after the given delay, or the error of the
+	// context if it is cancelled first.
+	a, err := agent.New(agent.Config{
+		Name: "slow_agent",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				select {
+				case <-time.After(100 * time.Millisecond):
+					ev := session.NewEvent(ctx.InvocationID())
+					ev.Author = "slow_agent"
+					ev.Content = genai.NewContentFromText("done", genai.RoleModel)
+					yield(ev, nil)
+				case <-ctx.Done():
+					yield(nil, ctx.Err())
+				}
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name          string
+		runTimeout    time.Duration
+		cfg           agent.RunConfig
+		wantErrorCode string
+	}{
+		{name: "no timeout"},
+		{name: "runner timeout", runTimeout: 10 * time.Millisecond, wantErrorCode: RunTimeoutErrorCode},
+		{name: "run overrides timeout", runTimeout: time.Minute, cfg: agent.RunConfig{Timeout: 10 * time.Millisecond}, wantErrorCode: RunTimeoutErrorCode},
+		{name: "run removes timeout", runTimeout: 10 * time.Millisecond, cfg: agent.RunConfig{Timeout: -1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			sessionService := session.InMemoryService()
+			resp, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "test", UserID: "user"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := New(Config{AppName: "test", Agent: a, SessionService: sessionService, RunTimeout: tc.runTimeout})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var events []*session.Event
+			for ev, err := range r.Run(t.Context(), "user", resp.Session.ID(), genai.NewContentFromText("hi", genai.RoleUser), tc.cfg) {
+				if err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+				events = append(events, ev)
+			}
+			if len(events) != 1 {
+				t.Fatalf("Run() yielded %d events, want 1", len(events))
+			}
+			if got := events[0].ErrorCode; got != tc.wantErrorCode {
+				t.Errorf("event error code = %q, want %q", got, tc.wantErrorCode)
+			}
+
+			got, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "test", UserID: "user", SessionID: resp.Session.ID()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := got.Session.Events().Len(); n != 2 {
+				t.Fatalf("got %d stored events, want 2", n)
+			}
+			if got := got.Session.Events().At(1).ErrorCode; got != tc.wantErrorCode {
+				t.Errorf("stored event error code = %q, want %q", got, tc.wantErrorCode)
+			}
+		})
+	}
+}
+
+func TestRunner_Clock(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name   string
+		offset time.Duration
+	}{
+		{name: "clock ahead", offset: time.Hour},
+		{name: "clock behind", offset: -time.Hour},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := clock.NewFake(time.Now().Add(tc.offset))
+			var gotNow, gotDeadline time.Time
+			var gotErr error
+			a, err := agent.New(agent.Config{
+				Name: "clock_agent",
+				Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+					return func(yield func(*session.Event, error) bool) {
+						gotNow = clock.Now(ctx)
+						gotDeadline, _ = ctx.Deadline()
+						gotErr = ctx.Err()
+					}
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sessionService := session.InMemoryService()
+			resp, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "test", UserID: "user"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := New(Config{AppName: "test", Agent: a, SessionService: sessionService, RunTimeout: time.Minute, Clock: fake})
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := time.Now()
+			for _, err := range r.Run(t.Context(), "user", resp.Session.ID(), genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+				if err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+			}
+			after := time.Now()
+
+			if want := fake.Now(); !gotNow.Equal(want) {
+				t.Errorf("time of the run = %v, want %v", gotNow, want)
+			}
+			if gotErr != nil {
+				t.Errorf("context error of the run = %v, want nil", gotErr)
+			}
+			// The deadline follows the system clock, not the fake one.
+			if gotDeadline.Before(before.Add(time.Minute)) || gotDeadline.After(after.Add(time.Minute)) {
+				t.Errorf("deadline of the run = %v, want between %v and %v", gotDeadline, before.Add(time.Minute), after.Add(time.Minute))
+			}
+			got, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "test", UserID: "user", SessionID: resp.Session.ID()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := got.Session.Events().At(0).Timestamp, fake.Now(); !got.Equal(want) {
+				t.Errorf("timestamp of the user event = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package safety defines the integration point for content-safety
+// classifiers that check the final responses of agents before they are
+// returned.
+package safety
+
+import (
+	"context"
+
+	"google.golang.org/genai"
+)
+
+// DefaultFallbackMessage replaces the content of blocked responses if no
+// other fallback message is configured.
+const DefaultFallbackMessage = "I'm sorry, but I can't provide that response."
+
+// Classifier scores the content of a final response.
+type Classifier interface {
+	// Classify returns the verdict on the content. An error fails the
+	// agent run, so that unchecked content is never returned.
+	Classify(ctx context.Context, content *genai.Content) (*Result, error)
+}
+
+// Result is the verdict of a [Classifier].
+type Result struct {
+	// Scores maps safety categories to the score of the content in that
+	// category, e.g. "harassment" to 0.02. They are attached to the event
+	// and to the span of the model call.
+	Scores map[string]float64
+	// Blocked reports that the content must not be returned. Its content is
+	// replaced with a fallback message.
+	Blocked bool
+}
+
+// Noop is a [Classifier] that lets all content through without scores.
+type Noop struct{}
+
+// Classify implements [Classifier].
+func (Noop) Classify(context.Context, *genai.Content) (*Result, error) {
+	return &Result{}, nil
+}
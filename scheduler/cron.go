@@ -0,0 +1,153 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxCronSearch bounds the search of the next time of a cron expression, so
+// that expressions that never match, e.g. "0 0 30 2 *", end the search.
+const maxCronSearch = 5 * 366 * 24 * time.Hour
+
+// Cron is a parsed cron expression.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny report whether the day of month and the day of week
+	// start with "*", e.g. "*" or "*/2". Unless either does, a day matching
+	// either one matches, as in crontab.
+	domAny, dowAny bool
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a standard five-field cron expression: minute, hour, day
+// of month, month and day of week (0 or 7 is Sunday). Each field is "*", a
+// value, a range "a-b" or a comma-separated list of them, optionally with a
+// step, e.g. "*/15" or "9-17/2". The macros @yearly, @annually, @monthly,
+// @weekly, @daily, @midnight and @hourly are also accepted.
+func ParseCron(expr string) (*Cron, error) {
+	if macro, ok := cronMacros[strings.TrimSpace(expr)]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, got %d", expr, len(fields))
+	}
+	var c Cron
+	var err error
+	for i, f := range []struct {
+		name     string
+		min, max int
+		bits     *uint64
+	}{
+		{"minute", 0, 59, &c.minute},
+		{"hour", 0, 23, &c.hour},
+		{"day of month", 1, 31, &c.dom},
+		{"month", 1, 12, &c.month},
+		{"day of week", 0, 7, &c.dow},
+	} {
+		if *f.bits, err = parseCronField(fields[i], f.min, f.max); err != nil {
+			return nil, fmt.Errorf("invalid %s in cron expression %q: %w", f.name, expr, err)
+		}
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny = strings.HasPrefix(fields[2], "*")
+	c.dowAny = strings.HasPrefix(fields[4], "*")
+	return &c, nil
+}
+
+// parseCronField returns the set of values of a cron field as a bitset.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for part := range strings.SplitSeq(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepStr)
+			}
+		}
+		lo, hi := min, max
+		if rng != "*" {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(loStr); err != nil {
+				return 0, fmt.Errorf("invalid value %q", loStr)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(hiStr); err != nil {
+					return 0, fmt.Errorf("invalid value %q", hiStr)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is out of range [%d, %d]", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first time after t matching the expression, in the
+// location of t, or the zero time if there is none in the next five years.
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	end := t.Add(maxCronSearch)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+	for t.Before(end) {
+		switch {
+		case c.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<int(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,311 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/clock"
+	"google.golang.org/adk/internal/logging"
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+)
+
+// OverlapPolicy tells what to do when a schedule is due while its previous
+// run is still in progress.
+type OverlapPolicy string
+
+const (
+	// OverlapSkip skips the due run and records it as skipped.
+	OverlapSkip OverlapPolicy = "skip"
+	// OverlapQueue starts the due run once the previous runs end.
+	OverlapQueue OverlapPolicy = "queue"
+)
+
+// Schedule is a recurring run of an agent, e.g. an hourly status check.
+// Each run happens in a fresh session of the user.
+type Schedule struct {
+	// ID identifies the schedule.
+	ID string
+	// AppName and UserID identify the app run and the user owning the
+	// sessions of the runs.
+	AppName string
+	UserID  string
+	// Cron is the cron expression of the schedule, evaluated in UTC. See
+	// ParseCron.
+	Cron string
+	// Message is sent to the agent as the user message of each run.
+	Message string
+	// Overlap tells what to do when a run is due while the previous one is
+	// still in progress. Defaults to OverlapSkip.
+	Overlap OverlapPolicy
+	// NextRunAt is the time the next run is due at.
+	NextRunAt time.Time
+}
+
+// Validate checks that the schedule can be run.
+func (s Schedule) Validate() error {
+	if s.ID == "" || s.AppName == "" || s.UserID == "" {
+		return errors.New("ID, AppName and UserID are required")
+	}
+	if s.Message == "" {
+		return errors.New("Message is required")
+	}
+	switch s.Overlap {
+	case "", OverlapSkip, OverlapQueue:
+	default:
+		return fmt.Errorf("unknown overlap policy %q", s.Overlap)
+	}
+	_, err := ParseCron(s.Cron)
+	return err
+}
+
+// RunStatus is the outcome of a run of a schedule.
+type RunStatus string
+
+const (
+	RunSucceeded RunStatus = "succeeded"
+	RunFailed    RunStatus = "failed"
+	// RunSkipped is a run skipped because the previous one was still in
+	// progress. See OverlapSkip.
+	RunSkipped RunStatus = "skipped"
+)
+
+// ScheduleRun records a run of a schedule.
+type ScheduleRun struct {
+	ScheduleID string
+	// ScheduledAt is the time the run was due at.
+	ScheduledAt time.Time
+	// StartedAt and EndedAt are zero for skipped runs.
+	StartedAt time.Time
+	EndedAt   time.Time
+	Status    RunStatus
+	// SessionID is the session created for the run.
+	SessionID string
+	// InvocationID is the invocation of the run, e.g. to find its traces.
+	InvocationID string
+	// Response is the text of the last response of the agent.
+	Response string
+	// Error is the error of a failed run.
+	Error string
+}
+
+// CronConfig is the configuration of a CronScheduler.
+type CronConfig struct {
+	// AppName is the name of the app whose schedules are run. It must be
+	// the app of Runner.
+	AppName string
+	// Store holds the schedules and records their runs.
+	Store ScheduleStore
+	// Runner runs the agent.
+	Runner *runner.Runner
+	// SessionService creates the session of each run. It must be the
+	// session service of Runner.
+	SessionService session.Service
+	// RunConfig is used for the runs of the schedules.
+	RunConfig agent.RunConfig
+	// PollInterval is the interval at which the store is polled for due
+	// schedules. Defaults to DefaultPollInterval.
+	PollInterval time.Duration
+}
+
+// CronScheduler runs the due schedules of a store. Runs happen in the
+// background, so that a long run does not delay the other schedules.
+type CronScheduler struct {
+	cfg   CronConfig
+	clock clock.Clock
+
+	mu sync.Mutex
+	// queued holds the due times of the queued runs of the schedules with
+	// a run in progress.
+	queued map[string][]time.Time
+	wg     sync.WaitGroup
+}
+
+// NewCron creates a CronScheduler. Call [CronScheduler.Run] to start running
+// schedules.
+func NewCron(cfg CronConfig) (*CronScheduler, error) {
+	if cfg.AppName == "" {
+		return nil, errors.New("AppName is required")
+	}
+	if cfg.Store == nil || cfg.Runner == nil || cfg.SessionService == nil {
+		return nil, errors.New("Store, Runner and SessionService are required")
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	if cfg.PollInterval < 0 {
+		return nil, errors.New("PollInterval must not be negative")
+	}
+	return &CronScheduler{cfg: cfg, clock: clock.Real(), queued: make(map[string][]time.Time)}, nil
+}
+
+// Run starts the due schedules every PollInterval until ctx is done, waits
+// for the runs in progress, which are cancelled with ctx, and returns the
+// error of ctx. Errors starting runs are logged.
+func (s *CronScheduler) Run(ctx context.Context) error {
+	defer s.Wait()
+	ticker := s.clock.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C():
+			if _, err := s.FireDue(ctx); err != nil {
+				logging.FromContext(ctx).WarnContext(ctx, "failed to fire schedules", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// FireDue claims the schedules due now and starts their runs in the
+// background, or queues or skips them according to their overlap policy.
+// It returns the number of schedules due. The runs use ctx.
+func (s *CronScheduler) FireDue(ctx context.Context) (int, error) {
+	due, err := s.cfg.Store.ClaimDue(ctx, s.cfg.AppName, s.clock.Now(), s.next)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim due schedules: %w", err)
+	}
+	for _, schedule := range due {
+		s.start(ctx, schedule)
+	}
+	return len(due), nil
+}
+
+// Wait waits for the runs started by FireDue, including the queued ones,
+// to end.
+func (s *CronScheduler) Wait() {
+	s.wg.Wait()
+}
+
+// next returns the time of the run of the schedule following now. Runs
+// missed while no scheduler was running are not caught up.
+func (s *CronScheduler) next(schedule Schedule) time.Time {
+	c, err := ParseCron(schedule.Cron)
+	if err != nil {
+		return time.Time{}
+	}
+	return c.Next(s.clock.Now().UTC())
+}
+
+func (s *CronScheduler) start(ctx context.Context, schedule Schedule) {
+	s.mu.Lock()
+	if queued, running := s.queued[schedule.ID]; running {
+		if schedule.Overlap == OverlapQueue {
+			s.queued[schedule.ID] = append(queued, schedule.NextRunAt)
+			s.mu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+		s.record(ctx, ScheduleRun{ScheduleID: schedule.ID, ScheduledAt: schedule.NextRunAt, Status: RunSkipped})
+		return
+	}
+	s.queued[schedule.ID] = nil
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		scheduledAt := schedule.NextRunAt
+		for {
+			s.record(ctx, s.run(ctx, schedule, scheduledAt))
+
+			s.mu.Lock()
+			queued := s.queued[schedule.ID]
+			if len(queued) == 0 {
+				delete(s.queued, schedule.ID)
+				s.mu.Unlock()
+				return
+			}
+			scheduledAt = queued[0]
+			s.queued[schedule.ID] = queued[1:]
+			s.mu.Unlock()
+		}
+	}()
+}
+
+// run runs the agent in a fresh session for the schedule.
+func (s *CronScheduler) run(ctx context.Context, schedule Schedule, scheduledAt time.Time) (rec ScheduleRun) {
+	rec = ScheduleRun{ScheduleID: schedule.ID, ScheduledAt: scheduledAt, StartedAt: s.clock.Now()}
+	spans := telemetry.StartTrace(ctx, "fire_schedule")
+	telemetry.AddTaskFiredEvent(spans, schedule.ID, rec.StartedAt.Sub(scheduledAt))
+	var err error
+	defer func() {
+		telemetry.TraceTaskRun(spans, err)
+		rec.EndedAt = s.clock.Now()
+		rec.Status = RunSucceeded
+		if err != nil {
+			rec.Status = RunFailed
+			rec.Error = err.Error()
+		}
+	}()
+
+	ctx = telemetry.ContextWithSpan(ctx, spans)
+	created, err := s.cfg.SessionService.Create(ctx, &session.CreateRequest{AppName: schedule.AppName, UserID: schedule.UserID})
+	if err != nil {
+		err = fmt.Errorf("failed to create session: %w", err)
+		return rec
+	}
+	rec.SessionID = created.Session.ID()
+
+	msg := genai.NewContentFromText(schedule.Message, genai.RoleUser)
+	for event, runErr := range s.cfg.Runner.Run(ctx, schedule.UserID, rec.SessionID, msg, s.cfg.RunConfig) {
+		if runErr != nil {
+			err = runErr
+			return rec
+		}
+		if rec.InvocationID == "" {
+			rec.InvocationID = event.InvocationID
+		}
+		if text := responseText(event); text != "" {
+			rec.Response = text
+		}
+	}
+	return rec
+}
+
+// record stores the record of a run. Errors are logged.
+func (s *CronScheduler) record(ctx context.Context, rec ScheduleRun) {
+	if err := s.cfg.Store.AddRun(context.WithoutCancel(ctx), rec); err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "failed to record schedule run",
+			slog.String("schedule_id", rec.ScheduleID),
+			slog.String(logging.KeyInvocationID, rec.InvocationID),
+			slog.Any("error", err))
+	}
+}
+
+// responseText returns the text of a final, non-thought response event.
+func responseText(event *session.Event) string {
+	if event.Partial || event.Content == nil {
+		return ""
+	}
+	var text string
+	for _, part := range event.Content.Parts {
+		if part != nil && !part.Thought {
+			text += part.Text
+		}
+	}
+	return text
+}
@@ -0,0 +1,151 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/clock"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+)
+
+func TestCronSchedulerOverlap(t *testing.T) {
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	// The agent acknowledges the message it receives once released.
+	a, err := agent.New(agent.Config{
+		Name: "ops",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				started <- struct{}{}
+				<-release
+				ev := session.NewEvent(ctx.InvocationID())
+				ev.Author = "ops"
+				ev.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText("ack: "+ctx.UserContent().Parts[0].Text, genai.RoleModel)}
+				yield(ev, nil)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	store := InMemoryScheduleStore()
+	for _, schedule := range []Schedule{
+		{ID: "skip", AppName: "app", UserID: "user", Cron: "* * * * *", Message: "check", NextRunAt: now},
+		{ID: "queue", AppName: "app", UserID: "user", Cron: "* * * * *", Message: "check", Overlap: OverlapQueue, NextRunAt: now},
+		{ID: "later", AppName: "app", UserID: "user", Cron: "@daily", Message: "check", NextRunAt: now.Add(time.Hour)},
+	} {
+		if err := store.CreateSchedule(t.Context(), schedule); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := NewCron(CronConfig{AppName: "app", Store: store, Runner: r, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.clock = fake
+
+	// Both schedules are due again while their first runs are in progress.
+	var gotDue []int
+	for _, advance := range []time.Duration{0, time.Minute} {
+		fake.Advance(advance)
+		n, err := s.FireDue(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotDue = append(gotDue, n)
+		if advance == 0 {
+			<-started
+			<-started
+		}
+	}
+	close(release)
+	s.Wait()
+	if diff := cmp.Diff([]int{2, 2}, gotDue); diff != "" {
+		t.Errorf("FireDue() counts mismatch (-want +got):\n%s", diff)
+	}
+
+	sessions := make(map[string]bool)
+	for id, want := range map[string][]string{
+		"skip":  {"skipped 09:01 ", "succeeded 09:00 ack: check"},
+		"queue": {"succeeded 09:00 ack: check", "succeeded 09:01 ack: check"},
+		"later": nil,
+	} {
+		runs, err := store.ListRuns(t.Context(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, run := range runs {
+			got = append(got, string(run.Status)+" "+run.ScheduledAt.Format("15:04")+" "+run.Response)
+			if run.Status != RunSucceeded {
+				continue
+			}
+			if run.InvocationID == "" {
+				t.Errorf("run of %q at %v has no invocation ID", id, run.ScheduledAt)
+			}
+			if sessions[run.SessionID] {
+				t.Errorf("run of %q at %v reused session %q", id, run.ScheduledAt, run.SessionID)
+			}
+			sessions[run.SessionID] = true
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("runs of %q mismatch (-want +got):\n%s", id, diff)
+		}
+	}
+
+	schedule, err := store.GetSchedule(t.Context(), "queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := now.Add(2 * time.Minute); !schedule.NextRunAt.Equal(want) {
+		t.Errorf("NextRunAt = %v, want %v", schedule.NextRunAt, want)
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	valid := Schedule{ID: "s", AppName: "app", UserID: "user", Cron: "@hourly", Message: "check"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	for name, mutate := range map[string]func(*Schedule){
+		"missing user":    func(s *Schedule) { s.UserID = "" },
+		"missing message": func(s *Schedule) { s.Message = "" },
+		"invalid cron":    func(s *Schedule) { s.Cron = "every hour" },
+		"unknown policy":  func(s *Schedule) { s.Overlap = "replace" },
+	} {
+		s := valid
+		mutate(&s)
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate() of a schedule with %s succeeded, want error", name)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// A Wednesday.
+	from := time.Date(2025, 6, 4, 9, 30, 15, 0, time.UTC)
+	for _, tc := range []struct {
+		expr string
+		want time.Time
+	}{
+		{expr: "* * * * *", want: time.Date(2025, 6, 4, 9, 31, 0, 0, time.UTC)},
+		{expr: "@hourly", want: time.Date(2025, 6, 4, 10, 0, 0, 0, time.UTC)},
+		{expr: "*/20 * * * *", want: time.Date(2025, 6, 4, 9, 40, 0, 0, time.UTC)},
+		{expr: "15 9-17/4 * * *", want: time.Date(2025, 6, 4, 13, 15, 0, 0, time.UTC)},
+		{expr: "0 8 * * 1,5", want: time.Date(2025, 6, 6, 8, 0, 0, 0, time.UTC)},
+		{expr: "0 0 * * 7", want: time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC)},
+		{expr: "@monthly", want: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)},
+		// Restricted day of month and day of week match either.
+		{expr: "0 0 10 * 4", want: time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC)},
+		// A stepped "*" counts as "*": days must match both fields.
+		{expr: "0 0 */2 * 1", want: time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 10 * */2", want: time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 12 29 2 *", want: time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{expr: "0 0 30 2 *"},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			c, err := ParseCron(tc.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.Next(from); !got.Equal(tc.want) {
+				t.Errorf("Next(%v) = %v, want %v", from, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@often",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want error", expr)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scheduler fires delayed follow-ups of sessions, e.g. reminders
+// registered by an agent with the scheduletool.
+//
+// Follow-up tasks are kept in a [Store]. A [Scheduler] polls the store and,
+// when a task is due, runs the agent on the session of the task with the
+// message of the task as a synthetic user message, so that the agent
+// resumes the conversation.
+//
+// Recurring runs, e.g. hourly status checks, are kept as cron [Schedule]s in
+// a [ScheduleStore]. A [CronScheduler] starts the due runs, each in a fresh
+// session, and records their outcome in the store.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/clock"
+	"google.golang.org/adk/internal/logging"
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/runner"
+)
+
+// DefaultPollInterval is the interval at which the store is polled for due
+// tasks if Config.PollInterval is zero.
+const DefaultPollInterval = time.Second
+
+// Task is a follow-up of a session to run at a later time.
+type Task struct {
+	// ID identifies the task.
+	ID string
+	// AppName, UserID and SessionID identify the session resumed.
+	AppName   string
+	UserID    string
+	SessionID string
+	// FireAt is the time the task is due at.
+	FireAt time.Time
+	// Message is sent to the agent as a user message when the task fires.
+	Message string
+}
+
+// Config is the configuration of a Scheduler.
+type Config struct {
+	// AppName is the name of the app whose tasks are fired. It must be the
+	// app of Runner.
+	AppName string
+	// Store holds the tasks.
+	Store Store
+	// Runner runs the agent when a task fires.
+	Runner *runner.Runner
+	// RunConfig is used for the runs of fired tasks.
+	RunConfig agent.RunConfig
+	// PollInterval is the interval at which the store is polled for due
+	// tasks. Defaults to DefaultPollInterval.
+	PollInterval time.Duration
+}
+
+// Scheduler fires the due tasks of a store.
+type Scheduler struct {
+	cfg   Config
+	clock clock.Clock
+}
+
+// New creates a Scheduler. Call [Scheduler.Run] to start firing tasks.
+func New(cfg Config) (*Scheduler, error) {
+	if cfg.AppName == "" {
+		return nil, errors.New("AppName is required")
+	}
+	if cfg.Store == nil || cfg.Runner == nil {
+		return nil, errors.New("Store and Runner are required")
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	if cfg.PollInterval < 0 {
+		return nil, errors.New("PollInterval must not be negative")
+	}
+	return &Scheduler{cfg: cfg, clock: clock.Real()}, nil
+}
+
+// Run fires the due tasks every PollInterval until ctx is done, and returns
+// the error of ctx. Errors firing tasks are logged.
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := s.clock.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C():
+			if _, err := s.FireDue(ctx); err != nil {
+				logging.FromContext(ctx).WarnContext(ctx, "failed to fire scheduled tasks", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// FireDue takes the tasks due now from the store and runs them one after
+// the other. It returns the number of tasks fired. A task whose run fails
+// is logged and not retried.
+func (s *Scheduler) FireDue(ctx context.Context) (int, error) {
+	now := s.clock.Now()
+	tasks, err := s.cfg.Store.TakeDue(ctx, s.cfg.AppName, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to take due tasks: %w", err)
+	}
+	for _, task := range tasks {
+		err := s.fire(ctx, task, now.Sub(task.FireAt))
+		if err != nil {
+			logging.FromContext(ctx).WarnContext(ctx, "scheduled task failed",
+				slog.String("task_id", task.ID),
+				slog.String(logging.KeySessionID, task.SessionID),
+				slog.Any("error", err))
+		}
+	}
+	return len(tasks), nil
+}
+
+func (s *Scheduler) fire(ctx context.Context, task Task, lateness time.Duration) (err error) {
+	spans := telemetry.StartTrace(ctx, "fire_task")
+	telemetry.AddTaskFiredEvent(spans, task.ID, lateness)
+	defer func() { telemetry.TraceTaskRun(spans, err) }()
+
+	ctx = telemetry.ContextWithSpan(ctx, spans)
+	msg := genai.NewContentFromText(task.Message, genai.RoleUser)
+	for _, err := range s.cfg.Runner.Run(ctx, task.UserID, task.SessionID, msg, s.cfg.RunConfig) {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
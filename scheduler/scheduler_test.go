@@ -0,0 +1,162 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"errors"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/clock"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+)
+
+func TestInMemoryStore(t *testing.T) {
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	store := InMemoryStore()
+	for _, task := range []Task{
+		{ID: "late", AppName: "app", FireAt: now.Add(time.Hour)},
+		{ID: "second", AppName: "app", FireAt: now},
+		{ID: "first", AppName: "app", FireAt: now.Add(-time.Minute)},
+		{ID: "other app", AppName: "other", FireAt: now},
+		{ID: "cancelled", AppName: "app", FireAt: now},
+	} {
+		if err := store.Add(t.Context(), task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Cancel(t.Context(), "cancelled"); err != nil {
+		t.Errorf("Cancel() error = %v", err)
+	}
+	if err := store.Cancel(t.Context(), "cancelled"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Cancel() of a cancelled task error = %v, want %v", err, ErrTaskNotFound)
+	}
+
+	for _, want := range [][]string{{"first", "second"}, nil} {
+		tasks, err := store.TakeDue(t.Context(), "app", now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, task := range tasks {
+			got = append(got, task.ID)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("TakeDue() mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestFireDue(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(prev)
+
+	// The agent acknowledges the message it receives.
+	a, err := agent.New(agent.Config{
+		Name: "reminder",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				ev := session.NewEvent(ctx.InvocationID())
+				ev.Author = "reminder"
+				ev.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText("ack: "+ctx.UserContent().Parts[0].Text, genai.RoleModel)}
+				yield(ev, nil)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	store := InMemoryStore()
+	for _, task := range []Task{
+		{ID: "t1", AppName: "app", UserID: "user", SessionID: "s1", FireAt: now.Add(time.Minute), Message: "stretch"},
+		{ID: "t2", AppName: "app", UserID: "user", SessionID: "s1", FireAt: now.Add(2 * time.Minute), Message: "drink water"},
+	} {
+		if err := store.Add(t.Context(), task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := New(Config{AppName: "app", Store: store, Runner: r})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.clock = fake
+
+	var gotFired []int
+	for _, advance := range []time.Duration{0, time.Minute, 90 * time.Second} {
+		fake.Advance(advance)
+		n, err := s.FireDue(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotFired = append(gotFired, n)
+	}
+	if diff := cmp.Diff([]int{0, 1, 1}, gotFired); diff != "" {
+		t.Errorf("FireDue() counts mismatch (-want +got):\n%s", diff)
+	}
+
+	resp, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for ev := range resp.Session.Events().All() {
+		got = append(got, ev.Author+": "+ev.Content.Parts[0].Text)
+	}
+	want := []string{"user: stretch", "reminder: ack: stretch", "user: drink water", "reminder: ack: drink water"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("session events mismatch (-want +got):\n%s", diff)
+	}
+
+	var firedEvents []string
+	for _, span := range recorder.Ended() {
+		if span.Name() != "fire_task" {
+			continue
+		}
+		for _, ev := range span.Events() {
+			for _, kv := range ev.Attributes {
+				if kv.Key == "gcp.vertex.agent.task_lateness_ms" {
+					firedEvents = append(firedEvents, ev.Name+" "+kv.Value.Emit())
+				}
+			}
+		}
+	}
+	// The second task fires 30 seconds late.
+	if diff := cmp.Diff([]string{"task_fired 0", "task_fired 30000"}, firedEvents); diff != "" {
+		t.Errorf("fire_task span events mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrScheduleNotFound is returned for a schedule that does not exist, e.g.
+// because it was deleted.
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+// ScheduleStore holds recurring schedules and the records of their runs.
+// Stores backed by a database keep schedules across restarts and can be
+// shared by several cron schedulers.
+type ScheduleStore interface {
+	// CreateSchedule adds a schedule.
+	CreateSchedule(ctx context.Context, schedule Schedule) error
+	// GetSchedule returns a schedule, or ErrScheduleNotFound.
+	GetSchedule(ctx context.Context, id string) (Schedule, error)
+	// ListSchedules returns the schedules of the user of the app, ordered
+	// by ID.
+	ListSchedules(ctx context.Context, appName, userID string) ([]Schedule, error)
+	// DeleteSchedule removes a schedule and the records of its runs. It
+	// returns ErrScheduleNotFound if the schedule does not exist.
+	DeleteSchedule(ctx context.Context, id string) error
+	// ClaimDue returns the schedules of the app due at now, ordered by
+	// their NextRunAt, and moves their NextRunAt to the time returned by
+	// next. Each due run is returned by a single call, also when several
+	// schedulers share the store. Schedules with a zero NextRunAt are never
+	// due.
+	ClaimDue(ctx context.Context, appName string, now time.Time, next func(Schedule) time.Time) ([]Schedule, error)
+	// AddRun records a run of a schedule.
+	AddRun(ctx context.Context, run ScheduleRun) error
+	// ListRuns returns the recorded runs of a schedule, oldest first.
+	ListRuns(ctx context.Context, scheduleID string) ([]ScheduleRun, error)
+}
+
+// InMemoryScheduleStore returns a ScheduleStore keeping schedules and runs in
+// memory. They are lost when the process exits.
+func InMemoryScheduleStore() ScheduleStore {
+	return &inMemoryScheduleStore{
+		schedules: make(map[string]Schedule),
+		runs:      make(map[string][]ScheduleRun),
+	}
+}
+
+type inMemoryScheduleStore struct {
+	mu        sync.Mutex
+	schedules map[string]Schedule
+	runs      map[string][]ScheduleRun
+}
+
+func (s *inMemoryScheduleStore) CreateSchedule(ctx context.Context, schedule Schedule) error {
+	if schedule.ID == "" {
+		return errors.New("schedule ID is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.schedules[schedule.ID]; ok {
+		return errors.New("schedule already exists")
+	}
+	s.schedules[schedule.ID] = schedule
+	return nil
+}
+
+func (s *inMemoryScheduleStore) GetSchedule(ctx context.Context, id string) (Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schedule, ok := s.schedules[id]
+	if !ok {
+		return Schedule{}, ErrScheduleNotFound
+	}
+	return schedule, nil
+}
+
+func (s *inMemoryScheduleStore) ListSchedules(ctx context.Context, appName, userID string) ([]Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var schedules []Schedule
+	for _, schedule := range s.schedules {
+		if schedule.AppName == appName && schedule.UserID == userID {
+			schedules = append(schedules, schedule)
+		}
+	}
+	slices.SortFunc(schedules, func(a, b Schedule) int { return strings.Compare(a.ID, b.ID) })
+	return schedules, nil
+}
+
+func (s *inMemoryScheduleStore) DeleteSchedule(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.schedules[id]; !ok {
+		return ErrScheduleNotFound
+	}
+	delete(s.schedules, id)
+	delete(s.runs, id)
+	return nil
+}
+
+func (s *inMemoryScheduleStore) ClaimDue(ctx context.Context, appName string, now time.Time, next func(Schedule) time.Time) ([]Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []Schedule
+	for id, schedule := range s.schedules {
+		if schedule.AppName != appName || schedule.NextRunAt.IsZero() || schedule.NextRunAt.After(now) {
+			continue
+		}
+		due = append(due, schedule)
+		schedule.NextRunAt = next(schedule)
+		s.schedules[id] = schedule
+	}
+	slices.SortFunc(due, func(a, b Schedule) int { return a.NextRunAt.Compare(b.NextRunAt) })
+	return due, nil
+}
+
+func (s *inMemoryScheduleStore) AddRun(ctx context.Context, run ScheduleRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.schedules[run.ScheduleID]; !ok {
+		return ErrScheduleNotFound
+	}
+	s.runs[run.ScheduleID] = append(s.runs[run.ScheduleID], run)
+	return nil
+}
+
+func (s *inMemoryScheduleStore) ListRuns(ctx context.Context, scheduleID string) ([]ScheduleRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.schedules[scheduleID]; !ok {
+		return nil, ErrScheduleNotFound
+	}
+	return slices.Clone(s.runs[scheduleID]), nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrTaskNotFound is returned when cancelling a task that is not scheduled,
+// e.g. because it already fired.
+var ErrTaskNotFound = errors.New("task not found")
+
+// Store holds scheduled tasks. Stores backed by a database keep tasks
+// across restarts and can be shared by several schedulers.
+type Store interface {
+	// Add schedules a task.
+	Add(ctx context.Context, task Task) error
+	// TakeDue removes and returns the tasks of the app due at now, ordered
+	// by fire time. Each task is returned by a single call, also when
+	// several schedulers share the store.
+	TakeDue(ctx context.Context, appName string, now time.Time) ([]Task, error)
+	// Cancel removes a task before it fires. It returns ErrTaskNotFound
+	// if the task is not scheduled.
+	Cancel(ctx context.Context, id string) error
+}
+
+// InMemoryStore returns a Store keeping tasks in memory. Tasks are lost
+// when the process exits.
+func InMemoryStore() Store {
+	return &inMemoryStore{tasks: make(map[string]Task)}
+}
+
+type inMemoryStore struct {
+	mu    sync.Mutex
+	tasks map[string]Task
+}
+
+func (s *inMemoryStore) Add(ctx context.Context, task Task) error {
+	if task.ID == "" {
+		return errors.New("task ID is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks[task.ID] = task
+	return nil
+}
+
+func (s *inMemoryStore) TakeDue(ctx context.Context, appName string, now time.Time) ([]Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []Task
+	for id, task := range s.tasks {
+		if task.AppName == appName && !task.FireAt.After(now) {
+			due = append(due, task)
+			delete(s.tasks, id)
+		}
+	}
+	slices.SortFunc(due, func(a, b Task) int { return a.FireAt.Compare(b.FireAt) })
+	return due, nil
+}
+
+func (s *inMemoryStore) Cancel(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tasks[id]; !ok {
+		return ErrTaskNotFound
+	}
+	delete(s.tasks, id)
+	return nil
+}
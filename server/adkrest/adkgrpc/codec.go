@@ -0,0 +1,61 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adkgrpc
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+)
+
+// CodecName is the gRPC content-subtype used by the agent service.
+//
+// Messages are encoded as JSON using the same data models as the REST API.
+// The codec is not registered globally, so that it does not replace the
+// "json" codec of other gRPC servers and clients of the process: servers
+// hosting the agent service must be created with [ServerCodec], and clients
+// must call with [CallCodec].
+const CodecName = "json"
+
+// ServerCodec returns the server option encoding the messages of the server
+// with the codec of the agent service. It applies to every service of the
+// server.
+func ServerCodec() grpc.ServerOption {
+	return grpc.ForceServerCodec(jsonCodec{})
+}
+
+// CallCodec returns the call option encoding the messages of a call with
+// the codec of the agent service.
+func CallCodec() grpc.CallOption {
+	return grpc.ForceCodec(jsonCodec{})
+}
+
+// jsonCodec implements encoding.Codec using encoding/json.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return CodecName
+}
+
+var _ encoding.Codec = jsonCodec{}
@@ -0,0 +1,74 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adkgrpc
+
+import (
+	"fmt"
+
+	"google.golang.org/adk/server/adkrest/internal/models"
+)
+
+// GetSessionRequest is the request message of the GetSession RPC.
+type GetSessionRequest struct {
+	AppName   string `json:"appName"`
+	UserID    string `json:"userId"`
+	SessionID string `json:"sessionId"`
+}
+
+func (r *GetSessionRequest) validate() error {
+	if r.AppName == "" {
+		return fmt.Errorf("appName is required")
+	}
+	if r.UserID == "" {
+		return fmt.Errorf("userId is required")
+	}
+	if r.SessionID == "" {
+		return fmt.Errorf("sessionId is required")
+	}
+	return nil
+}
+
+// ListSessionsRequest is the request message of the ListSessions RPC.
+type ListSessionsRequest struct {
+	AppName string `json:"appName"`
+	UserID  string `json:"userId"`
+}
+
+func (r *ListSessionsRequest) validate() error {
+	if r.AppName == "" {
+		return fmt.Errorf("appName is required")
+	}
+	if r.UserID == "" {
+		return fmt.Errorf("userId is required")
+	}
+	return nil
+}
+
+// ListSessionsResponse is the response message of the ListSessions RPC.
+type ListSessionsResponse struct {
+	Sessions []models.Session `json:"sessions"`
+}
+
+// TraceDictRequest is the request message of the GetTraceDict RPC.
+type TraceDictRequest struct {
+	EventID string `json:"eventId"`
+}
+
+// TraceDictResponse is the response message of the GetTraceDict RPC.
+type TraceDictResponse struct {
+	// Spans holds the attributes of all spans of the event, ordered by span
+	// start time.
+	Spans []map[string]string `json:"spans"`
+}
@@ -0,0 +1,344 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adkgrpc provides a gRPC front-end mirroring the ADK REST API.
+//
+// The service shares the session service, agent loader and data models with
+// the REST handlers. Messages are JSON encoded (see [CodecName]), so no
+// generated protobuf code is required on either side.
+//
+// Run mirrors the run endpoints of the REST API, except for resumable
+// streams: requests with resumable or a resumeToken fail with Unimplemented,
+// as a gRPC client that lost its stream cannot resume it.
+package adkgrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/cmd/launcher"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/server/adkrest/internal/models"
+	"google.golang.org/adk/server/adkrest/internal/services"
+	"google.golang.org/adk/session"
+)
+
+// ServiceName is the fully qualified gRPC service name.
+const ServiceName = "google.adk.v1.AgentService"
+
+// agentServiceServer is the server API for the agent service.
+type agentServiceServer interface {
+	Run(*models.RunAgentRequest, grpc.ServerStream) error
+	GetSession(context.Context, *GetSessionRequest) (*models.Session, error)
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	GetTraceDict(context.Context, *TraceDictRequest) (*TraceDictResponse, error)
+}
+
+// Option configures the agent service registered by [Register].
+type Option func(*agentService)
+
+// WithConcurrencyLimiter limits the number of concurrent runs per app and
+// user. Runs rejected by the limiter fail with ResourceExhausted. By default
+// runs are not limited.
+func WithConcurrencyLimiter(l runner.ConcurrencyLimiter) Option {
+	return func(s *agentService) {
+		s.limiter = l
+	}
+}
+
+// WithRunTimeout cancels runs exceeding the given duration, or the shorter
+// timeout of the request, ending them with an event with the RUN_TIMEOUT
+// error code. Requests with a negative timeout fail with InvalidArgument.
+// By default runs have no deadline.
+func WithRunTimeout(d time.Duration) Option {
+	return func(s *agentService) {
+		s.runTimeout = d
+	}
+}
+
+// WithSessionLocker serializes the runs of each session with the given
+// locker. Runs rejected by the locker fail with Aborted. By default
+// concurrent runs of a session are not serialized.
+func WithSessionLocker(l runner.SessionLocker) Option {
+	return func(s *agentService) {
+		s.sessionLocker = l
+	}
+}
+
+// WithModelOverrides allows run requests to replace the models of their
+// agents with the given models, named by the modelOverride field of the
+// request. Requests naming other models fail with InvalidArgument.
+func WithModelOverrides(models ...model.LLM) Option {
+	return func(s *agentService) {
+		s.modelOverrides = models
+	}
+}
+
+// WithAgentPools limits the number of concurrent runs of each agent with the
+// given pools. Runs rejected by the pools fail with ResourceExhausted. By
+// default runs of an agent are not limited.
+func WithAgentPools(p *runner.AgentPools) Option {
+	return func(s *agentService) {
+		s.agentPools = p
+	}
+}
+
+// Register registers the agent service on the given gRPC server. The server
+// must be created with [ServerCodec].
+func Register(s grpc.ServiceRegistrar, config *launcher.Config, opts ...Option) {
+	adkExporter := services.RegisterAPIServerSpanExporter(services.DebugCaptureConfig{})
+
+	svc := &agentService{
+		sessionService:  config.SessionService,
+		agentLoader:     config.AgentLoader,
+		artifactService: config.ArtifactService,
+		spansExporter:   adkExporter,
+	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	s.RegisterService(&serviceDesc, svc)
+}
+
+type agentService struct {
+	sessionService  session.Service
+	agentLoader     agent.Loader
+	artifactService artifact.Service
+	spansExporter   *services.APIServerSpanExporter
+	limiter         runner.ConcurrencyLimiter
+	runTimeout      time.Duration
+	sessionLocker   runner.SessionLocker
+	modelOverrides  []model.LLM
+	agentPools      *runner.AgentPools
+}
+
+// Run executes an agent run and streams the resulting events.
+func (s *agentService) Run(req *models.RunAgentRequest, stream grpc.ServerStream) error {
+	if err := req.AssertRunAgentRequestRequired(); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if req.Resumable || req.ResumeToken != "" {
+		return status.Error(codes.Unimplemented, "resumable streams are only supported by the REST API")
+	}
+	timeout, err := req.RunTimeout(s.runTimeout)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	ctx := stream.Context()
+	_, err = s.sessionService.Get(ctx, &session.GetRequest{
+		AppName:   req.AppName,
+		UserID:    req.UserId,
+		SessionID: req.SessionId,
+	})
+	if err != nil {
+		return status.Errorf(sessionErrorCode(err), "failed to get session: %v", err)
+	}
+
+	curAgent, err := s.agentLoader.LoadAgent(req.AppName)
+	if err != nil {
+		return status.Errorf(codes.NotFound, "failed to load agent: %v", err)
+	}
+	r, err := runner.New(runner.Config{
+		AppName:            req.AppName,
+		Agent:              curAgent,
+		SessionService:     s.sessionService,
+		ArtifactService:    s.artifactService,
+		ConcurrencyLimiter: s.limiter,
+		SessionLocker:      s.sessionLocker,
+		RunTimeout:         s.runTimeout,
+		ModelOverrides:     s.modelOverrides,
+		AgentPools:         s.agentPools,
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to create runner: %v", err)
+	}
+
+	streamingMode := agent.StreamingModeNone
+	if req.Streaming {
+		streamingMode = agent.StreamingModeSSE
+	}
+	runConfig := agent.RunConfig{
+		StreamingMode: streamingMode,
+		Labels:        req.Labels,
+		Timeout:       timeout,
+		PhaseEvents:   req.PhaseEvents,
+
+		ModelOverride:          req.ModelOverride,
+		ModelOverrideSubAgents: req.ModelOverrideSubAgents,
+		GenerationOverrides:    req.RunGenerationOverrides(),
+	}
+	for event, err := range r.Run(ctx, req.UserId, req.SessionId, &req.NewMessage, runConfig) {
+		if err != nil {
+			return status.Errorf(runErrorCode(err), "failed to run agent: %v", err)
+		}
+		ev := models.FromSessionEvent(*event)
+		if err := stream.SendMsg(&ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSession retrieves a specific session by its ID.
+func (s *agentService) GetSession(ctx context.Context, req *GetSessionRequest) (*models.Session, error) {
+	if err := req.validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	resp, err := s.sessionService.Get(ctx, &session.GetRequest{
+		AppName:   req.AppName,
+		UserID:    req.UserID,
+		SessionID: req.SessionID,
+	})
+	if err != nil {
+		return nil, status.Error(sessionErrorCode(err), err.Error())
+	}
+	sess, err := models.FromSession(resp.Session)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &sess, nil
+}
+
+// ListSessions lists all sessions for a given app and user.
+func (s *agentService) ListSessions(ctx context.Context, req *ListSessionsRequest) (*ListSessionsResponse, error) {
+	if err := req.validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	resp, err := s.sessionService.List(ctx, &session.ListRequest{
+		AppName: req.AppName,
+		UserID:  req.UserID,
+	})
+	if err != nil {
+		return nil, status.Error(contextErrorCode(err, codes.Internal), err.Error())
+	}
+	sessions := make([]models.Session, 0, len(resp.Sessions))
+	for _, storedSession := range resp.Sessions {
+		sess, err := models.FromSession(storedSession)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		sessions = append(sessions, sess)
+	}
+	return &ListSessionsResponse{Sessions: sessions}, nil
+}
+
+// GetTraceDict returns the debug information for the given event.
+func (s *agentService) GetTraceDict(ctx context.Context, req *TraceDictRequest) (*TraceDictResponse, error) {
+	if req.EventID == "" {
+		return nil, status.Error(codes.InvalidArgument, "eventId is required")
+	}
+	eventSpans, ok := s.spansExporter.GetTraceDict()[req.EventID]
+	if !ok {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("event not found: %s", req.EventID))
+	}
+	return &TraceDictResponse{Spans: eventSpans}, nil
+}
+
+// runErrorCode returns the status code for an error yielded by a run,
+// mirroring the status codes of the REST API.
+func runErrorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, runner.ErrTooManyRuns), errors.Is(err, runner.ErrAgentBusy):
+		return codes.ResourceExhausted
+	case errors.Is(err, runner.ErrSessionBusy):
+		return codes.Aborted
+	case errors.Is(err, runner.ErrModelNotAllowed), errors.Is(err, runner.ErrInvalidGenerationOverrides):
+		return codes.InvalidArgument
+	}
+	return contextErrorCode(err, codes.Internal)
+}
+
+// sessionErrorCode returns the status code for an error getting a session.
+// Session services do not tell missing sessions from other failures, so
+// errors other than context errors are reported as NotFound, like the 404 of
+// the REST API.
+func sessionErrorCode(err error) codes.Code {
+	return contextErrorCode(err, codes.NotFound)
+}
+
+// contextErrorCode returns the status code for a context error, or def for
+// other errors.
+func contextErrorCode(err error, def codes.Code) codes.Code {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	}
+	return def
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*agentServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetSession",
+			Handler:    unaryHandler("GetSession", agentServiceServer.GetSession),
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    unaryHandler("ListSessions", agentServiceServer.ListSessions),
+		},
+		{
+			MethodName: "GetTraceDict",
+			Handler:    unaryHandler("GetTraceDict", agentServiceServer.GetTraceDict),
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Run",
+			Handler:       runHandler,
+			ServerStreams: true,
+		},
+	},
+}
+
+// unaryHandler adapts a typed method of agentServiceServer to grpc.MethodHandler.
+func unaryHandler[Req, Resp any](method string, fn func(agentServiceServer, context.Context, *Req) (*Resp, error)) grpc.MethodHandler {
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		in := new(Req)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return fn(srv.(agentServiceServer), ctx, in)
+		}
+		info := &grpc.UnaryServerInfo{
+			Server:     srv,
+			FullMethod: "/" + ServiceName + "/" + method,
+		}
+		handler := func(ctx context.Context, req any) (any, error) {
+			return fn(srv.(agentServiceServer), ctx, req.(*Req))
+		}
+		return interceptor(ctx, in, info, handler)
+	}
+}
+
+func runHandler(srv any, stream grpc.ServerStream) error {
+	in := new(models.RunAgentRequest)
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	return srv.(agentServiceServer).Run(in, stream)
+}
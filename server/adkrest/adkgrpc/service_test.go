@@ -0,0 +1,278 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adkgrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"net"
+	"testing"
+
+	"google.golang.org/genai"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/cmd/launcher"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/server/adkrest/internal/models"
+	"google.golang.org/adk/session"
+)
+
+func newTestConn(t *testing.T, sessionService session.Service, opts ...Option) *grpc.ClientConn {
+	t.Helper()
+
+	a, err := agent.New(agent.Config{
+		Name: "test_app",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				ev := session.NewEvent(ctx.InvocationID())
+				ev.LLMResponse = model.LLMResponse{
+					Content: genai.NewContentFromText("hello", genai.RoleModel),
+				}
+				yield(ev, nil)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(ServerCodec())
+	Register(srv, &launcher.Config{
+		SessionService: sessionService,
+		AgentLoader:    agent.NewSingleLoader(a),
+	}, opts...)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(CallCodec()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// run calls the Run RPC and returns the streamed events.
+func run(t *testing.T, conn *grpc.ClientConn, req *models.RunAgentRequest) ([]models.Event, error) {
+	t.Helper()
+	stream, err := conn.NewStream(t.Context(), &serviceDesc.Streams[0], "/"+ServiceName+"/Run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.SendMsg(req); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []models.Event
+	for {
+		var ev models.Event
+		err := stream.RecvMsg(&ev)
+		if errors.Is(err, io.EOF) {
+			return events, nil
+		}
+		if err != nil {
+			return events, err
+		}
+		events = append(events, ev)
+	}
+}
+
+func TestRun(t *testing.T) {
+	ctx := t.Context()
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "test_app", UserID: "user", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	conn := newTestConn(t, sessionService)
+
+	events, err := run(t, conn, &models.RunAgentRequest{
+		AppName:    "test_app",
+		UserId:     "user",
+		SessionId:  "s1",
+		NewMessage: *genai.NewContentFromText("hi", genai.RoleUser),
+	})
+	if err != nil {
+		t.Fatalf("RecvMsg() error = %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if got := events[0].Content.Parts[0].Text; got != "hello" {
+		t.Errorf("event text = %q, want %q", got, "hello")
+	}
+
+	var got models.Session
+	if err := conn.Invoke(ctx, "/"+ServiceName+"/GetSession", &GetSessionRequest{AppName: "test_app", UserID: "user", SessionID: "s1"}, &got); err != nil {
+		t.Fatalf("GetSession() error = %v", err)
+	}
+	// The user message and the agent response.
+	if len(got.Events) != 2 {
+		t.Errorf("GetSession() returned %d events, want 2", len(got.Events))
+	}
+}
+
+func TestListSessions(t *testing.T) {
+	ctx := t.Context()
+	sessionService := session.InMemoryService()
+	for _, id := range []string{"s1", "s2"} {
+		if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "test_app", UserID: "user", SessionID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conn := newTestConn(t, sessionService)
+
+	var resp ListSessionsResponse
+	if err := conn.Invoke(ctx, "/"+ServiceName+"/ListSessions", &ListSessionsRequest{AppName: "test_app", UserID: "user"}, &resp); err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(resp.Sessions) != 2 {
+		t.Errorf("ListSessions() returned %d sessions, want 2", len(resp.Sessions))
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ctx := t.Context()
+	conn := newTestConn(t, session.InMemoryService())
+
+	tests := []struct {
+		name     string
+		method   string
+		req      any
+		resp     any
+		wantCode codes.Code
+	}{
+		{
+			name:     "get missing session",
+			method:   "GetSession",
+			req:      &GetSessionRequest{AppName: "test_app", UserID: "user", SessionID: "missing"},
+			resp:     &models.Session{},
+			wantCode: codes.NotFound,
+		},
+		{
+			name:     "get session without user",
+			method:   "GetSession",
+			req:      &GetSessionRequest{AppName: "test_app", SessionID: "s1"},
+			resp:     &models.Session{},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "unknown trace",
+			method:   "GetTraceDict",
+			req:      &TraceDictRequest{EventID: "missing"},
+			resp:     &TraceDictResponse{},
+			wantCode: codes.NotFound,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := conn.Invoke(ctx, "/"+ServiceName+"/"+tc.method, tc.req, tc.resp)
+			if got := status.Code(err); got != tc.wantCode {
+				t.Errorf("%s() code = %v, want %v (err: %v)", tc.method, got, tc.wantCode, err)
+			}
+		})
+	}
+}
+
+// busyLocker rejects every run.
+type busyLocker struct{}
+
+func (busyLocker) Lock(context.Context, string, string, string) (func(), error) {
+	return nil, runner.ErrSessionBusy
+}
+
+func TestRunErrors(t *testing.T) {
+	ctx := t.Context()
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "test_app", UserID: "user", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	newRequest := func() *models.RunAgentRequest {
+		return &models.RunAgentRequest{
+			AppName:    "test_app",
+			UserId:     "user",
+			SessionId:  "s1",
+			NewMessage: *genai.NewContentFromText("hi", genai.RoleUser),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		modify   func(*models.RunAgentRequest)
+		wantCode codes.Code
+	}{
+		{
+			name:     "missing session",
+			modify:   func(r *models.RunAgentRequest) { r.SessionId = "missing" },
+			wantCode: codes.NotFound,
+		},
+		{
+			name:     "model not allowed",
+			modify:   func(r *models.RunAgentRequest) { r.ModelOverride = "other-model" },
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "session busy",
+			opts:     []Option{WithSessionLocker(busyLocker{})},
+			wantCode: codes.Aborted,
+		},
+		{
+			name:     "negative timeout",
+			modify:   func(r *models.RunAgentRequest) { r.TimeoutSeconds = -1 },
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "resumable",
+			modify:   func(r *models.RunAgentRequest) { r.Resumable = true },
+			wantCode: codes.Unimplemented,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := newTestConn(t, sessionService, tc.opts...)
+			req := newRequest()
+			if tc.modify != nil {
+				tc.modify(req)
+			}
+			_, err := run(t, conn, req)
+			if got := status.Code(err); got != tc.wantCode {
+				t.Errorf("Run() code = %v, want %v (err: %v)", got, tc.wantCode, err)
+			}
+		})
+	}
+}
+
+func TestCodecNotRegistered(t *testing.T) {
+	if encoding.GetCodecV2(CodecName) != nil {
+		t.Errorf("codec %q is registered globally, want it set per server", CodecName)
+	}
+}
@@ -16,8 +16,12 @@ package controllers
 
 import (
 	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/server/adkrest/internal/services"
 )
 
 // AppsAPIController is the controller for the Apps API.
@@ -35,3 +39,29 @@ func (c *AppsAPIController) ListAppsHandler(rw http.ResponseWriter, req *http.Re
 	apps := c.agentLoader.ListAgents()
 	EncodeJSONResponse(apps, http.StatusOK, rw)
 }
+
+// ListAgentToolsHandler lists the tools of the agent of an app, so that they
+// can be shown before a session starts. With includeSubAgents=true the tools
+// of its sub-agents are listed too. It returns 404 for an unknown app.
+func (c *AppsAPIController) ListAgentToolsHandler(rw http.ResponseWriter, req *http.Request) {
+	appName := mux.Vars(req)["app_name"]
+	if appName == "" {
+		http.Error(rw, "app_name parameter is required", http.StatusBadRequest)
+		return
+	}
+	includeSubAgents := false
+	if v := req.URL.Query().Get("includeSubAgents"); v != "" {
+		var err error
+		includeSubAgents, err = strconv.ParseBool(v)
+		if err != nil {
+			http.Error(rw, "includeSubAgents parameter must be a boolean", http.StatusBadRequest)
+			return
+		}
+	}
+	a, err := c.agentLoader.LoadAgent(appName)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusNotFound)
+		return
+	}
+	EncodeJSONResponse(services.AgentTools(a, includeSubAgents), http.StatusOK, rw)
+}
@@ -0,0 +1,117 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/mux"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/server/adkrest/controllers"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+func TestListAgentTools(t *testing.T) {
+	type Args struct {
+		City string `json:"city"`
+	}
+	newTool := func(name string) tool.Tool {
+		t.Helper()
+		tl, err := functiontool.New(functiontool.Config{Name: name, Description: "the " + name + " tool"}, func(tool.Context, Args) (map[string]string, error) {
+			return nil, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tl
+	}
+	sub, err := llmagent.New(llmagent.Config{Name: "sub_agent", Tools: []tool.Tool{newTool("get_time")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := llmagent.New(llmagent.Config{
+		Name:        "root_agent",
+		Tools:       []tool.Tool{newTool("get_weather"), newTool("delete_all")},
+		DeniedTools: []string{"delete_all"},
+		SubAgents:   []agent.Agent{sub},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiController := controllers.NewAppsAPIController(agent.NewSingleLoader(root))
+
+	for _, tc := range []struct {
+		name       string
+		appName    string
+		query      string
+		wantStatus int
+		want       []string
+	}{
+		{name: "agent tools", appName: "root_agent", wantStatus: http.StatusOK, want: []string{"root_agent/get_weather"}},
+		{
+			name:       "with sub-agents",
+			appName:    "root_agent",
+			query:      "?includeSubAgents=true",
+			wantStatus: http.StatusOK,
+			want:       []string{"root_agent/get_weather", "sub_agent/get_time"},
+		},
+		{name: "invalid flag", appName: "root_agent", query: "?includeSubAgents=maybe", wantStatus: http.StatusBadRequest},
+		{name: "unknown app", appName: "other", wantStatus: http.StatusNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/apps/"+tc.appName+"/tools"+tc.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"app_name": tc.appName})
+			rr := httptest.NewRecorder()
+
+			apiController.ListAgentToolsHandler(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("ListAgentToolsHandler() status = %d, want %d, body: %s", rr.Code, tc.wantStatus, rr.Body)
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			var tools []struct {
+				Agent       string         `json:"agent"`
+				Name        string         `json:"name"`
+				Description string         `json:"description"`
+				InputSchema map[string]any `json:"inputSchema"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&tools); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			var got []string
+			for _, tl := range tools {
+				got = append(got, tl.Agent+"/"+tl.Name)
+				if tl.Description != "the "+tl.Name+" tool" {
+					t.Errorf("tool %q description = %q", tl.Name, tl.Description)
+				}
+				if _, ok := tl.InputSchema["properties"].(map[string]any)["city"]; !ok {
+					t.Errorf("tool %q input schema = %v, want a city property", tl.Name, tl.InputSchema)
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ListAgentToolsHandler() tools mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
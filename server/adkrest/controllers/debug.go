@@ -15,13 +15,17 @@
 package controllers
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/runner"
 	"google.golang.org/adk/server/adkrest/internal/models"
 	"google.golang.org/adk/server/adkrest/internal/services"
 	"google.golang.org/adk/session"
@@ -43,7 +47,8 @@ func NewDebugAPIController(sessionService session.Service, agentLoader agent.Loa
 	}
 }
 
-// TraceDictHandler returns the debug information for the session in form of dictionary.
+// TraceDictHandler returns the debug information for the event as a list of
+// span attribute dictionaries, ordered by span start time.
 func (c *DebugAPIController) TraceDictHandler(rw http.ResponseWriter, req *http.Request) {
 	params := mux.Vars(req)
 	eventID := params["event_id"]
@@ -52,15 +57,131 @@ func (c *DebugAPIController) TraceDictHandler(rw http.ResponseWriter, req *http.
 		return
 	}
 	traceDict := c.spansExporter.GetTraceDict()
-	eventDict, ok := traceDict[eventID]
+	eventSpans, ok := traceDict[eventID]
 	if !ok {
 		http.Error(rw, fmt.Sprintf("event not found: %s", eventID), http.StatusNotFound)
 		return
 	}
-	EncodeJSONResponse(eventDict, http.StatusOK, rw)
+	EncodeJSONResponseWithOptions(eventSpans, http.StatusOK, rw, DebugJSONOptions)
+}
+
+// TraceBatchHandler returns the debug information of several events in one
+// response. Events without trace are reported in the NotFound list of the
+// response instead of failing the request.
+func (c *DebugAPIController) TraceBatchHandler(rw http.ResponseWriter, req *http.Request) {
+	var batchRequest models.TraceBatchRequest
+	defer req.Body.Close()
+	d := json.NewDecoder(req.Body)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&batchRequest); err != nil {
+		http.Error(rw, fmt.Sprintf("failed to decode request: %v", err), decodeStatus(err))
+		return
+	}
+	if err := batchRequest.AssertTraceBatchRequestRequired(); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	traceDict := c.spansExporter.GetTraceDict()
+	resp := models.TraceBatchResponse{
+		Traces:   make(map[string][]map[string]string),
+		NotFound: []string{},
+	}
+	seen := make(map[string]bool)
+	for _, eventID := range batchRequest.EventIds {
+		if seen[eventID] {
+			continue
+		}
+		seen[eventID] = true
+		if eventSpans, ok := traceDict[eventID]; ok {
+			resp.Traces[eventID] = eventSpans
+		} else {
+			resp.NotFound = append(resp.NotFound, eventID)
+		}
+	}
+	EncodeJSONResponseWithOptions(resp, http.StatusOK, rw, DebugJSONOptions)
+}
+
+// TraceDiffHandler compares the span attributes of two events, e.g. of the
+// same step before and after a prompt change. The response reports for each
+// event whether its trace was found; the spans are only compared if both
+// were.
+func (c *DebugAPIController) TraceDiffHandler(rw http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	beforeID, afterID := params["event_id"], params["other_event_id"]
+	if beforeID == "" || afterID == "" {
+		http.Error(rw, "event_id and other_event_id parameters are required", http.StatusBadRequest)
+		return
+	}
+	traceDict := c.spansExporter.GetTraceDict()
+	side := func(eventID string) ([]map[string]string, models.TraceDiffSide) {
+		spans, ok := traceDict[eventID]
+		if !ok {
+			return nil, models.TraceDiffSide{EventID: eventID, Status: models.TraceNotFound}
+		}
+		return spans, models.TraceDiffSide{EventID: eventID, Status: models.TraceFound, Spans: len(spans)}
+	}
+	before, beforeSide := side(beforeID)
+	after, afterSide := side(afterID)
+	resp := models.TraceDiff{Before: beforeSide, After: afterSide}
+	if before != nil && after != nil {
+		resp.Spans = services.DiffTraces(before, after)
+	}
+	EncodeJSONResponseWithOptions(resp, http.StatusOK, rw, DebugJSONOptions)
+}
+
+// WaterfallHandler returns the timing of the spans of an event, or of all
+// spans of a trace, sorted by start time.
+func (c *DebugAPIController) WaterfallHandler(rw http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)["id"]
+	if id == "" {
+		http.Error(rw, "id parameter is required", http.StatusBadRequest)
+		return
+	}
+	spans, ok := c.spansExporter.GetWaterfall(id)
+	if !ok {
+		http.Error(rw, fmt.Sprintf("event or trace not found: %s", id), http.StatusNotFound)
+		return
+	}
+	EncodeJSONResponseWithOptions(spans, http.StatusOK, rw, DebugJSONOptions)
+}
+
+// NextRequestHandler returns the request the agent continuing the session
+// would send to its model on the next turn, without running it. Inline data
+// is left out, as in traces.
+func (c *DebugAPIController) NextRequestHandler(rw http.ResponseWriter, req *http.Request) {
+	sessionID, err := models.SessionIDFromHTTPParameters(mux.Vars(req))
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rootAgent, err := c.agentloader.LoadAgent(sessionID.AppName)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("failed to load agent: %v", err), http.StatusNotFound)
+		return
+	}
+	r, err := runner.New(runner.Config{
+		AppName:        sessionID.AppName,
+		Agent:          rootAgent,
+		SessionService: c.sessionService,
+	})
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("failed to create runner: %v", err), http.StatusInternalServerError)
+		return
+	}
+	nextAgent, llmRequest, err := r.PreviewRequest(req.Context(), sessionID.UserID, sessionID.ID)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("failed to build the next request: %v", err), http.StatusBadRequest)
+		return
+	}
+	EncodeJSONResponseWithOptions(models.RequestPreview{
+		Agent:   nextAgent.Name(),
+		Request: telemetry.LLMRequestForTrace(llmRequest),
+	}, http.StatusOK, rw, DebugJSONOptions)
 }
 
 // EventGraphHandler returns the debug information for the session and session events in form of graph.
+// With the includeToolResponses query parameter set to true, the function
+// responses of the highlighted tool edges are returned along with the graph.
 func (c *DebugAPIController) EventGraphHandler(rw http.ResponseWriter, req *http.Request) {
 	vars := mux.Vars(req)
 	sessionID, err := models.SessionIDFromHTTPParameters(vars)
@@ -68,6 +189,14 @@ func (c *DebugAPIController) EventGraphHandler(rw http.ResponseWriter, req *http
 		http.Error(rw, err.Error(), http.StatusBadRequest)
 		return
 	}
+	includeToolResponses := false
+	if v := req.URL.Query().Get("includeToolResponses"); v != "" {
+		includeToolResponses, err = strconv.ParseBool(v)
+		if err != nil {
+			http.Error(rw, "includeToolResponses parameter must be a boolean", http.StatusBadRequest)
+			return
+		}
+	}
 	resp, err := c.sessionService.Get(req.Context(), &session.GetRequest{
 		AppName:   sessionID.AppName,
 		UserID:    sessionID.UserID,
@@ -97,22 +226,24 @@ func (c *DebugAPIController) EventGraphHandler(rw http.ResponseWriter, req *http
 	}
 
 	highlightedPairs := [][]string{}
-	fc := functionalCalls(event)
-	fr := functionalResponses(event)
-
-	if len(fc) > 0 {
+	var fc []*genai.FunctionCall
+	var fr []*genai.FunctionResponse
+	switch session.KindOf(event) {
+	case session.EventKindToolCall:
+		fc = functionalCalls(event)
 		for _, f := range fc {
 			if f.Name != "" {
 				highlightedPairs = append(highlightedPairs, []string{f.Name, event.Author})
 			}
 		}
-	} else if len(fr) > 0 {
+	case session.EventKindToolResponse:
+		fr = functionalResponses(event)
 		for _, f := range fr {
 			if f.Name != "" {
 				highlightedPairs = append(highlightedPairs, []string{f.Name, event.Author})
 			}
 		}
-	} else {
+	default:
 		highlightedPairs = append(highlightedPairs, []string{event.Author, event.Author})
 	}
 
@@ -126,7 +257,48 @@ func (c *DebugAPIController) EventGraphHandler(rw http.ResponseWriter, req *http
 		http.Error(rw, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	EncodeJSONResponse(map[string]string{"dotSrc": graph}, http.StatusOK, rw)
+	eventGraph := models.EventGraph{DotSrc: graph}
+	if includeToolResponses {
+		eventGraph.ToolResponses = edgeToolResponses(resp.Session.Events(), event, fc, fr)
+	}
+	EncodeJSONResponseWithOptions(eventGraph, http.StatusOK, rw, DebugJSONOptions)
+}
+
+// edgeToolResponses returns the function responses along the highlighted
+// edges of the event. The responses to function calls of the event are looked
+// up in the events following it, by call ID or, if the call has no ID, by
+// tool name.
+func edgeToolResponses(events session.Events, event *session.Event, fc []*genai.FunctionCall, fr []*genai.FunctionResponse) []models.EdgeToolResponse {
+	edges := []models.EdgeToolResponse{}
+	if len(fc) == 0 {
+		for _, f := range fr {
+			if f.Name != "" {
+				edges = append(edges, models.EdgeToolResponse{From: f.Name, To: event.Author, Response: f})
+			}
+		}
+		return edges
+	}
+
+	var later []*genai.FunctionResponse
+	seen := false
+	for ev := range events.All() {
+		if seen && session.KindOf(ev) == session.EventKindToolResponse {
+			later = append(later, functionalResponses(ev)...)
+		}
+		seen = seen || ev.ID == event.ID
+	}
+	for _, f := range fc {
+		if f.Name == "" {
+			continue
+		}
+		for _, r := range later {
+			if (f.ID != "" && r.ID == f.ID) || (f.ID == "" && r.Name == f.Name) {
+				edges = append(edges, models.EdgeToolResponse{From: f.Name, To: event.Author, Response: r})
+				break
+			}
+		}
+	}
+	return edges
 }
 
 func functionalCalls(event *session.Event) []*genai.FunctionCall {
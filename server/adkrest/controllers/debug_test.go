@@ -0,0 +1,440 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genai"
+	"gorm.io/gorm"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/server/adkrest/controllers"
+	"google.golang.org/adk/server/adkrest/internal/models"
+	"google.golang.org/adk/server/adkrest/internal/services"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/session/database"
+)
+
+// base64Codec stores contents as base64-encoded JSON, like a datastore that
+// cannot store the JSON of contents.
+type base64Codec struct{}
+
+func (base64Codec) Name() string { return "base64" }
+
+func (base64Codec) Encode(c *genai.Content) ([]byte, error) {
+	b, err := session.JSONContentCodec.Encode(c)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(b)), nil
+}
+
+func (base64Codec) Decode(b []byte) (*genai.Content, error) {
+	decoded, err := base64.StdEncoding.DecodeString(string(b))
+	if err != nil {
+		return nil, err
+	}
+	return session.JSONContentCodec.Decode(decoded)
+}
+
+func TestEventGraphToolResponses(t *testing.T) {
+	for _, backend := range []struct {
+		name           string
+		sessionService func(t *testing.T) session.Service
+	}{
+		{
+			name:           "in memory",
+			sessionService: func(*testing.T) session.Service { return session.InMemoryService() },
+		},
+		{
+			name: "database with a custom content codec",
+			sessionService: func(t *testing.T) session.Service {
+				s, err := database.NewSessionService(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := database.AutoMigrate(s); err != nil {
+					t.Fatal(err)
+				}
+				if err := database.SetContentCodec(s, base64Codec{}); err != nil {
+					t.Fatal(err)
+				}
+				return s
+			},
+		},
+	} {
+		t.Run(backend.name, func(t *testing.T) {
+			testEventGraphToolResponses(t, backend.sessionService(t))
+		})
+	}
+}
+
+func testEventGraphToolResponses(t *testing.T, sessionService session.Service) {
+	a, err := agent.New(agent.Config{Name: "testApp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := &genai.FunctionResponse{ID: "c1", Name: "get_weather", Response: map[string]any{"temp": "21C"}}
+	for _, ev := range []struct {
+		id   string
+		part *genai.Part
+	}{
+		{id: "call", part: &genai.Part{FunctionCall: &genai.FunctionCall{ID: "c1", Name: "get_weather"}}},
+		{id: "other", part: &genai.Part{FunctionResponse: &genai.FunctionResponse{ID: "c0", Name: "get_weather"}}},
+		{id: "response", part: &genai.Part{FunctionResponse: response}},
+	} {
+		event := session.NewEvent("inv")
+		event.ID = ev.id
+		event.Author = "testApp"
+		event.LLMResponse = model.LLMResponse{Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{ev.part}}}
+		if err := sessionService.AppendEvent(t.Context(), created.Session, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	controller := controllers.NewDebugAPIController(sessionService, agent.NewSingleLoader(a), nil)
+
+	wantEdges := []models.EdgeToolResponse{{From: "get_weather", To: "testApp", Response: response}}
+	for _, tc := range []struct {
+		name       string
+		eventID    string
+		query      string
+		wantStatus int
+		want       []models.EdgeToolResponse
+	}{
+		{
+			name:       "default omits tool responses",
+			eventID:    "call",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "function call edge",
+			eventID:    "call",
+			query:      "?includeToolResponses=true",
+			wantStatus: http.StatusOK,
+			want:       wantEdges,
+		},
+		{
+			name:       "function response edge",
+			eventID:    "response",
+			query:      "?includeToolResponses=true",
+			wantStatus: http.StatusOK,
+			want:       wantEdges,
+		},
+		{
+			name:       "invalid flag",
+			eventID:    "call",
+			query:      "?includeToolResponses=maybe",
+			wantStatus: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/graph"+tc.query, nil)
+			req = mux.SetURLVars(req, map[string]string{
+				"app_name":   "testApp",
+				"user_id":    "testUser",
+				"session_id": "testSession",
+				"event_id":   tc.eventID,
+			})
+			rw := httptest.NewRecorder()
+			controller.EventGraphHandler(rw, req)
+			if rw.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rw.Code, tc.wantStatus, rw.Body)
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			var got models.EventGraph
+			if err := json.Unmarshal(rw.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.DotSrc == "" {
+				t.Error("dotSrc is empty")
+			}
+			if diff := cmp.Diff(tc.want, got.ToolResponses); diff != "" {
+				t.Errorf("tool responses mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNextRequestHandler(t *testing.T) {
+	a, err := llmagent.New(llmagent.Config{
+		Name:        "testApp",
+		Model:       &testutil.MockModel{},
+		Instruction: "Be brief.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := session.NewEvent("inv")
+	event.Author = "user"
+	event.LLMResponse = model.LLMResponse{Content: &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{
+		genai.NewPartFromText("describe this"),
+		genai.NewPartFromBytes([]byte("png"), "image/png"),
+	}}}
+	if err := sessionService.AppendEvent(t.Context(), created.Session, event); err != nil {
+		t.Fatal(err)
+	}
+	controller := controllers.NewDebugAPIController(sessionService, agent.NewSingleLoader(a), nil)
+
+	for _, tc := range []struct {
+		name       string
+		sessionID  string
+		wantStatus int
+	}{
+		{name: "existing session", sessionID: "testSession", wantStatus: http.StatusOK},
+		{name: "missing session", sessionID: "missing", wantStatus: http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/next-request", nil)
+			req = mux.SetURLVars(req, map[string]string{
+				"app_name":   "testApp",
+				"user_id":    "testUser",
+				"session_id": tc.sessionID,
+			})
+			rw := httptest.NewRecorder()
+			controller.NextRequestHandler(rw, req)
+			if rw.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rw.Code, tc.wantStatus, rw.Body)
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			var got struct {
+				Agent   string `json:"agent"`
+				Request struct {
+					Contents []struct {
+						Parts []map[string]any `json:"parts"`
+					} `json:"content"`
+				} `json:"request"`
+			}
+			if err := json.Unmarshal(rw.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Agent != "testApp" {
+				t.Errorf("agent = %q, want %q", got.Agent, "testApp")
+			}
+			if len(got.Request.Contents) != 1 || len(got.Request.Contents[0].Parts) != 1 {
+				t.Errorf("contents = %+v, want the text part only", got.Request.Contents)
+			}
+		})
+	}
+}
+
+func TestTraceBatchHandler(t *testing.T) {
+	exporter := services.NewAPIServerSpanExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	for _, eventID := range []string{"e1", "e2"} {
+		_, span := tp.Tracer("test").Start(context.Background(), "call_llm", trace.WithAttributes(
+			attribute.String("gcp.vertex.agent.event_id", eventID),
+		))
+		span.End()
+	}
+	controller := controllers.NewDebugAPIController(session.InMemoryService(), nil, exporter)
+
+	for _, tc := range []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantTraces   []string
+		wantNotFound []string
+	}{
+		{
+			name:         "found and missing events",
+			body:         `{"eventIds": ["e1", "missing", "e2", "e1"]}`,
+			wantStatus:   http.StatusOK,
+			wantTraces:   []string{"e1", "e2"},
+			wantNotFound: []string{"missing"},
+		},
+		{
+			name:         "only missing events",
+			body:         `{"eventIds": ["missing"]}`,
+			wantStatus:   http.StatusOK,
+			wantTraces:   []string{},
+			wantNotFound: []string{"missing"},
+		},
+		{
+			name:       "no events",
+			body:       `{"eventIds": []}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "too many events",
+			body:       `{"eventIds": [` + strings.Repeat(`"e",`, models.MaxTraceBatchSize) + `"e"]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown field",
+			body:       `{"eventId": "e1"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/debug/trace/batch", strings.NewReader(tc.body))
+			rw := httptest.NewRecorder()
+			controller.TraceBatchHandler(rw, req)
+			if rw.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rw.Code, tc.wantStatus, rw.Body)
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			var got models.TraceBatchResponse
+			if err := json.Unmarshal(rw.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			gotTraces := []string{}
+			for _, eventID := range []string{"e1", "e2"} {
+				if spans, ok := got.Traces[eventID]; ok {
+					gotTraces = append(gotTraces, eventID)
+					if len(spans) != 1 || spans[0]["gcp.vertex.agent.event_id"] != eventID {
+						t.Errorf("traces[%q] = %v, want the call_llm span of the event", eventID, spans)
+					}
+				}
+			}
+			if diff := cmp.Diff(tc.wantTraces, gotTraces); diff != "" {
+				t.Errorf("events with traces mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantNotFound, got.NotFound); diff != "" {
+				t.Errorf("NotFound mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTraceDiffHandler(t *testing.T) {
+	exporter := services.NewAPIServerSpanExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	for eventID, attrs := range map[string][]attribute.KeyValue{
+		"before": {
+			attribute.String("gcp.vertex.agent.llm_request", "old prompt"),
+			attribute.String("gcp.vertex.agent.session_id", "s1"),
+			attribute.String("gcp.vertex.agent.invocation_id", "i1"),
+		},
+		"after": {
+			attribute.String("gcp.vertex.agent.llm_request", "new prompt"),
+			attribute.String("gcp.vertex.agent.session_id", "s1"),
+			attribute.Int("gcp.vertex.agent.grounded_tool_results", 2),
+		},
+	} {
+		_, span := tp.Tracer("test").Start(context.Background(), "call_llm", trace.WithAttributes(
+			append(attrs, attribute.String("gcp.vertex.agent.event_id", eventID))...,
+		))
+		span.End()
+	}
+	controller := controllers.NewDebugAPIController(session.InMemoryService(), nil, exporter)
+
+	for _, tc := range []struct {
+		name string
+		vars map[string]string
+		want models.TraceDiff
+		code int
+	}{
+		{
+			name: "changed, added and removed attributes",
+			vars: map[string]string{"event_id": "before", "other_event_id": "after"},
+			code: http.StatusOK,
+			want: models.TraceDiff{
+				Before: models.TraceDiffSide{EventID: "before", Status: models.TraceFound, Spans: 1},
+				After:  models.TraceDiffSide{EventID: "after", Status: models.TraceFound, Spans: 1},
+				Spans: []models.SpanDiff{{
+					Index:   0,
+					Added:   map[string]string{"gcp.vertex.agent.grounded_tool_results": "2"},
+					Removed: map[string]string{"gcp.vertex.agent.invocation_id": "i1"},
+					Changed: map[string]models.AttributeChange{
+						"gcp.vertex.agent.llm_request": {Old: "old prompt", New: "new prompt"},
+					},
+				}},
+			},
+		},
+		{
+			name: "identical traces",
+			vars: map[string]string{"event_id": "after", "other_event_id": "after"},
+			code: http.StatusOK,
+			want: models.TraceDiff{
+				Before: models.TraceDiffSide{EventID: "after", Status: models.TraceFound, Spans: 1},
+				After:  models.TraceDiffSide{EventID: "after", Status: models.TraceFound, Spans: 1},
+			},
+		},
+		{
+			name: "one event not found",
+			vars: map[string]string{"event_id": "before", "other_event_id": "missing"},
+			code: http.StatusOK,
+			want: models.TraceDiff{
+				Before: models.TraceDiffSide{EventID: "before", Status: models.TraceFound, Spans: 1},
+				After:  models.TraceDiffSide{EventID: "missing", Status: models.TraceNotFound},
+			},
+		},
+		{
+			name: "both events not found",
+			vars: map[string]string{"event_id": "missing1", "other_event_id": "missing2"},
+			code: http.StatusOK,
+			want: models.TraceDiff{
+				Before: models.TraceDiffSide{EventID: "missing1", Status: models.TraceNotFound},
+				After:  models.TraceDiffSide{EventID: "missing2", Status: models.TraceNotFound},
+			},
+		},
+		{
+			name: "missing parameter",
+			vars: map[string]string{"event_id": "before"},
+			code: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/debug/trace/diff", nil)
+			req = mux.SetURLVars(req, tc.vars)
+			rw := httptest.NewRecorder()
+			controller.TraceDiffHandler(rw, req)
+			if rw.Code != tc.code {
+				t.Fatalf("status = %d, want %d: %s", rw.Code, tc.code, rw.Body)
+			}
+			if tc.code != http.StatusOK {
+				return
+			}
+			var got models.TraceDiff
+			if err := json.Unmarshal(rw.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("TraceDiffHandler() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
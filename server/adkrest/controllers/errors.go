@@ -14,6 +14,11 @@
 
 package controllers
 
+import (
+	"errors"
+	"net/http"
+)
+
 type statusError struct {
 	Err  error
 	Code int
@@ -32,3 +37,13 @@ func (se statusError) Error() string {
 func (se statusError) Status() int {
 	return se.Code
 }
+
+// decodeStatus returns the status code for an error decoding a request body:
+// 413 if the body exceeds the size limit, 400 otherwise.
+func decodeStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
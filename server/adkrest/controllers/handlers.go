@@ -22,16 +22,43 @@ import (
 
 // TODO: Move to an internal package, controllers doesn't have to be public API.
 
+// JSONOptions configures how responses are encoded to JSON.
+type JSONOptions struct {
+	// EscapeHTML escapes <, > and & in strings, which mangles URLs with query
+	// parameters.
+	EscapeHTML bool
+	// Indent pretty-prints the response with two-space indentation.
+	Indent bool
+}
+
+var (
+	// DefaultJSONOptions are the options of the main API responses.
+	DefaultJSONOptions = JSONOptions{EscapeHTML: true}
+	// DebugJSONOptions are the options of the debug endpoints responses,
+	// meant to be read by humans.
+	DebugJSONOptions = JSONOptions{Indent: true}
+)
+
 // EncodeJSONResponse uses the json encoder to write an interface to the http response with an optional status code
 func EncodeJSONResponse(i any, status int, w http.ResponseWriter) {
+	EncodeJSONResponseWithOptions(i, status, w, DefaultJSONOptions)
+}
+
+// EncodeJSONResponseWithOptions is like EncodeJSONResponse, encoding the
+// response with the given options.
+func EncodeJSONResponseWithOptions(i any, status int, w http.ResponseWriter, opts JSONOptions) {
 	wHeader := w.Header()
 	wHeader.Set("Content-Type", "application/json; charset=UTF-8")
 
 	w.WriteHeader(status)
 
 	if i != nil {
-		err := json.NewEncoder(w).Encode(i)
-		if err != nil {
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(opts.EscapeHTML)
+		if opts.Indent {
+			enc.SetIndent("", "  ")
+		}
+		if err := enc.Encode(i); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
 	}
@@ -0,0 +1,52 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/adk/server/adkrest/controllers"
+)
+
+func TestEncodeJSONResponseWithOptions(t *testing.T) {
+	resp := map[string]string{"url": "https://example.com/?a=1&b=2"}
+	for _, tc := range []struct {
+		name string
+		opts controllers.JSONOptions
+		want string
+	}{
+		{
+			name: "default",
+			opts: controllers.DefaultJSONOptions,
+			want: `{"url":"https://example.com/?a=1\u0026b=2"}` + "\n",
+		},
+		{
+			name: "debug",
+			opts: controllers.DebugJSONOptions,
+			want: "{\n  \"url\": \"https://example.com/?a=1&b=2\"\n}\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			controllers.EncodeJSONResponseWithOptions(resp, 200, rr, tc.opts)
+			if diff := cmp.Diff(tc.want, rr.Body.String()); diff != "" {
+				t.Errorf("EncodeJSONResponseWithOptions() body mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
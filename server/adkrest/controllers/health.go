@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/internal/clock"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/server/adkrest/internal/models"
+)
+
+// HealthAPIController is the controller for the readiness endpoint.
+type HealthAPIController struct {
+	models   []model.LLM
+	cacheFor time.Duration
+	clock    runner.Clock
+
+	mu     sync.Mutex
+	lastOK map[string]time.Time
+}
+
+// HealthOption configures the controller returned by
+// [NewHealthAPIController].
+type HealthOption func(*HealthAPIController)
+
+// WithHealthClock sets the clock telling the times of the pings and the age
+// of the cached ones. Defaults to the system clock.
+func WithHealthClock(clk runner.Clock) HealthOption {
+	return func(c *HealthAPIController) {
+		c.clock = clk
+	}
+}
+
+// NewHealthAPIController creates the controller for the readiness endpoint,
+// which pings the backends of the models with model.Ping. A successful ping
+// is reused for cacheFor so that frequent probes do not hammer the providers;
+// failed pings are not cached.
+func NewHealthAPIController(llms []model.LLM, cacheFor time.Duration, opts ...HealthOption) *HealthAPIController {
+	c := &HealthAPIController{models: llms, cacheFor: cacheFor, clock: clock.Real(), lastOK: make(map[string]time.Time)}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ReadyHandler reports whether the backends of all the models are reachable,
+// with 200 OK, or 503 Service Unavailable if any of them is not.
+func (c *HealthAPIController) ReadyHandler(rw http.ResponseWriter, req *http.Request) {
+	results := make([]models.ModelHealth, len(c.models))
+	var wg sync.WaitGroup
+	for i, m := range c.models {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.check(req, m)
+		}()
+	}
+	wg.Wait()
+
+	resp := models.Readiness{Ready: true, Models: results}
+	status := http.StatusOK
+	for _, r := range results {
+		if !r.Ready {
+			resp.Ready = false
+			status = http.StatusServiceUnavailable
+		}
+	}
+	EncodeJSONResponse(resp, status, rw)
+}
+
+// check returns the health of the backend of the model, pinging it unless
+// the last successful ping is recent enough.
+func (c *HealthAPIController) check(req *http.Request, m model.LLM) models.ModelHealth {
+	name := m.Name()
+	c.mu.Lock()
+	last, ok := c.lastOK[name]
+	c.mu.Unlock()
+	now := c.clock.Now()
+	if ok && now.Sub(last) < c.cacheFor {
+		return models.ModelHealth{Name: name, Ready: true, CheckedAt: last}
+	}
+
+	if err := model.Ping(req.Context(), m); err != nil {
+		return models.ModelHealth{Name: name, Error: err.Error(), CheckedAt: now}
+	}
+	c.mu.Lock()
+	c.lastOK[name] = now
+	c.mu.Unlock()
+	return models.ModelHealth{Name: name, Ready: true, CheckedAt: now}
+}
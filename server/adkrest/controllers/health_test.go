@@ -0,0 +1,117 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/server/adkrest/controllers"
+	"google.golang.org/adk/server/adkrest/internal/models"
+)
+
+// pingModel is a model whose pings fail with err.
+type pingModel struct {
+	name  string
+	err   error
+	pings atomic.Int32
+}
+
+func (m *pingModel) Name() string { return m.name }
+
+func (m *pingModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {}
+}
+
+func (m *pingModel) Ping(ctx context.Context) error {
+	m.pings.Add(1)
+	return m.err
+}
+
+func TestReadyHandler(t *testing.T) {
+	ok := &pingModel{name: "ok"}
+	expired := &pingModel{name: "expired", err: errors.New("credentials expired")}
+
+	for _, tc := range []struct {
+		name       string
+		models     []model.LLM
+		wantStatus int
+		want       models.Readiness
+	}{
+		{
+			name:       "no models",
+			wantStatus: http.StatusOK,
+			want:       models.Readiness{Ready: true, Models: []models.ModelHealth{}},
+		},
+		{
+			name:       "reachable",
+			models:     []model.LLM{ok},
+			wantStatus: http.StatusOK,
+			want:       models.Readiness{Ready: true, Models: []models.ModelHealth{{Name: "ok", Ready: true}}},
+		},
+		{
+			name:       "unreachable",
+			models:     []model.LLM{ok, expired},
+			wantStatus: http.StatusServiceUnavailable,
+			want: models.Readiness{Models: []models.ModelHealth{
+				{Name: "ok", Ready: true},
+				{Name: "expired", Error: "credentials expired"},
+			}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			controller := controllers.NewHealthAPIController(tc.models, time.Minute)
+			rr := httptest.NewRecorder()
+			controller.ReadyHandler(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			var got models.Readiness
+			if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreFields(models.ModelHealth{}, "CheckedAt")); diff != "" {
+				t.Errorf("readiness mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadyHandlerCachesSuccessfulPings(t *testing.T) {
+	ok := &pingModel{name: "ok"}
+	expired := &pingModel{name: "expired", err: errors.New("credentials expired")}
+	controller := controllers.NewHealthAPIController([]model.LLM{ok, expired}, time.Minute)
+	for range 3 {
+		controller.ReadyHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	}
+	if got := ok.pings.Load(); got != 1 {
+		t.Errorf("reachable model pinged %d times, want 1", got)
+	}
+	if got := expired.pings.Load(); got != 3 {
+		t.Errorf("unreachable model pinged %d times, want 3", got)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// idleTimer cancels a streaming run if no data is sent to the client for
+// the configured duration.
+type idleTimer struct {
+	timeout time.Duration
+	timer   *time.Timer
+	expired atomic.Bool
+}
+
+// startIdleTimer returns a context that is cancelled once the timer expires.
+// A non-positive timeout disables the timer. The returned cancel function
+// must be called to release the resources of the timer.
+func startIdleTimer(ctx context.Context, timeout time.Duration) (context.Context, *idleTimer, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	t := &idleTimer{timeout: timeout}
+	if timeout <= 0 {
+		return ctx, t, cancel
+	}
+	t.timer = time.AfterFunc(timeout, func() {
+		t.expired.Store(true)
+		cancel()
+	})
+	return ctx, t, func() {
+		t.timer.Stop()
+		cancel()
+	}
+}
+
+// touch records that data was sent and restarts the timer.
+func (t *idleTimer) touch() {
+	if t.timer != nil && !t.expired.Load() {
+		t.timer.Reset(t.timeout)
+	}
+}
+
+// Expired reports whether the run was cancelled because of the timeout.
+func (t *idleTimer) Expired() bool {
+	return t.expired.Load()
+}
+
+// flashIdleTimeout sends the final event telling the client that the run was
+// cancelled because the connection was idle.
+func (t *idleTimer) flashIdleTimeout(rc *http.ResponseController, rw http.ResponseWriter) error {
+	return flashData(rc, rw, map[string]string{
+		"error": fmt.Sprintf("no data sent for %v, the run was cancelled", t.timeout),
+	})
+}
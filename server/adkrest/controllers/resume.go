@@ -0,0 +1,189 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"time"
+
+	"google.golang.org/adk/server/adkrest/internal/models"
+	"google.golang.org/adk/session"
+)
+
+// resumePollInterval is how often a resumed stream checks the session for
+// the new events of a run still in progress.
+const resumePollInterval = 100 * time.Millisecond
+
+// resumeToken identifies the last event of a stream delivered to a client.
+// It is sent as the ID of each Server-Sent Event of a stored event.
+type resumeToken struct {
+	SessionID string `json:"s"`
+	// Index is the index of the event in the stored events of the session.
+	Index   int    `json:"i"`
+	EventID string `json:"e"`
+}
+
+func (t resumeToken) String() string {
+	data, err := json.Marshal(t)
+	if err != nil {
+		panic(err) // Can't happen: the token only has strings and ints.
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func parseResumeToken(s string) (resumeToken, error) {
+	var t resumeToken
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return t, fmt.Errorf("malformed resume token: %w", err)
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return t, fmt.Errorf("malformed resume token: %w", err)
+	}
+	if t.SessionID == "" || t.EventID == "" || t.Index < 0 {
+		return t, fmt.Errorf("malformed resume token")
+	}
+	return t, nil
+}
+
+// position returns the index of the event of the token in the stored events
+// of the session. The index of the token is checked first; the events are
+// searched if it doesn't match, e.g. because the persist policy of an agent
+// skipped some events of the stream.
+func (t resumeToken) position(events session.Events) (int, bool) {
+	if t.Index < events.Len() && events.At(t.Index).ID == t.EventID {
+		return t.Index, true
+	}
+	for i := range events.Len() {
+		if events.At(i).ID == t.EventID {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// requestResumeToken returns the resume token of the request: the one of
+// the body, or else the Last-Event-ID header sent by reconnecting
+// Server-Sent Events clients.
+func requestResumeToken(req *http.Request, runAgentRequest models.RunAgentRequest) string {
+	if runAgentRequest.ResumeToken != "" {
+		return runAgentRequest.ResumeToken
+	}
+	return req.Header.Get("Last-Event-ID")
+}
+
+// resumeSSE streams the stored events of the session after the event of the
+// token, then the events of the runs of the session still in progress until
+// they end. Superseded interim events are skipped; an interim event not yet
+// superseded is held back while runs are in progress, and sent once they end
+// if the response it belongs to was interrupted.
+func (c *RuntimeAPIController) resumeSSE(ctx context.Context, rc *http.ResponseController, rw http.ResponseWriter, runAgentRequest models.RunAgentRequest, rawToken string) error {
+	token, err := parseResumeToken(rawToken)
+	if err != nil {
+		return newStatusError(err, http.StatusBadRequest)
+	}
+	if token.SessionID != runAgentRequest.SessionId {
+		return newStatusError(fmt.Errorf("resume token is for another session"), http.StatusBadRequest)
+	}
+	sess, err := c.getSession(ctx, runAgentRequest.AppName, runAgentRequest.UserId, runAgentRequest.SessionId)
+	if err != nil {
+		return err
+	}
+	last, ok := token.position(sess.Events())
+	if !ok {
+		return newStatusError(fmt.Errorf("resume token does not match the session: event %q not found", token.EventID), http.StatusBadRequest)
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	next := last + 1
+	for {
+		// Check for runs before reading the events, so that the events of a
+		// run ending in between are still sent.
+		running := c.runs.Len(runAgentRequest.AppName, runAgentRequest.UserId, runAgentRequest.SessionId) > 0
+		events := sess.Events()
+		kept := keptEventIDs(events)
+		for ; next < events.Len(); next++ {
+			event := events.At(next)
+			if event.Interim && !kept[event.ID] {
+				continue
+			}
+			if event.Interim && running {
+				break
+			}
+			id := resumeToken{SessionID: sess.ID(), Index: next, EventID: event.ID}.String()
+			if err := flashEvent(rc, rw, *event, id); err != nil {
+				return err
+			}
+		}
+		if !running {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(resumePollInterval):
+		}
+		if sess, err = c.getSession(ctx, runAgentRequest.AppName, runAgentRequest.UserId, runAgentRequest.SessionId); err != nil {
+			return err
+		}
+	}
+}
+
+// keptEventIDs returns the IDs of the events kept by
+// session.ReconcileInterimEvents.
+func keptEventIDs(events session.Events) map[string]bool {
+	kept := make(map[string]bool, events.Len())
+	for ev := range session.ReconcileInterimEvents(events).All() {
+		kept[ev.ID] = true
+	}
+	return kept
+}
+
+// detach iterates seq in the background so that the run goes on if the
+// client of the stream disconnects. The returned sequence ends with the run
+// or once its consumer stops; the rest of the run is then drained, which
+// stores its events in the session. stop is called once the run ends.
+func detach(seq iter.Seq2[*session.Event, error], idle *idleTimer, stop func()) iter.Seq2[*session.Event, error] {
+	type item struct {
+		event *session.Event
+		err   error
+	}
+	items := make(chan item)
+	gone := make(chan struct{})
+	go func() {
+		defer stop()
+		defer close(items)
+		for event, err := range seq {
+			idle.touch()
+			select {
+			case items <- item{event, err}:
+			case <-gone:
+			}
+		}
+	}()
+	return func(yield func(*session.Event, error) bool) {
+		defer close(gone)
+		for it := range items {
+			if !yield(it.event, it.err) {
+				return
+			}
+		}
+	}
+}
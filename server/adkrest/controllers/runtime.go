@@ -17,28 +17,135 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"time"
 
+	"github.com/gorilla/mux"
+	"google.golang.org/genai"
+
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/model"
 	"google.golang.org/adk/runner"
 	"google.golang.org/adk/server/adkrest/internal/models"
+	"google.golang.org/adk/server/adkrest/internal/services"
 	"google.golang.org/adk/session"
 )
 
 // RuntimeAPIController is the controller for the Runtime API.
 type RuntimeAPIController struct {
-	sseTimeout      time.Duration
-	sessionService  session.Service
-	artifactService artifact.Service
-	agentLoader     agent.Loader
+	sseTimeout          time.Duration
+	idleTimeout         time.Duration
+	sessionService      session.Service
+	artifactService     artifact.Service
+	agentLoader         agent.Loader
+	limiter             runner.ConcurrencyLimiter
+	runTimeout          time.Duration
+	sessionLocker       runner.SessionLocker
+	modelOverrides      []model.LLM
+	agentPools          *runner.AgentPools
+	maxBatchInputs      int
+	maxBatchConcurrency int
+	clock               runner.Clock
+	runs                *services.ActiveRuns
+}
+
+const (
+	// DefaultMaxBatchInputs is the default maximum number of inputs of a
+	// batch run request.
+	DefaultMaxBatchInputs = 100
+	// DefaultMaxBatchConcurrency is the default maximum concurrency of a
+	// batch run request.
+	DefaultMaxBatchConcurrency = 16
+)
+
+// RuntimeOption configures the controller returned by
+// [NewRuntimeAPIController].
+type RuntimeOption func(*RuntimeAPIController)
+
+// WithIdleTimeout cancels streaming runs if no data is sent to the client
+// for d. Zero, the default, disables the idle timeout.
+func WithIdleTimeout(d time.Duration) RuntimeOption {
+	return func(c *RuntimeAPIController) {
+		c.idleTimeout = d
+	}
+}
+
+// WithConcurrencyLimiter limits the number of concurrent runs per app and
+// user; rejected runs fail with 429 Too Many Requests.
+func WithConcurrencyLimiter(l runner.ConcurrencyLimiter) RuntimeOption {
+	return func(c *RuntimeAPIController) {
+		c.limiter = l
+	}
+}
+
+// WithRunTimeout ends runs exceeding d, or the shorter timeout of the
+// request, with an event with the RUN_TIMEOUT error code. Zero, the
+// default, disables the run timeout.
+func WithRunTimeout(d time.Duration) RuntimeOption {
+	return func(c *RuntimeAPIController) {
+		c.runTimeout = d
+	}
+}
+
+// WithSessionLocker serializes the runs of each session; runs the locker
+// rejects fail with 409 Conflict.
+func WithSessionLocker(l runner.SessionLocker) RuntimeOption {
+	return func(c *RuntimeAPIController) {
+		c.sessionLocker = l
+	}
+}
+
+// WithModelOverrides lets requests replace the models of their agents with
+// the given models only; requests naming other models fail with 400 Bad
+// Request.
+func WithModelOverrides(models ...model.LLM) RuntimeOption {
+	return func(c *RuntimeAPIController) {
+		c.modelOverrides = models
+	}
+}
+
+// WithAgentPools limits the number of concurrent runs of each entry agent;
+// rejected runs fail with 429 Too Many Requests.
+func WithAgentPools(p *runner.AgentPools) RuntimeOption {
+	return func(c *RuntimeAPIController) {
+		c.agentPools = p
+	}
+}
+
+// WithBatchLimits limits the number of inputs and the concurrency of batch
+// run requests; requests above them fail with 400 Bad Request. Non-positive
+// values keep DefaultMaxBatchInputs and DefaultMaxBatchConcurrency.
+func WithBatchLimits(maxInputs, maxConcurrency int) RuntimeOption {
+	return func(c *RuntimeAPIController) {
+		if maxInputs > 0 {
+			c.maxBatchInputs = maxInputs
+		}
+		if maxConcurrency > 0 {
+			c.maxBatchConcurrency = maxConcurrency
+		}
+	}
+}
+
+// WithClock sets the clock of the runs, see runner.Config.Clock. Defaults to
+// the system clock.
+func WithClock(clk runner.Clock) RuntimeOption {
+	return func(c *RuntimeAPIController) {
+		c.clock = clk
+	}
 }
 
 // NewRuntimeAPIController creates the controller for the Runtime API.
-func NewRuntimeAPIController(sessionService session.Service, agentLoader agent.Loader, artifactService artifact.Service, sseTimeout time.Duration) *RuntimeAPIController {
-	return &RuntimeAPIController{sessionService: sessionService, agentLoader: agentLoader, artifactService: artifactService, sseTimeout: sseTimeout}
+func NewRuntimeAPIController(sessionService session.Service, agentLoader agent.Loader, artifactService artifact.Service, sseTimeout time.Duration, opts ...RuntimeOption) *RuntimeAPIController {
+	c := &RuntimeAPIController{sessionService: sessionService, agentLoader: agentLoader, artifactService: artifactService, sseTimeout: sseTimeout, maxBatchInputs: DefaultMaxBatchInputs, maxBatchConcurrency: DefaultMaxBatchConcurrency, runs: services.NewActiveRuns()}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // RunAgent executes a non-streaming agent run for a given session and message.
@@ -61,7 +168,7 @@ func (c *RuntimeAPIController) RunHandler(rw http.ResponseWriter, req *http.Requ
 
 // RunAgent executes a non-streaming agent run for a given session and message.
 func (c *RuntimeAPIController) runAgent(ctx context.Context, runAgentRequest models.RunAgentRequest) ([]*session.Event, error) {
-	err := c.validateSessionExists(ctx, runAgentRequest.AppName, runAgentRequest.UserId, runAgentRequest.SessionId)
+	_, err := c.getSession(ctx, runAgentRequest.AppName, runAgentRequest.UserId, runAgentRequest.SessionId)
 	if err != nil {
 		return nil, err
 	}
@@ -71,12 +178,14 @@ func (c *RuntimeAPIController) runAgent(ctx context.Context, runAgentRequest mod
 		return nil, err
 	}
 
+	ctx, done := c.runs.Start(ctx, runAgentRequest.AppName, runAgentRequest.UserId, runAgentRequest.SessionId)
+	defer done()
 	resp := r.Run(ctx, runAgentRequest.UserId, runAgentRequest.SessionId, &runAgentRequest.NewMessage, *rCfg)
 
 	var events []*session.Event
 	for event, err := range resp {
 		if err != nil {
-			return nil, newStatusError(fmt.Errorf("failed to run agent: %w", err), http.StatusInternalServerError)
+			return nil, newStatusError(fmt.Errorf("failed to run agent: %w", err), runErrorStatus(err))
 		}
 		events = append(events, event)
 	}
@@ -84,6 +193,21 @@ func (c *RuntimeAPIController) runAgent(ctx context.Context, runAgentRequest mod
 }
 
 // RunSSEHandler executes an agent run and streams the resulting events using Server-Sent Events (SSE).
+//
+// Each event stored in the session is sent with its resume token as the ID
+// of the Server-Sent Event. Partial events, and events the persist policy of
+// their author skips, are sent without an ID, so that the Last-Event-ID of a
+// client stays the token of the last stored event. A client that lost the stream
+// resumes it by sending the token of the last event it received, as the
+// resumeToken of the request or the Last-Event-ID header, instead of a new
+// message. The stored events after that event are then read from the
+// session, followed by those of the runs of the session still in progress.
+// Runs started with resumable go on when the client disconnects; other runs
+// are cancelled.
+//
+// Delivery is at-least-once: events received after the token was recorded
+// are sent again, so clients should skip events whose ID they have already
+// seen. Events that are not stored are not sent again.
 func (c *RuntimeAPIController) RunSSEHandler(rw http.ResponseWriter, req *http.Request) error {
 	rw.Header().Set("Content-Type", "text/event-stream")
 	rw.Header().Set("Cache-Control", "no-cache")
@@ -102,20 +226,58 @@ func (c *RuntimeAPIController) RunSSEHandler(rw http.ResponseWriter, req *http.R
 		return err
 	}
 
-	err = c.validateSessionExists(req.Context(), runAgentRequest.AppName, runAgentRequest.UserId, runAgentRequest.SessionId)
+	if token := requestResumeToken(req, runAgentRequest); token != "" {
+		return c.resumeSSE(req.Context(), rc, rw, runAgentRequest, token)
+	}
+
+	sess, err := c.getSession(req.Context(), runAgentRequest.AppName, runAgentRequest.UserId, runAgentRequest.SessionId)
 	if err != nil {
 		return err
 	}
+	// next is the index of the next stored event of the session. The run
+	// stores the user message, and may commit interim events of partial
+	// ones, without sending them, so next is read from the session once the
+	// run stored its first event and after partial events.
+	next, synced := sess.Events().Len(), false
 
 	r, rCfg, err := c.getRunner(runAgentRequest)
 	if err != nil {
 		return err
 	}
 
-	resp := r.Run(req.Context(), runAgentRequest.UserId, runAgentRequest.SessionId, &runAgentRequest.NewMessage, *rCfg)
+	runCtx := req.Context()
+	if runAgentRequest.Resumable {
+		runCtx = context.WithoutCancel(runCtx)
+	}
+	ctx, done := c.runs.Start(runCtx, runAgentRequest.AppName, runAgentRequest.UserId, runAgentRequest.SessionId)
+	ctx, idle, cancel := startIdleTimer(ctx, c.idleTimeout)
+	stop := func() {
+		cancel()
+		done()
+	}
+	resp := r.Run(ctx, runAgentRequest.UserId, runAgentRequest.SessionId, &runAgentRequest.NewMessage, *rCfg)
+	if runAgentRequest.Resumable {
+		resp = detach(resp, idle, stop)
+	} else {
+		defer stop()
+	}
 
-	rw.WriteHeader(http.StatusOK)
+	// The status is written with the first event, so that runs rejected by
+	// the concurrency limiter, the agent pools or the session locker fail with
+	// 429 or 409.
+	started := false
 	for event, err := range resp {
+		if !started {
+			if errors.Is(err, runner.ErrTooManyRuns) || errors.Is(err, runner.ErrAgentBusy) || errors.Is(err, runner.ErrSessionBusy) {
+				return newStatusError(fmt.Errorf("failed to run agent: %w", err), runErrorStatus(err))
+			}
+			rw.WriteHeader(http.StatusOK)
+			started = true
+		}
+		if idle.Expired() {
+			return idle.flashIdleTimeout(rc, rw)
+		}
+		idle.touch()
 		if err != nil {
 			_, err := fmt.Fprintf(rw, "Error while running agent: %v\n", err)
 			if err != nil {
@@ -128,20 +290,162 @@ func (c *RuntimeAPIController) RunSSEHandler(rw http.ResponseWriter, req *http.R
 
 			continue
 		}
-		err := flashEvent(rc, rw, *event)
+		var id string
+		if r.Stored(event) {
+			if !synced {
+				next = c.storedIndex(req.Context(), runAgentRequest, event.ID, next)
+				synced = true
+			}
+			id = resumeToken{SessionID: sess.ID(), Index: next, EventID: event.ID}.String()
+			next++
+		} else if event.Partial && rCfg.InterimCommitEvery > 0 {
+			synced = false
+		}
+		err := flashEvent(rc, rw, *event, id)
 		if err != nil {
 			return err
 		}
 	}
+	if idle.Expired() {
+		return idle.flashIdleTimeout(rc, rw)
+	}
 	return nil
 }
 
-func flashEvent(rc *http.ResponseController, rw http.ResponseWriter, event session.Event) error {
+// storedIndex returns the index of the stored event in the events of the
+// session, searched from the last one, or guess if it is not found. The run
+// stores its events before sending them.
+func (c *RuntimeAPIController) storedIndex(ctx context.Context, runAgentRequest models.RunAgentRequest, eventID string, guess int) int {
+	sess, err := c.getSession(ctx, runAgentRequest.AppName, runAgentRequest.UserId, runAgentRequest.SessionId)
+	if err != nil {
+		return guess
+	}
+	events := sess.Events()
+	for i := events.Len() - 1; i >= 0; i-- {
+		if events.At(i).ID == eventID {
+			return i
+		}
+	}
+	return guess
+}
+
+// RunBatchHandler runs an agent over a list of inputs with bounded concurrency
+// and streams one result per input using Server-Sent Events (SSE). A failed
+// input is reported in its result and does not fail the batch. Requests with
+// more inputs or a higher concurrency than the limits of the controller fail
+// with 400 Bad Request; see WithBatchLimits.
+func (c *RuntimeAPIController) RunBatchHandler(rw http.ResponseWriter, req *http.Request) error {
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+
+	// set custom deadlines for this request - it overrides server-wide timeouts
+	rc := http.NewResponseController(rw)
+	err := rc.SetWriteDeadline(time.Now().Add(c.sseTimeout))
+	if err != nil {
+		return newStatusError(fmt.Errorf("failed to set write deadline: %w", err), http.StatusInternalServerError)
+	}
+
+	var batchRequest models.RunBatchRequest
+	defer req.Body.Close()
+	d := json.NewDecoder(req.Body)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&batchRequest); err != nil {
+		return newStatusError(fmt.Errorf("failed to decode request: %w", err), decodeStatus(err))
+	}
+	if err := batchRequest.AssertRunBatchRequestRequired(); err != nil {
+		return newStatusError(err, http.StatusBadRequest)
+	}
+	if n := len(batchRequest.Inputs); n > c.maxBatchInputs {
+		return newStatusError(fmt.Errorf("batch has %d inputs, the maximum is %d", n, c.maxBatchInputs), http.StatusBadRequest)
+	}
+	if batchRequest.Concurrency > c.maxBatchConcurrency {
+		return newStatusError(fmt.Errorf("concurrency %d exceeds the maximum of %d", batchRequest.Concurrency, c.maxBatchConcurrency), http.StatusBadRequest)
+	}
+
+	r, err := c.newRunner(batchRequest.AppName)
+	if err != nil {
+		return err
+	}
+
+	inputs := make([]runner.BatchInput, len(batchRequest.Inputs))
+	for i, in := range batchRequest.Inputs {
+		inputs[i] = runner.BatchInput{
+			UserID:    batchRequest.UserId,
+			SessionID: in.SessionId,
+			Message:   &in.NewMessage,
+		}
+	}
+
+	ctx, idle, cancel := startIdleTimer(req.Context(), c.idleTimeout)
+	defer cancel()
+
+	rw.WriteHeader(http.StatusOK)
+	completed := 0
+	for res := range r.RunBatch(ctx, inputs, runner.BatchConfig{
+		Concurrency: batchRequest.Concurrency,
+		RunConfig:   agent.RunConfig{Labels: batchRequest.Labels},
+	}) {
+		if idle.Expired() {
+			return idle.flashIdleTimeout(rc, rw)
+		}
+		idle.touch()
+		completed++
+		result := models.RunBatchResult{
+			Index:     res.Index,
+			SessionId: res.SessionID,
+			Completed: completed,
+			Total:     len(inputs),
+		}
+		if res.FinalEvent != nil {
+			ev := models.FromSessionEvent(*res.FinalEvent)
+			result.FinalEvent = &ev
+		}
+		if res.Err != nil {
+			result.Error = res.Err.Error()
+		}
+		if err := flashData(rc, rw, result); err != nil {
+			return err
+		}
+	}
+	if idle.Expired() {
+		return idle.flashIdleTimeout(rc, rw)
+	}
+	return nil
+}
+
+// CancelSessionRunsHandler cancels all in-flight runs of a session started
+// with RunHandler or RunSSEHandler, and returns their number. Cancelling a
+// session without runs, e.g. again, cancels zero runs. Batch runs span
+// several sessions and are not cancelled.
+func (c *RuntimeAPIController) CancelSessionRunsHandler(rw http.ResponseWriter, req *http.Request) error {
+	sessionID, err := models.SessionIDFromHTTPParameters(mux.Vars(req))
+	if err != nil {
+		return newStatusError(err, http.StatusBadRequest)
+	}
+	n := c.runs.CancelSession(sessionID.AppName, sessionID.UserID, sessionID.ID)
+	EncodeJSONResponse(models.CancelRunsResponse{Cancelled: n}, http.StatusOK, rw)
+	return nil
+}
+
+// flashEvent writes the event as a single Server-Sent Event with the given
+// ID, if not empty, and flushes it.
+func flashEvent(rc *http.ResponseController, rw http.ResponseWriter, event session.Event, id string) error {
+	if id != "" {
+		if _, err := fmt.Fprintf(rw, "id: %s\n", id); err != nil {
+			return newStatusError(fmt.Errorf("failed to write response: %w", err), http.StatusInternalServerError)
+		}
+	}
+	return flashData(rc, rw, models.FromSessionEvent(event))
+}
+
+// flashData writes data as a single Server-Sent Event and flushes it.
+func flashData(rc *http.ResponseController, rw http.ResponseWriter, data any) error {
 	_, err := fmt.Fprintf(rw, "data: ")
 	if err != nil {
 		return newStatusError(fmt.Errorf("failed to write response: %w", err), http.StatusInternalServerError)
 	}
-	err = json.NewEncoder(rw).Encode(models.FromSessionEvent(event))
+	err = json.NewEncoder(rw).Encode(data)
 	if err != nil {
 		return newStatusError(fmt.Errorf("failed to encode response: %w", err), http.StatusInternalServerError)
 	}
@@ -156,33 +460,26 @@ func flashEvent(rc *http.ResponseController, rw http.ResponseWriter, event sessi
 	return nil
 }
 
-func (c *RuntimeAPIController) validateSessionExists(ctx context.Context, appName, userID, sessionID string) error {
-	_, err := c.sessionService.Get(ctx, &session.GetRequest{
+func (c *RuntimeAPIController) getSession(ctx context.Context, appName, userID, sessionID string) (session.Session, error) {
+	resp, err := c.sessionService.Get(ctx, &session.GetRequest{
 		AppName:   appName,
 		UserID:    userID,
 		SessionID: sessionID,
 	})
 	if err != nil {
-		return newStatusError(fmt.Errorf("failed to get session: %w", err), http.StatusNotFound)
+		return nil, newStatusError(fmt.Errorf("failed to get session: %w", err), http.StatusNotFound)
 	}
-	return nil
+	return resp.Session, nil
 }
 
 func (c *RuntimeAPIController) getRunner(req models.RunAgentRequest) (*runner.Runner, *agent.RunConfig, error) {
-	curAgent, err := c.agentLoader.LoadAgent(req.AppName)
+	timeout, err := req.RunTimeout(c.runTimeout)
 	if err != nil {
-		return nil, nil, newStatusError(fmt.Errorf("failed to load agent: %w", err), http.StatusInternalServerError)
+		return nil, nil, newStatusError(err, http.StatusBadRequest)
 	}
-
-	r, err := runner.New(runner.Config{
-		AppName:         req.AppName,
-		Agent:           curAgent,
-		SessionService:  c.sessionService,
-		ArtifactService: c.artifactService,
-	},
-	)
+	r, err := c.newRunner(req.AppName)
 	if err != nil {
-		return nil, nil, newStatusError(fmt.Errorf("failed to create runner: %w", err), http.StatusInternalServerError)
+		return nil, nil, err
 	}
 
 	streamingMode := agent.StreamingModeNone
@@ -191,18 +488,141 @@ func (c *RuntimeAPIController) getRunner(req models.RunAgentRequest) (*runner.Ru
 	}
 	return r, &agent.RunConfig{
 		StreamingMode: streamingMode,
+		Labels:        req.Labels,
+		Timeout:       timeout,
+		PhaseEvents:   req.PhaseEvents,
+
+		ModelOverride:          req.ModelOverride,
+		ModelOverrideSubAgents: req.ModelOverrideSubAgents,
+		GenerationOverrides:    req.RunGenerationOverrides(),
 	}, nil
 }
 
+func (c *RuntimeAPIController) newRunner(appName string) (*runner.Runner, error) {
+	curAgent, err := c.agentLoader.LoadAgent(appName)
+	if err != nil {
+		return nil, newStatusError(fmt.Errorf("failed to load agent: %w", err), http.StatusInternalServerError)
+	}
+
+	r, err := runner.New(runner.Config{
+		AppName:            appName,
+		Agent:              curAgent,
+		SessionService:     c.sessionService,
+		ArtifactService:    c.artifactService,
+		ConcurrencyLimiter: c.limiter,
+		SessionLocker:      c.sessionLocker,
+		RunTimeout:         c.runTimeout,
+		ModelOverrides:     c.modelOverrides,
+		AgentPools:         c.agentPools,
+		Clock:              c.clock,
+	},
+	)
+	if err != nil {
+		return nil, newStatusError(fmt.Errorf("failed to create runner: %w", err), http.StatusInternalServerError)
+	}
+	return r, nil
+}
+
+// runErrorStatus returns the status code for an error yielded by a run.
+func runErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, runner.ErrTooManyRuns), errors.Is(err, runner.ErrAgentBusy):
+		return http.StatusTooManyRequests
+	case errors.Is(err, runner.ErrSessionBusy):
+		return http.StatusConflict
+	case errors.Is(err, runner.ErrModelNotAllowed), errors.Is(err, runner.ErrInvalidGenerationOverrides):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// decodeRequestBody decodes the run request of the body according to its
+// content type:
+//   - application/json, the default: the request itself;
+//   - multipart/form-data: the request as JSON in the "request" field, and
+//     files whose contents are appended to the new message as inline data.
+//
+// Other content types fail with 415 Unsupported Media Type.
 func decodeRequestBody(req *http.Request) (decodedReq models.RunAgentRequest, err error) {
-	var runAgentRequest models.RunAgentRequest
 	defer func() {
-		err = req.Body.Close()
+		// Don't hide the decoding error.
+		if closeErr := req.Body.Close(); err == nil {
+			err = closeErr
+		}
 	}()
-	d := json.NewDecoder(req.Body)
+	mediaType := "application/json"
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return models.RunAgentRequest{}, newStatusError(fmt.Errorf("invalid content type: %w", err), http.StatusUnsupportedMediaType)
+		}
+	}
+	switch mediaType {
+	case "application/json":
+		return decodeRunAgentRequest(req.Body)
+	case "multipart/form-data":
+		return decodeMultipartRequest(req)
+	}
+	return models.RunAgentRequest{}, newStatusError(fmt.Errorf("unsupported content type %q, want application/json or multipart/form-data", mediaType), http.StatusUnsupportedMediaType)
+}
+
+func decodeRunAgentRequest(r io.Reader) (models.RunAgentRequest, error) {
+	var runAgentRequest models.RunAgentRequest
+	d := json.NewDecoder(r)
 	d.DisallowUnknownFields()
 	if err := d.Decode(&runAgentRequest); err != nil {
-		return runAgentRequest, newStatusError(fmt.Errorf("failed to decode request: %w", err), http.StatusBadRequest)
+		return runAgentRequest, newStatusError(fmt.Errorf("failed to decode request: %w", err), decodeStatus(err))
+	}
+	return runAgentRequest, nil
+}
+
+// decodeMultipartRequest decodes a multipart run request. The files are
+// appended to the new message in order, after the parts of the request.
+func decodeMultipartRequest(req *http.Request) (models.RunAgentRequest, error) {
+	mr, err := req.MultipartReader()
+	if err != nil {
+		return models.RunAgentRequest{}, newStatusError(fmt.Errorf("failed to read multipart request: %w", err), http.StatusBadRequest)
+	}
+	var (
+		runAgentRequest models.RunAgentRequest
+		hasRequest      bool
+		files           []*genai.Part
+	)
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return models.RunAgentRequest{}, newStatusError(fmt.Errorf("failed to read multipart request: %w", err), decodeStatus(err))
+		}
+		switch {
+		case part.FormName() == "request":
+			if runAgentRequest, err = decodeRunAgentRequest(part); err != nil {
+				return models.RunAgentRequest{}, err
+			}
+			hasRequest = true
+		case part.FileName() != "":
+			data, err := io.ReadAll(part)
+			if err != nil {
+				return models.RunAgentRequest{}, newStatusError(fmt.Errorf("failed to read file %q: %w", part.FileName(), err), decodeStatus(err))
+			}
+			mimeType := part.Header.Get("Content-Type")
+			if mimeType == "" || mimeType == "application/octet-stream" {
+				mimeType = http.DetectContentType(data)
+			}
+			files = append(files, genai.NewPartFromBytes(data, mimeType))
+		default:
+			return models.RunAgentRequest{}, newStatusError(fmt.Errorf("unexpected multipart field %q", part.FormName()), http.StatusBadRequest)
+		}
+	}
+	if !hasRequest {
+		return models.RunAgentRequest{}, newStatusError(errors.New("multipart request has no request field"), http.StatusBadRequest)
+	}
+	if len(files) > 0 {
+		if runAgentRequest.NewMessage.Role == "" {
+			runAgentRequest.NewMessage.Role = genai.RoleUser
+		}
+		runAgentRequest.NewMessage.Parts = append(runAgentRequest.NewMessage.Parts, files...)
 	}
 	return runAgentRequest, nil
 }
@@ -0,0 +1,910 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/mux"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/server/adkrest/controllers"
+	"google.golang.org/adk/server/adkrest/internal/models"
+	"google.golang.org/adk/session"
+)
+
+func TestRunSSEIdleTimeout(t *testing.T) {
+	cancelled := make(chan struct{})
+	a, err := agent.New(agent.Config{
+		Name: "testApp",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				ev := session.NewEvent(ctx.InvocationID())
+				ev.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText("first", genai.RoleModel)}
+				if !yield(ev, nil) {
+					return
+				}
+				// Hang until the run is cancelled.
+				<-ctx.Done()
+				close(cancelled)
+				yield(nil, ctx.Err())
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"}); err != nil {
+		t.Fatal(err)
+	}
+	controller := controllers.NewRuntimeAPIController(sessionService, agent.NewSingleLoader(a), nil, time.Minute, controllers.WithIdleTimeout(50*time.Millisecond))
+	srv := httptest.NewServer(controllers.NewErrorHandler(controller.RunSSEHandler))
+	defer srv.Close()
+
+	body, err := json.Marshal(models.RunAgentRequest{
+		AppName:    "testApp",
+		UserId:     "testUser",
+		SessionId:  "testSession",
+		NewMessage: *genai.NewContentFromText("hi", genai.RoleUser),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(srv.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("run was not cancelled after the idle timeout")
+	}
+	lines := strings.Split(strings.TrimSpace(string(got)), "\n\n")
+	if len(lines) != 2 {
+		t.Fatalf("got SSE stream %q, want the first event and the timeout event", got)
+	}
+	if !strings.Contains(lines[0], "first") {
+		t.Errorf("first SSE event = %q, want the agent event", lines[0])
+	}
+	if !strings.Contains(lines[1], `"error"`) || !strings.Contains(lines[1], "50ms") {
+		t.Errorf("last SSE event = %q, want the idle timeout error", lines[1])
+	}
+}
+
+func TestRunTooManyRuns(t *testing.T) {
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	a, err := agent.New(agent.Config{
+		Name: "testApp",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				close(started)
+				<-unblock
+				ev := session.NewEvent(ctx.InvocationID())
+				ev.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText("done", genai.RoleModel)}
+				yield(ev, nil)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	for _, id := range []string{"s1", "s2"} {
+		if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	limiter, err := runner.NewConcurrencyLimiter(runner.LimitConfig{MaxConcurrentRuns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	controller := controllers.NewRuntimeAPIController(sessionService, agent.NewSingleLoader(a), nil, time.Minute, controllers.WithConcurrencyLimiter(limiter))
+	runSrv := httptest.NewServer(controllers.NewErrorHandler(controller.RunHandler))
+	defer runSrv.Close()
+	sseSrv := httptest.NewServer(controllers.NewErrorHandler(controller.RunSSEHandler))
+	defer sseSrv.Close()
+
+	post := func(url, sessionID string) int {
+		body, err := json.Marshal(models.RunAgentRequest{
+			AppName:    "testApp",
+			UserId:     "testUser",
+			SessionId:  sessionID,
+			NewMessage: *genai.NewContentFromText("hi", genai.RoleUser),
+		})
+		if err != nil {
+			t.Error(err)
+			return 0
+		}
+		resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Error(err)
+			return 0
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode
+	}
+
+	first := make(chan int)
+	go func() { first <- post(runSrv.URL, "s1") }()
+	<-started
+
+	for name, url := range map[string]string{"run": runSrv.URL, "run_sse": sseSrv.URL} {
+		if got := post(url, "s2"); got != http.StatusTooManyRequests {
+			t.Errorf("%s: concurrent run status = %d, want %d", name, got, http.StatusTooManyRequests)
+		}
+	}
+	close(unblock)
+	if got := <-first; got != http.StatusOK {
+		t.Errorf("first run status = %d, want %d", got, http.StatusOK)
+	}
+}
+
+func TestRunTimeoutOfRequest(t *testing.T) {
+	var deadline time.Time
+	a, err := agent.New(agent.Config{
+		Name: "testApp",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				deadline, _ = ctx.Deadline()
+				ev := session.NewEvent(ctx.InvocationID())
+				ev.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText("done", genai.RoleModel)}
+				yield(ev, nil)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	controller := controllers.NewRuntimeAPIController(sessionService, agent.NewSingleLoader(a), nil, time.Minute, controllers.WithRunTimeout(time.Minute))
+	runSrv := httptest.NewServer(controllers.NewErrorHandler(controller.RunHandler))
+	defer runSrv.Close()
+	sseSrv := httptest.NewServer(controllers.NewErrorHandler(controller.RunSSEHandler))
+	defer sseSrv.Close()
+
+	post := func(url string, timeoutSeconds float64) int {
+		body, err := json.Marshal(models.RunAgentRequest{
+			AppName:        "testApp",
+			UserId:         "testUser",
+			SessionId:      "s1",
+			NewMessage:     *genai.NewContentFromText("hi", genai.RoleUser),
+			TimeoutSeconds: timeoutSeconds,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode
+	}
+
+	for name, url := range map[string]string{"run": runSrv.URL, "run_sse": sseSrv.URL} {
+		if got := post(url, -1); got != http.StatusBadRequest {
+			t.Errorf("%s: negative timeout status = %d, want %d", name, got, http.StatusBadRequest)
+		}
+	}
+	// Requests cannot extend the run timeout of the server.
+	start := time.Now()
+	if got := post(runSrv.URL, 3600); got != http.StatusOK {
+		t.Fatalf("run status = %d, want %d", got, http.StatusOK)
+	}
+	if deadline.IsZero() || deadline.After(start.Add(time.Minute+time.Second)) {
+		t.Errorf("run deadline = %v, want at most a minute after %v", deadline, start)
+	}
+}
+
+func TestCancelSessionRuns(t *testing.T) {
+	started := make(chan struct{})
+	a, err := agent.New(agent.Config{
+		Name: "testApp",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				close(started)
+				<-ctx.Done()
+				yield(nil, ctx.Err())
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	controller := controllers.NewRuntimeAPIController(sessionService, agent.NewSingleLoader(a), nil, time.Minute)
+	srv := httptest.NewServer(controllers.NewErrorHandler(controller.RunHandler))
+	defer srv.Close()
+
+	body, err := json.Marshal(models.RunAgentRequest{
+		AppName:    "testApp",
+		UserId:     "testUser",
+		SessionId:  "s1",
+		NewMessage: *genai.NewContentFromText("hi", genai.RoleUser),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		resp, err := http.Post(srv.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+	}()
+	<-started
+
+	cancel := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/apps/testApp/users/testUser/sessions/s1/cancel", nil)
+		req = mux.SetURLVars(req, map[string]string{"app_name": "testApp", "user_id": "testUser", "session_id": "s1"})
+		rr := httptest.NewRecorder()
+		controllers.NewErrorHandler(controller.CancelSessionRunsHandler)(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("CancelSessionRunsHandler() status = %d, want %d: %s", rr.Code, http.StatusOK, rr.Body)
+		}
+		var got models.CancelRunsResponse
+		if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		return got.Cancelled
+	}
+
+	if got := cancel(); got != 1 {
+		t.Errorf("first cancel cancelled %d runs, want 1", got)
+	}
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not end after being cancelled")
+	}
+	if got := cancel(); got != 0 {
+		t.Errorf("second cancel cancelled %d runs, want 0", got)
+	}
+}
+
+func TestRunSSEResume(t *testing.T) {
+	release := make(chan struct{})
+	a, err := agent.New(agent.Config{
+		Name: "testApp",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				for i, text := range []string{"one", "two", "three"} {
+					if i == 1 {
+						select {
+						case <-release:
+						case <-ctx.Done():
+							yield(nil, ctx.Err())
+							return
+						}
+					}
+					ev := session.NewEvent(ctx.InvocationID())
+					ev.Author = "testApp"
+					ev.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText(text, genai.RoleModel)}
+					if !yield(ev, nil) {
+						return
+					}
+				}
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	for _, id := range []string{"s1", "s2"} {
+		if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	controller := controllers.NewRuntimeAPIController(sessionService, agent.NewSingleLoader(a), nil, time.Minute)
+	srv := httptest.NewServer(controllers.NewErrorHandler(controller.RunSSEHandler))
+	defer srv.Close()
+
+	post := func(runReq models.RunAgentRequest, lastEventID string) *http.Response {
+		t.Helper()
+		body, err := json.Marshal(runReq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// Receive the first event, then drop the connection while the run goes on.
+	resp := post(models.RunAgentRequest{
+		AppName:    "testApp",
+		UserId:     "testUser",
+		SessionId:  "s1",
+		NewMessage: *genai.NewContentFromText("hi", genai.RoleUser),
+		Streaming:  true,
+		Resumable:  true,
+	}, "")
+	token, first := readSSEEvent(t, bufio.NewReader(resp.Body))
+	resp.Body.Close()
+	if token == "" {
+		t.Fatalf("first SSE event %q has no resume token", first)
+	}
+	if !strings.Contains(first, "one") {
+		t.Errorf("first SSE event = %q, want the first agent event", first)
+	}
+	close(release)
+
+	resp = post(models.RunAgentRequest{AppName: "testApp", UserId: "testUser", SessionId: "s1", ResumeToken: token}, "")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("resumed stream status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	var got []string
+	r := bufio.NewReader(resp.Body)
+	for {
+		id, data := readSSEEvent(t, r)
+		if data == "" {
+			break
+		}
+		if id == "" {
+			t.Errorf("resumed SSE event %q has no resume token", data)
+		}
+		var ev models.Event
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, ev.Content.Parts[0].Text)
+	}
+	if diff := cmp.Diff([]string{"two", "three"}, got); diff != "" {
+		t.Errorf("resumed events mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, tc := range []struct {
+		name        string
+		sessionID   string
+		lastEventID string
+	}{
+		{name: "malformed token", sessionID: "s1", lastEventID: "not a token"},
+		{name: "token of another session", sessionID: "s2", lastEventID: token},
+		{name: "unknown event", sessionID: "s1", lastEventID: "eyJzIjoiczEiLCJpIjoxLCJlIjoieCJ9"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := post(models.RunAgentRequest{AppName: "testApp", UserId: "testUser", SessionId: tc.sessionID}, tc.lastEventID)
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("resumed stream status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestRunSSEResumeTokenIndex(t *testing.T) {
+	a, err := agent.New(agent.Config{
+		Name: "testApp",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				for _, text := range []string{"one", "two"} {
+					ev := session.NewEvent(ctx.InvocationID())
+					ev.Author = "testApp"
+					ev.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText(text, genai.RoleModel)}
+					if !yield(ev, nil) {
+						return
+					}
+				}
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Events of an earlier run, including an interim one.
+	earlier := session.NewEvent("inv")
+	earlier.Author = "testApp"
+	earlier.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText("earlier", genai.RoleModel)}
+	for _, ev := range []*session.Event{earlier, session.NewInterimEvent("inv2", "testApp", "", "ea")} {
+		if err := sessionService.AppendEvent(t.Context(), created.Session, ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	controller := controllers.NewRuntimeAPIController(sessionService, agent.NewSingleLoader(a), nil, time.Minute)
+	srv := httptest.NewServer(controllers.NewErrorHandler(controller.RunSSEHandler))
+	defer srv.Close()
+
+	body, err := json.Marshal(models.RunAgentRequest{
+		AppName:    "testApp",
+		UserId:     "testUser",
+		SessionId:  "s1",
+		NewMessage: *genai.NewContentFromText("hi", genai.RoleUser),
+		Streaming:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(srv.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var tokens []string
+	r := bufio.NewReader(resp.Body)
+	for {
+		id, data := readSSEEvent(t, r)
+		if data == "" {
+			break
+		}
+		tokens = append(tokens, id)
+	}
+
+	if len(tokens) != 2 {
+		t.Fatalf("got %d SSE events, want 2", len(tokens))
+	}
+
+	got, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "testApp", UserID: "testUser", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := got.Session.Events()
+	// The events of the run follow the earlier ones and the user message.
+	for i, want := range []int{3, 4} {
+		data, err := base64.RawURLEncoding.DecodeString(tokens[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		var token struct {
+			Index   int    `json:"i"`
+			EventID string `json:"e"`
+		}
+		if err := json.Unmarshal(data, &token); err != nil {
+			t.Fatal(err)
+		}
+		if token.Index != want {
+			t.Errorf("index of the resume token of event %d = %d, want %d", i, token.Index, want)
+		}
+		if token.Index < events.Len() && events.At(token.Index).ID != token.EventID {
+			t.Errorf("stored event at the index of the resume token of event %d = %q, want %q", i, events.At(token.Index).ID, token.EventID)
+		}
+	}
+}
+
+func TestRunSSESkippedEvents(t *testing.T) {
+	// The phase events of the agent are not stored.
+	a, err := agent.New(agent.Config{
+		Name: "testApp",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				for _, kind := range []session.EventKind{session.EventKindModelText, session.EventKindPhase} {
+					ev := session.NewEvent(ctx.InvocationID())
+					ev.Author = "testApp"
+					ev.Kind = kind
+					ev.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText(string(kind), genai.RoleModel)}
+					if !yield(ev, nil) {
+						return
+					}
+				}
+			}
+		},
+		PersistedEvents: []session.EventKind{session.EventKindModelText},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	controller := controllers.NewRuntimeAPIController(sessionService, agent.NewSingleLoader(a), nil, time.Minute)
+	srv := httptest.NewServer(controllers.NewErrorHandler(controller.RunSSEHandler))
+	defer srv.Close()
+
+	post := func(runReq models.RunAgentRequest) *http.Response {
+		t.Helper()
+		body, err := json.Marshal(runReq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.Post(srv.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := post(models.RunAgentRequest{
+		AppName:    "testApp",
+		UserId:     "testUser",
+		SessionId:  "s1",
+		NewMessage: *genai.NewContentFromText("hi", genai.RoleUser),
+		Streaming:  true,
+	})
+	defer resp.Body.Close()
+	// lastEventID is the ID kept by a client, which events without an ID do
+	// not change.
+	var lastEventID string
+	r := bufio.NewReader(resp.Body)
+	for {
+		id, data := readSSEEvent(t, r)
+		if data == "" {
+			break
+		}
+		var ev models.Event
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			t.Fatal(err)
+		}
+		stored := ev.Content.Parts[0].Text == string(session.EventKindModelText)
+		if stored != (id != "") {
+			t.Errorf("SSE event %q has resume token %q, want one only for stored events", data, id)
+		}
+		if id != "" {
+			lastEventID = id
+		}
+	}
+
+	resp = post(models.RunAgentRequest{AppName: "testApp", UserId: "testUser", SessionId: "s1", ResumeToken: lastEventID})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("resumed stream status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestRunSSEResumeInterimEvents(t *testing.T) {
+	a, err := agent.New(agent.Config{
+		Name: "testApp",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				ev := session.NewEvent(ctx.InvocationID())
+				ev.Author = "testApp"
+				ev.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText("one", genai.RoleModel)}
+				yield(ev, nil)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	controller := controllers.NewRuntimeAPIController(sessionService, agent.NewSingleLoader(a), nil, time.Minute)
+	srv := httptest.NewServer(controllers.NewErrorHandler(controller.RunSSEHandler))
+	defer srv.Close()
+
+	post := func(runReq models.RunAgentRequest) *http.Response {
+		t.Helper()
+		body, err := json.Marshal(runReq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.Post(srv.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := post(models.RunAgentRequest{
+		AppName:    "testApp",
+		UserId:     "testUser",
+		SessionId:  "s1",
+		NewMessage: *genai.NewContentFromText("hi", genai.RoleUser),
+		Streaming:  true,
+	})
+	token, _ := readSSEEvent(t, bufio.NewReader(resp.Body))
+	resp.Body.Close()
+
+	// A response of another run superseding its interim event, then the
+	// interim event of an interrupted response.
+	final := session.NewEvent("inv")
+	final.Author = "testApp"
+	final.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText("two", genai.RoleModel)}
+	for _, ev := range []*session.Event{
+		session.NewInterimEvent("inv", "testApp", "", "tw"),
+		final,
+		session.NewInterimEvent("inv2", "testApp", "", "thr"),
+	} {
+		if err := sessionService.AppendEvent(t.Context(), created.Session, ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp = post(models.RunAgentRequest{AppName: "testApp", UserId: "testUser", SessionId: "s1", ResumeToken: token})
+	defer resp.Body.Close()
+	var got []string
+	r := bufio.NewReader(resp.Body)
+	for {
+		_, data := readSSEEvent(t, r)
+		if data == "" {
+			break
+		}
+		var ev models.Event
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, ev.Content.Parts[0].Text)
+	}
+	if diff := cmp.Diff([]string{"two", "thr"}, got); diff != "" {
+		t.Errorf("resumed events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// readSSEEvent reads the next Server-Sent Event of r and returns its ID and
+// data. The data is empty at the end of the stream.
+func readSSEEvent(t *testing.T, r *bufio.Reader) (id, data string) {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF {
+			return id, data
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			if data != "" {
+				return id, data
+			}
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestRunContentTypes(t *testing.T) {
+	// The agent describes the parts of the message it receives.
+	a, err := agent.New(agent.Config{
+		Name: "testApp",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				var parts []string
+				for _, p := range ctx.UserContent().Parts {
+					if p.InlineData != nil {
+						parts = append(parts, fmt.Sprintf("%s (%d bytes)", p.InlineData.MIMEType, len(p.InlineData.Data)))
+					} else {
+						parts = append(parts, p.Text)
+					}
+				}
+				ev := session.NewEvent(ctx.InvocationID())
+				ev.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText(strings.Join(parts, ", "), genai.RoleModel)}
+				yield(ev, nil)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	controller := controllers.NewRuntimeAPIController(sessionService, agent.NewSingleLoader(a), nil, time.Minute)
+	srv := httptest.NewServer(controllers.NewErrorHandler(controller.RunHandler))
+	defer srv.Close()
+
+	runRequest, err := json.Marshal(models.RunAgentRequest{
+		AppName:    "testApp",
+		UserId:     "testUser",
+		SessionId:  "s1",
+		NewMessage: *genai.NewContentFromText("describe", genai.RoleUser),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	png := []byte("\x89PNG\r\n\x1a\nimage data")
+	multipartBody := func(withRequest bool) (string, []byte) {
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		if withRequest {
+			if err := w.WriteField("request", string(runRequest)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		fw, err := w.CreateFormFile("files", "chart.png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write(png); err != nil {
+			t.Fatal(err)
+		}
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Disposition", `form-data; name="files"; filename="notes.csv"`)
+		h.Set("Content-Type", "text/csv")
+		fw, err = w.CreatePart(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(fw, "a,b\n1,2\n"); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return w.FormDataContentType(), buf.Bytes()
+	}
+
+	for _, tc := range []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantStatus  int
+		want        string
+	}{
+		{
+			name:        "json",
+			contentType: "application/json; charset=utf-8",
+			body:        runRequest,
+			wantStatus:  http.StatusOK,
+			want:        "describe",
+		},
+		{
+			name:       "json by default",
+			body:       runRequest,
+			wantStatus: http.StatusOK,
+			want:       "describe",
+		},
+		{
+			name:       "multipart with files",
+			wantStatus: http.StatusOK,
+			want:       fmt.Sprintf("describe, image/png (%d bytes), text/csv (8 bytes)", len(png)),
+		},
+		{
+			name:       "multipart without request",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "unsupported content type",
+			contentType: "text/plain",
+			body:        []byte("describe"),
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			contentType, body := tc.contentType, tc.body
+			if strings.HasPrefix(tc.name, "multipart") {
+				contentType, body = multipartBody(tc.name == "multipart with files")
+			}
+			req, err := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if contentType != "" {
+				req.Header.Set("Content-Type", contentType)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tc.wantStatus {
+				msg, _ := io.ReadAll(resp.Body)
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tc.wantStatus, msg)
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			var events []models.Event
+			if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != 1 {
+				t.Fatalf("got %d events, want 1", len(events))
+			}
+			if got := events[0].Content.Parts[0].Text; got != tc.want {
+				t.Errorf("agent saw %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunBatchLimits(t *testing.T) {
+	a, err := agent.New(agent.Config{
+		Name: "testApp",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	controller := controllers.NewRuntimeAPIController(session.InMemoryService(), agent.NewSingleLoader(a), nil, time.Minute, controllers.WithBatchLimits(2, 3))
+	srv := httptest.NewServer(controllers.NewErrorHandler(controller.RunBatchHandler))
+	defer srv.Close()
+
+	input := models.RunBatchInput{NewMessage: *genai.NewContentFromText("hi", genai.RoleUser)}
+	tests := []struct {
+		name        string
+		inputs      int
+		concurrency int
+		wantStatus  int
+	}{
+		{name: "within limits", inputs: 2, concurrency: 3, wantStatus: http.StatusOK},
+		{name: "too many inputs", inputs: 3, wantStatus: http.StatusBadRequest},
+		{name: "concurrency too high", inputs: 1, concurrency: 4, wantStatus: http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := models.RunBatchRequest{AppName: "testApp", UserId: "testUser", Concurrency: tc.concurrency}
+			for range tc.inputs {
+				req.Inputs = append(req.Inputs, input)
+			}
+			body, err := json.Marshal(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.Post(srv.URL, "application/json", bytes.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tc.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tc.wantStatus)
+			}
+		})
+	}
+}
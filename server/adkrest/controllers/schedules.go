@@ -0,0 +1,175 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"google.golang.org/adk/internal/clock"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/scheduler"
+	"google.golang.org/adk/server/adkrest/internal/models"
+)
+
+// SchedulesAPIController is the controller for the Schedules API, managing
+// the recurring runs of agents. The runs are started by a
+// scheduler.CronScheduler sharing the store.
+type SchedulesAPIController struct {
+	store scheduler.ScheduleStore
+	clock runner.Clock
+}
+
+// SchedulesOption configures the controller returned by
+// [NewSchedulesAPIController].
+type SchedulesOption func(*SchedulesAPIController)
+
+// WithSchedulesClock sets the clock telling the first run times of new
+// schedules. Defaults to the system clock.
+func WithSchedulesClock(clk runner.Clock) SchedulesOption {
+	return func(c *SchedulesAPIController) {
+		c.clock = clk
+	}
+}
+
+// NewSchedulesAPIController creates the controller for the Schedules API.
+func NewSchedulesAPIController(store scheduler.ScheduleStore, opts ...SchedulesOption) *SchedulesAPIController {
+	c := &SchedulesAPIController{store: store, clock: clock.Real()}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// CreateScheduleHandler creates a schedule for the user of the app. Its
+// first run is due at the next time matching its cron expression.
+func (c *SchedulesAPIController) CreateScheduleHandler(rw http.ResponseWriter, req *http.Request) error {
+	sessionID, err := models.SessionIDFromHTTPParameters(mux.Vars(req))
+	if err != nil {
+		return newStatusError(err, http.StatusBadRequest)
+	}
+	var createRequest models.CreateScheduleRequest
+	defer req.Body.Close()
+	d := json.NewDecoder(req.Body)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&createRequest); err != nil {
+		return newStatusError(fmt.Errorf("failed to decode request: %w", err), decodeStatus(err))
+	}
+	schedule := scheduler.Schedule{
+		ID:      uuid.NewString(),
+		AppName: sessionID.AppName,
+		UserID:  sessionID.UserID,
+		Cron:    createRequest.Cron,
+		Message: createRequest.Message,
+		Overlap: scheduler.OverlapPolicy(createRequest.Overlap),
+	}
+	if err := schedule.Validate(); err != nil {
+		return newStatusError(err, http.StatusBadRequest)
+	}
+	cron, err := scheduler.ParseCron(schedule.Cron)
+	if err != nil {
+		return newStatusError(err, http.StatusBadRequest)
+	}
+	schedule.NextRunAt = cron.Next(c.clock.Now().UTC())
+	if schedule.NextRunAt.IsZero() {
+		return newStatusError(fmt.Errorf("cron expression %q never matches", schedule.Cron), http.StatusBadRequest)
+	}
+	if err := c.store.CreateSchedule(req.Context(), schedule); err != nil {
+		return newStatusError(fmt.Errorf("failed to create schedule: %w", err), http.StatusInternalServerError)
+	}
+	EncodeJSONResponse(models.FromSchedule(schedule), http.StatusOK, rw)
+	return nil
+}
+
+// ListSchedulesHandler lists the schedules of the user of the app.
+func (c *SchedulesAPIController) ListSchedulesHandler(rw http.ResponseWriter, req *http.Request) error {
+	sessionID, err := models.SessionIDFromHTTPParameters(mux.Vars(req))
+	if err != nil {
+		return newStatusError(err, http.StatusBadRequest)
+	}
+	schedules, err := c.store.ListSchedules(req.Context(), sessionID.AppName, sessionID.UserID)
+	if err != nil {
+		return newStatusError(fmt.Errorf("failed to list schedules: %w", err), http.StatusInternalServerError)
+	}
+	resp := make([]models.Schedule, 0, len(schedules))
+	for _, schedule := range schedules {
+		resp = append(resp, models.FromSchedule(schedule))
+	}
+	EncodeJSONResponse(resp, http.StatusOK, rw)
+	return nil
+}
+
+// DeleteScheduleHandler deletes a schedule and the records of its runs.
+// Runs in progress are not cancelled.
+func (c *SchedulesAPIController) DeleteScheduleHandler(rw http.ResponseWriter, req *http.Request) error {
+	schedule, err := c.schedule(req)
+	if err != nil {
+		return err
+	}
+	if err := c.store.DeleteSchedule(req.Context(), schedule.ID); err != nil {
+		return scheduleStoreError(err)
+	}
+	EncodeJSONResponse(nil, http.StatusOK, rw)
+	return nil
+}
+
+// ListScheduleRunsHandler lists the recorded runs of a schedule, oldest
+// first.
+func (c *SchedulesAPIController) ListScheduleRunsHandler(rw http.ResponseWriter, req *http.Request) error {
+	schedule, err := c.schedule(req)
+	if err != nil {
+		return err
+	}
+	runs, err := c.store.ListRuns(req.Context(), schedule.ID)
+	if err != nil {
+		return scheduleStoreError(err)
+	}
+	resp := make([]models.ScheduleRun, 0, len(runs))
+	for _, run := range runs {
+		resp = append(resp, models.FromScheduleRun(run))
+	}
+	EncodeJSONResponse(resp, http.StatusOK, rw)
+	return nil
+}
+
+// schedule returns the schedule of the request. Schedules of other users or
+// apps are not found.
+func (c *SchedulesAPIController) schedule(req *http.Request) (scheduler.Schedule, error) {
+	vars := mux.Vars(req)
+	sessionID, err := models.SessionIDFromHTTPParameters(vars)
+	if err != nil {
+		return scheduler.Schedule{}, newStatusError(err, http.StatusBadRequest)
+	}
+	schedule, err := c.store.GetSchedule(req.Context(), vars["schedule_id"])
+	if err != nil {
+		return scheduler.Schedule{}, scheduleStoreError(err)
+	}
+	if schedule.AppName != sessionID.AppName || schedule.UserID != sessionID.UserID {
+		return scheduler.Schedule{}, scheduleStoreError(scheduler.ErrScheduleNotFound)
+	}
+	return schedule, nil
+}
+
+func scheduleStoreError(err error) error {
+	if errors.Is(err, scheduler.ErrScheduleNotFound) {
+		return newStatusError(err, http.StatusNotFound)
+	}
+	return newStatusError(err, http.StatusInternalServerError)
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/mux"
+
+	"google.golang.org/adk/scheduler"
+	"google.golang.org/adk/server/adkrest/controllers"
+	"google.golang.org/adk/server/adkrest/internal/models"
+)
+
+func TestSchedulesAPI(t *testing.T) {
+	store := scheduler.InMemoryScheduleStore()
+	controller := controllers.NewSchedulesAPIController(store)
+	serve := func(handler func(http.ResponseWriter, *http.Request) error, method, body string, vars map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, "/", strings.NewReader(body))
+		req = mux.SetURLVars(req, vars)
+		rr := httptest.NewRecorder()
+		controllers.NewErrorHandler(handler)(rr, req)
+		return rr
+	}
+	userVars := map[string]string{"app_name": "ops", "user_id": "alice"}
+
+	for _, tc := range []struct {
+		name string
+		body string
+	}{
+		{name: "invalid cron", body: `{"cron": "every hour", "message": "check"}`},
+		{name: "never matching cron", body: `{"cron": "0 0 30 2 *", "message": "check"}`},
+		{name: "missing message", body: `{"cron": "@hourly"}`},
+		{name: "unknown overlap policy", body: `{"cron": "@hourly", "message": "check", "overlap": "replace"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if rr := serve(controller.CreateScheduleHandler, http.MethodPost, tc.body, userVars); rr.Code != http.StatusBadRequest {
+				t.Errorf("CreateScheduleHandler() status = %d, want %d: %s", rr.Code, http.StatusBadRequest, rr.Body)
+			}
+		})
+	}
+
+	rr := serve(controller.CreateScheduleHandler, http.MethodPost, `{"cron": "@hourly", "message": "check status", "overlap": "queue"}`, userVars)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateScheduleHandler() status = %d, want %d: %s", rr.Code, http.StatusOK, rr.Body)
+	}
+	var created models.Schedule
+	if err := json.NewDecoder(rr.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == "" || created.NextRunAt.IsZero() || created.NextRunAt.Minute() != 0 {
+		t.Errorf("created schedule = %+v, want an ID and a next run on the hour", created)
+	}
+
+	list := func(vars map[string]string) []models.Schedule {
+		t.Helper()
+		rr := serve(controller.ListSchedulesHandler, http.MethodGet, "", vars)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("ListSchedulesHandler() status = %d, want %d: %s", rr.Code, http.StatusOK, rr.Body)
+		}
+		var got []models.Schedule
+		if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	if diff := cmp.Diff([]models.Schedule{created}, list(userVars)); diff != "" {
+		t.Errorf("ListSchedulesHandler() mismatch (-want +got):\n%s", diff)
+	}
+	if got := list(map[string]string{"app_name": "ops", "user_id": "bob"}); len(got) != 0 {
+		t.Errorf("ListSchedulesHandler() of another user = %v, want none", got)
+	}
+
+	if err := store.AddRun(t.Context(), scheduler.ScheduleRun{ScheduleID: created.ID, Status: scheduler.RunSucceeded, InvocationID: "e-1"}); err != nil {
+		t.Fatal(err)
+	}
+	scheduleVars := map[string]string{"app_name": "ops", "user_id": "alice", "schedule_id": created.ID}
+	rr = serve(controller.ListScheduleRunsHandler, http.MethodGet, "", scheduleVars)
+	var runs []models.ScheduleRun
+	if err := json.NewDecoder(rr.Body).Decode(&runs); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]models.ScheduleRun{{ScheduleID: created.ID, Status: "succeeded", InvocationID: "e-1"}}, runs); diff != "" {
+		t.Errorf("ListScheduleRunsHandler() mismatch (-want +got):\n%s", diff)
+	}
+
+	otherUserVars := map[string]string{"app_name": "ops", "user_id": "bob", "schedule_id": created.ID}
+	if rr := serve(controller.DeleteScheduleHandler, http.MethodDelete, "", otherUserVars); rr.Code != http.StatusNotFound {
+		t.Errorf("DeleteScheduleHandler() of another user status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+	if rr := serve(controller.DeleteScheduleHandler, http.MethodDelete, "", scheduleVars); rr.Code != http.StatusOK {
+		t.Errorf("DeleteScheduleHandler() status = %d, want %d: %s", rr.Code, http.StatusOK, rr.Body)
+	}
+	if rr := serve(controller.DeleteScheduleHandler, http.MethodDelete, "", scheduleVars); rr.Code != http.StatusNotFound {
+		t.Errorf("DeleteScheduleHandler() of a deleted schedule status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+	if got := list(userVars); len(got) != 0 {
+		t.Errorf("ListSchedulesHandler() after delete = %v, want none", got)
+	}
+}
@@ -16,8 +16,13 @@ package controllers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 
@@ -50,7 +55,7 @@ func (c *SessionsAPIController) CreateSessionHandler(rw http.ResponseWriter, req
 	if req.ContentLength > 0 {
 		err := json.NewDecoder(req.Body).Decode(&createSessionRequest)
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusBadRequest)
+			http.Error(rw, err.Error(), decodeStatus(err))
 			return
 		}
 	}
@@ -127,6 +132,12 @@ func (c *SessionsAPIController) GetSessionHandler(rw http.ResponseWriter, req *h
 		http.Error(rw, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	etag := sessionETag(storedSession.Session)
+	rw.Header().Set("ETag", etag)
+	if etagMatches(req.Header.Get("If-None-Match"), etag) {
+		rw.WriteHeader(http.StatusNotModified)
+		return
+	}
 	session, err := models.FromSession(storedSession.Session)
 	if err != nil {
 		http.Error(rw, err.Error(), http.StatusInternalServerError)
@@ -135,6 +146,58 @@ func (c *SessionsAPIController) GetSessionHandler(rw http.ResponseWriter, req *h
 	EncodeJSONResponse(session, http.StatusOK, rw)
 }
 
+// GetEventHandler returns the full content of a single event of a session.
+func (c *SessionsAPIController) GetEventHandler(rw http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	sessionID, err := models.SessionIDFromHTTPParameters(params)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	eventID := params["event_id"]
+	if sessionID.ID == "" || eventID == "" {
+		http.Error(rw, "session_id and event_id parameters are required", http.StatusBadRequest)
+		return
+	}
+	resp, err := session.GetEvent(req.Context(), c.service, &session.GetEventRequest{
+		AppName:   sessionID.AppName,
+		UserID:    sessionID.UserID,
+		SessionID: sessionID.ID,
+		EventID:   eventID,
+	})
+	if errors.Is(err, session.ErrEventNotFound) {
+		http.Error(rw, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	EncodeJSONResponse(models.FromSessionEvent(*resp.Event), http.StatusOK, rw)
+}
+
+// sessionETag returns an entity tag that changes whenever an event is
+// appended to the session.
+func sessionETag(s session.Session) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%d\x00%d", s.ID(), s.Events().Len(), s.LastUpdateTime().UnixNano())
+	if n := s.Events().Len(); n > 0 {
+		fmt.Fprintf(h, "\x00%s", s.Events().At(n-1).ID)
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// etagMatches reports whether the If-None-Match header value matches etag.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
 // ListSessions handles listing all sessions for a given app and user.
 func (c *SessionsAPIController) ListSessionsHandler(rw http.ResponseWriter, req *http.Request) {
 	params := mux.Vars(req)
@@ -27,10 +27,13 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/gorilla/mux"
+	"google.golang.org/genai"
 
+	"google.golang.org/adk/model"
 	"google.golang.org/adk/server/adkrest/controllers"
 	"google.golang.org/adk/server/adkrest/internal/fakes"
 	"google.golang.org/adk/server/adkrest/internal/models"
+	"google.golang.org/adk/session"
 )
 
 func TestGetSession(t *testing.T) {
@@ -39,6 +42,12 @@ func TestGetSession(t *testing.T) {
 		UserID:    "testUser",
 		SessionID: "testSession",
 	}
+	interim := session.NewInterimEvent("inv", "agent", "", "Hel")
+	interim.ID = "interim"
+	final := session.NewEvent("inv")
+	final.ID = "final"
+	final.Author = "agent"
+	final.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText("Hello", genai.RoleModel)}
 
 	tc := []struct {
 		name           string
@@ -71,6 +80,27 @@ func TestGetSession(t *testing.T) {
 			},
 			wantStatus: http.StatusOK,
 		},
+		{
+			name: "superseded interim events are left out",
+			storedSessions: map[fakes.SessionKey]fakes.TestSession{
+				id: {
+					Id:            id,
+					SessionState:  fakes.TestState{},
+					SessionEvents: fakes.TestEvents{interim, final},
+					UpdatedAt:     time.Now(),
+				},
+			},
+			sessionID: id,
+			wantSession: models.Session{
+				ID:        "testSession",
+				AppName:   "testApp",
+				UserID:    "testUser",
+				UpdatedAt: time.Now().Unix(),
+				Events:    []models.Event{models.FromSessionEvent(*final)},
+				State:     map[string]any{},
+			},
+			wantStatus: http.StatusOK,
+		},
 		{
 			name:           "session does not exist",
 			storedSessions: map[fakes.SessionKey]fakes.TestSession{},
@@ -150,6 +180,62 @@ func TestGetSession(t *testing.T) {
 	}
 }
 
+func TestGetSessionETag(t *testing.T) {
+	id := fakes.SessionKey{
+		AppName:   "testApp",
+		UserID:    "testUser",
+		SessionID: "testSession",
+	}
+	sessionService := fakes.FakeSessionService{Sessions: map[fakes.SessionKey]fakes.TestSession{
+		id: {
+			Id:            id,
+			SessionState:  fakes.TestState{},
+			SessionEvents: fakes.TestEvents{},
+			UpdatedAt:     time.Now(),
+		},
+	}}
+	apiController := controllers.NewSessionsAPIController(&sessionService)
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, "/apps/testApp/users/testUser/sessions/testSession", nil)
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		req = mux.SetURLVars(req, sessionVars(id))
+		rr := httptest.NewRecorder()
+		apiController.GetSessionHandler(rr, req)
+		return rr
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first GetSession() = (%d, ETag %q), want 200 with an ETag", first.Code, etag)
+	}
+
+	if rr := get(etag); rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("GetSession() with matching If-None-Match = (%d, %q), want 304 with empty body", rr.Code, rr.Body.String())
+	}
+	if rr := get(`"other", ` + etag); rr.Code != http.StatusNotModified {
+		t.Errorf("GetSession() with If-None-Match list = %d, want 304", rr.Code)
+	}
+
+	stored := sessionService.Sessions[id]
+	if err := sessionService.AppendEvent(t.Context(), &stored, &session.Event{ID: "newEvent", Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	rr := get(etag)
+	if rr.Code != http.StatusOK {
+		t.Errorf("GetSession() after AppendEvent = %d, want 200", rr.Code)
+	}
+	if got := rr.Header().Get("ETag"); got == etag {
+		t.Errorf("ETag did not change after AppendEvent: %q", got)
+	}
+}
+
 func TestCreateSession(t *testing.T) {
 	id := fakes.SessionKey{
 		AppName:   "testApp",
@@ -209,6 +295,7 @@ func TestCreateSession(t *testing.T) {
 						ID:     "eventID",
 						Author: "testUser",
 						Time:   time.Now().Add(5 * time.Minute).Unix(),
+						Kind:   "model_text",
 					},
 				},
 			},
@@ -456,3 +543,72 @@ func EquateApproxInt(margin int64) cmp.Option {
 		return diff <= margin
 	})
 }
+
+func TestGetEvent(t *testing.T) {
+	id := fakes.SessionKey{
+		AppName:   "testApp",
+		UserID:    "testUser",
+		SessionID: "testSession",
+	}
+	event := &session.Event{
+		ID:        "e1",
+		Author:    "agent",
+		Timestamp: time.Unix(1700000000, 0),
+		LLMResponse: model.LLMResponse{Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+			genai.NewPartFromText("checking"),
+			genai.NewPartFromFunctionCall("get_weather", map[string]any{"city": "Paris"}),
+		}}},
+	}
+	sessionService := fakes.FakeSessionService{Sessions: map[fakes.SessionKey]fakes.TestSession{
+		id: {
+			Id:            id,
+			SessionState:  fakes.TestState{},
+			SessionEvents: fakes.TestEvents{event},
+			UpdatedAt:     time.Now(),
+		},
+	}}
+	apiController := controllers.NewSessionsAPIController(&sessionService)
+
+	for _, tt := range []struct {
+		name       string
+		eventID    string
+		want       models.Event
+		wantStatus int
+	}{
+		{
+			name:       "event exists",
+			eventID:    "e1",
+			want:       models.FromSessionEvent(*event),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "event does not exist",
+			eventID:    "e2",
+			wantStatus: http.StatusNotFound,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/apps/testApp/users/testUser/sessions/testSession/events/"+tt.eventID, nil)
+			vars := sessionVars(id)
+			vars["event_id"] = tt.eventID
+			req = mux.SetURLVars(req, vars)
+			rr := httptest.NewRecorder()
+
+			apiController.GetEventHandler(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got models.Event
+			if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("GetEvent() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -19,32 +19,236 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/propagation"
 
 	"google.golang.org/adk/cmd/launcher"
+	"google.golang.org/adk/internal/clock"
 	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/scheduler"
 	"google.golang.org/adk/server/adkrest/controllers"
 	"google.golang.org/adk/server/adkrest/internal/routers"
 	"google.golang.org/adk/server/adkrest/internal/services"
 )
 
+// Option configures the handler returned by [NewHandler].
+type Option func(*handlerOptions)
+
+// DefaultMaxRequestBodyBytes is the default limit of request body sizes.
+const DefaultMaxRequestBodyBytes = 8 << 20
+
+type handlerOptions struct {
+	idleTimeout         time.Duration
+	maxRequestBodyBytes int64
+	limiter             runner.ConcurrencyLimiter
+	runTimeout          time.Duration
+	sessionLocker       runner.SessionLocker
+	debugCapture        services.DebugCaptureConfig
+	scheduleStore       scheduler.ScheduleStore
+	modelOverrides      []model.LLM
+	agentPools          *runner.AgentPools
+	readinessModels     []model.LLM
+	maxBatchInputs      int
+	maxBatchConcurrency int
+	clock               runner.Clock
+}
+
+// WithIdleTimeout cancels a streaming run and closes the SSE stream with a
+// final event explaining the timeout if no data is sent to the client for
+// the given duration. By default streaming runs never time out.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(o *handlerOptions) {
+		o.idleTimeout = d
+	}
+}
+
+// WithMaxRequestBodyBytes limits the size of request bodies. Requests with
+// larger bodies are rejected with 413 Request Entity Too Large. Defaults to
+// DefaultMaxRequestBodyBytes; a non-positive limit disables the check.
+func WithMaxRequestBodyBytes(n int64) Option {
+	return func(o *handlerOptions) {
+		o.maxRequestBodyBytes = n
+	}
+}
+
+// WithConcurrencyLimiter limits the number of concurrent runs per app and
+// user. Runs rejected by the limiter fail with 429 Too Many Requests. By
+// default runs are not limited.
+func WithConcurrencyLimiter(l runner.ConcurrencyLimiter) Option {
+	return func(o *handlerOptions) {
+		o.limiter = l
+	}
+}
+
+// WithRunTimeout cancels runs exceeding the given duration, ending them with
+// an event with the RUN_TIMEOUT error code. Requests can shorten it with
+// timeoutSeconds; negative timeoutSeconds fail with 400 Bad Request. By
+// default runs have no deadline.
+func WithRunTimeout(d time.Duration) Option {
+	return func(o *handlerOptions) {
+		o.runTimeout = d
+	}
+}
+
+// WithSessionLocker serializes the runs of each session with the given
+// locker. Runs rejected by the locker fail with 409 Conflict. By default
+// concurrent runs of a session are not serialized.
+func WithSessionLocker(l runner.SessionLocker) Option {
+	return func(o *handlerOptions) {
+		o.sessionLocker = l
+	}
+}
+
+// WithDebugSpanBuffer configures the buffering of the spans captured for the
+// debug endpoints: up to maxQueueSize ended spans are queued and each waits
+// at most batchTimeout before it is visible to the endpoints. Spans are never
+// dropped; ending a span waits while the queue is full. Non-positive values
+// keep the defaults of 8192 spans and 100ms.
+func WithDebugSpanBuffer(maxQueueSize int, batchTimeout time.Duration) Option {
+	return func(o *handlerOptions) {
+		o.debugCapture.MaxQueueSize = maxQueueSize
+		o.debugCapture.BatchTimeout = batchTimeout
+	}
+}
+
+// WithCompactTraceDict keeps only the IDs, model, token counts and timings of
+// the spans captured for the debug endpoints, dropping the serialized LLM
+// requests and responses and the tool arguments and responses. It cuts the
+// memory used by long conversations when only the debug graph is needed.
+func WithCompactTraceDict() Option {
+	return func(o *handlerOptions) {
+		o.debugCapture.Compact = true
+	}
+}
+
+// WithScheduleStore serves the Schedules API, creating, listing and deleting
+// the recurring runs of agents held by the store. The runs are started by a
+// scheduler.CronScheduler sharing the store. By default the API is not
+// served.
+func WithScheduleStore(store scheduler.ScheduleStore) Option {
+	return func(o *handlerOptions) {
+		o.scheduleStore = store
+	}
+}
+
+// WithModelOverrides allows run requests to replace the models of their
+// agents with the given models, named by the modelOverride field of the
+// request. Requests naming other models fail with 400 Bad Request.
+func WithModelOverrides(models ...model.LLM) Option {
+	return func(o *handlerOptions) {
+		o.modelOverrides = models
+	}
+}
+
+// WithAgentPools limits the number of concurrent runs of each agent with the
+// given pools, so that a burst of runs of one agent does not starve the
+// others. Only the agent a run starts with is limited, not the agents it
+// transfers to; see runner.AgentPools. Runs rejected by the pools fail with
+// 429 Too Many Requests. By default runs of an agent are not limited.
+func WithAgentPools(p *runner.AgentPools) Option {
+	return func(o *handlerOptions) {
+		o.agentPools = p
+	}
+}
+
+// WithBatchLimits limits the number of inputs and the concurrency of batch
+// run requests. Requests above them fail with 400 Bad Request. Non-positive
+// values keep the defaults, controllers.DefaultMaxBatchInputs and
+// controllers.DefaultMaxBatchConcurrency.
+func WithBatchLimits(maxInputs, maxConcurrency int) Option {
+	return func(o *handlerOptions) {
+		o.maxBatchInputs = maxInputs
+		o.maxBatchConcurrency = maxConcurrency
+	}
+}
+
+// WithReadinessModels makes the /readyz endpoint report whether the backends
+// of the models are reachable, e.g. to detect expired credentials before
+// user traffic does. Successful checks are cached for
+// ReadinessCacheDuration. Without models, /readyz always reports ready.
+func WithReadinessModels(models ...model.LLM) Option {
+	return func(o *handlerOptions) {
+		o.readinessModels = models
+	}
+}
+
+// WithClock sets the clock telling the time of runs, readiness checks and
+// schedules, see runner.Config.Clock. Tests set a fake clock to make them
+// deterministic. Defaults to the system clock.
+func WithClock(clk runner.Clock) Option {
+	return func(o *handlerOptions) {
+		o.clock = clk
+	}
+}
+
+// ReadinessCacheDuration is how long a successful check of a model backend
+// is reused by the /readyz endpoint.
+const ReadinessCacheDuration = 30 * time.Second
+
 // NewHandler creates and returns an http.Handler for the ADK REST API.
-func NewHandler(config *launcher.Config, sseWriteTimeout time.Duration) http.Handler {
-	adkExporter := services.NewAPIServerSpanExporter()
-	telemetry.AddSpanProcessor(sdktrace.NewSimpleSpanProcessor(adkExporter))
+func NewHandler(config *launcher.Config, sseWriteTimeout time.Duration, opts ...Option) http.Handler {
+	options := handlerOptions{maxRequestBodyBytes: DefaultMaxRequestBodyBytes, clock: clock.Real()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	adkExporter := services.RegisterAPIServerSpanExporter(options.debugCapture)
 
 	router := mux.NewRouter().StrictSlash(true)
 	// TODO: Allow taking a prefix to allow customizing the path
 	// where the ADK REST API will be served.
-	setupRouter(router,
+	subrouters := []routers.Router{
 		routers.NewSessionsAPIRouter(controllers.NewSessionsAPIController(config.SessionService)),
-		routers.NewRuntimeAPIRouter(controllers.NewRuntimeAPIController(config.SessionService, config.AgentLoader, config.ArtifactService, sseWriteTimeout)),
+		routers.NewRuntimeAPIRouter(controllers.NewRuntimeAPIController(config.SessionService, config.AgentLoader, config.ArtifactService, sseWriteTimeout,
+			controllers.WithIdleTimeout(options.idleTimeout),
+			controllers.WithConcurrencyLimiter(options.limiter),
+			controllers.WithRunTimeout(options.runTimeout),
+			controllers.WithSessionLocker(options.sessionLocker),
+			controllers.WithModelOverrides(options.modelOverrides...),
+			controllers.WithAgentPools(options.agentPools),
+			controllers.WithBatchLimits(options.maxBatchInputs, options.maxBatchConcurrency),
+			controllers.WithClock(options.clock),
+		)),
 		routers.NewAppsAPIRouter(controllers.NewAppsAPIController(config.AgentLoader)),
 		routers.NewDebugAPIRouter(controllers.NewDebugAPIController(config.SessionService, config.AgentLoader, adkExporter)),
 		routers.NewArtifactsAPIRouter(controllers.NewArtifactsAPIController(config.ArtifactService)),
 		&routers.EvalAPIRouter{},
-	)
-	return router
+		routers.NewHealthAPIRouter(controllers.NewHealthAPIController(options.readinessModels, ReadinessCacheDuration, controllers.WithHealthClock(options.clock))),
+	}
+	if options.scheduleStore != nil {
+		subrouters = append(subrouters, routers.NewSchedulesAPIRouter(controllers.NewSchedulesAPIController(options.scheduleStore, controllers.WithSchedulesClock(options.clock))))
+	}
+	setupRouter(router, subrouters...)
+	var handler http.Handler = router
+	if options.maxRequestBodyBytes > 0 {
+		handler = maxBytesHandler(handler, options.maxRequestBodyBytes)
+	}
+	return TraceContextMiddleware(handler)
+}
+
+// TraceContextMiddleware extracts the trace context from the headers of
+// incoming requests, e.g. traceparent, into the request context, so that the
+// spans of agent runs continue the trace of the caller. It uses the
+// propagator set with otel.SetTextMapPropagator, or W3C trace context if none
+// is set.
+//
+// The handler returned by [NewHandler] already includes this middleware.
+func TraceContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := telemetry.Propagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// maxBytesHandler limits the size of request bodies to n bytes.
+func maxBytesHandler(next http.Handler, n int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+		}
+		next.ServeHTTP(w, r)
+	})
 }
 
 func setupRouter(router *mux.Router, subrouters ...routers.Router) *mux.Router {
@@ -0,0 +1,112 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adkrest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"google.golang.org/adk/cmd/launcher"
+	"google.golang.org/adk/session"
+)
+
+func TestMaxRequestBodyBytes(t *testing.T) {
+	handler := NewHandler(&launcher.Config{SessionService: session.InMemoryService()}, time.Minute, WithMaxRequestBodyBytes(1024))
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "run with too large body",
+			path:       "/run",
+			body:       `{"appName": "` + strings.Repeat("a", 2048) + `"}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "create session with too large body",
+			path:       "/apps/app/users/user/sessions/s1",
+			body:       `{"state": {"key": "` + strings.Repeat("a", 2048) + `"}}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "create session within limit",
+			path:       "/apps/app/users/user/sessions/s2",
+			body:       `{"state": {"key": "value"}}`,
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.wantStatus {
+				t.Errorf("POST %s status = %d, want %d (body: %q)", tc.path, rr.Code, tc.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestTraceContextMiddleware(t *testing.T) {
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+	tests := []struct {
+		name        string
+		traceparent string
+		wantTraceID string
+	}{
+		{
+			name:        "continues the trace of the caller",
+			traceparent: "00-" + traceID + "-" + spanID + "-01",
+			wantTraceID: traceID,
+		},
+		{
+			name: "no trace context",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got trace.SpanContext
+			handler := TraceContextMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = trace.SpanContextFromContext(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/list-apps", nil)
+			if tc.traceparent != "" {
+				req.Header.Set("traceparent", tc.traceparent)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if tc.wantTraceID == "" {
+				if got.IsValid() {
+					t.Errorf("request context has span context %v, want none", got)
+				}
+				return
+			}
+			if got.TraceID().String() != tc.wantTraceID || got.SpanID().String() != spanID || !got.IsRemote() {
+				t.Errorf("request span context = %v, want remote span %s of trace %s", got, spanID, tc.wantTraceID)
+			}
+		})
+	}
+}
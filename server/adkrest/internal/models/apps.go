@@ -0,0 +1,26 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+// AgentTool describes a tool that an agent of an app can call.
+type AgentTool struct {
+	// Agent is the name of the agent declaring the tool.
+	Agent       string `json:"agent"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// InputSchema is the schema of the arguments of the tool, if it declares
+	// one.
+	InputSchema any `json:"inputSchema,omitempty"`
+}
@@ -0,0 +1,125 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// SpanTiming describes the timing of a span, sufficient to draw it in a
+// waterfall.
+type SpanTiming struct {
+	Name         string    `json:"name"`
+	TraceID      string    `json:"traceId"`
+	SpanID       string    `json:"spanId"`
+	ParentSpanID string    `json:"parentSpanId"`
+	StartTime    time.Time `json:"startTime"`
+	EndTime      time.Time `json:"endTime"`
+}
+
+// EventGraph is the agent graph of an app, with the path of an event
+// highlighted.
+type EventGraph struct {
+	DotSrc string `json:"dotSrc"`
+	// ToolResponses holds the function responses of the highlighted edges.
+	// It is only set when requested.
+	ToolResponses []EdgeToolResponse `json:"toolResponses,omitempty"`
+}
+
+// EdgeToolResponse is the function response passed along a highlighted edge
+// of an event graph.
+type EdgeToolResponse struct {
+	From     string                  `json:"from"`
+	To       string                  `json:"to"`
+	Response *genai.FunctionResponse `json:"response"`
+}
+
+// RequestPreview is the request an agent would send to its model on the next
+// turn of a session, redacted as in traces.
+type RequestPreview struct {
+	Agent   string         `json:"agent"`
+	Request map[string]any `json:"request"`
+}
+
+// MaxTraceBatchSize is the maximum number of events of a TraceBatchRequest.
+const MaxTraceBatchSize = 100
+
+// TraceBatchRequest is the request of the batch trace endpoint.
+type TraceBatchRequest struct {
+	EventIds []string `json:"eventIds"`
+}
+
+// AssertTraceBatchRequestRequired checks if the required fields are not zero-ed
+func (req TraceBatchRequest) AssertTraceBatchRequestRequired() error {
+	if len(req.EventIds) == 0 {
+		return fmt.Errorf("eventIds is required")
+	}
+	if len(req.EventIds) > MaxTraceBatchSize {
+		return fmt.Errorf("eventIds has %d events, the maximum is %d", len(req.EventIds), MaxTraceBatchSize)
+	}
+	return nil
+}
+
+// TraceBatchResponse is the response of the batch trace endpoint. Traces
+// holds the span attribute dictionaries of each event found, as returned by
+// the trace endpoint. NotFound lists the requested events without trace, in
+// the order of the request.
+type TraceBatchResponse struct {
+	Traces   map[string][]map[string]string `json:"traces"`
+	NotFound []string                       `json:"notFound"`
+}
+
+// Trace statuses of the events of a TraceDiff.
+const (
+	TraceFound    = "found"
+	TraceNotFound = "not_found"
+)
+
+// TraceDiff is the difference between the span attributes of two events, e.g.
+// of the same step before and after a prompt change. Spans are compared in
+// the order of their start times. Spans is only set if both events have a
+// trace.
+type TraceDiff struct {
+	Before TraceDiffSide `json:"before"`
+	After  TraceDiffSide `json:"after"`
+	Spans  []SpanDiff    `json:"spans,omitempty"`
+}
+
+// TraceDiffSide describes the trace of one of the events of a TraceDiff.
+type TraceDiffSide struct {
+	EventID string `json:"eventId"`
+	// Status is TraceFound or TraceNotFound.
+	Status string `json:"status"`
+	Spans  int    `json:"spans"`
+}
+
+// SpanDiff is the difference between the attributes of the spans at the same
+// position of two traces. Attributes identifying the spans, e.g. span IDs,
+// are not compared.
+type SpanDiff struct {
+	Index   int                        `json:"index"`
+	Added   map[string]string          `json:"added,omitempty"`
+	Removed map[string]string          `json:"removed,omitempty"`
+	Changed map[string]AttributeChange `json:"changed,omitempty"`
+}
+
+// AttributeChange is an attribute whose value differs between two spans.
+type AttributeChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
@@ -40,14 +40,45 @@ type Event struct {
 	LongRunningToolIDs []string                 `json:"longRunningToolIds"`
 	Content            *genai.Content           `json:"content"`
 	GroundingMetadata  *genai.GroundingMetadata `json:"groundingMetadata"`
+	Citations          []Citation               `json:"citations,omitempty"`
 	TurnComplete       bool                     `json:"turnComplete"`
 	Interrupted        bool                     `json:"interrupted"`
 	ErrorCode          string                   `json:"errorCode"`
 	ErrorMessage       string                   `json:"errorMessage"`
 	Actions            EventActions             `json:"actions"`
+	SafetyScores       map[string]float64       `json:"safetyScores,omitempty"`
+	Labels             map[string]string        `json:"labels,omitempty"`
+	Kind               string                   `json:"kind,omitempty"`
+	Progress           *Progress                `json:"progress,omitempty"`
+	Interim            bool                     `json:"interim,omitempty"`
 }
 
-// ToSessionEvent maps Event data struct to session.Event
+// Progress is the stage of the run reported by a phase event. See
+// session.Phase.
+type Progress struct {
+	Phase  string `json:"phase"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Citation links a segment of the text of an event to the sources that
+// support it. See model.Citation.
+type Citation struct {
+	Text       string           `json:"text"`
+	PartIndex  int              `json:"partIndex"`
+	StartIndex int              `json:"startIndex"`
+	EndIndex   int              `json:"endIndex"`
+	Sources    []CitationSource `json:"sources"`
+}
+
+// CitationSource is a source supporting a cited segment.
+type CitationSource struct {
+	URI        string  `json:"uri"`
+	Title      string  `json:"title,omitempty"`
+	Confidence float32 `json:"confidence,omitempty"`
+}
+
+// ToSessionEvent maps Event data struct to session.Event. Citations are
+// derived from the grounding metadata and not mapped back.
 func ToSessionEvent(event Event) *session.Event {
 	return &session.Event{
 		ID:                 event.ID,
@@ -56,6 +87,11 @@ func ToSessionEvent(event Event) *session.Event {
 		Branch:             event.Branch,
 		Author:             event.Author,
 		LongRunningToolIDs: event.LongRunningToolIDs,
+		SafetyScores:       event.SafetyScores,
+		Labels:             event.Labels,
+		Kind:               session.EventKind(event.Kind),
+		Progress:           toSessionProgress(event.Progress),
+		Interim:            event.Interim,
 		LLMResponse: model.LLMResponse{
 			Content:           event.Content,
 			GroundingMetadata: event.GroundingMetadata,
@@ -84,6 +120,7 @@ func FromSessionEvent(event session.Event) Event {
 		LongRunningToolIDs: event.LongRunningToolIDs,
 		Content:            event.LLMResponse.Content,
 		GroundingMetadata:  event.LLMResponse.GroundingMetadata,
+		Citations:          fromCitations(event.LLMResponse.Citations()),
 		TurnComplete:       event.LLMResponse.TurnComplete,
 		Interrupted:        event.LLMResponse.Interrupted,
 		ErrorCode:          event.LLMResponse.ErrorCode,
@@ -92,5 +129,45 @@ func FromSessionEvent(event session.Event) Event {
 			StateDelta:    event.Actions.StateDelta,
 			ArtifactDelta: event.Actions.ArtifactDelta,
 		},
+		SafetyScores: event.SafetyScores,
+		Labels:       event.Labels,
+		Kind:         string(session.KindOf(&event)),
+		Progress:     fromSessionProgress(event.Progress),
+		Interim:      event.Interim,
+	}
+}
+
+func toSessionProgress(p *Progress) *session.Progress {
+	if p == nil {
+		return nil
+	}
+	return &session.Progress{Phase: session.Phase(p.Phase), Detail: p.Detail}
+}
+
+func fromSessionProgress(p *session.Progress) *Progress {
+	if p == nil {
+		return nil
+	}
+	return &Progress{Phase: string(p.Phase), Detail: p.Detail}
+}
+
+func fromCitations(citations []model.Citation) []Citation {
+	if len(citations) == 0 {
+		return nil
+	}
+	res := make([]Citation, len(citations))
+	for i, c := range citations {
+		sources := make([]CitationSource, len(c.Sources))
+		for j, s := range c.Sources {
+			sources[j] = CitationSource(s)
+		}
+		res[i] = Citation{
+			Text:       c.Text,
+			PartIndex:  c.PartIndex,
+			StartIndex: c.StartIndex,
+			EndIndex:   c.EndIndex,
+			Sources:    sources,
+		}
 	}
+	return res
 }
@@ -0,0 +1,34 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "time"
+
+// Readiness is the response of the readiness endpoint.
+type Readiness struct {
+	// Ready reports whether all the model backends are reachable.
+	Ready  bool          `json:"ready"`
+	Models []ModelHealth `json:"models"`
+}
+
+// ModelHealth is the result of the last check of a model backend.
+type ModelHealth struct {
+	Name  string `json:"name"`
+	Ready bool   `json:"ready"`
+	Error string `json:"error,omitempty"`
+	// CheckedAt is the time of the check, which is earlier than the request
+	// if the result of a successful check was cached.
+	CheckedAt time.Time `json:"checkedAt"`
+}
@@ -16,8 +16,12 @@ package models
 
 import (
 	"fmt"
+	"math"
+	"time"
 
 	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
 )
 
 type RunAgentRequest struct {
@@ -32,6 +36,77 @@ type RunAgentRequest struct {
 	Streaming bool `json:"streaming,omitempty"`
 
 	StateDelta *map[string]any `json:"stateDelta,omitempty"`
+
+	// Labels tag the run. See agent.RunConfig.Labels.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// TimeoutSeconds overrides the run timeout of the server if positive,
+	// up to the run timeout of the server. Negative values are rejected.
+	// See agent.RunConfig.Timeout.
+	TimeoutSeconds float64 `json:"timeoutSeconds,omitempty"`
+
+	// PhaseEvents streams phase events reporting the progress of the run.
+	// See agent.RunConfig.PhaseEvents.
+	PhaseEvents bool `json:"phaseEvents,omitempty"`
+
+	// Resumable keeps a streaming run going if the client disconnects, so
+	// that the client can resume the stream with ResumeToken.
+	Resumable bool `json:"resumable,omitempty"`
+
+	// ResumeToken resumes the stream of the session after the event of the
+	// token instead of starting a new run. NewMessage is then ignored.
+	ResumeToken string `json:"resumeToken,omitempty"`
+
+	// ModelOverride names the model to use instead of the model of the agent.
+	// See agent.RunConfig.ModelOverride.
+	ModelOverride string `json:"modelOverride,omitempty"`
+
+	// ModelOverrideSubAgents extends ModelOverride to all the agents of the
+	// run. See agent.RunConfig.ModelOverrideSubAgents.
+	ModelOverrideSubAgents bool `json:"modelOverrideSubAgents,omitempty"`
+
+	// GenerationOverrides overrides generation parameters of the agents of
+	// the run. See agent.RunConfig.GenerationOverrides.
+	GenerationOverrides *GenerationOverrides `json:"generationOverrides,omitempty"`
+}
+
+// GenerationOverrides are generation parameters overriding those of the
+// agents of a run. See agent.GenerationOverrides.
+type GenerationOverrides struct {
+	Seed        *int32   `json:"seed,omitempty"`
+	Temperature *float32 `json:"temperature,omitempty"`
+	TopP        *float32 `json:"topP,omitempty"`
+}
+
+// RunGenerationOverrides returns the generation overrides of the run, or
+// nil if there are none.
+func (req RunAgentRequest) RunGenerationOverrides() *agent.GenerationOverrides {
+	o := req.GenerationOverrides
+	if o == nil {
+		return nil
+	}
+	return &agent.GenerationOverrides{Seed: o.Seed, Temperature: o.Temperature, TopP: o.TopP}
+}
+
+// RunTimeout returns the timeout of the run requested by TimeoutSeconds,
+// capped at maxTimeout if positive, or 0 to keep the run timeout of the
+// server. Unlike agent.RunConfig.Timeout, requests cannot remove the
+// deadline of their run: negative timeouts are rejected.
+func (req RunAgentRequest) RunTimeout(maxTimeout time.Duration) (time.Duration, error) {
+	switch {
+	case math.IsNaN(req.TimeoutSeconds) || req.TimeoutSeconds < 0:
+		return 0, fmt.Errorf("timeoutSeconds must not be negative, got %v", req.TimeoutSeconds)
+	case req.TimeoutSeconds == 0:
+		return 0, nil
+	}
+	d := time.Duration(math.MaxInt64)
+	if ns := req.TimeoutSeconds * float64(time.Second); ns < math.MaxInt64 {
+		d = max(time.Duration(ns), 1)
+	}
+	if maxTimeout > 0 {
+		d = min(d, maxTimeout)
+	}
+	return d, nil
 }
 
 // AssertRunAgentRequestRequired checks if the required fields are not zero-ed
@@ -50,3 +125,68 @@ func (req RunAgentRequest) AssertRunAgentRequestRequired() error {
 
 	return nil
 }
+
+// RunBatchRequest is the request of the batch run endpoint.
+type RunBatchRequest struct {
+	AppName string `json:"appName"`
+
+	UserId string `json:"userId"`
+
+	Inputs []RunBatchInput `json:"inputs"`
+
+	// Concurrency is the maximum number of inputs run in parallel.
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// Labels tag every run of the batch. See agent.RunConfig.Labels.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// RunBatchInput is a single input of a batch run. A new session is created
+// for the input if SessionId is empty.
+type RunBatchInput struct {
+	SessionId string `json:"sessionId,omitempty"`
+
+	NewMessage genai.Content `json:"newMessage"`
+}
+
+// RunBatchResult is the result of a single input of a batch run.
+type RunBatchResult struct {
+	Index int `json:"index"`
+
+	SessionId string `json:"sessionId,omitempty"`
+
+	FinalEvent *Event `json:"finalEvent,omitempty"`
+
+	Error string `json:"error,omitempty"`
+
+	// Completed is the number of inputs completed so far, including this one.
+	Completed int `json:"completed"`
+
+	Total int `json:"total"`
+}
+
+// AssertRunBatchRequestRequired checks if the required fields are not zero-ed
+func (req RunBatchRequest) AssertRunBatchRequestRequired() error {
+	elements := map[string]any{
+		"appName": req.AppName,
+		"userId":  req.UserId,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return fmt.Errorf("%s is required", name)
+		}
+	}
+	if len(req.Inputs) == 0 {
+		return fmt.Errorf("inputs is required")
+	}
+	if req.Concurrency < 0 {
+		return fmt.Errorf("concurrency must not be negative")
+	}
+	return nil
+}
+
+// CancelRunsResponse is the response of cancelling the runs of a session.
+type CancelRunsResponse struct {
+	// Cancelled is the number of runs cancelled.
+	Cancelled int `json:"cancelled"`
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"time"
+
+	"google.golang.org/adk/scheduler"
+)
+
+// Schedule is a recurring run of an agent. See scheduler.Schedule.
+type Schedule struct {
+	ID        string    `json:"id"`
+	AppName   string    `json:"appName"`
+	UserID    string    `json:"userId"`
+	Cron      string    `json:"cron"`
+	Message   string    `json:"message"`
+	Overlap   string    `json:"overlap"`
+	NextRunAt time.Time `json:"nextRunAt,omitzero"`
+}
+
+// CreateScheduleRequest is the request creating a schedule.
+type CreateScheduleRequest struct {
+	Cron    string `json:"cron"`
+	Message string `json:"message"`
+	// Overlap is "skip" (the default) or "queue".
+	Overlap string `json:"overlap,omitempty"`
+}
+
+// ScheduleRun records a run of a schedule. See scheduler.ScheduleRun.
+type ScheduleRun struct {
+	ScheduleID   string    `json:"scheduleId"`
+	ScheduledAt  time.Time `json:"scheduledAt"`
+	StartedAt    time.Time `json:"startedAt,omitzero"`
+	EndedAt      time.Time `json:"endedAt,omitzero"`
+	Status       string    `json:"status"`
+	SessionID    string    `json:"sessionId,omitempty"`
+	InvocationID string    `json:"invocationId,omitempty"`
+	Response     string    `json:"response,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// FromSchedule maps a scheduler.Schedule to a Schedule.
+func FromSchedule(s scheduler.Schedule) Schedule {
+	overlap := s.Overlap
+	if overlap == "" {
+		overlap = scheduler.OverlapSkip
+	}
+	return Schedule{
+		ID:        s.ID,
+		AppName:   s.AppName,
+		UserID:    s.UserID,
+		Cron:      s.Cron,
+		Message:   s.Message,
+		Overlap:   string(overlap),
+		NextRunAt: s.NextRunAt,
+	}
+}
+
+// FromScheduleRun maps a scheduler.ScheduleRun to a ScheduleRun.
+func FromScheduleRun(r scheduler.ScheduleRun) ScheduleRun {
+	return ScheduleRun{
+		ScheduleID:   r.ScheduleID,
+		ScheduledAt:  r.ScheduledAt,
+		StartedAt:    r.StartedAt,
+		EndedAt:      r.EndedAt,
+		Status:       string(r.Status),
+		SessionID:    r.SessionID,
+		InvocationID: r.InvocationID,
+		Response:     r.Response,
+		Error:        r.Error,
+	}
+}
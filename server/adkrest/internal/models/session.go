@@ -66,18 +66,19 @@ func SessionIDFromHTTPParameters(vars map[string]string) (SessionID, error) {
 	return sessionID, nil
 }
 
-func FromSession(session session.Session) (Session, error) {
+func FromSession(s session.Session) (Session, error) {
 	state := map[string]any{}
-	maps.Insert(state, session.State().All())
+	maps.Insert(state, s.State().All())
 	events := []Event{}
-	for event := range session.Events().All() {
+	// Superseded interim events are left out, as for the agents.
+	for event := range session.ReconcileInterimEvents(s.Events()).All() {
 		events = append(events, FromSessionEvent(*event))
 	}
 	mappedSession := Session{
-		ID:        session.ID(),
-		AppName:   session.AppName(),
-		UserID:    session.UserID(),
-		UpdatedAt: session.LastUpdateTime().Unix(),
+		ID:        s.ID(),
+		AppName:   s.AppName(),
+		UserID:    s.UserID(),
+		UpdatedAt: s.LastUpdateTime().Unix(),
 		Events:    events,
 		State:     state,
 	}
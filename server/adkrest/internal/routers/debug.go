@@ -39,12 +39,36 @@ func (r *DebugAPIRouter) Routes() Routes {
 			Pattern:     "/debug/trace/{event_id}",
 			HandlerFunc: r.runtimeController.TraceDictHandler,
 		},
+		Route{
+			Name:        "GetTraceBatch",
+			Methods:     []string{http.MethodPost},
+			Pattern:     "/debug/trace/batch",
+			HandlerFunc: r.runtimeController.TraceBatchHandler,
+		},
+		Route{
+			Name:        "GetTraceDiff",
+			Methods:     []string{http.MethodGet},
+			Pattern:     "/debug/trace/{event_id}/diff/{other_event_id}",
+			HandlerFunc: r.runtimeController.TraceDiffHandler,
+		},
+		Route{
+			Name:        "GetSpanWaterfall",
+			Methods:     []string{http.MethodGet},
+			Pattern:     "/debug/trace/{id}/waterfall",
+			HandlerFunc: r.runtimeController.WaterfallHandler,
+		},
 		Route{
 			Name:        "GetEventGraph",
 			Methods:     []string{http.MethodGet},
 			Pattern:     "/apps/{app_name}/users/{user_id}/sessions/{session_id}/events/{event_id}/graph",
 			HandlerFunc: r.runtimeController.EventGraphHandler,
 		},
+		Route{
+			Name:        "GetNextRequest",
+			Methods:     []string{http.MethodGet},
+			Pattern:     "/apps/{app_name}/users/{user_id}/sessions/{session_id}/next-request",
+			HandlerFunc: r.runtimeController.NextRequestHandler,
+		},
 		Route{
 			Name:        "GetSessionTrace",
 			Methods:     []string{http.MethodGet},
@@ -0,0 +1,43 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routers
+
+import (
+	"net/http"
+
+	"google.golang.org/adk/server/adkrest/controllers"
+)
+
+// HealthAPIRouter defines the routes for the health endpoints.
+type HealthAPIRouter struct {
+	healthController *controllers.HealthAPIController
+}
+
+// NewHealthAPIRouter creates a new HealthAPIRouter.
+func NewHealthAPIRouter(controller *controllers.HealthAPIController) *HealthAPIRouter {
+	return &HealthAPIRouter{healthController: controller}
+}
+
+// Routes returns the routes for the health endpoints.
+func (r *HealthAPIRouter) Routes() Routes {
+	return Routes{
+		Route{
+			Name:        "Ready",
+			Methods:     []string{http.MethodGet},
+			Pattern:     "/readyz",
+			HandlerFunc: r.healthController.ReadyHandler,
+		},
+	}
+}
@@ -45,5 +45,17 @@ func (r *RuntimeAPIRouter) Routes() Routes {
 			Pattern:     "/run_sse",
 			HandlerFunc: controllers.NewErrorHandler(r.runtimeController.RunSSEHandler),
 		},
+		Route{
+			Name:        "RunAgentBatch",
+			Methods:     []string{http.MethodPost, http.MethodOptions},
+			Pattern:     "/run_batch",
+			HandlerFunc: controllers.NewErrorHandler(r.runtimeController.RunBatchHandler),
+		},
+		Route{
+			Name:        "CancelSessionRuns",
+			Methods:     []string{http.MethodPost},
+			Pattern:     "/apps/{app_name}/users/{user_id}/sessions/{session_id}/cancel",
+			HandlerFunc: controllers.NewErrorHandler(r.runtimeController.CancelSessionRunsHandler),
+		},
 	}
 }
@@ -0,0 +1,61 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routers
+
+import (
+	"net/http"
+
+	"google.golang.org/adk/server/adkrest/controllers"
+)
+
+// SchedulesAPIRouter defines the routes for the Schedules API.
+type SchedulesAPIRouter struct {
+	schedulesController *controllers.SchedulesAPIController
+}
+
+// NewSchedulesAPIRouter creates a new SchedulesAPIRouter.
+func NewSchedulesAPIRouter(controller *controllers.SchedulesAPIController) *SchedulesAPIRouter {
+	return &SchedulesAPIRouter{schedulesController: controller}
+}
+
+// Routes returns the routes for the Schedules API.
+func (r *SchedulesAPIRouter) Routes() Routes {
+	return Routes{
+		Route{
+			Name:        "CreateSchedule",
+			Methods:     []string{http.MethodPost},
+			Pattern:     "/apps/{app_name}/users/{user_id}/schedules",
+			HandlerFunc: controllers.NewErrorHandler(r.schedulesController.CreateScheduleHandler),
+		},
+		Route{
+			Name:        "ListSchedules",
+			Methods:     []string{http.MethodGet},
+			Pattern:     "/apps/{app_name}/users/{user_id}/schedules",
+			HandlerFunc: controllers.NewErrorHandler(r.schedulesController.ListSchedulesHandler),
+		},
+		Route{
+			Name:        "DeleteSchedule",
+			Methods:     []string{http.MethodDelete, http.MethodOptions},
+			Pattern:     "/apps/{app_name}/users/{user_id}/schedules/{schedule_id}",
+			HandlerFunc: controllers.NewErrorHandler(r.schedulesController.DeleteScheduleHandler),
+		},
+		Route{
+			Name:        "ListScheduleRuns",
+			Methods:     []string{http.MethodGet},
+			Pattern:     "/apps/{app_name}/users/{user_id}/schedules/{schedule_id}/runs",
+			HandlerFunc: controllers.NewErrorHandler(r.schedulesController.ListScheduleRunsHandler),
+		},
+	}
+}
@@ -39,6 +39,12 @@ func (r *SessionsAPIRouter) Routes() Routes {
 			Pattern:     "/apps/{app_name}/users/{user_id}/sessions/{session_id}",
 			HandlerFunc: r.sessionController.GetSessionHandler,
 		},
+		Route{
+			Name:        "GetEvent",
+			Methods:     []string{http.MethodGet},
+			Pattern:     "/apps/{app_name}/users/{user_id}/sessions/{session_id}/events/{event_id}",
+			HandlerFunc: r.sessionController.GetEventHandler,
+		},
 		Route{
 			Name:        "CreateSession",
 			Methods:     []string{http.MethodPost},
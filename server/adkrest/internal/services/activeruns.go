@@ -0,0 +1,88 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"sync"
+)
+
+// ActiveRuns tracks the in-flight runs of each session so that they can be
+// cancelled together, e.g. when the user closes the UI.
+type ActiveRuns struct {
+	mu     sync.Mutex
+	nextID int
+	runs   map[runKey]map[int]context.CancelFunc
+}
+
+type runKey struct {
+	appName, userID, sessionID string
+}
+
+// NewActiveRuns returns an empty ActiveRuns registry.
+func NewActiveRuns() *ActiveRuns {
+	return &ActiveRuns{runs: make(map[runKey]map[int]context.CancelFunc)}
+}
+
+// Start returns a context for a run of the session that is cancelled by
+// [ActiveRuns.CancelSession], and a function to call once the run is
+// finished. The done function cancels the context and is safe to call more
+// than once.
+func (a *ActiveRuns) Start(ctx context.Context, appName, userID, sessionID string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	key := runKey{appName, userID, sessionID}
+
+	a.mu.Lock()
+	id := a.nextID
+	a.nextID++
+	if a.runs[key] == nil {
+		a.runs[key] = make(map[int]context.CancelFunc)
+	}
+	a.runs[key][id] = cancel
+	a.mu.Unlock()
+
+	return ctx, func() {
+		a.mu.Lock()
+		delete(a.runs[key], id)
+		if len(a.runs[key]) == 0 {
+			delete(a.runs, key)
+		}
+		a.mu.Unlock()
+		cancel()
+	}
+}
+
+// CancelSession cancels all in-flight runs of the session and returns their
+// number. Cancelled runs are removed, so cancelling again returns zero
+// unless new runs were started.
+func (a *ActiveRuns) CancelSession(appName, userID, sessionID string) int {
+	key := runKey{appName, userID, sessionID}
+	a.mu.Lock()
+	runs := a.runs[key]
+	delete(a.runs, key)
+	a.mu.Unlock()
+
+	for _, cancel := range runs {
+		cancel()
+	}
+	return len(runs)
+}
+
+// Len returns the number of in-flight runs of the session.
+func (a *ActiveRuns) Len(appName, userID, sessionID string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.runs[runKey{appName, userID, sessionID}])
+}
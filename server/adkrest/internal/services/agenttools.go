@@ -0,0 +1,80 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	llmagentinternal "google.golang.org/adk/internal/llminternal"
+	"google.golang.org/adk/server/adkrest/internal/models"
+)
+
+// AgentTools returns the tools of the agent permitted by its allow and deny
+// lists and, if includeSubAgents is set, those of its sub-agents, depth
+// first. Toolsets are resolved per invocation, so their tools are not listed.
+func AgentTools(a agent.Agent, includeSubAgents bool) []models.AgentTool {
+	tools := []models.AgentTool{}
+	visited := make(map[string]bool)
+	var visit func(a agent.Agent)
+	visit = func(a agent.Agent) {
+		if visited[a.Name()] {
+			return
+		}
+		visited[a.Name()] = true
+		if llmAgent, ok := a.(llmagentinternal.Agent); ok {
+			state := llmagentinternal.Reveal(llmAgent)
+			for _, t := range state.Tools {
+				if !state.ToolPermitted(t.Name()) {
+					continue
+				}
+				tools = append(tools, models.AgentTool{
+					Agent:       a.Name(),
+					Name:        t.Name(),
+					Description: t.Description(),
+					InputSchema: inputSchema(t),
+				})
+			}
+		}
+		if includeSubAgents {
+			for _, subAgent := range a.SubAgents() {
+				visit(subAgent)
+			}
+		}
+	}
+	visit(a)
+	return tools
+}
+
+// inputSchema returns the schema of the arguments declared by the tool, or
+// nil if there is none.
+func inputSchema(t any) any {
+	d, ok := t.(interface {
+		Declaration() *genai.FunctionDeclaration
+	})
+	if !ok {
+		return nil
+	}
+	decl := d.Declaration()
+	switch {
+	case decl == nil:
+		return nil
+	case decl.ParametersJsonSchema != nil:
+		return decl.ParametersJsonSchema
+	case decl.Parameters != nil:
+		return decl.Parameters
+	}
+	return nil
+}
@@ -16,51 +16,356 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/server/adkrest/internal/models"
 )
 
 // APIServerSpanExporter is a custom SpanExporter that stores relevant span data.
 // Stores attributes of specific spans (call_llm, send_data, execute_tool) keyed by `gcp.vertex.agent.event_id`.
+// The event ID key follows the attribute prefix configured with
+// telemetry.SetAttributePrefix; spans recorded under another prefix, e.g.
+// before the prefix was changed, are not associated with their events.
+// All spans of an event are kept, e.g. retried or repeated LLM calls.
 // This is used for debugging individual events.
+// The timing of every span is also kept per trace, to draw span waterfalls;
+// the oldest traces, with the spans of events recorded in them, are evicted
+// past a maximum number of traces, see WithMaxTraces.
 // APIServerSpanExporter implements sdktrace.SpanExporter interface.
 type APIServerSpanExporter struct {
-	traceDict map[string]map[string]string
+	mu        sync.RWMutex
+	traceDict map[string][]spanRecord
+	traces    map[string][]models.SpanTiming
+	// traceOrder holds the IDs of traces in the order they were first
+	// seen, to evict the oldest traces past maxTraces.
+	traceOrder []string
+	maxTraces  int
+	// traceEvents holds the IDs of the events of the trace dict with spans
+	// in each trace, to evict them with the trace.
+	traceEvents map[string][]string
+	// processor is the processor feeding the exporter, if it was created
+	// with NewDebugSpanProcessor.
+	processor sdktrace.SpanProcessor
+	// compact drops the payload attributes of the spans, see
+	// WithCompactTraceDict.
+	compact bool
+	// logger logs the spans left out of the trace dict, see WithLogger.
+	logger *slog.Logger
+	// ignored counts the spans left out of the trace dict by reason, if
+	// enabled with WithIgnoredSpanCounts.
+	ignored *ignoredCounts
+}
+
+// Reasons for which a span is left out of the trace dict.
+const (
+	// IgnoredSpanName is the reason of spans other than call_llm,
+	// send_data and execute_tool spans.
+	IgnoredSpanName = "span_name_not_recorded"
+	// IgnoredNoEventID is the reason of spans without event ID attribute,
+	// e.g. recorded under another attribute prefix.
+	IgnoredNoEventID = "missing_event_id"
+)
+
+// DefaultMaxTraces is the number of traces whose span timings are kept by
+// default.
+const DefaultMaxTraces = 1000
+
+type ignoredCounts struct {
+	name, noEventID atomic.Int64
+}
+
+// ExporterOption configures an APIServerSpanExporter.
+type ExporterOption func(*APIServerSpanExporter)
+
+// WithCompactTraceDict stores only the lightweight attributes of the spans:
+// IDs, model, token counts and similar metadata. The serialized LLM
+// requests and responses, tool arguments and tool responses are dropped,
+// which cuts the memory of long conversations for deployments that only
+// need the debug graph and the span timings.
+func WithCompactTraceDict() ExporterOption {
+	return func(s *APIServerSpanExporter) {
+		s.compact = true
+	}
+}
+
+// WithMaxTraces sets the number of traces whose span timings are kept.
+// Past it, the oldest traces are evicted. Defaults to DefaultMaxTraces.
+func WithMaxTraces(n int) ExporterOption {
+	return func(s *APIServerSpanExporter) {
+		s.maxTraces = n
+	}
+}
+
+// WithLogger sets the logger of the spans left out of the trace dict, logged
+// at debug level with their name and the reason. Defaults to
+// slog.Default().
+func WithLogger(l *slog.Logger) ExporterOption {
+	return func(s *APIServerSpanExporter) {
+		s.logger = l
+	}
+}
+
+// WithIgnoredSpanCounts counts the spans left out of the trace dict, see
+// IgnoredSpans.
+func WithIgnoredSpanCounts() ExporterOption {
+	return func(s *APIServerSpanExporter) {
+		s.ignored = &ignoredCounts{}
+	}
+}
+
+type spanRecord struct {
+	startTime  time.Time
+	timing     models.SpanTiming
+	attributes map[string]string
 }
 
 // NewAPIServerSpanExporter returns a APIServerSpanExporter instance
-func NewAPIServerSpanExporter() *APIServerSpanExporter {
-	return &APIServerSpanExporter{
-		traceDict: make(map[string]map[string]string),
+func NewAPIServerSpanExporter(opts ...ExporterOption) *APIServerSpanExporter {
+	s := &APIServerSpanExporter{
+		traceDict: make(map[string][]spanRecord),
+		traces:    make(map[string][]models.SpanTiming),
+		maxTraces: DefaultMaxTraces,
+
+		traceEvents: make(map[string][]string),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.maxTraces <= 0 {
+		s.maxTraces = DefaultMaxTraces
+	}
+	return s
+}
+
+// GetTraceDict returns stored trace informations: the attributes of all
+// spans of each event, ordered by span start time.
+func (s *APIServerSpanExporter) GetTraceDict() map[string][]map[string]string {
+	s.flush()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	traceDict := make(map[string][]map[string]string, len(s.traceDict))
+	for eventID, records := range s.traceDict {
+		spans := make([]map[string]string, len(records))
+		for i, r := range records {
+			spans[i] = maps.Clone(r.attributes)
+		}
+		traceDict[eventID] = spans
+	}
+	return traceDict
+}
+
+// IgnoredSpans returns the number of spans left out of the trace dict by
+// reason, e.g. IgnoredNoEventID, or nil if the exporter was not created with
+// WithIgnoredSpanCounts.
+func (s *APIServerSpanExporter) IgnoredSpans() map[string]int64 {
+	if s.ignored == nil {
+		return nil
+	}
+	s.flush()
+	return map[string]int64{
+		IgnoredSpanName:  s.ignored.name.Load(),
+		IgnoredNoEventID: s.ignored.noEventID.Load(),
 	}
 }
 
-// GetTraceDict returns stored trace informations
-func (s *APIServerSpanExporter) GetTraceDict() map[string]map[string]string {
-	return s.traceDict
+// GetWaterfall returns the timing of the spans of the event with the given
+// ID or, if there is no such event, of all spans of the trace with the given
+// ID. Spans are sorted by start time.
+func (s *APIServerSpanExporter) GetWaterfall(id string) ([]models.SpanTiming, bool) {
+	s.flush()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if records, ok := s.traceDict[id]; ok {
+		spans := make([]models.SpanTiming, len(records))
+		for i, r := range records {
+			spans[i] = r.timing
+		}
+		return spans, true
+	}
+	spans, ok := s.traces[id]
+	return slices.Clone(spans), ok
 }
 
 // ExportSpans implements custom export function for sdktrace.SpanExporter.
+// It only records the spans in memory and never returns an error, so it
+// cannot stall the span processor.
 func (s *APIServerSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	eventIDKey := telemetry.EventIDKey()
+	var payloadKeys []string
+	if s.compact {
+		payloadKeys = telemetry.PayloadKeys()
+	}
 	for _, span := range spans {
-		if span.Name() == "call_llm" || span.Name() == "send_data" || strings.HasPrefix(span.Name(), "execute_tool") {
-			spanAttributes := span.Attributes()
-			attributes := make(map[string]string)
-			for _, attribute := range spanAttributes {
-				key := string(attribute.Key)
-				attributes[key] = attribute.Value.AsString()
-			}
-			attributes["trace_id"] = span.SpanContext().TraceID().String()
-			attributes["span_id"] = span.SpanContext().SpanID().String()
-			if eventID, ok := attributes["gcp.vertex.agent.event_id"]; ok {
-				s.traceDict[eventID] = attributes
+		timing := models.SpanTiming{
+			Name:      span.Name(),
+			TraceID:   span.SpanContext().TraceID().String(),
+			SpanID:    span.SpanContext().SpanID().String(),
+			StartTime: span.StartTime(),
+			EndTime:   span.EndTime(),
+		}
+		if span.Parent().IsValid() {
+			timing.ParentSpanID = span.Parent().SpanID().String()
+		}
+		s.addTiming(timing)
+		if span.Name() != "call_llm" && span.Name() != "send_data" && !strings.HasPrefix(span.Name(), "execute_tool") {
+			s.ignore(ctx, span, IgnoredSpanName)
+			continue
+		}
+		spanAttributes := span.Attributes()
+		attributes := make(map[string]string)
+		for _, kv := range spanAttributes {
+			key := string(kv.Key)
+			if !slices.Contains(payloadKeys, key) {
+				attributes[key] = attributeString(kv.Value)
 			}
 		}
+		attributes["trace_id"] = span.SpanContext().TraceID().String()
+		attributes["span_id"] = span.SpanContext().SpanID().String()
+		// Root spans have an empty parent span ID.
+		attributes["parent_span_id"] = timing.ParentSpanID
+		eventID, ok := attributes[eventIDKey]
+		if !ok {
+			s.ignore(ctx, span, IgnoredNoEventID)
+			continue
+		}
+		s.add(eventID, spanRecord{startTime: span.StartTime(), timing: timing, attributes: attributes})
 	}
 	return nil
 }
 
+// ignore counts and logs a span left out of the trace dict. The record is
+// only built if debug logging is enabled, so ignored spans cost no
+// allocation otherwise.
+func (s *APIServerSpanExporter) ignore(ctx context.Context, span sdktrace.ReadOnlySpan, reason string) {
+	if s.ignored != nil {
+		if reason == IgnoredSpanName {
+			s.ignored.name.Add(1)
+		} else {
+			s.ignored.noEventID.Add(1)
+		}
+	}
+	logger := s.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	logger.LogAttrs(ctx, slog.LevelDebug, "span left out of the debug trace dict",
+		slog.String("span_name", span.Name()),
+		slog.String("reason", reason),
+		slog.String("trace_id", span.SpanContext().TraceID().String()),
+		slog.String("span_id", span.SpanContext().SpanID().String()),
+	)
+}
+
+// attributeString returns the string representation of an attribute value
+// stored in the trace dict. Strings are kept as is, numbers and booleans are
+// formatted with strconv and slices are JSON encoded, so that every element
+// type is read back the same way.
+func attributeString(v attribute.Value) string {
+	var slice any
+	switch v.Type() {
+	case attribute.STRING:
+		return v.AsString()
+	case attribute.BOOL:
+		return strconv.FormatBool(v.AsBool())
+	case attribute.INT64:
+		return strconv.FormatInt(v.AsInt64(), 10)
+	case attribute.FLOAT64:
+		return strconv.FormatFloat(v.AsFloat64(), 'g', -1, 64)
+	case attribute.BOOLSLICE:
+		slice = v.AsBoolSlice()
+	case attribute.INT64SLICE:
+		slice = v.AsInt64Slice()
+	case attribute.FLOAT64SLICE:
+		slice = v.AsFloat64Slice()
+	case attribute.STRINGSLICE:
+		slice = v.AsStringSlice()
+	default:
+		return v.Emit()
+	}
+	b, err := json.Marshal(slice)
+	if err != nil {
+		// NaN and infinite floats have no JSON representation.
+		return v.Emit()
+	}
+	return string(b)
+}
+
+// add inserts the record keeping the records of the event ordered by start
+// time. Records of traces already evicted are dropped.
+func (s *APIServerSpanExporter) add(eventID string, record spanRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	traceID := record.timing.TraceID
+	if _, ok := s.traces[traceID]; !ok {
+		return
+	}
+	if !slices.Contains(s.traceEvents[traceID], eventID) {
+		s.traceEvents[traceID] = append(s.traceEvents[traceID], eventID)
+	}
+	records := s.traceDict[eventID]
+	i, _ := slices.BinarySearchFunc(records, record.startTime, func(r spanRecord, t time.Time) int {
+		return r.startTime.Compare(t)
+	})
+	// Insert after records with the same start time to keep export order.
+	for i < len(records) && records[i].startTime.Equal(record.startTime) {
+		i++
+	}
+	s.traceDict[eventID] = slices.Insert(records, i, record)
+}
+
+// addTiming inserts the span timing keeping the spans of the trace ordered by
+// start time. The oldest trace is evicted if a new trace exceeds maxTraces.
+func (s *APIServerSpanExporter) addTiming(timing models.SpanTiming) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	spans, ok := s.traces[timing.TraceID]
+	if !ok {
+		if len(s.traceOrder) >= s.maxTraces {
+			s.evict(s.traceOrder[0])
+			s.traceOrder = s.traceOrder[1:]
+		}
+		s.traceOrder = append(s.traceOrder, timing.TraceID)
+	}
+	i, _ := slices.BinarySearchFunc(spans, timing.StartTime, func(t models.SpanTiming, start time.Time) int {
+		return t.StartTime.Compare(start)
+	})
+	for i < len(spans) && spans[i].StartTime.Equal(timing.StartTime) {
+		i++
+	}
+	s.traces[timing.TraceID] = slices.Insert(spans, i, timing)
+}
+
+// evict removes the trace and the records of the trace dict in it.
+func (s *APIServerSpanExporter) evict(traceID string) {
+	delete(s.traces, traceID)
+	for _, eventID := range s.traceEvents[traceID] {
+		records := slices.DeleteFunc(s.traceDict[eventID], func(r spanRecord) bool {
+			return r.timing.TraceID == traceID
+		})
+		if len(records) == 0 {
+			delete(s.traceDict, eventID)
+		} else {
+			s.traceDict[eventID] = records
+		}
+	}
+	delete(s.traceEvents, traceID)
+}
+
 // Shutdown is a function that sdktrace.SpanExporter has, should close the span exporter connections.
 // Since APIServerSpanExporter holds only in-memory dictionary, no additional logic required.
 func (s *APIServerSpanExporter) Shutdown(ctx context.Context) error {
@@ -15,12 +15,22 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"go.opentelemetry.io/otel/attribute"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
+
+	"google.golang.org/adk/server/adkrest/internal/models"
 )
 
 // capturingExporter is a custom exporter that captures spans for testing.
@@ -126,10 +136,14 @@ func TestAPIServerSpanExporterExportSpans(t *testing.T) {
 				t.Fatalf("traceDict should have 1 item, but has %d", len(traceDict))
 			}
 
-			eventDict, ok := traceDict["event-id"]
+			eventSpans, ok := traceDict["event-id"]
 			if !ok {
 				t.Fatalf("traceDict should contain event ID event-id")
 			}
+			if len(eventSpans) != 1 {
+				t.Fatalf("traceDict should have 1 span for event-id, but has %d", len(eventSpans))
+			}
+			eventDict := eventSpans[0]
 
 			if _, ok := eventDict["span_id"]; !ok {
 				t.Fatalf("traceDict should contain span_id")
@@ -142,6 +156,357 @@ func TestAPIServerSpanExporterExportSpans(t *testing.T) {
 	}
 }
 
+func TestAPIServerSpanExporterKeepsAllSpansOfEvent(t *testing.T) {
+	ctx := context.Background()
+	capturer := &capturingExporter{}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(capturer))
+	tracer := tp.Tracer("test-tracer")
+
+	start := time.Now()
+	eventID := attribute.String("gcp.vertex.agent.event_id", "event-id")
+	// Spans end in a different order than they start.
+	_, second := tracer.Start(ctx, "call_llm", trace.WithTimestamp(start.Add(time.Second)), trace.WithAttributes(eventID, attribute.String("attempt", "2")))
+	_, first := tracer.Start(ctx, "call_llm", trace.WithTimestamp(start), trace.WithAttributes(eventID, attribute.String("attempt", "1")))
+	_, tool := tracer.Start(ctx, "execute_tool lookup", trace.WithTimestamp(start.Add(2*time.Second)), trace.WithAttributes(eventID, attribute.String("attempt", "3")))
+	tool.End()
+	second.End()
+	first.End()
+	if err := tp.Shutdown(ctx); err != nil {
+		t.Fatalf("failed to shutdown tracer provider: %v", err)
+	}
+
+	exporter := NewAPIServerSpanExporter()
+	// Export one span at a time, as the simple span processor does.
+	for _, span := range capturer.spans {
+		if err := exporter.ExportSpans(ctx, []sdktrace.ReadOnlySpan{span}); err != nil {
+			t.Fatalf("ExportSpans() error = %v", err)
+		}
+	}
+
+	spans := exporter.GetTraceDict()["event-id"]
+	var got []string
+	for _, span := range spans {
+		got = append(got, span["attempt"])
+	}
+	if want := []string{"1", "2", "3"}; !slices.Equal(got, want) {
+		t.Errorf("span order = %v, want %v", got, want)
+	}
+}
+
+func TestAPIServerSpanExporterIgnoredSpans(t *testing.T) {
+	ctx := context.Background()
+	var logs bytes.Buffer
+	exporter := NewAPIServerSpanExporter(
+		WithLogger(slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		WithIgnoredSpanCounts(),
+	)
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracer := tp.Tracer("test-tracer")
+	for _, s := range []struct {
+		name  string
+		attrs []attribute.KeyValue
+	}{
+		{name: "call_llm", attrs: []attribute.KeyValue{attribute.String("gcp.vertex.agent.event_id", "event-id")}},
+		{name: "call_llm"},
+		{name: "invoke_agent", attrs: []attribute.KeyValue{attribute.String("gcp.vertex.agent.event_id", "event-id")}},
+	} {
+		_, span := tracer.Start(ctx, s.name, trace.WithAttributes(s.attrs...))
+		span.End()
+	}
+
+	want := map[string]int64{IgnoredSpanName: 1, IgnoredNoEventID: 1}
+	if diff := cmp.Diff(want, exporter.IgnoredSpans()); diff != "" {
+		t.Errorf("IgnoredSpans() mismatch (-want +got):\n%s", diff)
+	}
+	var got []string
+	for line := range strings.Lines(logs.String()) {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("failed to decode log record %q: %v", line, err)
+		}
+		got = append(got, fmt.Sprintf("%v: %v", record["span_name"], record["reason"]))
+	}
+	wantLogs := []string{"call_llm: " + IgnoredNoEventID, "invoke_agent: " + IgnoredSpanName}
+	if diff := cmp.Diff(wantLogs, got); diff != "" {
+		t.Errorf("logged ignored spans mismatch (-want +got):\n%s", diff)
+	}
+	if got := NewAPIServerSpanExporter().IgnoredSpans(); got != nil {
+		t.Errorf("IgnoredSpans() without WithIgnoredSpanCounts = %v, want nil", got)
+	}
+}
+
+func TestAPIServerSpanExporterParentSpanID(t *testing.T) {
+	ctx := context.Background()
+	capturer := &capturingExporter{}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(capturer))
+	tracer := tp.Tracer("test-tracer")
+
+	rootCtx, root := tracer.Start(ctx, "call_llm", trace.WithAttributes(attribute.String("gcp.vertex.agent.event_id", "root")))
+	_, child := tracer.Start(rootCtx, "execute_tool lookup", trace.WithAttributes(attribute.String("gcp.vertex.agent.event_id", "child")))
+	child.End()
+	root.End()
+	if err := tp.Shutdown(ctx); err != nil {
+		t.Fatalf("failed to shutdown tracer provider: %v", err)
+	}
+
+	exporter := NewAPIServerSpanExporter()
+	if err := exporter.ExportSpans(ctx, capturer.spans); err != nil {
+		t.Fatalf("ExportSpans() error = %v", err)
+	}
+
+	traceDict := exporter.GetTraceDict()
+	tests := []struct {
+		eventID string
+		want    string
+	}{
+		{eventID: "root", want: ""},
+		{eventID: "child", want: root.SpanContext().SpanID().String()},
+	}
+	for _, tc := range tests {
+		t.Run(tc.eventID, func(t *testing.T) {
+			spans := traceDict[tc.eventID]
+			if len(spans) != 1 {
+				t.Fatalf("traceDict has %d spans for %q, want 1", len(spans), tc.eventID)
+			}
+			got, ok := spans[0]["parent_span_id"]
+			if !ok {
+				t.Fatal("span has no parent_span_id")
+			}
+			if got != tc.want {
+				t.Errorf("parent_span_id = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAPIServerSpanExporterCompactTraceDict(t *testing.T) {
+	ctx := context.Background()
+	capturer := &capturingExporter{}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(capturer))
+	_, span := tp.Tracer("test-tracer").Start(ctx, "call_llm", trace.WithAttributes(
+		attribute.String("gcp.vertex.agent.event_id", "event-id"),
+		attribute.String("gcp.vertex.agent.invocation_id", "inv"),
+		attribute.String("gen_ai.request.model", "test-model"),
+		attribute.Int("gen_ai.usage.input_tokens", 12),
+		attribute.String("gcp.vertex.agent.llm_request", `{"contents":["long"]}`),
+		attribute.String("gcp.vertex.agent.llm_response", `{"content":"long"}`),
+	))
+	span.End()
+	if err := tp.Shutdown(ctx); err != nil {
+		t.Fatalf("failed to shutdown tracer provider: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		opts []ExporterOption
+		want map[string]string
+	}{
+		{
+			name: "full",
+			want: map[string]string{
+				"gcp.vertex.agent.event_id":      "event-id",
+				"gcp.vertex.agent.invocation_id": "inv",
+				"gen_ai.request.model":           "test-model",
+				"gen_ai.usage.input_tokens":      "12",
+				"gcp.vertex.agent.llm_request":   `{"contents":["long"]}`,
+				"gcp.vertex.agent.llm_response":  `{"content":"long"}`,
+			},
+		},
+		{
+			name: "compact",
+			opts: []ExporterOption{WithCompactTraceDict()},
+			want: map[string]string{
+				"gcp.vertex.agent.event_id":      "event-id",
+				"gcp.vertex.agent.invocation_id": "inv",
+				"gen_ai.request.model":           "test-model",
+				"gen_ai.usage.input_tokens":      "12",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			exporter := NewAPIServerSpanExporter(tc.opts...)
+			if err := exporter.ExportSpans(ctx, capturer.spans); err != nil {
+				t.Fatalf("ExportSpans() error = %v", err)
+			}
+			spans := exporter.GetTraceDict()["event-id"]
+			if len(spans) != 1 {
+				t.Fatalf("traceDict has %d spans, want 1", len(spans))
+			}
+			got := spans[0]
+			for _, k := range []string{"trace_id", "span_id", "parent_span_id"} {
+				delete(got, k)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("span attributes mismatch (-want +got):\n%s", diff)
+			}
+			if _, ok := exporter.GetWaterfall("event-id"); !ok {
+				t.Error("GetWaterfall() found no span timing")
+			}
+		})
+	}
+}
+
+func TestAPIServerSpanExporterAttributeTypes(t *testing.T) {
+	ctx := context.Background()
+	capturer := &capturingExporter{}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(capturer))
+	_, span := tp.Tracer("test-tracer").Start(ctx, "call_llm", trace.WithAttributes(
+		attribute.String("gcp.vertex.agent.event_id", "event-id"),
+		attribute.Int("gen_ai.request.max_tokens", 1024),
+		attribute.Float64("gen_ai.request.temperature", 0.25),
+		attribute.Float64("gen_ai.request.top_p", 1),
+		attribute.Bool("gcp.vertex.agent.cache_hit", true),
+		attribute.StringSlice("gen_ai.response.finish_reasons", []string{"STOP", "MAX_TOKENS"}),
+		attribute.IntSlice("sizes", []int{1, 2}),
+		attribute.Float64Slice("scores", []float64{0.5, 1.5}),
+		attribute.BoolSlice("flags", []bool{true, false}),
+		attribute.StringSlice("empty", nil),
+	))
+	span.End()
+	if err := tp.Shutdown(ctx); err != nil {
+		t.Fatalf("failed to shutdown tracer provider: %v", err)
+	}
+
+	exporter := NewAPIServerSpanExporter()
+	if err := exporter.ExportSpans(ctx, capturer.spans); err != nil {
+		t.Fatalf("ExportSpans() error = %v", err)
+	}
+	spans := exporter.GetTraceDict()["event-id"]
+	if len(spans) != 1 {
+		t.Fatalf("traceDict has %d spans, want 1", len(spans))
+	}
+	got := spans[0]
+	for _, k := range []string{"trace_id", "span_id", "parent_span_id"} {
+		delete(got, k)
+	}
+	want := map[string]string{
+		"gcp.vertex.agent.event_id":      "event-id",
+		"gen_ai.request.max_tokens":      "1024",
+		"gen_ai.request.temperature":     "0.25",
+		"gen_ai.request.top_p":           "1",
+		"gcp.vertex.agent.cache_hit":     "true",
+		"gen_ai.response.finish_reasons": `["STOP","MAX_TOKENS"]`,
+		"sizes":                          "[1,2]",
+		"scores":                         "[0.5,1.5]",
+		"flags":                          "[true,false]",
+		"empty":                          "[]",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("span attributes mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAPIServerSpanExporterWaterfall(t *testing.T) {
+	ctx := context.Background()
+	capturer := &capturingExporter{}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(capturer))
+	tracer := tp.Tracer("test-tracer")
+
+	start := time.Now()
+	eventID := attribute.String("gcp.vertex.agent.event_id", "event-id")
+	rootCtx, root := tracer.Start(ctx, "invoke_agent", trace.WithTimestamp(start))
+	_, tool := tracer.Start(rootCtx, "execute_tool lookup", trace.WithTimestamp(start.Add(2*time.Second)), trace.WithAttributes(eventID))
+	_, llm := tracer.Start(rootCtx, "call_llm", trace.WithTimestamp(start.Add(time.Second)), trace.WithAttributes(eventID))
+	tool.End(trace.WithTimestamp(start.Add(3 * time.Second)))
+	llm.End(trace.WithTimestamp(start.Add(2 * time.Second)))
+	root.End(trace.WithTimestamp(start.Add(4 * time.Second)))
+	if err := tp.Shutdown(ctx); err != nil {
+		t.Fatalf("failed to shutdown tracer provider: %v", err)
+	}
+
+	exporter := NewAPIServerSpanExporter()
+	for _, span := range capturer.spans {
+		if err := exporter.ExportSpans(ctx, []sdktrace.ReadOnlySpan{span}); err != nil {
+			t.Fatalf("ExportSpans() error = %v", err)
+		}
+	}
+
+	traceID := root.SpanContext().TraceID().String()
+	rootID := root.SpanContext().SpanID().String()
+	tests := []struct {
+		name string
+		id   string
+		want []models.SpanTiming
+	}{
+		{
+			name: "event",
+			id:   "event-id",
+			want: []models.SpanTiming{
+				{Name: "call_llm", TraceID: traceID, SpanID: llm.SpanContext().SpanID().String(), ParentSpanID: rootID, StartTime: start.Add(time.Second), EndTime: start.Add(2 * time.Second)},
+				{Name: "execute_tool lookup", TraceID: traceID, SpanID: tool.SpanContext().SpanID().String(), ParentSpanID: rootID, StartTime: start.Add(2 * time.Second), EndTime: start.Add(3 * time.Second)},
+			},
+		},
+		{
+			name: "trace",
+			id:   traceID,
+			want: []models.SpanTiming{
+				{Name: "invoke_agent", TraceID: traceID, SpanID: rootID, StartTime: start, EndTime: start.Add(4 * time.Second)},
+				{Name: "call_llm", TraceID: traceID, SpanID: llm.SpanContext().SpanID().String(), ParentSpanID: rootID, StartTime: start.Add(time.Second), EndTime: start.Add(2 * time.Second)},
+				{Name: "execute_tool lookup", TraceID: traceID, SpanID: tool.SpanContext().SpanID().String(), ParentSpanID: rootID, StartTime: start.Add(2 * time.Second), EndTime: start.Add(3 * time.Second)},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := exporter.GetWaterfall(tc.id)
+			if !ok {
+				t.Fatalf("GetWaterfall(%q) found nothing", tc.id)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("GetWaterfall(%q) mismatch (-want +got):\n%s", tc.id, diff)
+			}
+		})
+	}
+
+	if _, ok := exporter.GetWaterfall("missing"); ok {
+		t.Error("GetWaterfall(missing) found spans")
+	}
+}
+
+func TestAPIServerSpanExporterMaxTraces(t *testing.T) {
+	ctx := context.Background()
+	capturer := &capturingExporter{}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(capturer))
+	tracer := tp.Tracer("test-tracer")
+	var traceIDs []string
+	for i := range 3 {
+		_, span := tracer.Start(ctx, "call_llm")
+		span.SetAttributes(attribute.String("gcp.vertex.agent.event_id", fmt.Sprintf("event-%d", i)))
+		span.End()
+		traceIDs = append(traceIDs, span.SpanContext().TraceID().String())
+	}
+	if err := tp.Shutdown(ctx); err != nil {
+		t.Fatalf("failed to shutdown tracer provider: %v", err)
+	}
+
+	exporter := NewAPIServerSpanExporter(WithMaxTraces(2))
+	if err := exporter.ExportSpans(ctx, capturer.spans); err != nil {
+		t.Fatalf("ExportSpans() error = %v", err)
+	}
+	if _, ok := exporter.GetWaterfall(traceIDs[0]); ok {
+		t.Error("GetWaterfall() found the oldest trace, want it evicted")
+	}
+	for _, id := range traceIDs[1:] {
+		if _, ok := exporter.GetWaterfall(id); !ok {
+			t.Errorf("GetWaterfall(%q) found nothing", id)
+		}
+	}
+	// The spans of the events of the evicted trace are evicted with it.
+	var events []string
+	for eventID := range exporter.GetTraceDict() {
+		events = append(events, eventID)
+	}
+	slices.Sort(events)
+	if diff := cmp.Diff([]string{"event-1", "event-2"}, events); diff != "" {
+		t.Errorf("GetTraceDict() events mismatch (-want +got):\n%s", diff)
+	}
+	// The attributes returned are copies.
+	exporter.GetTraceDict()["event-1"][0]["span_id"] = "changed"
+	if got := exporter.GetTraceDict()["event-1"][0]["span_id"]; got == "changed" {
+		t.Error("GetTraceDict() returned the attributes stored by the exporter")
+	}
+}
+
 func TestAPIServerSpanExporterShutdown(t *testing.T) {
 	exporter := NewAPIServerSpanExporter()
 	if err := exporter.Shutdown(context.Background()); err != nil {
@@ -0,0 +1,110 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"google.golang.org/adk/internal/telemetry"
+)
+
+// Defaults used for unset DebugCaptureConfig fields. The queue is larger
+// than the default of sdktrace, since the spans of a long run end in bursts
+// and the debug UI needs all of them.
+const (
+	DefaultDebugMaxQueueSize       = 8192
+	DefaultDebugMaxExportBatchSize = 512
+	DefaultDebugBatchTimeout       = 100 * time.Millisecond
+)
+
+// debugFlushTimeout limits the wait for queued spans before the exporter
+// is read.
+const debugFlushTimeout = time.Second
+
+// DebugCaptureConfig configures the span processor feeding an
+// APIServerSpanExporter.
+type DebugCaptureConfig struct {
+	// MaxQueueSize is the number of ended spans buffered before they are
+	// exported. Defaults to DefaultDebugMaxQueueSize.
+	MaxQueueSize int
+	// MaxExportBatchSize is the maximum number of spans exported at once.
+	// Defaults to DefaultDebugMaxExportBatchSize.
+	MaxExportBatchSize int
+	// BatchTimeout is the longest time a span waits in the queue. Defaults
+	// to DefaultDebugBatchTimeout.
+	BatchTimeout time.Duration
+	// Compact stores only the lightweight attributes of the spans, see
+	// WithCompactTraceDict.
+	Compact bool
+}
+
+// NewDebugSpanProcessor returns a batch span processor exporting to the
+// given exporter. Unlike the default batch processor it never drops spans:
+// ending a span blocks while the queue is full, which is short since the
+// exporter only records the spans in memory. Reading the exporter first
+// flushes the spans still queued, so the debug endpoints see the spans of
+// runs that just ended.
+func NewDebugSpanProcessor(exporter *APIServerSpanExporter, cfg DebugCaptureConfig) sdktrace.SpanProcessor {
+	if cfg.MaxQueueSize <= 0 {
+		cfg.MaxQueueSize = DefaultDebugMaxQueueSize
+	}
+	if cfg.MaxExportBatchSize <= 0 {
+		cfg.MaxExportBatchSize = DefaultDebugMaxExportBatchSize
+	}
+	if cfg.BatchTimeout <= 0 {
+		cfg.BatchTimeout = DefaultDebugBatchTimeout
+	}
+	processor := sdktrace.NewBatchSpanProcessor(exporter,
+		sdktrace.WithMaxQueueSize(cfg.MaxQueueSize),
+		sdktrace.WithMaxExportBatchSize(min(cfg.MaxExportBatchSize, cfg.MaxQueueSize)),
+		sdktrace.WithBatchTimeout(cfg.BatchTimeout),
+		sdktrace.WithBlocking(),
+	)
+	exporter.mu.Lock()
+	exporter.processor = processor
+	exporter.mu.Unlock()
+	return processor
+}
+
+// RegisterAPIServerSpanExporter creates an APIServerSpanExporter and adds
+// it to the local tracer config with a processor from
+// NewDebugSpanProcessor.
+func RegisterAPIServerSpanExporter(cfg DebugCaptureConfig) *APIServerSpanExporter {
+	var opts []ExporterOption
+	if cfg.Compact {
+		opts = append(opts, WithCompactTraceDict())
+	}
+	exporter := NewAPIServerSpanExporter(opts...)
+	// Adding a non-nil processor never fails.
+	_ = telemetry.AddSpanProcessor(NewDebugSpanProcessor(exporter, cfg))
+	return exporter
+}
+
+// flush exports the spans queued in the processor of the exporter, if any.
+func (s *APIServerSpanExporter) flush() {
+	s.mu.RLock()
+	processor := s.processor
+	s.mu.RUnlock()
+	if processor == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), debugFlushTimeout)
+	defer cancel()
+	// Spans not flushed in time are returned by a later read.
+	_ = processor.ForceFlush(ctx)
+}
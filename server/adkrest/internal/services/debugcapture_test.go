@@ -0,0 +1,43 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestDebugSpanProcessor(t *testing.T) {
+	exporter := NewAPIServerSpanExporter()
+	// A queue much smaller than the number of spans, and a batch timeout
+	// longer than the test: spans are neither dropped nor delayed.
+	processor := NewDebugSpanProcessor(exporter, DebugCaptureConfig{MaxQueueSize: 4, BatchTimeout: time.Hour})
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor))
+	defer tp.Shutdown(t.Context())
+
+	const n = 100
+	for i := range n {
+		_, span := tp.Tracer("test").Start(t.Context(), "call_llm")
+		span.SetAttributes(attribute.String("gcp.vertex.agent.event_id", fmt.Sprintf("event-%d", i)))
+		span.End()
+	}
+	if got := len(exporter.GetTraceDict()); got != n {
+		t.Errorf("GetTraceDict() has %d events, want %d", got, n)
+	}
+}
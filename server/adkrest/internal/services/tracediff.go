@@ -0,0 +1,75 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/server/adkrest/internal/models"
+)
+
+// DiffTraces compares the span attribute dictionaries of two events, as
+// returned by APIServerSpanExporter.GetTraceDict, span by span. Spans without
+// a counterpart have all their attributes added or removed. Spans without
+// differences are left out.
+func DiffTraces(before, after []map[string]string) []models.SpanDiff {
+	ignored := map[string]bool{
+		"trace_id":             true,
+		"span_id":              true,
+		"parent_span_id":       true,
+		telemetry.EventIDKey(): true,
+	}
+	diffs := []models.SpanDiff{}
+	for i := range max(len(before), len(after)) {
+		var b, a map[string]string
+		if i < len(before) {
+			b = before[i]
+		}
+		if i < len(after) {
+			a = after[i]
+		}
+		d := models.SpanDiff{Index: i}
+		for k, old := range b {
+			if ignored[k] {
+				continue
+			}
+			v, ok := a[k]
+			switch {
+			case !ok:
+				if d.Removed == nil {
+					d.Removed = make(map[string]string)
+				}
+				d.Removed[k] = old
+			case v != old:
+				if d.Changed == nil {
+					d.Changed = make(map[string]models.AttributeChange)
+				}
+				d.Changed[k] = models.AttributeChange{Old: old, New: v}
+			}
+		}
+		for k, v := range a {
+			if _, ok := b[k]; ok || ignored[k] {
+				continue
+			}
+			if d.Added == nil {
+				d.Added = make(map[string]string)
+			}
+			d.Added[k] = v
+		}
+		if d.Added != nil || d.Removed != nil || d.Changed != nil {
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,430 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/internal/clock"
+)
+
+// Defaults used by [NewBufferedService] for unset [BufferConfig] fields.
+const (
+	DefaultFlushInterval     = 100 * time.Millisecond
+	DefaultMaxBufferedEvents = 64
+)
+
+// BufferConfig configures a [BufferedService].
+type BufferConfig struct {
+	// FlushInterval is how often buffered events are written to the
+	// underlying service. Defaults to [DefaultFlushInterval].
+	FlushInterval time.Duration
+	// MaxBufferedEvents is the number of buffered events of a session
+	// that triggers a synchronous flush of that session. Defaults to
+	// [DefaultMaxBufferedEvents].
+	MaxBufferedEvents int
+}
+
+// Flusher is implemented by session services that buffer writes.
+// The runner flushes the session when a run finishes.
+type Flusher interface {
+	// FlushSession writes all buffered events of the session to storage.
+	FlushSession(ctx context.Context, appName, userID, sessionID string) error
+}
+
+// BufferedService is a write-behind buffer in front of another [Service].
+//
+// AppendEvent queues events instead of writing them immediately. Queued
+// events are written to the underlying service periodically, when a session
+// buffers MaxBufferedEvents events, when the session is read with Get or
+// List, and when [BufferedService.FlushSession] or [BufferedService.Close]
+// is called. Events of a session are always written in append order.
+//
+// Sessions returned by the service include buffered events and their state
+// deltas, so a run observes its own writes before they are flushed. Events
+// that fail to flush are kept and retried; a background flush error is
+// returned by the next AppendEvent for that session, which still queues its
+// event.
+type BufferedService struct {
+	inner         Service
+	clock         clock.Clock
+	flushInterval time.Duration
+	maxEvents     int
+
+	mu      sync.Mutex
+	buffers map[sessionKey]*eventBuffer
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewBufferedService returns a [BufferedService] writing to inner. Call
+// [BufferedService.Close] to flush the remaining events and stop the
+// background flusher.
+func NewBufferedService(inner Service, cfg BufferConfig) *BufferedService {
+	return newBufferedService(inner, cfg, clock.Real())
+}
+
+// newBufferedService is NewBufferedService with the flush interval ticked
+// by clk.
+func newBufferedService(inner Service, cfg BufferConfig, clk clock.Clock) *BufferedService {
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	if cfg.MaxBufferedEvents <= 0 {
+		cfg.MaxBufferedEvents = DefaultMaxBufferedEvents
+	}
+	s := &BufferedService{
+		inner:         inner,
+		clock:         clk,
+		flushInterval: cfg.FlushInterval,
+		maxEvents:     cfg.MaxBufferedEvents,
+		buffers:       make(map[sessionKey]*eventBuffer),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go s.flushLoop(s.clock.NewTicker(s.flushInterval))
+	return s
+}
+
+type sessionKey struct {
+	appName, userID, sessionID string
+}
+
+// eventBuffer holds the queued events of one session. It is removed from
+// the service once a flush empties it.
+type eventBuffer struct {
+	key sessionKey
+	// flushMu serializes flushes so that events are written in order.
+	flushMu sync.Mutex
+
+	mu      sync.Mutex
+	entries []bufferedEvent
+	err     error
+	// removed reports whether the buffer was removed from the service;
+	// events are then queued in a new buffer of the session.
+	removed bool
+}
+
+type bufferedEvent struct {
+	session *bufferedSession
+	event   *Event
+}
+
+// IDGenerator implements [IDGeneratorProvider], returning the generator of
+// the inner service.
+func (s *BufferedService) IDGenerator() IDGenerator {
+	return IDGeneratorOf(s.inner)
+}
+
+func (s *BufferedService) Create(ctx context.Context, req *CreateRequest) (*CreateResponse, error) {
+	resp, err := s.inner.Create(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &CreateResponse{Session: s.wrap(resp.Session)}, nil
+}
+
+func (s *BufferedService) Get(ctx context.Context, req *GetRequest) (*GetResponse, error) {
+	if err := s.FlushSession(ctx, req.AppName, req.UserID, req.SessionID); err != nil {
+		return nil, err
+	}
+	resp, err := s.inner.Get(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &GetResponse{Session: s.wrap(resp.Session)}, nil
+}
+
+func (s *BufferedService) List(ctx context.Context, req *ListRequest) (*ListResponse, error) {
+	if err := s.Flush(ctx); err != nil {
+		return nil, err
+	}
+	resp, err := s.inner.List(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]Session, 0, len(resp.Sessions))
+	for _, sess := range resp.Sessions {
+		sessions = append(sessions, s.wrap(sess))
+	}
+	return &ListResponse{Sessions: sessions}, nil
+}
+
+// Delete drops the buffered events of the session and deletes it from the
+// underlying service.
+func (s *BufferedService) Delete(ctx context.Context, req *DeleteRequest) error {
+	s.mu.Lock()
+	key := sessionKey{req.AppName, req.UserID, req.SessionID}
+	if buf, ok := s.buffers[key]; ok {
+		buf.mu.Lock()
+		buf.removed = true
+		buf.mu.Unlock()
+		delete(s.buffers, key)
+	}
+	s.mu.Unlock()
+	return s.inner.Delete(ctx, req)
+}
+
+// AppendEvent queues the event for the session. Sessions not obtained from
+// this service are written through directly.
+func (s *BufferedService) AppendEvent(ctx context.Context, sess Session, event *Event) error {
+	bs, ok := sess.(*bufferedSession)
+	if !ok {
+		return s.inner.AppendEvent(ctx, sess, event)
+	}
+	if event == nil || event.Partial {
+		return nil
+	}
+
+	key := sessionKey{bs.AppName(), bs.UserID(), bs.ID()}
+	buf := s.buffer(key)
+	buf.mu.Lock()
+	for buf.removed {
+		buf.mu.Unlock()
+		buf = s.buffer(key)
+		buf.mu.Lock()
+	}
+	bs.mu.Lock()
+	bs.pending = append(bs.pending, event)
+	bs.mu.Unlock()
+	// The underlying service may modify the event it stores, while the
+	// caller keeps using the original.
+	stored := *event
+	buf.entries = append(buf.entries, bufferedEvent{session: bs, event: &stored})
+	full := len(buf.entries) >= s.maxEvents
+	// The event is queued even if a background flush failed, so that it is
+	// written with the failed events when a later flush succeeds.
+	flushErr := buf.err
+	buf.err = nil
+	buf.mu.Unlock()
+
+	if flushErr != nil {
+		return fmt.Errorf("failed to flush buffered events: %w", flushErr)
+	}
+	if full {
+		return s.flush(ctx, buf)
+	}
+	return nil
+}
+
+// FlushSession writes the buffered events of the session to the underlying
+// service.
+func (s *BufferedService) FlushSession(ctx context.Context, appName, userID, sessionID string) error {
+	s.mu.Lock()
+	buf, ok := s.buffers[sessionKey{appName, userID, sessionID}]
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return s.flush(ctx, buf)
+}
+
+// Flush writes the buffered events of all sessions to the underlying service.
+func (s *BufferedService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	buffers := make([]*eventBuffer, 0, len(s.buffers))
+	for _, buf := range s.buffers {
+		buffers = append(buffers, buf)
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	for _, buf := range buffers {
+		if err := s.flush(ctx, buf); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops the background flusher and flushes all buffered events.
+func (s *BufferedService) Close(ctx context.Context) error {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+	})
+	return s.Flush(ctx)
+}
+
+func (s *BufferedService) buffer(key sessionKey) *eventBuffer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buf, ok := s.buffers[key]
+	if !ok {
+		buf = &eventBuffer{key: key}
+		s.buffers[key] = buf
+	}
+	return buf
+}
+
+// flush writes the queued events of buf in order, then removes buf if no
+// events were queued meanwhile. If an event fails to be written, it and the
+// events after it are put back at the front of the queue.
+func (s *BufferedService) flush(ctx context.Context, buf *eventBuffer) error {
+	buf.flushMu.Lock()
+	defer buf.flushMu.Unlock()
+
+	buf.mu.Lock()
+	entries := buf.entries
+	buf.entries = nil
+	buf.mu.Unlock()
+
+	for i, e := range entries {
+		e.session.mu.Lock()
+		err := s.inner.AppendEvent(ctx, e.session.inner, e.event)
+		if err == nil {
+			e.session.pending = e.session.pending[1:]
+		}
+		e.session.mu.Unlock()
+		if err != nil {
+			buf.mu.Lock()
+			buf.entries = append(entries[i:len(entries):len(entries)], buf.entries...)
+			buf.mu.Unlock()
+			return fmt.Errorf("failed to append event to session %q: %w", e.session.ID(), err)
+		}
+	}
+	s.mu.Lock()
+	buf.mu.Lock()
+	if len(buf.entries) == 0 && buf.err == nil && !buf.removed {
+		delete(s.buffers, buf.key)
+		buf.removed = true
+	}
+	buf.mu.Unlock()
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *BufferedService) flushLoop(ticker clock.Ticker) {
+	defer close(s.done)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C():
+			s.mu.Lock()
+			buffers := make([]*eventBuffer, 0, len(s.buffers))
+			for _, buf := range s.buffers {
+				buffers = append(buffers, buf)
+			}
+			s.mu.Unlock()
+			for _, buf := range buffers {
+				if err := s.flush(context.Background(), buf); err != nil {
+					buf.mu.Lock()
+					buf.err = err
+					buf.mu.Unlock()
+				}
+			}
+		}
+	}
+}
+
+func (s *BufferedService) wrap(sess Session) Session {
+	if sess == nil {
+		return nil
+	}
+	return &bufferedSession{inner: sess}
+}
+
+// bufferedSession is a session whose events and state include the events
+// that are buffered but not yet flushed.
+type bufferedSession struct {
+	inner Session
+
+	// mu guards pending and the inner session, which the underlying
+	// service updates when a buffered event is flushed.
+	mu      sync.Mutex
+	pending []*Event
+}
+
+func (s *bufferedSession) ID() string      { return s.inner.ID() }
+func (s *bufferedSession) AppName() string { return s.inner.AppName() }
+func (s *bufferedSession) UserID() string  { return s.inner.UserID() }
+
+func (s *bufferedSession) State() State {
+	return &bufferedState{session: s}
+}
+
+func (s *bufferedSession) Events() Events {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	evs := make(events, 0, s.inner.Events().Len()+len(s.pending))
+	for ev := range s.inner.Events().All() {
+		evs = append(evs, ev)
+	}
+	return append(evs, s.pending...)
+}
+
+func (s *bufferedSession) LastUpdateTime() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n := len(s.pending); n > 0 {
+		return s.pending[n-1].Timestamp
+	}
+	return s.inner.LastUpdateTime()
+}
+
+// bufferedState overlays the state deltas of buffered events on the state
+// of the underlying session.
+type bufferedState struct {
+	session *bufferedSession
+}
+
+func (s *bufferedState) Get(key string) (any, error) {
+	s.session.mu.Lock()
+	defer s.session.mu.Unlock()
+	if !strings.HasPrefix(key, KeyPrefixTemp) {
+		for i := len(s.session.pending) - 1; i >= 0; i-- {
+			if v, ok := s.session.pending[i].Actions.StateDelta[key]; ok {
+				return v, nil
+			}
+		}
+	}
+	return s.session.inner.State().Get(key)
+}
+
+func (s *bufferedState) Set(key string, value any) error {
+	s.session.mu.Lock()
+	defer s.session.mu.Unlock()
+	return s.session.inner.State().Set(key, value)
+}
+
+func (s *bufferedState) All() iter.Seq2[string, any] {
+	s.session.mu.Lock()
+	merged := maps.Collect(s.session.inner.State().All())
+	for _, ev := range s.session.pending {
+		for k, v := range ev.Actions.StateDelta {
+			if !strings.HasPrefix(k, KeyPrefixTemp) {
+				merged[k] = v
+			}
+		}
+	}
+	s.session.mu.Unlock()
+	return maps.All(merged)
+}
+
+var (
+	_ Service = (*BufferedService)(nil)
+	_ Flusher = (*BufferedService)(nil)
+)
@@ -0,0 +1,243 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/adk/internal/clock"
+)
+
+func newBufferedTestSession(t *testing.T, cfg BufferConfig, clk clock.Clock) (Service, *BufferedService, Session) {
+	t.Helper()
+	inner := InMemoryService()
+	s := newBufferedService(inner, cfg, clk)
+	t.Cleanup(func() { _ = s.Close(context.Background()) })
+	resp, err := s.Create(t.Context(), &CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inner, s, resp.Session
+}
+
+func storedEventIDs(t *testing.T, s Service) []string {
+	t.Helper()
+	resp, err := s.Get(t.Context(), &GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for ev := range resp.Session.Events().All() {
+		ids = append(ids, ev.ID)
+	}
+	return ids
+}
+
+func appendTestEvents(t *testing.T, s Service, sess Session, n int) []string {
+	t.Helper()
+	var ids []string
+	for i := range n {
+		ev := &Event{
+			ID:        fmt.Sprintf("e%d", i),
+			Timestamp: time.Now(),
+			Actions:   EventActions{StateDelta: map[string]any{"count": i}},
+		}
+		if err := s.AppendEvent(t.Context(), sess, ev); err != nil {
+			t.Fatalf("AppendEvent() error = %v", err)
+		}
+		ids = append(ids, ev.ID)
+	}
+	return ids
+}
+
+func TestBufferedService_NoEventsLostOnFlush(t *testing.T) {
+	// A long interval so that only the size threshold and Flush write events.
+	inner, s, sess := newBufferedTestSession(t, BufferConfig{FlushInterval: time.Hour, MaxBufferedEvents: 4}, clock.Real())
+
+	want := appendTestEvents(t, s, sess, 10)
+
+	// Two size-triggered flushes have written the first 8 events.
+	if diff := cmp.Diff(want[:8], storedEventIDs(t, inner)); diff != "" {
+		t.Errorf("stored events before Flush mismatch (-want +got):\n%s", diff)
+	}
+	// The session still observes all of its events and state.
+	if got := sess.Events().Len(); got != len(want) {
+		t.Errorf("session has %d events, want %d", got, len(want))
+	}
+	if got, err := sess.State().Get("count"); err != nil || got != 9 {
+		t.Errorf("State().Get(count) = %v, %v, want 9", got, err)
+	}
+
+	if err := s.Flush(t.Context()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if diff := cmp.Diff(want, storedEventIDs(t, inner)); diff != "" {
+		t.Errorf("stored events mismatch (-want +got):\n%s", diff)
+	}
+	if got := sess.Events().Len(); got != len(want) {
+		t.Errorf("session has %d events after Flush, want %d", got, len(want))
+	}
+}
+
+func TestBufferedService_RemovesFlushedBuffers(t *testing.T) {
+	inner, s, sess := newBufferedTestSession(t, BufferConfig{FlushInterval: time.Hour}, clock.Real())
+	buffers := func() int {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.buffers)
+	}
+
+	want := appendTestEvents(t, s, sess, 2)
+	if got := buffers(); got != 1 {
+		t.Fatalf("got %d buffers, want 1", got)
+	}
+	if err := s.FlushSession(t.Context(), "app", "user", "s1"); err != nil {
+		t.Fatalf("FlushSession() error = %v", err)
+	}
+	if got := buffers(); got != 0 {
+		t.Errorf("got %d buffers after FlushSession, want 0", got)
+	}
+
+	// The session keeps buffering its events in a new buffer.
+	ev := &Event{ID: "e2", Timestamp: time.Now()}
+	if err := s.AppendEvent(t.Context(), sess, ev); err != nil {
+		t.Fatalf("AppendEvent() error = %v", err)
+	}
+	if err := s.Flush(t.Context()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if diff := cmp.Diff(append(want, ev.ID), storedEventIDs(t, inner)); diff != "" {
+		t.Errorf("stored events mismatch (-want +got):\n%s", diff)
+	}
+	if got := buffers(); got != 0 {
+		t.Errorf("got %d buffers after Flush, want 0", got)
+	}
+}
+
+func TestBufferedService_GetFlushes(t *testing.T) {
+	_, s, sess := newBufferedTestSession(t, BufferConfig{FlushInterval: time.Hour}, clock.Real())
+
+	want := appendTestEvents(t, s, sess, 3)
+	if diff := cmp.Diff(want, storedEventIDs(t, s)); diff != "" {
+		t.Errorf("Get() events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBufferedService_FlushInterval(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	inner, s, sess := newBufferedTestSession(t, BufferConfig{FlushInterval: time.Minute}, fake)
+
+	want := appendTestEvents(t, s, sess, 3)
+	if got := storedEventIDs(t, inner); len(got) != 0 {
+		t.Fatalf("events stored before the flush interval: %v", got)
+	}
+	fake.Advance(time.Minute)
+	// The flush runs in the background once the tick is delivered.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got := storedEventIDs(t, inner)
+		if cmp.Equal(want, got) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stored events = %v, want %v", got, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// failingService fails the first fail appends.
+type failingService struct {
+	Service
+	fail int
+}
+
+func (s *failingService) AppendEvent(ctx context.Context, sess Session, event *Event) error {
+	if s.fail > 0 {
+		s.fail--
+		return errors.New("unavailable")
+	}
+	return s.Service.AppendEvent(ctx, sess, event)
+}
+
+func TestBufferedService_RetriesFailedFlush(t *testing.T) {
+	inner := &failingService{Service: InMemoryService()}
+	s := NewBufferedService(inner, BufferConfig{FlushInterval: time.Hour})
+	t.Cleanup(func() { _ = s.Close(context.Background()) })
+	resp, err := s.Create(t.Context(), &CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := appendTestEvents(t, s, resp.Session, 3)
+	inner.fail = 1
+	if err := s.Flush(t.Context()); err == nil {
+		t.Fatal("Flush() succeeded, want error")
+	}
+	if err := s.Flush(t.Context()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if diff := cmp.Diff(want, storedEventIDs(t, inner)); diff != "" {
+		t.Errorf("stored events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBufferedService_AppendAfterFailedBackgroundFlush(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	inner := &failingService{Service: InMemoryService()}
+	s := newBufferedService(inner, BufferConfig{FlushInterval: time.Minute}, fake)
+	t.Cleanup(func() { _ = s.Close(context.Background()) })
+	resp, err := s.Create(t.Context(), &CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := appendTestEvents(t, s, resp.Session, 1)
+	inner.fail = 1
+	fake.Advance(time.Minute)
+	// The flush runs in the background once the tick is delivered.
+	buf := s.buffer(sessionKey{"app", "user", "s1"})
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		buf.mu.Lock()
+		failed := buf.err != nil
+		buf.mu.Unlock()
+		if failed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("background flush did not fail")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ev := &Event{ID: "after", Timestamp: time.Now()}
+	if err := s.AppendEvent(t.Context(), resp.Session, ev); err == nil {
+		t.Fatal("AppendEvent() succeeded, want the background flush error")
+	}
+	want = append(want, ev.ID)
+	if err := s.Flush(t.Context()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if diff := cmp.Diff(want, storedEventIDs(t, inner)); diff != "" {
+		t.Errorf("stored events mismatch (-want +got):\n%s", diff)
+	}
+}
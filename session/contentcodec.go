@@ -0,0 +1,57 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"encoding/json"
+
+	"google.golang.org/genai"
+)
+
+// ContentCodec converts the content of events to and from the format in
+// which a session service stores it, e.g. to compress large contents or to
+// map them to the schema of a datastore. Services keeping events in memory
+// do not encode them.
+type ContentCodec interface {
+	// Name identifies the format of the encoded contents. Services store it
+	// with each content, so that a content is only decoded by a codec of
+	// the format it was encoded with.
+	Name() string
+	Encode(*genai.Content) ([]byte, error)
+	Decode([]byte) (*genai.Content, error)
+}
+
+// JSONContentCodecName is the name of [JSONContentCodec].
+const JSONContentCodecName = "json"
+
+// JSONContentCodec encodes contents as their JSON. It is the codec of
+// services configured with none.
+var JSONContentCodec ContentCodec = jsonContentCodec{}
+
+type jsonContentCodec struct{}
+
+func (jsonContentCodec) Name() string { return JSONContentCodecName }
+
+func (jsonContentCodec) Encode(c *genai.Content) ([]byte, error) {
+	return json.Marshal(c)
+}
+
+func (jsonContentCodec) Decode(b []byte) (*genai.Content, error) {
+	var c *genai.Content
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
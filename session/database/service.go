@@ -22,15 +22,22 @@ import (
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"google.golang.org/adk/internal/clock"
 	"google.golang.org/adk/session"
 )
 
 // databaseService is an database implementation of sessionService.Service.
 type databaseService struct {
 	db *gorm.DB
+	// codec encodes the contents of events, JSON if nil.
+	codec session.ContentCodec
+	// idGenerator generates the IDs of sessions and events, the default
+	// generator if nil.
+	idGenerator session.IDGenerator
+	// clock tells the creation time of sessions.
+	clock clock.Clock
 }
 
 // NewSessionService creates a new [session.Service] implementation that uses a
@@ -46,7 +53,7 @@ func NewSessionService(dialector gorm.Dialector, opts ...gorm.Option) (session.S
 	if err != nil {
 		return nil, fmt.Errorf("error creating database session service: %w", err)
 	}
-	return &databaseService{db: db}, nil
+	return &databaseService{db: db, clock: clock.Real()}, nil
 }
 
 // AutoMigrate runs the GORM auto-migration tool to ensure the database schema
@@ -67,6 +74,64 @@ func AutoMigrate(service session.Service) error {
 	return nil
 }
 
+// SetContentCodec makes the service store the contents of the events it
+// appends in the format of codec, e.g. compressed. Contents in the JSON
+// format, the default, are stored in the content column, and contents in
+// other formats in the encoded_content column with the name of their codec.
+// Events whose content is in another format than JSON or that of codec
+// cannot be read.
+//
+// NOTE: This function relies on a type assertion to the concrete *databaseService
+// implementation. It will return an error if the provided session.Service is
+// a different implementation.
+func SetContentCodec(service session.Service, codec session.ContentCodec) error {
+	dbservice, ok := service.(*databaseService)
+	if !ok {
+		return fmt.Errorf("invalid session service type")
+	}
+	if codec == nil {
+		return fmt.Errorf("content codec is nil")
+	}
+	dbservice.codec = codec
+	return nil
+}
+
+// SetIDGenerator makes the service generate the IDs of its sessions and
+// events with g, e.g. to embed the shard of the database in them. See
+// session.IDGenerator for the uniqueness contract of the IDs.
+//
+// NOTE: This function relies on a type assertion to the concrete *databaseService
+// implementation. It will return an error if the provided session.Service is
+// a different implementation.
+func SetIDGenerator(service session.Service, g session.IDGenerator) error {
+	dbservice, ok := service.(*databaseService)
+	if !ok {
+		return fmt.Errorf("invalid session service type")
+	}
+	if g == nil {
+		return fmt.Errorf("ID generator is nil")
+	}
+	dbservice.idGenerator = g
+	return nil
+}
+
+// IDGenerator returns the generator of the IDs of the sessions and events of
+// the service, implementing session.IDGeneratorProvider.
+func (s *databaseService) IDGenerator() session.IDGenerator {
+	if s.idGenerator == nil {
+		return session.DefaultIDGenerator()
+	}
+	return s.idGenerator
+}
+
+// contentCodec returns the codec of the contents of the events.
+func (s *databaseService) contentCodec() session.ContentCodec {
+	if s.codec == nil {
+		return session.JSONContentCodec
+	}
+	return s.codec
+}
+
 // Create generates a session and inserts it to the db, implements session.Service
 func (s *databaseService) Create(ctx context.Context, req *session.CreateRequest) (*session.CreateResponse, error) {
 	if req.AppName == "" || req.UserID == "" {
@@ -75,7 +140,7 @@ func (s *databaseService) Create(ctx context.Context, req *session.CreateRequest
 
 	sessionID := req.SessionID
 	if sessionID == "" {
-		sessionID = uuid.NewString()
+		sessionID = s.IDGenerator().NewSessionID(ctx, req.AppName, req.UserID)
 	}
 
 	stateMap := req.State
@@ -87,7 +152,7 @@ func (s *databaseService) Create(ctx context.Context, req *session.CreateRequest
 		userID:    req.UserID,
 		sessionID: sessionID,
 		state:     stateMap,
-		updatedAt: time.Now(),
+		updatedAt: s.clock.Now(),
 	}
 	createdSession, err := createStorageSession(val)
 	if err != nil {
@@ -205,7 +270,7 @@ func (s *databaseService) Get(ctx context.Context, req *session.GetRequest) (*se
 	// Convert storage events to response events
 	responseEvents := make([]*session.Event, 0, len(storageEvents))
 	for i := len(storageEvents) - 1; i >= 0; i-- {
-		evt, err := createEventFromStorageEvent(&storageEvents[i])
+		evt, err := createEventFromStorageEvent(&storageEvents[i], s.contentCodec())
 		if err != nil {
 			return nil, fmt.Errorf("failed to map storage event: %w", err)
 		}
@@ -218,6 +283,36 @@ func (s *databaseService) Get(ctx context.Context, req *session.GetRequest) (*se
 	}, nil
 }
 
+// GetEvent implements [session.EventGetter], fetching only the requested
+// event.
+func (s *databaseService) GetEvent(ctx context.Context, req *session.GetEventRequest) (*session.GetEventResponse, error) {
+	appName, userID, sessionID := req.AppName, req.UserID, req.SessionID
+	if appName == "" || userID == "" || sessionID == "" {
+		return nil, fmt.Errorf("app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", appName, userID, sessionID)
+	}
+
+	var found storageEvent
+	err := s.db.WithContext(ctx).
+		Where(&storageEvent{
+			ID:        req.EventID,
+			AppName:   appName,
+			UserID:    userID,
+			SessionID: sessionID,
+		}).
+		First(&found).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %q in session %q", session.ErrEventNotFound, req.EventID, sessionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("database error while fetching event: %w", err)
+	}
+	event, err := createEventFromStorageEvent(&found, s.contentCodec())
+	if err != nil {
+		return nil, fmt.Errorf("failed to map storage event: %w", err)
+	}
+	return &session.GetEventResponse{Event: event}, nil
+}
+
 // List retrieves sessions from the database using its appName and optional UserID
 func (s *databaseService) List(ctx context.Context, req *session.ListRequest) (*session.ListResponse, error) {
 	appName, userID := req.AppName, req.UserID
@@ -409,7 +504,7 @@ func (s *databaseService) applyEvent(ctx context.Context, session *localSession,
 		}
 
 		// Create the new event record in the database.
-		storageEv, err := createStorageEvent(session, event)
+		storageEv, err := createStorageEvent(session, event, s.contentCodec())
 		if err != nil {
 			return fmt.Errorf("failed to map event to storage model: %w", err)
 		}
@@ -15,6 +15,10 @@
 package database
 
 import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
 	"maps"
 	"strconv"
 	"testing"
@@ -1015,3 +1019,161 @@ func emptyService(t *testing.T) *databaseService {
 	}
 	return dbservice
 }
+
+func Test_databaseService_GetEvent(t *testing.T) {
+	service := serviceDbWithData(t)
+	for _, tc := range []struct {
+		name         string
+		req          *session.GetEventRequest
+		wantErr      bool
+		wantNotFound bool
+	}{
+		{
+			name: "event exists",
+			req:  &session.GetEventRequest{AppName: "app2", UserID: "user2", SessionID: "session2", EventID: "existing_event1"},
+		},
+		{
+			name:         "event of another session",
+			req:          &session.GetEventRequest{AppName: "app1", UserID: "user1", SessionID: "session1", EventID: "existing_event1"},
+			wantErr:      true,
+			wantNotFound: true,
+		},
+		{
+			name:    "missing session ID",
+			req:     &session.GetEventRequest{AppName: "app2", UserID: "user2", EventID: "existing_event1"},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := session.GetEvent(t.Context(), service, tc.req)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("GetEvent() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got := errors.Is(err, session.ErrEventNotFound); got != tc.wantNotFound {
+				t.Errorf("GetEvent() error = %v, is ErrEventNotFound = %v, want %v", err, got, tc.wantNotFound)
+			}
+			if err == nil && resp.Event.ID != tc.req.EventID {
+				t.Errorf("GetEvent() returned event %q, want %q", resp.Event.ID, tc.req.EventID)
+			}
+		})
+	}
+}
+
+func Test_databaseService_EventKind(t *testing.T) {
+	service := emptyService(t)
+	created, err := service.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ev := range []*session.Event{
+		{ID: "with_kind", Author: "agent", Kind: session.EventKindToolCall, Timestamp: time.Now()},
+		{ID: "legacy", Author: "user", Timestamp: time.Now()},
+	} {
+		if err := service.AppendEvent(t.Context(), created.Session, ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := service.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make(map[string]session.EventKind)
+	for ev := range got.Session.Events().All() {
+		kinds[ev.ID] = ev.Kind
+	}
+	if diff := cmp.Diff(map[string]session.EventKind{"with_kind": session.EventKindToolCall, "legacy": ""}, kinds); diff != "" {
+		t.Errorf("stored event kinds mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// gzipCodec stores contents as gzipped JSON.
+type gzipCodec struct{ name string }
+
+func (c gzipCodec) Name() string { return c.name }
+
+func (gzipCodec) Encode(content *genai.Content) ([]byte, error) {
+	b, err := session.JSONContentCodec.Encode(content)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCodec) Decode(b []byte) (*genai.Content, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return session.JSONContentCodec.Decode(decoded)
+}
+
+func Test_databaseService_ContentCodec(t *testing.T) {
+	service := emptyService(t)
+	created, err := service.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonContent := genai.NewContentFromText("stored as JSON", genai.RoleUser)
+	if err := service.AppendEvent(t.Context(), created.Session, &session.Event{ID: "json", Author: "user", Timestamp: time.Now(), LLMResponse: model.LLMResponse{Content: jsonContent}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetContentCodec(service, gzipCodec{name: "gzip"}); err != nil {
+		t.Fatal(err)
+	}
+	gzipContent := genai.NewContentFromText("stored gzipped", genai.RoleModel)
+	if err := service.AppendEvent(t.Context(), created.Session, &session.Event{ID: "gzip", Author: "agent", Timestamp: time.Now(), LLMResponse: model.LLMResponse{Content: gzipContent}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Contents of both formats are decoded.
+	got, err := service.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := make(map[string]*genai.Content)
+	for ev := range got.Session.Events().All() {
+		contents[ev.ID] = ev.Content
+	}
+	if diff := cmp.Diff(map[string]*genai.Content{"json": jsonContent, "gzip": gzipContent}, contents); diff != "" {
+		t.Errorf("Get() contents mismatch (-want +got):\n%s", diff)
+	}
+	resp, err := service.GetEvent(t.Context(), &session.GetEventRequest{AppName: "app", UserID: "user", SessionID: "s1", EventID: "gzip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(gzipContent, resp.Event.Content); diff != "" {
+		t.Errorf("GetEvent() content mismatch (-want +got):\n%s", diff)
+	}
+
+	// The encoded content is stored with the name of its codec.
+	var row storageEvent
+	if err := service.db.Where(&storageEvent{ID: "gzip", AppName: "app", UserID: "user", SessionID: "s1"}).First(&row).Error; err != nil {
+		t.Fatal(err)
+	}
+	if row.Content != nil || derefOrZero(row.ContentCodec) != "gzip" || len(row.EncodedContent) == 0 {
+		t.Errorf("stored row has content %q, codec %q and %d bytes of encoded content, want no content, codec %q and encoded content", row.Content, derefOrZero(row.ContentCodec), len(row.EncodedContent), "gzip")
+	}
+
+	// Contents of another codec are not decoded.
+	if err := SetContentCodec(service, gzipCodec{name: "other"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"}); err == nil {
+		t.Errorf("Get() with another codec succeeded, want error")
+	}
+	if err := SetContentCodec(session.InMemoryService(), gzipCodec{name: "gzip"}); err == nil {
+		t.Errorf("SetContentCodec() of an in-memory service succeeded, want error")
+	}
+}
@@ -50,8 +50,8 @@ func createStorageSession(s *localSession) (*storageSession, error) {
 		AppName:    s.appName,
 		ID:         s.sessionID,
 		State:      s.state,
-		CreateTime: time.Now(),
-		UpdateTime: time.Now(),
+		CreateTime: s.updatedAt,
+		UpdateTime: s.updatedAt,
 	}, nil
 }
 
@@ -88,12 +88,21 @@ type storageEvent struct {
 	CustomMetadata    dynamicJSON
 	UsageMetadata     dynamicJSON
 	CitationMetadata  dynamicJSON
+	SafetyScores      dynamicJSON
+	Labels            dynamicJSON
+
+	// EncodedContent is the content encoded by the codec named by
+	// ContentCodec, if it is not JSON.
+	EncodedContent []byte
+	ContentCodec   *string
 
 	Partial      *bool
 	TurnComplete *bool
 	ErrorCode    *string
 	ErrorMessage *string
 	Interrupted  *bool
+	Kind         *string
+	Interim      *bool
 
 	// Belongs-To relationship: An event belongs to a session.
 	Session storageSession `gorm:"foreignKey:AppName,UserID,SessionID;references:AppName,UserID,ID"`
@@ -106,7 +115,7 @@ func (storageEvent) TableName() string {
 
 // createStorageEvent translates the application-level Session and Event models
 // into a GORM-compatible storageEvent struct, ready for database insertion.
-func createStorageEvent(session session.Session, event *session.Event) (*storageEvent, error) {
+func createStorageEvent(session session.Session, event *session.Event, codec session.ContentCodec) (*storageEvent, error) {
 	// Initialize the base storageEvent with direct field mappings.
 	storageEv := &storageEvent{
 		ID:           event.ID,
@@ -146,17 +155,29 @@ func createStorageEvent(session session.Session, event *session.Event) (*storage
 	if event.ErrorMessage != "" {
 		storageEv.ErrorMessage = &event.ErrorMessage
 	}
+	if event.Kind != "" {
+		kind := string(event.Kind)
+		storageEv.Kind = &kind
+	}
 
 	// For booleans, we can assign pointers directly.
 	storageEv.Partial = &event.Partial
 	storageEv.TurnComplete = &event.TurnComplete
 	storageEv.Interrupted = &event.Interrupted
+	storageEv.Interim = &event.Interim
 
 	// --- Handle JSON content fields ---
 	if event.Content != nil {
-		storageEv.Content, err = json.Marshal(event.Content)
+		encoded, err := codec.Encode(event.Content)
 		if err != nil {
-			return nil, fmt.Errorf("failed to marshal content: %w", err)
+			return nil, fmt.Errorf("failed to encode content: %w", err)
+		}
+		if isJSONCodec(codec.Name()) {
+			storageEv.Content = encoded
+		} else {
+			name := codec.Name()
+			storageEv.EncodedContent = encoded
+			storageEv.ContentCodec = &name
 		}
 	}
 	if event.GroundingMetadata != nil {
@@ -183,6 +204,18 @@ func createStorageEvent(session session.Session, event *session.Event) (*storage
 			return nil, fmt.Errorf("failed to marshal citation metadata: %w", err)
 		}
 	}
+	if len(event.SafetyScores) > 0 {
+		storageEv.SafetyScores, err = json.Marshal(event.SafetyScores)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal safety scores: %w", err)
+		}
+	}
+	if len(event.Labels) > 0 {
+		storageEv.Labels, err = json.Marshal(event.Labels)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal labels: %w", err)
+		}
+	}
 
 	return storageEv, nil
 }
@@ -197,9 +230,15 @@ func derefOrZero[T any](p *T) T {
 	return *p
 }
 
+// isJSONCodec reports whether the content codec of the given name stores
+// contents in the content column.
+func isJSONCodec(name string) bool {
+	return name == session.JSONContentCodecName
+}
+
 // createEventFromStorageEvent translates a GORM storageEvent back into an
 // application-level Event model.
-func createEventFromStorageEvent(se *storageEvent) (*session.Event, error) {
+func createEventFromStorageEvent(se *storageEvent, codec session.ContentCodec) (*session.Event, error) {
 	var actions session.EventActions
 	if len(se.Actions) > 0 {
 		if err := json.Unmarshal(se.Actions, &actions); err != nil {
@@ -208,7 +247,16 @@ func createEventFromStorageEvent(se *storageEvent) (*session.Event, error) {
 	}
 
 	var content *genai.Content
-	if len(se.Content) > 0 {
+	switch name := derefOrZero(se.ContentCodec); {
+	case name != "" && !isJSONCodec(name):
+		if name != codec.Name() {
+			return nil, fmt.Errorf("content of event %q is encoded with codec %q, not %q", se.ID, name, codec.Name())
+		}
+		var err error
+		if content, err = codec.Decode(se.EncodedContent); err != nil {
+			return nil, fmt.Errorf("failed to decode content: %w", err)
+		}
+	case len(se.Content) > 0:
 		if err := json.Unmarshal(se.Content, &content); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal content: %w", err)
 		}
@@ -242,6 +290,20 @@ func createEventFromStorageEvent(se *storageEvent) (*session.Event, error) {
 		}
 	}
 
+	var safetyScores map[string]float64
+	if len(se.SafetyScores) > 0 {
+		if err := json.Unmarshal(se.SafetyScores, &safetyScores); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal safety scores: %w", err)
+		}
+	}
+
+	var labels map[string]string
+	if len(se.Labels) > 0 {
+		if err := json.Unmarshal(se.Labels, &labels); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal labels: %w", err)
+		}
+	}
+
 	// --- Handle JSON-encoded *string field ---
 	var toolIDs []string
 	if se.LongRunningToolIDsJSON != nil {
@@ -258,6 +320,8 @@ func createEventFromStorageEvent(se *storageEvent) (*session.Event, error) {
 	partial := derefOrZero(se.Partial)
 	turnComplete := derefOrZero(se.TurnComplete)
 	interrupted := derefOrZero(se.Interrupted)
+	kind := derefOrZero(se.Kind)
+	interim := derefOrZero(se.Interim)
 
 	// --- Assemble the final Event struct ---
 	event := &session.Event{
@@ -267,6 +331,10 @@ func createEventFromStorageEvent(se *storageEvent) (*session.Event, error) {
 		Timestamp:          se.Timestamp,
 		Actions:            actions,
 		LongRunningToolIDs: toolIDs,
+		SafetyScores:       safetyScores,
+		Labels:             labels,
+		Kind:               session.EventKind(kind),
+		Interim:            interim,
 		Branch:             branch,
 		LLMResponse: model.LLMResponse{
 			Content:           content,
@@ -0,0 +1,64 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+// EventKind is the kind of an event, e.g. a user message or a tool call.
+type EventKind string
+
+const (
+	// EventKindUser is a message of the user.
+	EventKindUser EventKind = "user"
+	// EventKindModelText is a response of an agent without function calls.
+	EventKindModelText EventKind = "model_text"
+	// EventKindToolCall is a response of an agent with function calls.
+	EventKindToolCall EventKind = "tool_call"
+	// EventKindToolResponse holds the responses to function calls.
+	EventKindToolResponse EventKind = "tool_response"
+	// EventKindError reports an error, e.g. a blocked model response or a
+	// run timeout.
+	EventKindError EventKind = "error"
+	// EventKindSummary summarizes earlier events of the session. It is never
+	// derived from the content of an event.
+	EventKindSummary EventKind = "summary"
+	// EventKindPhase reports the stage of a run, see Phase. It is never
+	// derived from the content of an event.
+	EventKindPhase EventKind = "phase"
+)
+
+// KindOf returns the kind of the event: its Kind if set, else the kind
+// derived from its content, e.g. for events stored before kinds were
+// recorded.
+func KindOf(e *Event) EventKind {
+	if e.Kind != "" {
+		return e.Kind
+	}
+	return DeriveKind(e)
+}
+
+// DeriveKind returns the kind of the event derived from its error code,
+// author and content, ignoring its Kind.
+func DeriveKind(e *Event) EventKind {
+	switch {
+	case e.ErrorCode != "":
+		return EventKindError
+	case hasFunctionResponses(&e.LLMResponse):
+		return EventKindToolResponse
+	case e.Author == "user":
+		return EventKindUser
+	case hasFunctionCalls(&e.LLMResponse):
+		return EventKindToolCall
+	}
+	return EventKindModelText
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"testing"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+func TestKindOf(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		event *Event
+		want  EventKind
+	}{
+		{
+			name:  "user message",
+			event: &Event{Author: "user", LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("hi", genai.RoleUser)}},
+			want:  EventKindUser,
+		},
+		{
+			name:  "model text",
+			event: &Event{Author: "agent", LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("hello", genai.RoleModel)}},
+			want:  EventKindModelText,
+		},
+		{
+			name:  "state delta only",
+			event: &Event{Author: "agent"},
+			want:  EventKindModelText,
+		},
+		{
+			name:  "tool call",
+			event: &Event{Author: "agent", LLMResponse: model.LLMResponse{Content: genai.NewContentFromFunctionCall("f", nil, genai.RoleModel)}},
+			want:  EventKindToolCall,
+		},
+		{
+			name:  "tool response",
+			event: &Event{Author: "agent", LLMResponse: model.LLMResponse{Content: genai.NewContentFromFunctionResponse("f", nil, genai.RoleUser)}},
+			want:  EventKindToolResponse,
+		},
+		{
+			name:  "tool response of the user",
+			event: &Event{Author: "user", LLMResponse: model.LLMResponse{Content: genai.NewContentFromFunctionResponse("f", nil, genai.RoleUser)}},
+			want:  EventKindToolResponse,
+		},
+		{
+			name:  "error",
+			event: &Event{Author: "agent", LLMResponse: model.LLMResponse{ErrorCode: "SAFETY", Content: genai.NewContentFromText("x", genai.RoleModel)}},
+			want:  EventKindError,
+		},
+		{
+			name:  "explicit kind",
+			event: &Event{Author: "agent", Kind: EventKindSummary, LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("summary", genai.RoleModel)}},
+			want:  EventKindSummary,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := KindOf(tc.event); got != tc.want {
+				t.Errorf("KindOf() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
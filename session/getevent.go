@@ -0,0 +1,72 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrEventNotFound is returned by [GetEvent] when the session has no event
+// with the requested ID.
+var ErrEventNotFound = errors.New("event not found")
+
+// GetEventRequest represents a request to get a single event of a session.
+type GetEventRequest struct {
+	AppName   string
+	UserID    string
+	SessionID string
+	EventID   string
+}
+
+// GetEventResponse represents a response from [GetEvent].
+type GetEventResponse struct {
+	Event *Event
+}
+
+// EventGetter is implemented by session services that can look up a single
+// event without loading the whole session.
+type EventGetter interface {
+	// GetEvent returns the event of the session with the requested ID, or
+	// an error wrapping [ErrEventNotFound] if there is none.
+	GetEvent(context.Context, *GetEventRequest) (*GetEventResponse, error)
+}
+
+// GetEvent returns the event of a session with the requested ID. It uses
+// the GetEvent method of s if s implements [EventGetter], and otherwise
+// looks for the event in the session returned by s.Get.
+func GetEvent(ctx context.Context, s Service, req *GetEventRequest) (*GetEventResponse, error) {
+	if req.EventID == "" {
+		return nil, errors.New("event_id is required")
+	}
+	if g, ok := s.(EventGetter); ok {
+		return g.GetEvent(ctx, req)
+	}
+	resp, err := s.Get(ctx, &GetRequest{
+		AppName:   req.AppName,
+		UserID:    req.UserID,
+		SessionID: req.SessionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for event := range resp.Session.Events().All() {
+		if event.ID == req.EventID {
+			return &GetEventResponse{Event: event}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q in session %q", ErrEventNotFound, req.EventID, req.SessionID)
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+func TestGetEvent(t *testing.T) {
+	service := InMemoryService()
+	created, err := service.Create(t.Context(), &CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"e1", "e2"} {
+		ev := &Event{ID: id, LLMResponse: model.LLMResponse{Content: genai.NewContentFromText(id, genai.RoleModel)}}
+		if err := service.AppendEvent(t.Context(), created.Session, ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, svc := range map[string]Service{
+		"EventGetter": service,
+		// Embedding hides GetEvent, so the session is loaded with Get.
+		"fallback": struct{ Service }{service},
+	} {
+		t.Run(name, func(t *testing.T) {
+			for _, tc := range []struct {
+				req          GetEventRequest
+				wantErr      bool
+				wantNotFound bool
+			}{
+				{req: GetEventRequest{AppName: "app", UserID: "user", SessionID: "s1", EventID: "e2"}},
+				{req: GetEventRequest{AppName: "app", UserID: "user", SessionID: "s1", EventID: "missing"}, wantErr: true, wantNotFound: true},
+				{req: GetEventRequest{AppName: "app", UserID: "user", SessionID: "missing", EventID: "e2"}, wantErr: true},
+				{req: GetEventRequest{AppName: "app", UserID: "user", SessionID: "s1"}, wantErr: true},
+			} {
+				resp, err := GetEvent(t.Context(), svc, &tc.req)
+				if (err != nil) != tc.wantErr {
+					t.Errorf("GetEvent(%+v) error = %v, wantErr %v", tc.req, err, tc.wantErr)
+					continue
+				}
+				if got := errors.Is(err, ErrEventNotFound); got != tc.wantNotFound {
+					t.Errorf("GetEvent(%+v) error = %v, is ErrEventNotFound = %v, want %v", tc.req, err, got, tc.wantNotFound)
+				}
+				if err == nil && resp.Event.ID != tc.req.EventID {
+					t.Errorf("GetEvent(%+v) returned event %q", tc.req, resp.Event.ID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// IDGenerator generates the IDs of the sessions and events of a session
+// service, e.g. to embed a shard or tenant prefix in them. Services take
+// their generator as configuration, see InMemoryConfig and
+// database.SetIDGenerator, so that services of the same process can
+// generate different IDs.
+//
+// Implementations must be safe for concurrent use. Session IDs must be
+// unique within an app and user, and event IDs must be unique across all
+// sessions: an ID returned once must never be returned again, including
+// across process restarts and by other processes sharing the same storage.
+// IDs must be non-empty and should be URL-safe, since they appear in REST
+// API paths.
+type IDGenerator interface {
+	// NewSessionID returns the ID for a session of the app and user created
+	// without an explicit ID.
+	NewSessionID(ctx context.Context, appName, userID string) string
+	// NewEventID returns the ID for a new event of the session.
+	NewEventID(ctx context.Context, appName, userID, sessionID string) string
+}
+
+// DefaultIDGenerator returns the IDGenerator used by services without one,
+// which returns random UUIDs.
+func DefaultIDGenerator() IDGenerator {
+	return uuidGenerator{}
+}
+
+// IDGeneratorProvider is an optional interface of a Service generating the
+// IDs of its sessions and events with an IDGenerator. See IDGeneratorOf.
+type IDGeneratorProvider interface {
+	IDGenerator() IDGenerator
+}
+
+// IDGeneratorOf returns the IDGenerator of s if it implements
+// IDGeneratorProvider, or the default generator otherwise.
+func IDGeneratorOf(s Service) IDGenerator {
+	if p, ok := s.(IDGeneratorProvider); ok {
+		if g := p.IDGenerator(); g != nil {
+			return g
+		}
+	}
+	return DefaultIDGenerator()
+}
+
+type idScopeKey struct{}
+
+// idScope is the generator and session of the events created with a
+// context.
+type idScope struct {
+	generator                  IDGenerator
+	appName, userID, sessionID string
+}
+
+// ContextWithIDGenerator returns a copy of ctx in which NewEventID and
+// NewEventContext generate the IDs of the events of the given session with
+// g. The runner sets it for its runs with the IDGenerator of its session
+// service.
+func ContextWithIDGenerator(ctx context.Context, g IDGenerator, appName, userID, sessionID string) context.Context {
+	return context.WithValue(ctx, idScopeKey{}, &idScope{generator: g, appName: appName, userID: userID, sessionID: sessionID})
+}
+
+// NewEventID returns the ID for a new event, generated by the IDGenerator
+// of ctx if any (see ContextWithIDGenerator), or a random UUID otherwise.
+func NewEventID(ctx context.Context) string {
+	if scope, ok := ctx.Value(idScopeKey{}).(*idScope); ok && scope.generator != nil {
+		return scope.generator.NewEventID(ctx, scope.appName, scope.userID, scope.sessionID)
+	}
+	return uuid.NewString()
+}
+
+// uuidGenerator is the default IDGenerator.
+type uuidGenerator struct{}
+
+func (uuidGenerator) NewSessionID(context.Context, string, string) string { return uuid.NewString() }
+
+func (uuidGenerator) NewEventID(context.Context, string, string, string) string {
+	return uuid.NewString()
+}
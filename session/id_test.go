@@ -0,0 +1,77 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// prefixIDGenerator prefixes the IDs with the app name, e.g. to select the
+// shard of a tenant.
+type prefixIDGenerator struct {
+	n atomic.Int64
+}
+
+func (g *prefixIDGenerator) NewSessionID(_ context.Context, appName, _ string) string {
+	return appName + "-s" + strconv.FormatInt(g.n.Add(1), 10)
+}
+
+func (g *prefixIDGenerator) NewEventID(_ context.Context, appName, _, sessionID string) string {
+	return appName + "-" + sessionID + "-e" + strconv.FormatInt(g.n.Add(1), 10)
+}
+
+func TestIDGenerator(t *testing.T) {
+	ctx := t.Context()
+	service := NewInMemoryService(InMemoryConfig{IDGenerator: &prefixIDGenerator{}})
+
+	resp, err := service.Create(ctx, &CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionID := resp.Session.ID()
+	if !strings.HasPrefix(sessionID, "app-s") {
+		t.Errorf("session ID = %q, want prefix %q", sessionID, "app-s")
+	}
+
+	eventCtx := ContextWithIDGenerator(ctx, IDGeneratorOf(service), "app", "user", sessionID)
+	if got, want := NewEventContext(eventCtx, "inv").ID, "app-"+sessionID+"-e"; !strings.HasPrefix(got, want) {
+		t.Errorf("event ID = %q, want prefix %q", got, want)
+	}
+
+	// An explicit session ID is kept.
+	resp, err = service.Create(ctx, &CreateRequest{AppName: "app", UserID: "user", SessionID: "explicit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Session.ID(); got != "explicit" {
+		t.Errorf("session ID = %q, want %q", got, "explicit")
+	}
+
+	// Other services and contexts keep the default generator.
+	resp, err = InMemoryService().Create(ctx, &CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Session.ID(); strings.HasPrefix(got, "app-") || got == "" {
+		t.Errorf("session ID of another service = %q, want a UUID", got)
+	}
+	if got := NewEventContext(ctx, "inv").ID; strings.HasPrefix(got, "app-") || got == "" {
+		t.Errorf("event ID without a generator = %q, want a UUID", got)
+	}
+}
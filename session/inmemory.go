@@ -25,10 +25,10 @@ import (
 	"sync"
 	"time"
 
-	"github.com/google/uuid"
 	"rsc.io/omap"
 	"rsc.io/ordered"
 
+	"google.golang.org/adk/internal/clock"
 	"google.golang.org/adk/internal/sessionutils"
 )
 
@@ -41,6 +41,14 @@ type inMemoryService struct {
 	sessions  omap.Map[string, *session] // session.ID) -> storedSession
 	userState map[string]map[string]stateMap
 	appState  map[string]stateMap
+
+	idGenerator IDGenerator
+	// clock tells the creation time of sessions.
+	clock clock.Clock
+}
+
+func (s *inMemoryService) IDGenerator() IDGenerator {
+	return s.idGenerator
 }
 
 func (s *inMemoryService) Create(ctx context.Context, req *CreateRequest) (*CreateResponse, error) {
@@ -50,7 +58,7 @@ func (s *inMemoryService) Create(ctx context.Context, req *CreateRequest) (*Crea
 
 	sessionID := req.SessionID
 	if sessionID == "" {
-		sessionID = uuid.NewString()
+		sessionID = s.idGenerator.NewSessionID(ctx, req.AppName, req.UserID)
 	}
 
 	key := id{
@@ -74,7 +82,7 @@ func (s *inMemoryService) Create(ctx context.Context, req *CreateRequest) (*Crea
 	val := &session{
 		id:        key,
 		state:     state,
-		updatedAt: time.Now(),
+		updatedAt: s.clock.Now(),
 	}
 
 	s.sessions.Set(encodedKey, val)
@@ -138,6 +146,33 @@ func (s *inMemoryService) Get(ctx context.Context, req *GetRequest) (*GetRespons
 	}, nil
 }
 
+// GetEvent implements [EventGetter].
+func (s *inMemoryService) GetEvent(ctx context.Context, req *GetEventRequest) (*GetEventResponse, error) {
+	appName, userID, sessionID := req.AppName, req.UserID, req.SessionID
+	if appName == "" || userID == "" || sessionID == "" {
+		return nil, fmt.Errorf("app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", appName, userID, sessionID)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id := id{
+		appName:   appName,
+		userID:    userID,
+		sessionID: sessionID,
+	}
+	res, ok := s.sessions.Get(id.Encode())
+	if !ok {
+		return nil, fmt.Errorf("session %+v not found", req.SessionID)
+	}
+	for _, event := range res.events {
+		if event.ID == req.EventID {
+			return &GetEventResponse{Event: event}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q in session %q", ErrEventNotFound, req.EventID, sessionID)
+}
+
 func (s *inMemoryService) List(ctx context.Context, req *ListRequest) (*ListResponse, error) {
 	appName, userID := req.AppName, req.UserID
 	if appName == "" {
@@ -458,4 +493,7 @@ func copySessionWithoutStateAndEvents(sess *session) *session {
 	}
 }
 
-var _ Service = (*inMemoryService)(nil)
+var (
+	_ Service     = (*inMemoryService)(nil)
+	_ EventGetter = (*inMemoryService)(nil)
+)
@@ -27,6 +27,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"google.golang.org/genai"
 
+	"google.golang.org/adk/internal/clock"
 	"google.golang.org/adk/model"
 )
 
@@ -1049,3 +1050,29 @@ func Test_inMemoryService_CreateConcurrentAccess(t *testing.T) {
 		t.Errorf("expected %d 'already exists' errors, but got %d", expectedErrors, errorCount.Load())
 	}
 }
+
+func Test_inMemoryService_Timestamps(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	s := NewInMemoryService(InMemoryConfig{}).(*inMemoryService)
+	s.clock = fake
+	resp, err := s.Create(t.Context(), &CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Session.LastUpdateTime(); !got.Equal(now) {
+		t.Errorf("LastUpdateTime() after Create = %v, want %v", got, now)
+	}
+
+	fake.Advance(time.Minute)
+	ev := NewEventContext(clock.NewContext(t.Context(), fake.Now), "invocation")
+	if want := now.Add(time.Minute); !ev.Timestamp.Equal(want) {
+		t.Errorf("NewEventContext() timestamp = %v, want %v", ev.Timestamp, want)
+	}
+	if err := s.AppendEvent(t.Context(), resp.Session, ev); err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Session.LastUpdateTime(); !got.Equal(ev.Timestamp) {
+		t.Errorf("LastUpdateTime() after AppendEvent = %v, want %v", got, ev.Timestamp)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import "google.golang.org/genai"
+
+// NewInterimEvent returns an interim event of the given author holding the
+// text streamed so far of a response. See Event.Interim.
+func NewInterimEvent(invocationID, author, branch, text string) *Event {
+	ev := NewEvent(invocationID)
+	ev.Author = author
+	ev.Branch = branch
+	ev.Kind = EventKindModelText
+	ev.Interim = true
+	ev.Content = &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{genai.NewPartFromText(text)}}
+	return ev
+}
+
+// ReconcileInterim returns the events without the interim events that are
+// superseded, i.e. followed by an event of the same invocation, author and
+// branch that is not an error: a later interim event of the same response,
+// or its final event. The interim events of a response whose streaming was
+// interrupted, e.g. by a crash or a run timeout, are kept up to the last
+// one, which holds all the text streamed before the interruption.
+func ReconcileInterim(events []*Event) []*Event {
+	type key struct{ invocationID, author, branch string }
+	var superseding map[key]bool
+	var superseded map[int]bool
+	for i := len(events) - 1; i >= 0; i-- {
+		ev := events[i]
+		k := key{ev.InvocationID, ev.Author, ev.Branch}
+		if ev.Interim && superseding[k] {
+			if superseded == nil {
+				superseded = make(map[int]bool)
+			}
+			superseded[i] = true
+		}
+		if ev.ErrorCode == "" {
+			if superseding == nil {
+				superseding = make(map[key]bool)
+			}
+			superseding[k] = true
+		}
+	}
+	if len(superseded) == 0 {
+		return events
+	}
+	kept := make([]*Event, 0, len(events)-len(superseded))
+	for i, ev := range events {
+		if !superseded[i] {
+			kept = append(kept, ev)
+		}
+	}
+	return kept
+}
+
+// ReconcileInterimEvents is ReconcileInterim for the events of a session.
+// Readers of the events of a session should use it rather than the stored
+// events, which keep the superseded interim events.
+func ReconcileInterimEvents(evs Events) Events {
+	all := make([]*Event, 0, evs.Len())
+	for ev := range evs.All() {
+		all = append(all, ev)
+	}
+	kept := ReconcileInterim(all)
+	if len(kept) == len(all) {
+		return evs
+	}
+	return events(kept)
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReconcileInterim(t *testing.T) {
+	event := func(id, author, branch string, interim bool, errorCode string) *Event {
+		ev := &Event{ID: id, InvocationID: "inv", Author: author, Branch: branch, Interim: interim}
+		ev.ErrorCode = errorCode
+		return ev
+	}
+	for _, tc := range []struct {
+		name   string
+		events []*Event
+		want   []string
+	}{
+		{
+			name:   "superseded by the final event",
+			events: []*Event{event("u", "user", "", false, ""), event("i1", "a", "", true, ""), event("i2", "a", "", true, ""), event("f", "a", "", false, "")},
+			want:   []string{"u", "f"},
+		},
+		{
+			name:   "last interim event of an interrupted stream is kept",
+			events: []*Event{event("i1", "a", "", true, ""), event("i2", "a", "", true, "")},
+			want:   []string{"i2"},
+		},
+		{
+			name:   "error events do not supersede",
+			events: []*Event{event("i1", "a", "", true, ""), event("t", "a", "", false, "RUN_TIMEOUT")},
+			want:   []string{"i1", "t"},
+		},
+		{
+			name:   "events of other authors and branches do not supersede",
+			events: []*Event{event("i1", "a", "x", true, ""), event("b", "b", "x", false, ""), event("a", "a", "y", false, "")},
+			want:   []string{"i1", "b", "a"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, ev := range ReconcileInterim(tc.events) {
+				got = append(got, ev.ID)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ReconcileInterim() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcileInterimEvents(t *testing.T) {
+	stored := events{
+		{ID: "i1", InvocationID: "inv", Author: "a", Interim: true},
+		{ID: "f", InvocationID: "inv", Author: "a"},
+	}
+	got := ReconcileInterimEvents(stored)
+	if got.Len() != 1 || got.At(0).ID != "f" {
+		t.Errorf("ReconcileInterimEvents() = %v, want only the final event", got)
+	}
+	if stored.Len() != 2 {
+		t.Errorf("ReconcileInterimEvents() modified the stored events: %v", stored)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"google.golang.org/adk/internal/clock"
+)
+
+// Names of the instruments recorded by [NewMetricsService].
+const (
+	MetricOperations        = "adk.session.operations"
+	MetricOperationDuration = "adk.session.operation.duration"
+)
+
+// Attribute keys and values of the instruments recorded by
+// [NewMetricsService].
+const (
+	MetricKeyOperation = "operation"
+	MetricKeyOutcome   = "outcome"
+
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// MetricsConfig configures [NewMetricsService].
+type MetricsConfig struct {
+	// MeterProvider provides the meter the instruments are created with.
+	// Defaults to the global meter provider.
+	MeterProvider metric.MeterProvider
+}
+
+// NewMetricsService returns a [Service] recording a counter and a latency
+// histogram of the operations of inner, labelled by operation (create, get,
+// list, delete, append_event, get_event) and outcome (success, error).
+//
+// The returned service implements [Flusher], delegating to inner if it
+// buffers writes, and [EventGetter], delegating to [GetEvent]. It implements
+// [Snapshotter] if inner does.
+func NewMetricsService(inner Service, cfg MetricsConfig) (Service, error) {
+	return newMetricsService(inner, cfg, clock.Real())
+}
+
+// newMetricsService is NewMetricsService measuring the durations with clk.
+func newMetricsService(inner Service, cfg MetricsConfig, clk clock.Clock) (Service, error) {
+	if inner == nil {
+		return nil, fmt.Errorf("inner session service is required")
+	}
+	mp := cfg.MeterProvider
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+	meter := mp.Meter("google.golang.org/adk/session")
+	ops, err := meter.Int64Counter(MetricOperations,
+		metric.WithDescription("Number of session service operations."),
+		metric.WithUnit("{operation}"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s counter: %w", MetricOperations, err)
+	}
+	duration, err := meter.Float64Histogram(MetricOperationDuration,
+		metric.WithDescription("Duration of session service operations."),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s histogram: %w", MetricOperationDuration, err)
+	}
+	s := &metricsService{inner: inner, ops: ops, duration: duration, clock: clk}
+	if snap, ok := inner.(Snapshotter); ok {
+		return &snapshotMetricsService{metricsService: s, Snapshotter: snap}, nil
+	}
+	return s, nil
+}
+
+type metricsService struct {
+	inner    Service
+	ops      metric.Int64Counter
+	duration metric.Float64Histogram
+	clock    clock.Clock
+}
+
+func (s *metricsService) Create(ctx context.Context, req *CreateRequest) (*CreateResponse, error) {
+	start := s.clock.Now()
+	resp, err := s.inner.Create(ctx, req)
+	s.record(ctx, "create", start, err)
+	return resp, err
+}
+
+func (s *metricsService) Get(ctx context.Context, req *GetRequest) (*GetResponse, error) {
+	start := s.clock.Now()
+	resp, err := s.inner.Get(ctx, req)
+	s.record(ctx, "get", start, err)
+	return resp, err
+}
+
+func (s *metricsService) List(ctx context.Context, req *ListRequest) (*ListResponse, error) {
+	start := s.clock.Now()
+	resp, err := s.inner.List(ctx, req)
+	s.record(ctx, "list", start, err)
+	return resp, err
+}
+
+func (s *metricsService) Delete(ctx context.Context, req *DeleteRequest) error {
+	start := s.clock.Now()
+	err := s.inner.Delete(ctx, req)
+	s.record(ctx, "delete", start, err)
+	return err
+}
+
+func (s *metricsService) AppendEvent(ctx context.Context, sess Session, event *Event) error {
+	start := s.clock.Now()
+	err := s.inner.AppendEvent(ctx, sess, event)
+	s.record(ctx, "append_event", start, err)
+	return err
+}
+
+// GetEvent implements [EventGetter], so that services looking up single
+// events are not read in full through Get.
+func (s *metricsService) GetEvent(ctx context.Context, req *GetEventRequest) (*GetEventResponse, error) {
+	start := s.clock.Now()
+	resp, err := GetEvent(ctx, s.inner, req)
+	s.record(ctx, "get_event", start, err)
+	return resp, err
+}
+
+// IDGenerator implements [IDGeneratorProvider], returning the generator of
+// inner.
+func (s *metricsService) IDGenerator() IDGenerator {
+	return IDGeneratorOf(s.inner)
+}
+
+// FlushSession implements [Flusher].
+func (s *metricsService) FlushSession(ctx context.Context, appName, userID, sessionID string) error {
+	if f, ok := s.inner.(Flusher); ok {
+		return f.FlushSession(ctx, appName, userID, sessionID)
+	}
+	return nil
+}
+
+func (s *metricsService) record(ctx context.Context, op string, start time.Time, err error) {
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeError
+	}
+	attrs := metric.WithAttributes(
+		attribute.String(MetricKeyOperation, op),
+		attribute.String(MetricKeyOutcome, outcome),
+	)
+	s.ops.Add(ctx, 1, attrs)
+	s.duration.Record(ctx, s.clock.Since(start).Seconds(), attrs)
+}
+
+// snapshotMetricsService is a metricsService whose inner service implements
+// [Snapshotter].
+type snapshotMetricsService struct {
+	*metricsService
+	Snapshotter
+}
+
+var (
+	_ Flusher     = (*metricsService)(nil)
+	_ EventGetter = (*metricsService)(nil)
+	_ Snapshotter = (*snapshotMetricsService)(nil)
+)
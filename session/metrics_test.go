@@ -0,0 +1,167 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"google.golang.org/adk/internal/clock"
+)
+
+// slowService advances the fake clock on every Get.
+type slowService struct {
+	Service
+	clock *clock.Fake
+}
+
+func (s slowService) Get(ctx context.Context, req *GetRequest) (*GetResponse, error) {
+	s.clock.Advance(2 * time.Second)
+	return s.Service.Get(ctx, req)
+}
+
+func TestMetricsService(t *testing.T) {
+	fake := clock.NewFake(time.Unix(1_700_000_000, 0))
+	reader := sdkmetric.NewManualReader()
+	s, err := newMetricsService(slowService{Service: InMemoryService(), clock: fake}, MetricsConfig{
+		MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	}, fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := t.Context()
+	created, err := s.Create(ctx, &CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AppendEvent(ctx, created.Session, NewEvent("inv")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, &GetRequest{AppName: "app", UserID: "user", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, &GetRequest{AppName: "app", UserID: "user", SessionID: "missing"}); err == nil {
+		t.Fatal("Get() of a missing session succeeded")
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatal(err)
+	}
+	gotCounts := make(map[string]int64)
+	gotDurations := make(map[string]float64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					gotCounts[metricKey(dp.Attributes)] += dp.Value
+				}
+			case metricdata.Histogram[float64]:
+				for _, dp := range data.DataPoints {
+					gotDurations[metricKey(dp.Attributes)] += dp.Sum
+				}
+			}
+		}
+	}
+
+	wantCounts := map[string]int64{
+		"create/success":       1,
+		"append_event/success": 1,
+		"get/success":          1,
+		"get/error":            1,
+	}
+	if diff := cmp.Diff(wantCounts, gotCounts); diff != "" {
+		t.Errorf("%s mismatch (-want +got):\n%s", MetricOperations, diff)
+	}
+	wantDurations := map[string]float64{
+		"create/success":       0,
+		"append_event/success": 0,
+		"get/success":          2,
+		"get/error":            2,
+	}
+	if diff := cmp.Diff(wantDurations, gotDurations); diff != "" {
+		t.Errorf("%s mismatch (-want +got):\n%s", MetricOperationDuration, diff)
+	}
+}
+
+func TestMetricsServiceFlushes(t *testing.T) {
+	inner, buffered, sess := newBufferedTestSession(t, BufferConfig{FlushInterval: time.Hour}, clock.NewFake(time.Unix(1_700_000_000, 0)))
+	s, err := NewMetricsService(buffered, MetricsConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AppendEvent(t.Context(), sess, NewEvent("inv")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.(Flusher).FlushSession(t.Context(), sess.AppName(), sess.UserID(), sess.ID()); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := inner.Get(t.Context(), &GetRequest{AppName: sess.AppName(), UserID: sess.UserID(), SessionID: sess.ID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Session.Events().Len(); got != 1 {
+		t.Errorf("inner service has %d events after FlushSession(), want 1", got)
+	}
+}
+
+func TestMetricsServiceOptionalInterfaces(t *testing.T) {
+	inner := InMemoryService()
+	s, err := NewMetricsService(inner, MetricsConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.(Snapshotter); !ok {
+		t.Error("metrics service of an in-memory service does not implement Snapshotter")
+	}
+	// Hide the optional interfaces of the in-memory service.
+	hidden, err := NewMetricsService(struct{ Service }{inner}, MetricsConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := hidden.(Snapshotter); ok {
+		t.Error("metrics service implements Snapshotter, want not since its inner service does not")
+	}
+
+	resp, err := s.Create(t.Context(), &CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := NewEvent("inv")
+	if err := s.AppendEvent(t.Context(), resp.Session, ev); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.(EventGetter).GetEvent(t.Context(), &GetEventRequest{AppName: "app", UserID: "user", SessionID: "s1", EventID: ev.ID})
+	if err != nil {
+		t.Fatalf("GetEvent() error = %v", err)
+	}
+	if got.Event.ID != ev.ID {
+		t.Errorf("GetEvent() returned event %q, want %q", got.Event.ID, ev.ID)
+	}
+}
+
+func metricKey(attrs attribute.Set) string {
+	op, _ := attrs.Value(MetricKeyOperation)
+	outcome, _ := attrs.Value(MetricKeyOutcome)
+	return op.AsString() + "/" + outcome.AsString()
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+// Phase is a coarse stage of a run, reported by phase events so that
+// clients can show a status indicator.
+//
+// With agent.RunConfig.PhaseEvents set, an LLM agent yields a phase event at
+// the start of each stage, before any event of the stage:
+//
+//   - PhaseThinking before each model call;
+//   - PhaseGenerating before the first event with text of a model call, at
+//     most once per model call;
+//   - PhaseCallingTool before each tool call of a model response, in the
+//     order of the function calls, with the name of the tool as detail.
+//     Function calls rejected by the tool filters of the agent have none.
+//
+// Phase events are partial events without content: they are neither stored
+// in the session nor ever a final response, and the phase reported last
+// lasts until the next phase event or the end of the run.
+type Phase string
+
+const (
+	// PhaseThinking is reported before a model call.
+	PhaseThinking Phase = "thinking"
+	// PhaseGenerating is reported when the model starts answering with text.
+	PhaseGenerating Phase = "generating"
+	// PhaseCallingTool is reported before a tool is run.
+	PhaseCallingTool Phase = "calling_tool"
+)
+
+// Progress is the stage reported by a phase event.
+type Progress struct {
+	Phase Phase
+	// Detail qualifies the phase, e.g. the name of the tool being called.
+	Detail string
+}
+
+// NewPhaseEvent returns a phase event reporting the given phase. See Phase.
+func NewPhaseEvent(invocationID string, phase Phase, detail string) *Event {
+	ev := NewEvent(invocationID)
+	ev.Kind = EventKindPhase
+	ev.Partial = true
+	ev.Progress = &Progress{Phase: phase, Detail: detail}
+	return ev
+}
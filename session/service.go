@@ -17,6 +17,8 @@ package session
 import (
 	"context"
 	"time"
+
+	"google.golang.org/adk/internal/clock"
 )
 
 // Service is a session storage service.
@@ -33,9 +35,29 @@ type Service interface {
 
 // InMemoryService returns an in-memory implementation of the session service.
 func InMemoryService() Service {
+	return NewInMemoryService(InMemoryConfig{})
+}
+
+// InMemoryConfig configures the service returned by [NewInMemoryService].
+type InMemoryConfig struct {
+	// IDGenerator generates the IDs of the sessions and events of the
+	// service.
+	// optional: random UUIDs are used if nil.
+	IDGenerator IDGenerator
+}
+
+// NewInMemoryService returns an in-memory implementation of the session
+// service configured by cfg.
+func NewInMemoryService(cfg InMemoryConfig) Service {
+	idGenerator := cfg.IDGenerator
+	if idGenerator == nil {
+		idGenerator = DefaultIDGenerator()
+	}
 	return &inMemoryService{
-		appState:  make(map[string]stateMap),
-		userState: make(map[string]map[string]stateMap),
+		appState:    make(map[string]stateMap),
+		userState:   make(map[string]map[string]stateMap),
+		idGenerator: idGenerator,
+		clock:       clock.Real(),
 	}
 }
 
@@ -15,12 +15,14 @@
 package session
 
 import (
+	"context"
 	"errors"
 	"iter"
 	"time"
 
 	"github.com/google/uuid"
 
+	"google.golang.org/adk/internal/clock"
 	"google.golang.org/adk/model"
 )
 
@@ -114,6 +116,26 @@ type Event struct {
 	// Agent client will know from this field about which function call is long running.
 	// Only valid for function call event.
 	LongRunningToolIDs []string
+	// SafetyScores are the scores given to the content of a final response
+	// by the safety classifier of the agent, if any.
+	SafetyScores map[string]float64
+	// Labels are the labels of the run that created the event. See
+	// agent.RunConfig.Labels.
+	Labels map[string]string
+	// Kind is the kind of the event, set when the event is created. It may be
+	// empty for events created before kinds were recorded or by custom
+	// agents; use KindOf to get the kind of any event.
+	Kind EventKind
+	// Progress is the stage of the run reported by a phase event, nil for
+	// other events.
+	Progress *Progress
+	// Interim reports whether the event is an interim commit of a response
+	// that is still streaming, see agent.RunConfig.InterimCommitEvery. It
+	// holds the text streamed so far and is superseded by the next interim
+	// event or the final event of the response. Superseded interim events
+	// stay stored; readers leave them out, see ReconcileInterimEvents. An
+	// interim event is never a final response.
+	Interim bool
 }
 
 // IsFinalResponse returns whether the event is the final response of an agent.
@@ -121,6 +143,9 @@ type Event struct {
 // Note: when multiple agents participate in one invocation, there could be
 // multiple events with IsFinalResponse() as True, for each participating agent.
 func (e *Event) IsFinalResponse() bool {
+	if e.Interim {
+		return false
+	}
 	if (e.Actions.SkipSummarization) || len(e.LongRunningToolIDs) > 0 {
 		return true
 	}
@@ -128,12 +153,25 @@ func (e *Event) IsFinalResponse() bool {
 	return !hasFunctionCalls(&e.LLMResponse) && !hasFunctionResponses(&e.LLMResponse) && !e.LLMResponse.Partial && !hasTrailingCodeExecutionResult(&e.LLMResponse)
 }
 
-// NewEvent creates a new event defining now as the timestamp.
+// NewEvent creates a new event defining now as the timestamp. Its ID is a
+// random UUID; use NewEventContext for an ID from the IDGenerator of the
+// session service of a run.
 func NewEvent(invocationID string) *Event {
+	return newEvent(uuid.NewString(), invocationID, time.Now())
+}
+
+// NewEventContext is like NewEvent, but the ID of the event is generated by
+// the IDGenerator of ctx, if any (see NewEventID), and its timestamp is told
+// by the clock of the run of ctx, if any (see runner.Config.Clock).
+func NewEventContext(ctx context.Context, invocationID string) *Event {
+	return newEvent(NewEventID(ctx), invocationID, clock.Now(ctx))
+}
+
+func newEvent(id, invocationID string, timestamp time.Time) *Event {
 	return &Event{
-		ID:           uuid.NewString(),
+		ID:           id,
 		InvocationID: invocationID,
-		Timestamp:    time.Now(),
+		Timestamp:    timestamp,
 		Actions:      EventActions{StateDelta: make(map[string]any)},
 	}
 }
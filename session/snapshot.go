@@ -0,0 +1,155 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"rsc.io/omap"
+)
+
+// SnapshotVersion is the version of the format written by
+// [Snapshotter.Snapshot]. Restore reads snapshots of this version and of
+// earlier versions.
+const SnapshotVersion = 1
+
+// Snapshotter is implemented by session services whose whole store can be
+// saved and reloaded, like the service returned by [InMemoryService], e.g.
+// to seed a development server with recorded sessions or to reproduce a bug.
+type Snapshotter interface {
+	// Snapshot writes all the sessions with their events, and the app and
+	// user states, to w as versioned JSON.
+	Snapshot(w io.Writer) error
+	// Restore replaces the whole store with a snapshot read from r. The
+	// store is left unchanged if the snapshot cannot be read. State values
+	// and event metadata are restored as decoded from JSON, e.g. numbers
+	// as float64.
+	Restore(r io.Reader) error
+}
+
+// storeSnapshot is the JSON format of a snapshot. New fields can be added
+// without changing the version; other changes require a new version and a
+// migration in Restore.
+type storeSnapshot struct {
+	Version   int                                  `json:"version"`
+	AppState  map[string]map[string]any            `json:"app_state,omitempty"`
+	UserState map[string]map[string]map[string]any `json:"user_state,omitempty"`
+	Sessions  []sessionSnapshot                    `json:"sessions"`
+}
+
+type sessionSnapshot struct {
+	AppName   string         `json:"app_name"`
+	UserID    string         `json:"user_id"`
+	SessionID string         `json:"session_id"`
+	State     map[string]any `json:"state,omitempty"`
+	Events    []*Event       `json:"events,omitempty"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// Snapshot implements [Snapshotter].
+func (s *inMemoryService) Snapshot(w io.Writer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := storeSnapshot{
+		Version:   SnapshotVersion,
+		AppState:  make(map[string]map[string]any, len(s.appState)),
+		UserState: make(map[string]map[string]map[string]any, len(s.userState)),
+		Sessions:  make([]sessionSnapshot, 0),
+	}
+	for app, state := range s.appState {
+		snap.AppState[app] = state
+	}
+	for app, users := range s.userState {
+		snap.UserState[app] = make(map[string]map[string]any, len(users))
+		for user, state := range users {
+			snap.UserState[app][user] = state
+		}
+	}
+	for _, sess := range s.sessions.All() {
+		sess.mu.RLock()
+		snap.Sessions = append(snap.Sessions, sessionSnapshot{
+			AppName:   sess.id.appName,
+			UserID:    sess.id.userID,
+			SessionID: sess.id.sessionID,
+			State:     sess.state,
+			Events:    sess.events,
+			UpdatedAt: sess.updatedAt,
+		})
+		sess.mu.RUnlock()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(snap); err != nil {
+		return fmt.Errorf("failed to write session snapshot: %w", err)
+	}
+	return nil
+}
+
+// Restore implements [Snapshotter].
+func (s *inMemoryService) Restore(r io.Reader) error {
+	var snap storeSnapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("failed to read session snapshot: %w", err)
+	}
+	// Snapshots of earlier versions are migrated here.
+	if snap.Version != SnapshotVersion {
+		return fmt.Errorf("unsupported session snapshot version %d, want at most %d", snap.Version, SnapshotVersion)
+	}
+
+	var sessions omap.Map[string, *session]
+	for _, ss := range snap.Sessions {
+		if ss.AppName == "" || ss.UserID == "" || ss.SessionID == "" {
+			return fmt.Errorf("invalid session snapshot: app_name, user_id and session_id are required, got app_name: %q, user_id: %q, session_id: %q", ss.AppName, ss.UserID, ss.SessionID)
+		}
+		key := id{appName: ss.AppName, userID: ss.UserID, sessionID: ss.SessionID}
+		if _, ok := sessions.Get(key.Encode()); ok {
+			return fmt.Errorf("invalid session snapshot: duplicate session %q", ss.SessionID)
+		}
+		state := ss.State
+		if state == nil {
+			state = make(stateMap)
+		}
+		sessions.Set(key.Encode(), &session{
+			id:        key,
+			state:     state,
+			events:    ss.Events,
+			updatedAt: ss.UpdatedAt,
+		})
+	}
+	appState := make(map[string]stateMap, len(snap.AppState))
+	for app, state := range snap.AppState {
+		appState[app] = state
+	}
+	userState := make(map[string]map[string]stateMap, len(snap.UserState))
+	for app, users := range snap.UserState {
+		userState[app] = make(map[string]stateMap, len(users))
+		for user, state := range users {
+			userState[app][user] = state
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = sessions
+	s.appState = appState
+	s.userState = userState
+	return nil
+}
+
+var _ Snapshotter = (*inMemoryService)(nil)
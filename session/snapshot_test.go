@@ -0,0 +1,139 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	ctx := t.Context()
+	src := InMemoryService()
+	for _, req := range []*CreateRequest{
+		{AppName: "app", UserID: "u1", SessionID: "s1", State: map[string]any{"k": "v", "app:theme": "dark", "user:lang": "en"}},
+		{AppName: "app", UserID: "u2", SessionID: "s2"},
+		{AppName: "other", UserID: "u1", SessionID: "s3"},
+	} {
+		if _, err := src.Create(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resp, err := src.Get(ctx, &GetRequest{AppName: "app", UserID: "u1", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ev := range []*Event{
+		{ID: "e1", InvocationID: "inv", Author: "user", Timestamp: time.Unix(100, 0).UTC(), LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("hi", genai.RoleUser)}},
+		{ID: "e2", InvocationID: "inv", Author: "agent", Timestamp: time.Unix(101, 0).UTC(), Kind: EventKindModelText, Labels: map[string]string{"tier": "free"},
+			LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("hello", genai.RoleModel), TurnComplete: true},
+			Actions:     EventActions{StateDelta: map[string]any{"count": "1", "user:seen": true}, ArtifactDelta: map[string]int64{"a.txt": 2}}},
+	} {
+		if err := src.AppendEvent(ctx, resp.Session, ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := src.(Snapshotter).Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	dst := InMemoryService()
+	if _, err := dst.Create(ctx, &CreateRequest{AppName: "app", UserID: "u9", SessionID: "replaced"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.(Snapshotter).Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := cmp.Options{
+		cmp.AllowUnexported(session{}, id{}),
+		cmpopts.IgnoreFields(session{}, "mu"),
+		cmpopts.EquateEmpty(),
+	}
+	for _, app := range []string{"app", "other"} {
+		want, err := src.List(ctx, &ListRequest{AppName: app})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := dst.List(ctx, &ListRequest{AppName: app})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, got, opts); diff != "" {
+			t.Errorf("List(%q) after Restore() mismatch (-want +got):\n%s", app, diff)
+		}
+		for _, sess := range want.Sessions {
+			req := &GetRequest{AppName: app, UserID: sess.UserID(), SessionID: sess.ID()}
+			want, err := src.Get(ctx, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := dst.Get(ctx, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, got, opts); diff != "" {
+				t.Errorf("Get(%q) after Restore() mismatch (-want +got):\n%s", sess.ID(), diff)
+			}
+		}
+	}
+	if _, err := dst.Get(ctx, &GetRequest{AppName: "app", UserID: "u9", SessionID: "replaced"}); err == nil {
+		t.Errorf("Get() of a session created before Restore() succeeded, want error")
+	}
+
+	// Restored sessions keep working.
+	got, err := dst.Get(ctx, &GetRequest{AppName: "app", UserID: "u1", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.AppendEvent(ctx, got.Session, &Event{ID: "e3", Author: "user", Timestamp: time.Unix(102, 0).UTC()}); err != nil {
+		t.Errorf("AppendEvent() to a restored session failed: %v", err)
+	}
+}
+
+func TestRestoreInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		snapshot string
+	}{
+		{name: "not json", snapshot: "{"},
+		{name: "missing version", snapshot: `{"sessions": []}`},
+		{name: "future version", snapshot: `{"version": 99, "sessions": []}`},
+		{name: "missing session id", snapshot: `{"version": 1, "sessions": [{"app_name": "app", "user_id": "u"}]}`},
+		{name: "duplicate session", snapshot: `{"version": 1, "sessions": [{"app_name": "app", "user_id": "u", "session_id": "s"}, {"app_name": "app", "user_id": "u", "session_id": "s"}]}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := InMemoryService()
+			if _, err := s.Create(t.Context(), &CreateRequest{AppName: "app", UserID: "u", SessionID: "kept"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.(Snapshotter).Restore(strings.NewReader(tc.snapshot)); err == nil {
+				t.Errorf("Restore() succeeded, want error")
+			}
+			if _, err := s.Get(t.Context(), &GetRequest{AppName: "app", UserID: "u", SessionID: "kept"}); err != nil {
+				t.Errorf("Get() after a failed Restore() = %v, want the session kept", err)
+			}
+		})
+	}
+}
@@ -14,19 +14,122 @@
 
 // Package telemetry allows to set up custom telemetry processors that the ADK events
 // will be emitted to.
+//
+// Processors can be registered at any time, e.g. to turn on an exporter on
+// demand; a processor receives the spans started after its registration.
+// Only [InsertSpanProcessorAt] requires the processor to be registered before
+// the first span is emitted. Its rejections are also reported to the
+// OpenTelemetry error handler (see otel.SetErrorHandler), so that a dropped
+// processor is noticed even where the error is ignored.
 package telemetry
 
 import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 
 	internaltelemetry "google.golang.org/adk/internal/telemetry"
 )
 
+// ErrTelemetryStarted is returned by [InsertSpanProcessorAt] after the first
+// span was emitted, as processors can then only be appended.
+var ErrTelemetryStarted = internaltelemetry.ErrTelemetryStarted
+
 // RegisterSpanProcessor registers the span processor to local trace provider instance.
-// Any processor should be registered BEFORE any of the events are emitted, otherwise
-// the registration will be ignored.
+// A processor registered after events were emitted receives the spans
+// started from then on, but not the spans already in flight.
 // In addition to the RegisterSpanProcessor function, global trace provider configs
 // are respected.
+// Processors are called in registration order, both when a span starts and
+// when it ends; see [InsertSpanProcessorAt] to control the position.
+// Panics and errors of the processor are logged at debug level and counted in
+// the adk.telemetry.export_failures metric; they never affect agent runs.
+// A failed registration, e.g. of a nil processor, is reported to the
+// OpenTelemetry error handler; use [RegisterSpanProcessors] to get the error.
 func RegisterSpanProcessor(processor sdktrace.SpanProcessor) {
-	internaltelemetry.AddSpanProcessor(processor)
+	if err := internaltelemetry.AddSpanProcessor(processor); err != nil {
+		otel.Handle(err)
+	}
+}
+
+// RegisterSpanProcessors registers several span processors at once, e.g. an
+// OTLP exporter, an in-memory recorder and a file exporter, in the given
+// order. Either all processors are registered or, on error, none, and the
+// error is returned. The same registration rules as for
+// [RegisterSpanProcessor] apply.
+func RegisterSpanProcessors(processors ...sdktrace.SpanProcessor) error {
+	return internaltelemetry.AddSpanProcessors(processors...)
+}
+
+// RegisteredProcessorCount returns the number of span processors registered
+// to the local trace provider, including those registered by ADK itself,
+// e.g. for the API server debug endpoints.
+func RegisteredProcessorCount() int {
+	return internaltelemetry.SpanProcessorCount()
+}
+
+// InsertSpanProcessorAt registers the span processor at the given position of
+// the processors of the local trace provider. The index is clamped to the
+// number of registered processors, so 0 makes it the first processor called
+// and a large index is equivalent to [RegisterSpanProcessor].
+//
+// Use it when a processor depends on another one, e.g. to run a processor
+// redacting span attributes in OnStart before the processor exporting the
+// spans. Ended spans are read-only, so attributes recorded after the start
+// of a span can only be redacted by wrapping the exporter.
+// The processor must be registered before any of the events are emitted,
+// otherwise the registration fails with [ErrTelemetryStarted].
+func InsertSpanProcessorAt(index int, processor sdktrace.SpanProcessor) error {
+	return internaltelemetry.InsertSpanProcessorAt(index, processor)
+}
+
+// SafeExporter wraps a span exporter so that its errors and panics are logged
+// at debug level and counted in the adk.telemetry.export_failures metric
+// instead of being reported to the span processor, e.g.
+//
+//	telemetry.RegisterSpanProcessor(sdktrace.NewBatchSpanProcessor(telemetry.SafeExporter(exporter)))
+//
+// Use it for exporters writing to a collector that may be unavailable.
+func SafeExporter(exporter sdktrace.SpanExporter) sdktrace.SpanExporter {
+	return internaltelemetry.SafeExporter(exporter)
+}
+
+// SetAttributePrefix sets the prefix of the keys of the span attributes
+// recorded by ADK, which defaults to "gcp.vertex.agent". Set it before any
+// events are emitted, e.g. to "example.agent" to record the event ID as
+// "example.agent.event_id".
+//
+// The API server debug endpoints look events up by the event ID attribute
+// under the configured prefix. The ADK web UI reads span attributes under
+// the default prefix.
+func SetAttributePrefix(prefix string) {
+	internaltelemetry.SetAttributePrefix(prefix)
+}
+
+// SetIDMasking records the user IDs of the sessions on spans as salted
+// hashes, e.g. if they are emails, and the session IDs too if maskSessionID
+// is set. Equal IDs are recorded as equal hashes, so the spans of a user can
+// still be correlated, but the hashes cannot be reversed without the salt,
+// which must be kept secret. IDs are recorded as they are by default; an
+// empty salt restores the default. Log records are not affected.
+func SetIDMasking(salt []byte, maskSessionID bool) {
+	internaltelemetry.SetIDMasking(salt, maskSessionID)
+}
+
+// SetOutboundPropagation enables or disables the injection of the trace
+// context, e.g. the traceparent header, into requests made by ADK model
+// clients and by transports returned from NewTransport. It is enabled by
+// default. Disable it if a downstream service rejects the headers.
+func SetOutboundPropagation(enabled bool) {
+	internaltelemetry.SetOutboundPropagation(enabled)
+}
+
+// NewTransport returns an http.RoundTripper that injects the trace context of
+// each request's context into the request headers. Tools can use it for
+// their HTTP clients, together with the tool context as request context, so
+// that downstream services continue the trace of the tool call.
+// If base is nil, http.DefaultTransport is used.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	return &internaltelemetry.Transport{Base: base}
 }
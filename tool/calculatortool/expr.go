@@ -0,0 +1,320 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calculatortool
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var functions = map[string]func(float64) float64{
+	"sqrt":  math.Sqrt,
+	"abs":   math.Abs,
+	"round": math.Round,
+	"floor": math.Floor,
+	"ceil":  math.Ceil,
+	"ln":    math.Log,
+	"log10": math.Log10,
+}
+
+var constants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+// evaluate parses and evaluates an arithmetic expression with a recursive
+// descent parser. The grammar, from lowest to highest precedence, is
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/" | "%") unary }
+//	unary   = ("+" | "-") unary | power
+//	power   = primary [ "^" unary ]
+//	primary = number | constant | function "(" expr ")" | "(" expr ")"
+//
+// so that ^ is right associative and binds tighter than unary minus, e.g.
+// -2^2 is -4.
+func evaluate(expression string, maxDepth int) (float64, error) {
+	p := &parser{input: expression, maxDepth: maxDepth}
+	p.next()
+	v, err := p.expr()
+	if err != nil {
+		return 0, err
+	}
+	if p.tok.kind != tokEOF {
+		return 0, p.errorf("unexpected %s", p.tok)
+	}
+	return v, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp
+	tokInvalid
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value float64
+	pos   int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+type parser struct {
+	input    string
+	pos      int
+	tok      token
+	depth    int
+	maxDepth int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid expression at position %d: %s", p.tok.pos+1, fmt.Sprintf(format, args...))
+}
+
+// next reads the next token into p.tok.
+func (p *parser) next() {
+	for p.pos < len(p.input) && strings.IndexByte(" \t\n\r", p.input[p.pos]) >= 0 {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos >= len(p.input) {
+		p.tok = token{kind: tokEOF, pos: start}
+		return
+	}
+	c := rune(p.input[p.pos])
+	switch {
+	case c >= '0' && c <= '9' || c == '.':
+		for p.pos < len(p.input) && (isDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		// Exponent, e.g. 1.5e3.
+		if p.pos < len(p.input) && (p.input[p.pos] == 'e' || p.input[p.pos] == 'E') {
+			end := p.pos + 1
+			if end < len(p.input) && (p.input[end] == '+' || p.input[end] == '-') {
+				end++
+			}
+			if end < len(p.input) && isDigit(p.input[end]) {
+				for end < len(p.input) && isDigit(p.input[end]) {
+					end++
+				}
+				p.pos = end
+			}
+		}
+		text := p.input[start:p.pos]
+		v, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			p.tok = token{kind: tokInvalid, text: text, pos: start}
+			return
+		}
+		p.tok = token{kind: tokNumber, text: text, value: v, pos: start}
+	case unicode.IsLetter(c):
+		for p.pos < len(p.input) && (unicode.IsLetter(rune(p.input[p.pos])) || isDigit(p.input[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{kind: tokIdent, text: strings.ToLower(p.input[start:p.pos]), pos: start}
+	case strings.ContainsRune("+-*/%^()", c):
+		p.pos++
+		p.tok = token{kind: tokOp, text: string(c), pos: start}
+	default:
+		p.pos++
+		p.tok = token{kind: tokInvalid, text: p.input[start:p.pos], pos: start}
+	}
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func (p *parser) isOp(ops string) bool {
+	return p.tok.kind == tokOp && strings.Contains(ops, p.tok.text)
+}
+
+func (p *parser) expr() (float64, error) {
+	v, err := p.term()
+	if err != nil {
+		return 0, err
+	}
+	for p.isOp("+-") {
+		op := p.tok.text
+		p.next()
+		rhs, err := p.term()
+		if err != nil {
+			return 0, err
+		}
+		if op == "+" {
+			v += rhs
+		} else {
+			v -= rhs
+		}
+		if v, err = finite(v); err != nil {
+			return 0, err
+		}
+	}
+	return v, nil
+}
+
+func (p *parser) term() (float64, error) {
+	v, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	for p.isOp("*/%") {
+		op := p.tok.text
+		p.next()
+		rhs, err := p.unary()
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case "*":
+			v *= rhs
+		case "/":
+			if rhs == 0 {
+				return 0, errors.New("division by zero")
+			}
+			v /= rhs
+		case "%":
+			if rhs == 0 {
+				return 0, errors.New("modulo by zero")
+			}
+			v = math.Mod(v, rhs)
+		}
+		if v, err = finite(v); err != nil {
+			return 0, err
+		}
+	}
+	return v, nil
+}
+
+func (p *parser) unary() (float64, error) {
+	if !p.isOp("+-") {
+		return p.power()
+	}
+	if err := p.enter(); err != nil {
+		return 0, err
+	}
+	defer p.leave()
+	neg := p.tok.text == "-"
+	p.next()
+	v, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	if neg {
+		v = -v
+	}
+	return v, nil
+}
+
+func (p *parser) power() (float64, error) {
+	base, err := p.primary()
+	if err != nil {
+		return 0, err
+	}
+	if !p.isOp("^") {
+		return base, nil
+	}
+	if err := p.enter(); err != nil {
+		return 0, err
+	}
+	defer p.leave()
+	p.next()
+	exp, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	return finite(math.Pow(base, exp))
+}
+
+func (p *parser) primary() (float64, error) {
+	switch tok := p.tok; tok.kind {
+	case tokNumber:
+		p.next()
+		return tok.value, nil
+	case tokIdent:
+		if c, ok := constants[tok.text]; ok {
+			p.next()
+			return c, nil
+		}
+		fn, ok := functions[tok.text]
+		if !ok {
+			return 0, p.errorf("unknown function or constant %s", tok)
+		}
+		p.next()
+		if !p.isOp("(") {
+			return 0, p.errorf("expected \"(\" after %s, got %s", tok, p.tok)
+		}
+		v, err := p.parenthesized()
+		if err != nil {
+			return 0, err
+		}
+		return finite(fn(v))
+	case tokOp:
+		if tok.text == "(" {
+			return p.parenthesized()
+		}
+	}
+	return 0, p.errorf("unexpected %s", p.tok)
+}
+
+// parenthesized parses an expression in parentheses, p.tok being "(".
+func (p *parser) parenthesized() (float64, error) {
+	if err := p.enter(); err != nil {
+		return 0, err
+	}
+	defer p.leave()
+	p.next()
+	v, err := p.expr()
+	if err != nil {
+		return 0, err
+	}
+	if !p.isOp(")") {
+		return 0, p.errorf("expected \")\", got %s", p.tok)
+	}
+	p.next()
+	return v, nil
+}
+
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > p.maxDepth {
+		return fmt.Errorf("expression is nested deeper than %d levels", p.maxDepth)
+	}
+	return nil
+}
+
+func (p *parser) leave() { p.depth-- }
+
+// finite rejects results that are not finite numbers, e.g. overflows or the
+// square root of a negative number.
+func finite(v float64) (float64, error) {
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, errors.New("result is not a finite number")
+	}
+	return v, nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package calculatortool provides a tool that evaluates arithmetic
+// expressions and converts between units deterministically, so that the
+// model does not have to do math itself.
+//
+// Expressions are parsed, never executed as code. They support numbers,
+// the operators + - * / % ^ (power), parentheses, the constants pi and e and
+// the functions sqrt, abs, round, floor, ceil, ln and log10.
+package calculatortool
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// Defaults used for unset Config fields.
+const (
+	DefaultMaxExpressionLength = 256
+	DefaultMaxDepth            = 32
+)
+
+// Config is the configuration of the calculate tool.
+type Config struct {
+	// MaxExpressionLength limits the length of an expression in bytes.
+	// Defaults to DefaultMaxExpressionLength.
+	MaxExpressionLength int
+	// MaxDepth limits the nesting of parentheses, function calls and unary
+	// operators. Defaults to DefaultMaxDepth.
+	MaxDepth int
+}
+
+// Args are the arguments of the calculate tool.
+type Args struct {
+	// Expression is the arithmetic expression to evaluate, e.g. "2 * (3 + 4)".
+	Expression string `json:"expression"`
+	// FromUnit is the unit of the value of the expression, a symbol such
+	// as "km" or a full name such as "kilometers". Symbols are case
+	// sensitive, e.g. "MB" (megabyte) and "Mb" (megabit). If set, ToUnit
+	// must be set too.
+	FromUnit string `json:"from_unit,omitempty"`
+	// ToUnit is the unit the value is converted to, e.g. "mi", in the same
+	// forms as FromUnit.
+	ToUnit string `json:"to_unit,omitempty"`
+}
+
+// Result is the response of the calculate tool.
+type Result struct {
+	// Value is the value of the expression, converted to Unit if a
+	// conversion was requested.
+	Value float64 `json:"value"`
+	// Unit is the unit of Value, if a conversion was requested.
+	Unit string `json:"unit,omitempty"`
+}
+
+// New creates the calculate tool.
+func New(cfg Config) (tool.Tool, error) {
+	if cfg.MaxExpressionLength == 0 {
+		cfg.MaxExpressionLength = DefaultMaxExpressionLength
+	}
+	if cfg.MaxDepth == 0 {
+		cfg.MaxDepth = DefaultMaxDepth
+	}
+	if cfg.MaxExpressionLength < 0 || cfg.MaxDepth < 0 {
+		return nil, errors.New("MaxExpressionLength and MaxDepth must not be negative")
+	}
+	calcTool, err := functiontool.New(functiontool.Config{
+		Name: "calculate",
+		Description: "Evaluates an arithmetic expression exactly, e.g. \"(17.5 * 3) / 4 ^ 2\", and optionally converts the result " +
+			"from_unit to_unit, e.g. km to mi or C to F. Use it for any arithmetic or unit conversion instead of computing it yourself.",
+	}, func(ctx tool.Context, args Args) (Result, error) {
+		return calculate(cfg, args)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating calculate tool: %w", err)
+	}
+	return calcTool, nil
+}
+
+func calculate(cfg Config, args Args) (Result, error) {
+	if len(args.Expression) > cfg.MaxExpressionLength {
+		return Result{}, tool.NewError(tool.ErrorCodeInvalidArgument, fmt.Errorf("expression is %d bytes, exceeds the limit of %d bytes", len(args.Expression), cfg.MaxExpressionLength))
+	}
+	value, err := evaluate(args.Expression, cfg.MaxDepth)
+	if err != nil {
+		return Result{}, tool.NewError(tool.ErrorCodeInvalidArgument, err)
+	}
+	if args.FromUnit == "" && args.ToUnit == "" {
+		return Result{Value: value}, nil
+	}
+	if args.FromUnit == "" || args.ToUnit == "" {
+		return Result{}, tool.NewError(tool.ErrorCodeInvalidArgument, errors.New("from_unit and to_unit must be set together"))
+	}
+	converted, err := convert(value, args.FromUnit, args.ToUnit)
+	if err != nil {
+		return Result{}, tool.NewError(tool.ErrorCodeInvalidArgument, err)
+	}
+	return Result{Value: converted, Unit: args.ToUnit}, nil
+}
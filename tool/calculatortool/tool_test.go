@@ -0,0 +1,207 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calculatortool
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/tool"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{expr: "1 + 2 * 3", want: 7},
+		{expr: "(1 + 2) * 3", want: 9},
+		{expr: "10 - 4 - 3", want: 3},
+		{expr: "24 / 4 / 2", want: 3},
+		{expr: "2 ^ 3 ^ 2", want: 512},
+		{expr: "-2 ^ 2", want: -4},
+		{expr: "(-2) ^ 2", want: 4},
+		{expr: "2 ^ -1", want: 0.5},
+		{expr: "7 % 4 * 2", want: 6},
+		{expr: "--3", want: 3},
+		{expr: "1.5e3 + .5", want: 1500.5},
+		{expr: "sqrt(16) + abs(-2)", want: 6},
+		{expr: "round(2 * pi)", want: 6},
+		{expr: "ln(e)", want: 1},
+		{expr: "LOG10(1000)", want: 3},
+		{expr: " 3\t*\n2 ", want: 6},
+	}
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			got, err := evaluate(tc.expr, DefaultMaxDepth)
+			if err != nil {
+				t.Fatalf("evaluate(%q) error = %v", tc.expr, err)
+			}
+			if math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("evaluate(%q) = %v, want %v", tc.expr, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{expr: "", wantErr: "unexpected end of expression"},
+		{expr: "1 +", wantErr: "unexpected end of expression"},
+		{expr: "(1 + 2", wantErr: `expected ")"`},
+		{expr: "1 + 2)", wantErr: `unexpected ")"`},
+		{expr: "2 3", wantErr: `unexpected "3"`},
+		{expr: "1 / 0", wantErr: "division by zero"},
+		{expr: "5 % 0", wantErr: "modulo by zero"},
+		{expr: "10 ^ 400", wantErr: "not a finite number"},
+		{expr: "sqrt(-1)", wantErr: "not a finite number"},
+		{expr: "os.exit(1)", wantErr: `unknown function or constant "os"`},
+		{expr: "sqrt 4", wantErr: `expected "(" after "sqrt"`},
+		{expr: "1.2.3", wantErr: `unexpected "1.2.3"`},
+		{expr: "2 = 2", wantErr: `unexpected "="`},
+		{expr: strings.Repeat("(", 40) + "1" + strings.Repeat(")", 40), wantErr: "nested deeper than 32 levels"},
+		{expr: strings.Repeat("-", 40) + "1", wantErr: "nested deeper than 32 levels"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := evaluate(tc.expr, DefaultMaxDepth)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("evaluate(%q) error = %v, want containing %q", tc.expr, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		value    float64
+		from, to string
+		want     float64
+		wantErr  string
+	}{
+		{value: 5, from: "km", to: "mi", want: 3.1068559611866697},
+		{value: 100, from: "C", to: "F", want: 212},
+		{value: -40, from: "F", to: "C", want: -40},
+		{value: 0, from: "K", to: "C", want: -273.15},
+		{value: 2, from: "lb", to: "kg", want: 0.90718474},
+		{value: 90, from: "min", to: "h", want: 1.5},
+		{value: 1, from: "GiB", to: "MB", want: 1073.741824},
+		{value: 100, from: "km/h", to: "m/s", want: 27.777777777777779},
+		{value: 1, from: "km", to: "kg", wantErr: "cannot convert km (length) to kg (mass)"},
+		{value: 1, from: "parsec", to: "m", wantErr: `unknown unit "parsec"`},
+		{value: 8, from: "Mb", to: "MB", want: 1},
+		{value: 1, from: "mm", to: "m", want: 0.001},
+		{value: 1, from: "mb", to: "MB", wantErr: `unknown unit "mb"`},
+		{value: 1, from: "MM", to: "m", wantErr: `unknown unit "MM"`},
+		{value: 2, from: "Kilometers", to: "meter", want: 2000},
+		{value: 1, from: "Megabyte", to: "megabits", want: 8},
+	}
+	for _, tc := range tests {
+		t.Run(tc.from+" to "+tc.to, func(t *testing.T) {
+			got, err := convert(tc.value, tc.from, tc.to)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("convert() error = %v, want containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("convert() error = %v", err)
+			}
+			if math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("convert(%v, %q, %q) = %v, want %v", tc.value, tc.from, tc.to, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTool(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		args map[string]any
+		want map[string]any
+	}{
+		{
+			name: "expression",
+			args: map[string]any{"expression": "(17.5 * 3) / 4 ^ 2"},
+			want: map[string]any{"value": 3.28125},
+		},
+		{
+			name: "conversion",
+			args: map[string]any{"expression": "20 + 5", "from_unit": "C", "to_unit": "F"},
+			want: map[string]any{"value": 77.0, "unit": "F"},
+		},
+		{
+			name: "expression too long",
+			cfg:  Config{MaxExpressionLength: 8},
+			args: map[string]any{"expression": "1 + 2 + 3 + 4"},
+			want: map[string]any{"status": "error", "error_code": tool.ErrorCodeInvalidArgument, "error": "expression is 13 bytes, exceeds the limit of 8 bytes"},
+		},
+		{
+			name: "missing target unit",
+			args: map[string]any{"expression": "1", "from_unit": "m"},
+			want: map[string]any{"status": "error", "error_code": tool.ErrorCodeInvalidArgument, "error": "from_unit and to_unit must be set together"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			calc, err := New(tc.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := llmagent.New(llmagent.Config{
+				Name: "math_agent",
+				Model: &testutil.MockModel{Responses: []*genai.Content{
+					genai.NewContentFromFunctionCall("calculate", tc.args, genai.RoleModel),
+					genai.NewContentFromText("done", genai.RoleModel),
+				}},
+				Tools: []tool.Tool{calc},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			parts, err := testutil.CollectParts(testutil.NewTestAgentRunner(t, a).Run(t, "s1", "compute"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]any
+			for _, p := range parts {
+				if p.FunctionResponse != nil {
+					got = p.FunctionResponse.Response
+				}
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("calculate response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	if _, err := New(Config{MaxDepth: -1}); err == nil {
+		t.Error("New() with a negative depth succeeded, want error")
+	}
+}
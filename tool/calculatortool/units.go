@@ -0,0 +1,214 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calculatortool
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// unit converts values of a unit to and from the base unit of its dimension.
+type unit struct {
+	dimension string
+	// factor is the value of the unit in the base unit.
+	factor float64
+	// offset is added after scaling to the base unit, for temperatures.
+	offset float64
+}
+
+// units maps unit symbols to units. Symbols are case sensitive, as in "Mb"
+// (megabit) and "MB" (megabyte). The base units are the meter, the
+// kilogram, the second, the liter, the kelvin, the byte, the square meter
+// and the meter per second.
+var units = map[string]unit{
+	// Length.
+	"mm":  {dimension: "length", factor: 0.001},
+	"cm":  {dimension: "length", factor: 0.01},
+	"m":   {dimension: "length", factor: 1},
+	"km":  {dimension: "length", factor: 1000},
+	"in":  {dimension: "length", factor: 0.0254},
+	"ft":  {dimension: "length", factor: 0.3048},
+	"yd":  {dimension: "length", factor: 0.9144},
+	"mi":  {dimension: "length", factor: 1609.344},
+	"nmi": {dimension: "length", factor: 1852},
+	// Mass.
+	"mg": {dimension: "mass", factor: 1e-6},
+	"g":  {dimension: "mass", factor: 0.001},
+	"kg": {dimension: "mass", factor: 1},
+	"t":  {dimension: "mass", factor: 1000},
+	"oz": {dimension: "mass", factor: 0.028349523125},
+	"lb": {dimension: "mass", factor: 0.45359237},
+	// Time.
+	"ms":  {dimension: "time", factor: 0.001},
+	"s":   {dimension: "time", factor: 1},
+	"min": {dimension: "time", factor: 60},
+	"h":   {dimension: "time", factor: 3600},
+	"d":   {dimension: "time", factor: 86400},
+	"wk":  {dimension: "time", factor: 604800},
+	// Volume.
+	"ml":     {dimension: "volume", factor: 0.001},
+	"mL":     {dimension: "volume", factor: 0.001},
+	"l":      {dimension: "volume", factor: 1},
+	"L":      {dimension: "volume", factor: 1},
+	"m3":     {dimension: "volume", factor: 1000},
+	"floz":   {dimension: "volume", factor: 0.0295735295625},
+	"cup":    {dimension: "volume", factor: 0.2365882365},
+	"pt":     {dimension: "volume", factor: 0.473176473},
+	"qt":     {dimension: "volume", factor: 0.946352946},
+	"gal":    {dimension: "volume", factor: 3.785411784},
+	"impgal": {dimension: "volume", factor: 4.54609},
+	// Temperature.
+	"K": {dimension: "temperature", factor: 1},
+	"C": {dimension: "temperature", factor: 1, offset: 273.15},
+	"F": {dimension: "temperature", factor: 5.0 / 9, offset: 273.15 - 32*5.0/9},
+	// Area.
+	"m2":   {dimension: "area", factor: 1},
+	"km2":  {dimension: "area", factor: 1e6},
+	"ft2":  {dimension: "area", factor: 0.09290304},
+	"ha":   {dimension: "area", factor: 1e4},
+	"acre": {dimension: "area", factor: 4046.8564224},
+	// Speed.
+	"m/s":  {dimension: "speed", factor: 1},
+	"km/h": {dimension: "speed", factor: 1000.0 / 3600},
+	"mph":  {dimension: "speed", factor: 1609.344 / 3600},
+	"kn":   {dimension: "speed", factor: 1852.0 / 3600},
+	// Data.
+	"B":   {dimension: "data", factor: 1},
+	"kB":  {dimension: "data", factor: 1e3},
+	"MB":  {dimension: "data", factor: 1e6},
+	"GB":  {dimension: "data", factor: 1e9},
+	"TB":  {dimension: "data", factor: 1e12},
+	"KiB": {dimension: "data", factor: 1 << 10},
+	"MiB": {dimension: "data", factor: 1 << 20},
+	"GiB": {dimension: "data", factor: 1 << 30},
+	"TiB": {dimension: "data", factor: 1 << 40},
+	"b":   {dimension: "data", factor: 1.0 / 8},
+	"kb":  {dimension: "data", factor: 1e3 / 8},
+	"Mb":  {dimension: "data", factor: 1e6 / 8},
+	"Gb":  {dimension: "data", factor: 1e9 / 8},
+	"Tb":  {dimension: "data", factor: 1e12 / 8},
+}
+
+// unitNames lists the full names of the units, singular and plural, by
+// symbol.
+var unitNames = map[string][]string{
+	// Length.
+	"mm":  {"millimeter", "millimeters", "millimetre", "millimetres"},
+	"cm":  {"centimeter", "centimeters", "centimetre", "centimetres"},
+	"m":   {"meter", "meters", "metre", "metres"},
+	"km":  {"kilometer", "kilometers", "kilometre", "kilometres"},
+	"in":  {"inch", "inches"},
+	"ft":  {"foot", "feet"},
+	"yd":  {"yard", "yards"},
+	"mi":  {"mile", "miles"},
+	"nmi": {"nautical mile", "nautical miles"},
+	// Mass.
+	"mg": {"milligram", "milligrams"},
+	"g":  {"gram", "grams"},
+	"kg": {"kilogram", "kilograms"},
+	"t":  {"tonne", "tonnes"},
+	"oz": {"ounce", "ounces"},
+	"lb": {"pound", "pounds"},
+	// Time.
+	"ms":  {"millisecond", "milliseconds"},
+	"s":   {"second", "seconds"},
+	"min": {"minute", "minutes"},
+	"h":   {"hour", "hours"},
+	"d":   {"day", "days"},
+	"wk":  {"week", "weeks"},
+	// Volume.
+	"ml":     {"milliliter", "milliliters", "millilitre", "millilitres"},
+	"l":      {"liter", "liters", "litre", "litres"},
+	"floz":   {"fluid ounce", "fluid ounces"},
+	"cup":    {"cups"},
+	"pt":     {"pint", "pints"},
+	"qt":     {"quart", "quarts"},
+	"gal":    {"gallon", "gallons"},
+	"impgal": {"imperial gallon", "imperial gallons"},
+	// Temperature.
+	"K": {"kelvin"},
+	"C": {"celsius"},
+	"F": {"fahrenheit"},
+	// Area.
+	"ha":   {"hectare", "hectares"},
+	"acre": {"acres"},
+	// Speed.
+	"kn": {"knot", "knots"},
+	// Data.
+	"B":   {"byte", "bytes"},
+	"kB":  {"kilobyte", "kilobytes"},
+	"MB":  {"megabyte", "megabytes"},
+	"GB":  {"gigabyte", "gigabytes"},
+	"TB":  {"terabyte", "terabytes"},
+	"KiB": {"kibibyte", "kibibytes"},
+	"MiB": {"mebibyte", "mebibytes"},
+	"GiB": {"gibibyte", "gibibytes"},
+	"TiB": {"tebibyte", "tebibytes"},
+	"b":   {"bit", "bits"},
+	"kb":  {"kilobit", "kilobits"},
+	"Mb":  {"megabit", "megabits"},
+	"Gb":  {"gigabit", "gigabits"},
+	"Tb":  {"terabit", "terabits"},
+}
+
+// unitSymbols maps the full names of unitNames to their symbols.
+var unitSymbols = func() map[string]string {
+	symbols := make(map[string]string)
+	for symbol, names := range unitNames {
+		for _, name := range names {
+			symbols[name] = symbol
+		}
+	}
+	return symbols
+}()
+
+// convert converts a value between units of the same dimension, given by
+// their symbols or full names. Symbols are case sensitive; full names are
+// not.
+func convert(value float64, from, to string) (float64, error) {
+	fromUnit, err := lookupUnit(from)
+	if err != nil {
+		return 0, err
+	}
+	toUnit, err := lookupUnit(to)
+	if err != nil {
+		return 0, err
+	}
+	if fromUnit.dimension != toUnit.dimension {
+		return 0, fmt.Errorf("cannot convert %s (%s) to %s (%s)", from, fromUnit.dimension, to, toUnit.dimension)
+	}
+	base := value*fromUnit.factor + fromUnit.offset
+	return finite((base - toUnit.offset) / toUnit.factor)
+}
+
+// lookupUnit returns the unit of the symbol, matched exactly, or else of the
+// full name, matched regardless of case.
+func lookupUnit(symbol string) (unit, error) {
+	name := strings.TrimSpace(symbol)
+	u, ok := units[name]
+	if !ok {
+		u, ok = units[unitSymbols[strings.ToLower(name)]]
+	}
+	if !ok {
+		symbols := make([]string, 0, len(units))
+		for s := range units {
+			symbols = append(symbols, s)
+		}
+		slices.Sort(symbols)
+		return unit{}, fmt.Errorf("unknown unit %q, supported units: %s", symbol, strings.Join(symbols, ", "))
+	}
+	return u, nil
+}
@@ -0,0 +1,221 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package commandtool provides a tool running a command line program with
+// arguments filled in from the function call.
+//
+// The command is run directly, not through a shell: each element of the
+// command template becomes exactly one argument, whatever the values of the
+// function call arguments are, so they cannot inject shell syntax. The
+// command may still interpret an argument starting with "-" as a flag;
+// put "--" before templated arguments if the command supports it.
+package commandtool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/jsonschema-go/jsonschema"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// Defaults used for unset Config fields.
+const (
+	DefaultTimeout        = 30 * time.Second
+	DefaultMaxOutputBytes = 64 << 10
+)
+
+// Config is the configuration of a command tool.
+type Config struct {
+	// Name is the name of the tool.
+	Name string
+	// Description tells the model what the command does.
+	Description string
+	// Command is the program followed by its arguments. The arguments are
+	// text/template templates executed with the function call arguments,
+	// e.g. []string{"git", "log", "-n", "{{.count}}", "--", "{{.path}}"}.
+	// The program itself is not templated.
+	Command []string
+	// InputSchema is the JSON schema of the function call arguments.
+	InputSchema *jsonschema.Schema
+	// Dir is the working directory of the command. Defaults to the working
+	// directory of the current process.
+	Dir string
+	// Timeout limits the run time of the command. Defaults to
+	// DefaultTimeout.
+	Timeout time.Duration
+	// MaxOutputBytes limits the size of each of stdout and stderr returned.
+	// The rest of the output is discarded. Defaults to DefaultMaxOutputBytes.
+	MaxOutputBytes int
+	// AllowedEnv lists the environment variables of the current process
+	// passed to the command. Other variables are not passed.
+	AllowedEnv []string
+}
+
+// Result is the response of a command tool.
+type Result struct {
+	// Stdout is the standard output of the command.
+	Stdout string `json:"stdout"`
+	// Stderr is the standard error of the command.
+	Stderr string `json:"stderr"`
+	// ExitCode is the exit code of the command, or -1 if it was killed.
+	ExitCode int `json:"exit_code"`
+	// TimedOut reports whether the command was killed after the timeout.
+	TimedOut bool `json:"timed_out,omitempty"`
+	// Truncated reports whether stdout or stderr was truncated.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// New creates a tool running the configured command.
+//
+// A command exiting with a non-zero code or killed after the timeout is not
+// an error: its output and exit code are returned to the model. Errors are
+// returned if the arguments cannot be templated or the command cannot be
+// started.
+func New(cfg Config) (tool.Tool, error) {
+	r, err := newRunner(cfg)
+	if err != nil {
+		return nil, err
+	}
+	commandTool, err := functiontool.New(functiontool.Config{
+		Name:        cfg.Name,
+		Description: cfg.Description,
+		InputSchema: cfg.InputSchema,
+	}, func(ctx tool.Context, args map[string]any) (Result, error) {
+		return r.run(ctx, args)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s tool: %w", cfg.Name, err)
+	}
+	return commandTool, nil
+}
+
+type runner struct {
+	cfg  Config
+	args []*template.Template
+}
+
+func newRunner(cfg Config) (*runner, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("Name is required")
+	}
+	if len(cfg.Command) == 0 || cfg.Command[0] == "" {
+		return nil, errors.New("Command is required")
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.MaxOutputBytes == 0 {
+		cfg.MaxOutputBytes = DefaultMaxOutputBytes
+	}
+	if cfg.Timeout < 0 || cfg.MaxOutputBytes < 0 {
+		return nil, errors.New("Timeout and MaxOutputBytes must not be negative")
+	}
+	r := &runner{cfg: cfg}
+	for i, arg := range cfg.Command[1:] {
+		t, err := template.New(fmt.Sprintf("arg%d", i+1)).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template of argument %d: %w", i+1, err)
+		}
+		r.args = append(r.args, t)
+	}
+	return r, nil
+}
+
+func (r *runner) run(ctx context.Context, args map[string]any) (Result, error) {
+	argv := make([]string, len(r.args))
+	for i, t := range r.args {
+		var b strings.Builder
+		if err := t.Execute(&b, args); err != nil {
+			return Result{}, fmt.Errorf("failed to template argument %d: %w", i+1, err)
+		}
+		argv[i] = b.String()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, r.cfg.Command[0], argv...)
+	cmd.Dir = r.cfg.Dir
+	cmd.Env = r.env()
+	// Do not wait for children holding the output pipes after a kill.
+	cmd.WaitDelay = time.Second
+	stdout := &cappedBuffer{limit: r.cfg.MaxOutputBytes}
+	stderr := &cappedBuffer{limit: r.cfg.MaxOutputBytes}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	result := Result{
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		Truncated: stdout.truncated || stderr.truncated,
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.ExitCode = -1
+		result.TimedOut = true
+		return result, nil
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		return Result{}, fmt.Errorf("failed to run %s: %w", r.cfg.Command[0], err)
+	}
+	return result, nil
+}
+
+// env returns the allowed variables of the environment of the process.
+func (r *runner) env() []string {
+	env := []string{}
+	for _, name := range r.cfg.AllowedEnv {
+		if v, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+v)
+		}
+	}
+	return env
+}
+
+// cappedBuffer keeps the first limit bytes written to it.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+	} else {
+		b.buf.Write(p)
+	}
+	// Report the whole write as done so the command is not killed by a
+	// broken pipe.
+	return len(p), nil
+}
+
+func (b *cappedBuffer) String() string {
+	return b.buf.String()
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commandtool
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// TestHelperProcess is not a real test. It is the command run by the other
+// tests, behaving as selected by its first argument.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("COMMANDTOOL_HELPER") != "1" {
+		return
+	}
+	args := os.Args
+	for len(args) > 0 && args[0] != "--" {
+		args = args[1:]
+	}
+	args = args[1:]
+	switch args[0] {
+	case "echo":
+		fmt.Println(strings.Join(args[1:], "|"))
+	case "env":
+		fmt.Printf("helper=%s secret=%s\n", os.Getenv("COMMANDTOOL_HELPER"), os.Getenv("COMMANDTOOL_SECRET"))
+	case "fail":
+		fmt.Fprintln(os.Stderr, "something went wrong")
+		os.Exit(3)
+	case "sleep":
+		fmt.Println("started")
+		time.Sleep(time.Minute)
+	case "flood":
+		fmt.Print(strings.Repeat("x", 1000))
+	}
+	os.Exit(0)
+}
+
+func helperConfig(mode ...string) Config {
+	return Config{
+		Name:       "helper",
+		Command:    append([]string{os.Args[0], "-test.run=^TestHelperProcess$", "--"}, mode...),
+		AllowedEnv: []string{"COMMANDTOOL_HELPER"},
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv("COMMANDTOOL_HELPER", "1")
+	t.Setenv("COMMANDTOOL_SECRET", "hunter2")
+
+	for _, tc := range []struct {
+		name    string
+		cfg     Config
+		args    map[string]any
+		want    Result
+		wantErr bool
+	}{
+		{
+			name: "templated arguments are not split",
+			cfg:  helperConfig("echo", "{{.path}}", "n={{.count}}"),
+			args: map[string]any{"path": "a b; rm -rf /", "count": 3},
+			want: Result{Stdout: "a b; rm -rf /|n=3\n"},
+		},
+		{
+			name: "only allowed environment variables are passed",
+			cfg:  helperConfig("env"),
+			want: Result{Stdout: "helper=1 secret=\n"},
+		},
+		{
+			name: "non-zero exit",
+			cfg:  helperConfig("fail"),
+			want: Result{Stderr: "something went wrong\n", ExitCode: 3},
+		},
+		{
+			name: "timeout",
+			cfg: func() Config {
+				cfg := helperConfig("sleep")
+				cfg.Timeout = 500 * time.Millisecond
+				return cfg
+			}(),
+			want: Result{Stdout: "started\n", ExitCode: -1, TimedOut: true},
+		},
+		{
+			name: "output is capped",
+			cfg: func() Config {
+				cfg := helperConfig("flood")
+				cfg.MaxOutputBytes = 10
+				return cfg
+			}(),
+			want: Result{Stdout: "xxxxxxxxxx", Truncated: true},
+		},
+		{
+			name:    "missing argument",
+			cfg:     helperConfig("echo", "{{.path}}"),
+			args:    map[string]any{},
+			wantErr: true,
+		},
+		{
+			name:    "program not found",
+			cfg:     Config{Name: "missing", Command: []string{"commandtool-missing-program"}},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := newRunner(tc.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			start := time.Now()
+			got, err := r.run(t.Context(), tc.args)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("run() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > 10*time.Second {
+				t.Errorf("run() took %v", elapsed)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("run() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	commandTool, err := New(Config{
+		Name:        "list_files",
+		Description: "lists the files of a directory",
+		Command:     []string{"ls", "--", "{{.dir}}"},
+		InputSchema: &jsonschema.Schema{
+			Type:       "object",
+			Properties: map[string]*jsonschema.Schema{"dir": {Type: "string"}},
+			Required:   []string{"dir"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := commandTool.Name(); got != "list_files" {
+		t.Errorf("Name() = %q, want %q", got, "list_files")
+	}
+
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "missing name", cfg: Config{Command: []string{"ls"}}},
+		{name: "missing command", cfg: Config{Name: "ls"}},
+		{name: "invalid template", cfg: Config{Name: "ls", Command: []string{"ls", "{{.path"}}},
+		{name: "negative timeout", cfg: Config{Name: "ls", Command: []string{"ls"}, Timeout: -time.Second}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := New(tc.cfg); err == nil {
+				t.Errorf("New() succeeded, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tool
+
+// ConfirmFunc is the confirmation gate of a tool performing actions that
+// cannot be undone, e.g. writing a file or sending a message. It decides
+// whether the action may be performed, e.g. by asking an operator, and may
+// block until a decision is made; the context is cancelled if the run is.
+// Returning false declines the action.
+type ConfirmFunc[T any] func(ctx Context, action T) (bool, error)
+
+// Confirm passes the action through the gate and reports whether it was
+// approved. Actions are declined if the gate is nil, so that a tool
+// missing its gate fails closed.
+func Confirm[T any](ctx Context, gate ConfirmFunc[T], action T) (bool, error) {
+	if gate == nil {
+		return false, nil
+	}
+	return gate(ctx, action)
+}
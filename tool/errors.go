@@ -0,0 +1,104 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tool
+
+import (
+	"context"
+	"errors"
+)
+
+// Machine-readable codes of failed tool calls, reported in the error_code
+// field of their function responses.
+const (
+	// ErrorCodeInternal is the code of errors without a more specific code.
+	ErrorCodeInternal = "INTERNAL"
+	// ErrorCodeInvalidArgument reports arguments not matching the
+	// declaration of the tool.
+	ErrorCodeInvalidArgument = "INVALID_ARGUMENT"
+	// ErrorCodeDeadlineExceeded reports a tool call running out of time.
+	ErrorCodeDeadlineExceeded = "DEADLINE_EXCEEDED"
+	// ErrorCodeCancelled reports a cancelled tool call.
+	ErrorCodeCancelled = "CANCELLED"
+	// ErrorCodePermissionDenied reports a call to a tool the agent may not
+	// use.
+	ErrorCodePermissionDenied = "PERMISSION_DENIED"
+	// ErrorCodeNotFound reports a call to an unknown tool or a missing
+	// resource.
+	ErrorCodeNotFound = "NOT_FOUND"
+	// ErrorCodeUnavailable reports a tool that could not be reached or
+	// constructed, which may succeed if retried.
+	ErrorCodeUnavailable = "UNAVAILABLE"
+	// ErrorCodeResourceExhausted reports a call rejected by a limit, e.g. on
+	// the number of tool calls of a turn.
+	ErrorCodeResourceExhausted = "RESOURCE_EXHAUSTED"
+)
+
+// Error is an error of a tool call with a machine-readable code. Tools
+// return it, e.g. with NewError, to choose the code reported to the model.
+type Error struct {
+	Code string
+	Err  error
+}
+
+// NewError returns an error of a tool call with the given code.
+func NewError(code string, err error) error {
+	return &Error{Code: code, Err: err}
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// ErrorCode returns the code of the error of a tool call: the code of the
+// first Error in its chain, else a code derived from context errors, else
+// ErrorCodeInternal.
+func ErrorCode(err error) string {
+	var e *Error
+	if errors.As(err, &e) && e.Code != "" {
+		return e.Code
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorCodeDeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return ErrorCodeCancelled
+	}
+	return ErrorCodeInternal
+}
+
+// ErrorResponse returns the function response reporting the error of a tool
+// call to the model:
+//
+//	{"status": "error", "error_code": "<code>", "error": "<message>"}
+//
+// The code is ErrorCode(err) and the message is err.Error().
+func ErrorResponse(err error) map[string]any {
+	return map[string]any{
+		"status":     "error",
+		"error_code": ErrorCode(err),
+		"error":      err.Error(),
+	}
+}
+
+// ResponseError returns the code and message of a function response created
+// by ErrorResponse, and whether the response reports an error.
+func ResponseError(resp map[string]any) (code, message string, ok bool) {
+	if status, _ := resp["status"].(string); status != "error" {
+		return "", "", false
+	}
+	code, _ = resp["error_code"].(string)
+	message, _ = resp["error"].(string)
+	return code, message, true
+}
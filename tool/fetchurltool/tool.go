@@ -0,0 +1,494 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fetchurltool provides a tool that fetches a web page and returns
+// its readable text, e.g. for the model to summarize it.
+//
+// By default the tool refuses to connect to loopback, private, link-local
+// and other non-public addresses, including after redirects, to guard
+// against server-side request forgery.
+package fetchurltool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// Defaults used for unset Config fields.
+const (
+	DefaultTimeout      = 10 * time.Second
+	DefaultMaxBodyBytes = 2 << 20
+	DefaultMaxRedirects = 5
+)
+
+// DefaultAllowedContentTypes are the media types fetched if
+// Config.AllowedContentTypes is empty.
+var DefaultAllowedContentTypes = []string{"text/html", "application/xhtml+xml", "text/plain"}
+
+// charsPerToken approximates the number of characters of a token.
+const charsPerToken = 4
+
+// Config is the configuration of the fetch_url tool.
+type Config struct {
+	// Timeout limits the duration of a fetch, including redirects.
+	// Defaults to DefaultTimeout.
+	Timeout time.Duration
+	// MaxBodyBytes limits the size of a response body. Larger responses are
+	// rejected. Defaults to DefaultMaxBodyBytes.
+	MaxBodyBytes int64
+	// MaxRedirects is the number of redirects followed. Defaults to
+	// DefaultMaxRedirects; a negative value disables redirects.
+	MaxRedirects int
+	// AllowedContentTypes lists the accepted media types, e.g. "text/html".
+	// Defaults to DefaultAllowedContentTypes.
+	AllowedContentTypes []string
+	// MaxTokens truncates the returned text to about this many tokens,
+	// estimated as four characters per token. Zero disables truncation.
+	MaxTokens int
+	// AllowedHosts lists host names the tool may connect to even if they
+	// resolve to non-public addresses, e.g. internal documentation servers.
+	AllowedHosts []string
+	// AllowPrivateNetworks disables the check of the addresses connected to.
+	AllowPrivateNetworks bool
+	// Client is used as the base of the HTTP client of the tool, e.g. to set
+	// a proxy, a CA bundle or a cookie jar. Timeout and redirects are still
+	// limited by the tool. The addresses connected to by its transport are
+	// checked like those of the default one; with a proxy these are the
+	// addresses of the proxy, which may have to be listed in AllowedHosts,
+	// and the host of each request sent through the proxy, including
+	// redirects, is resolved and checked too. Its Transport must be nil or
+	// an *http.Transport without DialTLSContext or DialTLS unless
+	// AllowPrivateNetworks is set. Defaults to a client without proxy.
+	Client *http.Client
+}
+
+// Args are the arguments of the fetch_url tool.
+type Args struct {
+	// URL is the http or https URL of the page to fetch.
+	URL string `json:"url"`
+}
+
+// Result is the response of the fetch_url tool.
+type Result struct {
+	// URL is the URL the content was fetched from, after redirects.
+	URL string `json:"url"`
+	// Title is the title of an HTML page.
+	Title string `json:"title,omitempty"`
+	// Text is the readable text of the page.
+	Text string `json:"text"`
+	// Truncated reports whether Text was truncated to the token budget.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// New creates the fetch_url tool.
+func New(cfg Config) (tool.Tool, error) {
+	f, err := newFetcher(cfg)
+	if err != nil {
+		return nil, err
+	}
+	fetchTool, err := functiontool.New(functiontool.Config{
+		Name:        "fetch_url",
+		Description: "Fetches a web page and returns its readable text. Use it to read or summarize the content of a URL.",
+	}, func(ctx tool.Context, args Args) (Result, error) {
+		return f.fetch(ctx, args.URL)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating fetch_url tool: %w", err)
+	}
+	return fetchTool, nil
+}
+
+type fetcher struct {
+	cfg    Config
+	client *http.Client
+}
+
+func newFetcher(cfg Config) (*fetcher, error) {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.MaxBodyBytes == 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if cfg.MaxRedirects == 0 {
+		cfg.MaxRedirects = DefaultMaxRedirects
+	}
+	if len(cfg.AllowedContentTypes) == 0 {
+		cfg.AllowedContentTypes = DefaultAllowedContentTypes
+	}
+	if cfg.Timeout < 0 || cfg.MaxBodyBytes < 0 || cfg.MaxTokens < 0 {
+		return nil, errors.New("Timeout, MaxBodyBytes and MaxTokens must not be negative")
+	}
+
+	client := &http.Client{}
+	if cfg.Client != nil {
+		*client = *cfg.Client
+	}
+	transport, err := newTransport(cfg, client.Transport)
+	if err != nil {
+		return nil, err
+	}
+	checkRedirect := client.CheckRedirect
+	client.Transport = transport
+	client.Timeout = cfg.Timeout
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > cfg.MaxRedirects {
+			return fmt.Errorf("stopped after %d redirects", cfg.MaxRedirects)
+		}
+		if err := checkScheme(req.URL); err != nil {
+			return err
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		return nil
+	}
+	return &fetcher{cfg: cfg, client: client}, nil
+}
+
+// newTransport returns the transport of the tool, based on the transport of
+// Config.Client if there is one.
+func newTransport(cfg Config, base http.RoundTripper) (http.RoundTripper, error) {
+	var transport *http.Transport
+	switch t := base.(type) {
+	case nil:
+		transport = &http.Transport{Proxy: nil}
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		if !cfg.AllowPrivateNetworks {
+			return nil, fmt.Errorf("Client.Transport of type %T cannot be checked for private network access, use an *http.Transport or set AllowPrivateNetworks", base)
+		}
+		return base, nil
+	}
+	if !cfg.AllowPrivateNetworks && (transport.DialTLSContext != nil || transport.DialTLS != nil) {
+		return nil, fmt.Errorf("Client.Transport with DialTLSContext or DialTLS cannot be checked for private network access, set AllowPrivateNetworks")
+	}
+	if transport.TLSHandshakeTimeout == 0 {
+		transport.TLSHandshakeTimeout = cfg.Timeout
+	}
+	if transport.ResponseHeaderTimeout == 0 {
+		transport.ResponseHeaderTimeout = cfg.Timeout
+	}
+	baseDial := transport.DialContext
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		checked := !cfg.AllowPrivateNetworks && !slices.Contains(cfg.AllowedHosts, host)
+		if baseDial != nil {
+			if !checked {
+				return baseDial(ctx, network, addr)
+			}
+			// The base dialer can't be checked as it connects, so the
+			// addresses are checked before and dialed instead of the host
+			// name, which DNS rebinding could resolve to others.
+			addrs, err := resolveHost(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+			var errs []error
+			for _, a := range addrs {
+				conn, err := baseDial(ctx, network, net.JoinHostPort(a.String(), port))
+				if err == nil {
+					return conn, nil
+				}
+				errs = append(errs, err)
+			}
+			return nil, errors.Join(errs...)
+		}
+		d := *dialer
+		if checked {
+			// Checking the address connected to, rather than the
+			// resolved host name, also covers DNS rebinding.
+			d.Control = func(network, address string, _ syscall.RawConn) error {
+				return checkAddress(address)
+			}
+		}
+		return d.DialContext(ctx, network, addr)
+	}
+	if transport.Proxy != nil && !cfg.AllowPrivateNetworks {
+		return &proxyChecker{cfg: cfg, base: transport}, nil
+	}
+	return transport, nil
+}
+
+// proxyChecker checks the host of each request sent through a proxy, as the
+// connections of the transport only reveal the address of the proxy.
+type proxyChecker struct {
+	cfg  Config
+	base *http.Transport
+}
+
+func (p *proxyChecker) RoundTrip(req *http.Request) (*http.Response, error) {
+	proxyURL, err := p.base.Proxy(req)
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL != nil {
+		if err := checkHost(req.Context(), p.cfg, req.URL.Hostname()); err != nil {
+			return nil, err
+		}
+	}
+	return p.base.RoundTrip(req)
+}
+
+// checkHost resolves host, unless it is listed in Config.AllowedHosts, and
+// checks all its addresses. The proxy resolves the host again, so unlike the
+// check of the addresses connected to it does not cover DNS rebinding.
+func checkHost(ctx context.Context, cfg Config, host string) error {
+	if slices.Contains(cfg.AllowedHosts, host) {
+		return nil
+	}
+	_, err := resolveHost(ctx, host)
+	return err
+}
+
+// resolveHost returns the addresses of host, failing if any of them is not
+// public.
+func resolveHost(ctx context.Context, host string) ([]netip.Addr, error) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return []netip.Addr{addr}, checkAddr(addr)
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if err := checkAddr(addr); err != nil {
+			return nil, err
+		}
+	}
+	return addrs, nil
+}
+
+// errBlockedAddress is returned for connections to non-public addresses.
+var errBlockedAddress = errors.New("address is not public")
+
+func checkAddress(address string) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	return checkAddr(addrPort.Addr())
+}
+
+func checkAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || cgnat.Contains(addr) {
+		return fmt.Errorf("connection to %s refused: %w", addr, errBlockedAddress)
+	}
+	if v4, ok := embeddedIPv4(addr); ok {
+		if err := checkAddr(v4); err != nil {
+			return fmt.Errorf("connection to %s refused: %w", addr, errBlockedAddress)
+		}
+	}
+	return nil
+}
+
+var (
+	// cgnat is the shared address space of carrier-grade NAT, not covered
+	// by netip.Addr.IsPrivate.
+	cgnat = netip.MustParsePrefix("100.64.0.0/10")
+	// nat64 and sixToFour are IPv6 prefixes of addresses translated to the
+	// IPv4 addresses they embed, by NAT64 and 6to4 relays.
+	nat64     = netip.MustParsePrefix("64:ff9b::/96")
+	sixToFour = netip.MustParsePrefix("2002::/16")
+)
+
+// embeddedIPv4 returns the IPv4 address embedded in a NAT64 or 6to4 address.
+func embeddedIPv4(addr netip.Addr) (netip.Addr, bool) {
+	b := addr.As16()
+	switch {
+	case nat64.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[12:16])), true
+	case sixToFour.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[2:6])), true
+	}
+	return netip.Addr{}, false
+}
+
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported URL scheme %q, want http or https", u.Scheme)
+	}
+	return nil
+}
+
+func (f *fetcher) fetch(ctx context.Context, rawURL string) (Result, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid URL: %w", err)
+	}
+	if err := checkScheme(u); err != nil {
+		return Result{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid URL: %w", err)
+	}
+	req.Header.Set("Accept", strings.Join(f.cfg.AllowedContentTypes, ", "))
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Result{}, fmt.Errorf("failed to fetch %s: %s", rawURL, resp.Status)
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid content type %q: %w", resp.Header.Get("Content-Type"), err)
+	}
+	if !slices.Contains(f.cfg.AllowedContentTypes, mediaType) {
+		return Result{}, fmt.Errorf("content type %q is not allowed", mediaType)
+	}
+	if resp.ContentLength > f.cfg.MaxBodyBytes {
+		return Result{}, fmt.Errorf("response body of %d bytes exceeds the limit of %d bytes", resp.ContentLength, f.cfg.MaxBodyBytes)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.cfg.MaxBodyBytes+1))
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if int64(len(body)) > f.cfg.MaxBodyBytes {
+		return Result{}, fmt.Errorf("response body exceeds the limit of %d bytes", f.cfg.MaxBodyBytes)
+	}
+
+	result := Result{URL: resp.Request.URL.String()}
+	if mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		result.Title, result.Text = extractText(string(body))
+	} else {
+		result.Text = strings.TrimSpace(strings.ToValidUTF8(string(body), ""))
+	}
+	if f.cfg.MaxTokens > 0 {
+		result.Text, result.Truncated = truncate(result.Text, f.cfg.MaxTokens*charsPerToken)
+	}
+	return result, nil
+}
+
+// skippedElements are elements whose content is not readable text.
+var skippedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+}
+
+// blockElements start a new line of text.
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Br: true, atom.Li: true, atom.Tr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Section: true, atom.Article: true, atom.Header: true, atom.Footer: true,
+	atom.Blockquote: true, atom.Pre: true, atom.Table: true, atom.Ul: true, atom.Ol: true,
+}
+
+// extractText returns the title and the readable text of an HTML document.
+func extractText(doc string) (title, text string) {
+	var lines []string
+	var line strings.Builder
+	endLine := func() {
+		if s := strings.Join(strings.Fields(line.String()), " "); s != "" {
+			lines = append(lines, s)
+		}
+		line.Reset()
+	}
+
+	z := html.NewTokenizer(strings.NewReader(doc))
+	skipDepth := 0
+	inTitle := false
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			endLine()
+			return title, strings.Join(lines, "\n")
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			a := atom.Lookup(name)
+			if a == atom.Title {
+				inTitle = true
+			}
+			if skippedElements[a] && tt == html.StartTagToken {
+				skipDepth++
+			}
+			if blockElements[a] {
+				endLine()
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			a := atom.Lookup(name)
+			if a == atom.Title {
+				inTitle = false
+			}
+			if skippedElements[a] && skipDepth > 0 {
+				skipDepth--
+			}
+			if blockElements[a] {
+				endLine()
+			}
+		case html.TextToken:
+			if inTitle {
+				title = strings.Join(strings.Fields(string(z.Text())), " ")
+				continue
+			}
+			if skipDepth == 0 {
+				line.Write(z.Text())
+				line.WriteByte(' ')
+			}
+		}
+	}
+}
+
+// truncate cuts s to at most n characters at a word boundary if possible.
+func truncate(s string, n int) (string, bool) {
+	if utf8.RuneCountInString(s) <= n {
+		return s, false
+	}
+	cut := 0
+	for i := range s {
+		if n == 0 {
+			cut = i
+			break
+		}
+		n--
+	}
+	if i := strings.LastIndexAny(s[:cut], " \n"); i > cut/2 {
+		cut = i
+	}
+	return s[:cut], true
+}
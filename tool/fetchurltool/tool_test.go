@@ -0,0 +1,395 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetchurltool
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testPage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Test  page</title>
+  <style>body { color: red; }</style>
+  <script>var secret = "hidden";</script>
+</head>
+<body>
+  <h1>Heading</h1>
+  <p>First   paragraph with <a href="/x">a link</a>.</p>
+  <noscript>Enable JavaScript</noscript>
+  <ul><li>one</li><li>two</li></ul>
+</body>
+</html>`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, testPage)
+	})
+	mux.HandleFunc("/text", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, "  plain text words  ")
+	})
+	mux.HandleFunc("/image", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		fmt.Fprint(w, "png")
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, strings.Repeat("a", 2048))
+	})
+	mux.HandleFunc("/large-chunked", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		for range 4 {
+			fmt.Fprint(w, strings.Repeat("a", 512))
+			w.(http.Flusher).Flush()
+		}
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	mux.HandleFunc("/redirect/{n}", func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		fmt.Sscan(r.PathValue("n"), &n)
+		if n == 0 {
+			http.Redirect(w, r, "/page", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/redirect/%d", n-1), http.StatusFound)
+	})
+	mux.HandleFunc("/redirect-to", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Query().Get("url"), http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFetch(t *testing.T) {
+	srv := newTestServer(t)
+	tests := []struct {
+		name    string
+		cfg     Config
+		path    string
+		want    Result
+		wantErr string
+	}{
+		{
+			name: "html page",
+			path: "/page",
+			want: Result{
+				URL:   srv.URL + "/page",
+				Title: "Test page",
+				Text:  "Heading\nFirst paragraph with a link .\none\ntwo",
+			},
+		},
+		{
+			name: "plain text",
+			path: "/text",
+			want: Result{URL: srv.URL + "/text", Text: "plain text words"},
+		},
+		{
+			name: "truncated to token budget",
+			cfg:  Config{MaxTokens: 4},
+			path: "/page",
+			want: Result{
+				URL:       srv.URL + "/page",
+				Title:     "Test page",
+				Text:      "Heading\nFirst",
+				Truncated: true,
+			},
+		},
+		{
+			name:    "content type not allowed",
+			path:    "/image",
+			wantErr: `content type "image/png" is not allowed`,
+		},
+		{
+			name:    "oversized body",
+			cfg:     Config{MaxBodyBytes: 1024},
+			path:    "/large",
+			wantErr: "exceeds the limit of 1024 bytes",
+		},
+		{
+			name:    "oversized body without content length",
+			cfg:     Config{MaxBodyBytes: 1024},
+			path:    "/large-chunked",
+			wantErr: "exceeds the limit of 1024 bytes",
+		},
+		{
+			name:    "status error",
+			path:    "/missing",
+			wantErr: "404 Not Found",
+		},
+		{
+			name: "redirects are followed",
+			path: "/redirect/3",
+			want: Result{
+				URL:   srv.URL + "/page",
+				Title: "Test page",
+				Text:  "Heading\nFirst paragraph with a link .\none\ntwo",
+			},
+		},
+		{
+			name:    "too many redirects",
+			cfg:     Config{MaxRedirects: 2},
+			path:    "/redirect/3",
+			wantErr: "stopped after 2 redirects",
+		},
+		{
+			name:    "redirect to a blocked host",
+			path:    "/redirect-to?url=" + strings.Replace(srv.URL, "127.0.0.1", "localhost", 1) + "/page",
+			wantErr: "is not public",
+		},
+		{
+			name:    "redirect to another scheme",
+			path:    "/redirect-to?url=file:///etc/passwd",
+			wantErr: `unsupported URL scheme "file"`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := tc.cfg
+			cfg.AllowedHosts = []string{"127.0.0.1"}
+			f, err := newFetcher(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := f.fetch(t.Context(), srv.URL+tc.path)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("fetch() error = %v, want containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetch() error = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("fetch() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFetchBlocksPrivateAddresses(t *testing.T) {
+	srv := newTestServer(t)
+	f, err := newFetcher(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.fetch(t.Context(), srv.URL+"/page"); !errors.Is(err, errBlockedAddress) {
+		t.Errorf("fetch() of a loopback address error = %v, want %v", err, errBlockedAddress)
+	}
+	if _, err := f.fetch(t.Context(), "ftp://example.com/file"); err == nil {
+		t.Error("fetch() of an ftp URL succeeded")
+	}
+}
+
+func TestFetchWithClient(t *testing.T) {
+	srv := newTestServer(t)
+	proxyURL, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The test server also serves the requests it receives as a proxy.
+	proxied := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+	// publicURL has a public address, checked without resolving it.
+	const publicURL = "http://93.184.216.34/text"
+	tests := []struct {
+		name       string
+		cfg        Config
+		url        string
+		wantErr    string
+		wantNewErr bool
+	}{
+		{
+			name: "proxy in allowed hosts",
+			cfg:  Config{Client: proxied, AllowedHosts: []string{"127.0.0.1"}},
+		},
+		{
+			name:    "private proxy is blocked",
+			cfg:     Config{Client: proxied},
+			wantErr: "is not public",
+		},
+		{
+			name:    "private host through proxy is blocked",
+			cfg:     Config{Client: proxied, AllowedHosts: []string{"127.0.0.1"}},
+			url:     "http://10.0.0.1/text",
+			wantErr: "is not public",
+		},
+		{
+			name:    "redirect to private host through proxy is blocked",
+			cfg:     Config{Client: proxied, AllowedHosts: []string{"127.0.0.1"}},
+			url:     "http://93.184.216.34/redirect-to?url=http://10.0.0.1/text",
+			wantErr: "is not public",
+		},
+		{
+			name: "private host through proxy with private networks allowed",
+			cfg:  Config{Client: proxied, AllowPrivateNetworks: true},
+			url:  "http://10.0.0.1/text",
+		},
+		{
+			name: "custom round tripper with private networks allowed",
+			cfg: Config{
+				Client:               &http.Client{Transport: roundTripperFunc(proxied.Transport.RoundTrip)},
+				AllowPrivateNetworks: true,
+			},
+		},
+		{
+			name:       "custom round tripper",
+			cfg:        Config{Client: &http.Client{Transport: roundTripperFunc(proxied.Transport.RoundTrip)}},
+			wantNewErr: true,
+		},
+		{
+			name:       "custom TLS dialer",
+			cfg:        Config{Client: &http.Client{Transport: &http.Transport{DialTLSContext: (&tls.Dialer{}).DialContext}}},
+			wantNewErr: true,
+		},
+		{
+			name: "custom TLS dialer with private networks allowed",
+			cfg: Config{
+				Client:               &http.Client{Transport: &http.Transport{DialTLSContext: (&tls.Dialer{}).DialContext}},
+				AllowPrivateNetworks: true,
+			},
+			url: srv.URL + "/text",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := newFetcher(tc.cfg)
+			if (err != nil) != tc.wantNewErr {
+				t.Fatalf("newFetcher() error = %v, want error: %v", err, tc.wantNewErr)
+			}
+			if err != nil {
+				return
+			}
+			if tc.url == "" {
+				tc.url = publicURL
+			}
+			got, err := f.fetch(t.Context(), tc.url)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("fetch() error = %v, want containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetch() error = %v", err)
+			}
+			want := Result{URL: tc.url, Text: "plain text words"}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("fetch() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFetchWithDialer(t *testing.T) {
+	srv := newTestServer(t)
+	var dialed []string
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialed = append(dialed, addr)
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+
+	f, err := newFetcher(Config{Client: client})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.fetch(t.Context(), srv.URL+"/text"); !errors.Is(err, errBlockedAddress) {
+		t.Errorf("fetch() of a loopback address error = %v, want %v", err, errBlockedAddress)
+	}
+	if len(dialed) != 0 {
+		t.Errorf("the dialer of the client connected to %v, want no connection", dialed)
+	}
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err = newFetcher(Config{Client: client, AllowedHosts: []string{u.Hostname()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.fetch(t.Context(), srv.URL+"/text"); err != nil {
+		t.Errorf("fetch() of an allowed host error = %v", err)
+	}
+	if len(dialed) != 1 {
+		t.Errorf("the dialer of the client connected to %v, want one connection", dialed)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+func TestCheckAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		blocked bool
+	}{
+		{address: "93.184.216.34:443"},
+		{address: "[2606:2800:220:1:248:1893:25c8:1946]:443"},
+		{address: "127.0.0.1:80", blocked: true},
+		{address: "10.1.2.3:80", blocked: true},
+		{address: "172.16.0.1:80", blocked: true},
+		{address: "192.168.1.1:80", blocked: true},
+		{address: "169.254.169.254:80", blocked: true},
+		{address: "100.64.0.1:80", blocked: true},
+		{address: "0.0.0.0:80", blocked: true},
+		{address: "[::1]:80", blocked: true},
+		{address: "[fd00::1]:80", blocked: true},
+		{address: "[::ffff:127.0.0.1]:80", blocked: true},
+		{address: "[64:ff9b::5db8:d822]:443"},
+		{address: "[64:ff9b::7f00:1]:80", blocked: true},
+		{address: "[64:ff9b::a9fe:a9fe]:80", blocked: true},
+		{address: "[2002:5db8:d822::1]:443"},
+		{address: "[2002:7f00:1::1]:80", blocked: true},
+		{address: "[2002:c0a8:101::1]:80", blocked: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.address, func(t *testing.T) {
+			err := checkAddress(tc.address)
+			if got := errors.Is(err, errBlockedAddress); got != tc.blocked {
+				t.Errorf("checkAddress(%q) = %v, want blocked %v", tc.address, err, tc.blocked)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Config{}); err != nil {
+		t.Errorf("New() error = %v", err)
+	}
+	if _, err := New(Config{MaxTokens: -1}); err == nil {
+		t.Error("New() with a negative token budget succeeded")
+	}
+}
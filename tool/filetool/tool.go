@@ -0,0 +1,309 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package filetool provides tools reading and writing the files of a
+// workspace directory, e.g. the project of a coding agent.
+//
+// All paths are confined to the root directory of the workspace: absolute
+// paths and paths with ".." elements are rejected, and files are opened
+// with [os.Root], so symbolic links cannot lead outside of the root either.
+// An optional allowlist of file name patterns further limits the files the
+// tools see.
+//
+// Writes go through Config.Confirm, a [tool.ConfirmFunc], before the file is
+// changed.
+package filetool
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// Names of the tools.
+const (
+	ReadToolName  = "read_file"
+	WriteToolName = "write_file"
+)
+
+// DefaultMaxBytes is used if Config.MaxBytes is zero.
+const DefaultMaxBytes = 64 << 10
+
+// WriteRequest is a write submitted for confirmation.
+type WriteRequest struct {
+	// Path is the path of the file, relative to the root of the workspace.
+	Path string
+	// Content is the new content of the file.
+	Content string
+}
+
+// ConfirmFunc approves the writes of the write tool.
+type ConfirmFunc = tool.ConfirmFunc[WriteRequest]
+
+// Config is the configuration of the file tools.
+type Config struct {
+	// Root is the directory of the workspace. The tools only access files
+	// under it. Required.
+	Root string
+	// MaxBytes caps the size of the content returned by the read tool,
+	// which returns the head of larger files, and the size of the content
+	// accepted by the write tool. Defaults to DefaultMaxBytes.
+	MaxBytes int
+	// AllowedPatterns, if not empty, restricts the files to those whose
+	// name matches one of the patterns, in the syntax of filepath.Match,
+	// e.g. []string{"*.go", "*.md", "go.mod"}.
+	AllowedPatterns []string
+	// Confirm approves each write. Required by NewWrite.
+	Confirm ConfirmFunc
+}
+
+// ReadArgs are the arguments of a call of the read tool.
+type ReadArgs struct {
+	// Path is the path of the file.
+	Path string `json:"path" jsonschema:"the path of the file, relative to the root of the workspace"`
+}
+
+// ReadResult is the response of the read tool.
+type ReadResult struct {
+	// Content is the content of the file, or its head if Truncated.
+	Content string `json:"content"`
+	// Size is the size of the file in bytes.
+	Size int64 `json:"size"`
+	// Truncated reports whether Content is only the head of the file.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// WriteArgs are the arguments of a call of the write tool.
+type WriteArgs struct {
+	// Path is the path of the file.
+	Path string `json:"path" jsonschema:"the path of the file, relative to the root of the workspace; its directory must exist"`
+	// Content is the new content of the file.
+	Content string `json:"content" jsonschema:"the new content of the file"`
+}
+
+// Statuses of a write reported in WriteResult.Status.
+const (
+	StatusWritten  = "written"
+	StatusDeclined = "declined"
+)
+
+// WriteResult is the response of the write tool.
+type WriteResult struct {
+	// Status is StatusWritten or StatusDeclined.
+	Status string `json:"status"`
+}
+
+// NewRead creates a tool reading the files of the workspace.
+//
+// Paths outside of the workspace or not matching cfg.AllowedPatterns are
+// rejected with a PERMISSION_DENIED error, missing files with a NOT_FOUND
+// error.
+func NewRead(cfg Config) (tool.Tool, error) {
+	w, err := newWorkspace(cfg)
+	if err != nil {
+		return nil, err
+	}
+	readTool, err := functiontool.New(functiontool.Config{
+		Name:        ReadToolName,
+		Description: "Reads a file of the workspace. Large files are truncated to their head.",
+	}, func(ctx tool.Context, args ReadArgs) (ReadResult, error) {
+		return w.read(args.Path)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s tool: %w", ReadToolName, err)
+	}
+	return readTool, nil
+}
+
+// NewWrite creates a tool writing the files of the workspace once approved
+// by cfg.Confirm. Files are created or replaced; directories are not
+// created.
+//
+// Paths are checked as by the read tool before the write is submitted for
+// confirmation.
+func NewWrite(cfg Config) (tool.Tool, error) {
+	if cfg.Confirm == nil {
+		return nil, errors.New("Confirm is required")
+	}
+	w, err := newWorkspace(cfg)
+	if err != nil {
+		return nil, err
+	}
+	writeTool, err := functiontool.New(functiontool.Config{
+		Name:        WriteToolName,
+		Description: "Creates or replaces a file of the workspace. The user is asked to approve each write.",
+	}, func(ctx tool.Context, args WriteArgs) (WriteResult, error) {
+		return w.write(ctx, WriteRequest(args))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s tool: %w", WriteToolName, err)
+	}
+	return writeTool, nil
+}
+
+type workspace struct {
+	cfg Config
+}
+
+func newWorkspace(cfg Config) (*workspace, error) {
+	if cfg.Root == "" {
+		return nil, errors.New("Root is required")
+	}
+	info, err := os.Stat(cfg.Root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Root: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("invalid Root: %s is not a directory", cfg.Root)
+	}
+	if cfg.MaxBytes < 0 {
+		return nil, errors.New("MaxBytes must not be negative")
+	}
+	if cfg.MaxBytes == 0 {
+		cfg.MaxBytes = DefaultMaxBytes
+	}
+	for _, pattern := range cfg.AllowedPatterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q in AllowedPatterns: %w", pattern, err)
+		}
+	}
+	return &workspace{cfg: cfg}, nil
+}
+
+// check rejects paths leading outside of the root or not allowed by the
+// patterns, and returns the cleaned path.
+func (w *workspace) check(path string) (string, error) {
+	if !filepath.IsLocal(path) {
+		return "", tool.NewError(tool.ErrorCodePermissionDenied, fmt.Errorf("path %q is outside of the workspace", path))
+	}
+	path = filepath.Clean(path)
+	if len(w.cfg.AllowedPatterns) == 0 {
+		return path, nil
+	}
+	name := filepath.Base(path)
+	for _, pattern := range w.cfg.AllowedPatterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return path, nil
+		}
+	}
+	return "", tool.NewError(tool.ErrorCodePermissionDenied, fmt.Errorf("file %q is not allowed", path))
+}
+
+func (w *workspace) read(path string) (ReadResult, error) {
+	path, err := w.check(path)
+	if err != nil {
+		return ReadResult{}, err
+	}
+	root, err := os.OpenRoot(w.cfg.Root)
+	if err != nil {
+		return ReadResult{}, tool.NewError(tool.ErrorCodeUnavailable, fmt.Errorf("failed to open the workspace: %w", err))
+	}
+	defer root.Close()
+
+	f, err := root.Open(path)
+	if err != nil {
+		return ReadResult{}, openError(path, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return ReadResult{}, fmt.Errorf("failed to read %q: %w", path, err)
+	}
+	if info.IsDir() {
+		return ReadResult{}, tool.NewError(tool.ErrorCodeInvalidArgument, fmt.Errorf("%q is a directory", path))
+	}
+	content, err := io.ReadAll(io.LimitReader(f, int64(w.cfg.MaxBytes)+1))
+	if err != nil {
+		return ReadResult{}, fmt.Errorf("failed to read %q: %w", path, err)
+	}
+	result := ReadResult{Size: info.Size()}
+	if len(content) > w.cfg.MaxBytes {
+		content, result.Truncated = trimPartialRune(content[:w.cfg.MaxBytes]), true
+	}
+	result.Content = string(content)
+	return result, nil
+}
+
+func (w *workspace) write(ctx tool.Context, req WriteRequest) (WriteResult, error) {
+	path, err := w.check(req.Path)
+	if err != nil {
+		return WriteResult{}, err
+	}
+	if len(req.Content) > w.cfg.MaxBytes {
+		return WriteResult{}, tool.NewError(tool.ErrorCodeInvalidArgument, fmt.Errorf("content of %d bytes exceeds the limit of %d bytes", len(req.Content), w.cfg.MaxBytes))
+	}
+	root, err := os.OpenRoot(w.cfg.Root)
+	if err != nil {
+		return WriteResult{}, tool.NewError(tool.ErrorCodeUnavailable, fmt.Errorf("failed to open the workspace: %w", err))
+	}
+	defer root.Close()
+	// Reject writes that would fail, e.g. through symbolic links escaping
+	// the root, before asking for confirmation.
+	if _, err := root.Stat(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return WriteResult{}, openError(path, err)
+	}
+	if info, err := root.Stat(filepath.Dir(path)); err != nil {
+		return WriteResult{}, openError(filepath.Dir(path), err)
+	} else if !info.IsDir() {
+		return WriteResult{}, tool.NewError(tool.ErrorCodeInvalidArgument, fmt.Errorf("%q is not a directory", filepath.Dir(path)))
+	}
+
+	req.Path = path
+	ok, err := tool.Confirm(ctx, w.cfg.Confirm, req)
+	if err != nil {
+		return WriteResult{}, fmt.Errorf("failed to confirm write: %w", err)
+	}
+	if !ok {
+		return WriteResult{Status: StatusDeclined}, nil
+	}
+	f, err := root.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return WriteResult{}, openError(path, err)
+	}
+	if _, err := f.WriteString(req.Content); err != nil {
+		f.Close()
+		return WriteResult{}, fmt.Errorf("failed to write %q: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return WriteResult{}, fmt.Errorf("failed to write %q: %w", path, err)
+	}
+	return WriteResult{Status: StatusWritten}, nil
+}
+
+// openError returns the tool error of a file that could not be opened.
+// Besides missing files, this includes symbolic links escaping the root.
+func openError(path string, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return tool.NewError(tool.ErrorCodeNotFound, fmt.Errorf("%q not found", path))
+	}
+	return tool.NewError(tool.ErrorCodePermissionDenied, fmt.Errorf("cannot open %q: %w", path, err))
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence cut at the end of b.
+func trimPartialRune(b []byte) []byte {
+	for i := 0; i < utf8.UTFMax-1 && len(b) > 0; i++ {
+		if r, size := utf8.DecodeLastRune(b); r != utf8.RuneError || size != 1 {
+			break
+		}
+		b = b[:len(b)-1]
+	}
+	return b
+}
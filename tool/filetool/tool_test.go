@@ -0,0 +1,280 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filetool
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+)
+
+func toolContext(ctx context.Context) tool.Context {
+	return toolinternal.NewToolContext(icontext.NewInvocationContext(ctx, icontext.InvocationContextParams{}), "fc1", &session.EventActions{})
+}
+
+// newTestWorkspace creates a workspace with a few files next to a secret
+// file outside of it.
+func newTestWorkspace(t *testing.T) (root, secret string) {
+	t.Helper()
+	dir := t.TempDir()
+	root = filepath.Join(dir, "workspace")
+	secret = filepath.Join(dir, "secret.txt")
+	for path, content := range map[string]string{
+		secret:                                 "top secret",
+		filepath.Join(root, "main.go"):         "package main",
+		filepath.Join(root, "docs", "a.md"):    "# Title",
+		filepath.Join(root, "big.txt"):         "0123456789",
+		filepath.Join(root, "utf8.txt"):        "aé",
+		filepath.Join(root, "docs", "key.pem"): "private",
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(secret, filepath.Join(root, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Dir(secret), filepath.Join(root, "parent")); err != nil {
+		t.Fatal(err)
+	}
+	return root, secret
+}
+
+func TestRead(t *testing.T) {
+	root, secret := newTestWorkspace(t)
+	for _, tc := range []struct {
+		name     string
+		cfg      Config
+		path     string
+		want     ReadResult
+		wantCode string
+	}{
+		{name: "file", path: "main.go", want: ReadResult{Content: "package main", Size: 12}},
+		{name: "nested file", path: "docs/a.md", want: ReadResult{Content: "# Title", Size: 7}},
+		{name: "inner dot-dot", path: "docs/../main.go", want: ReadResult{Content: "package main", Size: 12}},
+		{name: "truncated", cfg: Config{MaxBytes: 4}, path: "big.txt", want: ReadResult{Content: "0123", Size: 10, Truncated: true}},
+		{name: "truncated in a rune", cfg: Config{MaxBytes: 2}, path: "utf8.txt", want: ReadResult{Content: "a", Size: 3, Truncated: true}},
+		{name: "allowed pattern", cfg: Config{AllowedPatterns: []string{"*.md"}}, path: "docs/a.md", want: ReadResult{Content: "# Title", Size: 7}},
+		{name: "missing file", path: "missing.go", wantCode: tool.ErrorCodeNotFound},
+		{name: "directory", path: "docs", wantCode: tool.ErrorCodeInvalidArgument},
+		{name: "disallowed pattern", cfg: Config{AllowedPatterns: []string{"*.go", "*.md"}}, path: "docs/key.pem", wantCode: tool.ErrorCodePermissionDenied},
+		{name: "parent", path: "../secret.txt", wantCode: tool.ErrorCodePermissionDenied},
+		{name: "nested parent", path: "docs/../../secret.txt", wantCode: tool.ErrorCodePermissionDenied},
+		{name: "absolute", path: secret, wantCode: tool.ErrorCodePermissionDenied},
+		{name: "absolute inside", path: filepath.Join(root, "main.go"), wantCode: tool.ErrorCodePermissionDenied},
+		{name: "empty", path: "", wantCode: tool.ErrorCodePermissionDenied},
+		{name: "symlink to outside file", path: "link.txt", wantCode: tool.ErrorCodePermissionDenied},
+		{name: "through symlink to outside directory", path: "parent/secret.txt", wantCode: tool.ErrorCodePermissionDenied},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Root = root
+			w, err := newWorkspace(tc.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := w.read(tc.path)
+			if tc.wantCode != "" {
+				if code := tool.ErrorCode(err); code != tc.wantCode {
+					t.Fatalf("read(%q) error = %v (code %s), want code %s", tc.path, err, code, tc.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("read(%q) error = %v", tc.path, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("read(%q) mismatch (-want +got):\n%s", tc.path, diff)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	approve := func(tool.Context, WriteRequest) (bool, error) { return true, nil }
+	for _, tc := range []struct {
+		name        string
+		cfg         Config
+		req         WriteRequest
+		want        WriteResult
+		wantCode    string
+		wantErr     bool
+		wantConfirm bool
+		wantFile    string
+	}{
+		{
+			name:        "approved",
+			cfg:         Config{Confirm: approve},
+			req:         WriteRequest{Path: "docs/new.md", Content: "new"},
+			want:        WriteResult{Status: StatusWritten},
+			wantConfirm: true,
+			wantFile:    "docs/new.md",
+		},
+		{
+			name:        "replaces a file",
+			cfg:         Config{Confirm: approve},
+			req:         WriteRequest{Path: "main.go", Content: "package other"},
+			want:        WriteResult{Status: StatusWritten},
+			wantConfirm: true,
+			wantFile:    "main.go",
+		},
+		{
+			name:        "declined",
+			cfg:         Config{Confirm: func(tool.Context, WriteRequest) (bool, error) { return false, nil }},
+			req:         WriteRequest{Path: "docs/new.md", Content: "new"},
+			want:        WriteResult{Status: StatusDeclined},
+			wantConfirm: true,
+		},
+		{
+			name:        "confirmation failure",
+			cfg:         Config{Confirm: func(tool.Context, WriteRequest) (bool, error) { return false, errors.New("operator unavailable") }},
+			req:         WriteRequest{Path: "docs/new.md", Content: "new"},
+			wantErr:     true,
+			wantConfirm: true,
+		},
+		{
+			name:     "too large",
+			cfg:      Config{Confirm: approve, MaxBytes: 2},
+			req:      WriteRequest{Path: "docs/new.md", Content: "new"},
+			wantCode: tool.ErrorCodeInvalidArgument,
+		},
+		{
+			name:     "disallowed pattern",
+			cfg:      Config{Confirm: approve, AllowedPatterns: []string{"*.md"}},
+			req:      WriteRequest{Path: "run.sh", Content: "rm -rf /"},
+			wantCode: tool.ErrorCodePermissionDenied,
+		},
+		{
+			name:     "parent",
+			cfg:      Config{Confirm: approve},
+			req:      WriteRequest{Path: "../secret.txt", Content: "overwritten"},
+			wantCode: tool.ErrorCodePermissionDenied,
+		},
+		{
+			name:     "absolute",
+			cfg:      Config{Confirm: approve},
+			req:      WriteRequest{Path: "/tmp/evil", Content: "overwritten"},
+			wantCode: tool.ErrorCodePermissionDenied,
+		},
+		{
+			name:     "symlink to outside file",
+			cfg:      Config{Confirm: approve},
+			req:      WriteRequest{Path: "link.txt", Content: "overwritten"},
+			wantCode: tool.ErrorCodePermissionDenied,
+		},
+		{
+			name:     "through symlink to outside directory",
+			cfg:      Config{Confirm: approve},
+			req:      WriteRequest{Path: "parent/new.txt", Content: "overwritten"},
+			wantCode: tool.ErrorCodePermissionDenied,
+		},
+		{
+			name:     "missing directory",
+			cfg:      Config{Confirm: approve},
+			req:      WriteRequest{Path: "missing/new.md", Content: "new"},
+			wantCode: tool.ErrorCodeNotFound,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			root, secret := newTestWorkspace(t)
+			tc.cfg.Root = root
+			confirm := tc.cfg.Confirm
+			var confirmed []WriteRequest
+			tc.cfg.Confirm = func(ctx tool.Context, req WriteRequest) (bool, error) {
+				confirmed = append(confirmed, req)
+				return confirm(ctx, req)
+			}
+			w, err := newWorkspace(tc.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := w.write(toolContext(t.Context()), tc.req)
+			switch {
+			case tc.wantCode != "":
+				if code := tool.ErrorCode(err); code != tc.wantCode {
+					t.Errorf("write() error = %v (code %s), want code %s", err, code, tc.wantCode)
+				}
+			case tc.wantErr:
+				if err == nil {
+					t.Errorf("write() succeeded, want error")
+				}
+			case err != nil:
+				t.Errorf("write() error = %v", err)
+			default:
+				if diff := cmp.Diff(tc.want, got); diff != "" {
+					t.Errorf("write() mismatch (-want +got):\n%s", diff)
+				}
+			}
+			if gotConfirm := len(confirmed) > 0; gotConfirm != tc.wantConfirm {
+				t.Errorf("write() asked for confirmation: %v, want %v", gotConfirm, tc.wantConfirm)
+			}
+			if tc.wantFile != "" {
+				content, err := os.ReadFile(filepath.Join(root, tc.wantFile))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(content) != tc.req.Content {
+					t.Errorf("content of %s = %q, want %q", tc.wantFile, content, tc.req.Content)
+				}
+			}
+			if content, err := os.ReadFile(secret); err != nil || string(content) != "top secret" {
+				t.Errorf("file outside of the workspace changed: %q, %v", content, err)
+			}
+			if _, err := os.Stat(filepath.Join(filepath.Dir(secret), "new.txt")); err == nil {
+				t.Errorf("file created outside of the workspace")
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	root := t.TempDir()
+	approve := func(tool.Context, WriteRequest) (bool, error) { return true, nil }
+	for _, tc := range []struct {
+		name    string
+		cfg     Config
+		write   bool
+		wantErr bool
+	}{
+		{name: "read", cfg: Config{Root: root}},
+		{name: "write", cfg: Config{Root: root, Confirm: approve}, write: true},
+		{name: "no root", cfg: Config{}, wantErr: true},
+		{name: "missing root", cfg: Config{Root: filepath.Join(root, "missing")}, wantErr: true},
+		{name: "bad pattern", cfg: Config{Root: root, AllowedPatterns: []string{"["}}, wantErr: true},
+		{name: "negative max bytes", cfg: Config{Root: root, MaxBytes: -1}, wantErr: true},
+		{name: "write without confirm", cfg: Config{Root: root}, write: true, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			newTool := NewRead
+			if tc.write {
+				newTool = NewWrite
+			}
+			if _, err := newTool(tc.cfg); (err != nil) != tc.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/google/jsonschema-go/jsonschema"
 	"google.golang.org/genai"
 
+	"google.golang.org/adk/internal/toolinternal"
 	"google.golang.org/adk/internal/toolinternal/toolutils"
 	"google.golang.org/adk/internal/typeutil"
 	"google.golang.org/adk/model"
@@ -46,8 +47,21 @@ type Config struct {
 	OutputSchema *jsonschema.Schema
 	// IsLongRunning makes a FunctionTool a long-running operation.
 	IsLongRunning bool
+	// PostProcess, if set, transforms the response of the tool before it is
+	// sent to the model, e.g. to trim a verbose API response. raw is the
+	// response of the tool as a map[string]any; a result that is not a map
+	// is sent as {"result": result}. The raw response is still recorded on
+	// the tool span and in the custom metadata of the function response
+	// event, under RawResponsesMetadataKey. Error responses are not
+	// post-processed.
+	PostProcess func(ctx tool.Context, raw any) (any, error)
 }
 
+// RawResponsesMetadataKey is the key of the custom metadata of function
+// response events keeping the raw responses of the tools with a PostProcess
+// function, by function call ID.
+const RawResponsesMetadataKey = toolinternal.RawResponsesMetadataKey
+
 // Func represents a Go function that can be wrapped in a tool.
 // It takes a tool.Context and a generic argument type, and returns a generic result type.
 type Func[TArgs, TResults any] func(tool.Context, TArgs) (TResults, error)
@@ -115,6 +129,11 @@ func (f *functionTool[TArgs, TResults]) IsLongRunning() bool {
 	return f.cfg.IsLongRunning
 }
 
+// PostProcessor implements toolinternal.PostProcessedTool.
+func (f *functionTool[TArgs, TResults]) PostProcessor() func(ctx tool.Context, raw any) (any, error) {
+	return f.cfg.PostProcess
+}
+
 // ProcessRequest packs the function tool's declaration into the LLM request.
 func (f *functionTool[TArgs, TResults]) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
 	return toolutils.PackTool(req, f)
@@ -160,7 +179,7 @@ func (f *functionTool[TArgs, TResults]) Run(ctx tool.Context, args any) (result
 	}
 	input, err := typeutil.ConvertToWithJSONSchema[map[string]any, TArgs](m, f.inputSchema)
 	if err != nil {
-		return nil, err
+		return nil, tool.NewError(tool.ErrorCodeInvalidArgument, err)
 	}
 	output, err := f.handler(ctx, input)
 	if err != nil {
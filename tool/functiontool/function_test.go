@@ -28,6 +28,7 @@ import (
 	"github.com/google/jsonschema-go/jsonschema"
 	"google.golang.org/genai"
 
+	"google.golang.org/adk/agent/llmagent"
 	"google.golang.org/adk/internal/httprr"
 	"google.golang.org/adk/internal/testutil"
 	"google.golang.org/adk/internal/toolinternal"
@@ -655,3 +656,96 @@ func TestFunctionTool_PanicRecovery(t *testing.T) {
 		}
 	}
 }
+
+func TestFunctionTool_PostProcess(t *testing.T) {
+	t.Parallel()
+
+	type Args struct {
+		Query string `json:"query"`
+	}
+	type Result struct {
+		Rows []string `json:"rows"`
+	}
+	raw := map[string]any{"rows": []any{"a", "b", "c"}}
+
+	for _, tc := range []struct {
+		name        string
+		postProcess func(ctx tool.Context, raw any) (any, error)
+		want        map[string]any
+	}{
+		{
+			name: "summary",
+			postProcess: func(ctx tool.Context, raw any) (any, error) {
+				rows := raw.(map[string]any)["rows"].([]any)
+				return map[string]any{"count": len(rows), "first": rows[0]}, nil
+			},
+			want: map[string]any{"count": 3, "first": "a"},
+		},
+		{
+			name: "non-map result",
+			postProcess: func(ctx tool.Context, raw any) (any, error) {
+				return len(raw.(map[string]any)["rows"].([]any)), nil
+			},
+			want: map[string]any{"result": 3},
+		},
+		{
+			name: "error",
+			postProcess: func(ctx tool.Context, raw any) (any, error) {
+				return nil, errors.New("too many rows")
+			},
+			want: map[string]any{"status": "error", "error_code": "INTERNAL", "error": "failed to post-process the response: too many rows"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := functiontool.New(functiontool.Config{
+				Name:        "query",
+				Description: "queries rows",
+				PostProcess: tc.postProcess,
+			}, func(ctx tool.Context, args Args) (Result, error) {
+				return Result{Rows: []string{"a", "b", "c"}}, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := &testutil.MockModel{Responses: []*genai.Content{
+				genai.NewContentFromFunctionCall("query", map[string]any{"query": "all"}, genai.RoleModel),
+				genai.NewContentFromText("done", genai.RoleModel),
+			}}
+			a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, Tools: []tool.Tool{query}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "s1", "hi"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotRaw any
+			for _, ev := range events {
+				if v, ok := ev.CustomMetadata[functiontool.RawResponsesMetadataKey].(map[string]any); ok {
+					for _, r := range v {
+						gotRaw = r
+					}
+				}
+			}
+			if diff := cmp.Diff(raw, gotRaw); diff != "" {
+				t.Errorf("raw response of the event mismatch (-want +got):\n%s", diff)
+			}
+
+			if len(m.Requests) != 2 {
+				t.Fatalf("got %d model requests, want 2", len(m.Requests))
+			}
+			var got map[string]any
+			for _, c := range m.Requests[1].Contents {
+				for _, p := range c.Parts {
+					if p.FunctionResponse != nil {
+						got = p.FunctionResponse.Response
+					}
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("function response sent to the model mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
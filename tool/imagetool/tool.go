@@ -0,0 +1,221 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package imagetool provides a tool generating images with an image
+// generation model, e.g. Imagen.
+//
+// If the invocation has an artifact service, the generated images are saved
+// as artifacts and the response lists their names, so the model can load
+// them with the load_artifacts tool. Otherwise the images are attached
+// inline to the function response. Either way the image bytes are not
+// recorded on spans: inline data is filtered out of traces.
+package imagetool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// Defaults used for unset Config fields.
+const (
+	DefaultName     = "generate_image"
+	DefaultMaxCount = 4
+)
+
+// Generator generates images. It is implemented by genai.Models, e.g. the
+// Models field of a genai.Client.
+type Generator interface {
+	GenerateImages(ctx context.Context, model, prompt string, config *genai.GenerateImagesConfig) (*genai.GenerateImagesResponse, error)
+}
+
+// Config is the configuration of an image generation tool.
+type Config struct {
+	// Name is the name of the tool. Defaults to DefaultName.
+	Name string
+	// Description tells the model what the images are for. Defaults to a
+	// generic description.
+	Description string
+	// Generator generates the images.
+	Generator Generator
+	// Model is the name of the image generation model, e.g.
+	// "imagen-4.0-generate-001".
+	Model string
+	// MaxCount limits the number of images of a call. Defaults to
+	// DefaultMaxCount.
+	MaxCount int
+	// MIMEType is the format of the generated images, e.g. "image/jpeg".
+	// Defaults to the format chosen by the model.
+	MIMEType string
+}
+
+// Args are the arguments of a call of an image generation tool.
+type Args struct {
+	// Prompt describes the images to generate.
+	Prompt string `json:"prompt" jsonschema:"a description of the images to generate"`
+	// Count is the number of images to generate. Defaults to 1.
+	Count int `json:"count,omitempty" jsonschema:"the number of images to generate, 1 by default"`
+	// AspectRatio is the aspect ratio of the images, e.g. "1:1" or "16:9".
+	AspectRatio string `json:"aspect_ratio,omitempty" jsonschema:"the aspect ratio of the images, e.g. 1:1, 3:4, 4:3, 9:16 or 16:9"`
+	// Size is the resolution of the images, e.g. "1K" or "2K".
+	Size string `json:"size,omitempty" jsonschema:"the resolution of the images, 1K or 2K"`
+}
+
+// Result is the response of an image generation tool.
+type Result struct {
+	// Images are the generated images, in order.
+	Images []Image `json:"images,omitempty"`
+	// Error describes why the model failed to generate the images.
+	Error *Error `json:"error,omitempty"`
+}
+
+// Image describes a generated image.
+type Image struct {
+	// Artifact is the name of the artifact the image was saved as, if the
+	// invocation has an artifact service.
+	Artifact string `json:"artifact,omitempty"`
+	// Version is the version of the artifact.
+	Version int64 `json:"version,omitempty"`
+	// MIMEType is the format of the image.
+	MIMEType string `json:"mime_type,omitempty"`
+	// FilteredReason is the reason why the image was filtered out by the
+	// responsible AI filters of the model, if it was.
+	FilteredReason string `json:"filtered_reason,omitempty"`
+}
+
+// Error is a failure of the image generation model reported to the model.
+type Error struct {
+	// Code is the HTTP status code of the error, if any.
+	Code int `json:"code,omitempty"`
+	// Status is the status of the error, e.g. "INVALID_ARGUMENT".
+	Status string `json:"status,omitempty"`
+	// Message describes the error.
+	Message string `json:"message"`
+}
+
+// New creates a tool generating images with the configured model.
+//
+// A failure of the image generation model is not an error of the call: it
+// is returned to the model as a Result with an Error, so it can rephrase
+// the prompt or tell the user. Errors are returned for invalid arguments and
+// failures of the artifact service.
+func New(cfg Config) (tool.Tool, error) {
+	g, err := newGenerator(cfg)
+	if err != nil {
+		return nil, err
+	}
+	imageTool, err := functiontool.New(functiontool.Config{
+		Name:        g.cfg.Name,
+		Description: g.cfg.Description,
+	}, func(ctx tool.Context, args Args) (Result, error) {
+		return g.generate(ctx, args)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s tool: %w", g.cfg.Name, err)
+	}
+	return imageTool, nil
+}
+
+type generator struct {
+	cfg Config
+}
+
+func newGenerator(cfg Config) (*generator, error) {
+	if cfg.Generator == nil {
+		return nil, errors.New("Generator is required")
+	}
+	if cfg.Model == "" {
+		return nil, errors.New("Model is required")
+	}
+	if cfg.Name == "" {
+		cfg.Name = DefaultName
+	}
+	if cfg.Description == "" {
+		cfg.Description = "Generates images from a text description."
+	}
+	if cfg.MaxCount == 0 {
+		cfg.MaxCount = DefaultMaxCount
+	}
+	if cfg.MaxCount < 0 {
+		return nil, errors.New("MaxCount must not be negative")
+	}
+	return &generator{cfg: cfg}, nil
+}
+
+func (g *generator) generate(ctx tool.Context, args Args) (Result, error) {
+	if args.Prompt == "" {
+		return Result{}, errors.New("prompt is required")
+	}
+	if args.Count == 0 {
+		args.Count = 1
+	}
+	if args.Count < 0 || args.Count > g.cfg.MaxCount {
+		return Result{}, fmt.Errorf("count must be between 1 and %d", g.cfg.MaxCount)
+	}
+	resp, err := g.cfg.Generator.GenerateImages(ctx, g.cfg.Model, args.Prompt, &genai.GenerateImagesConfig{
+		NumberOfImages:   int32(args.Count),
+		AspectRatio:      args.AspectRatio,
+		ImageSize:        args.Size,
+		OutputMIMEType:   g.cfg.MIMEType,
+		IncludeRAIReason: true,
+	})
+	if err != nil {
+		return Result{Error: toolError(err)}, nil
+	}
+
+	var result Result
+	generatedCount := 0
+	for i, generated := range resp.GeneratedImages {
+		if generated.Image == nil || len(generated.Image.ImageBytes) == 0 {
+			result.Images = append(result.Images, Image{FilteredReason: generated.RAIFilteredReason})
+			continue
+		}
+		image := Image{MIMEType: generated.Image.MIMEType}
+		if image.MIMEType == "" {
+			image.MIMEType = "image/png"
+		}
+		if artifacts := ctx.Artifacts(); artifacts != nil {
+			name := fmt.Sprintf("%s_%s_%d", g.cfg.Name, ctx.FunctionCallID(), i)
+			saved, err := artifacts.Save(ctx, name, genai.NewPartFromBytes(generated.Image.ImageBytes, image.MIMEType))
+			if err != nil {
+				return Result{}, fmt.Errorf("failed to save image %d: %w", i, err)
+			}
+			image.Artifact, image.Version = name, saved.Version
+		} else if !tool.AddResponseParts(ctx, &genai.FunctionResponsePart{InlineData: &genai.FunctionResponseBlob{
+			MIMEType: image.MIMEType,
+			Data:     generated.Image.ImageBytes,
+		}}) {
+			return Result{}, fmt.Errorf("image %d can neither be saved as an artifact nor attached to the response", i)
+		}
+		result.Images = append(result.Images, image)
+		generatedCount++
+	}
+	if generatedCount == 0 {
+		result.Error = &Error{Message: "the model generated no image"}
+	}
+	return result, nil
+}
+
+func toolError(err error) *Error {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return &Error{Code: apiErr.Code, Status: apiErr.Status, Message: apiErr.Message}
+	}
+	return &Error{Message: err.Error()}
+}
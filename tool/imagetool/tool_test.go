@@ -0,0 +1,225 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imagetool
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/artifact"
+	artifactinternal "google.golang.org/adk/internal/artifact"
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+)
+
+// imageBytes is large enough to be noticed in a trace.
+var imageBytes = []byte(strings.Repeat("png!", 64))
+
+// fakeGenerator returns an image per requested image, except the second
+// one which is filtered out, or all of them if filterAll is set.
+type fakeGenerator struct {
+	err       error
+	filterAll bool
+	prompts   []string
+	configs   []*genai.GenerateImagesConfig
+}
+
+func (g *fakeGenerator) GenerateImages(ctx context.Context, model, prompt string, config *genai.GenerateImagesConfig) (*genai.GenerateImagesResponse, error) {
+	g.prompts = append(g.prompts, prompt)
+	g.configs = append(g.configs, config)
+	if g.err != nil {
+		return nil, g.err
+	}
+	resp := &genai.GenerateImagesResponse{}
+	for i := range config.NumberOfImages {
+		if i == 1 || g.filterAll {
+			resp.GeneratedImages = append(resp.GeneratedImages, &genai.GeneratedImage{RAIFilteredReason: "blocked"})
+			continue
+		}
+		resp.GeneratedImages = append(resp.GeneratedImages, &genai.GeneratedImage{Image: &genai.Image{ImageBytes: imageBytes, MIMEType: "image/png"}})
+	}
+	return resp, nil
+}
+
+func TestGenerateInline(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(prev)
+
+	backend := &fakeGenerator{}
+	imageTool, err := New(Config{Generator: backend, Model: "imagen"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name: "creative_agent",
+		Model: &testutil.MockModel{Responses: []*genai.Content{
+			genai.NewContentFromFunctionCall(DefaultName, map[string]any{"prompt": "a red fox", "count": 2, "aspect_ratio": "16:9"}, genai.RoleModel),
+			genai.NewContentFromText("here it is", genai.RoleModel),
+		}},
+		Tools: []tool.Tool{imageTool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "s1", "draw a fox"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fr *genai.FunctionResponse
+	for _, ev := range events {
+		for _, p := range ev.Content.Parts {
+			if p.FunctionResponse != nil {
+				fr = p.FunctionResponse
+			}
+		}
+	}
+	if fr == nil {
+		t.Fatal("no function response")
+	}
+	wantResponse := map[string]any{"images": []any{
+		map[string]any{"mime_type": "image/png"},
+		map[string]any{"filtered_reason": "blocked"},
+	}}
+	if diff := cmp.Diff(wantResponse, fr.Response); diff != "" {
+		t.Errorf("function response mismatch (-want +got):\n%s", diff)
+	}
+	wantParts := []*genai.FunctionResponsePart{{InlineData: &genai.FunctionResponseBlob{MIMEType: "image/png", Data: imageBytes}}}
+	if diff := cmp.Diff(wantParts, fr.Parts); diff != "" {
+		t.Errorf("function response parts mismatch (-want +got):\n%s", diff)
+	}
+	wantConfig := &genai.GenerateImagesConfig{NumberOfImages: 2, AspectRatio: "16:9", IncludeRAIReason: true}
+	if diff := cmp.Diff([]*genai.GenerateImagesConfig{wantConfig}, backend.configs); diff != "" {
+		t.Errorf("image generation config mismatch (-want +got):\n%s", diff)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(imageBytes)
+	for _, span := range recorder.Ended() {
+		for _, kv := range span.Attributes() {
+			if v := kv.Value.Emit(); strings.Contains(v, encoded) || strings.Contains(v, string(imageBytes)) {
+				t.Errorf("attribute %s of span %s records the image", kv.Key, span.Name())
+			}
+		}
+	}
+}
+
+func TestGenerateArtifacts(t *testing.T) {
+	service := artifact.InMemoryService()
+	sess := session.InMemoryService()
+	created, err := sess.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inv := icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{
+		Session: created.Session,
+		Artifacts: &artifactinternal.Artifacts{
+			Service:   service,
+			AppName:   "app",
+			UserID:    "user",
+			SessionID: created.Session.ID(),
+		},
+	})
+	actions := &session.EventActions{}
+	ctx := toolinternal.NewToolContext(inv, "fc1", actions)
+
+	g, err := newGenerator(Config{Generator: &fakeGenerator{}, Model: "imagen"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := g.generate(ctx, Args{Prompt: "a red fox"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Result{Images: []Image{{Artifact: "generate_image_fc1_0", Version: 1, MIMEType: "image/png"}}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("generate() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]int64{"generate_image_fc1_0": 1}, actions.ArtifactDelta); diff != "" {
+		t.Errorf("artifact delta mismatch (-want +got):\n%s", diff)
+	}
+	loaded, err := service.Load(t.Context(), &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: created.Session.ID(), FileName: "generate_image_fc1_0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(imageBytes, loaded.Part.InlineData.Data); diff != "" {
+		t.Errorf("saved image mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	ctx := toolinternal.NewToolContext(icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{}), "fc1", nil)
+	for _, tc := range []struct {
+		name      string
+		err       error
+		filterAll bool
+		args      Args
+		want      Result
+		wantErr   bool
+	}{
+		{
+			name: "model error",
+			err:  genai.APIError{Code: 400, Status: "INVALID_ARGUMENT", Message: "unsupported aspect ratio"},
+			args: Args{Prompt: "a fox", AspectRatio: "7:5"},
+			want: Result{Error: &Error{Code: 400, Status: "INVALID_ARGUMENT", Message: "unsupported aspect ratio"}},
+		},
+		{
+			name:      "all images filtered",
+			filterAll: true,
+			args:      Args{Prompt: "a fox", Count: 2},
+			want: Result{
+				Images: []Image{{FilteredReason: "blocked"}, {FilteredReason: "blocked"}},
+				Error:  &Error{Message: "the model generated no image"},
+			},
+		},
+		{
+			name:    "too many images",
+			args:    Args{Prompt: "a fox", Count: 5},
+			wantErr: true,
+		},
+		{
+			name:    "no prompt",
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			backend := &fakeGenerator{err: tc.err, filterAll: tc.filterAll}
+			g, err := newGenerator(Config{Generator: backend, Model: "imagen"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := g.generate(ctx, tc.args)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("generate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("generate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -110,6 +110,7 @@ func TestMCPToolSet(t *testing.T) {
 	wantEvents := []*session.Event{
 		{
 			Author: "weather_time_agent",
+			Kind:   session.EventKindToolCall,
 			LLMResponse: model.LLMResponse{
 				Content: &genai.Content{
 					Parts: []*genai.Part{
@@ -126,6 +127,7 @@ func TestMCPToolSet(t *testing.T) {
 		},
 		{
 			Author: "weather_time_agent",
+			Kind:   session.EventKindToolResponse,
 			LLMResponse: model.LLMResponse{
 				Content: &genai.Content{
 					Parts: []*genai.Part{
@@ -144,6 +146,7 @@ func TestMCPToolSet(t *testing.T) {
 		},
 		{
 			Author: "weather_time_agent",
+			Kind:   session.EventKindModelText,
 			LLMResponse: model.LLMResponse{
 				Content: &genai.Content{
 					Parts: []*genai.Part{
@@ -0,0 +1,182 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package notifytool provides a tool sending notifications, e.g. emails,
+// through a pluggable [Transport].
+//
+// Messages go through Config.Confirm, a [tool.ConfirmFunc], before they are
+// handed to the transport. In dry-run mode messages are logged instead of
+// sent and no confirmation is asked.
+//
+// Only the recipients and the subject of a message are recorded on the
+// spans and logs of a call; the body never is.
+package notifytool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/adk/internal/logging"
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// Message is a notification handed to a transport.
+type Message struct {
+	// To lists the recipients, e.g. email addresses.
+	To []string
+	// Subject is the subject of the message.
+	Subject string
+	// Body is the text of the message.
+	Body string
+}
+
+// Transport delivers messages.
+type Transport interface {
+	// Name identifies the transport in tool errors, e.g. "smtp".
+	Name() string
+	// Send delivers the message.
+	Send(ctx context.Context, msg Message) error
+}
+
+// ConfirmFunc approves the messages of a notification tool.
+type ConfirmFunc = tool.ConfirmFunc[Message]
+
+// Config is the configuration of a notification tool.
+type Config struct {
+	// Name is the name of the tool. Defaults to "send_notification".
+	Name string
+	// Description tells the model what the notifications are for.
+	// Defaults to a generic description.
+	Description string
+	// Transport delivers the approved messages. Required unless DryRun is
+	// set.
+	Transport Transport
+	// Confirm approves each message before it is sent. Required unless
+	// DryRun is set.
+	Confirm ConfirmFunc
+	// DryRun logs messages instead of sending them.
+	DryRun bool
+}
+
+// Args are the arguments of a call of a notification tool.
+type Args struct {
+	// To lists the recipients of the message.
+	To []string `json:"to" jsonschema:"the recipients of the message"`
+	// Subject is the subject of the message.
+	Subject string `json:"subject" jsonschema:"the subject of the message"`
+	// Body is the text of the message.
+	Body string `json:"body" jsonschema:"the text of the message"`
+}
+
+// Statuses of a call reported in Result.Status.
+const (
+	StatusSent     = "sent"
+	StatusDryRun   = "dry_run"
+	StatusDeclined = "declined"
+	StatusFailed   = "failed"
+)
+
+// Result is the response of a notification tool.
+type Result struct {
+	// Status is one of StatusSent, StatusDryRun, StatusDeclined and
+	// StatusFailed.
+	Status string `json:"status"`
+	// Error describes why the transport failed to send the message, if
+	// Status is StatusFailed.
+	Error *Error `json:"error,omitempty"`
+}
+
+// Error is a transport failure reported to the model.
+type Error struct {
+	// Transport is the name of the transport that failed.
+	Transport string `json:"transport"`
+	// Message is the error returned by the transport.
+	Message string `json:"message"`
+}
+
+// New creates a tool sending the messages approved by cfg.Confirm through
+// cfg.Transport.
+//
+// A transport failure is not an error of the call: it is returned to the
+// model as a Result with an Error, so it can tell the user or retry. Errors
+// are returned for invalid arguments and failures of Confirm.
+func New(cfg Config) (tool.Tool, error) {
+	n, err := newNotifier(cfg)
+	if err != nil {
+		return nil, err
+	}
+	notifyTool, err := functiontool.New(functiontool.Config{
+		Name:        n.cfg.Name,
+		Description: n.cfg.Description,
+	}, func(ctx tool.Context, args Args) (Result, error) {
+		return n.notify(ctx, Message(args))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s tool: %w", n.cfg.Name, err)
+	}
+	return notifyTool, nil
+}
+
+type notifier struct {
+	cfg Config
+}
+
+func newNotifier(cfg Config) (*notifier, error) {
+	if cfg.Name == "" {
+		cfg.Name = "send_notification"
+	}
+	if cfg.Description == "" {
+		cfg.Description = "Sends a notification with a subject and a body to the given recipients. The user is asked to approve each notification before it is sent."
+	}
+	if !cfg.DryRun && (cfg.Transport == nil || cfg.Confirm == nil) {
+		return nil, errors.New("Transport and Confirm are required unless DryRun is set")
+	}
+	return &notifier{cfg: cfg}, nil
+}
+
+func (n *notifier) notify(ctx tool.Context, msg Message) (Result, error) {
+	if len(msg.To) == 0 {
+		return Result{}, errors.New("at least one recipient is required")
+	}
+	for _, to := range msg.To {
+		if strings.TrimSpace(to) == "" {
+			return Result{}, errors.New("recipients must not be empty")
+		}
+	}
+	telemetry.SetNotification(telemetry.SpansFromContext(ctx), msg.To, msg.Subject, n.cfg.DryRun)
+
+	logger := logging.FromContext(ctx).With(slog.String("tool", n.cfg.Name), slog.Any("to", msg.To), slog.String("subject", msg.Subject))
+	if n.cfg.DryRun {
+		logger.InfoContext(ctx, "dry run: notification not sent")
+		return Result{Status: StatusDryRun}, nil
+	}
+	ok, err := tool.Confirm(ctx, n.cfg.Confirm, msg)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to confirm notification: %w", err)
+	}
+	if !ok {
+		logger.InfoContext(ctx, "notification declined")
+		return Result{Status: StatusDeclined}, nil
+	}
+	if err := n.cfg.Transport.Send(ctx, msg); err != nil {
+		logger.WarnContext(ctx, "failed to send notification", slog.String("transport", n.cfg.Transport.Name()), slog.Any("error", err))
+		return Result{Status: StatusFailed, Error: &Error{Transport: n.cfg.Transport.Name(), Message: err.Error()}}, nil
+	}
+	return Result{Status: StatusSent}, nil
+}
@@ -0,0 +1,241 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifytool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+)
+
+type fakeTransport struct {
+	sent []Message
+	err  error
+}
+
+func (t *fakeTransport) Name() string { return "fake" }
+
+func (t *fakeTransport) Send(ctx context.Context, msg Message) error {
+	if t.err != nil {
+		return t.err
+	}
+	t.sent = append(t.sent, msg)
+	return nil
+}
+
+func toolContext(ctx context.Context) tool.Context {
+	return toolinternal.NewToolContext(icontext.NewInvocationContext(ctx, icontext.InvocationContextParams{}), "fc1", &session.EventActions{})
+}
+
+func TestNotify(t *testing.T) {
+	msg := Message{To: []string{"ana@example.com"}, Subject: "Invoice", Body: "Please find it attached."}
+	approve := func(tool.Context, Message) (bool, error) { return true, nil }
+	for _, tc := range []struct {
+		name         string
+		cfg          Config
+		transportErr error
+		msg          Message
+		want         Result
+		wantSent     bool
+		wantErr      bool
+	}{
+		{
+			name:     "approved",
+			cfg:      Config{Confirm: approve},
+			msg:      msg,
+			want:     Result{Status: StatusSent},
+			wantSent: true,
+		},
+		{
+			name: "declined",
+			cfg:  Config{Confirm: func(tool.Context, Message) (bool, error) { return false, nil }},
+			msg:  msg,
+			want: Result{Status: StatusDeclined},
+		},
+		{
+			name: "dry run",
+			cfg: Config{DryRun: true, Confirm: func(tool.Context, Message) (bool, error) {
+				return false, errors.New("confirmation asked in dry run")
+			}},
+			msg:  msg,
+			want: Result{Status: StatusDryRun},
+		},
+		{
+			name:         "transport failure",
+			cfg:          Config{Confirm: approve},
+			transportErr: errors.New("connection refused"),
+			msg:          msg,
+			want:         Result{Status: StatusFailed, Error: &Error{Transport: "fake", Message: "connection refused"}},
+		},
+		{
+			name:    "confirmation failure",
+			cfg:     Config{Confirm: func(tool.Context, Message) (bool, error) { return false, errors.New("operator unavailable") }},
+			msg:     msg,
+			wantErr: true,
+		},
+		{
+			name:    "no recipient",
+			cfg:     Config{Confirm: approve},
+			msg:     Message{Subject: "Invoice"},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			transport := &fakeTransport{err: tc.transportErr}
+			tc.cfg.Transport = transport
+			n, err := newNotifier(tc.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := n.notify(toolContext(t.Context()), tc.msg)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("notify() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("notify() mismatch (-want +got):\n%s", diff)
+			}
+			var wantSent []Message
+			if tc.wantSent {
+				wantSent = []Message{tc.msg}
+			}
+			if diff := cmp.Diff(wantSent, transport.sent); diff != "" {
+				t.Errorf("sent messages mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNotifySpanAttributes(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	_, span := tp.Tracer("test").Start(t.Context(), "execute_tool")
+	ctx := telemetry.ContextWithSpan(t.Context(), []trace.Span{span})
+
+	n, err := newNotifier(Config{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.notify(toolContext(ctx), Message{To: []string{"ana@example.com"}, Subject: "Invoice", Body: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	span.End()
+
+	got := make(map[string]any)
+	for _, kv := range recorder.Ended()[0].Attributes() {
+		got[string(kv.Key)] = kv.Value.AsInterface()
+		if strings.Contains(kv.Value.Emit(), "secret") {
+			t.Errorf("attribute %s records the body", kv.Key)
+		}
+	}
+	want := map[string]any{
+		"gcp.vertex.agent.notification_recipients": []string{"ana@example.com"},
+		"gcp.vertex.agent.notification_subject":    "Invoice",
+		"gcp.vertex.agent.notification_dry_run":    true,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("span attributes mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Config{DryRun: true}); err != nil {
+		t.Errorf("New() in dry run error = %v", err)
+	}
+	if _, err := New(Config{Transport: &fakeTransport{}}); err == nil {
+		t.Error("New() without Confirm succeeded")
+	}
+}
+
+func TestSMTPTransport(t *testing.T) {
+	transport, err := NewSMTPTransport(SMTPConfig{Addr: "smtp.example.com:587", From: "bot@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotTo []string
+	var gotMsg string
+	transport.(*smtpTransport).sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotTo, gotMsg = to, string(msg)
+		return nil
+	}
+	if err := transport.Send(t.Context(), Message{To: []string{"Ana <ana@example.com>"}, Subject: "Café", Body: "line 1\nline 2"}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"ana@example.com"}, gotTo); diff != "" {
+		t.Errorf("recipients mismatch (-want +got):\n%s", diff)
+	}
+	wantMsg := "From: bot@example.com\r\nTo: ana@example.com\r\nSubject: =?utf-8?q?Caf=C3=A9?=\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\nline 1\r\nline 2"
+	if diff := cmp.Diff(wantMsg, gotMsg); diff != "" {
+		t.Errorf("message mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, msg := range []Message{
+		{To: []string{"not an address"}, Subject: "x"},
+		{To: []string{"ana@example.com"}, Subject: "x\r\nBcc: eve@example.com"},
+	} {
+		if err := transport.Send(t.Context(), msg); err == nil {
+			t.Errorf("Send(%+v) succeeded, want error", msg)
+		}
+	}
+}
+
+func TestWebhookTransport(t *testing.T) {
+	var got Args
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	transport, err := NewWebhookTransport(WebhookConfig{URL: srv.URL, Header: http.Header{"Authorization": {"Bearer token"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := Message{To: []string{"#ops"}, Subject: "Deploy", Body: "done"}
+	if err := transport.Send(t.Context(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(Args(msg), got); diff != "" {
+		t.Errorf("payload mismatch (-want +got):\n%s", diff)
+	}
+
+	status = http.StatusBadGateway
+	if err := transport.Send(t.Context(), msg); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("Send() error = %v, want a 502 error", err)
+	}
+}
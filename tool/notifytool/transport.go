@@ -0,0 +1,143 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifytool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPConfig is the configuration of an SMTP transport.
+type SMTPConfig struct {
+	// Addr is the host:port of the SMTP server.
+	Addr string
+	// Auth authenticates to the server, e.g. smtp.PlainAuth. Optional.
+	Auth smtp.Auth
+	// From is the address of the sender.
+	From string
+}
+
+// NewSMTPTransport returns a transport sending messages as plain text
+// emails. Recipients must be email addresses.
+func NewSMTPTransport(cfg SMTPConfig) (Transport, error) {
+	if cfg.Addr == "" {
+		return nil, errors.New("Addr is required")
+	}
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return nil, fmt.Errorf("invalid From address %q: %w", cfg.From, err)
+	}
+	return &smtpTransport{cfg: cfg, sendMail: smtp.SendMail}, nil
+}
+
+type smtpTransport struct {
+	cfg      SMTPConfig
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func (t *smtpTransport) Name() string { return "smtp" }
+
+func (t *smtpTransport) Send(ctx context.Context, msg Message) error {
+	var to []string
+	for _, r := range msg.To {
+		addr, err := mail.ParseAddress(r)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", r, err)
+		}
+		to = append(to, addr.Address)
+	}
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return errors.New("subject must be a single line")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", t.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return t.sendMail(t.cfg.Addr, t.cfg.Auth, t.cfg.From, to, b.Bytes())
+}
+
+// DefaultWebhookTimeout limits the duration of a webhook request if
+// WebhookConfig.Client is not set.
+const DefaultWebhookTimeout = 10 * time.Second
+
+// WebhookConfig is the configuration of a webhook transport.
+type WebhookConfig struct {
+	// URL receives the messages.
+	URL string
+	// Header is added to each request, e.g. for authorization.
+	Header http.Header
+	// Client sends the requests. Defaults to a client with
+	// DefaultWebhookTimeout.
+	Client *http.Client
+}
+
+// NewWebhookTransport returns a transport posting messages as JSON objects
+// with "to", "subject" and "body" fields. A response with a status other
+// than 2xx is an error.
+func NewWebhookTransport(cfg WebhookConfig) (Transport, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("URL is required")
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: DefaultWebhookTimeout}
+	}
+	return &webhookTransport{cfg: cfg}, nil
+}
+
+type webhookTransport struct {
+	cfg WebhookConfig
+}
+
+func (t *webhookTransport) Name() string { return "webhook" }
+
+func (t *webhookTransport) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(Args(msg))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	for k, v := range t.cfg.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook responded with %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
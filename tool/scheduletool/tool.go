@@ -0,0 +1,113 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scheduletool provides a tool scheduling a follow-up of the
+// current session, e.g. a reminder.
+//
+// The tool only stores the follow-up; a [scheduler.Scheduler] sharing the
+// store fires it when it is due.
+package scheduletool
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"google.golang.org/adk/internal/clock"
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/scheduler"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// DefaultMaxDelay is the maximum delay of a follow-up if Config.MaxDelay is
+// zero.
+const DefaultMaxDelay = 30 * 24 * time.Hour
+
+// Config is the configuration of the schedule tool.
+type Config struct {
+	// Store holds the scheduled follow-ups.
+	Store scheduler.Store
+	// MaxDelay limits how far in the future a follow-up can be scheduled.
+	// Defaults to DefaultMaxDelay; it must not be negative.
+	MaxDelay time.Duration
+}
+
+// Args are the arguments of a call of the schedule tool.
+type Args struct {
+	// DelaySeconds is the delay after which the follow-up fires.
+	DelaySeconds int `json:"delay_seconds" jsonschema:"the number of seconds after which the follow-up fires"`
+	// Message is sent to the agent when the follow-up fires.
+	Message string `json:"message" jsonschema:"the message you receive when the follow-up fires, e.g. what to remind the user of"`
+}
+
+// Result is the response of the schedule tool.
+type Result struct {
+	// TaskID identifies the scheduled follow-up.
+	TaskID string `json:"task_id"`
+	// FireAt is the time the follow-up fires at, in RFC 3339 format.
+	FireAt string `json:"fire_at"`
+}
+
+// New creates a tool named schedule_follow_up, scheduling a follow-up of
+// the session of the call.
+func New(cfg Config) (tool.Tool, error) {
+	return newTool(cfg, clock.Real())
+}
+
+func newTool(cfg Config, clk clock.Clock) (tool.Tool, error) {
+	if cfg.Store == nil {
+		return nil, errors.New("Store is required")
+	}
+	if cfg.MaxDelay < 0 {
+		return nil, fmt.Errorf("MaxDelay must not be negative, got %v", cfg.MaxDelay)
+	}
+	if cfg.MaxDelay == 0 {
+		cfg.MaxDelay = DefaultMaxDelay
+	}
+	maxSeconds := int(cfg.MaxDelay / time.Second)
+	scheduleTool, err := functiontool.New(functiontool.Config{
+		Name:        "schedule_follow_up",
+		Description: "Schedules a follow-up of this conversation: after the delay, you receive the message and can act on it, e.g. remind the user of something.",
+	}, func(ctx tool.Context, args Args) (Result, error) {
+		// The seconds are checked before their conversion to a duration,
+		// which could overflow.
+		if args.DelaySeconds <= 0 || args.DelaySeconds > maxSeconds {
+			return Result{}, fmt.Errorf("delay_seconds must be between 1 and %d", maxSeconds)
+		}
+		delay := time.Duration(args.DelaySeconds) * time.Second
+		if args.Message == "" {
+			return Result{}, errors.New("message is required")
+		}
+		task := scheduler.Task{
+			ID:        uuid.NewString(),
+			AppName:   ctx.AppName(),
+			UserID:    ctx.UserID(),
+			SessionID: ctx.SessionID(),
+			FireAt:    clk.Now().Add(delay),
+			Message:   args.Message,
+		}
+		if err := cfg.Store.Add(ctx, task); err != nil {
+			return Result{}, fmt.Errorf("failed to schedule follow-up: %w", err)
+		}
+		telemetry.AddTaskScheduledEvent(telemetry.SpansFromContext(ctx), task.ID, task.FireAt)
+		return Result{TaskID: task.ID, FireAt: task.FireAt.UTC().Format(time.RFC3339)}, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating schedule_follow_up tool: %w", err)
+	}
+	return scheduleTool, nil
+}
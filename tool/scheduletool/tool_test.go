@@ -0,0 +1,139 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduletool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/clock"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/scheduler"
+	"google.golang.org/adk/tool"
+)
+
+func TestScheduleFollowUp(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(prev)
+
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name      string
+		args      map[string]any
+		wantTasks []scheduler.Task
+		wantErr   bool
+	}{
+		{
+			name: "scheduled",
+			args: map[string]any{"delay_seconds": 90, "message": "remind the user to stretch"},
+			wantTasks: []scheduler.Task{{
+				AppName:   "test_app",
+				UserID:    "test_user",
+				SessionID: "s1",
+				FireAt:    now.Add(90 * time.Second),
+				Message:   "remind the user to stretch",
+			}},
+		},
+		{
+			name:    "delay too long",
+			args:    map[string]any{"delay_seconds": 3600 * 24 * 365, "message": "happy new year"},
+			wantErr: true,
+		},
+		{
+			name:    "delay overflowing a duration",
+			args:    map[string]any{"delay_seconds": 18446744074, "message": "soon"},
+			wantErr: true,
+		},
+		{
+			name:    "no message",
+			args:    map[string]any{"delay_seconds": 90},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store := scheduler.InMemoryStore()
+			scheduleTool, err := newTool(Config{Store: store}, clock.NewFake(now))
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := llmagent.New(llmagent.Config{
+				Name: "test_agent",
+				Model: &testutil.MockModel{Responses: []*genai.Content{
+					genai.NewContentFromFunctionCall("schedule_follow_up", tc.args, genai.RoleModel),
+					genai.NewContentFromText("done", genai.RoleModel),
+				}},
+				Tools: []tool.Tool{scheduleTool},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parts, err := testutil.CollectParts(testutil.NewTestAgentRunner(t, a).Run(t, "s1", "remind me"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var response map[string]any
+			for _, p := range parts {
+				if p.FunctionResponse != nil {
+					response = p.FunctionResponse.Response
+				}
+			}
+			if _, gotErr := response["error"]; gotErr != tc.wantErr {
+				t.Errorf("function response = %v, wantErr %v", response, tc.wantErr)
+			}
+
+			tasks, err := store.TakeDue(context.Background(), "test_app", now.Add(DefaultMaxDelay))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.wantTasks, tasks, cmpopts.IgnoreFields(scheduler.Task{}, "ID")); diff != "" {
+				t.Errorf("scheduled tasks mismatch (-want +got):\n%s", diff)
+			}
+			if len(tasks) == 1 && response["task_id"] != tasks[0].ID {
+				t.Errorf("task_id = %v, want %q", response["task_id"], tasks[0].ID)
+			}
+		})
+	}
+
+	scheduled := 0
+	for _, span := range recorder.Ended() {
+		for _, ev := range span.Events() {
+			if ev.Name == "task_scheduled" {
+				scheduled++
+			}
+		}
+	}
+	if scheduled != 1 {
+		t.Errorf("got %d task_scheduled span events, want 1", scheduled)
+	}
+}
+
+func TestNewNegativeMaxDelay(t *testing.T) {
+	if _, err := New(Config{Store: scheduler.InMemoryStore(), MaxDelay: -time.Hour}); err == nil {
+		t.Error("New() with a negative MaxDelay succeeded")
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sessionhistorytool provides a tool that summarizes the events of
+// another session, e.g. for a supervisor agent to inspect what a worker agent
+// did in a prior session.
+//
+// The summary only lists the authors of the events, the tools called and the
+// final answer, not the full transcript. By default only sessions of the
+// app and user of the calling agent can be read.
+package sessionhistorytool
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// DefaultMaxAnswerChars is the final answer length limit used if
+// Config.MaxAnswerChars is zero.
+const DefaultMaxAnswerChars = 2000
+
+// ErrNotAuthorized is returned for sessions the calling agent may not read.
+var ErrNotAuthorized = errors.New("not authorized to read the session")
+
+// Config is the configuration of the get_session_history tool.
+type Config struct {
+	// SessionService is the service the sessions are read from. Required.
+	SessionService session.Service
+	// Authorize reports whether the calling agent may read the session. It
+	// replaces the default check, which only allows sessions of the app and
+	// user of the calling agent.
+	Authorize func(ctx tool.Context, appName, userID, sessionID string) bool
+	// MaxAnswerChars truncates the final answer of the summary to this many
+	// characters. Defaults to DefaultMaxAnswerChars.
+	MaxAnswerChars int
+}
+
+// Args are the arguments of the get_session_history tool.
+type Args struct {
+	// AppName is the app of the session. Defaults to the current app.
+	AppName string `json:"app_name,omitempty"`
+	// UserID is the user of the session. Defaults to the current user.
+	UserID string `json:"user_id,omitempty"`
+	// SessionID is the ID of the session to summarize.
+	SessionID string `json:"session_id"`
+}
+
+// Result is the response of the get_session_history tool.
+type Result struct {
+	AppName   string `json:"app_name"`
+	UserID    string `json:"user_id"`
+	SessionID string `json:"session_id"`
+	// Events is the number of events of the session.
+	Events int `json:"events"`
+	// Authors are the authors of the events, in order of first appearance.
+	Authors []string `json:"authors"`
+	// ToolCalls are the tools called in the session, in order of first call.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// FinalAnswer is the text of the last final response of an agent.
+	FinalAnswer string `json:"final_answer,omitempty"`
+	// Truncated reports whether FinalAnswer was truncated.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// ToolCall counts the calls of a tool by an agent.
+type ToolCall struct {
+	Author string `json:"author"`
+	Name   string `json:"name"`
+	Count  int    `json:"count"`
+}
+
+// New creates the get_session_history tool.
+func New(cfg Config) (tool.Tool, error) {
+	if cfg.SessionService == nil {
+		return nil, errors.New("SessionService is required")
+	}
+	if cfg.MaxAnswerChars == 0 {
+		cfg.MaxAnswerChars = DefaultMaxAnswerChars
+	}
+	if cfg.MaxAnswerChars < 0 {
+		return nil, fmt.Errorf("MaxAnswerChars must not be negative, got %d", cfg.MaxAnswerChars)
+	}
+	h := &historyTool{cfg: cfg}
+	summaryTool, err := functiontool.New(functiontool.Config{
+		Name:        "get_session_history",
+		Description: "Returns a summary of another session: the authors of its events, the tools they called and the final answer. Use it to check what an agent did in a prior session.",
+	}, h.summarize)
+	if err != nil {
+		return nil, fmt.Errorf("error creating get_session_history tool: %w", err)
+	}
+	return summaryTool, nil
+}
+
+type historyTool struct {
+	cfg Config
+}
+
+func (h *historyTool) summarize(ctx tool.Context, args Args) (Result, error) {
+	if args.SessionID == "" {
+		return Result{}, tool.NewError(tool.ErrorCodeInvalidArgument, errors.New("session_id is required"))
+	}
+	if args.AppName == "" {
+		args.AppName = ctx.AppName()
+	}
+	if args.UserID == "" {
+		args.UserID = ctx.UserID()
+	}
+	if !h.authorized(ctx, args) {
+		return Result{}, tool.NewError(tool.ErrorCodePermissionDenied, fmt.Errorf("session %q of app %q and user %q: %w", args.SessionID, args.AppName, args.UserID, ErrNotAuthorized))
+	}
+	resp, err := h.cfg.SessionService.Get(ctx, &session.GetRequest{
+		AppName:   args.AppName,
+		UserID:    args.UserID,
+		SessionID: args.SessionID,
+	})
+	if err != nil {
+		return Result{}, tool.NewError(tool.ErrorCodeNotFound, fmt.Errorf("failed to get session: %w", err))
+	}
+	result := Result{AppName: args.AppName, UserID: args.UserID, SessionID: args.SessionID, Authors: []string{}}
+	for ev := range resp.Session.Events().All() {
+		result.Events++
+		if ev.Author != "" && !slices.Contains(result.Authors, ev.Author) {
+			result.Authors = append(result.Authors, ev.Author)
+		}
+		if ev.Content == nil {
+			continue
+		}
+		for _, p := range ev.Content.Parts {
+			if p.FunctionCall != nil {
+				result.addToolCall(ev.Author, p.FunctionCall.Name)
+			}
+		}
+		if ev.Author != "user" && ev.IsFinalResponse() {
+			if text := eventText(ev); text != "" {
+				result.FinalAnswer = text
+			}
+		}
+	}
+	if runes := []rune(result.FinalAnswer); len(runes) > h.cfg.MaxAnswerChars {
+		result.FinalAnswer = string(runes[:h.cfg.MaxAnswerChars])
+		result.Truncated = true
+	}
+	return result, nil
+}
+
+func (h *historyTool) authorized(ctx tool.Context, args Args) bool {
+	if h.cfg.Authorize != nil {
+		return h.cfg.Authorize(ctx, args.AppName, args.UserID, args.SessionID)
+	}
+	return args.AppName == ctx.AppName() && args.UserID == ctx.UserID()
+}
+
+func (r *Result) addToolCall(author, name string) {
+	for i := range r.ToolCalls {
+		if r.ToolCalls[i].Author == author && r.ToolCalls[i].Name == name {
+			r.ToolCalls[i].Count++
+			return
+		}
+	}
+	r.ToolCalls = append(r.ToolCalls, ToolCall{Author: author, Name: name, Count: 1})
+}
+
+// eventText returns the text of the event, without thoughts.
+func eventText(ev *session.Event) string {
+	var b strings.Builder
+	for _, p := range ev.Content.Parts {
+		if p.Text != "" && !p.Thought {
+			b.WriteString(p.Text)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,176 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessionhistorytool_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/sessionhistorytool"
+)
+
+func TestSummarize(t *testing.T) {
+	sessions := session.InMemoryService()
+	for _, userID := range []string{"test_user", "other_user"} {
+		resp, err := sessions.Create(t.Context(), &session.CreateRequest{AppName: "test_app", UserID: userID, SessionID: "worker_session"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ev := range []struct {
+			author  string
+			content *genai.Content
+		}{
+			{"user", genai.NewContentFromText("find the weather", genai.RoleUser)},
+			{"worker", genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "Paris"}, genai.RoleModel)},
+			{"worker", genai.NewContentFromFunctionResponse("get_weather", map[string]any{"temp": "21C"}, genai.RoleUser)},
+			{"worker", genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "Rome"}, genai.RoleModel)},
+			{"worker", genai.NewContentFromFunctionResponse("get_weather", map[string]any{"temp": "30C"}, genai.RoleUser)},
+			{"worker", genai.NewContentFromText("It is 21C in Paris and 30C in Rome.", genai.RoleModel)},
+		} {
+			event := session.NewEvent("inv1")
+			event.Author = ev.author
+			event.LLMResponse = model.LLMResponse{Content: ev.content}
+			if err := sessions.AppendEvent(t.Context(), resp.Session, event); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name string
+		cfg  sessionhistorytool.Config
+		args map[string]any
+		want map[string]any
+	}{
+		{
+			name: "session of the current user",
+			args: map[string]any{"session_id": "worker_session"},
+			want: map[string]any{
+				"app_name":   "test_app",
+				"user_id":    "test_user",
+				"session_id": "worker_session",
+				"events":     6.0,
+				"authors":    []any{"user", "worker"},
+				"tool_calls": []any{
+					map[string]any{"author": "worker", "name": "get_weather", "count": 2.0},
+				},
+				"final_answer": "It is 21C in Paris and 30C in Rome.",
+			},
+		},
+		{
+			name: "truncated final answer",
+			cfg:  sessionhistorytool.Config{MaxAnswerChars: 5},
+			args: map[string]any{"session_id": "worker_session"},
+			want: map[string]any{
+				"app_name":   "test_app",
+				"user_id":    "test_user",
+				"session_id": "worker_session",
+				"events":     6.0,
+				"authors":    []any{"user", "worker"},
+				"tool_calls": []any{
+					map[string]any{"author": "worker", "name": "get_weather", "count": 2.0},
+				},
+				"final_answer": "It is",
+				"truncated":    true,
+			},
+		},
+		{
+			name: "session of another user",
+			args: map[string]any{"user_id": "other_user", "session_id": "worker_session"},
+			want: map[string]any{"error": `session "worker_session" of app "test_app" and user "other_user": not authorized to read the session`, "error_code": "PERMISSION_DENIED", "status": "error"},
+		},
+		{
+			name: "session of another app",
+			args: map[string]any{"app_name": "other_app", "session_id": "worker_session"},
+			want: map[string]any{"error": `session "worker_session" of app "other_app" and user "test_user": not authorized to read the session`, "error_code": "PERMISSION_DENIED", "status": "error"},
+		},
+		{
+			name: "custom authorization",
+			cfg: sessionhistorytool.Config{Authorize: func(ctx tool.Context, appName, userID, sessionID string) bool {
+				return appName == ctx.AppName()
+			}},
+			args: map[string]any{"user_id": "other_user", "session_id": "worker_session"},
+			want: map[string]any{
+				"app_name":   "test_app",
+				"user_id":    "other_user",
+				"session_id": "worker_session",
+				"events":     6.0,
+				"authors":    []any{"user", "worker"},
+				"tool_calls": []any{
+					map[string]any{"author": "worker", "name": "get_weather", "count": 2.0},
+				},
+				"final_answer": "It is 21C in Paris and 30C in Rome.",
+			},
+		},
+		{
+			name: "unknown session",
+			args: map[string]any{"session_id": "missing"},
+			want: nil,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := tc.cfg
+			cfg.SessionService = sessions
+			historyTool, err := sessionhistorytool.New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := llmagent.New(llmagent.Config{
+				Name: "supervisor",
+				Model: &testutil.MockModel{Responses: []*genai.Content{
+					genai.NewContentFromFunctionCall("get_session_history", tc.args, genai.RoleModel),
+					genai.NewContentFromText("done", genai.RoleModel),
+				}},
+				Tools: []tool.Tool{historyTool},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			parts, err := testutil.CollectParts(testutil.NewTestAgentRunner(t, a).Run(t, "supervisor_session", "what did the worker do?"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]any
+			for _, p := range parts {
+				if p.FunctionResponse != nil {
+					got = p.FunctionResponse.Response
+				}
+			}
+			if tc.want == nil {
+				if got["error_code"] != tool.ErrorCodeNotFound {
+					t.Errorf("get_session_history response = %v, want a %s error", got, tool.ErrorCodeNotFound)
+				}
+				return
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("get_session_history response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	if _, err := sessionhistorytool.New(sessionhistorytool.Config{}); err == nil {
+		t.Error("New() without a session service succeeded, want error")
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqltool provides a tool answering questions with read-only SQL
+// queries.
+//
+// The model sends a single SELECT statement with placeholders and the values
+// of its parameters. Before running it, the statement is checked: comments,
+// several statements, statements not starting with SELECT or WITH and
+// statements containing a keyword that modifies data or schema (INSERT,
+// UPDATE, DELETE, CREATE, DROP, INTO, ...) are rejected. Accepted statements
+// run in a read-only transaction that is always rolled back, with a time
+// limit, and at most a configured number of rows is returned.
+//
+// The check is a guardrail, not a sandbox: it does not detect functions with
+// side effects. The database must be opened with a user that is only granted
+// read access, preferably on a read replica.
+package sqltool
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// Defaults used for unset Config fields.
+const (
+	DefaultMaxRows = 100
+	DefaultTimeout = 10 * time.Second
+)
+
+// ErrRejectedQuery is returned for queries that are not a single read
+// statement.
+var ErrRejectedQuery = errors.New("query rejected")
+
+// Config is the configuration of a SQL query tool.
+type Config struct {
+	// Name is the name of the tool.
+	Name string
+	// Description tells the model which data the database holds, e.g. its
+	// tables and columns, and the placeholder syntax of the driver.
+	Description string
+	// DB is the database queried. It must be connected with a read-only
+	// user.
+	DB *sql.DB
+	// MaxRows limits the number of rows returned. Defaults to
+	// DefaultMaxRows.
+	MaxRows int
+	// Timeout limits the run time of a query. Defaults to DefaultTimeout.
+	Timeout time.Duration
+}
+
+// Args are the arguments of a call of a SQL query tool.
+type Args struct {
+	// Query is a single SELECT statement.
+	Query string `json:"query" jsonschema:"a single SELECT statement, with placeholders for the values"`
+	// Params are the values of the placeholders of the query, in order.
+	Params []any `json:"params,omitempty" jsonschema:"the values of the placeholders of the query, in order"`
+}
+
+// Result is the response of a SQL query tool.
+type Result struct {
+	// Columns are the names of the columns of the result set.
+	Columns []string `json:"columns"`
+	// Rows are the rows of the result set, keyed by column name.
+	Rows []map[string]any `json:"rows"`
+	// Truncated reports whether rows were left out because of the row limit.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// New creates a tool running read-only SQL queries on the configured
+// database.
+func New(cfg Config) (tool.Tool, error) {
+	q, err := newQuerier(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sqlTool, err := functiontool.New(functiontool.Config{
+		Name:        cfg.Name,
+		Description: cfg.Description,
+	}, func(ctx tool.Context, args Args) (Result, error) {
+		telemetry.SetSQLQuery(telemetry.SpansFromContext(ctx), args.Query)
+		return q.query(ctx, args)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s tool: %w", cfg.Name, err)
+	}
+	return sqlTool, nil
+}
+
+type querier struct {
+	cfg Config
+}
+
+func newQuerier(cfg Config) (*querier, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("Name is required")
+	}
+	if cfg.DB == nil {
+		return nil, errors.New("DB is required")
+	}
+	if cfg.MaxRows == 0 {
+		cfg.MaxRows = DefaultMaxRows
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.MaxRows < 0 || cfg.Timeout < 0 {
+		return nil, errors.New("MaxRows and Timeout must not be negative")
+	}
+	return &querier{cfg: cfg}, nil
+}
+
+func (q *querier) query(ctx context.Context, args Args) (Result, error) {
+	if err := CheckQuery(args.Query); err != nil {
+		return Result{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, q.cfg.Timeout)
+	defer cancel()
+	tx, err := q.cfg.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to begin read-only transaction: %w", err)
+	}
+	// Nothing is ever committed.
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, args.Query, args.Params...)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to run query: %w", err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	result := Result{Columns: columns, Rows: []map[string]any{}}
+	for rows.Next() {
+		if len(result.Rows) == q.cfg.MaxRows {
+			result.Truncated = true
+			break
+		}
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return Result{}, fmt.Errorf("failed to read row: %w", err)
+		}
+		row := make(map[string]any, len(columns))
+		for i, col := range columns {
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			row[col] = values[i]
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return Result{}, fmt.Errorf("failed to read rows: %w", err)
+	}
+	return result, nil
+}
+
+// deniedKeywords modify data, schema, permissions or the session, or run
+// other code. They are rejected anywhere outside of literals and quoted
+// identifiers.
+var deniedKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "UPSERT": true,
+	"CREATE": true, "DROP": true, "ALTER": true, "TRUNCATE": true, "RENAME": true,
+	"GRANT": true, "REVOKE": true, "ATTACH": true, "DETACH": true, "PRAGMA": true,
+	"VACUUM": true, "CALL": true, "EXEC": true, "EXECUTE": true, "COPY": true,
+	"LOCK": true, "INTO": true, "LOAD": true,
+}
+
+// CheckQuery returns an error wrapping ErrRejectedQuery unless query is a
+// single statement starting with SELECT or WITH and containing no denied
+// keyword.
+//
+// Comments, backslashes in literals and quoted identifiers, and
+// dollar-quoted strings are rejected, since databases disagree on how to
+// parse them.
+func CheckQuery(query string) error {
+	reject := func(reason string) error {
+		return fmt.Errorf("%w: %s", ErrRejectedQuery, reason)
+	}
+	var words []string
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				return reject("unterminated literal or quoted identifier")
+			}
+			if strings.ContainsRune(query[i+1:i+1+end], '\\') {
+				return reject("backslashes are not allowed in literals and quoted identifiers")
+			}
+			// A doubled quote is an escaped quote and is skipped as two
+			// adjacent literals.
+			i += end + 2
+		case strings.HasPrefix(query[i:], "--") || strings.HasPrefix(query[i:], "/*") || c == '#':
+			return reject("comments are not allowed")
+		case c == '$' && (i+1 == len(query) || !isDigit(query[i+1])):
+			return reject("dollar-quoted strings are not allowed")
+		case c == ';':
+			if strings.TrimSpace(query[i+1:]) != "" {
+				return reject("only a single statement is allowed")
+			}
+			i++
+		case isWordByte(c):
+			j := i
+			for j < len(query) && isWordByte(query[j]) {
+				j++
+			}
+			words = append(words, strings.ToUpper(query[i:j]))
+			i = j
+		default:
+			i++
+		}
+	}
+	if len(words) == 0 || (words[0] != "SELECT" && words[0] != "WITH") {
+		return reject("only SELECT statements are allowed")
+	}
+	for _, w := range words {
+		if deniedKeywords[w] {
+			return reject(w + " is not allowed")
+		}
+	}
+	return nil
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 0x80 || unicode.IsLetter(rune(c)) || isDigit(c)
+}
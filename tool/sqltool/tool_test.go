@@ -0,0 +1,153 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqltool
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "github.com/glebarez/go-sqlite"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheckQuery(t *testing.T) {
+	for _, tc := range []struct {
+		query   string
+		wantErr bool
+	}{
+		{query: "SELECT name FROM users WHERE id = ?"},
+		{query: "select count(*) from users;"},
+		{query: "WITH t AS (SELECT 1 AS n) SELECT n FROM t"},
+		{query: `SELECT "delete", 'drop table; x' FROM users`},
+		{query: "SELECT 'it''s' FROM users"},
+		{query: "SELECT $1, $2"},
+		{query: "", wantErr: true},
+		{query: "DELETE FROM users", wantErr: true},
+		{query: "SELECT 1; DROP TABLE users", wantErr: true},
+		{query: "SELECT 1;;", wantErr: true},
+		{query: "SELECT * INTO copy FROM users", wantErr: true},
+		{query: "WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d", wantErr: true},
+		{query: "SELECT * FROM users FOR UPDATE", wantErr: true},
+		{query: "SELECT 1 -- comment", wantErr: true},
+		{query: "SELECT /* x */ 1", wantErr: true},
+		{query: "SELECT 1 # comment", wantErr: true},
+		{query: `SELECT E'\''; DROP TABLE users; SELECT ''`, wantErr: true},
+		{query: `SELECT "\""; DROP TABLE users; SELECT ""`, wantErr: true},
+		{query: "SELECT $$; DROP TABLE users; $$", wantErr: true},
+		{query: "SELECT 'unterminated", wantErr: true},
+		{query: "PRAGMA writable_schema = 1", wantErr: true},
+		{query: "EXPLAIN ANALYZE DELETE FROM users", wantErr: true},
+	} {
+		err := CheckQuery(tc.query)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("CheckQuery(%q) error = %v, wantErr %v", tc.query, err, tc.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrRejectedQuery) {
+			t.Errorf("CheckQuery(%q) error = %v, does not wrap ErrRejectedQuery", tc.query, err)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// Each connection has its own in-memory database.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER, name TEXT);
+		INSERT INTO users VALUES (1, 'ada'), (2, 'bob'), (3, 'cy')`); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		cfg     Config
+		args    Args
+		want    Result
+		wantErr bool
+	}{
+		{
+			name: "parameterized",
+			args: Args{Query: "SELECT id, name FROM users WHERE id > ? ORDER BY id", Params: []any{1.0}},
+			want: Result{Columns: []string{"id", "name"}, Rows: []map[string]any{
+				{"id": int64(2), "name": "bob"},
+				{"id": int64(3), "name": "cy"},
+			}},
+		},
+		{
+			name: "row limit",
+			cfg:  Config{MaxRows: 1},
+			args: Args{Query: "SELECT name FROM users ORDER BY id"},
+			want: Result{Columns: []string{"name"}, Rows: []map[string]any{{"name": "ada"}}, Truncated: true},
+		},
+		{
+			name: "no rows",
+			args: Args{Query: "SELECT name FROM users WHERE id = ?", Params: []any{42}},
+			want: Result{Columns: []string{"name"}, Rows: []map[string]any{}},
+		},
+		{
+			name:    "rejected",
+			args:    Args{Query: "DELETE FROM users"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid",
+			args:    Args{Query: "SELECT missing FROM users"},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := tc.cfg
+			cfg.Name = "query"
+			cfg.DB = db
+			q, err := newQuerier(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := q.query(t.Context(), tc.args)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("query() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("query() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	var n int
+	if err := db.QueryRow("SELECT count(*) FROM users").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("users count = %d after the queries, want 3", n)
+	}
+}
+
+func TestNew(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := New(Config{Name: "query", Description: "queries the users table", DB: db}); err != nil {
+		t.Errorf("New() error = %v", err)
+	}
+	if _, err := New(Config{Name: "query"}); err == nil {
+		t.Errorf("New() without DB succeeded, want error")
+	}
+}
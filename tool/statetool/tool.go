@@ -0,0 +1,176 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package statetool provides tools that let the model read and write
+// namespaced session state.
+package statetool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+const (
+	// DefaultNamespace is the namespace used if Config.Namespace is empty.
+	DefaultNamespace = "tool_state"
+	// DefaultMaxValueBytes is the value size limit used if
+	// Config.MaxValueBytes is zero.
+	DefaultMaxValueBytes = 4096
+)
+
+// Scopes accepted in the "scope" argument.
+const (
+	ScopeSession = "session"
+	ScopeUser    = "user"
+)
+
+// Config is the configuration for the state tools.
+type Config struct {
+	// Namespace is prepended to every key so that the model can only touch
+	// state owned by these tools. Defaults to DefaultNamespace.
+	Namespace string
+	// AllowUserScope lets the model read and write keys shared by all
+	// sessions of the user. By default only the current session is visible.
+	AllowUserScope bool
+	// MaxValueBytes limits the JSON encoded size of a stored value.
+	// Defaults to DefaultMaxValueBytes.
+	MaxValueBytes int
+}
+
+// GetArgs are the arguments of the get_state tool.
+type GetArgs struct {
+	// Key is the state key to read.
+	Key string `json:"key"`
+	// Scope is either "session" (default) or "user".
+	Scope string `json:"scope,omitempty"`
+}
+
+// SetArgs are the arguments of the set_state tool.
+type SetArgs struct {
+	// Key is the state key to write.
+	Key string `json:"key"`
+	// Value is the value to store.
+	Value any `json:"value"`
+	// Scope is either "session" (default) or "user".
+	Scope string `json:"scope,omitempty"`
+}
+
+// Result is returned by both tools.
+type Result struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+	// Found reports whether the key was present. Only set by get_state.
+	Found bool `json:"found"`
+}
+
+var keyRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.-]{0,127}$`)
+
+// New creates the get_state and set_state tools.
+func New(cfg Config) ([]tool.Tool, error) {
+	if cfg.Namespace == "" {
+		cfg.Namespace = DefaultNamespace
+	}
+	if !keyRegexp.MatchString(cfg.Namespace) {
+		return nil, fmt.Errorf("invalid namespace %q", cfg.Namespace)
+	}
+	if cfg.MaxValueBytes == 0 {
+		cfg.MaxValueBytes = DefaultMaxValueBytes
+	}
+	if cfg.MaxValueBytes < 0 {
+		return nil, fmt.Errorf("MaxValueBytes must not be negative, got %d", cfg.MaxValueBytes)
+	}
+	s := &stateTools{cfg: cfg}
+
+	scopeDoc := ""
+	if cfg.AllowUserScope {
+		scopeDoc = " Set scope to \"user\" to access a value shared by all sessions of the user."
+	}
+	getTool, err := functiontool.New(functiontool.Config{
+		Name:        "get_state",
+		Description: "Reads a value previously stored with set_state." + scopeDoc,
+	}, s.get)
+	if err != nil {
+		return nil, fmt.Errorf("error creating get_state tool: %w", err)
+	}
+	setTool, err := functiontool.New(functiontool.Config{
+		Name:        "set_state",
+		Description: "Stores a value under the given key so it can be read in later turns." + scopeDoc,
+	}, s.set)
+	if err != nil {
+		return nil, fmt.Errorf("error creating set_state tool: %w", err)
+	}
+	return []tool.Tool{getTool, setTool}, nil
+}
+
+type stateTools struct {
+	cfg Config
+}
+
+func (s *stateTools) get(ctx tool.Context, args GetArgs) (Result, error) {
+	key, err := s.stateKey(args.Key, args.Scope)
+	if err != nil {
+		return Result{}, err
+	}
+	value, err := ctx.State().Get(key)
+	if errors.Is(err, session.ErrStateKeyNotExist) {
+		return Result{Key: args.Key}, nil
+	}
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to get state: %w", err)
+	}
+	return Result{Key: args.Key, Value: value, Found: true}, nil
+}
+
+func (s *stateTools) set(ctx tool.Context, args SetArgs) (Result, error) {
+	key, err := s.stateKey(args.Key, args.Scope)
+	if err != nil {
+		return Result{}, err
+	}
+	encoded, err := json.Marshal(args.Value)
+	if err != nil {
+		return Result{}, fmt.Errorf("value is not JSON serializable: %w", err)
+	}
+	if len(encoded) > s.cfg.MaxValueBytes {
+		return Result{}, fmt.Errorf("value is %d bytes, exceeds the limit of %d bytes", len(encoded), s.cfg.MaxValueBytes)
+	}
+	if err := ctx.State().Set(key, args.Value); err != nil {
+		return Result{}, fmt.Errorf("failed to set state: %w", err)
+	}
+	return Result{Key: args.Key, Value: args.Value}, nil
+}
+
+// stateKey validates the key and scope and returns the key in session state.
+func (s *stateTools) stateKey(key, scope string) (string, error) {
+	if !keyRegexp.MatchString(key) {
+		return "", fmt.Errorf("invalid key %q: must match %s", key, keyRegexp)
+	}
+	full := s.cfg.Namespace + "." + key
+	switch scope {
+	case "", ScopeSession:
+		return full, nil
+	case ScopeUser:
+		if !s.cfg.AllowUserScope {
+			return "", fmt.Errorf("user scope is not enabled")
+		}
+		return session.KeyPrefixUser + full, nil
+	default:
+		return "", fmt.Errorf("unknown scope %q, want %q or %q", scope, ScopeSession, ScopeUser)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statetool_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/tool/statetool"
+)
+
+func TestReadAfterWrite(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   statetool.Config
+		scope string
+	}{
+		{
+			name: "session scope",
+		},
+		{
+			name:  "user scope",
+			cfg:   statetool.Config{AllowUserScope: true},
+			scope: statetool.ScopeUser,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tools, err := statetool.New(tc.cfg)
+			if err != nil {
+				t.Fatalf("statetool.New() error = %v", err)
+			}
+			setArgs := map[string]any{"key": "choice", "value": "option_b"}
+			getArgs := map[string]any{"key": "choice"}
+			if tc.scope != "" {
+				setArgs["scope"] = tc.scope
+				getArgs["scope"] = tc.scope
+			}
+			mockModel := &testutil.MockModel{Responses: []*genai.Content{
+				genai.NewContentFromFunctionCall("set_state", setArgs, genai.RoleModel),
+				genai.NewContentFromText("saved", genai.RoleModel),
+				genai.NewContentFromFunctionCall("get_state", getArgs, genai.RoleModel),
+				genai.NewContentFromText("done", genai.RoleModel),
+			}}
+			a, err := llmagent.New(llmagent.Config{
+				Name:  "state_agent",
+				Model: mockModel,
+				Tools: tools,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			runner := testutil.NewTestAgentRunner(t, a)
+
+			// The value is written in the first turn and read in the second.
+			if _, err := testutil.CollectEvents(runner.Run(t, "session1", "remember option b")); err != nil {
+				t.Fatalf("first turn failed: %v", err)
+			}
+			events, err := testutil.CollectEvents(runner.Run(t, "session1", "what did I choose?"))
+			if err != nil {
+				t.Fatalf("second turn failed: %v", err)
+			}
+
+			var got map[string]any
+			for _, ev := range events {
+				if ev.Content == nil {
+					continue
+				}
+				for _, p := range ev.Content.Parts {
+					if p.FunctionResponse != nil && p.FunctionResponse.Name == "get_state" {
+						got = p.FunctionResponse.Response
+					}
+				}
+			}
+			want := map[string]any{"key": "choice", "value": "option_b", "found": true}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("get_state response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     statetool.Config
+		call    *genai.Content
+		wantErr string
+	}{
+		{
+			name:    "invalid key",
+			call:    genai.NewContentFromFunctionCall("set_state", map[string]any{"key": "bad key!", "value": 1}, genai.RoleModel),
+			wantErr: "invalid key",
+		},
+		{
+			name:    "value too large",
+			cfg:     statetool.Config{MaxValueBytes: 8},
+			call:    genai.NewContentFromFunctionCall("set_state", map[string]any{"key": "k", "value": "0123456789"}, genai.RoleModel),
+			wantErr: "exceeds the limit",
+		},
+		{
+			name:    "user scope not enabled",
+			call:    genai.NewContentFromFunctionCall("get_state", map[string]any{"key": "k", "scope": "user"}, genai.RoleModel),
+			wantErr: "user scope is not enabled",
+		},
+		{
+			name:    "unknown scope",
+			call:    genai.NewContentFromFunctionCall("get_state", map[string]any{"key": "k", "scope": "app"}, genai.RoleModel),
+			wantErr: "unknown scope",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tools, err := statetool.New(tc.cfg)
+			if err != nil {
+				t.Fatalf("statetool.New() error = %v", err)
+			}
+			mockModel := &testutil.MockModel{Responses: []*genai.Content{
+				tc.call,
+				genai.NewContentFromText("done", genai.RoleModel),
+			}}
+			a, err := llmagent.New(llmagent.Config{
+				Name:  "state_agent",
+				Model: mockModel,
+				Tools: tools,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			parts, err := testutil.CollectParts(testutil.NewTestAgentRunner(t, a).Run(t, "session1", "hi"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotErr string
+			for _, p := range parts {
+				if p.FunctionResponse != nil {
+					gotErr, _ = p.FunctionResponse.Response["error"].(string)
+				}
+			}
+			if !strings.Contains(gotErr, tc.wantErr) {
+				t.Errorf("tool error = %q, want it to contain %q", gotErr, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	if _, err := statetool.New(statetool.Config{Namespace: "bad namespace"}); err == nil {
+		t.Error("statetool.New() with invalid namespace succeeded, want error")
+	}
+	if _, err := statetool.New(statetool.Config{MaxValueBytes: -1}); err == nil {
+		t.Error("statetool.New() with negative MaxValueBytes succeeded, want error")
+	}
+}
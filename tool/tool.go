@@ -20,6 +20,9 @@ package tool
 import (
 	"context"
 
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/genai"
+
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/memory"
 	"google.golang.org/adk/session"
@@ -36,6 +39,15 @@ type Tool interface {
 	IsLongRunning() bool
 }
 
+// SpanAttributer is an optional interface of a Tool adding custom attributes,
+// e.g. the external endpoint it calls, to the execute_tool spans of its
+// calls. The attributes of the tool follow those of the agent (see
+// agent.Config.SpanAttributes), and attributes beyond the first 32 custom
+// attributes of a span are dropped.
+type SpanAttributer interface {
+	SpanAttributes() []attribute.KeyValue
+}
+
 // Context defines the interface for the context passed to a tool when it's
 // called. It provides access to invocation-specific information and allows
 // the tool to interact with the agent's state and memory.
@@ -53,6 +65,24 @@ type Context interface {
 	SearchMemory(context.Context, string) (*memory.SearchResponse, error)
 }
 
+// ResponsePartsAdder is an optional interface of a Context attaching media,
+// e.g. a generated image, to the function response so that the model can
+// reason over it. Parts are sent in addition to the map returned by the
+// tool. The contexts that ADK agents pass to tools implement it.
+type ResponsePartsAdder interface {
+	AddResponseParts(parts ...*genai.FunctionResponsePart)
+}
+
+// AddResponseParts attaches the parts to the function response of the call
+// of ctx if ctx implements [ResponsePartsAdder], and reports whether it does.
+func AddResponseParts(ctx Context, parts ...*genai.FunctionResponsePart) bool {
+	a, ok := ctx.(ResponsePartsAdder)
+	if ok {
+		a.AddResponseParts(parts...)
+	}
+	return ok
+}
+
 // Toolset is an interface for a collection of tools. It allows grouping
 // related tools together and providing them to an agent.
 type Toolset interface {
@@ -103,3 +103,20 @@ func TestTypes(t *testing.T) {
 		})
 	}
 }
+
+func TestConfirm(t *testing.T) {
+	var got string
+	approve := func(_ tool.Context, action string) (bool, error) {
+		got = action
+		return true, nil
+	}
+	if ok, err := tool.Confirm(nil, approve, "write"); err != nil || !ok {
+		t.Errorf("Confirm() = %v, %v, want true, nil", ok, err)
+	}
+	if got != "write" {
+		t.Errorf("gate got action %q, want %q", got, "write")
+	}
+	if ok, err := tool.Confirm[string](nil, nil, "write"); err != nil || ok {
+		t.Errorf("Confirm() without gate = %v, %v, want false, nil", ok, err)
+	}
+}
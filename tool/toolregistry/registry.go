@@ -0,0 +1,205 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package toolregistry provides a toolset whose tools are constructed
+// lazily, on their first call.
+//
+// Agents declaring many rarely used tools, each of them e.g. opening
+// connections, pay the construction cost only for the tools that are used.
+// The declarations sent to the model are built from metadata, without
+// constructing the tools.
+//
+// Example:
+//
+//	registry, err := toolregistry.New(toolregistry.Config{
+//		Name: "crm",
+//		Entries: []toolregistry.Entry{{
+//			Metadata: toolregistry.Metadata{
+//				Name:        "lookup_customer",
+//				Description: "Looks up a customer by ID.",
+//				InputSchema: inputSchema,
+//			},
+//			Factory: func() (tool.Tool, error) { return newLookupCustomerTool(crmAddr) },
+//		}},
+//	})
+//	...
+//	llmagent.New(llmagent.Config{
+//		...
+//		Toolsets: []tool.Toolset{registry},
+//	})
+package toolregistry
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/internal/toolinternal/toolutils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+)
+
+// Metadata describes a tool to the model without constructing it.
+type Metadata struct {
+	// The name of the tool. It must match the name of the constructed tool.
+	Name string
+	// A human-readable description of the tool.
+	Description string
+	// An optional JSON schema object defining the expected parameters for
+	// the tool. jsonschema.For can infer it from the argument type.
+	InputSchema *jsonschema.Schema
+	// An optional JSON schema object defining the structure of the tool's
+	// output.
+	OutputSchema *jsonschema.Schema
+	// IsLongRunning marks the tool as a long-running operation.
+	IsLongRunning bool
+}
+
+// Factory constructs a tool. The constructed tool must be a function tool,
+// e.g. one created with functiontool.New.
+type Factory func() (tool.Tool, error)
+
+// Entry is a tool of the registry.
+type Entry struct {
+	Metadata Metadata
+	Factory  Factory
+}
+
+// Config is used to create a registry with [New].
+type Config struct {
+	// Name of the toolset.
+	Name string
+	// Entries are the tools of the registry.
+	Entries []Entry
+}
+
+// New returns a toolset that constructs each of its tools on its first call
+// and reuses it for all further calls. A failed construction is retried on
+// the next call.
+func New(cfg Config) (tool.Toolset, error) {
+	s := &set{name: cfg.Name}
+	seen := make(map[string]bool)
+	for i, e := range cfg.Entries {
+		if e.Metadata.Name == "" {
+			return nil, fmt.Errorf("entry %d: tool name is required", i)
+		}
+		if e.Factory == nil {
+			return nil, fmt.Errorf("tool %q: factory is required", e.Metadata.Name)
+		}
+		if seen[e.Metadata.Name] {
+			return nil, fmt.Errorf("duplicate tool: %q", e.Metadata.Name)
+		}
+		seen[e.Metadata.Name] = true
+		s.tools = append(s.tools, &lazyTool{meta: e.Metadata, factory: e.Factory})
+	}
+	return s, nil
+}
+
+type set struct {
+	name  string
+	tools []tool.Tool
+}
+
+// Name implements tool.Toolset.
+func (s *set) Name() string {
+	return s.name
+}
+
+// Tools implements tool.Toolset.
+func (s *set) Tools(agent.ReadonlyContext) ([]tool.Tool, error) {
+	return s.tools, nil
+}
+
+// lazyTool is declared from its metadata and constructed on the first call.
+type lazyTool struct {
+	meta    Metadata
+	factory Factory
+
+	mu   sync.Mutex
+	tool toolinternal.FunctionTool
+}
+
+// Name implements tool.Tool.
+func (t *lazyTool) Name() string {
+	return t.meta.Name
+}
+
+// Description implements tool.Tool.
+func (t *lazyTool) Description() string {
+	return t.meta.Description
+}
+
+// IsLongRunning implements tool.Tool.
+func (t *lazyTool) IsLongRunning() bool {
+	return t.meta.IsLongRunning
+}
+
+// ProcessRequest packs the tool's declaration into the LLM request.
+func (t *lazyTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
+	return toolutils.PackTool(req, t)
+}
+
+// Declaration implements toolinternal.FunctionTool.
+func (t *lazyTool) Declaration() *genai.FunctionDeclaration {
+	decl := &genai.FunctionDeclaration{
+		Name:        t.meta.Name,
+		Description: t.meta.Description,
+	}
+	if t.meta.InputSchema != nil {
+		decl.ParametersJsonSchema = t.meta.InputSchema
+	}
+	if t.meta.OutputSchema != nil {
+		decl.ResponseJsonSchema = t.meta.OutputSchema
+	}
+	return decl
+}
+
+// Run constructs the tool if needed and runs it.
+func (t *lazyTool) Run(ctx tool.Context, args any) (map[string]any, error) {
+	ft, err := t.construct()
+	if err != nil {
+		return nil, err
+	}
+	return ft.Run(ctx, args)
+}
+
+func (t *lazyTool) construct() (toolinternal.FunctionTool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.tool != nil {
+		return t.tool, nil
+	}
+	constructed, err := t.factory()
+	if err != nil {
+		return nil, tool.NewError(tool.ErrorCodeUnavailable, fmt.Errorf("failed to construct tool %q: %w", t.meta.Name, err))
+	}
+	ft, ok := constructed.(toolinternal.FunctionTool)
+	if !ok {
+		return nil, fmt.Errorf("tool %q is not a function tool", t.meta.Name)
+	}
+	if ft.Name() != t.meta.Name {
+		return nil, fmt.Errorf("constructed tool is named %q, want %q", ft.Name(), t.meta.Name)
+	}
+	t.tool = ft
+	return ft, nil
+}
+
+var (
+	_ toolinternal.FunctionTool     = (*lazyTool)(nil)
+	_ toolinternal.RequestProcessor = (*lazyTool)(nil)
+)
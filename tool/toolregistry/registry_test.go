@@ -0,0 +1,144 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolregistry_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/adk/tool/toolregistry"
+)
+
+type Args struct {
+	Q string `json:"q"`
+}
+
+func newTool(name string) (tool.Tool, error) {
+	return functiontool.New(functiontool.Config{Name: name, Description: "test tool"}, func(ctx tool.Context, args Args) (map[string]string, error) {
+		return map[string]string{"tool": name, "q": args.Q}, nil
+	})
+}
+
+func TestLazyConstruction(t *testing.T) {
+	t.Parallel()
+
+	inputSchema, err := jsonschema.For[Args](nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	constructed := make(map[string]int)
+	failures := 1
+	entry := func(name string) toolregistry.Entry {
+		return toolregistry.Entry{
+			Metadata: toolregistry.Metadata{Name: name, Description: "lazy " + name, InputSchema: inputSchema},
+			Factory: func() (tool.Tool, error) {
+				if name == "flaky" && failures > 0 {
+					failures--
+					return nil, fmt.Errorf("connection refused")
+				}
+				constructed[name]++
+				return newTool(name)
+			},
+		}
+	}
+	registry, err := toolregistry.New(toolregistry.Config{
+		Name:    "registry",
+		Entries: []toolregistry.Entry{entry("used"), entry("unused"), entry("flaky")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockModel := &testutil.MockModel{Responses: []*genai.Content{
+		genai.NewContentFromFunctionCall("used", map[string]any{"q": "a"}, genai.RoleModel),
+		genai.NewContentFromFunctionCall("used", map[string]any{"q": "b"}, genai.RoleModel),
+		genai.NewContentFromFunctionCall("flaky", map[string]any{"q": "c"}, genai.RoleModel),
+		genai.NewContentFromFunctionCall("flaky", map[string]any{"q": "d"}, genai.RoleModel),
+		genai.NewContentFromText("done", genai.RoleModel),
+	}}
+	a, err := llmagent.New(llmagent.Config{
+		Name:     "test_agent",
+		Model:    mockModel,
+		Toolsets: []tool.Toolset{registry},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(constructed) != 0 {
+		t.Fatalf("tools constructed when the agent was created: %v", constructed)
+	}
+
+	parts, err := testutil.CollectParts(testutil.NewTestAgentRunner(t, a).Run(t, "session", "hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var responses []map[string]any
+	for _, p := range parts {
+		if p.FunctionResponse != nil {
+			responses = append(responses, p.FunctionResponse.Response)
+		}
+	}
+	wantResponses := []map[string]any{
+		{"tool": "used", "q": "a"},
+		{"tool": "used", "q": "b"},
+		{"status": "error", "error_code": "UNAVAILABLE", "error": `failed to construct tool "flaky": connection refused`},
+		{"tool": "flaky", "q": "d"},
+	}
+	if diff := cmp.Diff(wantResponses, responses); diff != "" {
+		t.Errorf("function responses mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]int{"used": 1, "flaky": 1}, constructed); diff != "" {
+		t.Errorf("constructed tools mismatch (-want +got):\n%s", diff)
+	}
+
+	var decls []string
+	for _, gt := range mockModel.Requests[0].Config.Tools {
+		for _, d := range gt.FunctionDeclarations {
+			decls = append(decls, d.Name+": "+d.Description)
+		}
+	}
+	if diff := cmp.Diff([]string{"used: lazy used", "unused: lazy unused", "flaky: lazy flaky"}, decls); diff != "" {
+		t.Errorf("function declarations mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	factory := func() (tool.Tool, error) { return newTool("a") }
+	for _, tc := range []struct {
+		name    string
+		entries []toolregistry.Entry
+	}{
+		{name: "missing name", entries: []toolregistry.Entry{{Factory: factory}}},
+		{name: "missing factory", entries: []toolregistry.Entry{{Metadata: toolregistry.Metadata{Name: "a"}}}},
+		{name: "duplicate name", entries: []toolregistry.Entry{
+			{Metadata: toolregistry.Metadata{Name: "a"}, Factory: factory},
+			{Metadata: toolregistry.Metadata{Name: "a"}, Factory: factory},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := toolregistry.New(toolregistry.Config{Entries: tc.entries}); err == nil {
+				t.Errorf("New() succeeded, want error")
+			}
+		})
+	}
+}
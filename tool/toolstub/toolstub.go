@@ -0,0 +1,130 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package toolstub answers tool calls with canned responses, for
+// deterministic tests and replays.
+//
+// Stubs are injected into a run through [agent.RunConfig.ToolStub], so the
+// agent definition does not change:
+//
+//	stubs, err := toolstub.New(toolstub.Config{Stubs: []toolstub.Stub{
+//		{Tool: "get_weather", Args: map[string]any{"city": "Paris"}, Response: map[string]any{"temp": 21}},
+//	}})
+//	...
+//	r.Run(ctx, userID, sessionID, msg, agent.RunConfig{ToolStub: stubs.Stub})
+package toolstub
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"google.golang.org/adk/tool"
+)
+
+// Stub is the canned response to calls of a tool with the given arguments.
+type Stub struct {
+	// Tool is the name of the stubbed tool.
+	Tool string
+	// Args are the arguments the call must have to match the stub. A nil
+	// Args matches calls of the tool that no other stub matches.
+	Args map[string]any
+	// Response is returned as the result of the call.
+	Response map[string]any
+}
+
+// Config is used to create [Stubs].
+type Config struct {
+	// Stubs are the canned responses. Stubs with the same tool and arguments
+	// answer the matching calls in order and the last of them answers all
+	// further calls.
+	Stubs []Stub
+	// Strict makes calls without a matching stub fail with an error response
+	// instead of running the real tool.
+	Strict bool
+}
+
+// Stubs intercepts tool calls and answers them with canned responses. It is
+// safe for concurrent use.
+type Stubs struct {
+	strict bool
+
+	mu        sync.Mutex
+	responses map[string][]map[string]any
+}
+
+// New creates [Stubs] from the config.
+func New(cfg Config) (*Stubs, error) {
+	s := &Stubs{
+		strict:    cfg.Strict,
+		responses: make(map[string][]map[string]any),
+	}
+	for i, stub := range cfg.Stubs {
+		if stub.Tool == "" {
+			return nil, fmt.Errorf("stub %d: tool name is required", i)
+		}
+		key, err := callKey(stub.Tool, stub.Args)
+		if err != nil {
+			return nil, fmt.Errorf("stub %d: invalid args: %w", i, err)
+		}
+		s.responses[key] = append(s.responses[key], stub.Response)
+	}
+	return s, nil
+}
+
+// Stub returns the canned response for a call of the named tool with args.
+// It reports false if the call has no stub and the real tool should run.
+// Its signature matches [agent.RunConfig.ToolStub].
+func (s *Stubs) Stub(name string, args map[string]any) (map[string]any, bool) {
+	if resp, ok := s.next(name, args); ok {
+		return resp, true
+	}
+	if resp, ok := s.next(name, nil); ok {
+		return resp, true
+	}
+	if s.strict {
+		return tool.ErrorResponse(tool.NewError(tool.ErrorCodeNotFound, fmt.Errorf("no stub for tool %q with args %v", name, args))), true
+	}
+	return nil, false
+}
+
+func (s *Stubs) next(name string, args map[string]any) (map[string]any, bool) {
+	key, err := callKey(name, args)
+	if err != nil {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queue := s.responses[key]
+	if len(queue) == 0 {
+		return nil, false
+	}
+	if len(queue) > 1 {
+		s.responses[key] = queue[1:]
+	}
+	return queue[0], true
+}
+
+// callKey identifies a call by the tool name and its arguments.
+// encoding/json sorts map keys, so equal arguments give equal keys.
+func callKey(name string, args map[string]any) (string, error) {
+	if args == nil {
+		return name, nil
+	}
+	b, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	return name + ":" + string(b), nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolstub_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/adk/tool/toolstub"
+)
+
+func TestStubbedMultiToolRun(t *testing.T) {
+	t.Parallel()
+
+	type WeatherArgs struct {
+		City string `json:"city"`
+	}
+	type TimeArgs struct {
+		Zone string `json:"zone"`
+	}
+
+	for _, tc := range []struct {
+		name      string
+		cfg       toolstub.Config
+		want      map[string]map[string]any
+		wantCalls []string
+	}{
+		{
+			name: "miss falls back to the real tool",
+			cfg: toolstub.Config{Stubs: []toolstub.Stub{
+				{Tool: "get_weather", Args: map[string]any{"city": "Paris"}, Response: map[string]any{"temp": "21C"}},
+			}},
+			want: map[string]map[string]any{
+				"get_weather": {"temp": "21C"},
+				"get_time":    {"time": "real UTC"},
+			},
+			wantCalls: []string{"get_time"},
+		},
+		{
+			name: "strict miss returns an error",
+			cfg: toolstub.Config{Strict: true, Stubs: []toolstub.Stub{
+				{Tool: "get_weather", Args: map[string]any{"city": "Paris"}, Response: map[string]any{"temp": "21C"}},
+			}},
+			want: map[string]map[string]any{
+				"get_weather": {"temp": "21C"},
+				"get_time":    {"status": "error", "error_code": "NOT_FOUND", "error": `no stub for tool "get_time" with args map[zone:UTC]`},
+			},
+		},
+		{
+			name: "stub without args matches any call",
+			cfg: toolstub.Config{Stubs: []toolstub.Stub{
+				{Tool: "get_weather", Args: map[string]any{"city": "Rome"}, Response: map[string]any{"temp": "30C"}},
+				{Tool: "get_weather", Response: map[string]any{"temp": "unknown"}},
+				{Tool: "get_time", Response: map[string]any{"time": "noon"}},
+			}},
+			want: map[string]map[string]any{
+				"get_weather": {"temp": "unknown"},
+				"get_time":    {"time": "noon"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			weather, err := functiontool.New(functiontool.Config{
+				Name:        "get_weather",
+				Description: "returns the weather",
+			}, func(ctx tool.Context, args WeatherArgs) (map[string]string, error) {
+				calls = append(calls, "get_weather")
+				return map[string]string{"temp": "real " + args.City}, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			clock, err := functiontool.New(functiontool.Config{
+				Name:        "get_time",
+				Description: "returns the time",
+			}, func(ctx tool.Context, args TimeArgs) (map[string]string, error) {
+				calls = append(calls, "get_time")
+				return map[string]string{"time": "real " + args.Zone}, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := llmagent.New(llmagent.Config{
+				Name: "test_agent",
+				Model: &testutil.MockModel{Responses: []*genai.Content{
+					{Role: genai.RoleModel, Parts: []*genai.Part{
+						genai.NewPartFromFunctionCall("get_weather", map[string]any{"city": "Paris"}),
+						genai.NewPartFromFunctionCall("get_time", map[string]any{"zone": "UTC"}),
+					}},
+					genai.NewContentFromText("done", genai.RoleModel),
+				}},
+				Tools: []tool.Tool{weather, clock},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			stubs, err := toolstub.New(tc.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := testutil.NewTestAgentRunner(t, a)
+			parts, err := testutil.CollectParts(r.RunContentWithConfig(t, "s1", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{ToolStub: stubs.Stub}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]map[string]any)
+			for _, p := range parts {
+				if p.FunctionResponse != nil {
+					got[p.FunctionResponse.Name] = p.FunctionResponse.Response
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("function responses mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantCalls, calls); diff != "" {
+				t.Errorf("real tool calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStubsInOrder(t *testing.T) {
+	stubs, err := toolstub.New(toolstub.Config{Stubs: []toolstub.Stub{
+		{Tool: "roll", Args: map[string]any{}, Response: map[string]any{"n": 1}},
+		{Tool: "roll", Args: map[string]any{}, Response: map[string]any{"n": 2}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []any
+	for range 3 {
+		resp, ok := stubs.Stub("roll", map[string]any{})
+		if !ok {
+			t.Fatal("Stub() reported no stub")
+		}
+		got = append(got, resp["n"])
+	}
+	if diff := cmp.Diff([]any{1, 2, 2}, got); diff != "" {
+		t.Errorf("Stub() responses mismatch (-want +got):\n%s", diff)
+	}
+	if _, ok := stubs.Stub("other", nil); ok {
+		t.Errorf("Stub() of an unknown tool reported a stub")
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	if _, err := toolstub.New(toolstub.Config{Stubs: []toolstub.Stub{{Response: map[string]any{}}}}); err == nil {
+		t.Errorf("New() with a stub without tool name succeeded, want error")
+	}
+}
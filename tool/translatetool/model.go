@@ -0,0 +1,89 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package translatetool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// NewModelTranslator returns a translator asking llm for the translations.
+func NewModelTranslator(llm model.LLM) Translator {
+	return modelTranslator{llm: llm}
+}
+
+type modelTranslator struct {
+	llm model.LLM
+}
+
+// modelAnswer is the JSON answer requested from the model.
+type modelAnswer struct {
+	Translation    string `json:"translation"`
+	SourceLanguage string `json:"source_language"`
+}
+
+const translatePrompt = `Translate the text of the user to %s. Answer only with a JSON object with the fields "translation", the translated text, and "source_language", the ISO 639-1 code of the language of the original text.`
+
+func (t modelTranslator) Translate(ctx context.Context, text, targetLanguage string) (Translation, error) {
+	req := &model.LLMRequest{
+		Model:    t.llm.Name(),
+		Contents: []*genai.Content{genai.NewContentFromText(text, genai.RoleUser)},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText(fmt.Sprintf(translatePrompt, targetLanguage), genai.RoleUser),
+			ResponseMIMEType:  "application/json",
+		},
+	}
+	var answer strings.Builder
+	for resp, err := range t.llm.GenerateContent(ctx, req, false) {
+		if err != nil {
+			return Translation{}, err
+		}
+		if resp.Content == nil {
+			continue
+		}
+		for _, p := range resp.Content.Parts {
+			if p != nil && !p.Thought {
+				answer.WriteString(p.Text)
+			}
+		}
+	}
+	var parsed modelAnswer
+	if err := json.Unmarshal([]byte(trimCodeFence(answer.String())), &parsed); err != nil {
+		return Translation{}, fmt.Errorf("invalid answer of model %q: %w", t.llm.Name(), err)
+	}
+	if parsed.Translation == "" {
+		return Translation{}, errors.New("the model answered with an empty translation")
+	}
+	return Translation{Text: parsed.Translation, SourceLanguage: parsed.SourceLanguage}, nil
+}
+
+// trimCodeFence returns s without the markdown code fence that some models
+// wrap JSON answers in.
+func trimCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	s = strings.TrimPrefix(s, "json")
+	return strings.TrimSpace(strings.TrimSuffix(s, "```"))
+}
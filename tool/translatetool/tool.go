@@ -0,0 +1,219 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package translatetool provides a tool translating text to a target
+// language through a pluggable [Translator], by default a model.
+//
+// Translations are cached by text and target language, so that the same
+// text is translated once, e.g. a greeting repeated to each user. Only the
+// target language is recorded on the spans of a call; the text never is.
+package translatetool
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// Defaults used for unset Config fields.
+const (
+	DefaultName      = "translate"
+	DefaultCacheSize = 256
+)
+
+// Translation is a translated text.
+type Translation struct {
+	// Text is the translation.
+	Text string
+	// SourceLanguage is the detected language of the original text, e.g.
+	// "fr", or empty if the translator does not detect it.
+	SourceLanguage string
+}
+
+// Translator translates texts, e.g. with a translation service.
+type Translator interface {
+	// Translate translates text to the target language, e.g. "en" or
+	// "German".
+	Translate(ctx context.Context, text, targetLanguage string) (Translation, error)
+}
+
+// Config is the configuration of a translation tool.
+type Config struct {
+	// Name is the name of the tool. Defaults to DefaultName.
+	Name string
+	// Description tells the model what the translations are for. Defaults
+	// to a generic description.
+	Description string
+	// Translator translates the texts. Defaults to a translator asking
+	// Model, see NewModelTranslator.
+	Translator Translator
+	// Model translates the texts if Translator is not set.
+	Model model.LLM
+	// CacheSize is the number of translations kept in the cache, the least
+	// recently used being evicted first. Defaults to DefaultCacheSize; a
+	// negative value disables the cache.
+	CacheSize int
+}
+
+// Args are the arguments of a call of a translation tool.
+type Args struct {
+	// Text is the text to translate.
+	Text string `json:"text" jsonschema:"the text to translate"`
+	// TargetLanguage is the language to translate the text to.
+	TargetLanguage string `json:"target_language" jsonschema:"the language to translate the text to, e.g. en or German"`
+}
+
+// Result is the response of a translation tool.
+type Result struct {
+	// Translation is the translated text.
+	Translation string `json:"translation"`
+	// SourceLanguage is the detected language of the original text, if
+	// known.
+	SourceLanguage string `json:"source_language,omitempty"`
+	// TargetLanguage is the language of the translation.
+	TargetLanguage string `json:"target_language"`
+}
+
+// New creates a tool translating texts with cfg.Translator.
+func New(cfg Config) (tool.Tool, error) {
+	tr, err := newTranslator(cfg)
+	if err != nil {
+		return nil, err
+	}
+	translateTool, err := functiontool.New(functiontool.Config{
+		Name:        tr.cfg.Name,
+		Description: tr.cfg.Description,
+	}, func(ctx tool.Context, args Args) (Result, error) {
+		return tr.translate(ctx, args)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s tool: %w", tr.cfg.Name, err)
+	}
+	return translateTool, nil
+}
+
+type translator struct {
+	cfg   Config
+	cache *cache
+}
+
+func newTranslator(cfg Config) (*translator, error) {
+	if cfg.Translator == nil {
+		if cfg.Model == nil {
+			return nil, errors.New("Translator or Model is required")
+		}
+		cfg.Translator = NewModelTranslator(cfg.Model)
+	}
+	if cfg.Name == "" {
+		cfg.Name = DefaultName
+	}
+	if cfg.Description == "" {
+		cfg.Description = "Translates a text to the given language and detects the language of the text."
+	}
+	if cfg.CacheSize == 0 {
+		cfg.CacheSize = DefaultCacheSize
+	}
+	tr := &translator{cfg: cfg}
+	if cfg.CacheSize > 0 {
+		tr.cache = newCache(cfg.CacheSize)
+	}
+	return tr, nil
+}
+
+func (tr *translator) translate(ctx tool.Context, args Args) (Result, error) {
+	target := strings.TrimSpace(args.TargetLanguage)
+	if args.Text == "" || target == "" {
+		return Result{}, tool.NewError(tool.ErrorCodeInvalidArgument, errors.New("text and target_language are required"))
+	}
+	key := cacheKey{text: args.Text, target: strings.ToLower(target)}
+	translation, cached := tr.cache.get(key)
+	telemetry.SetTranslation(telemetry.SpansFromContext(ctx), target, cached)
+	if !cached {
+		var err error
+		translation, err = tr.cfg.Translator.Translate(ctx, args.Text, target)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to translate to %q: %w", target, err)
+		}
+		tr.cache.add(key, translation)
+	}
+	return Result{
+		Translation:    translation.Text,
+		SourceLanguage: translation.SourceLanguage,
+		TargetLanguage: target,
+	}, nil
+}
+
+type cacheKey struct {
+	text, target string
+}
+
+type cacheEntry struct {
+	key         cacheKey
+	translation Translation
+}
+
+// cache is a least recently used cache of translations. A nil cache caches
+// nothing.
+type cache struct {
+	size int
+
+	mu      sync.Mutex
+	order   *list.List // of *cacheEntry, most recently used first
+	entries map[cacheKey]*list.Element
+}
+
+func newCache(size int) *cache {
+	return &cache{size: size, order: list.New(), entries: make(map[cacheKey]*list.Element)}
+}
+
+func (c *cache) get(key cacheKey) (Translation, bool) {
+	if c == nil {
+		return Translation{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return Translation{}, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cacheEntry).translation, true
+}
+
+func (c *cache) add(key cacheKey, translation Translation) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*cacheEntry).translation = translation
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, translation: translation})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package translatetool
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genai"
+
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+)
+
+// fakeTranslator upper-cases the texts and records the translated texts.
+type fakeTranslator struct {
+	calls []string
+	err   error
+}
+
+func (t *fakeTranslator) Translate(ctx context.Context, text, targetLanguage string) (Translation, error) {
+	if t.err != nil {
+		return Translation{}, t.err
+	}
+	t.calls = append(t.calls, text+"/"+targetLanguage)
+	return Translation{Text: strings.ToUpper(text), SourceLanguage: "fr"}, nil
+}
+
+func toolContext(ctx context.Context) tool.Context {
+	return toolinternal.NewToolContext(icontext.NewInvocationContext(ctx, icontext.InvocationContextParams{}), "fc1", &session.EventActions{})
+}
+
+func TestTranslate(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		cacheSize int
+		args      []Args
+		want      []Result
+		wantCalls []string
+	}{
+		{
+			name: "identical pairs are cached",
+			args: []Args{
+				{Text: "bonjour", TargetLanguage: "en"},
+				{Text: "bonjour", TargetLanguage: " EN "},
+				{Text: "bonjour", TargetLanguage: "de"},
+			},
+			want: []Result{
+				{Translation: "BONJOUR", SourceLanguage: "fr", TargetLanguage: "en"},
+				{Translation: "BONJOUR", SourceLanguage: "fr", TargetLanguage: "EN"},
+				{Translation: "BONJOUR", SourceLanguage: "fr", TargetLanguage: "de"},
+			},
+			wantCalls: []string{"bonjour/en", "bonjour/de"},
+		},
+		{
+			name:      "cache disabled",
+			cacheSize: -1,
+			args: []Args{
+				{Text: "salut", TargetLanguage: "en"},
+				{Text: "salut", TargetLanguage: "en"},
+			},
+			want: []Result{
+				{Translation: "SALUT", SourceLanguage: "fr", TargetLanguage: "en"},
+				{Translation: "SALUT", SourceLanguage: "fr", TargetLanguage: "en"},
+			},
+			wantCalls: []string{"salut/en", "salut/en"},
+		},
+		{
+			name:      "least recently used is evicted",
+			cacheSize: 2,
+			args: []Args{
+				{Text: "un", TargetLanguage: "en"},
+				{Text: "deux", TargetLanguage: "en"},
+				{Text: "un", TargetLanguage: "en"},
+				{Text: "trois", TargetLanguage: "en"},
+				{Text: "un", TargetLanguage: "en"},
+				{Text: "deux", TargetLanguage: "en"},
+			},
+			want: []Result{
+				{Translation: "UN", SourceLanguage: "fr", TargetLanguage: "en"},
+				{Translation: "DEUX", SourceLanguage: "fr", TargetLanguage: "en"},
+				{Translation: "UN", SourceLanguage: "fr", TargetLanguage: "en"},
+				{Translation: "TROIS", SourceLanguage: "fr", TargetLanguage: "en"},
+				{Translation: "UN", SourceLanguage: "fr", TargetLanguage: "en"},
+				{Translation: "DEUX", SourceLanguage: "fr", TargetLanguage: "en"},
+			},
+			wantCalls: []string{"un/en", "deux/en", "trois/en", "deux/en"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &fakeTranslator{}
+			tr, err := newTranslator(Config{Translator: fake, CacheSize: tc.cacheSize})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []Result
+			for _, args := range tc.args {
+				res, err := tr.translate(toolContext(t.Context()), args)
+				if err != nil {
+					t.Fatalf("translate(%v) failed: %v", args, err)
+				}
+				got = append(got, res)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("results mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantCalls, fake.calls); diff != "" {
+				t.Errorf("translator calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTranslateErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		err      error
+		args     Args
+		wantCode string
+	}{
+		{
+			name:     "missing text",
+			args:     Args{TargetLanguage: "en"},
+			wantCode: tool.ErrorCodeInvalidArgument,
+		},
+		{
+			name:     "missing target language",
+			args:     Args{Text: "bonjour", TargetLanguage: " "},
+			wantCode: tool.ErrorCodeInvalidArgument,
+		},
+		{
+			name: "translator failure",
+			err:  errors.New("quota exceeded"),
+			args: Args{Text: "bonjour", TargetLanguage: "en"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &fakeTranslator{err: tc.err}
+			tr, err := newTranslator(Config{Translator: fake})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = tr.translate(toolContext(t.Context()), tc.args)
+			if err == nil {
+				t.Fatal("translate() succeeded, want error")
+			}
+			if tc.err != nil && !errors.Is(err, tc.err) {
+				t.Errorf("translate() error = %v, want %v", err, tc.err)
+			}
+			var toolErr *tool.Error
+			if tc.wantCode != "" && (!errors.As(err, &toolErr) || toolErr.Code != tc.wantCode) {
+				t.Errorf("translate() error = %v, want code %s", err, tc.wantCode)
+			}
+			// Failures are not cached.
+			if tc.err != nil {
+				fake.err = nil
+				if _, err := tr.translate(toolContext(t.Context()), tc.args); err != nil {
+					t.Errorf("translate() after recovery failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestTranslateSpanAttributes(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	tr, err := newTranslator(Config{Translator: &fakeTranslator{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		_, span := tp.Tracer("test").Start(t.Context(), "execute_tool")
+		ctx := telemetry.ContextWithSpan(t.Context(), []trace.Span{span})
+		if _, err := tr.translate(toolContext(ctx), Args{Text: "secret", TargetLanguage: "en"}); err != nil {
+			t.Fatal(err)
+		}
+		span.End()
+	}
+
+	var got []map[string]any
+	for _, s := range recorder.Ended() {
+		attrs := make(map[string]any)
+		for _, kv := range s.Attributes() {
+			attrs[string(kv.Key)] = kv.Value.AsInterface()
+			if strings.Contains(kv.Value.Emit(), "secret") {
+				t.Errorf("attribute %s records the text", kv.Key)
+			}
+		}
+		got = append(got, attrs)
+	}
+	want := []map[string]any{
+		{"gcp.vertex.agent.translation_target_language": "en", "gcp.vertex.agent.translation_cached": false},
+		{"gcp.vertex.agent.translation_target_language": "en", "gcp.vertex.agent.translation_cached": true},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("span attributes mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModelTranslator(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		answer  string
+		want    Translation
+		wantErr bool
+	}{
+		{
+			name:   "json",
+			answer: `{"translation": "hello", "source_language": "fr"}`,
+			want:   Translation{Text: "hello", SourceLanguage: "fr"},
+		},
+		{
+			name:   "code fence",
+			answer: "```json\n{\"translation\": \"hello\", \"source_language\": \"fr\"}\n```",
+			want:   Translation{Text: "hello", SourceLanguage: "fr"},
+		},
+		{
+			name:    "not json",
+			answer:  "hello",
+			wantErr: true,
+		},
+		{
+			name:    "empty translation",
+			answer:  `{"source_language": "fr"}`,
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			llm := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText(tc.answer, genai.RoleModel)}}
+			got, err := NewModelTranslator(llm).Translate(t.Context(), "bonjour", "English")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Translate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Translate() mismatch (-want +got):\n%s", diff)
+			}
+			if len(llm.Requests) != 1 {
+				t.Fatalf("model got %d requests, want 1", len(llm.Requests))
+			}
+			if instruction := llm.Requests[0].Config.SystemInstruction.Parts[0].Text; !strings.Contains(instruction, "English") {
+				t.Errorf("system instruction %q does not name the target language", instruction)
+			}
+		})
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Errorf("New() without Translator nor Model succeeded, want error")
+	}
+}